@@ -148,7 +148,9 @@ local MAG = "\027[38;2;179;108;248m"
 local DIM = "\027[2m"
 local BLD = "\027[1m"
 
-local BIT_REPO_RAW = "https://raw.githubusercontent.com/bit-io/repository/main/bit-repo/repo-list.json"
+-- BIT_REPO — własna lista pakietów (mirror: file://…, serwer w sieci lokalnej: http://…)
+local BIT_REPO_RAW = os.getenv("BIT_REPO")
+    or "https://raw.githubusercontent.com/bit-io/repository/main/bit-repo/repo-list.json"
 local BIT_REPO_URL = "https://github.com/bit-io/repository"
 
 -- ── Resolve paths z env + config.hk ──────────────────────────────────────────
@@ -160,8 +162,8 @@ local BIT_LOCK_FILE = os.getenv("BIT_LOCK_FILE") or (HOME.."/.hackeros/hacker-la
 local BIT_REPO_FILE = BIT_CACHE_DIR.."/repo-list.json"
 
 local function resolve_paths()
-    local cfg = HOME.."/.config/hackeros/hacker-lang/config.hk"
-    local f = io.open(cfg, "r")
+    local xdg = os.getenv("XDG_CONFIG_HOME") or (HOME.."/.config")
+    local f = io.open(xdg.."/hacker-lang/config.hk", "r") or io.open(HOME.."/.config/hackeros/hacker-lang/config.hk", "r")
     if not f then return end
     for line in f:lines() do
         local p = line:match("^%->%s*active_path%s*=>%s*(.-)%s*$")
@@ -333,6 +335,29 @@ local function load_repo()
     return read_json(BIT_REPO_FILE) or {}
 end
 
+-- ── Status pakietu ────────────────────────────────────────────────────────────
+-- "deprecated" / "yanked" (true lub powód) + "replacement" w repo-list.json
+local function flag_text(v, default)
+    if v==nil or v==false then return nil end
+    if v==true then return default end
+    return tostring(v)
+end
+
+local function repo_status(info)
+    info = info or {}
+    return flag_text(info.deprecated, "przestarzały"), flag_text(info.yanked, "wycofany"), info.replacement
+end
+
+local function repo_status_warn(pkg, info)
+    local dep, yank, repl = repo_status(info)
+    if yank then red("  "..pkg.." jest wycofany (yanked): "..yank) end
+    if dep  then yellow("  "..pkg.." jest przestarzały: "..dep) end
+    if (dep or yank) and repl and repl~="" then
+        print("    "..DIM.."Zamiennik: "..RST..GRN.."bit install "..repl..RST)
+    end
+    return yank~=nil
+end
+
 -- ── Set current symlink ───────────────────────────────────────────────────────
 local function set_current(pkg, commit)
     local link = BIT_HOME.."/"..pkg.."/current"
@@ -341,28 +366,37 @@ local function set_current(pkg, commit)
 end
 
 -- ── Install ───────────────────────────────────────────────────────────────────
+-- Płytki klon w cache zostaje między aktualizacjami — upgrade pobiera tylko
+-- różnicę (git fetch --depth=1); przy błędzie pełne klonowanie od nowa.
 local function _do_install(pkg, url, silent)
     bit_init_dirs()
-    local tmp = BIT_CACHE_DIR.."/_tmp_"..pkg
-    os.execute("rm -rf '"..tmp.."'")
-    if not silent then print("  "..DIM.."Klonowanie: "..url..RST) end
-    local ok2 = run("git clone --depth=1 '"..url.."' '"..tmp.."'"
-                    ..(silent and " >/dev/null 2>&1" or ""))
-    if ok2 ~= 0 and ok2 ~= true then
-        if not silent then red("Błąd klonowania: "..url) end
-        os.execute("rm -rf '"..tmp.."'"); return false
+    local tmp = BIT_CACHE_DIR.."/git/"..pkg
+    local fetched = false
+    if run_ok("test -d '"..tmp.."/.git'") then
+        if not silent then print("  "..DIM.."Pobieranie zmian: "..url..RST) end
+        fetched = run_ok("git -C '"..tmp.."' fetch -q --depth=1 origin HEAD")
+              and run_ok("git -C '"..tmp.."' reset -q --hard FETCH_HEAD")
+    end
+    if not fetched then
+        os.execute("rm -rf '"..tmp.."'")
+        os.execute("mkdir -p '"..BIT_CACHE_DIR.."/git'")
+        if not silent then print("  "..DIM.."Klonowanie: "..url..RST) end
+        local ok2 = run("git clone -q --depth=1 '"..url.."' '"..tmp.."'"
+                        ..(silent and " >/dev/null 2>&1" or ""))
+        if ok2 ~= 0 and ok2 ~= true then
+            if not silent then red("Błąd klonowania: "..url) end
+            os.execute("rm -rf '"..tmp.."'"); return false
+        end
     end
     local commit      = get_commit(tmp)
     local commit_date = get_commit_date(tmp)
     local dest        = BIT_HOME.."/"..pkg.."/"..commit
     if run_ok("test -d '"..dest.."'") then
         if not silent then yellow("  Wersja "..commit.." już zainstalowana.") end
-        os.execute("rm -rf '"..tmp.."'")
         set_current(pkg, commit); return true
     end
     os.execute("mkdir -p '"..dest.."'")
-    os.execute("cp -r '"..tmp.."/.' '"..dest.."/'")
-    os.execute("rm -rf '"..tmp.."'")
+    os.execute("tar -C '"..tmp.."' --exclude=.git -cf - . | tar -C '"..dest.."' -xf -")
     if not silent then
         io.write("  "..DIM.."Obliczanie checksum..."..RST); io.flush()
     end
@@ -387,10 +421,13 @@ local function _install_silent(pkg)
     local url  = info.url or ""
     if url=="" then yellow("  Pakiet '"..pkg.."' nie w repo — pomijam."); return end
     if lock_has(pkg) and resolve_current(pkg) then return end
+    if select(2, repo_status(info)) then
+        red("  Pakiet '"..pkg.."' został wycofany — pomijam (bit install "..pkg.." --allow-yanked)."); return
+    end
     _do_install(pkg, url, true)
 end
 
-local function bit_install(pkg)
+local function bit_install(pkg, allow_yanked)
     if not pkg or pkg=="" then red("Podaj nazwę pakietu: bit install <nazwa>"); os.exit(1) end
     bit_ensure_repo()
     local repo = load_repo()
@@ -403,6 +440,10 @@ local function bit_install(pkg)
         pb_done(); red("Pakiet '"..pkg.."' nie znaleziony w repozytorium.")
         print("  Lista: "..CYN.."bit search all"..RST); os.exit(1)
     end
+    pb_done()
+    if repo_status_warn(pkg, info) and not allow_yanked then
+        red("Pakiet '"..pkg.."' został wycofany — instalacja wymaga --allow-yanked."); os.exit(1)
+    end
     pb_draw(30)
     if not _do_install(pkg, url, false) then
         pb_done(); red("Instalacja '"..pkg.."' nie powiodła się."); os.exit(1)
@@ -429,11 +470,72 @@ local function bit_remove(pkg)
         red("Pakiet '"..pkg.."' nie jest zainstalowany."); os.exit(1)
     end
     print(YEL.."Usuwanie:"..RST.." "..pkg)
-    pb_draw(30); os.execute("rm -rf '"..pkg_dir.."'"); pb_draw(80)
+    pb_draw(30); os.execute("rm -rf '"..pkg_dir.."' '"..BIT_CACHE_DIR.."/git/"..pkg.."'"); pb_draw(80)
     lock_del(pkg)
     pb_draw(100); pb_done(); green("Pakiet '"..pkg.."' usunięty.")
 end
 
+-- ── Link ──────────────────────────────────────────────────────────────────────
+-- BIT_HOME/<nazwa>/current → kopia robocza biblioteki; poprzedni cel w .bit-link-prev
+local function link_name(pkg)
+    if pkg and pkg~="" then return pkg end
+    local f = io.open("bit.hk", "r")
+    if f then
+        local in_proj = false
+        for line in f:lines() do
+            if line:match("^%[project%]") then in_proj=true
+            elseif line:match("^%[") then in_proj=false
+            elseif in_proj then
+                local v = line:match("^%s*%->%s*name%s*=>%s*(%S+)")
+                if v then f:close(); return v end
+            end
+        end
+        f:close()
+    end
+    return run_out("basename \"$(pwd)\"")
+end
+
+local function write_file(path, s)
+    local f = io.open(path, "w")
+    if f then f:write(s); f:close() end
+end
+
+local function bit_link(pkg)
+    local name = link_name(pkg)
+    bit_init_dirs()
+    local src = run_out("pwd")
+    local dir = BIT_HOME.."/"..name
+    os.execute("mkdir -p '"..dir.."'")
+    if not run_ok("test -e '"..dir.."/.bit-link'") then
+        local prev = run_out("readlink '"..dir.."/current'")
+        if prev~="" then write_file(dir.."/.bit-link-prev", prev.."\n") end
+    end
+    os.execute("rm -f '"..dir.."/current'")
+    os.execute("ln -s '"..src.."' '"..dir.."/current'")
+    write_file(dir.."/.bit-link", src.."\n")
+    green("Podlinkowano "..name.." → "..src)
+    print("  Projekty z "..CYN.."# <bit/"..name..">"..RST.." używają teraz kopii roboczej. Powrót: "
+        ..GRN.."bit unlink "..name..RST)
+end
+
+local function bit_unlink(pkg)
+    local name = link_name(pkg)
+    local dir  = BIT_HOME.."/"..name
+    if not run_ok("test -e '"..dir.."/.bit-link'") then
+        red("Pakiet '"..name.."' nie jest podlinkowany."); os.exit(1)
+    end
+    os.execute("rm -f '"..dir.."/current' '"..dir.."/.bit-link'")
+    local prev = run_out("cat '"..dir.."/.bit-link-prev'")
+    os.execute("rm -f '"..dir.."/.bit-link-prev'")
+    if prev~="" and run_ok("test -d '"..dir.."/"..prev.."'") then
+        os.execute("ln -s '"..prev.."' '"..dir.."/current'")
+        green("Odlinkowano "..name.." — przywrócono wersję "..prev)
+    else
+        run_ok("rmdir '"..dir.."'")
+        green("Odlinkowano "..name..".")
+    end
+end
+
 -- ── List ──────────────────────────────────────────────────────────────────────
 local function bit_list()
     local repo = load_repo()
@@ -468,6 +570,84 @@ local function bit_update()
     bit_fetch_repo()
 end
 
+-- ── Pin ───────────────────────────────────────────────────────────────────────
+-- Lokalnie: BIT_META_DIR/bit.pins (nazwa na linię); w projekcie: sekcja [pins] w bit.hk
+local function pins_file() return BIT_META_DIR.."/bit.pins" end
+
+local function read_lines(path)
+    local out = {}
+    local f = io.open(path, "r")
+    if not f then return out end
+    for line in f:lines() do
+        local v = line:match("^%s*(.-)%s*$")
+        if v~="" then out[#out+1]=v end
+    end
+    f:close()
+    return out
+end
+
+local function project_pins()
+    local out = {}
+    local f = io.open("bit.hk", "r")
+    if not f then return out end
+    local in_pins = false
+    for line in f:lines() do
+        if line:match("^%[pins%]") then in_pins=true
+        elseif line:match("^%[") then in_pins=false
+        elseif in_pins then
+            local name = line:match("^%s*%->%s*(%S+)")
+            if name then out[#out+1]=name end
+        end
+    end
+    f:close()
+    return out
+end
+
+local function pin_has(pkg)
+    for _, p in ipairs(read_lines(pins_file())) do if p==pkg then return true end end
+    for _, p in ipairs(project_pins())         do if p==pkg then return true end end
+    return false
+end
+
+local function bit_pin_list()
+    hr(44); print(CYN.."Przypięte pakiety:"..RST); hr(44)
+    local any = false
+    for _, p in ipairs(read_lines(pins_file())) do
+        print("  "..YEL..p..RST.."  "..DIM.."(lokalnie)"..RST); any=true
+    end
+    for _, p in ipairs(project_pins()) do
+        print("  "..YEL..p..RST.."  "..DIM.."(bit.hk)"..RST); any=true
+    end
+    if not any then pr("Brak przypiętych pakietów.") end
+end
+
+local function bit_pin(pkg)
+    bit_init_dirs()
+    for _, p in ipairs(read_lines(pins_file())) do
+        if p==pkg then yellow("Pakiet '"..pkg.."' jest już przypięty."); return end
+    end
+    local f = io.open(pins_file(), "a")
+    if not f then red("Nie można zapisać "..pins_file()); os.exit(1) end
+    f:write(pkg.."\n"); f:close()
+    green("Przypięto '"..pkg.."' — bit upgrade go pominie.")
+end
+
+local function bit_unpin(pkg)
+    local pins, kept, found = read_lines(pins_file()), {}, false
+    for _, p in ipairs(pins) do
+        if p==pkg then found=true else kept[#kept+1]=p end
+    end
+    if not found then
+        yellow("Pakiet '"..pkg.."' nie jest przypięty lokalnie (przypięcia z bit.hk usuwa się w pliku).")
+        return
+    end
+    local f = io.open(pins_file(), "w")
+    if not f then red("Nie można zapisać "..pins_file()); os.exit(1) end
+    for _, p in ipairs(kept) do f:write(p.."\n") end
+    f:close()
+    green("Odpięto '"..pkg.."'.")
+end
+
 -- ── Upgrade ───────────────────────────────────────────────────────────────────
 local function bit_upgrade(pkg)
     local lock = load_lock()
@@ -477,9 +657,15 @@ local function bit_upgrade(pkg)
     local repo    = load_repo()
     local targets = {}
     if pkg and pkg~="" then
+        -- Jawne żądanie wygrywa z przypięciem
+        if pin_has(pkg) then yellow("Pakiet '"..pkg.."' jest przypięty — aktualizuję na jawne żądanie.") end
         targets = {pkg}
     else
-        for k in pairs(lock) do targets[#targets+1]=k end; table.sort(targets)
+        for k in pairs(lock) do
+            if pin_has(k) then yellow("Pominięto "..k.." (przypięty)")
+            else targets[#targets+1]=k end
+        end
+        table.sort(targets)
     end
     for _, name in ipairs(targets) do
         local info = repo[name] or lock[name] or {}
@@ -492,6 +678,54 @@ local function bit_upgrade(pkg)
     end
 end
 
+-- ── Mirror ────────────────────────────────────────────────────────────────────
+-- Katalog: repo-list.json + pkgs/<nazwa>.git (bare, gotowe do serwowania statycznym HTTP).
+-- URL-e w repo-list.json wskazują na BIT_MIRROR_URL (domyślnie file://<katalog>).
+local function bit_mirror(dir, only)
+    if not dir or dir=="" then red("Podaj katalog: bit mirror <katalog> [pakiet ...]"); os.exit(1) end
+    bit_fetch_repo()
+    local repo = read_json(BIT_REPO_FILE) or {}
+    os.execute("mkdir -p '"..dir.."/pkgs'")
+    local abs  = run_out("realpath '"..dir.."'")
+    local base = os.getenv("BIT_MIRROR_URL") or ("file://"..abs)
+    local out  = read_json(abs.."/repo-list.json") or {}
+    local names = {}
+    if #only>0 then names = only
+    else for k in pairs(repo) do names[#names+1]=k end; table.sort(names) end
+    hr(50); print(CYN.."Mirror repozytorium bit → "..RST..abs)
+    print(DIM.."Adres pakietów: "..base.."/pkgs"..RST); hr(50)
+    local ok_c, fail_c = 0, 0
+    for _, name in ipairs(names) do
+        local info = repo[name]
+        if not info or (info.url or "")=="" then
+            yellow("  "..name.." — nie ma w repo, pomijam.")
+        else
+            local dest = abs.."/pkgs/"..name..".git"
+            local fetched
+            if run_ok("test -d '"..dest.."'") then
+                fetched = run_ok("git -C '"..dest.."' fetch -q --prune")
+            else
+                fetched = run_ok("git clone -q --mirror '"..info.url.."' '"..dest.."'")
+            end
+            if fetched then
+                run_ok("git -C '"..dest.."' update-server-info")
+                local entry = {}
+                for k, v in pairs(info) do entry[k]=v end
+                entry.url = base.."/pkgs/"..name..".git"
+                out[name] = entry
+                print("  "..GRN.."✓"..RST.." "..name); ok_c=ok_c+1
+            else
+                print("  "..RED.."✗"..RST.." "..name.." "..DIM.."— błąd pobierania "..info.url..RST); fail_c=fail_c+1
+            end
+        end
+    end
+    write_json(abs.."/repo-list.json", out)
+    hr(50); print("OK: "..GRN..ok_c..RST.."  Błędy: "..RED..fail_c..RST)
+    pr("Serwuj katalog dowolnym serwerem HTTP, a na maszynach docelowych ustaw:")
+    print("  "..CYN.."BIT_REPO="..base.."/repo-list.json"..RST)
+    if fail_c>0 then os.exit(1) end
+end
+
 -- ── Info ──────────────────────────────────────────────────────────────────────
 local function bit_info(pkg)
     if not pkg or pkg=="" then red("Podaj nazwę pakietu: bit info <nazwa>"); os.exit(1) end
@@ -503,6 +737,7 @@ local function bit_info(pkg)
     else
         print("  "..DIM.."URL:  "..(info.url or "?")..RST)
         print("  "..DIM.."Typ:  "..(info.type or "?")..RST)
+        repo_status_warn(pkg, info)
     end
     local entry = lock[pkg]
     if entry then
@@ -602,6 +837,7 @@ local function bit_workspace()
         pr("  [project]");  pr("  -> name    => MojProjekt")
         pr("  -> version => 1.0.0"); pr("  -> entry   => source-code/main.hl")
         pr("  -> type    => hl");   pr(); pr("  [dependencies]"); pr("  -> tui")
+        pr(); pr("  [pins]"); pr("  -> tui")
     end
     pr()
     local lock = load_lock()
@@ -738,6 +974,7 @@ local function bit_run()
                 local dep = line:match("^%s*%->%s*(.-)%s*$")
                 if dep and dep~="" then
                     dep = dep:match("^(%S+)")
+                    if dep then repo_status_warn(dep, load_repo()[dep]) end
                     if dep and not lock_has(dep) then
                         print("  "..DIM.."instaluje: "..dep..RST)
                         _install_silent(dep)
@@ -762,9 +999,12 @@ local function bit_help()
     bold("Uruchamianie projektu:")
     print("  "..GRN.."bit run"..RST.."                    — znajdź + check + zależności + uruchom")
     pr(); bold("Manager pakietów:")
-    print("  "..GRN.."bit install "..CYN.."<nazwa> ..."..RST.."      — zainstaluj pakiet(y)")
+    print("  "..GRN.."bit install "..CYN.."<nazwa> ..."..RST.."      — zainstaluj pakiet(y) (wycofane: --allow-yanked)")
     print("  "..GRN.."bit remove  "..CYN.."<nazwa> ..."..RST.."      — usuń pakiet(y)")
-    print("  "..GRN.."bit upgrade "..CYN.."[nazwa]"..RST.."         — upgrade pakietu (lub wszystkich)")
+    print("  "..GRN.."bit upgrade "..CYN.."[nazwa]"..RST.."         — upgrade pakietu (lub wszystkich poza przypiętymi)")
+    print("  "..GRN.."bit pin     "..CYN.."<nazwa> ..."..RST.."      — wstrzymaj upgrade pakietu(ów)")
+    print("  "..GRN.."bit pin     "..CYN.."[--list]"..RST.."        — przypięte pakiety (lokalne i z bit.hk)")
+    print("  "..GRN.."bit unpin   "..CYN.."<nazwa> ..."..RST.."      — zdejmij przypięcie")
     print("  "..GRN.."bit verify  "..CYN.."[nazwa]"..RST.."         — weryfikuj checksum")
     print("  "..GRN.."bit list"..RST.."                   — lista pakietów z repo")
     print("  "..GRN.."bit installed"..RST.."              — zainstalowane biblioteki")
@@ -773,12 +1013,17 @@ local function bit_help()
     print("  "..GRN.."bit update"..RST.."                 — aktualizuj listę repo")
     print("  "..GRN.."bit info    "..CYN.."<nazwa>"..RST.."         — info + checksum o pakiecie")
     print("  "..GRN.."bit clean"..RST.."                  — usuń stare wersje i cache")
+    print("  "..GRN.."bit mirror  "..CYN.."<kat> [nazwa]"..RST.."   — kopia repo do katalogu (sieć bez internetu)")
     pr(); bold("Projekt:")
     print("  "..GRN.."bit workspace"..RST.."              — info o projekcie i zainstalowanych libs")
+    print("  "..GRN.."bit link    "..CYN.."[nazwa]"..RST.."         — kopia robocza biblioteki jako aktywna wersja")
+    print("  "..GRN.."bit unlink  "..CYN.."[nazwa]"..RST.."         — przywróć zainstalowaną wersję")
     pr(); bold("Lokalizacje:")
     print("  "..DIM.."Libs:   "..BIT_HOME..RST)
     print("  "..DIM.."Meta:   "..BIT_META_DIR..RST)
     print("  "..DIM.."Lock:   "..BIT_LOCK_FILE..RST)
+    pr(); bold("Zmienne:")
+    print("  "..DIM.."BIT_REPO=<url>   — własna lista pakietów (file://, http:// w sieci lokalnej)"..RST)
     pr(); print("Repozytorium: "..BIT_REPO_URL)
 end
 
@@ -792,8 +1037,8 @@ local pkgs = {}
 for i=2,#arg do pkgs[#pkgs+1]=arg[i] end
 local pkg = pkgs[1] or ""
 
-local KNOWN = {run=1,install=1,remove=1,upgrade=1,verify=1,list=1,
-               installed=1,search=1,update=1,info=1,clean=1,workspace=1,help=1}
+local KNOWN = {run=1,install=1,remove=1,upgrade=1,pin=1,unpin=1,verify=1,list=1,
+               installed=1,search=1,update=1,info=1,clean=1,mirror=1,workspace=1,link=1,unlink=1,help=1}
 
 if cmd=="" then bit_help(); os.exit(0) end
 
@@ -806,8 +1051,12 @@ if not KNOWN[cmd] then
 end
 
 if cmd=="install" then
-    if #pkgs==0 then red("Podaj nazwę pakietu: bit install <nazwa> [nazwa2 ...]"); os.exit(1) end
-    bit_ensure_repo(); for _,p in ipairs(pkgs) do bit_install(p) end; os.exit(0)
+    local allow_yanked, names = false, {}
+    for _,p in ipairs(pkgs) do
+        if p=="--allow-yanked" then allow_yanked=true else names[#names+1]=p end
+    end
+    if #names==0 then red("Podaj nazwę pakietu: bit install <nazwa> [nazwa2 ...]"); os.exit(1) end
+    bit_ensure_repo(); for _,p in ipairs(names) do bit_install(p, allow_yanked) end; os.exit(0)
 end
 if cmd=="remove" then
     if #pkgs==0 then red("Podaj nazwę pakietu: bit remove <nazwa> [nazwa2 ...]"); os.exit(1) end
@@ -818,10 +1067,23 @@ if cmd=="upgrade" then
     if #pkgs>0 then for _,p in ipairs(pkgs) do bit_upgrade(p) end
     else bit_upgrade("") end; os.exit(0)
 end
+if cmd=="pin" then
+    if #pkgs==0 or pkg=="--list" then bit_pin_list(); os.exit(0) end
+    for _,p in ipairs(pkgs) do bit_pin(p) end; os.exit(0)
+end
+if cmd=="unpin" then
+    if #pkgs==0 then red("Podaj nazwę pakietu: bit unpin <nazwa> [nazwa2 ...]"); os.exit(1) end
+    for _,p in ipairs(pkgs) do bit_unpin(p) end; os.exit(0)
+end
 if cmd=="verify" then
     if #pkgs>0 then for _,p in ipairs(pkgs) do bit_verify(p) end
     else bit_verify("") end; os.exit(0)
 end
+if cmd=="mirror" then
+    local only = {}
+    for i=2,#pkgs do only[#only+1]=pkgs[i] end
+    bit_mirror(pkg, only); os.exit(0)
+end
 if cmd=="info" then
     if #pkgs==0 then red("Podaj nazwę pakietu: bit info <nazwa>"); os.exit(1) end
     for _,p in ipairs(pkgs) do bit_info(p) end; os.exit(0)
@@ -831,6 +1093,7 @@ local dispatch = {
     run=bit_run, list=function() bit_ensure_repo(); bit_list() end,
     installed=bit_installed, search=function() bit_ensure_repo(); bit_search(pkg) end,
     update=bit_update, clean=bit_clean, workspace=bit_workspace, help=bit_help,
+    link=function() bit_link(pkg) end, unlink=function() bit_unlink(pkg) end,
 }
 local fn = dispatch[cmd]
 if fn then fn() end
@@ -1,17 +1,44 @@
 use anyhow::Result;
-use clap::{Parser, Subcommand};
+use std::sync::atomic::{AtomicBool, Ordering};
+use clap::{CommandFactory, FromArgMatches, Parser, Subcommand};
 use colored::Colorize;
 use hl_core::diagnostics::{parse_error_to_diag, DiagRenderer, DiagSummary, lint_source, lint_gen};
 use hl_core::env::Env;
-use hl_core::{check_source, run_source, cmd_clean_cache};
+use hl_core::{check_source, eval_source, cmd_clean_cache};
 use hl_core::{HL_MAX_GEN, HL_DEFAULT_GEN, parse_source_with_meta};
 use hl_core::{
     cmd_env_create, cmd_env_enter, cmd_env_exit,
     cmd_env_remove, cmd_env_list, cmd_env_status, cmd_env_help,
     load_config, config_path, get_active_env,
 };
+use hl_core::{cmd_ci_init, cmd_deploy_systemd, SystemdOptions};
+use hl_core::{cmd_schedule_add, cmd_schedule_list, cmd_schedule_remove};
+use hl_core::{cmd_export_docker, DockerExportOptions};
+use hl_core::cmd_serve;
+use hl_core::{notify_run_finished, RunSummary};
+use hl_core::{record_run, cmd_history_list, cmd_history_show, cmd_history_rerun};
+use hl_core::{cmd_bug_report, install_panic_hook};
+use hl_core::{cmd_paths, ensure_layout};
+use hl_core::{cmd_clean_temp, parse_age};
+use hl_core::{cmd_cache_info, cmd_cache_clean, cmd_cache_verify, cmd_cache_gc};
+use hl_core::{security_lint, load_policy, count_denied, parse_manifest, manifest_violations, goroutine_count, CmdStep, Manifest};
+use hl_core::{cmd_sign, cmd_verify, cmd_trust_add, cmd_trust_list};
+use hl_core::{sudo_preflight, SudoSession};
+use hl_core::{cmd_new, NewKind};
+use hl_core::{cmd_config_edit, cmd_config_get, cmd_config_list, cmd_config_set, cmd_config_validate};
+use hl_core::cmd_explain;
+use hl_core::i18n::{self, t, tf};
+use hl_core::{CheckRenderer, DiagFormat};
+use hl_core::project_hl_files;
+use hl_core::{is_remote_spec, parse_remote_spec, fetch_remote, review_remote};
+use hl_core::{fix_file, migrate_file};
+use hl_core::apply_run_vars;
+use hl_core::check_compat;
+use hl_core::set_no_wait;
+use hl_core::exit::{self, exit_code_for};
 use hl_shell::{run_interactive, run_as_shell};
 use std::path::{Path, PathBuf};
+use std::time::Instant;
 use tracing_subscriber::{EnvFilter, fmt};
 
 const HL_SCRIPTS_DIR: &str = "/usr/share/HackerOS/Scripts/Bin";
@@ -30,37 +57,19 @@ fn check_hackeros_only() {
 #[cold] #[inline(never)]
 fn die_not_hackeros() -> ! {
     eprintln!("{} {}", "hl:".bright_magenta().bold(),
-              "Hacker Lang działa wyłącznie na HackerOS.".white().bold());
+              t("not_hackeros").white().bold());
     eprintln!("    {}", "https://github.com/HackerOS-Linux-System".bright_black());
-    std::process::exit(1);
+    exit_with(1);
 }
 
 // ── CLI ───────────────────────────────────────────────────────────────────────
 
+// about i after_help — z katalogu hl_core::i18n (parse_cli)
 #[derive(Parser, Debug)]
 #[command(
 name    = "hl",
 version = "gen 2",
 author  = "HackerOS Team",
-about   = "Hacker Lang — język skryptowy HackerOS (gen 2)",
-          after_help = "\
-SKRYPTY SYSTEMOWE:
-hl search <nazwa>    Szukaj skryptu w /usr/share/HackerOS/Scripts/Bin/
-hl search all        Pokaż wszystkie dostępne skrypty
-hl exec <nazwa>      Uruchom skrypt z /usr/share/HackerOS/Scripts/Bin/
-
-BYTECODE / JIT:
-hl run plik.hl       Uruchom skrypt (domyślnie: tree-walk interpreter)
-hl run --jit plik.hl Uruchom przez JIT pipeline (eksperymentalny)
-hl run plik.bc       Uruchom bytecode bezpośrednio przez JIT
-hl compile plik.hl   Kompiluj .hl → .bc (do katalogu źródłowego)
-hl clean             Wyczyść cache .bc (~/.hackeros/hacker-lang/cache/)
-
-PRZYKŁADY:
-hl run skrypt.hl
-hl exec update-system
-hl search update
-hl repl"
 )]
 struct Cli {
     #[command(subcommand)]
@@ -76,6 +85,32 @@ struct Cli {
     #[arg(short, long, global = true)]
     verbose: bool,
 
+    /// Poziom logów: trace | debug | info | warn | error (nadpisuje --verbose)
+    #[arg(long, global = true, value_name = "LEVEL",
+          value_parser = ["trace", "debug", "info", "warn", "error"])]
+    log_level: Option<String>,
+
+    /// Zapisuj logi do pliku (dopisywanie) zamiast na terminal
+    #[arg(long, global = true, value_name = "PATH")]
+    log_file: Option<PathBuf>,
+
+    /// Format logów: text | json
+    #[arg(long, global = true, value_name = "FORMAT", default_value = "text",
+          value_parser = ["text", "json"])]
+    log_format: String,
+
+    /// Język komunikatów hl: pl | en (też HL_LANG, LANG)
+    #[arg(long, global = true, value_name = "LANG", value_parser = ["pl", "en"])]
+    lang: Option<String>,
+
+    /// Nie czekaj na blokady bibliotek/cache trzymane przez inny proces hl (exit 5)
+    #[arg(long, global = true)]
+    no_wait: bool,
+
+    /// Limit prędkości pobierania (np. 500K, 1M) — nadpisuje [net] limit_rate
+    #[arg(long, global = true, value_name = "RATE")]
+    limit_rate: Option<String>,
+
     #[arg(short = 'c', long = "code", value_name = "CODE")]
     inline_code: Option<String>,
 }
@@ -84,21 +119,214 @@ struct Cli {
 enum Commands {
     /// Uruchom skrypt .hl lub .bc
     Run {
+        /// Plik .hl, katalog projektu, URL lub github:org/repo[@ref][#ścieżka]
         file: PathBuf,
         /// Użyj JIT pipeline zamiast tree-walk (eksperymentalny)
         #[arg(long)]
         jit: bool,
+        /// Uruchom zdalnie przez SSH (można powtarzać)
+        #[arg(long, value_name = "USER@HOST")]
+        host: Vec<String>,
+        /// Plik z listą hostów (jeden na linię)
+        #[arg(long, value_name = "FILE")]
+        hosts_file: Option<PathBuf>,
+        /// Pomiń sprawdzenie manifestu uprawnień (/// Requires:)
+        #[arg(long)]
+        trust: bool,
+        /// Uruchom skrypt zdalny bez pytania o potwierdzenie
+        #[arg(long, short = 'y')]
+        yes: bool,
+        /// Nadpisz zmienną skryptu (można powtarzać; wygrywa z .env i `%`)
+        #[arg(long = "var", value_name = "NAZWA=WARTOŚĆ")]
+        vars: Vec<String>,
+        /// Nie uruchamiaj — wypisz odpowiednik w bash z numerami linii źródła
+        #[arg(long)]
+        explain: bool,
+        /// Z --explain: zoptymalizowany bash bez komentarzy (jedna kontrola zależności,
+        /// scalone exporty, bez rozstrzygniętych gałęzi)
+        #[arg(long, requires = "explain")]
+        minify: bool,
+        /// Z --explain: wstaw małe pliki z `<< plik` w miejsce importu
+        #[arg(long, requires = "explain")]
+        inline: bool,
+        /// Nie zmieniaj systemu: raportuj, które kroki `~check` coś by zmieniły
+        #[arg(long)]
+        check_mode: bool,
+        /// Nagraj wyjście, czasy i kody wyjścia komend do pliku (hl replay)
+        #[arg(long, value_name = "PLIK.rec")]
+        record: Option<PathBuf>,
+        /// Włącz sekcje `?feature` (cechy z `/// Features:`, po przecinku)
+        #[arg(long, value_name = "CECHY", value_delimiter = ',')]
+        features: Vec<String>,
+        /// Środowisko: [env.<NAZWA>] z bit.hk i .env.<NAZWA> (jak HL_ENV=<NAZWA>)
+        #[arg(long = "env", value_name = "NAZWA")]
+        env_name: Option<String>,
         #[arg(last = true)]
         args: Vec<String>,
     },
 
+    /// Pokaż metadane .bc, zawartość paczki (tar/.pkg) lub właściwości binarki ELF
+    Inspect {
+        file: PathBuf,
+        /// Wypisz jako JSON
+        #[arg(long)]
+        json: bool,
+    },
+
     /// Kompiluj .hl → .bc
     Compile {
-        file: PathBuf,
+        /// Skrypt .hl; bez niego — programy z [bins] w bit.hk
+        file: Option<PathBuf>,
+        /// Program z [bins] w bit.hk (→ <nazwa>.bc obok skryptu)
+        #[arg(long, value_name = "NAZWA", conflicts_with = "file")]
+        bin: Option<String>,
         #[arg(long)]
         shared: bool,
         #[arg(short, long)]
         output: Option<PathBuf>,
+        /// Poziom optymalizacji: 0 (bytecode 1:1, do debugowania) | 1
+        #[arg(short = 'O', long = "opt-level", default_value_t = 1,
+              value_parser = clap::value_parser!(u8).range(0..=1))]
+        opt_level: u8,
+        /// Bez metadanych `///`, user@host i katalogu źródła w nagłówku .bc
+        #[arg(long)]
+        strip: bool,
+        /// Zapisz <plik>.bc.provenance.json (źródła, importy, toolchain, sha256)
+        #[arg(long)]
+        provenance: bool,
+        /// Podpisz .bc i provenance kluczem hl sign (włącza --provenance)
+        #[arg(long)]
+        sign: bool,
+        /// Zostaw sekcje `?feature` tych cech, resztę wytnij (np. --features gpu,debug)
+        #[arg(long, value_name = "CECHY", value_delimiter = ',')]
+        features: Vec<String>,
+    },
+
+    /// Samowystarczalna kopia skryptu: importy << / <* wstawione, zmienne podstawione
+    /// tam, gdzie to bezpieczne, wersje bibliotek w nagłówku (archiwum po incydencie)
+    Freeze {
+        file: PathBuf,
+        /// Plik wynikowy (domyślnie <skrypt>.frozen.hl obok skryptu)
+        #[arg(short, long)]
+        output: Option<PathBuf>,
+        /// Wartość zmiennej z uruchomienia (jak hl run --var; można powtarzać)
+        #[arg(long = "var", value_name = "NAZWA=WARTOŚĆ")]
+        vars: Vec<String>,
+    },
+
+    /// Zainstaluj brakujące zależności projektu z bit.hk: [dependencies] i wybrane grupy z [groups]
+    Fetch {
+        /// Grupa z [groups] (dev, test, …; można powtarzać albo podać po przecinku)
+        #[arg(long = "group", value_name = "GRUPA")]
+        groups: Vec<String>,
+        /// Tylko pokaż, czego brakuje
+        #[arg(long)]
+        dry_run: bool,
+    },
+
+    /// Uruchom skrypt z [scripts] w bit.hk (bez nazwy — lista)
+    Script {
+        name: Option<String>,
+        /// Argumenty skryptu (dostępne jako "$@")
+        #[arg(last = true)]
+        args: Vec<String>,
+    },
+
+    /// Uruchom zadanie na hostach z inventory.hk falami (canary, limit równoległości i błędów)
+    Rollout {
+        /// Nazwa z [tasks] w inventory albo ścieżka skryptu
+        task: String,
+        #[arg(long, default_value = hl_core::rollout::INVENTORY_FILE)]
+        inventory: PathBuf,
+        /// Grupa z [groups] albo pojedynczy host (domyślnie wszystkie)
+        #[arg(long)]
+        group: Option<String>,
+        #[arg(long, default_value_t = 5)]
+        max_parallel: usize,
+        /// Ile hostów uruchomić najpierw osobno; błąd zatrzymuje rollout
+        #[arg(long, default_value_t = 0)]
+        canary: usize,
+        /// Próg zatrzymania: liczba hostów albo procent (np. 2, 10%)
+        #[arg(long, default_value = "0")]
+        max_failures: String,
+        #[arg(last = true)]
+        args: Vec<String>,
+    },
+
+    /// Kroki `~once` zapisane w .hl-state.json projektu
+    State {
+        /// Katalog projektu albo skrypt
+        #[arg(default_value = ".")]
+        path: PathBuf,
+        /// Usuń stan — wszystkie kroki albo tylko --step
+        #[arg(long)]
+        reset: bool,
+        #[arg(long, requires = "reset")]
+        step: Option<String>,
+    },
+
+    /// Cofnij uruchomienie: komendy z kroków `~undo` w odwrotnej kolejności
+    Rollback {
+        /// Dziennik z `hl rollback --list`
+        id: Option<String>,
+        /// Ostatnie uruchomienie z krokami ~undo
+        #[arg(long, conflicts_with = "id")]
+        last: bool,
+        /// Wypisz dzienniki do cofnięcia
+        #[arg(long, conflicts_with_all = ["id", "last"])]
+        list: bool,
+        /// Bez pytania o potwierdzenie
+        #[arg(long)]
+        yes: bool,
+        /// Tylko pokaż komendy cofające
+        #[arg(long)]
+        dry_run: bool,
+    },
+
+    /// Odtwórz nagranie z `hl run --record`
+    Replay {
+        file: PathBuf,
+        /// Mnożnik prędkości (2 — dwa razy szybciej)
+        #[arg(long, default_value_t = 1.0)]
+        speed: f64,
+        /// Najdłuższa przerwa między zdarzeniami (np. 500ms, 2s)
+        #[arg(long, value_name = "CZAS")]
+        max_idle: Option<String>,
+        /// Tylko stdout/stderr, bez linii kroków i podsumowania
+        #[arg(long)]
+        raw: bool,
+    },
+
+    /// Sprawdź artefakt: podpisy, sha256 i źródła z provenance
+    Verify {
+        file: PathBuf,
+        /// Przyjmij artefakt bez podpisu (sprawdź tylko sha256 z provenance)
+        #[arg(long)]
+        allow_unsigned: bool,
+    },
+
+    /// Graf projektu: importy, biblioteki, narzędzia `//`, bloki extern
+    Graph {
+        /// Plik .hl lub katalog projektu (entry z bit.hk / main.hl)
+        #[arg(default_value = ".")]
+        path: PathBuf,
+        #[arg(long, default_value = "ascii", value_parser = ["ascii", "dot", "mermaid"])]
+        format: String,
+    },
+
+    /// Różnice zachowania skryptów między rewizjami git (sudo, zależności, komendy, zmienne)
+    Diff {
+        rev1: String,
+        rev2: String,
+        /// Jeden plik .hl (domyślnie każdy zmieniony)
+        file: Option<PathBuf>,
+    },
+
+    /// Stan projektu: zależności, ostatnie uruchomienia, cache, diagnostyki
+    Status {
+        /// Plik .hl lub katalog projektu (entry z bit.hk / main.hl)
+        #[arg(default_value = ".")]
+        path: PathBuf,
     },
 
     /// Uruchom skrypt z /usr/share/HackerOS/Scripts/Bin/ po nazwie (bez .hl)
@@ -124,32 +352,98 @@ enum Commands {
 
     /// Sprawdź składnię (bez uruchamiania)
     Check {
+        /// Plik .hl albo katalog projektu (z pominięciem .hackerignore)
         file: PathBuf,
         #[arg(long)]
         meta: bool,
+        /// Analiza niebezpiecznych komend wg polityki security.hk (exit 126 przy naruszeniu deny)
+        #[arg(long)]
+        security: bool,
+        /// Format diagnostyk: text (stderr) | json | sarif (code scanning) | github (adnotacje Actions)
+        #[arg(long, default_value = "text", value_parser = ["text", "json", "sarif", "github"])]
+        format: String,
+        /// Zastosuj automatyczne poprawki (kopia oryginału w <plik>.bak)
+        #[arg(long)]
+        fix: bool,
+        /// Przepisz tylko przestarzałą składnię (HL0023, kopia w <plik>.bak)
+        #[arg(long, conflicts_with = "fix")]
+        migrate: bool,
     },
 
     /// Wydrukuj AST jako JSON
     Ast { file: PathBuf },
 
     /// Wyczyść cache bytecode + bibliotek
-    Clean,
+    Clean {
+        /// Pokaż co zostałoby usunięte, nic nie usuwaj
+        #[arg(long)]
+        dry_run: bool,
+        /// Tylko pliki tymczasowe starsze niż podany wiek (np. 12h, 7d); cache zostaje
+        #[arg(long, value_name = "AGE")]
+        older_than: Option<String>,
+    },
 
-    /// Informacje o cache bytecode
+    /// Stara nazwa `hl cache info`
+    #[command(hide = true)]
     CacheInfo,
 
+    /// Zarządzanie cache: info | clean | verify | gc
+    Cache {
+        #[command(subcommand)]
+        action: CacheAction,
+    },
+
     /// Informacje o systemie bibliotek
     Lib {
         #[command(subcommand)]
         action: Option<LibAction>,
     },
 
-    /// Otwórz interaktywną dokumentację Hacker Lang (TUI)
-    Docs,
+    /// Odczyt i zapis JSON bez jq (.yaml / .yml — YAML)
+    Json {
+        #[command(subcommand)]
+        action: QueryAction,
+    },
+
+    /// Odczyt i zapis YAML bez yq
+    Yaml {
+        #[command(subcommand)]
+        action: QueryAction,
+    },
+
+    /// Schowek i powiadomienia: notify "tytuł" [treść] | copy [tekst] | paste
+    Desktop {
+        #[arg(required = true, trailing_var_arg = true, allow_hyphen_values = true)]
+        args: Vec<String>,
+    },
+
+    /// Wyjaśnij kod diagnostyki (HL0001…); bez kodu — lista kodów
+    Explain {
+        code: Option<String>,
+    },
+
+    /// Otwórz interaktywną dokumentację Hacker Lang (TUI; język — globalne --lang)
+    Docs {
+        #[command(subcommand)]
+        topic: Option<DocsTopic>,
+    },
 
     /// Informacje o wersji HL i systemie genów
     Version,
 
+    /// Skrypt podpowiedzi powłoki: bash | zsh | fish
+    Completions {
+        #[arg(value_parser = ["bash", "zsh", "fish"])]
+        shell: String,
+    },
+
+    /// Kandydaci podpowiedzi dla skryptu powłoki (ostatnie słowo — prefiks)
+    #[command(name = "__complete", hide = true)]
+    Complete {
+        #[arg(last = true)]
+        words: Vec<String>,
+    },
+
     /// Informacje o genie i shebangu pliku .hl
     GenInfo { file: PathBuf },
 
@@ -159,6 +453,393 @@ enum Commands {
         #[command(subcommand)]
         action: Option<EnvAction>,
     },
+
+    /// Generator konfiguracji CI (GitHub Actions / GitLab CI)
+    Ci {
+        #[command(subcommand)]
+        action: CiAction,
+    },
+
+    /// Wdrażanie skryptów jako usług systemowych
+    Deploy {
+        #[command(subcommand)]
+        target: DeployTarget,
+    },
+
+    /// Harmonogram uruchamiania skryptów (crontab)
+    Schedule {
+        #[command(subcommand)]
+        action: ScheduleAction,
+    },
+
+    /// Eksport skryptu do formatu dystrybucji (obraz kontenera)
+    Export {
+        #[command(subcommand)]
+        target: ExportTarget,
+    },
+
+    /// Serwer HTTP uruchamiający skrypty z serve.hk (webhooki, chatops)
+    Serve {
+        /// Plik konfiguracji tras (domyślnie ./serve.hk)
+        #[arg(short, long, value_name = "FILE")]
+        config: Option<PathBuf>,
+        /// Adres nasłuchu, nadpisuje [serve] bind
+        #[arg(long, value_name = "ADDR")]
+        bind: Option<String>,
+    },
+
+    /// Historia uruchomień skryptów (dziennik, logi, ponowienie)
+    History {
+        #[command(subcommand)]
+        action: Option<HistoryAction>,
+        /// Filtruj po fragmencie ścieżki skryptu
+        #[arg(long)]
+        script: Option<String>,
+        /// Tylko nieudane uruchomienia
+        #[arg(long)]
+        failed: bool,
+        /// Liczba wpisów
+        #[arg(short = 'n', long, default_value = "20")]
+        limit: usize,
+    },
+
+    /// Pakiet diagnostyczny (.tar.gz) do dołączenia do zgłoszenia błędu
+    BugReport {
+        /// Plik wynikowy (domyślnie ./hl-bug-report-<ts>.tar.gz)
+        #[arg(short, long)]
+        output: Option<PathBuf>,
+        /// Komenda hl, której wyjście dołączyć (po --)
+        #[arg(last = true)]
+        command: Vec<String>,
+    },
+
+    /// Test dymny instalacji: hello-world przez parser, compile, .bc, interpreter, JIT, REPL i kontener
+    VerifyInstall {
+        /// Zostaw katalog roboczy (hello.hl, hello.bc) do obejrzenia
+        #[arg(long)]
+        keep: bool,
+    },
+
+    /// Pokaż katalogi danych HL (układ legacy / XDG)
+    Paths,
+
+    /// Podpisz skrypt (plik.sig) lub sprawdź podpis (--verify)
+    Sign {
+        file: PathBuf,
+        #[arg(long)]
+        verify: bool,
+    },
+
+    /// Zaufane klucze podpisów (~/.hackeros/keys)
+    Trust {
+        #[command(subcommand)]
+        action: TrustAction,
+    },
+
+    /// Konfiguracja hl (config.hk)
+    Config {
+        #[command(subcommand)]
+        action: ConfigAction,
+    },
+
+    /// Zmiany od zainstalowanej wersji (GitHub Releases, cache na dobę)
+    Changelog {
+        /// Wszystkie wydania
+        #[arg(long)]
+        all: bool,
+        /// Pobierz listę wydań teraz
+        #[arg(long)]
+        refresh: bool,
+        /// Tylko odśwież cache, bez wypisywania
+        #[arg(long, hide = true)]
+        quiet: bool,
+    },
+
+    /// Przykłady z repozytorium bit: list | show | run
+    Examples {
+        #[command(subcommand)]
+        action: ExamplesAction,
+    },
+
+    /// Interaktywne lekcje z zadaniami w piaskownicy; bez nazwy — lista i postęp
+    Learn {
+        lesson: Option<String>,
+        /// Sprawdź bieżące zadanie bez trybu interaktywnego (exit 1 — niezaliczone)
+        #[arg(long, requires = "lesson")]
+        check: bool,
+        /// Zacznij lekcję od nowa (postęp i piaskownica)
+        #[arg(long, requires = "lesson", conflicts_with = "check")]
+        reset: bool,
+    },
+
+    /// Anonimowa telemetria użycia (opt-in): on | off | status | send
+    Telemetry {
+        #[command(subcommand)]
+        action: TelemetryAction,
+    },
+
+    /// Nowy projekt: biblioteka bit lub aplikacja
+    New {
+        #[command(subcommand)]
+        kind: NewCommand,
+    },
+
+    /// bit.hk ze starszego układu projektu: build.hl, nagłówek ///, importy bit
+    Migrate {
+        /// Katalog projektu (domyślnie bieżący)
+        dir: Option<PathBuf>,
+        /// Zapisz bit.hk (bez tego tylko wypisz)
+        #[arg(long)]
+        write: bool,
+    },
+}
+
+#[derive(Subcommand, Debug)]
+enum ConfigAction {
+    /// Sprawdź config.hk (i bit.hk projektu): składnia, nieznane klucze, wartości
+    Validate {
+        /// Plik do sprawdzenia (domyślnie config.hk użytkownika i bit.hk projektu)
+        file: Option<PathBuf>,
+    },
+    /// Wypisz wartość klucza (sekcja.klucz, np. runtime.jit)
+    Get { key: String },
+    /// Ustaw klucz w config.hk (komentarze zostają)
+    Set {
+        key: String,
+        value: String,
+        /// Zapisz także klucz spoza znanego schematu
+        #[arg(long)]
+        force: bool,
+    },
+    /// Wszystkie ustawienia: sekcja.klucz = wartość
+    List,
+    /// Otwórz config.hk w $VISUAL / $EDITOR i sprawdź po zapisie
+    Edit,
+}
+
+#[derive(Subcommand, Debug)]
+enum DocsTopic {
+    /// Dokumentacja biblioteki z komentarzy ;;; / ;; (main-libs, std, bit, GitHub, plik .hl)
+    Lib {
+        name: String,
+        /// Markdown na stdout
+        #[arg(long, conflicts_with = "json")]
+        markdown: bool,
+        /// JSON na stdout
+        #[arg(long)]
+        json: bool,
+        /// Tekst na stdout zamiast strony w TUI
+        #[arg(long)]
+        print: bool,
+    },
+    /// Szukaj w dokumentacji, bibliotekach i kodach diagnostyk (dopasowanie rozmyte)
+    Search {
+        #[arg(required = true)]
+        query: Vec<String>,
+        /// Ile wyników pokazać
+        #[arg(long, default_value_t = 10)]
+        limit: usize,
+        /// Wyniki jako JSON
+        #[arg(long)]
+        json: bool,
+        /// Otwórz wynik nr N (bez numeru — pierwszy)
+        #[arg(long, num_args = 0..=1, default_missing_value = "1")]
+        open: Option<usize>,
+    },
+}
+
+#[derive(Subcommand, Debug)]
+enum ExamplesAction {
+    /// Przykłady z sekcji @examples w repo-list.json
+    List {
+        /// Pobierz listę repozytorium na nowo
+        #[arg(long)]
+        refresh: bool,
+    },
+    /// Pobierz przykład i pokaż jego pliki
+    Show { name: String },
+    /// Pobierz przykład do katalogu tymczasowego i uruchom
+    Run {
+        name: String,
+        /// Osobne przestrzenie nazw: mount, pid, bez sieci
+        #[arg(long)]
+        isolated: bool,
+        /// Argumenty dla przykładu (po --)
+        #[arg(last = true)]
+        args: Vec<String>,
+    },
+}
+
+#[derive(Subcommand, Debug)]
+enum TelemetryAction {
+    /// Włącz zapis: podkomenda, czas, wynik, OS/arch — bez argumentów i treści plików
+    On,
+    /// Wyłącz i usuń lokalny bufor
+    Off,
+    /// Stan, bufor i endpoint
+    Status,
+    /// Wyślij bufor na [telemetry] -> endpoint
+    Send,
+}
+
+#[derive(Subcommand, Debug)]
+enum NewCommand {
+    /// Biblioteka (lib.hl, tests/, README.adoc)
+    Lib { name: String },
+    /// Aplikacja (main.hl, build.hl, tests/)
+    App { name: String },
+}
+
+#[derive(Subcommand, Debug)]
+enum TrustAction {
+    /// Dodaj klucz publiczny SSH do zaufanych
+    Add {
+        pubkey: PathBuf,
+        /// Nazwa klucza (domyślnie nazwa pliku)
+        #[arg(short, long)]
+        name: Option<String>,
+    },
+    /// Lista zaufanych kluczy
+    List,
+}
+
+#[derive(Subcommand, Debug)]
+enum QueryAction {
+    /// Wartość pod ścieżką (.a.b[0], .lista[]); tekst bez cudzysłowów
+    Get {
+        path: String,
+        /// Plik; brak albo `-` — stdin
+        file: Option<PathBuf>,
+        /// JSON w jednej linii
+        #[arg(long)]
+        compact: bool,
+    },
+    /// Klucze obiektu (indeksy listy)
+    Keys { path: String, file: Option<PathBuf> },
+    /// Exit 0, jeśli ścieżka istnieje
+    Has { path: String, file: Option<PathBuf> },
+    /// Liczba elementów / znaków
+    Len { path: String, file: Option<PathBuf> },
+    /// Ustaw wartość w pliku (JSON: 8080, true, [1,2]; inaczej tekst)
+    Set { path: String, value: String, file: PathBuf },
+    /// Głębokie scalenie plików (późniejszy wygrywa)
+    Merge {
+        #[arg(required = true)]
+        files: Vec<PathBuf>,
+        #[arg(long)]
+        compact: bool,
+    },
+}
+
+#[derive(Subcommand, Debug)]
+enum CacheAction {
+    /// Rozmiar każdej kategorii cache
+    Info,
+    /// Wyczyść kategorię (bytecode | github | temp | scripts) lub wszystkie
+    Clean {
+        category: Option<String>,
+        #[arg(long)]
+        dry_run: bool,
+    },
+    /// Sprawdź pliki .bc w cache
+    Verify {
+        /// Usuń uszkodzone pliki
+        #[arg(long)]
+        fix: bool,
+    },
+    /// Usuń najdawniej używane elementy do limitu rozmiaru
+    Gc {
+        /// Limit (np. 500M); domyślnie [cache] max_size z config.hk
+        #[arg(long, value_name = "SIZE")]
+        max_size: Option<String>,
+        #[arg(long)]
+        dry_run: bool,
+    },
+}
+
+#[derive(Subcommand, Debug)]
+enum HistoryAction {
+    /// Szczegóły uruchomienia i pełny log
+    Show { id: u64 },
+    /// Ponów uruchomienie z tymi samymi argumentami i środowiskiem
+    Rerun { id: u64 },
+}
+
+#[derive(Subcommand, Debug)]
+enum ExportTarget {
+    /// Kontekst Dockerfile (hl + main-libs + katalog skryptu)
+    Docker {
+        /// Skrypt; bez niego — programy z [bins] w bit.hk (kontekst <out>/<nazwa>/ na program)
+        script: Option<PathBuf>,
+        /// Tylko ten program z [bins]
+        #[arg(long, value_name = "NAZWA", conflicts_with = "script")]
+        bin: Option<String>,
+        /// Obraz bazowy HackerOS (HL działa wyłącznie na HackerOS)
+        #[arg(long, value_name = "IMAGE")]
+        base: String,
+        /// Katalog wyjściowy kontekstu
+        #[arg(long, default_value = "hl-docker")]
+        out: PathBuf,
+        /// Zbuduj obraz przez docker/podman z podanym tagiem
+        #[arg(long, value_name = "TAG")]
+        build: Option<String>,
+    },
+}
+
+#[derive(Subcommand, Debug)]
+enum ScheduleAction {
+    /// Dodaj wpis: hl schedule add "0 3 * * *" skrypt.hl
+    Add {
+        spec: String,
+        script: PathBuf,
+        /// Nazwa harmonogramu (domyślnie: nazwa pliku)
+        #[arg(long)]
+        name: Option<String>,
+    },
+    /// Lista zaplanowanych skryptów
+    List,
+    /// Usuń harmonogram po nazwie
+    Remove { name: String },
+}
+
+#[derive(Subcommand, Debug)]
+enum DeployTarget {
+    /// Wygeneruj (i opcjonalnie zainstaluj) jednostkę systemd + timer
+    Systemd {
+        name: String,
+        /// Skrypt .hl lub skompilowany .bc
+        #[arg(long, value_name = "FILE")]
+        script: PathBuf,
+        /// Jednostka użytkownika (~/.config/systemd/user)
+        #[arg(long)]
+        user: bool,
+        /// Zainstaluj i włącz jednostkę (systemctl enable --now)
+        #[arg(long)]
+        install: bool,
+        /// Wygeneruj timer, np. "daily" lub "*-*-* 03:00:00"
+        #[arg(long, value_name = "SPEC")]
+        on_calendar: Option<String>,
+        /// Plik EnvironmentFile= dla usługi
+        #[arg(long, value_name = "FILE")]
+        env_file: Option<PathBuf>,
+        /// Polityka Restart= (no | on-failure | always)
+        #[arg(long, default_value = "on-failure")]
+        restart: String,
+        /// Pomiń dyrektywy sandboxingu (ProtectSystem, PrivateTmp, ...)
+        #[arg(long)]
+        no_sandbox: bool,
+    },
+}
+
+#[derive(Subcommand, Debug)]
+enum CiAction {
+    /// Wygeneruj konfigurację CI: github | gitlab
+    Init {
+        provider: String,
+        /// Nadpisz istniejący plik konfiguracji
+        #[arg(long)]
+        force: bool,
+    },
 }
 
 #[derive(Subcommand, Debug)]
@@ -186,35 +867,230 @@ enum LibAction {
     Remove  { name: String },
     Bit,
     Info    { name: String },
+    /// Dopisz `#sha256=` do importów z URL bez przypięcia (HL0021)
+    Pin     { file: PathBuf },
+}
+
+// ── Logowanie ─────────────────────────────────────────────────────────────────
+// Logi diagnostyczne (tracing) całego workspace'u: core, compiler, jit, shell.
+// Poziom: --log-level > --verbose (debug) > HL_LOG > warn.
+// --log-file: logi do pliku (bez kolorów, ze znacznikiem czasu).
+
+fn init_logging(cli: &Cli) -> Result<()> {
+    let level = cli.log_level.clone()
+        .or_else(|| cli.verbose.then(|| "debug".to_string()))
+        .or_else(|| std::env::var("HL_LOG").ok())
+        .unwrap_or_else(|| "warn".to_string());
+    let filter = EnvFilter::try_new(&level)
+        .map_err(|e| anyhow::anyhow!("Nieprawidłowy poziom logów '{}': {}", level, e))?;
+    let json = cli.log_format == "json";
+    let builder = fmt().with_env_filter(filter);
+
+    match &cli.log_file {
+        Some(path) => {
+            let file = std::fs::OpenOptions::new().create(true).append(true).open(path)
+                .map_err(|e| anyhow::anyhow!("Nie można otworzyć pliku logów {}: {}", path.display(), e))?;
+            let builder = builder.with_writer(std::sync::Mutex::new(file)).with_ansi(false);
+            if json { builder.json().init() } else { builder.init() }
+        }
+        None if json => builder.without_time().json().init(),
+        None         => builder.without_time().compact().init(),
+    }
+    let json_only = json && cli.log_file.is_none();
+    if json_only { colored::control::set_override(false); }
+    LOG_MESSAGES.store(json || cli.log_file.is_some(), Ordering::Relaxed);
+    STDERR_MESSAGES.store(!json_only, Ordering::Relaxed);
+    Ok(())
 }
 
 fn main() -> Result<()> {
+    let res = run_cli();
+    hl_core::telemetry::finish(if res.is_ok() { exit::OK } else { exit::FAILURE });
+    res
+}
+
+/// Cli z pomocą główną w języku z hl_core::i18n
+fn parse_cli() -> Cli {
+    let matches = Cli::command().about(t("about")).after_help(t("after_help")).get_matches();
+    Cli::from_arg_matches(&matches).unwrap_or_else(|e| e.exit())
+}
+
+fn run_cli() -> Result<()> {
+    i18n::init(&std::env::args().collect::<Vec<_>>());
     check_hackeros_only();
+    install_panic_hook();
 
-    let cli = Cli::parse();
+    let cli = parse_cli();
 
-    fmt().with_env_filter(
-        if cli.verbose { EnvFilter::new("debug") } else { EnvFilter::new("warn") }
-    ).without_time().compact().init();
+    if let Err(e) = init_logging(&cli) {
+        report_error(&e);
+        exit_with(1);
+    }
+    if let Err(e) = ensure_layout() {
+        report_warning(&e);
+    }
+    // Podpowiedzi powłoki działają niezależnie od przypiętej wersji
+    if !matches!(cli.command, Some(Commands::Complete { .. }) | Some(Commands::Completions { .. })) {
+        if let Err(e) = hl_core::pin::enforce_pin() { fail(e); }
+    }
+    if let Some(name) = telemetry_command_name() { hl_core::telemetry::start(&name); }
+    if !matches!(cli.command, Some(Commands::Complete { .. }) | Some(Commands::Completions { .. }) | Some(Commands::Changelog { .. })) {
+        hl_core::changelog::notice_new_release();
+    }
+    set_no_wait(cli.no_wait);
+    if let Some(rate) = &cli.limit_rate {
+        match hl_core::cache::parse_size(rate) {
+            Ok(bytes) => hl_core::net::set_limit_rate(bytes),
+            Err(e)    => { report_error(format_args!("--limit-rate: {}", e)); exit_with(1); }
+        }
+    }
 
     match cli.command {
 
         Some(Commands::Exec { name, args }) => {
-            std::process::exit(cmd_exec(&name, &args, cli.verbose));
+            exit_with(cmd_exec(&name, &args, cli.verbose));
         }
 
         Some(Commands::Search { query }) => {
             cmd_search(&query);
         }
 
-        Some(Commands::Compile { file, shared: _, output }) => {
-            cmd_compile(&file, output.as_deref())?;
+        Some(Commands::Compile { file, bin, shared: _, output, opt_level, strip, provenance, sign, features }) => {
+            let opts = hl_compiler::CompileOptions { opt_level, strip, features };
+            match file {
+                Some(file) => cmd_compile(&file, output.as_deref(), opts, provenance || sign, sign)?,
+                None => {
+                    let project = hl_core::project::current_project().unwrap_or_else(|e| fail(e));
+                    let bins = project.select(bin.as_deref()).unwrap_or_else(|e| fail(e));
+                    if output.is_some() && bins.len() > 1 {
+                        fail(anyhow::anyhow!("-o działa z jednym programem — dodaj --bin <nazwa>"));
+                    }
+                    for b in bins {
+                        let out = output.clone().unwrap_or_else(|| b.artifact("bc"));
+                        cmd_compile(&b.script, Some(out.as_path()), opts.clone(), provenance || sign, sign)?;
+                    }
+                }
+            }
+        }
+
+        Some(Commands::Freeze { file, output, vars }) => {
+            if let Err(e) = hl_core::freeze::cmd_freeze(&file, output.as_deref(), &vars) { fail(e); }
+        }
+
+        Some(Commands::Fetch { groups, dry_run }) => {
+            match hl_core::project::cmd_fetch(&groups, dry_run) {
+                Ok(code) => exit_with(code),
+                Err(e)   => fail(e),
+            }
+        }
+
+        Some(Commands::Script { name, args }) => {
+            match hl_core::project::cmd_script(name.as_deref(), &args) {
+                Ok(code) => exit_with(code),
+                Err(e)   => fail(e),
+            }
+        }
+
+        Some(Commands::Verify { file, allow_unsigned }) => {
+            if let Err(e) = hl_core::cmd_verify_artifact(&file, allow_unsigned) { fail(e); }
+        }
+
+        Some(Commands::Graph { path, format }) => {
+            use hl_core::graph::{project_graph, GraphFormat};
+            let format = GraphFormat::from_str(&format).unwrap_or(GraphFormat::Ascii);
+            match project_graph(&resolve_entry(&path), format) {
+                Ok(out) => print!("{}", out),
+                Err(e)  => fail(e),
+            }
+        }
+
+        Some(Commands::Rollout { task, inventory, group, max_parallel, canary, max_failures, args }) => {
+            use hl_core::rollout::{cmd_rollout, load_inventory, RolloutOptions, Threshold};
+            let inv = load_inventory(&inventory).unwrap_or_else(|e| fail(e));
+            let max_failures = Threshold::parse(&max_failures)
+                .unwrap_or_else(|e| fail(anyhow::anyhow!("--max-failures: {}", e)));
+            let script = resolve_entry(&inv.task_script(&task));
+            let opts = RolloutOptions { group, max_parallel, canary, max_failures, args };
+            match cmd_rollout(&inv, &script, &opts) {
+                Ok(code) => exit_with(code),
+                Err(e)   => fail(e),
+            }
+        }
+
+        Some(Commands::State { path, reset, step }) => {
+            let dir = if path.is_file() { path.parent().map(Path::to_path_buf).unwrap_or_default() } else { path };
+            if let Err(e) = hl_core::state::cmd_state(&dir, reset, step.as_deref()) { fail(e); }
+        }
+
+        Some(Commands::Rollback { id, last, list, yes, dry_run }) => {
+            if list {
+                if let Err(e) = hl_core::rollback::cmd_rollback_list() { fail(e); }
+            } else if id.is_none() && !last {
+                fail(anyhow::anyhow!("Podaj --last albo id dziennika (hl rollback --list)"));
+            } else {
+                match hl_core::rollback::cmd_rollback(id.as_deref(), yes, dry_run) {
+                    Ok(code) => exit_with(code),
+                    Err(e)   => fail(e),
+                }
+            }
+        }
+
+        Some(Commands::Replay { file, speed, max_idle, raw }) => {
+            let max_idle = max_idle.map(|s| hl_parser::ast::parse_delay_ms(&s)
+                .map(std::time::Duration::from_millis)
+                .unwrap_or_else(|| fail(anyhow::anyhow!("--max-idle: nieprawidłowy czas '{}' (np. 500ms, 2s)", s))));
+            let opts = hl_core::record::ReplayOptions { speed, max_idle, raw };
+            match hl_core::record::cmd_replay(&file, &opts) {
+                Ok(code) => exit_with(code),
+                Err(e)   => fail(e),
+            }
+        }
+
+        Some(Commands::Diff { rev1, rev2, file }) => {
+            if let Err(e) = hl_core::revdiff::cmd_diff(&rev1, &rev2, file.as_deref()) { fail(e); }
         }
 
-        Some(Commands::Docs) => run_docs(),
+        Some(Commands::Status { path }) => {
+            if let Err(e) = hl_core::status::cmd_status(&resolve_entry(&path)) { fail(e); }
+        }
+
+        Some(Commands::Inspect { file, json }) => {
+            if let Err(e) = cmd_inspect(&file, json) {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::Docs { topic: None }) => run_docs(cli.lang.as_deref(), &[]),
+        Some(Commands::Docs { topic: Some(DocsTopic::Lib { name, markdown, json, print }) }) => {
+            if let Err(e) = cmd_docs_lib(&name, cli.lang.as_deref(), markdown, json, print) { fail(e); }
+        }
+        Some(Commands::Docs { topic: Some(DocsTopic::Search { query, limit, json, open }) }) => {
+            if let Err(e) = cmd_docs_search(&query.join(" "), cli.lang.as_deref(), limit, json, open) { fail(e); }
+        }
+
+        Some(Commands::Json { action }) => run_query(action, None),
+        Some(Commands::Yaml { action }) => run_query(action, Some(hl_core::query::Format::Yaml)),
+
+        Some(Commands::Desktop { args }) => match hl_core::desktop::run(&args) {
+            Ok(out) => print!("{}", out),
+            Err(e)  => fail(e),
+        },
+
+        Some(Commands::Explain { code }) => {
+            if let Err(e) = cmd_explain(code.as_deref()) { fail(e); }
+        }
 
         Some(Commands::Version) => print_version(),
 
+        Some(Commands::Completions { shell }) => {
+            print!("{}", hl_core::complete::script(&shell).unwrap_or_default());
+        }
+
+        Some(Commands::Complete { words }) => {
+            for c in complete_words(&words) { println!("{}", c); }
+        }
+
         Some(Commands::Env { action }) => {
             match action {
                 None | Some(EnvAction::Help) => {
@@ -222,43 +1098,249 @@ fn main() -> Result<()> {
                 }
                 Some(EnvAction::Create { name }) => {
                     if let Err(e) = cmd_env_create(&name) {
-                        eprintln!("{} {}", "BŁĄD".red().bold(), e);
-                        std::process::exit(1);
+                        report_error(&e);
+                        exit_with(1);
                     }
                 }
                 Some(EnvAction::Enter { name }) => {
                     if let Err(e) = cmd_env_enter(name.as_deref()) {
-                        eprintln!("{} {}", "BŁĄD".red().bold(), e);
-                        std::process::exit(1);
+                        report_error(&e);
+                        exit_with(1);
                     }
                 }
                 Some(EnvAction::Exit) => {
                     if let Err(e) = cmd_env_exit() {
-                        eprintln!("{} {}", "BŁĄD".red().bold(), e);
-                        std::process::exit(1);
+                        report_error(&e);
+                        exit_with(1);
                     }
                 }
                 Some(EnvAction::Remove { name }) => {
                     if let Err(e) = cmd_env_remove(&name) {
-                        eprintln!("{} {}", "BŁĄD".red().bold(), e);
-                        std::process::exit(1);
+                        report_error(&e);
+                        exit_with(1);
                     }
                 }
                 Some(EnvAction::List) => {
                     if let Err(e) = cmd_env_list() {
-                        eprintln!("{} {}", "BŁĄD".red().bold(), e);
-                        std::process::exit(1);
+                        report_error(&e);
+                        exit_with(1);
                     }
                 }
                 Some(EnvAction::Status) => {
                     if let Err(e) = cmd_env_status() {
-                        eprintln!("{} {}", "BŁĄD".red().bold(), e);
-                        std::process::exit(1);
+                        report_error(&e);
+                        exit_with(1);
                     }
                 }
             }
         }
 
+        Some(Commands::Ci { action: CiAction::Init { provider, force } }) => {
+            if let Err(e) = cmd_ci_init(&provider, force) {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::Deploy { target: DeployTarget::Systemd {
+            name, script, user, install, on_calendar, env_file, restart, no_sandbox,
+        } }) => {
+            let opts = SystemdOptions {
+                name, script, user, install, on_calendar, env_file, restart,
+                sandbox: !no_sandbox,
+            };
+            if let Err(e) = cmd_deploy_systemd(&opts) {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::Schedule { action }) => {
+            let res = match action {
+                ScheduleAction::Add { spec, script, name } => cmd_schedule_add(&spec, &script, name.as_deref()),
+                ScheduleAction::List                       => cmd_schedule_list(),
+                ScheduleAction::Remove { name }            => cmd_schedule_remove(&name),
+            };
+            if let Err(e) = res {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::Export { target: ExportTarget::Docker { script, bin, base, out, build } }) => {
+            // Bez skryptu: program z --bin albo każdy program z [bins] we własnym kontekście
+            let targets = match script {
+                Some(script) => vec![DockerExportOptions { script, base, out, build }],
+                None => {
+                    let project = hl_core::project::current_project().unwrap_or_else(|e| fail(e));
+                    let bins = project.select(bin.as_deref()).unwrap_or_else(|e| fail(e));
+                    let single = bin.is_some();
+                    bins.into_iter().map(|b| DockerExportOptions {
+                        script: b.script.clone(),
+                        base:   base.clone(),
+                        out:    if single { out.clone() } else { out.join(&b.name) },
+                        build:  build.as_deref().map(|t| if single { t.to_string() } else { hl_core::export::bin_tag(t, &b.name) }),
+                    }).collect()
+                }
+            };
+            for opts in &targets {
+                if let Err(e) = cmd_export_docker(opts) {
+                    report_error(&e);
+                    exit_with(1);
+                }
+            }
+        }
+
+        Some(Commands::Serve { config, bind }) => {
+            if let Err(e) = cmd_serve(config.as_deref(), bind.as_deref()) {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::History { action, script, failed, limit }) => {
+            let res = match action {
+                None                             => cmd_history_list(script.as_deref(), failed, limit),
+                Some(HistoryAction::Show { id }) => cmd_history_show(id),
+                Some(HistoryAction::Rerun { id }) => match cmd_history_rerun(id) {
+                    Ok(code) => exit_with(code),
+                    Err(e)   => Err(e),
+                },
+            };
+            if let Err(e) = res {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::BugReport { output, command }) => {
+            if let Err(e) = cmd_bug_report(output.as_deref(), &command) { fail(e); }
+        }
+
+        Some(Commands::VerifyInstall { keep }) => {
+            match hl_core::verify_install::cmd_verify_install(keep) {
+                Ok(code) => exit_with(code),
+                Err(e)   => fail(e),
+            }
+        }
+
+        Some(Commands::Config { action: ConfigAction::Validate { file } }) => {
+            // Bez pliku: config.hk użytkownika i bit.hk projektu, jeśli jest
+            let paths = match file {
+                Some(f) => vec![f],
+                None => std::iter::once(config_path())
+                    .chain(std::env::current_dir().ok().and_then(|d| hl_core::project::find_project(&d)))
+                    .collect(),
+            };
+            let mut ok = true;
+            for path in paths {
+                match cmd_config_validate(&path) {
+                    Ok(valid) => ok &= valid,
+                    Err(e)    => { report_error(&e); exit_with(1); }
+                }
+            }
+            if !ok { exit_with(1); }
+        }
+
+        Some(Commands::Config { action }) => {
+            let res = match action {
+                ConfigAction::Get { key }                => cmd_config_get(&key),
+                ConfigAction::Set { key, value, force }  => cmd_config_set(&key, &value, force).map(|_| true),
+                ConfigAction::List                       => cmd_config_list().map(|_| true),
+                ConfigAction::Edit                       => cmd_config_edit(),
+                ConfigAction::Validate { .. }            => unreachable!(),
+            };
+            match res {
+                Ok(true)  => {}
+                Ok(false) => exit_with(1),
+                Err(e)    => { report_error(&e); exit_with(1); }
+            }
+        }
+
+        Some(Commands::Changelog { all, refresh, quiet }) => {
+            if let Err(e) = hl_core::changelog::cmd_changelog(all, refresh, quiet) { fail(e); }
+        }
+
+        Some(Commands::Examples { action }) => match action {
+            ExamplesAction::List { refresh } => {
+                if let Err(e) = hl_core::examples::cmd_examples_list(refresh) { fail(e); }
+            }
+            ExamplesAction::Show { name } => {
+                if let Err(e) = hl_core::examples::cmd_examples_show(&name) { fail(e); }
+            }
+            ExamplesAction::Run { name, isolated, args } => {
+                match hl_core::examples::cmd_examples_run(&name, isolated, &args) {
+                    Ok(code) => exit_with(code),
+                    Err(e)   => fail(e),
+                }
+            }
+        },
+
+        Some(Commands::Learn { lesson, check, reset }) => {
+            let res = match lesson {
+                None                => hl_core::learn::cmd_learn_list().map(|_| true),
+                Some(id) if reset   => hl_core::learn::cmd_learn_reset(&id).map(|_| true),
+                Some(id)            => hl_core::learn::cmd_learn(&id, check),
+            };
+            match res {
+                Ok(true)  => {}
+                Ok(false) => exit_with(exit::FAILURE),
+                Err(e)    => fail(e),
+            }
+        }
+
+        Some(Commands::Telemetry { action }) => {
+            let res = match action {
+                TelemetryAction::On     => hl_core::telemetry::cmd_telemetry_on(),
+                TelemetryAction::Off    => hl_core::telemetry::cmd_telemetry_off(),
+                TelemetryAction::Status => hl_core::telemetry::cmd_telemetry_status(),
+                TelemetryAction::Send   => hl_core::telemetry::cmd_telemetry_send(),
+            };
+            if let Err(e) = res { fail(e); }
+        }
+
+        Some(Commands::New { kind }) => {
+            let res = match kind {
+                NewCommand::Lib { name } => cmd_new(NewKind::Lib, &name),
+                NewCommand::App { name } => cmd_new(NewKind::App, &name),
+            };
+            if let Err(e) = res {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::Migrate { dir, write }) => {
+            match hl_core::project::cmd_migrate(&dir.unwrap_or_else(|| PathBuf::from(".")), write) {
+                Ok(true)  => {}
+                Ok(false) => exit_with(1),
+                Err(e)    => fail(e),
+            }
+        }
+
+        Some(Commands::Sign { file, verify }) => {
+            let res = if verify { cmd_verify(&file) } else { cmd_sign(&file).map(|_| ()) };
+            if let Err(e) = res { fail(e); }
+        }
+
+        Some(Commands::Trust { action }) => {
+            let res = match action {
+                TrustAction::Add { pubkey, name } => cmd_trust_add(&pubkey, name.as_deref()),
+                TrustAction::List                 => cmd_trust_list(),
+            };
+            if let Err(e) = res {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::Paths) => {
+            if let Err(e) = cmd_paths() {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
         Some(Commands::GenInfo { file }) => {
             let source = std::fs::read_to_string(&file)?;
             let meta   = parse_source_with_meta(&source)?;
@@ -274,72 +1356,110 @@ fn main() -> Result<()> {
 
         Some(Commands::Repl) => {
             let mut env = Env::new();
-            run_interactive(&mut env)?;
+            if let Err(e) = run_interactive(&mut env) { fail(e); }
         }
 
         Some(Commands::Shell { config, command }) => {
             let mut env = Env::new();
             if let Some(cmd) = command {
-                std::process::exit(run_source_with_diag("<shell -c>", &cmd, &mut env));
+                if let Err(e) = hl_core::signing::enforce_signed_inline("hl shell -c") { fail(e); }
+                exit_with(eval_source("<shell -c>", &cmd, &mut env));
             }
-            run_as_shell(config.as_deref(), &mut env)?;
+            if let Err(e) = run_as_shell(config.as_deref(), &mut env) { fail(e); }
         }
 
         // ── hl run ───────────────────────────────────────────────────────────
         // Domyślnie: tree-walk interpreter (sprawdzony, poprawnie obsługuje @VAR)
         // --jit: eksperymentalny JIT pipeline (compile→cache→bytecode)
-        Some(Commands::Run { file, jit, args }) => {
-            let exit_code = if jit && file.extension().and_then(|e| e.to_str()) != Some("bc") {
+        Some(Commands::Run { file, jit, host, hosts_file, trust, yes, vars, explain, minify, inline, check_mode, record, features, env_name, args }) => {
+            // Ustawione przed resolve_entry — overlay środowiska może zmienić entry
+            if let Some(name) = &env_name { std::env::set_var(hl_core::project::ENV_VAR, name); }
+            let spec = file.to_str().filter(|s| is_remote_spec(s)).map(str::to_string);
+            let file = match &spec {
+                Some(s) => parse_remote_spec(s).and_then(|r| fetch_remote(&r)).unwrap_or_else(|e| fail(e)),
+                None    => file,
+            };
+            let file = resolve_entry(&file);
+            if let Some(name) = &env_name {
+                if let Err(e) = hl_core::project::check_env(&file, name) { fail(e); }
+            }
+            if !features.is_empty() { enable_features(&file, &features); }
+            if explain {
+                let source = std::fs::read_to_string(&file).unwrap_or_else(|e| fail(e.into()));
+                let name = file.display().to_string();
+                let opts = hl_core::plan::ExplainOptions { minify, inline };
+                print!("{}", hl_core::plan::explain_source_with(&name, &source, opts).unwrap_or_else(|e| fail(e)));
+                return Ok(());
+            }
+            if let Some(s) = &spec {
+                match review_remote(s, &file, yes) {
+                    Ok(true)  => {}
+                    Ok(false) => { eprintln!("  Anulowano."); exit_with(exit::CANCELLED); }
+                    Err(e)    => fail(e),
+                }
+            }
+            if !host.is_empty() || hosts_file.is_some() {
+                if record.is_some() { fail(anyhow::anyhow!("--record działa tylko dla uruchomień lokalnych")); }
+                exit_with(run_file_remote(&file, host, hosts_file.as_deref(), &args));
+            }
+            enforce_signature(&file);
+            if !trust { enforce_manifest(&file); }
+            grant_desktop(&file);
+            enforce_compat(&file);
+            if let Some(rec) = record {
+                // Potomek `hl run` sam zapisuje dziennik i powiadomienia
+                let code = hl_core::record::record_session(&rec, &file, &args, &vars, jit).unwrap_or_else(|e| fail(e));
+                exit_with(code);
+            }
+            let is_bc = file.extension().and_then(|e| e.to_str()) == Some("bc");
+            if check_mode {
+                if jit || is_bc { fail(anyhow::anyhow!("--check-mode działa tylko z interpreterem (bez --jit i .bc)")); }
+                hl_core::checkmode::set_check_mode(true);
+            }
+            prefetch_imports(&file);
+            let t0 = Instant::now();
+            let exit_code = if jit && !is_bc {
                 // JIT pipeline — tylko gdy jawnie włączony i plik nie jest .bc
                 run_file_jit(&file, &args, cli.verbose)
-            } else if file.extension().and_then(|e| e.to_str()) == Some("bc") {
+            } else if is_bc {
                 // .bc plik — zawsze przez JIT interpreter
                 run_bc_direct(&file, &args)
             } else {
                 // Tree-walk interpreter — domyślny, stabilny
                 let mut env = Env::new();
                 inject_args(&mut env, &args);
+                apply_run_vars(&mut env, &file, &vars).unwrap_or_else(|e| fail(e));
                 run_file_with_diag(&file, &mut env, cli.verbose)
             };
-            std::process::exit(exit_code);
+            if check_mode {
+                // Podgląd — nie trafia do dziennika ani powiadomień
+                hl_core::checkmode::print_check_summary();
+                exit_with(exit_code);
+            }
+            record_run(&file, &args, exit_code, t0.elapsed());
+            notify_run_finished(&RunSummary { script: &file, exit_code, elapsed: t0.elapsed() });
+            exit_with(exit_code);
         }
 
-        Some(Commands::Check { file, meta: show_meta }) => {
-            let source = std::fs::read_to_string(&file)?;
-            let fname  = file.file_name().and_then(|n| n.to_str()).unwrap_or("<unknown>");
-            let renderer = DiagRenderer::new(fname, &source);
-            let mut exit_code = 0i32;
+        Some(Commands::Check { file, meta: show_meta, security, format, fix, migrate }) => {
+            let format = DiagFormat::from_str(&format).unwrap_or(DiagFormat::Text);
+            let opts = CheckOptions { meta: show_meta, security, fix, migrate, text: format == DiagFormat::Text };
+            let files = if file.is_dir() { project_hl_files(&file) } else { vec![file.clone()] };
+            if files.is_empty() {
+                eprintln!("{} brak plików .hl w {}", "hl check:".bright_magenta().bold(), file.display());
+            }
 
-            let mut lint_diags = lint_source(&source);
-            lint_diags.extend(lint_gen(&source));
-
-            if !lint_diags.is_empty() {
-                renderer.emit_all(&lint_diags);
-                let sum = DiagSummary::from_diags(&lint_diags);
-                sum.print();
-                if sum.has_errors() { exit_code = 2; }
-            }
-
-            if exit_code == 0 {
-                match parse_source_with_meta(&source) {
-                    Ok(meta) => {
-                        println!("{} {} ({} węzłów, gen {}, {} ostrzeżeń)",
-                                 "OK".green().bold(),
-                                 file.display().to_string().bright_white(),
-                                 meta.nodes.len(),
-                                 meta.gen.number(),
-                                 lint_diags.len());
-                        if show_meta {
-                            println!("  Gen:     {}", format!("gen {}", meta.gen.number()).bright_magenta());
-                            if let Some(sb) = &meta.shebang {
-                                println!("  Shebang: {}", sb.raw.bright_black());
-                            }
-                        }
-                    }
-                    Err(e) => { renderer.emit(&parse_error_to_diag(&e)); exit_code = 1; }
-                }
+            let mut out = format.renderer(file.is_dir());
+            let mut exit_code = 0i32;
+            let mut reports = Vec::with_capacity(files.len());
+            for f in &files {
+                let f = f.strip_prefix(".").unwrap_or(f);
+                let (code, diags) = check_file(f, &opts, out.as_mut()).unwrap_or_else(|e| fail(e));
+                if exit_code == 0 { exit_code = code; }
+                reports.push((f.display().to_string(), diags));
             }
-            std::process::exit(exit_code);
+            out.finish(&reports)?;
+            exit_with(exit_code);
         }
 
         Some(Commands::Ast { file }) => {
@@ -349,22 +1469,64 @@ fn main() -> Result<()> {
                 Err(e) => {
                     let fname = file.file_name().and_then(|n| n.to_str()).unwrap_or("<unknown>");
                     DiagRenderer::new(fname, &source).emit(&parse_error_to_diag(&e));
-                    std::process::exit(1);
+                    exit_with(exit::SOURCE);
                 }
             }
         }
 
-        Some(Commands::Clean) => {
-            cmd_clean_cache();
-            match hl_compiler::cache::cache_clean_all() {
-                Ok(n) if n > 0 => println!("{} Usunięto {} plików .bc z cache.", "✓".green(), n),
-                Ok(_)          => println!("{}", "Cache .bc jest pusty.".bright_black()),
-                Err(e)         => eprintln!("{} Błąd czyszczenia cache .bc: {}", "✗".red(), e),
+        Some(Commands::Clean { dry_run, older_than }) => {
+            let age = match older_than.as_deref().map(parse_age).transpose() {
+                Ok(a)  => a,
+                Err(e) => { report_error(&e); exit_with(1); }
+            };
+            if age.is_none() && !dry_run {
+                cmd_clean_cache();
+                match hl_compiler::cache::cache_clean_all() {
+                    Ok(n) if n > 0 => println!("{} Usunięto {} plików .bc z cache.", "✓".green(), n),
+                    Ok(_)          => println!("{}", "Cache .bc jest pusty.".bright_black()),
+                    Err(e)         => eprintln!("{} Błąd czyszczenia cache .bc: {}", "✗".red(), e),
+                }
+            } else if dry_run && age.is_none() {
+                println!("  {} cache bibliotek i .bc zostałby wyczyszczony", "~".bright_yellow());
+            }
+            if let Err(e) = cmd_clean_temp(dry_run, age) {
+                report_error(&e);
+                exit_with(1);
+            }
+        }
+
+        Some(Commands::Cache { action }) => {
+            let res = match action {
+                CacheAction::Info                       => cmd_cache_info(),
+                CacheAction::Clean { category, dry_run } => cmd_cache_clean(category.as_deref(), dry_run),
+                CacheAction::Gc { max_size, dry_run }    => cmd_cache_gc(max_size.as_deref(), dry_run),
+                CacheAction::Verify { fix } => {
+                    match cmd_cache_verify(fix, |p| hl_compiler::read_bc_file(p).map(|_| ())) {
+                        Ok(0)  => Ok(()),
+                        Ok(_)  => exit_with(1),
+                        Err(e) => Err(e),
+                    }
+                }
+            };
+            if let Err(e) = res {
+                report_error(&e);
+                exit_with(1);
             }
         }
 
         Some(Commands::CacheInfo) => {
-            hl_jit::runner::print_cache_stats();
+            if let Err(e) = cmd_cache_info() { fail(e); }
+        }
+
+        Some(Commands::Lib { action: Some(LibAction::Pin { file }) }) => {
+            match hl_core::libs::pin_url_imports(&file) {
+                Ok(0) => println!("{}", "Wszystkie importy z URL są przypięte.".bright_black()),
+                Ok(n) => println!("{} przypięto {} import(ów) z URL w {}", "✓".green().bold(), n, file.display()),
+                Err(e) => {
+                    report_error(&e);
+                    exit_with(exit_code_for(&e, exit::FAILURE));
+                }
+            }
         }
 
         Some(Commands::Lib { .. }) => {
@@ -392,24 +1554,36 @@ fn main() -> Result<()> {
 
         None => {
             if let Some(code) = cli.inline_code {
+                if let Err(e) = hl_core::signing::enforce_signed_inline("hl -c") { fail(e); }
                 let mut env = Env::new();
                 inject_args(&mut env, &cli.script_args);
-                std::process::exit(run_source_with_diag("<inline>", &code, &mut env));
+                exit_with(eval_source("<inline>", &code, &mut env));
             } else if let Some(file) = cli.file {
+                let file = resolve_entry(&file);
                 if !file.exists() {
-                    eprintln!("{} Plik nie istnieje: {}", "BŁĄD".red().bold(), file.display());
-                    std::process::exit(1);
+                    report_error(tf("file.missing", &[&file.display()]));
+                    exit_with(1);
                 }
+                enforce_signature(&file);
+                enforce_manifest(&file);
+                grant_desktop(&file);
+                enforce_compat(&file);
+                prefetch_imports(&file);
                 // .bc → JIT, wszystko inne → tree-walk
-                if file.extension().and_then(|e| e.to_str()) == Some("bc") {
-                    std::process::exit(run_bc_direct(&file, &cli.script_args));
-                }
-                let mut env = Env::new();
-                inject_args(&mut env, &cli.script_args);
-                std::process::exit(run_file_with_diag(&file, &mut env, cli.verbose));
+                let t0 = Instant::now();
+                let exit_code = if file.extension().and_then(|e| e.to_str()) == Some("bc") {
+                    run_bc_direct(&file, &cli.script_args)
+                } else {
+                    let mut env = Env::new();
+                    inject_args(&mut env, &cli.script_args);
+                    run_file_with_diag(&file, &mut env, cli.verbose)
+                };
+                record_run(&file, &cli.script_args, exit_code, t0.elapsed());
+                notify_run_finished(&RunSummary { script: &file, exit_code, elapsed: t0.elapsed() });
+                exit_with(exit_code);
             } else {
                 let mut env = Env::new();
-                run_interactive(&mut env)?;
+                if let Err(e) = run_interactive(&mut env) { fail(e); }
             }
         }
     }
@@ -417,12 +1591,302 @@ fn main() -> Result<()> {
     Ok(())
 }
 
+// ── hl run . ──────────────────────────────────────────────────────────────────
+// Katalog projektu → plik wejściowy, w tej samej kolejności co bit run:
+// [project] entry z bit.hk, source-code/main.hl, src/main.hl, main.hl
+// (project::detect_entry). Nazwa programu z [bins] w bit.hk (gdy nie ma
+// takiego pliku) → jego skrypt.
+
+fn resolve_entry(path: &Path) -> PathBuf {
+    if let Some(script) = hl_core::project::bin_script(path).unwrap_or_else(|e| fail(e)) { return script; }
+    if !path.is_dir() { return path.to_path_buf(); }
+    match hl_core::project::detect_entry(path).unwrap_or_else(|e| fail(e)) {
+        Some(entry) => entry,
+        None => {
+            report_error(tf("entry.missing", &[&path.display(), &hl_core::project::PROJECT_FILE,
+                                                &hl_core::project::ENTRY_CANDIDATES.join(" / ")]));
+            exit_with(exit::USAGE);
+        }
+    }
+}
+
+// ── hl check ──────────────────────────────────────────────────────────────────
+// Plik albo katalog projektu (wszystkie .hl poza wzorcami .hackerignore).
+
+struct CheckOptions { meta: bool, security: bool, fix: bool, migrate: bool, text: bool }
+
+/// Sprawdź jeden plik; diagnostyki każdego etapu idą od razu do `out`
+fn check_file(file: &Path, opts: &CheckOptions, out: &mut dyn CheckRenderer) -> Result<(i32, Vec<hl_core::Diag>)> {
+    if opts.fix || opts.migrate {
+        let (label, result) = if opts.migrate { ("hl check --migrate:", migrate_file(file)?) } else { ("hl check --fix:", fix_file(file)?) };
+        match result {
+            (applied, Some(backup)) => {
+                eprintln!("{} {} poprawek → {} (kopia: {})", label.bright_magenta().bold(),
+                          applied.len(), file.display().to_string().bright_white(), backup.display());
+                for d in &applied {
+                    let at = d.span.as_ref().map(|s| format!("{}:{}", s.line, s.col)).unwrap_or_default();
+                    eprintln!("  {} {:<7} {}  {}", "✓".green(), at, d.code.unwrap_or("").bright_cyan(), d.message);
+                }
+            }
+            _ => eprintln!("{} {} — brak poprawek do zastosowania", label.bright_magenta().bold(), file.display()),
+        }
+    }
+    let source = std::fs::read_to_string(file)?;
+    let path   = file.display().to_string();
+    let text = opts.text;
+    let mut exit_code = 0i32;
+    let mut all_diags = Vec::new();
+
+    let mut lint_diags = lint_source(&source);
+    lint_diags.extend(lint_gen(&source));
+
+    if !lint_diags.is_empty() {
+        let sum = DiagSummary::from_diags(&lint_diags);
+        out.emit(&path, &source, &lint_diags);
+        if text { sum.print(); }
+        if sum.has_errors() { exit_code = exit::SOURCE; }
+    }
+    let lint_count = lint_diags.len();
+    all_diags.extend(lint_diags);
+
+    if exit_code == 0 {
+        match parse_source_with_meta(&source) {
+            Ok(meta) if text => {
+                println!("{} {} ({} węzłów, gen {}, {} ostrzeżeń)",
+                         "OK".green().bold(),
+                         file.display().to_string().bright_white(),
+                         meta.nodes.len(),
+                         meta.gen.number(),
+                         lint_count);
+                if opts.meta {
+                    println!("  Gen:     {}", format!("gen {}", meta.gen.number()).bright_magenta());
+                    if let Some(sb) = &meta.shebang {
+                        println!("  Shebang: {}", sb.raw.bright_black());
+                    }
+                }
+            }
+            Ok(_) => {}
+            Err(e) => {
+                let diag = parse_error_to_diag(&e);
+                out.emit(&path, &source, std::slice::from_ref(&diag));
+                all_diags.push(diag);
+                exit_code = exit::SOURCE;
+            }
+        }
+    }
+
+    if opts.security {
+        let policy = load_policy(file)?;
+        let sec = security_lint(&source, &policy);
+        let denied = count_denied(&sec);
+        out.emit(&path, &source, &sec);
+        if text {
+            println!("{} {} naruszeń (deny: {}){}",
+                     "security:".bright_magenta().bold(), sec.len(), denied,
+                     policy.source.map(|p| format!(", polityka: {}", p.display())).unwrap_or_default());
+        }
+        if denied > 0 && exit_code == 0 { exit_code = exit::DENIED; }
+        all_diags.extend(sec);
+    }
+    Ok((exit_code, all_diags))
+}
+
+// ── Manifest uprawnień ────────────────────────────────────────────────────────
+// Skrypt z `/// Requires: ...` nie wystartuje, jeśli używa czegoś spoza deklaracji.
+// Sprawdzana jest lista komend po lowerowaniu (ta sama, którą wykonuje bytecode),
+// dla skryptu i wszystkich importów/bibliotek; .bc niesie manifest w metadanych.
+// Kod exit::DENIED (126) — jak powłoka przy braku uprawnień do uruchomienia.
+
+fn is_bc_file(file: &Path) -> bool {
+    file.extension().and_then(|e| e.to_str()) == Some("bc")
+}
+
+/// Manifest skryptu — z bloku `///` albo z metadanych .bc; None — brak deklaracji
+fn script_manifest(file: &Path) -> Result<Option<Manifest>> {
+    if is_bc_file(file) {
+        let Ok((_, _, meta)) = hl_compiler::read_bc_header(file) else { return Ok(None) };
+        return meta.and_then(|m| m.requires).map(|items| Manifest::from_items(&items)).transpose();
+    }
+    let Ok(source) = std::fs::read_to_string(file) else { return Ok(None) };
+    parse_manifest(&source)
+}
+
+/// Komendy pliku po lowerowaniu i liczba goroutines; None — plik nie daje się wczytać
+fn lowered_steps(file: &Path) -> Option<(Vec<CmdStep>, usize, Option<String>)> {
+    let to_steps = |module: &hl_compiler::HlModule| hl_compiler::lowered_commands(module).into_iter()
+        .map(|c| CmdStep { cmd: c.cmd, sudo: c.sudo }).collect::<Vec<_>>();
+    if is_bc_file(file) {
+        let module = hl_compiler::read_bc_file(file).ok()?;
+        return Some((to_steps(&module), 0, None));
+    }
+    let source = std::fs::read_to_string(file).ok()?;
+    let meta = parse_source_with_meta(&source).ok()?;
+    let module = hl_compiler::lower_ast(&meta.nodes, file, meta.gen.number());
+    Some((to_steps(&module), goroutine_count(&meta.nodes), Some(source)))
+}
+
+/// Pliki do sprawdzenia: skrypt z importami i bibliotekami; dla .bc — moduł
+/// i źródła importów, które wczyta (`__hl_import__`)
+fn manifest_files(file: &Path) -> Vec<PathBuf> {
+    if !is_bc_file(file) {
+        return hl_core::graph::resolved_sources(file).unwrap_or_else(|_| vec![file.to_path_buf()]);
+    }
+    let mut files = vec![file.to_path_buf()];
+    let base = file.parent().unwrap_or(Path::new("."));
+    if let Some((steps, _, _)) = lowered_steps(file) {
+        for step in steps {
+            let Some(path) = step.cmd.strip_prefix("__hl_import__ ") else { continue };
+            if path.contains('@') { continue; }
+            let path = if path.contains('.') { path.to_string() } else { format!("{}.hl", path) };
+            let src = if base.join(&path).exists() { base.join(&path) } else { PathBuf::from(&path) };
+            for f in hl_core::graph::resolved_sources(&src).unwrap_or_default() {
+                if !files.contains(&f) { files.push(f); }
+            }
+        }
+    }
+    files
+}
+
+fn enforce_manifest(file: &Path) {
+    let manifest = match script_manifest(file) {
+        Ok(Some(m)) => m,
+        Ok(None)    => return,
+        Err(e)      => { report_error(&e); exit_with(exit::DENIED); }
+    };
+    let mut total = 0;
+    for f in manifest_files(file) {
+        let Some((steps, goroutines, source)) = lowered_steps(&f) else { continue };
+        let diags = manifest_violations(&steps, goroutines, &manifest, source.as_deref());
+        if diags.is_empty() { continue; }
+        let fname = f.display().to_string();
+        DiagRenderer::new(&fname, source.as_deref().unwrap_or("")).emit_all(&diags);
+        total += diags.len();
+    }
+    if total == 0 { return; }
+    report_error(tf("manifest.denied", &[&total, &"hl run --trust".bright_cyan()]));
+    exit_with(exit::DENIED);
+}
+
+/// Uprawnienia hl-desktop zadeklarowane w manifeście nie wymagają pytania
+fn grant_desktop(file: &Path) {
+    if let Ok(Some(manifest)) = script_manifest(file) {
+        hl_core::desktop::grant_from_manifest(&manifest);
+    }
+}
+
+// Zgodność ze środowiskiem — `/// MinHl:` / `/// RequiresOS:` sprawdzane przed startem,
+// zamiast błędu w połowie skryptu. Niespełnione wymaganie → exit::DEPENDENCY (4).
+fn enforce_compat(file: &Path) {
+    if file.extension().and_then(|e| e.to_str()) == Some("bc") { return; }
+    let Ok(source) = std::fs::read_to_string(file) else { return };
+    if let Err(e) = check_compat(&source) {
+        report_error(&e);
+        exit_with(exit_code_for(&e, exit::FAILURE));
+    }
+}
+
+// Biblioteki z URL pobierane równolegle przed startem; błędy zgłosi sam import.
+fn prefetch_imports(file: &Path) {
+    if file.extension().and_then(|e| e.to_str()) == Some("bc") { return; }
+    let Ok(source) = std::fs::read_to_string(file) else { return };
+    let _ = hl_core::libs::prefetch_url_libs(&source);
+}
+
+// Sudo pre-flight — jedno uwierzytelnienie przed startem zamiast pytań w trakcie skryptu.
+fn sudo_session_for(file: &Path) -> Option<SudoSession> {
+    let source = std::fs::read_to_string(file).ok()?;
+    let meta = parse_source_with_meta(&source).ok()?;
+    sudo_preflight(&meta.nodes)
+}
+
+// Tryb tylko-podpisane ([security] signed_only) — bez poprawnego podpisu nie uruchamiaj.
+// Tu tylko wczesna odmowa przed manifestem i --record; wykonywany bufor (skrypt,
+// .bc, --jit, importy, hl exec) i tak przechodzi przez hl_core::signing::read_trusted_bytes,
+// który sprawdza podpis na tych samych bajtach, które potem są parsowane.
+fn enforce_signature(file: &Path) {
+    if let Err(e) = hl_core::signing::enforce_signed(file) { fail(e); }
+}
+
 // ── hl compile ────────────────────────────────────────────────────────────────
 
-fn cmd_compile(file: &Path, output: Option<&Path>) -> Result<()> {
+fn cmd_inspect(file: &Path, json: bool) -> Result<()> {
+    use hl_core::inspect::{detect_kind, inspect_archive, inspect_elf, FileKind};
+    match detect_kind(file)? {
+        FileKind::Bytecode => {}
+        FileKind::Archive  => return inspect_archive(file, json),
+        FileKind::Elf      => return inspect_elf(file, json),
+        FileKind::Unknown  => anyhow::bail!("{}: nierozpoznany format — oczekiwano .bc, tar/.pkg lub ELF", file.display()),
+    }
+    let (ver, header, meta) = hl_compiler::read_bc_header(file)?;
+    let sha256 = hl_core::libs::sha256_file(file).ok();
+    if json {
+        println!("{}", serde_json::to_string_pretty(&serde_json::json!({
+            "bc_version": ver, "header": header, "meta": meta, "sha256": sha256,
+        }))?);
+        return Ok(());
+    }
+    println!("{} {}", "hl inspect:".bright_magenta().bold(), file.display().to_string().bright_white());
+    println!("  Format:      .bc v{}{}", ver,
+             if ver != hl_compiler::BC_VERSION { " (niezgodny z tym hl)".red().to_string() } else { String::new() });
+    if let Some(sha) = &sha256 { println!("  sha256:      {}", sha.bright_black()); }
+    println!("  Gen:         {}", format!("gen {}", header.hl_gen).bright_magenta());
+    println!("  Źródło:      {}", header.source_path);
+    println!("  Skompilowano: {} (unix)", header.compiled_at);
+    match meta {
+        Some(m) => {
+            let show = |label: &str, v: &Option<String>| {
+                println!("  {:<12} {}", label, v.as_deref().unwrap_or("-").bright_white());
+            };
+            show("Autor:", &m.author);
+            show("Wersja:", &m.version);
+            show("Opis:", &m.description);
+            println!("  {:<12} {}", "Zbudował:", m.built_by.bright_black());
+            println!("  {:<12} {}", "Kompilator:", m.compiler.bright_black());
+            if let Some(level) = m.opt_level {
+                println!("  {:<12} -O{}{}", "Build:", level, if m.stripped { ", stripped" } else { "" });
+            }
+            if !m.features.is_empty() {
+                println!("  {:<12} {}", "Cechy:", m.features.join(", ").bright_white());
+            }
+        }
+        None => println!("  {}", "Brak metadanych (plik skompilowany starszym hl)".bright_black()),
+    }
+    Ok(())
+}
+
+/// Wczytaj zapisany .bc ponownie i pokaż jego właściwości
+fn report_bc(path: &Path) -> Result<()> {
+    let module = hl_compiler::read_bc_file(path)
+        .map_err(|e| anyhow::anyhow!("zapisany .bc nie wczytuje się: {}", e))?;
+    let (_, _, meta) = hl_compiler::read_bc_header(path)?;
+    let size = std::fs::metadata(path)?.len();
+    let meta = meta.unwrap_or_default();
+    println!("  {} · {} instrukcji · {} funkcji · -O{}{}",
+             hl_core::tmp::human_size(size), module.instructions.len(), module.funcs.entries.len(),
+             meta.opt_level.unwrap_or(1), if meta.stripped { " · stripped" } else { "" });
+    if !meta.features.is_empty() { println!("  cechy: {}", meta.features.join(", ")); }
+    Ok(())
+}
+
+/// `hl run --features` — sprawdź nazwy z `/// Features:` i przekaż przez HL_FEATURES
+/// (interpreter, cache .bc i potomne `hl run` czytają tę zmienną)
+fn enable_features(file: &Path, features: &[String]) {
+    use hl_parser::features::{declared_features, FEATURES_ENV};
+    let source = std::fs::read_to_string(file).unwrap_or_default();
+    if let Some((_, declared)) = declared_features(&source) {
+        if let Some(bad) = features.iter().find(|f| !declared.contains(f)) {
+            report_error(tf("feature.undeclared", &[&bad, &declared.join(", ")]));
+            exit_with(exit::USAGE);
+        }
+    }
+    std::env::set_var(FEATURES_ENV, features.join(","));
+}
+
+fn cmd_compile(file: &Path, output: Option<&Path>, opts: hl_compiler::CompileOptions,
+               provenance: bool, sign: bool) -> Result<()> {
     if !file.exists() {
-        eprintln!("{} Plik nie istnieje: {}", "BŁĄD".red().bold(), file.display());
-        std::process::exit(1);
+        report_error(tf("file.missing", &[&file.display()]));
+        exit_with(1);
     }
 
     let ext = file.extension().and_then(|e| e.to_str()).unwrap_or("");
@@ -434,17 +1898,29 @@ fn cmd_compile(file: &Path, output: Option<&Path>) -> Result<()> {
                       file.display().to_string().bright_white());
 
             let t0 = std::time::Instant::now();
-            match hl_compiler::compile_hl_to_bc(file, output) {
+            let compiled = std::fs::read_to_string(file).map_err(anyhow::Error::from)
+                .and_then(|src| hl_compiler::compile_source_with(&src, file, output, &opts));
+            match compiled {
                 Ok(bc_path) => {
                     let elapsed = t0.elapsed();
                     println!("{} {} ({:.1}ms)",
                              "✓".green().bold(),
                              bc_path.display().to_string().bright_white(),
                              elapsed.as_secs_f64() * 1000.0);
+                    report_bc(&bc_path)?;
+                    if provenance {
+                        let params = serde_json::json!({ "opt_level": opts.opt_level, "stripped": opts.strip, "features": opts.features });
+                        let prov = hl_core::write_provenance(&bc_path, file, params).unwrap_or_else(|e| fail(e));
+                        println!("{} {}", "✓".green(), prov.display().to_string().bright_white());
+                        if sign {
+                            cmd_sign(&bc_path).unwrap_or_else(|e| fail(e));
+                            cmd_sign(&prov).unwrap_or_else(|e| fail(e));
+                        }
+                    }
                 }
                 Err(e) => {
                     eprintln!("{} {}", "BŁĄD kompilacji:".red().bold(), e);
-                    std::process::exit(1);
+                    exit_with(1);
                 }
             }
         }
@@ -453,11 +1929,11 @@ fn cmd_compile(file: &Path, output: Option<&Path>) -> Result<()> {
                       "hl compile:".bright_magenta().bold());
             eprintln!("  Użyj {} aby uruchomić bytecode.",
                       "hl run plik.bc".bright_cyan());
-            std::process::exit(1);
+            exit_with(1);
         }
         other => {
-            eprintln!("{} Nieznane rozszerzenie: .{}", "BŁĄD".red().bold(), other);
-            std::process::exit(1);
+            report_error(tf("file.ext", &[&other]));
+            exit_with(1);
         }
     }
 
@@ -472,7 +1948,7 @@ fn run_bc_direct(file: &Path, args: &[String]) -> i32 {
         Ok(code) => code,
         Err(e) => {
             eprintln!("{} {}", "BŁĄD .bc:".red().bold(), e);
-            1
+            exit_code_for(&e, exit::EXECUTION)
         }
     }
 }
@@ -491,6 +1967,23 @@ fn run_file_jit(file: &Path, args: &[String], _verbose: bool) -> i32 {
     }
 }
 
+/// Uruchom plik zdalnie na hostach przez SSH (hl run --host)
+fn run_file_remote(file: &Path, mut hosts: Vec<String>, hosts_file: Option<&Path>, args: &[String]) -> i32 {
+    if let Some(hf) = hosts_file {
+        match hl_core::load_hosts_file(hf) {
+            Ok(more) => hosts.extend(more),
+            Err(e)   => { report_error(&e); return 1; }
+        }
+    }
+    match hl_core::run_remote(&hosts, file, args) {
+        Ok(results) => {
+            hl_core::print_remote_summary(&results);
+            if results.iter().all(|r| r.exit_code == 0) { 0 } else { 1 }
+        }
+        Err(e) => { report_error(&e); 1 }
+    }
+}
+
 // ── hl exec ───────────────────────────────────────────────────────────────────
 
 fn cmd_exec(name: &str, args: &[String], verbose: bool) -> i32 {
@@ -515,9 +2008,8 @@ fn cmd_exec(name: &str, args: &[String], verbose: bool) -> i32 {
             run_file_with_diag(path, &mut env, verbose)
         }
         None => {
-            eprintln!("{} Skrypt '{}' nie znaleziony w {}",
-                      "BŁĄD".red().bold(), name.bright_white(), HL_SCRIPTS_DIR.bright_black());
-            eprintln!("  Użyj {} aby zobaczyć dostępne skrypty.", "hl search all".bright_cyan());
+            report_error(tf("script.missing", &[&name.bright_white(), &HL_SCRIPTS_DIR.bright_black()]));
+            eprintln!("{}", tf("script.hint", &[&"hl search all".bright_cyan()]));
             1
         }
     }
@@ -529,7 +2021,7 @@ fn cmd_search(query: &str) {
     let scripts_dir = Path::new(HL_SCRIPTS_DIR);
 
     if !scripts_dir.exists() {
-        eprintln!("{} Katalog skryptów nie istnieje: {}", "BŁĄD".red().bold(), HL_SCRIPTS_DIR.bright_black());
+        report_error(tf("scripts.missing", &[&HL_SCRIPTS_DIR.bright_black()]));
         return;
     }
 
@@ -547,7 +2039,7 @@ fn cmd_search(query: &str) {
         })
         .collect(),
         Err(e) => {
-            eprintln!("{} Nie można odczytać katalogu: {}", "BŁĄD".red().bold(), e);
+            report_error(tf("dir.unreadable", &[&e]));
             return;
         }
     };
@@ -611,7 +2103,7 @@ fn read_script_description(path: &Path) -> Option<String> {
 
 fn run_file_with_diag(file: &Path, env: &mut Env, verbose: bool) -> i32 {
     if !file.exists() {
-        eprintln!("{} Plik nie istnieje: {}", "BŁĄD".red().bold(), file.display());
+        report_error(tf("file.missing", &[&file.display()]));
         return 1;
     }
 
@@ -626,30 +2118,59 @@ fn run_file_with_diag(file: &Path, env: &mut Env, verbose: bool) -> i32 {
     }
 
     env.set_var("HL_SCRIPT", hl_core::Value::String(file.display().to_string()));
+    let _sudo = sudo_session_for(file);
 
     match hl_shell::run_file(file, env) {
         Ok(code) => code,
-        Err(e)   => { eprintln!("{} {}", "BŁĄD".red().bold(), e); 1 }
+        Err(e)   => { report_error(&e); exit_code_for(&e, exit::FAILURE) }
     }
 }
 
-fn run_source_with_diag(fname: &str, source: &str, env: &mut Env) -> i32 {
-    let renderer = DiagRenderer::new(fname, source);
-    let mut lint_diags = lint_source(source);
-    lint_diags.extend(lint_gen(source));
-    if !lint_diags.is_empty() {
-        renderer.emit_all(&lint_diags);
-        let sum = DiagSummary::from_diags(&lint_diags);
-        sum.print();
-        if sum.has_errors() { return 2; }
-    }
-    if let Err(e) = check_source(source) { renderer.emit(&parse_error_to_diag(&e)); return 2; }
-    match run_source(source, env) {
-        Ok(r)  => r.exit_code,
-        Err(e) => { renderer.emit(&hl_core::Diag::error(e.to_string())); 1 }
+/// Zakończ proces; z włączoną telemetrią najpierw zapisz rekord wywołania
+fn exit_with(code: i32) -> ! {
+    hl_core::telemetry::finish(code);
+    std::process::exit(code)
+}
+
+/// Nazwa podkomendy do telemetrii — nigdy argumenty; None dla ukrytych (__complete)
+fn telemetry_command_name() -> Option<String> {
+    let root = Cli::command();
+    let first = std::env::args().skip(1).find(|a| !a.starts_with('-'));
+    match first {
+        None => Some("shell".into()),
+        Some(w) => match root.find_subcommand(&w) {
+            Some(sub) if sub.is_hide_set() => None,
+            Some(sub) => Some(sub.get_name().to_string()),
+            None if w.ends_with(".hl") || w.ends_with(".bc") => Some("run".into()),
+            None => Some("other".into()),
+        },
     }
 }
 
+// ── Komunikaty BŁĄD / UWAGA ───────────────────────────────────────────────────
+// Bez --log-file i z --log-format json idą wyłącznie przez logger (zdarzenia
+// error / warn, bez kolorów) — wyjście diagnostyczne to same linie JSON.
+// Z --log-file trafiają na stderr i do pliku logów; w trybie text — na stderr.
+
+static LOG_MESSAGES: AtomicBool = AtomicBool::new(false);
+static STDERR_MESSAGES: AtomicBool = AtomicBool::new(true);
+
+fn report_error(msg: impl std::fmt::Display) {
+    if LOG_MESSAGES.load(Ordering::Relaxed) { tracing::error!("{}", msg); }
+    if STDERR_MESSAGES.load(Ordering::Relaxed) { eprintln!("{} {}", t("error").red().bold(), msg); }
+}
+
+fn report_warning(msg: impl std::fmt::Display) {
+    if LOG_MESSAGES.load(Ordering::Relaxed) { tracing::warn!("{}", msg); }
+    if STDERR_MESSAGES.load(Ordering::Relaxed) { eprintln!("{} {}", t("warning").yellow().bold(), msg); }
+}
+
+/// Wypisz błąd i zakończ kodem z jego klasy (exit.rs), domyślnie 1
+fn fail(e: anyhow::Error) -> ! {
+    report_error(&e);
+    exit_with(exit_code_for(&e, exit::FAILURE));
+}
+
 fn inject_args(env: &mut Env, args: &[String]) {
     env.set_var("argc", hl_core::Value::Number(args.len() as f64));
     for (i, arg) in args.iter().enumerate() {
@@ -661,17 +2182,143 @@ fn inject_args_env(env: &mut Env, args: &[String]) {
     inject_args(env, args);
 }
 
-fn run_docs() {
-    const DOCS_BIN: &str = "/usr/lib/HackerOS/Hacker-Lang/hl-docs";
+// ── Podpowiedzi powłoki ───────────────────────────────────────────────────────
+// Komendy i flagi z definicji clap; wartości (pliki, biblioteki, zadania,
+// środowiska) z hl_core::complete — zależnie od komendy i poprzedniej flagi.
+
+fn complete_words(words: &[String]) -> Vec<String> {
+    use hl_core::complete as c;
+    let (cur, done) = match words.split_last() {
+        Some((cur, done)) => (cur.as_str(), done),
+        None              => ("", &[][..]),
+    };
+    let root = Cli::command();
+    let mut cmd = &root;
+    let mut path: Vec<String> = Vec::new();
+    for w in done {
+        if let Some(sub) = cmd.find_subcommand(w) {
+            path.push(sub.get_name().to_string());
+            cmd = sub;
+        }
+    }
+    let path: Vec<&str> = path.iter().map(String::as_str).collect();
+    let prev = done.last().map(String::as_str).unwrap_or("");
+    let scripts = || c::files(cur, &["hl", "bc"]);
+
+    let mut out: Vec<String> = if cur.starts_with('-') {
+        cmd.get_arguments().chain(root.get_arguments().filter(|a| a.is_global_set()))
+            .filter(|a| !a.is_hide_set())
+            .filter_map(|a| a.get_long().map(|l| format!("--{}", l)))
+            .collect()
+    } else {
+        match (path.as_slice(), prev) {
+            (_, "--inventory" | "--hosts-file") => c::files(cur, &["hk"]),
+            (["rollout"], "--group")             => c::inventory_groups(),
+            (["state"], "--step")                => c::state_steps(),
+            (_, "--record") | (_, "--log-file")  => c::files(cur, &[]),
+            (["replay"], _)                      => c::files(cur, &["rec"]),
+            (["rollout"], _)                     => c::task_names().into_iter().chain(c::files(cur, &["hl"])).collect(),
+            (["rollback"], _)                    => c::rollback_ids(),
+            (["compile"] | ["export", _], "--bin") => c::bin_names(),
+            (["fetch"], "--group")               => c::group_names(),
+            (["run"], "--env")                   => c::project_envs(),
+            (["script"], _)                      => c::script_names(),
+            (["run"], _)                         => c::bin_names().into_iter().chain(scripts()).collect(),
+            (["learn"], _)                       => c::lesson_ids(),
+            (["config", "get" | "set"], "get" | "set") => c::config_keys(),
+            (["exec"], _)                        => c::exec_names(Path::new(HL_SCRIPTS_DIR)),
+            (["lib", "remove" | "info"], _)      => c::lib_names(),
+            (["env", "enter" | "remove"], _)     => c::env_names(),
+            (["run" | "check" | "compile" | "verify" | "ast" | "gen-info" | "inspect" | "graph"
+              | "status" | "sign"], _) => scripts(),
+            (["json" | "yaml", _], _)            => c::files(cur, &["json", "yaml", "yml"]),
+            _ if cmd.has_subcommands() => cmd.get_subcommands()
+                .filter(|s| !s.is_hide_set())
+                .map(|s| s.get_name().to_string())
+                .chain(if path.is_empty() { scripts() } else { Vec::new() })
+                .collect(),
+            _ => c::files(cur, &[]),
+        }
+    };
+    out.retain(|w| w.starts_with(cur));
+    out
+}
+
+fn run_query(action: QueryAction, forced: Option<hl_core::query::Format>) {
+    use hl_core::query::{self as q, Format};
+    let res = match action {
+        QueryAction::Get { path, file, compact } =>
+            q::cmd_get(&path, file.as_deref(), Format::detect(forced, file.as_deref()), compact),
+        QueryAction::Keys { path, file } => q::cmd_keys(&path, file.as_deref(), Format::detect(forced, file.as_deref())),
+        QueryAction::Has { path, file }  => q::cmd_has(&path, file.as_deref(), Format::detect(forced, file.as_deref())),
+        QueryAction::Len { path, file }  => q::cmd_len(&path, file.as_deref(), Format::detect(forced, file.as_deref())),
+        QueryAction::Set { path, value, file } => q::cmd_set(&path, &value, &file, Format::detect(forced, Some(&file))),
+        QueryAction::Merge { files, compact }  => q::cmd_merge(&files, forced, compact),
+    };
+    match res {
+        Ok(code) => exit_with(code),
+        Err(e)   => fail(e),
+    }
+}
+
+const DOCS_BIN: &str = "/usr/lib/HackerOS/Hacker-Lang/hl-docs";
+
+fn run_docs(lang: Option<&str>, extra: &[String]) {
     if !std::path::Path::new(DOCS_BIN).exists() {
         eprintln!("{} Binarka hl-docs nie znaleziona.", "hl docs:".bright_magenta().bold());
         eprintln!("  Oczekiwana ścieżka: {}", DOCS_BIN.bright_white());
         eprintln!("  Zainstaluj: {}", "sudo hl-docs-install".bright_cyan());
-        std::process::exit(1);
+        exit_with(exit::TOOLCHAIN);
+    }
+    let mut cmd = std::process::Command::new(DOCS_BIN);
+    if let Some(l) = lang { cmd.args(["--lang", l]); }
+    cmd.args(extra);
+    let status = cmd.status()
+    .unwrap_or_else(|e| { report_error(&e); exit_with(1); });
+    exit_with(status.code().unwrap_or(0));
+}
+
+/// hl docs lib — strona w TUI na terminalu, inaczej tekst / markdown / JSON
+fn cmd_docs_lib(name: &str, lang: Option<&str>, markdown: bool, json: bool, print: bool) -> Result<()> {
+    use hl_core::libdoc;
+    use std::io::IsTerminal;
+    let (source, src) = libdoc::find_source(name)?;
+    let doc = libdoc::extract(name, &source, &src);
+    if json {
+        println!("{}", serde_json::to_string_pretty(&doc)?);
+    } else if markdown {
+        print!("{}", libdoc::render_markdown(&doc));
+    } else if !print && std::io::stdout().is_terminal() && Path::new(DOCS_BIN).exists() {
+        // Strona dla hl-docs --page: pierwsza linia to tytuł, reszta — treść
+        let page = hl_core::tmp::run_temp_dir("docs")?.join(format!("{}.txt", name.replace('/', "_")));
+        std::fs::write(&page, format!("{}\n{}", name, libdoc::render_text(&doc)))?;
+        run_docs(lang, &["--page".to_string(), page.display().to_string()]);
+    } else {
+        print!("{}", libdoc::render_text(&doc));
     }
-    let status = std::process::Command::new(DOCS_BIN).status()
-    .unwrap_or_else(|e| { eprintln!("{} {}", "BŁĄD".red().bold(), e); std::process::exit(1); });
-    std::process::exit(status.code().unwrap_or(0));
+    Ok(())
+}
+
+/// hl docs search — ranking wyników; --open N otwiera wynik tam, gdzie żyje
+fn cmd_docs_search(query: &str, lang: Option<&str>, limit: usize, json: bool, open: Option<usize>) -> Result<()> {
+    use hl_core::docsearch::{self, Kind};
+    let mut hits = docsearch::search(&docsearch::index(Path::new(DOCS_BIN)), query);
+    hits.truncate(limit);
+    let Some(n) = open else {
+        if json { println!("{}", serde_json::to_string_pretty(&hits)?); } else { docsearch::print_hits(query, &hits); }
+        if hits.is_empty() { exit_with(exit::FAILURE); }
+        return Ok(());
+    };
+    let Some(hit) = n.checked_sub(1).and_then(|i| hits.get(i)) else {
+        anyhow::bail!("brak wyniku nr {} dla „{}” ({} wyników)", n, query, hits.len());
+    };
+    match hit.kind {
+        Kind::Docs  => run_docs(lang, &["--section".to_string(), hit.key.clone()]),
+        Kind::Lib   => cmd_docs_lib(&hit.key, lang, false, false, false)?,
+        Kind::Diag  => hl_core::cmd_explain(Some(&hit.key))?,
+        Kind::Learn => { if !hl_core::learn::cmd_learn(&hit.key, false)? { exit_with(exit::FAILURE); } }
+    }
+    Ok(())
 }
 
 fn print_version() {
@@ -698,6 +2345,10 @@ fn print_version() {
     println!("  Domyślny gen:     {}", format!("gen {}", HL_DEFAULT_GEN).bright_magenta());
     println!("  Deklaracja:       {}", "using <gen 2>".bright_cyan());
     println!();
+    if let Some(pin) = hl_core::pin::describe_pin() {
+        println!("{} {}", "Przypięta wersja:".bright_yellow(), pin);
+        println!();
+    }
     println!("{}", "Shebang:".bright_yellow());
     println!("  {}", "#!/usr/bin/env hl".bright_cyan());
     println!("  {}", "#!/usr/bin/hl".bright_cyan());
@@ -707,7 +2358,7 @@ fn print_version() {
     println!("  hl run plik.bc        -- uruchom .bc przez JIT");
     println!("  hl run --jit plik.hl  -- JIT pipeline (eksperymentalny)");
     println!("  hl clean              -- wyczyść cache .bc");
-    println!("  hl cache-info         -- statystyki cache .bc");
+    println!("  hl cache info         -- rozmiary cache (.bc, github, temp, scripts)");
     println!();
     println!("{}", "Arena Functions (gen 2):".bright_yellow());
     println!("  {}  -- zdefiniuj z areną 4k", ":: fn <4k> def ... done".bright_cyan());
@@ -720,6 +2371,7 @@ fn print_version() {
     println!("  {}  -- biblioteka standardowa", "# <main/nazwa>".bright_cyan());
     println!("  {}   -- biblioteka bit", "# <bit/nazwa>".bright_magenta());
     println!("  {} -- GitHub", "# <github/user/repo>".bright_blue());
+    println!("  {}  -- wybrane funkcje", "# <bit/nazwa>::{a, b}".bright_magenta());
     println!();
     println!("{}", "Skrypty systemowe:".bright_yellow());
     println!("  Katalog:  {}", HL_SCRIPTS_DIR.bright_white());
@@ -2,6 +2,7 @@ use anyhow::Result;
 use std::path::PathBuf;
 
 pub const CACHE_MAX_FILES: usize = 30;
+// Przy układzie XDG (hl_core::paths) ta ścieżka jest symlinkiem do $XDG_CACHE_HOME/hacker-lang/cache
 pub const CACHE_DIR_NAME: &str = ".hackeros/hacker-lang/cache";
 
 pub fn cache_dir() -> PathBuf {
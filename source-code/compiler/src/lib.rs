@@ -3,17 +3,35 @@ pub mod lower;
 pub mod optimize;
 pub mod serialize;
 pub mod cache;
+pub mod meta;
 
 pub use bytecode::{HlModule, HlBcHeader, Instruction, ConstPool, FuncTable};
-pub use lower::lower_ast;
-pub use optimize::optimize_module;
-pub use serialize::{write_bc_file, read_bc_file, BC_MAGIC, BC_VERSION};
+pub use lower::{lower_ast, lower_ast_with_warnings, lowered_commands, LoweredCmd};
+pub use optimize::{optimize_module, optimize_module_at};
+pub use serialize::{write_bc_file, write_bc_file_with_meta, read_bc_file, read_bc_header, BC_MAGIC, BC_VERSION};
+pub use meta::BcMetadata;
 pub use cache::{bc_cache_path, ensure_cache_dir, cache_cleanup_if_needed, CACHE_MAX_FILES};
 
 use anyhow::Result;
+use hl_parser::features::{apply_features, declared_features, enabled_from_env};
 use hl_parser::{parse_source_with_meta, ParseMeta};
 use std::path::Path;
 
+/// Opcje `hl compile`
+#[derive(Debug, Clone)]
+pub struct CompileOptions {
+    /// 0 — bez optymalizacji, 1 — domyślnie
+    pub opt_level: u8,
+    /// Usuń metadane `///`, user@host i katalog źródła z nagłówka
+    pub strip:     bool,
+    /// Włączone cechy — sekcje `?feature` nieaktywne dla nich są wycinane z AST
+    pub features:  Vec<String>,
+}
+
+impl Default for CompileOptions {
+    fn default() -> Self { Self { opt_level: 1, strip: false, features: Vec::new() } }
+}
+
 /// Główna funkcja: .hl → .bc
 /// Kompiluje plik źródłowy do zoptymalizowanego bytecode.
 /// Zwraca ścieżkę do pliku .bc.
@@ -27,15 +45,34 @@ pub fn compile_source_to_bc(
     source: &str,
     source_path: &Path,
     out_path: Option<&Path>,
+) -> Result<std::path::PathBuf> {
+    compile_source_with(source, source_path, out_path, &CompileOptions::default())
+}
+
+/// .hl → .bc z opcjami `hl compile`
+pub fn compile_source_with(
+    source: &str,
+    source_path: &Path,
+    out_path: Option<&Path>,
+    opts: &CompileOptions,
 ) -> Result<std::path::PathBuf> {
     // 1. Parse
     let meta: ParseMeta = parse_source_with_meta(source)?;
+    if let Some((_, declared)) = declared_features(source) {
+        if let Some(bad) = opts.features.iter().find(|f| !declared.contains(f)) {
+            anyhow::bail!("cecha '{}' nie jest zadeklarowana w `/// Features:` ({})", bad, declared.join(", "));
+        }
+    }
+    let nodes = apply_features(meta.nodes, &opts.features);
 
     // 2. Lower AST → HlModule (nasz IR bytecode)
-    let mut module = lower_ast(&meta.nodes, source_path, meta.gen.number());
+    let (mut module, warnings) = lower_ast_with_warnings(&nodes, source_path, meta.gen.number());
+    for w in &warnings {
+        tracing::warn!("{}: {}", source_path.display(), w);
+    }
 
     // 3. Optymalizuj
-    optimize_module(&mut module);
+    optimize_module_at(&mut module, opts.opt_level);
 
     // 4. Wyznacz ścieżkę wyjściową
     let bc_path = match out_path {
@@ -49,7 +86,17 @@ pub fn compile_source_to_bc(
     };
 
     // 5. Serializuj do pliku
-    write_bc_file(&module, &bc_path)?;
+    let mut meta = BcMetadata::from_source(source);
+    meta.opt_level = Some(opts.opt_level);
+    meta.features = opts.features.clone();
+    if opts.strip {
+        meta = BcMetadata { compiler: meta.compiler, opt_level: meta.opt_level, stripped: true, features: meta.features,
+                            requires: meta.requires, ..Default::default() };
+        if let Some(name) = source_path.file_name().and_then(|n| n.to_str()) {
+            module.header.source_path = name.to_string();
+        }
+    }
+    write_bc_file_with_meta(&module, Some(&meta), &bc_path)?;
 
     Ok(bc_path)
 }
@@ -60,8 +107,10 @@ pub fn compile_to_cache(source: &str, source_path: &Path) -> Result<std::path::P
     ensure_cache_dir()?;
     cache_cleanup_if_needed()?;
 
-    // Hash jakości produkcyjnej: FNV-1a zamiast DefaultHasher (stabilny między procesami)
-    let hash = fnv1a_hash_source(source, source_path);
+    // Hash jakości produkcyjnej: FNV-1a zamiast DefaultHasher (stabilny między procesami);
+    // cechy z HL_FEATURES zmieniają wycięte sekcje, więc są częścią klucza
+    let features = enabled_from_env();
+    let hash = fnv1a_hash_source(&format!("{}\0{}", source, features.join(",")), source_path);
     let cache_path = bc_cache_path(&format!("{:016x}", hash));
 
     // Jeśli cache trafiony i plik .bc nowszy niż źródło — zwróć od razu
@@ -83,7 +132,7 @@ pub fn compile_to_cache(source: &str, source_path: &Path) -> Result<std::path::P
     }
 
     tracing::debug!("cache miss, kompiluje: {:?}", source_path);
-    compile_source_to_bc(source, source_path, Some(&cache_path))?;
+    compile_source_with(source, source_path, Some(&cache_path), &CompileOptions { features, ..Default::default() })?;
     Ok(cache_path)
 }
 
@@ -6,6 +6,11 @@ use std::path::Path;
 struct Lowerer {
     module:    HlModule,
     reg_alloc: u32,
+    /// Konstrukcje, które .bc wykona inaczej niż interpreter (bez powtórzeń)
+    warnings:  Vec<String>,
+    /// Nazwy zadeklarowane jako lista / mapa — do wykrywania rozbieżności
+    lists:     std::collections::HashSet<String>,
+    maps:      std::collections::HashSet<String>,
 }
 
 /// Maksymalna liczba rejestrów — zapobiega przepełnieniu przy dużych skryptach
@@ -16,6 +21,16 @@ impl Lowerer {
         Self {
             module:    HlModule::new(source_path, gen),
             reg_alloc: 0,
+            warnings:  Vec::new(),
+            lists:     Default::default(),
+            maps:      Default::default(),
+        }
+    }
+
+    /// Zapisz rozbieżność .bc ↔ interpreter — każdy komunikat tylko raz
+    fn diverge(&mut self, msg: String) {
+        if !self.warnings.contains(&msg) {
+            self.warnings.push(msg);
         }
     }
 
@@ -73,6 +88,20 @@ impl Lowerer {
             }
 
             Node::VarDecl { name, value, .. } => {
+                match value {
+                    VarValue::List(_) => { self.lists.insert(name.clone()); }
+                    VarValue::Map(_) if !self.maps.insert(name.clone()) => self.diverge(format!(
+                        "mapa '{}' zadeklarowana ponownie — .bc nie usuwa starych wpisów `{}.klucz`", name, name)),
+                    _ => {}
+                }
+                // Mapa: wpisy jako zmienne `nazwa.klucz`, sama nazwa — lista kluczy
+                if let VarValue::Map(entries) = value {
+                    for (key, v) in entries {
+                        let src = self.lower_var_value(v);
+                        let key_idx = self.module.consts.add_str(&format!("{}.{}", name, key));
+                        self.emit(Instruction::SetVar { name: key_idx, src });
+                    }
+                }
                 let src = self.lower_var_value(value);
                 let name_idx = self.module.consts.add_str(name.as_str());
                 self.emit(Instruction::SetVar { name: name_idx, src });
@@ -131,7 +160,8 @@ impl Lowerer {
                 self.emit(Instruction::SetVar { name: le_idx, src: dst });
             }
 
-            Node::PipeToVar { command, mode, var_name } => {
+            // `strict` (|>!) sprawdza tylko tree-walk executor
+            Node::PipeToVar { command, mode, var_name, .. } => {
                 // Interpoluj @VAR w komendzie
                 let parts = hl_parser::ast::parse_string_parts(command);
                 let cmd_reg = self.lower_string_parts(&parts);
@@ -165,7 +195,24 @@ impl Lowerer {
                 self.patch_jump(jump_ph, after);
             }
 
+            // ? <wyrażenie> — Truthy ewaluuje porównanie jak w ?~
+            Node::IfExpr { condition, body } => {
+                let cond_reg = self.lower_string_parts(condition);
+                let bool_reg = self.alloc_reg();
+                self.emit(Instruction::Truthy { dst: bool_reg, src: cond_reg });
+                let skip_ph = self.emit_jump_placeholder(Some(bool_reg));
+                self.lower_nodes(body);
+                let after = self.current_offset();
+                self.patch_jump(skip_ph, after);
+            }
+
             Node::ForIn { var, iterable, body } => {
+                if let [StringPart::Var(list)] = iterable.as_slice() {
+                    if self.lists.contains(list) {
+                        self.diverge(format!(
+                            "pętla po liście '{}' — .bc dzieli elementy na spacjach i nie cytuje ich w komendach", list));
+                    }
+                }
                 let src = self.lower_string_parts(iterable);
                 let iter_reg = self.alloc_reg();
                 self.emit(Instruction::ForInStart { iter_reg, src });
@@ -253,9 +300,45 @@ impl Lowerer {
                 });
             }
 
-            Node::FuncCall { name } => {
+            // Argumenty jako zmienne arg0.. i argc, wartość `return` przez zmienną _return.
+            // W BC zmienne argumentów nie są przywracane po powrocie (tree-walk je przywraca).
+            Node::FuncCall { name, args, ret } => {
+                if !args.is_empty() {
+                    self.diverge(format!(
+                        "wywołanie '{}' z argumentami — .bc nie przywraca @arg0.. i @argc po powrocie", name));
+                    for (i, arg) in args.iter().enumerate() {
+                        let src = self.lower_string_parts(arg);
+                        let idx = self.module.consts.add_str(format!("arg{}", i));
+                        self.emit(Instruction::SetVar { name: idx, src });
+                    }
+                    let src = self.alloc_reg();
+                    let num_idx = self.module.consts.add_num(args.len() as f64);
+                    self.emit(Instruction::LoadNum { dst: src, idx: num_idx });
+                    let argc_idx = self.module.consts.add_str("argc");
+                    self.emit(Instruction::SetVar { name: argc_idx, src });
+                }
+                let ret_idx = self.module.consts.add_str("_return");
+                if ret.is_some() {
+                    let empty = self.alloc_reg();
+                    let empty_idx = self.module.consts.add_str("");
+                    self.emit(Instruction::LoadStr { dst: empty, idx: empty_idx });
+                    self.emit(Instruction::SetVar { name: ret_idx, src: empty });
+                }
                 let name_idx = self.module.consts.add_str(name.as_str());
                 self.emit(Instruction::CallFunc { name: name_idx });
+                if let Some(var) = ret {
+                    let dst = self.alloc_reg();
+                    self.emit(Instruction::GetVar { dst, name: ret_idx });
+                    let var_idx = self.module.consts.add_str(var.as_str());
+                    self.emit(Instruction::SetVar { name: var_idx, src: dst });
+                }
+            }
+
+            Node::Return { value } => {
+                let src = self.lower_string_parts(value);
+                let ret_idx = self.module.consts.add_str("_return");
+                self.emit(Instruction::SetVar { name: ret_idx, src });
+                self.emit(Instruction::Return { src: Some(src) });
             }
 
             Node::QuickCall { name, args } => {
@@ -285,6 +368,69 @@ impl Lowerer {
                 self.emit(Instruction::HackerOsCall { tool: tool_idx, args: args_reg, dst });
             }
 
+            Node::Check { check, body } => {
+                // Komenda sprawdzająca: kod 0 — stan docelowy, ciało pomijane
+                let parts   = hl_parser::ast::parse_string_parts(check);
+                let cmd_reg = self.lower_string_parts(&parts);
+                let ec_reg  = self.alloc_reg();
+                self.emit(Instruction::ExecCmd { cmd: cmd_reg, mode: CmdMode::Plain, dst: ec_reg });
+                let zero_reg = self.alloc_reg();
+                let zero_idx = self.module.consts.add_num(0.0);
+                self.emit(Instruction::LoadNum { dst: zero_reg, idx: zero_idx });
+                let needed = self.alloc_reg();
+                self.emit(Instruction::CmpNe { dst: needed, a: ec_reg, b: zero_reg });
+                let skip = self.emit_jump_placeholder(Some(needed));
+                self.lower_nodes(body);
+                let after = self.current_offset();
+                self.patch_jump(skip, after);
+            }
+
+            // compile_source_with wycina sekcje przed lowerowaniem; tu trafiają tylko
+            // przy bezpośrednim lower_ast — wtedy według HL_FEATURES
+            Node::FeatureBlock { conditions, body } => {
+                if hl_parser::features::is_active(conditions, &hl_parser::features::enabled_from_env()) {
+                    self.lower_nodes(body);
+                }
+            }
+
+            // .bc nie ma dostępu do .hl-state.json — krok wykonuje się zawsze
+            Node::Once { name, body, .. } => {
+                self.diverge(format!(
+                    "krok ~once '{}' — .bc wykona go przy każdym uruchomieniu (brak .hl-state.json)", name));
+                self.lower_nodes(body);
+            }
+
+            // Dziennik ~undo prowadzi tylko interpreter — .bc wykonuje sam krok
+            Node::Undo { body, .. } => self.lower_nodes(body),
+
+            Node::Retry { spec, body } => {
+                // Próby rozwinięte (najwyżej RetrySpec::MAX_ATTEMPTS): po każdej nieudanej (poza ostatnią) `sleep` i kolejna kopia body
+                let mut done_jumps = Vec::new();
+                for attempt in 1..=spec.attempts {
+                    self.lower_nodes(body);
+                    if attempt == spec.attempts { break; }
+                    let ec_reg   = self.alloc_reg();
+                    let le_idx   = self.module.consts.add_str("_last_exit_code");
+                    self.emit(Instruction::GetVar { dst: ec_reg, name: le_idx });
+                    let zero_reg = self.alloc_reg();
+                    let zero_idx = self.module.consts.add_num(0.0);
+                    self.emit(Instruction::LoadNum { dst: zero_reg, idx: zero_idx });
+                    let failed   = self.alloc_reg();
+                    self.emit(Instruction::CmpNe { dst: failed, a: ec_reg, b: zero_reg });
+                    done_jumps.push(self.emit_jump_placeholder(Some(failed)));
+                    let delay = spec.delay_after(attempt);
+                    if delay > 0 {
+                        let cmd_reg = self.alloc_reg();
+                        let cmd_idx = self.module.consts.add_str(&format!("sleep {}", delay as f64 / 1000.0));
+                        self.emit(Instruction::LoadStr { dst: cmd_reg, idx: cmd_idx });
+                        let dst = self.alloc_reg();
+                        self.emit(Instruction::ExecCmd { cmd: cmd_reg, mode: CmdMode::Plain, dst });
+                    }
+                }
+                let after = self.current_offset();
+                for ph in done_jumps { self.patch_jump(ph, after); }
+            }
+
             Node::RepeatN { count, body } => {
                 // Unroll małych pętli (≤4), resztę kompiluj jako loop
                 if *count <= 4 {
@@ -333,8 +479,8 @@ impl Lowerer {
                 self.emit(Instruction::SetVar { name: le_idx, src: dst });
             }
 
-            Node::Background { raw } => {
-                // Interpoluj @VAR, dodaj prefix &
+            Node::Background { raw, .. } => {
+                // Interpoluj @VAR, dodaj prefix & (nazwy zadań śledzi tylko tree-walk executor)
                 let inner_parts = hl_parser::ast::parse_string_parts(raw);
                 let inner_reg = self.lower_string_parts(&inner_parts);
                 let prefix_idx = self.module.consts.add_str("& ");
@@ -556,10 +702,28 @@ impl Lowerer {
                 dst_out
             }
             VarValue::List(_) | VarValue::Map(_) => {
-                // Uproszczenie: listy/mapy traktuj jak pusty string w BC (nie używane w gen 2 skryptach)
+                // W BC lista to elementy rozdzielone spacją (jak Value::List::to_string_val),
+                // mapa — jej klucze; elementy ze spacjami zachowuje tylko tree-walk
+                let regs: Vec<Reg> = match value {
+                    VarValue::List(items) => items.iter().map(|v| self.lower_var_value(v)).collect(),
+                    VarValue::Map(entries) => entries.iter().map(|(k, _)| {
+                        let dst = self.alloc_reg();
+                        let idx = self.module.consts.add_str(k.as_str());
+                        self.emit(Instruction::LoadStr { dst, idx });
+                        dst
+                    }).collect(),
+                    _ => unreachable!(),
+                };
+                let sep_reg = self.alloc_reg();
+                let sep_idx = self.module.consts.add_str(" ");
+                self.emit(Instruction::LoadStr { dst: sep_reg, idx: sep_idx });
+                let mut parts = Vec::with_capacity(regs.len() * 2);
+                for (i, r) in regs.into_iter().enumerate() {
+                    if i > 0 { parts.push(sep_reg); }
+                    parts.push(r);
+                }
                 let dst = self.alloc_reg();
-                let idx = self.module.consts.add_str("");
-                self.emit(Instruction::LoadStr { dst, idx });
+                self.emit(Instruction::Concat { dst, parts });
                 dst
             }
         }
@@ -688,10 +852,57 @@ fn lower_cmd_mode(mode: &CommandMode) -> CmdMode {
 
 /// Główna funkcja lowering
 pub fn lower_ast(nodes: &[Node], source_path: &Path, gen: u32) -> HlModule {
+    lower_ast_with_warnings(nodes, source_path, gen).0
+}
+
+/// Jak `lower_ast`, ale zwraca też ostrzeżenia o konstrukcjach, które .bc
+/// wykonuje inaczej niż interpreter (~once, pętle po listach, mapy, argumenty funkcji)
+pub fn lower_ast_with_warnings(nodes: &[Node], source_path: &Path, gen: u32) -> (HlModule, Vec<String>) {
     let path_str = source_path.display().to_string();
     let mut lowerer = Lowerer::new(&path_str, gen);
     lowerer.lower_nodes(nodes);
     lowerer.emit(Instruction::Return { src: None });
     lowerer.module.main_regs = lowerer.reg_alloc;
-    lowerer.module
+    (lowerer.module, lowerer.warnings)
+}
+
+/// Komenda, którą wykona moduł — tak, jak widzi ją bytecode
+#[derive(Debug, Clone, PartialEq)]
+pub struct LoweredCmd {
+    /// Tekst komendy; zmienne jako `@nazwa`, wartości nieznane przy kompilacji jako `@?`
+    pub cmd:  String,
+    pub sudo: bool,
+}
+
+/// Wszystkie komendy modułu (ExecCmd, ExecCapture, HackerOsCall) z odtworzonym
+/// tekstem — wspólne źródło dla sprawdzania manifestu `.hl` i `.bc`
+pub fn lowered_commands(module: &HlModule) -> Vec<LoweredCmd> {
+    use std::collections::HashMap;
+    let s = |i: ConstIdx| module.consts.strings.get(i as usize).cloned().unwrap_or_default();
+    let mut regs: HashMap<Reg, String> = HashMap::new();
+    let text = |regs: &HashMap<Reg, String>, r: &Reg| regs.get(r).cloned().unwrap_or_else(|| "@?".into());
+    let mut out = Vec::new();
+    for insn in &module.instructions {
+        match insn {
+            Instruction::LoadStr { dst, idx } => { regs.insert(*dst, s(*idx)); }
+            Instruction::LoadNum { dst, idx } => {
+                regs.insert(*dst, module.consts.numbers.get(*idx as usize).map(|n| n.to_string()).unwrap_or_default());
+            }
+            Instruction::GetVar { dst, name }   => { regs.insert(*dst, format!("@{}", s(*name))); }
+            Instruction::ToString { dst, src }  => { let t = text(&regs, src); regs.insert(*dst, t); }
+            Instruction::Concat { dst, parts }  => {
+                let t: String = parts.iter().map(|r| text(&regs, r)).collect();
+                regs.insert(*dst, t);
+            }
+            Instruction::ExecCmd { cmd, mode, .. } | Instruction::ExecCapture { cmd, mode, .. } => {
+                let sudo = matches!(mode, CmdMode::Sudo | CmdMode::IsolatedSudo | CmdMode::WithVarsSudo);
+                out.push(LoweredCmd { cmd: text(&regs, cmd), sudo });
+            }
+            Instruction::HackerOsCall { tool, args, .. } => {
+                out.push(LoweredCmd { cmd: format!("{} {}", s(*tool), text(&regs, args)).trim().to_string(), sudo: false });
+            }
+            _ => {}
+        }
+    }
+    out
 }
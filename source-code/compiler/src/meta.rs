@@ -0,0 +1,76 @@
+use serde::{Deserialize, Serialize};
+
+/// Metadane skryptu osadzane w nagłówku JSON pliku .bc (`hl inspect`).
+///
+/// Źródło: blok komentarzy `///` na początku pliku .hl:
+///   /// Author: Jan Kowalski
+///   /// Version: 1.4.0
+///   /// Description: Backup katalogów domowych
+/// Pierwsza linia `///` bez klucza jest traktowana jako opis.
+#[derive(Debug, Clone, Default, Serialize, Deserialize, PartialEq)]
+pub struct BcMetadata {
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub author:      Option<String>,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub version:     Option<String>,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub description: Option<String>,
+    /// user@host, na którym skompilowano
+    #[serde(default)]
+    pub built_by:    String,
+    /// Wersja hl-compiler
+    #[serde(default)]
+    pub compiler:    String,
+    /// `hl compile -O`; None — plik ze starszego hl
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub opt_level:   Option<u8>,
+    /// `hl compile --strip` — bez autora, opisu, miejsca budowania i pełnej ścieżki źródła
+    #[serde(default)]
+    pub stripped:    bool,
+    /// `hl compile --features` — cechy, dla których zostały sekcje `?feature`
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub features:    Vec<String>,
+    /// Manifest uprawnień `/// Requires:` — hl run sprawdza go także dla .bc,
+    /// więc zostaje również po --strip
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub requires:    Option<Vec<String>>,
+}
+
+impl BcMetadata {
+    pub fn from_source(source: &str) -> Self {
+        let mut meta = BcMetadata {
+            built_by: build_identity(),
+            compiler: env!("CARGO_PKG_VERSION").to_string(),
+            ..Default::default()
+        };
+        let mut first_doc: Option<String> = None;
+
+        for line in source.lines().take(30) {
+            let t = line.trim();
+            if t.is_empty() || t.starts_with("#!") || t.starts_with("using") || t.starts_with(";;") { continue; }
+            let Some(doc) = t.strip_prefix("///") else { break };
+            let doc = doc.trim();
+            match doc.split_once(':') {
+                Some((k, v)) if !v.trim().is_empty() => match k.trim().to_ascii_lowercase().as_str() {
+                    "author" | "autor"        => meta.author      = Some(v.trim().to_string()),
+                    "version" | "wersja"      => meta.version     = Some(v.trim().to_string()),
+                    "description" | "opis"    => meta.description = Some(v.trim().to_string()),
+                    "requires" | "wymaga"     => meta.requires.get_or_insert_with(Vec::new).extend(
+                        v.split(',').map(str::trim).filter(|s| !s.is_empty() && *s != "none").map(str::to_string)),
+                    "minhl" | "minruntime" | "requiresos" | "features" | "cechy" | "frozen" | "lib" => {}
+                    _ => { first_doc.get_or_insert_with(|| doc.to_string()); }
+                },
+                _ if !doc.is_empty() => { first_doc.get_or_insert_with(|| doc.to_string()); }
+                _ => {}
+            }
+        }
+        if meta.description.is_none() { meta.description = first_doc; }
+        meta
+    }
+}
+
+fn build_identity() -> String {
+    let user = std::env::var("USER").unwrap_or_else(|_| "?".into());
+    let host = std::fs::read_to_string("/etc/hostname").unwrap_or_else(|_| "?".into());
+    format!("{}@{}", user, host.trim())
+}
@@ -7,6 +7,11 @@ pub fn optimize_module(module: &mut HlModule) {
     // Deduplacja stałych jest już wbudowana w ConstPool
 }
 
+/// `hl compile -O <poziom>`: 0 — bytecode 1:1 z lowering, 1 — wszystkie passy
+pub fn optimize_module_at(module: &mut HlModule, level: u8) {
+    if level > 0 { optimize_module(module); }
+}
+
 /// Constant folding: dwa LoadNum + Add/Sub/Mul/Div → jeden LoadNum
 fn pass_constant_folding(module: &mut HlModule) {
     // Śledź jakie rejestry są wynikiem LoadNum i ich wartości
@@ -189,4 +194,21 @@ mod tests {
             panic!("Oczekiwano JumpIfFalse");
         }
     }
+
+    #[test]
+    fn test_opt_level_zero_keeps_instructions() {
+        let insns = vec![
+            Instruction::LoadNum { dst: 0, idx: 0 },
+            Instruction::LoadNum { dst: 1, idx: 1 },
+            Instruction::Add { dst: 2, a: 0, b: 1 },
+            Instruction::Nop,
+        ];
+        let mut m = make_module_with(insns.clone(), vec![2.0, 3.0]);
+        optimize_module_at(&mut m, 0);
+        assert_eq!(m.instructions.len(), insns.len());
+        assert!(m.instructions.iter().any(|i| matches!(i, Instruction::Add { .. })));
+
+        optimize_module_at(&mut m, 1);
+        assert!(!m.instructions.iter().any(|i| matches!(i, Instruction::Add { .. } | Instruction::Nop)));
+    }
 }
@@ -1,5 +1,6 @@
 use anyhow::{bail, Context, Result};
 use crate::bytecode::{HlBcHeader, HlModule};
+use crate::meta::BcMetadata;
 use std::path::Path;
 
 pub const BC_MAGIC: &[u8; 4] = b"HLBC";
@@ -9,6 +10,12 @@ pub const BC_VERSION: u32 = 3; // bump: fix @VAR interpolation in commands
 const BC_SHEBANG: &str = "#!/usr/bin/env -S /usr/bin/hl run\n";
 
 pub fn write_bc_file(module: &HlModule, path: &Path) -> Result<()> {
+    write_bc_file_with_meta(module, None, path)
+}
+
+/// Zapis .bc z metadanymi skryptu w nagłówku JSON (klucz "meta").
+/// Część bincode się nie zmienia — starsze hl ignorują nieznany klucz.
+pub fn write_bc_file_with_meta(module: &HlModule, meta: Option<&BcMetadata>, path: &Path) -> Result<()> {
 
     let mut buf: Vec<u8> = Vec::with_capacity(4096);
 
@@ -22,7 +29,12 @@ pub fn write_bc_file(module: &HlModule, path: &Path) -> Result<()> {
     buf.extend_from_slice(&BC_VERSION.to_le_bytes());
 
     // JSON header
-    let header_json = serde_json::to_vec(&module.header)
+    let mut header_value = serde_json::to_value(&module.header)
+    .context("Serializacja nagłówka .bc")?;
+    if let (Some(meta), Some(obj)) = (meta, header_value.as_object_mut()) {
+        obj.insert("meta".into(), serde_json::to_value(meta)?);
+    }
+    let header_json = serde_json::to_vec(&header_value)
     .context("Serializacja nagłówka .bc")?;
     let header_len = header_json.len() as u64;
     buf.extend_from_slice(&header_len.to_le_bytes());
@@ -49,6 +61,34 @@ pub fn write_bc_file(module: &HlModule, path: &Path) -> Result<()> {
     Ok(())
 }
 
+/// Odczytaj tylko nagłówek JSON (+ metadane, jeśli są) bez deserializacji modułu
+pub fn read_bc_header(path: &Path) -> Result<(u32, HlBcHeader, Option<BcMetadata>)> {
+    let raw = std::fs::read(path)
+    .with_context(|| format!("Odczyt .bc: {:?}", path))?;
+    let mut pos = if raw.starts_with(b"#!") {
+        raw.iter().position(|&b| b == b'\n').map(|i| i + 1).unwrap_or(0)
+    } else { 0 };
+    if raw.get(pos..pos + 4) != Some(BC_MAGIC) {
+        bail!("Nieprawidłowy magic w .bc: {:?} (czy to plik .bc?)", path);
+    }
+    pos += 4;
+    let ver = raw.get(pos..pos + 4)
+    .map(|b| u32::from_le_bytes(b.try_into().unwrap()))
+    .with_context(|| format!("Urwany nagłówek .bc: {:?}", path))?;
+    pos += 4;
+    let header_len = raw.get(pos..pos + 8)
+    .map(|b| u64::from_le_bytes(b.try_into().unwrap()) as usize)
+    .with_context(|| format!("Urwany nagłówek JSON w .bc: {:?}", path))?;
+    pos += 8;
+    let json = pos.checked_add(header_len).and_then(|end| raw.get(pos..end))
+    .with_context(|| format!("Urwane dane nagłówka JSON w .bc: {:?}", path))?;
+
+    let value: serde_json::Value = serde_json::from_slice(json).context("Parsowanie nagłówka .bc")?;
+    let header: HlBcHeader = serde_json::from_value(value.clone()).context("Parsowanie nagłówka .bc")?;
+    let meta = value.get("meta").cloned().and_then(|m| serde_json::from_value(m).ok());
+    Ok((ver, header, meta))
+}
+
 pub fn read_bc_file(path: &Path) -> Result<HlModule> {
     let raw = std::fs::read(path)
     .with_context(|| format!("Odczyt .bc: {:?}", path))?;
@@ -92,13 +132,14 @@ pub fn parse_bc_bytes(raw: &[u8], path: &Path) -> Result<HlModule> {
     let header_len = u64::from_le_bytes(raw[pos..pos+8].try_into().unwrap()) as usize;
     pos += 8;
 
-    // JSON header (tylko do walidacji / logowania)
-    if raw.len() < pos + header_len {
-        bail!("Urwane dane nagłówka JSON w .bc: {:?}", path);
-    }
-    let _header: HlBcHeader = serde_json::from_slice(&raw[pos..pos+header_len])
+    // JSON header (tylko do walidacji / logowania); długość z pliku może przepełnić usize
+    let end = match pos.checked_add(header_len) {
+        Some(end) if end <= raw.len() => end,
+        _ => bail!("Urwane dane nagłówka JSON w .bc: {:?}", path),
+    };
+    let _header: HlBcHeader = serde_json::from_slice(&raw[pos..end])
     .context("Parsowanie nagłówka .bc")?;
-    pos += header_len;
+    pos = end;
 
     // Bincode module
     let mut module: HlModule = bincode::deserialize(&raw[pos..])
@@ -0,0 +1,248 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+use std::process::Command;
+use std::time::{SystemTime, UNIX_EPOCH};
+use crate::config::config_path;
+use crate::deploy::hl_binary;
+use crate::exit::{classified, ErrorClass};
+use crate::schedule::schedule_logs_dir;
+use crate::tmp::run_temp_dir;
+use crate::HL_MAX_GEN;
+
+// ── hl bug-report ─────────────────────────────────────────────────────────────
+//
+// Pakiet diagnostyczny do dołączenia do zgłoszenia:
+//   hl bug-report [-o plik.tar.gz] [-- <komenda hl>]
+//
+// hl-bug-report-<ts>.tar.gz zawiera:
+//   version.txt   wersja hl, gen, binarka, uname, /etc/os-release
+//   config.hk     konfiguracja z zamaskowanymi sekretami
+//   env.txt       wybrane zmienne środowiska (zamaskowane)
+//   logs/         ogon dziennika uruchomień, ostatnie logi i raporty awarii
+//   command.txt   wyjście i exit code komendy podanej po `--`
+//
+// Każdy zebrany plik przechodzi przez redact_text: wartości kluczy
+// wyglądających na sekrety (.hk, KLUCZ=wartość, JSON, pary ["KLUCZ", "wartość"]
+// w history.jsonl), nagłówki `Bearer` i wartości sekretnych zmiennych bieżącego
+// środowiska zamieniane są na ***.
+
+const LOG_FILES_MAX: usize = 10;
+const LOG_TAIL_BYTES: usize = 64 * 1024;
+const SECRET_HINTS: &[&str] = &["token", "secret", "password", "passwd", "pass", "key", "webhook", "auth", "cred"];
+const ENV_KEEP: &[&str] = &["PATH", "SHELL", "LANG", "LC_ALL", "TERM", "HOME", "USER", "XDG_CONFIG_HOME"];
+
+fn looks_secret(key: &str) -> bool {
+    let k = key.to_ascii_lowercase();
+    SECRET_HINTS.iter().any(|h| k.contains(h))
+}
+
+/// Zamaskuj wartości kluczy wyglądających na sekrety w pliku .hk (`-> klucz => wartość`)
+pub fn redact_hk(src: &str) -> String {
+    src.lines()
+        .map(|line| {
+            let trimmed = line.trim_start();
+            if let Some(rest) = trimmed.strip_prefix("->") {
+                if let Some((key, _)) = rest.split_once("=>") {
+                    if looks_secret(key) {
+                        let indent = &line[..line.len() - trimmed.len()];
+                        return format!("{}->{}=> ***", indent, key);
+                    }
+                }
+            }
+            line.to_string()
+        })
+        .collect::<Vec<_>>()
+        .join("\n") + "\n"
+}
+
+/// Wartości sekretnych zmiennych bieżącego środowiska (krótkie pomijamy — za dużo trafień)
+fn secret_env_values() -> Vec<String> {
+    let mut out: Vec<String> = std::env::vars()
+        .filter(|(k, v)| looks_secret(k) && v.len() >= 6)
+        .map(|(_, v)| v)
+        .collect();
+    out.sort_by_key(|v| std::cmp::Reverse(v.len()));
+    out
+}
+
+fn redact_json(v: &mut serde_json::Value) {
+    use serde_json::Value;
+    match v {
+        Value::Object(map) => for (k, val) in map.iter_mut() {
+            if looks_secret(k) && !val.is_null() { *val = Value::String("***".into()); } else { redact_json(val); }
+        },
+        // Zmienne w history.jsonl: ["HL_SERVE_TOKEN", "wartość"]
+        Value::Array(items) => {
+            if let [Value::String(k), val @ Value::String(_)] = items.as_mut_slice() {
+                if looks_secret(k) { *val = Value::String("***".into()); return; }
+            }
+            items.iter_mut().for_each(redact_json);
+        }
+        _ => {}
+    }
+}
+
+fn redact_line(line: &str) -> String {
+    if let Ok(mut v) = serde_json::from_str::<serde_json::Value>(line) {
+        if v.is_object() || v.is_array() {
+            redact_json(&mut v);
+            return v.to_string();
+        }
+    }
+    let line = redact_hk(line);
+    let line = line.trim_end_matches('\n');
+    let mut out: Vec<String> = Vec::new();
+    let mut bearer = false;
+    for word in line.split(' ') {
+        if bearer && !word.is_empty() { out.push("***".into()); bearer = false; continue; }
+        bearer = word.eq_ignore_ascii_case("bearer");
+        match word.split_once('=') {
+            Some((k, v)) if !v.is_empty() && looks_secret(k.trim_start_matches("--")) => out.push(format!("{}=***", k)),
+            _ => out.push(word.to_string()),
+        }
+    }
+    out.join(" ")
+}
+
+/// Zamaskuj sekrety w dowolnym zebranym pliku (log, dziennik, wyjście komendy)
+pub fn redact_text(src: &str) -> String {
+    let mut text = src.to_string();
+    for secret in secret_env_values() { text = text.replace(&secret, "***"); }
+    let mut out: String = text.lines().map(redact_line).collect::<Vec<_>>().join("\n");
+    if src.ends_with('\n') { out.push('\n'); }
+    out
+}
+
+fn tail_bytes(path: &Path) -> String {
+    let content = std::fs::read(path).unwrap_or_default();
+    let start = content.len().saturating_sub(LOG_TAIL_BYTES);
+    String::from_utf8_lossy(&content[start..]).into_owned()
+}
+
+fn command_output(cmd: &str, args: &[&str]) -> String {
+    Command::new(cmd).args(args).output()
+        .map(|o| String::from_utf8_lossy(&o.stdout).trim().to_string())
+        .unwrap_or_else(|_| format!("({} niedostępne)", cmd))
+}
+
+fn version_info() -> String {
+    format!(
+        "hl_core:   {}\nmax gen:   {}\nbinarka:   {}\nuname:     {}\n\n--- /etc/os-release ---\n{}",
+        env!("CARGO_PKG_VERSION"),
+        HL_MAX_GEN,
+        hl_binary(),
+        command_output("uname", &["-a"]),
+        std::fs::read_to_string("/etc/os-release").unwrap_or_default(),
+    )
+}
+
+fn env_info() -> String {
+    let mut vars: Vec<(String, String)> = std::env::vars()
+        .filter(|(k, _)| k.starts_with("HL_") || ENV_KEEP.contains(&k.as_str()))
+        .map(|(k, v)| { let v = if looks_secret(&k) { "***".into() } else { v }; (k, v) })
+        .collect();
+    vars.sort();
+    vars.into_iter().map(|(k, v)| format!("{}={}\n", k, v)).collect()
+}
+
+fn collect_logs(dst: &Path) -> Result<usize> {
+    std::fs::create_dir_all(dst)?;
+    let logs = schedule_logs_dir();
+    let mut files: Vec<(SystemTime, PathBuf)> = std::fs::read_dir(&logs)
+        .map(|rd| rd.flatten()
+            .filter(|e| e.file_type().map(|t| t.is_file()).unwrap_or(false))
+            .filter_map(|e| Some((e.metadata().ok()?.modified().ok()?, e.path())))
+            .collect())
+        .unwrap_or_default();
+    files.sort_by(|a, b| b.0.cmp(&a.0));
+
+    let mut n = 0;
+    for (_, path) in files.into_iter().take(LOG_FILES_MAX) {
+        if let Some(name) = path.file_name() {
+            std::fs::write(dst.join(name), redact_text(&tail_bytes(&path)))?;
+            n += 1;
+        }
+    }
+    Ok(n)
+}
+
+fn run_failing_command(args: &[String]) -> String {
+    match Command::new(hl_binary()).args(args).output() {
+        Ok(o) => format!(
+            "$ hl {}\nexit code: {}\n\n--- stdout ---\n{}\n--- stderr ---\n{}",
+            args.join(" "),
+            o.status.code().map(|c| c.to_string()).unwrap_or_else(|| "sygnał".into()),
+            String::from_utf8_lossy(&o.stdout),
+            String::from_utf8_lossy(&o.stderr),
+        ),
+        Err(e) => format!("$ hl {}\nnie można uruchomić: {}\n", args.join(" "), e),
+    }
+}
+
+pub fn cmd_bug_report(out: Option<&Path>, command: &[String]) -> Result<PathBuf> {
+    if which::which("tar").is_err() { return Err(classified(ErrorClass::Toolchain, "tar nie jest zainstalowany")); }
+    let ts   = SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0);
+    let name = format!("hl-bug-report-{}", ts);
+    let out  = out.map(Path::to_path_buf).unwrap_or_else(|| PathBuf::from(format!("{}.tar.gz", name)));
+
+    println!("{}", "hl bug-report:".bright_magenta().bold());
+    let staging_root = run_temp_dir("bug-report")?;
+    let staging = staging_root.join(&name);
+    std::fs::create_dir_all(&staging)?;
+
+    std::fs::write(staging.join("version.txt"), version_info())?;
+    println!("  {} wersja i system", "✓".green());
+
+    let cfg = config_path();
+    if let Ok(src) = std::fs::read_to_string(&cfg) {
+        std::fs::write(staging.join("config.hk"), redact_text(&redact_hk(&src)))?;
+        println!("  {} config.hk (sekrety zamaskowane)", "✓".green());
+    }
+
+    std::fs::write(staging.join("env.txt"), env_info())?;
+    println!("  {} zmienne środowiska", "✓".green());
+
+    let n = collect_logs(&staging.join("logs"))?;
+    println!("  {} logi: {} plików (sekrety zamaskowane)", "✓".green(), n);
+
+    if !command.is_empty() {
+        println!("  {} hl {}", "→".bright_cyan(), command.join(" "));
+        std::fs::write(staging.join("command.txt"), redact_text(&run_failing_command(command)))?;
+        println!("  {} wyjście komendy", "✓".green());
+    }
+
+    let status = Command::new("tar")
+        .arg("czf").arg(&out)
+        .arg("-C").arg(&staging_root)
+        .arg(&name)
+        .status()
+        .context("Nie można uruchomić tar")?;
+    std::fs::remove_dir_all(&staging_root).ok();
+    if !status.success() { bail!("tar zakończony błędem"); }
+
+    println!("  {} {}", "✓".green(), out.display().to_string().bright_white().bold());
+    println!("  Przejrzyj zawartość przed wysłaniem: {}", format!("tar tzf {}", out.display()).bright_cyan());
+    Ok(out)
+}
+
+/// Hook paniki: zapisuje raport do logs/panic-<ts>.log i wskazuje hl bug-report
+pub fn install_panic_hook() {
+    std::panic::set_hook(Box::new(|info| {
+        let ts = SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0);
+        let report = format!(
+            "{}\n\nargs: {:?}\nhl_core: {}\n\n{}",
+            info,
+            std::env::args().collect::<Vec<_>>(),
+            env!("CARGO_PKG_VERSION"),
+            std::backtrace::Backtrace::force_capture(),
+        );
+        let logs = schedule_logs_dir();
+        let path = logs.join(format!("panic-{}.log", ts));
+        let saved = std::fs::create_dir_all(&logs).and_then(|_| std::fs::write(&path, &report)).is_ok();
+
+        eprintln!("{} hl uległ awarii: {}", "BŁĄD".red().bold(), info);
+        if saved { eprintln!("  Raport: {}", path.display().to_string().bright_black()); }
+        eprintln!("  Zgłoś błąd z pakietem diagnostycznym: {}", "hl bug-report".bright_cyan());
+    }));
+}
@@ -0,0 +1,208 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+use std::time::SystemTime;
+use crate::config::load_config;
+use crate::libs::{bit_base_dir, github_libs_dir, hl_cache_dir};
+use crate::fetch::scripts_cache_dir;
+use crate::tmp::{dir_size, human_size, owner_alive, temp_root};
+
+// ── hl cache ──────────────────────────────────────────────────────────────────
+//
+//   hl cache info                     rozmiar każdej kategorii + limit
+//   hl cache clean [kategoria]        wyczyść jedną lub wszystkie kategorie
+//   hl cache verify [--fix]           sprawdź pliki .bc (--fix usuwa uszkodzone)
+//   hl cache gc [--max-size 500M]     LRU: usuwaj najdawniej używane do limitu
+//
+// Kategorie: bytecode (.bc), github (sklonowane # <github/...>), temp,
+// scripts (skrypty pobrane przez hl run <URL> / github:org/repo).
+// Biblioteki bit są instalacjami, nie cache — info tylko je pokazuje.
+// clean i gc pomijają katalogi temp procesów hl, które jeszcze działają.
+// Limit dla gc: --max-size lub [cache] max_size w config.hk (domyślnie 512M).
+
+const DEFAULT_MAX_SIZE: u64 = 512 << 20;
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum CacheKind { Bytecode, Github, Temp, Scripts }
+
+impl CacheKind {
+    pub const ALL: [CacheKind; 4] = [CacheKind::Bytecode, CacheKind::Github, CacheKind::Temp, CacheKind::Scripts];
+
+    pub fn from_str(s: &str) -> Option<Self> {
+        match s {
+            "bytecode" | "bc" => Some(CacheKind::Bytecode),
+            "github"          => Some(CacheKind::Github),
+            "temp" | "tmp"    => Some(CacheKind::Temp),
+            "scripts"         => Some(CacheKind::Scripts),
+            _                 => None,
+        }
+    }
+
+    pub fn name(&self) -> &'static str {
+        match self {
+            CacheKind::Bytecode => "bytecode",
+            CacheKind::Github   => "github",
+            CacheKind::Temp     => "temp",
+            CacheKind::Scripts  => "scripts",
+        }
+    }
+
+    pub fn dir(&self) -> PathBuf {
+        match self {
+            CacheKind::Bytecode => hl_cache_dir(),
+            CacheKind::Github   => github_libs_dir(),
+            CacheKind::Temp     => temp_root(),
+            CacheKind::Scripts  => scripts_cache_dir(),
+        }
+    }
+}
+
+/// "500M", "2G", "800K" lub liczba bajtów
+pub fn parse_size(s: &str) -> Result<u64> {
+    let s = s.trim();
+    let (num, mult) = match s.chars().last().map(|c| c.to_ascii_uppercase()) {
+        Some('K') => (&s[..s.len() - 1], 1u64 << 10),
+        Some('M') => (&s[..s.len() - 1], 1u64 << 20),
+        Some('G') => (&s[..s.len() - 1], 1u64 << 30),
+        _         => (s, 1),
+    };
+    match num.trim().parse::<u64>() {
+        Ok(n)  => Ok(n * mult),
+        Err(_) => bail!("Nieprawidłowy rozmiar '{}' — użyj np. 800K, 500M, 2G", s),
+    }
+}
+
+fn configured_max_size() -> Result<u64> {
+    match load_config().get("cache", "max_size") {
+        Some(v) => parse_size(v),
+        None    => Ok(DEFAULT_MAX_SIZE),
+    }
+}
+
+/// Elementy cache — jednostka usuwania: plik .bc albo cały katalog (repo github, katalog temp)
+fn cache_items(kind: CacheKind) -> Vec<(SystemTime, u64, PathBuf)> {
+    let Ok(rd) = std::fs::read_dir(kind.dir()) else { return vec![] };
+    rd.flatten()
+        .filter_map(|e| {
+            let meta = e.metadata().ok()?;
+            let used = match (meta.accessed().ok(), meta.modified().ok()) {
+                (Some(a), Some(m)) => a.max(m),
+                (a, m)             => a.or(m)?,
+            };
+            Some((used, dir_size(&e.path()), e.path()))
+        })
+        .collect()
+}
+
+/// Elementy do usunięcia (clean, gc): bez katalogów temp działających procesów hl
+fn removable_items(kind: CacheKind) -> Vec<(SystemTime, u64, PathBuf)> {
+    let mut items = cache_items(kind);
+    if kind == CacheKind::Temp {
+        items.retain(|(_, _, path)| !path.file_name().is_some_and(|n| owner_alive(&n.to_string_lossy())));
+    }
+    items
+}
+
+fn remove_item(path: &Path) -> std::io::Result<()> {
+    if path.is_dir() { std::fs::remove_dir_all(path) } else { std::fs::remove_file(path) }
+}
+
+pub fn cmd_cache_info() -> Result<()> {
+    println!("{}", "=== hl cache ===".bright_cyan().bold());
+    let mut total = 0u64;
+    for kind in CacheKind::ALL {
+        let items = cache_items(kind);
+        let size: u64 = items.iter().map(|(_, s, _)| s).sum();
+        total += size;
+        println!("  {} {:>10}  {:>5} el.  {}",
+                 format!("{:<9}", kind.name()).bright_white().bold(),
+                 human_size(size).bright_yellow(),
+                 items.len(),
+                 kind.dir().display().to_string().bright_black());
+    }
+    let limit = configured_max_size()?;
+    println!("  {} {:>10}  limit gc: {}", format!("{:<9}", "razem").bright_white().bold(),
+             human_size(total).bright_yellow(), human_size(limit));
+    println!("  {} {:>10}  {}", format!("{:<9}", "bit libs").bright_black(),
+             human_size(dir_size(&bit_base_dir())), "(instalacje — hl lib remove)".bright_black());
+    Ok(())
+}
+
+pub fn cmd_cache_clean(category: Option<&str>, dry_run: bool) -> Result<()> {
+    let kinds: Vec<CacheKind> = match category {
+        Some(c) => vec![CacheKind::from_str(c)
+            .ok_or_else(|| anyhow::anyhow!("Nieznana kategoria '{}' — bytecode | github | temp | scripts", c))?],
+        None => CacheKind::ALL.to_vec(),
+    };
+    let _libs  = crate::lock::lock_state("libs")?;
+    let _cache = crate::lock::lock_state("cache")?;
+    for kind in kinds {
+        let items = removable_items(kind);
+        let size: u64 = items.iter().map(|(_, s, _)| s).sum();
+        if items.is_empty() {
+            println!("  {} {}: pusty", "·".bright_black(), kind.name());
+            continue;
+        }
+        if !dry_run {
+            for (_, _, path) in &items {
+                if let Err(e) = remove_item(path) { eprintln!("  {} {}: {}", "✗".red(), path.display(), e); }
+            }
+        }
+        let mark = if dry_run { "~".bright_yellow() } else { "✓".green() };
+        println!("  {} {}: {} el. ({})", mark, kind.name(), items.len(), human_size(size));
+    }
+    Ok(())
+}
+
+/// Sprawdź każdy plik .bc w cache przez `verify` (pełne wczytanie modułu)
+pub fn cmd_cache_verify(fix: bool, verify: impl Fn(&Path) -> Result<()>) -> Result<usize> {
+    let dir = CacheKind::Bytecode.dir();
+    let mut bad = 0usize;
+    let mut checked = 0usize;
+    for (_, _, path) in cache_items(CacheKind::Bytecode) {
+        if path.extension().and_then(|e| e.to_str()) != Some("bc") { continue; }
+        checked += 1;
+        if let Err(e) = verify(&path) {
+            bad += 1;
+            println!("  {} {}: {}", "✗".red(), path.file_name().and_then(|n| n.to_str()).unwrap_or("?"), e);
+            if fix { remove_item(&path).ok(); }
+        }
+    }
+    if bad == 0 {
+        println!("{} {} plików .bc poprawnych ({})", "✓".green(), checked, dir.display());
+    } else if fix {
+        println!("{} usunięto {} uszkodzonych z {} plików .bc", "✓".green(), bad, checked);
+    } else {
+        println!("{} {} uszkodzonych z {} — usuń: {}", "✗".red(), bad, checked, "hl cache verify --fix".bright_cyan());
+    }
+    Ok(if fix { 0 } else { bad })
+}
+
+/// LRU: usuwaj najdawniej używane elementy wszystkich kategorii aż do limitu
+pub fn cmd_cache_gc(max_size: Option<&str>, dry_run: bool) -> Result<()> {
+    let limit = match max_size { Some(s) => parse_size(s)?, None => configured_max_size()? };
+    let _libs  = crate::lock::lock_state("libs")?;
+    let _cache = crate::lock::lock_state("cache")?;
+    let mut items: Vec<(SystemTime, u64, PathBuf)> = CacheKind::ALL.iter().flat_map(|k| removable_items(*k)).collect();
+    let mut total: u64 = items.iter().map(|(_, s, _)| s).sum();
+    println!("{} {} / limit {}", "hl cache gc:".bright_magenta().bold(), human_size(total), human_size(limit));
+    if total <= limit {
+        println!("  {} poniżej limitu — nic do zrobienia", "✓".green());
+        return Ok(());
+    }
+
+    items.sort_by_key(|(used, _, _)| *used);
+    let (mut n, mut freed) = (0usize, 0u64);
+    for (_, size, path) in items {
+        if total <= limit { break; }
+        if !dry_run && remove_item(&path).is_err() { continue; }
+        println!("  {} {} ({})", if dry_run { "~".bright_yellow() } else { "-".red() },
+                 path.display(), human_size(size));
+        total -= size;
+        freed += size;
+        n += 1;
+    }
+    println!("  {} {} el., {} {}", if dry_run { "~".bright_yellow() } else { "✓".green() },
+             n, human_size(freed), if dry_run { "do zwolnienia" } else { "zwolniono" });
+    Ok(())
+}
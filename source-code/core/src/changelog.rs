@@ -0,0 +1,150 @@
+use anyhow::{Context, Result};
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::io::IsTerminal;
+use std::path::PathBuf;
+use std::process::{Command, Stdio};
+use std::time::{Duration, SystemTime};
+use crate::compat::{parse_version, HL_VERSION};
+use crate::exit::{classified, ErrorClass};
+
+// ── hl changelog — co nowego od zainstalowanej wersji ─────────────────────────
+//
+//   hl changelog              wydania nowsze niż zainstalowany hl (albo notatki bieżącego)
+//   hl changelog --all        wszystkie wydania
+//   hl changelog --refresh    pobierz listę wydań teraz
+//
+// Lista wydań pochodzi z GitHub Releases API i leży w cache/releases.json.
+// Przy starcie hl sprawdza tylko cache (bez sieci): jeśli jest nowsze wydanie,
+// o którym jeszcze nie mówił, wypisuje jednorazowe powiadomienie na stderr.
+// Cache starszy niż doba jest odświeżany w tle przez `hl changelog --refresh`.
+// Wyłączenie: `[updates] -> notify => false` w config.hk lub HL_NO_UPDATE_NOTICE=1;
+// poza terminalem (CI, potoki) powiadomień nie ma.
+
+pub const RELEASES_API: &str = "https://api.github.com/repos/HackerOS-Linux-System/Hacker-Lang/releases";
+const MAX_AGE: Duration = Duration::from_secs(24 * 3600);
+
+#[derive(Debug, Clone, Deserialize)]
+pub struct Release {
+    pub tag_name:     String,
+    #[serde(default)]
+    pub name:         Option<String>,
+    #[serde(default)]
+    pub body:         Option<String>,
+    #[serde(default)]
+    pub published_at: Option<String>,
+    #[serde(default)]
+    pub html_url:     String,
+    #[serde(default)]
+    pub prerelease:   bool,
+    #[serde(default)]
+    pub draft:        bool,
+}
+
+impl Release {
+    fn version(&self) -> Option<Vec<u64>> { parse_version(&self.tag_name) }
+}
+
+#[derive(Debug, Default, Serialize, Deserialize)]
+struct Seen {
+    notified: Option<String>,
+}
+
+fn cache_file() -> PathBuf { crate::paths::cache_dir().join("releases.json") }
+fn seen_file() -> PathBuf { crate::paths::data_dir("meta").join("changelog-seen.json") }
+
+fn installed() -> Vec<u64> { parse_version(HL_VERSION).unwrap_or_default() }
+
+pub fn refresh() -> Result<()> {
+    let dest = cache_file();
+    if let Some(dir) = dest.parent() { std::fs::create_dir_all(dir)?; }
+    let part = dest.with_extension(format!("json.{}", std::process::id()));
+    if !crate::net::curl_download(RELEASES_API, &part)? {
+        let _ = std::fs::remove_file(&part);
+        return Err(classified(ErrorClass::Dependency, format!("Nie można pobrać listy wydań: {}", RELEASES_API)));
+    }
+    std::fs::rename(&part, &dest)?;
+    Ok(())
+}
+
+/// Wydania z cache (bez szkiców i wersji testowych), od najnowszego
+fn cached_releases() -> Option<Vec<Release>> {
+    let src = std::fs::read_to_string(cache_file()).ok()?;
+    let mut all: Vec<Release> = serde_json::from_str(&src).ok()?;
+    all.retain(|r| !r.draft && !r.prerelease && r.version().is_some());
+    all.sort_by(|a, b| b.version().cmp(&a.version()));
+    Some(all)
+}
+
+fn cache_stale() -> bool {
+    std::fs::metadata(cache_file()).and_then(|m| m.modified()).ok()
+        .and_then(|t| SystemTime::now().duration_since(t).ok())
+        .map_or(true, |age| age > MAX_AGE)
+}
+
+fn notices_enabled() -> bool {
+    std::env::var_os("HL_NO_UPDATE_NOTICE").is_none()
+        && !matches!(crate::config::load_config().get("updates", "notify"), Some("false" | "no" | "0"))
+        && std::io::stderr().is_terminal()
+}
+
+/// Start hl: powiadomienie o nowym wydaniu (raz na wydanie) i odświeżenie cache w tle
+pub fn notice_new_release() {
+    if !notices_enabled() { return; }
+    if cache_stale() {
+        if let Ok(hl) = std::env::current_exe() {
+            let _ = Command::new(hl).args(["changelog", "--refresh", "--quiet"])
+                .env("HL_NO_UPDATE_NOTICE", "1").env("HL_TELEMETRY", "0")
+                .stdin(Stdio::null()).stdout(Stdio::null()).stderr(Stdio::null())
+                .spawn();
+        }
+    }
+    let Some(latest) = cached_releases().and_then(|r| r.into_iter().next()) else { return };
+    if latest.version().unwrap_or_default() <= installed() { return; }
+    let seen: Seen = std::fs::read_to_string(seen_file()).ok()
+        .and_then(|s| serde_json::from_str(&s).ok()).unwrap_or_default();
+    if seen.notified.as_deref() == Some(latest.tag_name.as_str()) { return; }
+    eprintln!("{} dostępna nowa wersja hl {} (zainstalowana {}) — zmiany: {}",
+              "hl:".bright_magenta().bold(), latest.tag_name.bright_green().bold(), HL_VERSION, "hl changelog".bright_cyan());
+    let path = seen_file();
+    if let Some(dir) = path.parent() { let _ = std::fs::create_dir_all(dir); }
+    let _ = serde_json::to_string(&Seen { notified: Some(latest.tag_name) }).map(|s| std::fs::write(&path, s));
+}
+
+fn print_release(r: &Release) {
+    let date = r.published_at.as_deref().map(|d| d.get(..10).unwrap_or(d)).unwrap_or("");
+    let title = r.name.as_deref().filter(|n| !n.is_empty() && *n != r.tag_name).unwrap_or("");
+    println!("\n{} {}  {}", r.tag_name.bright_green().bold(), title.bold(), date.bright_black());
+    for line in r.body.as_deref().unwrap_or("(brak notatek)").lines() {
+        println!("  {}", line.trim_end());
+    }
+    if !r.html_url.is_empty() { println!("  {}", r.html_url.bright_black()); }
+}
+
+pub fn cmd_changelog(all: bool, force_refresh: bool, quiet: bool) -> Result<()> {
+    if force_refresh || cached_releases().is_none() || cache_stale() {
+        if let Err(e) = refresh() {
+            // Bez sieci wystarczy starszy cache
+            if cached_releases().is_none() { return Err(e); }
+            if !quiet { eprintln!("{} {} — pokazuję zapisaną listę", "UWAGA".yellow().bold(), e); }
+        }
+    }
+    if quiet { return Ok(()); }
+    let releases = cached_releases().context("pusta lista wydań")?;
+    let have = installed();
+    println!("{} zainstalowana wersja {}", "hl changelog:".bright_magenta().bold(), HL_VERSION.bright_white());
+    let shown: Vec<&Release> = if all {
+        releases.iter().collect()
+    } else {
+        let newer: Vec<&Release> = releases.iter().filter(|r| r.version().unwrap_or_default() > have).collect();
+        if newer.is_empty() {
+            println!("  {} to najnowsze wydanie", "✓".green());
+            releases.iter().filter(|r| r.version() == Some(have.clone())).collect()
+        } else {
+            println!("  {} nowszych wydań: {}", "↑".yellow(), newer.len());
+            newer
+        }
+    };
+    for r in shown { print_release(r); }
+    Ok(())
+}
@@ -0,0 +1,97 @@
+use colored::Colorize;
+use hl_parser::ast::{CommandMode, Node};
+use std::process::{Command, Stdio};
+use std::sync::atomic::{AtomicBool, AtomicUsize, Ordering};
+use crate::env::Env;
+
+// ── Kroki stanowe i hl run --check-mode ───────────────────────────────────────
+//
+//   ~check(dpkg -s nginx) ^> apt-get install -y nginx
+//   ~check(cmp -s app.conf /etc/app.conf) ^> cp app.conf /etc/app.conf
+//
+// `~check(komenda)` deklaruje, jak sprawdzić, czy następny krok jest potrzebny:
+// kod 0 — system już jest w docelowym stanie. Zwykłe uruchomienie pomija wtedy
+// krok, więc skrypt można bezpiecznie powtarzać.
+//
+// `hl run --check-mode` niczego nie zmienia: kroki `~check` uruchamiają tylko
+// komendę sprawdzającą i raportują „bez zmian” albo „zmieni”. Pozostałe komendy
+// (`>`, `^>`, `&`, hsh, `_>`, narzędzia HackerOS) są pomijane i wypisywane jako
+// nieznane — bez `~check` nie wiadomo, co by zrobiły. `|> @zmienna` działa
+// normalnie (odczyt), żeby warunki dalej w skrypcie miały wartości.
+
+static ENABLED: AtomicBool = AtomicBool::new(false);
+static UNCHANGED: AtomicUsize = AtomicUsize::new(0);
+static CHANGED: AtomicUsize = AtomicUsize::new(0);
+static UNKNOWN: AtomicUsize = AtomicUsize::new(0);
+
+pub fn set_check_mode(on: bool) {
+    ENABLED.store(on, Ordering::Relaxed);
+}
+
+pub fn check_mode() -> bool {
+    ENABLED.load(Ordering::Relaxed)
+}
+
+/// Komenda sprawdzająca (bez wyjścia na terminal); true — kod 0
+pub(crate) fn in_desired_state(check: &str, env: &mut Env) -> bool {
+    let expanded = env.interpolate(check);
+    Command::new("bash").args(["-c", expanded.trim()])
+        .stdin(Stdio::null()).stdout(Stdio::null()).stderr(Stdio::null())
+        .status()
+        .map(|s| s.success())
+        .unwrap_or(false)
+}
+
+fn is_sudo(mode: &CommandMode) -> bool {
+    matches!(mode, CommandMode::Sudo | CommandMode::IsolatedSudo | CommandMode::WithVarsSudo)
+}
+
+/// Krótki opis kroku do raportu
+fn describe(node: &Node, env: &mut Env) -> String {
+    match node {
+        Node::Command { raw, mode, .. } =>
+            format!("{}{}", if is_sudo(mode) { "sudo " } else { "" }, env.interpolate(raw).trim()),
+        Node::HshCommand { raw }         => format!("hsh {}", env.interpolate(raw).trim()),
+        Node::Background { raw, .. }     => format!("& {}", env.interpolate(raw).trim()),
+        Node::ExternDef  { file, .. }    => format!("_> {}", file),
+        Node::HackerOsApi { tool, .. }   => tool.binary_name().to_string(),
+        Node::Retry { body, .. } | Node::Check { body, .. } | Node::Once { body, .. } | Node::Undo { body, .. }
+        | Node::RepeatN { body, .. } =>
+            body.first().map(|n| describe(n, env)).unwrap_or_default(),
+        _ => String::new(),
+    }
+}
+
+/// Wynik kroku `~check` w --check-mode
+pub(crate) fn report_check(in_state: bool, body: &[Node], env: &mut Env) {
+    let what = body.first().map(|n| describe(n, env)).unwrap_or_default();
+    if in_state {
+        UNCHANGED.fetch_add(1, Ordering::Relaxed);
+        eprintln!("  {} {}  {}", "[check]".bright_black(), "✓ bez zmian".green(), what.bright_black());
+    } else {
+        CHANGED.fetch_add(1, Ordering::Relaxed);
+        eprintln!("  {} {}     {}", "[check]".bright_black(), "~ zmieni".yellow().bold(), what);
+    }
+}
+
+/// W --check-mode: krok zmieniający system bez `~check` — pomiń i zgłoś; true — pominięty
+pub(crate) fn skip_unchecked(node: &Node, env: &mut Env) -> bool {
+    if !matches!(node, Node::Command { .. } | Node::HshCommand { .. } | Node::Background { .. }
+                     | Node::ExternDef { .. } | Node::HackerOsApi { .. }) {
+        return false;
+    }
+    UNKNOWN.fetch_add(1, Ordering::Relaxed);
+    eprintln!("  {} {}   {}  {}", "[check]".bright_black(), "· pominięto".bright_black(),
+              describe(node, env), "(brak ~check)".bright_black());
+    true
+}
+
+/// Podsumowanie hl run --check-mode
+pub fn print_check_summary() {
+    let (unchanged, changed, unknown) = (UNCHANGED.load(Ordering::Relaxed), CHANGED.load(Ordering::Relaxed),
+                                         UNKNOWN.load(Ordering::Relaxed));
+    eprintln!();
+    eprintln!("{} {} bez zmian, {} do zmiany, {} nieznanych (brak ~check)",
+              "hl --check-mode:".bright_magenta().bold(),
+              unchanged.to_string().green(), changed.to_string().yellow(), unknown.to_string().bright_black());
+}
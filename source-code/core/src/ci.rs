@@ -0,0 +1,177 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+use crate::ignore::IgnoreRules;
+
+// ── hl ci init ────────────────────────────────────────────────────────────────
+//
+// Generuje konfigurację CI dla bieżącego katalogu projektu:
+//   hl ci init github  → .github/workflows/hacker-lang.yml
+//   hl ci init gitlab  → .gitlab-ci.yml
+//
+// HL działa wyłącznie na HackerOS, więc joby są przypięte do self-hosted
+// runnerów z etykietą `hackeros`. Pipeline:
+//   1. sprawdza toolchain (hl version)
+//   2. przywraca cache .bc (~/.hackeros/hacker-lang/cache)
+//   3. hl check . — każdy plik .hl poza wzorcami .hackerignore (w GitHub Actions
+//      z --format github, więc diagnostyki trafiają jako adnotacje do PR)
+//   4. uruchamia testy z tests/*.hl
+//   5. hl compile dla plików wejściowych
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum CiProvider { GitHub, GitLab }
+
+impl CiProvider {
+    pub fn from_str(s: &str) -> Option<Self> {
+        match s.trim().to_lowercase().as_str() {
+            "github" | "gh"  => Some(CiProvider::GitHub),
+            "gitlab" | "gl"  => Some(CiProvider::GitLab),
+            _                => None,
+        }
+    }
+
+    pub fn output_path(&self, root: &Path) -> PathBuf {
+        match self {
+            CiProvider::GitHub => root.join(".github").join("workflows").join("hacker-lang.yml"),
+            CiProvider::GitLab => root.join(".gitlab-ci.yml"),
+        }
+    }
+}
+
+/// Układ projektu wykryty w katalogu roboczym
+#[derive(Debug, Default)]
+pub struct CiLayout {
+    /// Pliki .hl w katalogu głównym (kandydaci do hl compile)
+    pub entries: Vec<String>,
+    /// Pliki testów tests/*.hl
+    pub tests:   Vec<String>,
+    /// Czy istnieje build.hl
+    pub has_build: bool,
+}
+
+pub fn detect_layout(root: &Path) -> CiLayout {
+    let rules = IgnoreRules::load(root);
+    let mut entries = list_hl_files(root, "", &rules);
+    let has_build = entries.iter().any(|e| e == "build.hl");
+    entries.retain(|e| e != "build.hl");
+    CiLayout { entries, tests: list_hl_files(root, "tests/", &rules), has_build }
+}
+
+/// Pliki .hl w `root/prefix` z pominięciem .hackerignore
+fn list_hl_files(root: &Path, prefix: &str, rules: &IgnoreRules) -> Vec<String> {
+    let mut files: Vec<String> = std::fs::read_dir(root.join(prefix))
+        .map(|rd| rd.flatten()
+            .filter_map(|e| {
+                let path = e.path();
+                if path.extension().and_then(|x| x.to_str()) != Some("hl") { return None; }
+                let name = format!("{}{}", prefix, path.file_name()?.to_str()?);
+                if rules.is_ignored(Path::new(&name), false) { return None; }
+                Some(name)
+            })
+            .collect())
+        .unwrap_or_default();
+    files.sort();
+    files
+}
+
+pub fn render_ci(provider: CiProvider, layout: &CiLayout) -> String {
+    match provider {
+        CiProvider::GitHub => render_github(layout),
+        CiProvider::GitLab => render_gitlab(layout),
+    }
+}
+
+fn check_script(provider: CiProvider) -> &'static str {
+    match provider {
+        CiProvider::GitHub => "hl check --format github .",
+        CiProvider::GitLab => "hl check .",
+    }
+}
+
+fn render_github(layout: &CiLayout) -> String {
+    let mut out = String::new();
+    out.push_str("# Wygenerowane przez: hl ci init github\n");
+    out.push_str("name: hacker-lang\n\n");
+    out.push_str("on:\n  push:\n  pull_request:\n\n");
+    out.push_str("jobs:\n  hl:\n    runs-on: [self-hosted, hackeros]\n    steps:\n");
+    out.push_str("      - uses: actions/checkout@v4\n\n");
+    out.push_str("      - name: Toolchain\n        run: hl version\n\n");
+    out.push_str("      - name: Cache .bc\n        uses: actions/cache@v4\n");
+    out.push_str("        with:\n          path: ~/.hackeros/hacker-lang/cache\n");
+    out.push_str("          key: hl-bc-${{ hashFiles('**/*.hl') }}\n");
+    out.push_str("          restore-keys: hl-bc-\n\n");
+    out.push_str(&format!("      - name: Check\n        run: {}\n", check_script(CiProvider::GitHub)));
+    if !layout.tests.is_empty() {
+        out.push_str("\n      - name: Test\n        run: |\n");
+        for t in &layout.tests {
+            out.push_str(&format!("          hl run {}\n", t));
+        }
+    }
+    if layout.has_build {
+        out.push_str("\n      - name: Build\n        run: hl run build.hl\n");
+    }
+    if !layout.entries.is_empty() {
+        out.push_str("\n      - name: Compile\n        run: |\n");
+        for e in &layout.entries {
+            out.push_str(&format!("          hl compile {}\n", e));
+        }
+    }
+    out
+}
+
+fn render_gitlab(layout: &CiLayout) -> String {
+    let mut out = String::new();
+    out.push_str("# Wygenerowane przez: hl ci init gitlab\n");
+    out.push_str("stages:\n  - check\n  - test\n  - compile\n\n");
+    out.push_str("default:\n  tags: [hackeros]\n  before_script:\n    - hl version\n");
+    out.push_str("  cache:\n    key: hl-bc\n    paths:\n      - .hl-home/.hackeros/hacker-lang/cache/\n\n");
+    out.push_str("variables:\n  HOME: \"$CI_PROJECT_DIR/.hl-home\"\n\n");
+    out.push_str(&format!("hl:check:\n  stage: check\n  script:\n    - {}\n", check_script(CiProvider::GitLab)));
+    if !layout.tests.is_empty() || layout.has_build {
+        out.push_str("\nhl:test:\n  stage: test\n  script:\n");
+        if layout.has_build { out.push_str("    - hl run build.hl\n"); }
+        for t in &layout.tests {
+            out.push_str(&format!("    - hl run {}\n", t));
+        }
+    }
+    if !layout.entries.is_empty() {
+        out.push_str("\nhl:compile:\n  stage: compile\n  script:\n");
+        for e in &layout.entries {
+            out.push_str(&format!("    - hl compile {}\n", e));
+        }
+        out.push_str("  artifacts:\n    paths:\n      - \"*.bc\"\n");
+    }
+    out
+}
+
+pub fn cmd_ci_init(provider: &str, force: bool) -> Result<()> {
+    let provider = match CiProvider::from_str(provider) {
+        Some(p) => p,
+        None    => bail!("Nieznany dostawca CI: '{}' (dostępne: github, gitlab)", provider),
+    };
+    let root = std::env::current_dir()?;
+    let out_path = provider.output_path(&root);
+
+    if out_path.exists() && !force {
+        bail!(
+            "Plik {} już istnieje — użyj {} aby nadpisać",
+            out_path.display(),
+            "--force".bright_yellow()
+        );
+    }
+
+    let layout = detect_layout(&root);
+    if let Some(parent) = out_path.parent() {
+        std::fs::create_dir_all(parent)?;
+    }
+    std::fs::write(&out_path, render_ci(provider, &layout))?;
+
+    println!("{} {}", "hl ci init:".bright_magenta().bold(),
+             out_path.display().to_string().bright_white());
+    println!("  Pliki wejściowe: {}", layout.entries.len().to_string().bright_cyan());
+    println!("  Testy:           {}", layout.tests.len().to_string().bright_cyan());
+    println!("  build.hl:        {}", if layout.has_build { "tak".green() } else { "nie".bright_black() });
+    println!();
+    println!("  {}", "Joby wymagają self-hosted runnera HackerOS z etykietą `hackeros`.".bright_black());
+    Ok(())
+}
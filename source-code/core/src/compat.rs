@@ -0,0 +1,93 @@
+use anyhow::{bail, Result};
+use crate::exit::{classified, ErrorClass};
+
+// ── Zgodność skryptu ze środowiskiem ──────────────────────────────────────────
+//
+//   /// MinHl: 1.2                     minimalna wersja hl (alias: MinRuntime)
+//   /// RequiresOS: debian, hackeros   ID lub ID_LIKE z /etc/os-release
+//
+// hl run sprawdza wymagania przed wykonaniem i kończy się kodem 4 zamiast
+// przerywać skrypt w połowie. hl check zgłasza nieprawidłowe wartości
+// i niezgodność z bieżącym systemem (HL0022).
+
+pub const HL_VERSION: &str = env!("CARGO_PKG_VERSION");
+
+#[derive(Debug, Clone, PartialEq)]
+pub enum Requirement {
+    MinHl(String),
+    Os(Vec<String>),
+}
+
+/// Wymagania z bloku `///` wraz z numerem linii
+pub fn parse_requirements(source: &str) -> Vec<(usize, Requirement)> {
+    let mut reqs = Vec::new();
+    for (i, line) in source.lines().enumerate().take(30) {
+        let t = line.trim();
+        if t.is_empty() || t.starts_with("#!") || t.starts_with("using") || t.starts_with(";;") { continue; }
+        let Some(doc) = t.strip_prefix("///") else { break };
+        let Some((k, v)) = doc.split_once(':') else { continue };
+        let v = v.trim();
+        match k.trim().to_ascii_lowercase().as_str() {
+            "minhl" | "minruntime" => reqs.push((i + 1, Requirement::MinHl(v.to_string()))),
+            "requiresos"           => reqs.push((i + 1, Requirement::Os(
+                v.split(',').map(|s| s.trim().to_ascii_lowercase()).filter(|s| !s.is_empty()).collect()))),
+            _ => {}
+        }
+    }
+    reqs
+}
+
+/// "1.2" / "v1.2.3" → [1, 2] / [1, 2, 3]
+pub(crate) fn parse_version(s: &str) -> Option<Vec<u64>> {
+    let s = s.trim().trim_start_matches('v');
+    if s.is_empty() { return None; }
+    s.split('.').map(|p| p.parse().ok()).collect()
+}
+
+fn version_at_least(current: &[u64], min: &[u64]) -> bool {
+    let len = current.len().max(min.len());
+    let pad = |v: &[u64]| (0..len).map(|i| v.get(i).copied().unwrap_or(0)).collect::<Vec<_>>();
+    pad(current) >= pad(min)
+}
+
+/// ID i ID_LIKE z /etc/os-release (małe litery)
+pub fn os_ids() -> Vec<String> {
+    let release = std::fs::read_to_string("/etc/os-release").unwrap_or_default();
+    release.lines()
+        .filter_map(|l| l.strip_prefix("ID=").or_else(|| l.strip_prefix("ID_LIKE=")))
+        .flat_map(|v| v.trim_matches('"').split_whitespace().map(str::to_ascii_lowercase).collect::<Vec<_>>())
+        .collect()
+}
+
+/// Ok(None) — spełnione; Ok(Some(powód)) — niespełnione; Err — nieprawidłowa wartość
+pub fn check_requirement(req: &Requirement) -> std::result::Result<Option<String>, String> {
+    match req {
+        Requirement::MinHl(min) => {
+            let Some(want) = parse_version(min) else {
+                return Err(format!("nieprawidłowa wersja '{}' w MinHl — oczekiwano np. 1.2", min));
+            };
+            let have = parse_version(HL_VERSION).unwrap_or_default();
+            Ok((!version_at_least(&have, &want))
+                .then(|| format!("skrypt wymaga hl ≥ {} (zainstalowany: {})", min, HL_VERSION)))
+        }
+        Requirement::Os(wanted) => {
+            if wanted.is_empty() { return Err("pusta lista systemów w RequiresOS".into()); }
+            let ids = os_ids();
+            Ok((!wanted.iter().any(|w| ids.contains(w))).then(|| format!(
+                "skrypt wspiera tylko: {} — ten system: {}",
+                wanted.join(", "), if ids.is_empty() { "nieznany".to_string() } else { ids.join(", ") })))
+        }
+    }
+}
+
+/// Sprawdź wymagania przed uruchomieniem (hl run)
+pub fn check_compat(source: &str) -> Result<()> {
+    for (_, req) in parse_requirements(source) {
+        match check_requirement(&req) {
+            Ok(None)         => {}
+            Ok(Some(reason)) => return Err(classified(ErrorClass::Dependency, reason)),
+            Err(e)           => bail!("{}", e),
+        }
+    }
+    Ok(())
+}
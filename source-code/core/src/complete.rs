@@ -0,0 +1,160 @@
+use std::path::Path;
+
+// ── Podpowiedzi powłoki — źródła dynamiczne ───────────────────────────────────
+//
+//   hl completions bash > /etc/bash_completion.d/hl
+//   hl completions zsh  > "${fpath[1]}/_hl"
+//   hl completions fish > ~/.config/fish/completions/hl.fish
+//
+// Skrypt powłoki przy każdym Tab woła `hl __complete -- <słowa>` (ostatnie
+// słowo to wpisywany prefiks). Komendy i flagi bierze CLI z definicji clap,
+// a wartości zależne od katalogu bieżącego i systemu — z funkcji poniżej:
+// pliki .hl / .bc, programy i grupy zależności z bit.hk, biblioteki, zadania
+// z inventory.hk, środowiska hl env, kroki ~once z .hl-state.json, dzienniki
+// hl rollback, skrypty hl exec i lekcje.
+
+pub const BASH: &str = r#"# hl — podpowiedzi bash (hl completions bash)
+_hl() {
+    local IFS=$'\n'
+    COMPREPLY=($(hl __complete -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]] && compopt -o nospace
+}
+complete -o default -F _hl hl
+"#;
+
+pub const ZSH: &str = r#"#compdef hl
+# hl — podpowiedzi zsh (hl completions zsh)
+_hl() {
+    local -a candidates
+    candidates=("${(@f)$(hl __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -Q -- $candidates
+    else
+        _files
+    fi
+}
+compdef _hl hl
+"#;
+
+pub const FISH: &str = r#"# hl — podpowiedzi fish (hl completions fish)
+complete -c hl -f -a '(hl __complete -- (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+"#;
+
+pub fn script(shell: &str) -> Option<&'static str> {
+    match shell {
+        "bash" => Some(BASH),
+        "zsh"  => Some(ZSH),
+        "fish" => Some(FISH),
+        _      => None,
+    }
+}
+
+fn names_in(dir: &Path, keep: impl Fn(&Path) -> bool) -> Vec<String> {
+    let Ok(rd) = std::fs::read_dir(dir) else { return vec![] };
+    let mut out: Vec<String> = rd.flatten()
+        .filter(|e| keep(&e.path()))
+        .filter_map(|e| e.file_name().to_str().map(str::to_string))
+        .collect();
+    out.sort();
+    out
+}
+
+/// Pliki z rozszerzeniem z `exts` (puste — dowolne) i katalogi pasujące do prefiksu
+pub fn files(prefix: &str, exts: &[&str]) -> Vec<String> {
+    let (dir, shown) = match prefix.rfind('/') {
+        Some(i) => (&prefix[..=i], &prefix[..=i]),
+        None    => (".", ""),
+    };
+    let Ok(rd) = std::fs::read_dir(dir) else { return vec![] };
+    let mut out: Vec<String> = rd.flatten().filter_map(|e| {
+        let name = e.file_name().to_str()?.to_string();
+        if name.starts_with('.') && !prefix.rsplit('/').next().unwrap_or("").starts_with('.') { return None; }
+        let path = e.path();
+        if path.is_dir() { return Some(format!("{}{}/", shown, name)); }
+        let ext = path.extension().and_then(|x| x.to_str()).unwrap_or("");
+        (exts.is_empty() || exts.contains(&ext)).then(|| format!("{}{}", shown, name))
+    }).collect();
+    out.sort();
+    out
+}
+
+/// Zainstalowane biblioteki: projektu (libs/, vendor/), bit i GitHub
+pub fn lib_names() -> Vec<String> {
+    let mut out: Vec<String> = Vec::new();
+    for dir in [Path::new("libs").to_path_buf(), Path::new("vendor").to_path_buf(), crate::libs::bit_base_dir()] {
+        out.extend(names_in(&dir, |p| p.is_dir()));
+    }
+    out.extend(names_in(&crate::libs::github_libs_dir(), |p| p.is_dir()).into_iter().map(|n| n.replace("__", "/")));
+    out.sort();
+    out.dedup();
+    out
+}
+
+/// Zadania z [tasks] w inventory.hk katalogu bieżącego
+pub fn task_names() -> Vec<String> {
+    crate::rollout::load_inventory(Path::new(crate::rollout::INVENTORY_FILE))
+        .map(|inv| inv.tasks.into_iter().map(|(name, _)| name).collect())
+        .unwrap_or_default()
+}
+
+/// Programy z [bins] w bit.hk projektu (hl run, --bin)
+pub fn bin_names() -> Vec<String> {
+    crate::project::current_project().map(|p| p.bins.into_iter().map(|b| b.name).collect()).unwrap_or_default()
+}
+
+/// Grupy zależności z [groups] w bit.hk projektu (hl fetch --group)
+pub fn group_names() -> Vec<String> {
+    crate::project::current_project().and_then(|p| p.groups()).unwrap_or_default()
+}
+
+/// Skrypty z [scripts] w bit.hk projektu (hl script)
+pub fn script_names() -> Vec<String> {
+    crate::project::current_project().map(|p| p.scripts.into_iter().map(|(s, _)| s).collect()).unwrap_or_default()
+}
+
+/// Środowiska z [env.<nazwa>] w bit.hk projektu (hl run --env)
+pub fn project_envs() -> Vec<String> {
+    crate::project::current_project().map(|p| p.envs).unwrap_or_default()
+}
+
+/// Grupy i hosty z inventory.hk (dla --group)
+pub fn inventory_groups() -> Vec<String> {
+    crate::rollout::load_inventory(Path::new(crate::rollout::INVENTORY_FILE))
+        .map(|inv| inv.groups.into_iter().map(|(g, _)| g).chain(inv.hosts.into_iter().map(|(h, _)| h)).collect())
+        .unwrap_or_default()
+}
+
+/// Klucze config.hk (sekcja.klucz) dla hl config get / set
+pub fn config_keys() -> Vec<String> {
+    crate::config_check::known_keys()
+}
+
+/// Środowiska hl env
+pub fn env_names() -> Vec<String> {
+    names_in(&crate::config::envs_base_dir(), |p| p.join("env.hk").exists())
+}
+
+/// Kroki ~once zapisane w .hl-state.json katalogu bieżącego
+pub fn state_steps() -> Vec<String> {
+    crate::state::load_state(Path::new(crate::state::STATE_FILE)).steps.into_keys().collect()
+}
+
+/// Dzienniki do cofnięcia (hl rollback)
+pub fn rollback_ids() -> Vec<String> {
+    crate::rollback::journal_ids()
+}
+
+/// Lekcje hl learn
+pub fn lesson_ids() -> Vec<String> {
+    crate::learn::LESSONS.iter().map(|l| l.id.to_string()).collect()
+}
+
+/// Skrypty hl exec (nazwy bez .hl)
+pub fn exec_names(scripts_dir: &Path) -> Vec<String> {
+    let mut out: Vec<String> = names_in(scripts_dir, |p| p.is_file()).into_iter()
+        .map(|n| n.strip_suffix(".hl").map(str::to_string).unwrap_or(n))
+        .collect();
+    out.dedup();
+    out
+}
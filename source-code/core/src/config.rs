@@ -2,32 +2,25 @@ use anyhow::Result;
 use hk_parser::{parse_hk, write_hk_file, HkConfig, HkValue};
 use indexmap::IndexMap;
 use std::path::{Path, PathBuf};
+use crate::paths;
 
-/// Ścieżka do pliku config.hk
+/// Ścieżka do pliku config.hk: $XDG_CONFIG_HOME/hacker-lang/config.hk; stare
+/// ~/.config/hackeros/hacker-lang/config.hk, dopóki tylko ono istnieje
 pub fn config_path() -> PathBuf {
-    dirs::config_dir()
-        .unwrap_or_else(|| PathBuf::from("/tmp"))
-        .join("hackeros")
-        .join("hacker-lang")
-        .join("config.hk")
+    let xdg = paths::xdg_config_path();
+    if xdg.exists() { return xdg; }
+    let legacy = paths::legacy_config_path();
+    if legacy.exists() { legacy } else { xdg }
 }
 
 /// Katalog envów
 pub fn envs_base_dir() -> PathBuf {
-    dirs::home_dir()
-        .unwrap_or_else(|| PathBuf::from("/tmp"))
-        .join(".hackeros")
-        .join("hacker-lang")
-        .join("envs")
+    paths::data_dir("envs")
 }
 
 /// Katalog libs globalny
 pub fn global_libs_dir() -> PathBuf {
-    dirs::home_dir()
-        .unwrap_or_else(|| PathBuf::from("/tmp"))
-        .join(".hackeros")
-        .join("hacker-lang")
-        .join("libs")
+    paths::data_dir("libs")
 }
 
 /// Wrapper nad HkConfig (IndexMap) dla konfiguracji HL
@@ -99,9 +92,7 @@ impl HlConfig {
         if let Some(env_path) = self.active_env_path() {
             env_path.join("bit.lock")
         } else {
-            dirs::home_dir()
-                .unwrap_or_else(|| PathBuf::from("/tmp"))
-                .join(".hackeros/hacker-lang/meta/bit.lock")
+            paths::data_dir("meta").join("bit.lock")
         }
     }
 
@@ -111,6 +102,27 @@ impl HlConfig {
 
     /// Pobierz wewnętrzny HkConfig do serializacji
     pub fn hk_config(&self) -> &HkConfig { &self.inner }
+
+    /// Wszystkie pary klucz → wartość (string) z sekcji, w kolejności z pliku
+    pub fn entries(&self, section: &str) -> Vec<(String, String)> {
+        match self.inner.get(section) {
+            Some(HkValue::Map(map)) => map.iter()
+                .filter_map(|(k, v)| match v {
+                    HkValue::String(s) => Some((k.clone(), s.clone())),
+                    _ => None,
+                })
+                .collect(),
+            _ => vec![],
+        }
+    }
+}
+
+/// Wczytaj dowolny plik .hk (np. serve.hk projektu) jako HlConfig
+pub fn load_hk_file(path: &Path) -> Result<HlConfig> {
+    let content = std::fs::read_to_string(path)?;
+    let inner = parse_hk(&content)
+        .map_err(|e| anyhow::anyhow!("{}: {:?}", path.display(), e))?;
+    Ok(HlConfig { inner })
 }
 
 // ── Odczyt / zapis przez hk-parser ────────────────────────────────────────────
@@ -123,7 +135,10 @@ pub fn load_config() -> HlConfig {
     match std::fs::read_to_string(&path) {
         Ok(content) => match parse_hk(&content) {
             Ok(inner) => HlConfig { inner },
-            Err(_)    => default_config(),
+            Err(e)    => {
+                tracing::warn!("{}: błąd składni ({:?}) — ustawienia domyślne; sprawdź: hl config validate", path.display(), e);
+                default_config()
+            }
         },
         Err(_) => default_config(),
     }
@@ -140,16 +155,14 @@ pub fn save_config(cfg: &HlConfig) -> Result<()> {
 
 fn default_config() -> HlConfig {
     let mut cfg = HlConfig::new();
-    let home = dirs::home_dir().unwrap_or_else(|| PathBuf::from("/home/user"));
-    let base = home.join(".hackeros/hacker-lang");
-
     cfg.set("env", "active",       "");
     cfg.set("env", "active_path",  "");
 
-    cfg.set("paths", "libs",  base.join("libs").to_str().unwrap_or(""));
-    cfg.set("paths", "cache", base.join("cache").to_str().unwrap_or(""));
-    cfg.set("paths", "meta",  base.join("meta").to_str().unwrap_or(""));
-    cfg.set("paths", "envs",  base.join("envs").to_str().unwrap_or(""));
+    cfg.set("paths", "layout", if paths::layout() == paths::Layout::Xdg { "xdg" } else { "legacy" });
+    cfg.set("paths", "libs",  paths::data_dir("libs").to_str().unwrap_or(""));
+    cfg.set("paths", "cache", paths::cache_dir().to_str().unwrap_or(""));
+    cfg.set("paths", "meta",  paths::data_dir("meta").to_str().unwrap_or(""));
+    cfg.set("paths", "envs",  paths::data_dir("envs").to_str().unwrap_or(""));
 
     cfg.set("runtime", "default_gen", "2");
     cfg.set("runtime", "jit",         "false");
@@ -0,0 +1,349 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use hk_parser::{parse_hk, HkValue};
+use rustc_hash::FxHashMap;
+use std::path::Path;
+use crate::config::{config_path, load_config};
+use crate::diagnostics::{Diag, DiagLevel, DiagRenderer, DiagSummary, Span};
+use crate::HL_MAX_GEN;
+
+// ── hl config validate ────────────────────────────────────────────────────────
+//
+// load_config() przy błędzie składni po cichu wraca do ustawień domyślnych,
+// a nieznane klucze są ignorowane. `hl config validate [plik]` sprawdza
+// config.hk względem znanych sekcji i kluczy:
+//   error   — błąd składni, nieprawidłowa wartość (rozmiar, bool, enum, liczba)
+//   warning — nieznana sekcja lub klucz (literówka?)
+
+#[derive(Debug, Clone, Copy)]
+enum Kind {
+    Str,
+    Bool,
+    Int,
+    Size,
+    Gen,
+    Duration,
+    /// `host=ZMIENNA, …`
+    HostVars,
+    OneOf(&'static [&'static str]),
+    ListOf(&'static [&'static str]),
+}
+
+const SCHEMA: &[(&str, &[(&str, Kind)])] = &[
+    ("env",      &[("active", Kind::Str), ("active_path", Kind::Str)]),
+    ("paths",    &[("layout", Kind::OneOf(&["legacy", "xdg"])), ("libs", Kind::Str), ("cache", Kind::Str),
+                   ("meta", Kind::Str), ("envs", Kind::Str), ("lib_path", Kind::Str)]),
+    ("runtime",  &[("default_gen", Kind::Gen), ("jit", Kind::Bool), ("capture_max", Kind::Size),
+                   ("lock_timeout", Kind::Duration)]),
+    ("extern",   &[("python", Kind::Str), ("java", Kind::Str), ("shell", Kind::Str)]),
+    ("deps",     &[("manager", Kind::OneOf(&["apt", "apt-get", "lpm", "dnf", "pacman", "zypper", "apk"])),
+                   ("install", Kind::Str)]),
+    ("cache",    &[("max_size", Kind::Size)]),
+    ("notify",   &[("on", Kind::OneOf(&["failure", "success", "always", "never"])),
+                   ("via", Kind::ListOf(&["desktop", "webhook", "email"])),
+                   ("webhook", Kind::Str), ("email", Kind::Str)]),
+    ("security", &[("signed_only", Kind::Bool)]),
+    ("net",      &[("proxy", Kind::Str), ("no_proxy", Kind::Str), ("ca_bundle", Kind::Str),
+                   ("client_cert", Kind::Str), ("client_key", Kind::Str), ("tokens", Kind::HostVars),
+                   ("limit_rate", Kind::Size), ("parallel", Kind::Int)]),
+    ("sudo",     &[("preflight", Kind::Bool)]),
+    ("repl",     &[("history_size", Kind::Int)]),
+    ("telemetry", &[("enabled", Kind::Bool), ("endpoint", Kind::Str)]),
+    ("updates",  &[("notify", Kind::Bool)]),
+];
+
+const BOOLS: &[&str] = &["true", "false", "yes", "no", "1", "0"];
+
+fn check_value(kind: Kind, v: &str) -> Option<String> {
+    let v = v.trim();
+    match kind {
+        Kind::Str  => None,
+        Kind::Bool => (!BOOLS.contains(&v)).then(|| format!("oczekiwano true | false, jest '{}'", v)),
+        Kind::Int  => v.parse::<u64>().is_err().then(|| format!("oczekiwano liczby, jest '{}'", v)),
+        Kind::Size => crate::cache::parse_size(v).err().map(|e| e.to_string()),
+        Kind::Duration => hl_parser::ast::parse_delay_ms(v).is_none()
+            .then(|| format!("oczekiwano czasu (30s, 5m, 1h), jest '{}'", v)),
+        Kind::HostVars => crate::net::parse_tokens(v).err(),
+        Kind::Gen  => match v.parse::<u32>() {
+            Ok(g) if (1..=HL_MAX_GEN).contains(&g) => None,
+            _ => Some(format!("gen musi być liczbą 1..{}, jest '{}'", HL_MAX_GEN, v)),
+        },
+        Kind::OneOf(opts) => (!opts.contains(&v)).then(|| format!("'{}' — dozwolone: {}", v, opts.join(" | "))),
+        Kind::ListOf(opts) => v.split(',').map(str::trim).find(|x| !x.is_empty() && !opts.contains(x))
+            .map(|x| format!("'{}' — dozwolone: {}", x, opts.join(", "))),
+    }
+}
+
+/// Numery linii sekcji i kluczy (`[sekcja]`, `-> klucz => wartość`)
+fn locate(source: &str) -> (FxHashMap<String, usize>, FxHashMap<(String, String), usize>) {
+    let (mut sections, mut keys) = (FxHashMap::default(), FxHashMap::default());
+    let mut current = String::new();
+    for (i, line) in source.lines().enumerate() {
+        let t = line.trim();
+        if let Some(name) = t.strip_prefix('[').and_then(|r| r.strip_suffix(']')) {
+            current = name.trim().to_string();
+            sections.entry(current.clone()).or_insert(i + 1);
+        } else if let Some(rest) = t.strip_prefix("->") {
+            let key = rest.split("=>").next().unwrap_or("").trim().to_string();
+            keys.entry((current.clone(), key)).or_insert(i + 1);
+        }
+    }
+    (sections, keys)
+}
+
+/// Walidacja treści config.hk; zwraca diagnostyki z numerami linii
+pub fn validate_config_source(source: &str) -> Vec<Diag> {
+    let parsed = match parse_hk(source) {
+        Ok(p)  => p,
+        Err(e) => return vec![Diag::error(format!("błąd składni .hk: {:?}", e))
+            .with_note("hl używa teraz ustawień domyślnych zamiast tego pliku")],
+    };
+    let (section_lines, key_lines) = locate(source);
+    let span_for = |line: Option<&usize>| Span::line_only(line.copied().unwrap_or(1));
+    let mut diags = Vec::new();
+
+    for (section, value) in parsed.iter() {
+        let Some((_, known)) = SCHEMA.iter().find(|(s, _)| *s == section.as_str()) else {
+            let names: Vec<&str> = SCHEMA.iter().map(|(s, _)| *s).collect();
+            diags.push(Diag::warning(format!("nieznana sekcja [{}]", section))
+                .with_span(span_for(section_lines.get(section)))
+                .with_note(format!("znane sekcje: {}", names.join(", "))));
+            continue;
+        };
+        let HkValue::Map(map) = value else { continue };
+        for (key, val) in map {
+            let line = key_lines.get(&(section.clone(), key.clone()));
+            let Some((_, kind)) = known.iter().find(|(k, _)| *k == key.as_str()) else {
+                let names: Vec<&str> = known.iter().map(|(k, _)| *k).collect();
+                diags.push(Diag::warning(format!("nieznany klucz `{}` w [{}]", key, section))
+                    .with_span(span_for(line))
+                    .with_note(format!("znane klucze: {}", names.join(", "))));
+                continue;
+            };
+            let HkValue::String(s) = val else { continue };
+            if let Some(msg) = check_value(*kind, s) {
+                diags.push(Diag::error(format!("[{}] {}: {}", section, key, msg)).with_span(span_for(line)));
+            }
+        }
+    }
+    diags
+}
+
+// ── bit.hk ────────────────────────────────────────────────────────────────────
+//
+// `hl config validate bit.hk` (i samo `hl config validate` w projekcie) sprawdza
+// manifest projektu: znane sekcje, klucze [project], pliki entry i programów
+// z [bins], klucze overlay [env.<nazwa>], [notify] według schematu config.hk. Reguły nazw programów i [scripts]
+// sprawdza project::load_project — tak samo jak przy hl run.
+
+const PROJECT_SECTIONS: &[&str] = &["project", "dependencies", "pins", "bins", "groups", "scripts", "notify"];
+const PROJECT_KEYS: &[&str] = &["name", "entry", "version", "type"];
+
+/// Walidacja treści bit.hk; `path` — do rozwiązania ścieżek i load_project
+pub fn validate_project_source(path: &Path, source: &str) -> Vec<Diag> {
+    if let Err(e) = parse_hk(source) {
+        return vec![Diag::error(format!("błąd składni .hk: {:?}", e))];
+    }
+    let dir = path.parent().unwrap_or(Path::new("."));
+    let mut diags = Vec::new();
+    let mut section = String::new();
+    for (i, line) in source.lines().enumerate() {
+        let t = line.trim();
+        let t = t[..t.len() - trailing_comment(t).len()].trim_end();
+        let span = Span::line_only(i + 1);
+        if let Some(name) = t.strip_prefix('[').and_then(|r| r.strip_suffix(']')) {
+            section = name.trim().to_string();
+            let env = section.strip_prefix("env.").is_some_and(|e| !e.is_empty());
+            if !env && !PROJECT_SECTIONS.contains(&section.as_str()) {
+                diags.push(Diag::warning(format!("nieznana sekcja [{}]", section)).with_span(span)
+                    .with_note(format!("znane sekcje: {}, env.<nazwa>", PROJECT_SECTIONS.join(", "))));
+            }
+            continue;
+        }
+        let Some(rest) = t.strip_prefix("->") else { continue };
+        let (key, value) = match rest.split_once("=>") {
+            Some((k, v)) => (k.trim(), v.trim().trim_matches('"')),
+            None         => (rest.trim(), ""),
+        };
+        let missing = |what: &str| {
+            let file = dir.join(value);
+            (!value.is_empty() && !file.exists()).then(|| Diag::error(format!("{} {} nie istnieje", what, file.display())).with_span(span.clone()))
+        };
+        match section.as_str() {
+            "project" if !PROJECT_KEYS.contains(&key) => diags.push(Diag::warning(format!("nieznany klucz `{}` w [project]", key))
+                .with_span(span.clone()).with_note(format!("znane klucze: {}", PROJECT_KEYS.join(", ")))),
+            "project" if key == "entry" => diags.extend(missing("[project] entry:")),
+            "bins" => diags.extend(missing(&format!("[bins] {}:", key))),
+            "groups" if value.is_empty() => diags.push(Diag::warning(format!("grupa `{}` bez pakietów", key)).with_span(span.clone())),
+            // [notify] projektu — ten sam schemat co w config.hk
+            "notify" => if let Err(e) = check_setting("notify", key, value, false) {
+                diags.push(Diag::error(e.to_string()).with_span(span.clone()));
+            },
+            s if s.starts_with("env.") => match key {
+                "entry" => diags.extend(missing(&format!("[{}] entry:", s))),
+                k if k.starts_with("scripts.") || crate::dotenv::valid_name(k) => {}
+                k => diags.push(Diag::error(format!("[{}] '{}' — klucz to `entry`, `scripts.<nazwa>` albo nazwa zmiennej", s, k))
+                    .with_span(span.clone())),
+            },
+            _ => {}
+        }
+    }
+    if let Err(e) = crate::project::load_project(path) {
+        diags.push(Diag::error(format!("{:#}", e)));
+    }
+    diags
+}
+
+/// hl config validate — Ok(true), gdy brak błędów; bit.hk sprawdzany jako manifest projektu
+pub fn cmd_config_validate(path: &Path) -> Result<bool> {
+    if !path.exists() {
+        println!("{} {} nie istnieje — hl używa ustawień domyślnych", "·".bright_black(), path.display());
+        return Ok(true);
+    }
+    let source = std::fs::read_to_string(path)?;
+    let is_project = path.file_name().and_then(|n| n.to_str()) == Some(crate::project::PROJECT_FILE);
+    let diags = if is_project { validate_project_source(path, &source) } else { validate_config_source(&source) };
+    let fname = path.file_name().and_then(|n| n.to_str()).unwrap_or("config.hk");
+    if diags.is_empty() {
+        println!("{} {}", "OK".green().bold(), path.display().to_string().bright_white());
+        return Ok(true);
+    }
+    DiagRenderer::new(fname, &source).emit_all(&diags);
+    DiagSummary::from_diags(&diags).print();
+    Ok(!diags.iter().any(|d| d.level == DiagLevel::Error))
+}
+
+// ── hl config get / set / list / edit ─────────────────────────────────────────
+//
+//   hl config get runtime.jit
+//   hl config set net.proxy http://proxy:3128
+//   hl config list
+//   hl config edit                   $VISUAL / $EDITOR, potem walidacja
+//
+// `set` zmienia tylko wartość w linii klucza (albo dopisuje linię na końcu
+// sekcji), więc komentarze `! …` — także ten za wartością — i układ pliku zostają. Klucz i wartość są sprawdzane względem
+// schematu przed zapisem; --force pozwala na klucz spoza schematu.
+
+fn split_key(key: &str) -> Result<(&str, &str)> {
+    match key.split_once('.') {
+        Some((s, k)) if !s.is_empty() && !k.is_empty() => Ok((s, k)),
+        _ => bail!("klucz '{}' — oczekiwano sekcja.klucz (np. runtime.jit)", key),
+    }
+}
+
+/// Wszystkie klucze schematu jako `sekcja.klucz`
+pub fn known_keys() -> Vec<String> {
+    SCHEMA.iter().flat_map(|(s, keys)| keys.iter().map(move |(k, _)| format!("{}.{}", s, k))).collect()
+}
+
+fn check_setting(section: &str, key: &str, value: &str, force: bool) -> Result<()> {
+    let known = SCHEMA.iter().find(|(s, _)| *s == section).and_then(|(_, keys)| keys.iter().find(|(k, _)| *k == key));
+    match known {
+        Some((_, kind)) => match check_value(*kind, value) {
+            Some(msg) => bail!("[{}] {}: {}", section, key, msg),
+            None      => Ok(()),
+        },
+        None if force => Ok(()),
+        None => bail!("nieznany klucz {}.{} — sprawdź `hl config list` albo użyj --force", section, key),
+    }
+}
+
+fn quote(value: &str) -> String {
+    format!("\"{}\"", value.replace('\\', "\\\\").replace('"', "\\\""))
+}
+
+/// Komentarz `! …` na końcu linii (poza cudzysłowem), razem z odstępem przed nim
+fn trailing_comment(line: &str) -> &str {
+    let mut quoted = false;
+    let mut prev = ' ';
+    for (i, c) in line.char_indices() {
+        match c {
+            '"' => quoted = !quoted,
+            '!' if !quoted && prev.is_whitespace() => {
+                let start = line[..i].trim_end().len();
+                return &line[start..];
+            }
+            _ => {}
+        }
+        prev = c;
+    }
+    ""
+}
+
+/// Treść pliku z ustawionym kluczem — reszta linii bez zmian
+fn set_in_source(source: &str, section: &str, key: &str, value: &str) -> String {
+    let mut lines: Vec<String> = source.lines().map(str::to_string).collect();
+    let header = |l: &str| l.trim().strip_prefix('[').and_then(|r| r.strip_suffix(']')).map(|n| n.trim().to_string());
+    let entry = format!("-> {} => {}", key, quote(value));
+    let Some(start) = lines.iter().position(|l| header(l).as_deref() == Some(section)) else {
+        if lines.last().is_some_and(|l| !l.trim().is_empty()) { lines.push(String::new()); }
+        lines.push(format!("[{}]", section));
+        lines.push(entry);
+        return lines.join("\n") + "\n";
+    };
+    let end = lines[start + 1..].iter().position(|l| header(l).is_some()).map_or(lines.len(), |i| start + 1 + i);
+    let existing = (start + 1..end).find(|&i| lines[i].trim().strip_prefix("->")
+        .is_some_and(|r| r.split("=>").next().unwrap_or("").trim() == key));
+    match existing {
+        Some(i) => {
+            let indent: String = lines[i].chars().take_while(|c| c.is_whitespace()).collect();
+            lines[i] = format!("{}{}{}", indent, entry, trailing_comment(&lines[i]));
+        }
+        None => {
+            let last = (start..end).rev().find(|&i| !lines[i].trim().is_empty()).unwrap_or(start);
+            lines.insert(last + 1, entry);
+        }
+    }
+    lines.join("\n") + "\n"
+}
+
+pub fn cmd_config_get(key: &str) -> Result<bool> {
+    let (section, k) = split_key(key)?;
+    match load_config().get(section, k) {
+        Some(v) => { println!("{}", v); Ok(true) }
+        None    => { eprintln!("{} {} nie jest ustawione", "·".bright_black(), key); Ok(false) }
+    }
+}
+
+pub fn cmd_config_set(key: &str, value: &str, force: bool) -> Result<()> {
+    let (section, k) = split_key(key)?;
+    check_setting(section, k, value, force)?;
+    let path = config_path();
+    let source = std::fs::read_to_string(&path).unwrap_or_default();
+    let updated = set_in_source(&source, section, k, value);
+    if let Err(e) = parse_hk(&updated) {
+        bail!("{}: po zmianie plik nie jest poprawnym .hk ({:?}) — nie zapisano; popraw: hl config edit", path.display(), e);
+    }
+    if let Some(parent) = path.parent() { std::fs::create_dir_all(parent)?; }
+    let tmp = path.with_extension(format!("hk.{}", std::process::id()));
+    std::fs::write(&tmp, updated)?;
+    std::fs::rename(&tmp, &path)?;
+    println!("{} {} = {}", "✓".green(), key.bright_white(), value);
+    Ok(())
+}
+
+pub fn cmd_config_list() -> Result<()> {
+    let path = config_path();
+    let source = if path.exists() { path.display().to_string() } else { "ustawienia domyślne".to_string() };
+    println!("{} {}", "hl config:".bright_magenta().bold(), source.bright_black());
+    for (section, value) in load_config().hk_config().iter() {
+        let HkValue::Map(map) = value else { continue };
+        for (key, val) in map {
+            let HkValue::String(v) = val else { continue };
+            println!("  {} = {}", format!("{}.{}", section, key).bright_white(), v);
+        }
+    }
+    Ok(())
+}
+
+/// hl config edit — Ok(true), gdy plik po edycji przechodzi walidację
+pub fn cmd_config_edit() -> Result<bool> {
+    let path = config_path();
+    if let Some(parent) = path.parent() { std::fs::create_dir_all(parent)?; }
+    if !path.exists() { std::fs::write(&path, "")?; }
+    let editor = std::env::var("VISUAL").or_else(|_| std::env::var("EDITOR")).unwrap_or_else(|_| "vi".into());
+    // $EDITOR może zawierać argumenty (np. "code --wait")
+    let status = std::process::Command::new("sh").arg("-c").arg(format!("{} \"$1\"", editor)).arg("sh").arg(&path).status()?;
+    if !status.success() { bail!("{} zakończył się kodem {}", editor, status.code().unwrap_or(1)); }
+    cmd_config_validate(&path)
+}
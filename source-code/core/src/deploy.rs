@@ -0,0 +1,216 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+use std::process::Command;
+
+// ── hl deploy systemd ─────────────────────────────────────────────────────────
+//
+// Generuje jednostkę systemd (.service) i opcjonalnie .timer dla skryptu .hl
+// lub skompilowanego .bc. Usługa uruchamia `hl run <skrypt>`.
+//
+//   hl deploy systemd backup --script backup.hl --on-calendar daily
+//   hl deploy systemd backup --script backup.bc --user --install
+//
+// Bez --install pliki trafiają do bieżącego katalogu.
+// Z --install: /etc/systemd/system (przez sudo) lub ~/.config/systemd/user (--user)
+// + systemctl daemon-reload + enable --now dla timera/usługi.
+// Nazwa jednostki: tylko [A-Za-z0-9_.@-]; ścieżki w ExecStart= są cytowane
+// według reguł systemd (\\, \", %% i $$), więc spacje i `%` w ścieżce są bezpieczne.
+
+#[derive(Debug, Clone)]
+pub struct SystemdOptions {
+    pub name:        String,
+    pub script:      PathBuf,
+    pub user:        bool,
+    pub install:     bool,
+    pub on_calendar: Option<String>,
+    pub env_file:    Option<PathBuf>,
+    pub restart:     String,
+    pub sandbox:     bool,
+}
+
+pub fn hl_binary() -> String {
+    std::env::current_exe()
+        .map(|p| p.display().to_string())
+        .unwrap_or_else(|_| "/usr/bin/hl".into())
+}
+
+/// Nazwa jednostki / zadania: niepusta, tylko [A-Za-z0-9_.@-], bez `.` / `..`
+pub(crate) fn validate_unit_name(name: &str) -> Result<()> {
+    let ok = !name.is_empty() && name != "." && name != ".."
+        && name.chars().all(|c| c.is_ascii_alphanumeric() || matches!(c, '_' | '.' | '@' | '-'));
+    if !ok { bail!("Nieprawidłowa nazwa '{}' — dozwolone znaki: A-Z a-z 0-9 _ . @ -", name); }
+    Ok(())
+}
+
+/// Wartość jednej linii pliku jednostki — bez znaków nowej linii i sterujących
+fn single_line(what: &str, value: &str) -> Result<()> {
+    if value.chars().any(|c| c.is_control()) { bail!("{}: niedozwolony znak sterujący w '{}'", what, value.escape_debug()); }
+    Ok(())
+}
+
+/// Argument ExecStart= w cudzysłowie — systemd rozwija `%` i `$`, więc są podwajane
+fn systemd_quote(s: &str) -> String {
+    format!("\"{}\"", s.replace('\\', "\\\\").replace('"', "\\\"").replace('%', "%%").replace('$', "$$"))
+}
+
+/// Ścieżka w dyrektywie bez cudzysłowów (WorkingDirectory=, EnvironmentFile=)
+fn systemd_path(p: &Path) -> String {
+    p.display().to_string().replace('%', "%%")
+}
+
+fn absolute(path: &Path) -> PathBuf {
+    std::fs::canonicalize(path).unwrap_or_else(|_| {
+        std::env::current_dir().unwrap_or_default().join(path)
+    })
+}
+
+pub fn render_service(opts: &SystemdOptions) -> String {
+    let script  = absolute(&opts.script);
+    let workdir = script.parent().map(|p| p.to_path_buf()).unwrap_or_else(|| PathBuf::from("/"));
+    // Usługa odpalana przez timer to oneshot — restart ma sens tylko dla usług ciągłych
+    let oneshot = opts.on_calendar.is_some();
+
+    let mut out = String::new();
+    out.push_str("# Wygenerowane przez: hl deploy systemd\n");
+    out.push_str("[Unit]\n");
+    out.push_str(&format!("Description=Hacker Lang: {}\n", opts.name));
+    out.push_str("After=network-online.target\nWants=network-online.target\n\n");
+    out.push_str("[Service]\n");
+    out.push_str(if oneshot { "Type=oneshot\n" } else { "Type=simple\n" });
+    out.push_str(&format!("WorkingDirectory={}\n", systemd_path(&workdir)));
+    out.push_str(&format!("ExecStart={} run {}\n", systemd_quote(&hl_binary()), systemd_quote(&script.display().to_string())));
+    out.push_str(&format!("Environment=HL_EXEC_NAME={}\n", opts.name));
+    if let Some(ref env_file) = opts.env_file {
+        out.push_str(&format!("EnvironmentFile={}\n", systemd_path(&absolute(env_file))));
+    }
+    if !oneshot {
+        out.push_str(&format!("Restart={}\nRestartSec=5\n", opts.restart));
+    }
+    if opts.sandbox {
+        out.push_str("NoNewPrivileges=yes\n");
+        out.push_str("PrivateTmp=yes\n");
+        out.push_str("ProtectSystem=full\n");
+        out.push_str("ProtectKernelTunables=yes\n");
+        out.push_str("ProtectControlGroups=yes\n");
+        out.push_str("RestrictSUIDSGID=yes\n");
+    }
+    out.push_str("\n[Install]\n");
+    out.push_str(if opts.user { "WantedBy=default.target\n" } else { "WantedBy=multi-user.target\n" });
+    out
+}
+
+pub fn render_timer(name: &str, on_calendar: &str) -> String {
+    let mut out = String::new();
+    out.push_str("# Wygenerowane przez: hl deploy systemd\n");
+    out.push_str("[Unit]\n");
+    out.push_str(&format!("Description=Hacker Lang timer: {}\n\n", name));
+    out.push_str("[Timer]\n");
+    out.push_str(&format!("OnCalendar={}\n", on_calendar));
+    out.push_str("Persistent=true\n\n");
+    out.push_str("[Install]\nWantedBy=timers.target\n");
+    out
+}
+
+pub fn systemd_unit_dir(user: bool) -> PathBuf {
+    if user {
+        dirs::config_dir()
+            .unwrap_or_else(|| PathBuf::from("/tmp"))
+            .join("systemd")
+            .join("user")
+    } else {
+        PathBuf::from("/etc/systemd/system")
+    }
+}
+
+fn systemctl(user: bool, args: &[&str]) -> Result<()> {
+    let status = if user {
+        Command::new("systemctl").arg("--user").args(args).status()?
+    } else {
+        Command::new("sudo").arg("systemctl").args(args).status()?
+    };
+    if !status.success() { bail!("systemctl {} zakończone błędem", args.join(" ")); }
+    Ok(())
+}
+
+fn write_unit(path: &Path, content: &str, user: bool, install: bool) -> Result<()> {
+    if install && !user {
+        // /etc/systemd/system wymaga roota — zapis przez sudo tee
+        let mut child = Command::new("sudo")
+            .args(["tee", path.to_str().unwrap_or("")])
+            .stdin(std::process::Stdio::piped())
+            .stdout(std::process::Stdio::null())
+            .spawn()?;
+        if let Some(stdin) = child.stdin.as_mut() {
+            use std::io::Write;
+            stdin.write_all(content.as_bytes())?;
+        }
+        if !child.wait()?.success() { bail!("Nie można zapisać {}", path.display()); }
+        return Ok(());
+    }
+    if let Some(parent) = path.parent() { std::fs::create_dir_all(parent)?; }
+    std::fs::write(path, content)?;
+    Ok(())
+}
+
+pub fn cmd_deploy_systemd(opts: &SystemdOptions) -> Result<()> {
+    validate_unit_name(&opts.name)?;
+    single_line("--restart", &opts.restart)?;
+    if let Some(ref cal) = opts.on_calendar { single_line("--on-calendar", cal)?; }
+    for p in [Some(&opts.script), opts.env_file.as_ref()].into_iter().flatten() {
+        single_line("ścieżka", &p.display().to_string())?;
+    }
+    if !opts.script.exists() {
+        bail!("Plik nie istnieje: {}", opts.script.display());
+    }
+    let ext = opts.script.extension().and_then(|e| e.to_str()).unwrap_or("");
+    if ext != "hl" && ext != "bc" {
+        bail!("Oczekiwano pliku .hl lub .bc, otrzymano: {}", opts.script.display());
+    }
+
+    let out_dir = if opts.install {
+        systemd_unit_dir(opts.user)
+    } else {
+        std::env::current_dir()?
+    };
+    let service_path = out_dir.join(format!("hl-{}.service", opts.name));
+    let timer_path   = out_dir.join(format!("hl-{}.timer", opts.name));
+
+    println!("{} {}", "hl deploy systemd:".bright_magenta().bold(), opts.name.bright_cyan().bold());
+
+    write_unit(&service_path, &render_service(opts), opts.user, opts.install)?;
+    println!("  {} {}", "✓".green(), service_path.display().to_string().bright_white());
+
+    if let Some(ref cal) = opts.on_calendar {
+        write_unit(&timer_path, &render_timer(&opts.name, cal), opts.user, opts.install)?;
+        println!("  {} {}", "✓".green(), timer_path.display().to_string().bright_white());
+    }
+
+    if opts.install {
+        systemctl(opts.user, &["daemon-reload"])?;
+        let unit = if opts.on_calendar.is_some() {
+            format!("hl-{}.timer", opts.name)
+        } else {
+            format!("hl-{}.service", opts.name)
+        };
+        systemctl(opts.user, &["enable", "--now", &unit])?;
+        println!("  {} włączono {}", "✓".green(), unit.bright_cyan());
+    } else {
+        println!();
+        println!("  Aby zainstalować: {}", install_hint(opts).bright_cyan());
+    }
+    Ok(())
+}
+
+/// Polecenie instalacji z tymi samymi opcjami, z którymi wygenerowano pliki
+fn install_hint(opts: &SystemdOptions) -> String {
+    let q = crate::remote::shell_quote;
+    let mut cmd = format!("hl deploy systemd {} --script {}", opts.name, q(&opts.script.display().to_string()));
+    if let Some(ref cal) = opts.on_calendar { cmd.push_str(&format!(" --on-calendar {}", q(cal))); }
+    if let Some(ref env) = opts.env_file { cmd.push_str(&format!(" --env-file {}", q(&env.display().to_string()))); }
+    if opts.restart != "on-failure" { cmd.push_str(&format!(" --restart {}", q(&opts.restart))); }
+    if !opts.sandbox { cmd.push_str(" --no-sandbox"); }
+    if opts.user { cmd.push_str(" --user"); }
+    cmd.push_str(" --install");
+    cmd
+}
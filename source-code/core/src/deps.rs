@@ -1,25 +1,95 @@
 use anyhow::{Context, Result};
 use std::process::Command;
 use tracing::{info, warn};
+use crate::config::load_config;
 
 pub fn is_installed(name: &str) -> bool { which::which(name).is_ok() }
 
-/// Zainstaluj pakiet przez apt-get lub lpm.
-/// `apt_name` — co zainstalować (może się różnić od nazwy binارki).
+// ── Menedżery pakietów ────────────────────────────────────────────────────────
+//
+// Kolejność wykrywania: apt-get, lpm, dnf, pacman, zypper, apk.
+// Nadpisanie w config.hk:
+//
+//   [deps]
+//   -> manager => dnf                              ! wymuś menedżer
+//   -> install => "sudo xbps-install -y {pkg}"     ! własna komenda ({pkg} = nazwa)
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum PackageManager { Apt, Lpm, Dnf, Pacman, Zypper, Apk }
+
+impl PackageManager {
+    pub const ALL: [PackageManager; 6] = [
+        PackageManager::Apt, PackageManager::Lpm, PackageManager::Dnf,
+        PackageManager::Pacman, PackageManager::Zypper, PackageManager::Apk,
+    ];
+
+    pub fn from_str(s: &str) -> Option<Self> {
+        match s.trim() {
+            "apt" | "apt-get" => Some(PackageManager::Apt),
+            "lpm"             => Some(PackageManager::Lpm),
+            "dnf"             => Some(PackageManager::Dnf),
+            "pacman"          => Some(PackageManager::Pacman),
+            "zypper"          => Some(PackageManager::Zypper),
+            "apk"             => Some(PackageManager::Apk),
+            _                 => None,
+        }
+    }
+
+    pub fn binary(&self) -> &'static str {
+        match self {
+            PackageManager::Apt    => "apt-get",
+            PackageManager::Lpm    => "lpm",
+            PackageManager::Dnf    => "dnf",
+            PackageManager::Pacman => "pacman",
+            PackageManager::Zypper => "zypper",
+            PackageManager::Apk    => "apk",
+        }
+    }
+
+    /// Argumenty instalacji (bez sudo)
+    pub fn install_args(&self, pkg: &str) -> Vec<String> {
+        let args: &[&str] = match self {
+            PackageManager::Apt    => &["apt-get", "-y", "install"],
+            PackageManager::Lpm    => &["lpm", "install"],
+            PackageManager::Dnf    => &["dnf", "-y", "install"],
+            PackageManager::Pacman => &["pacman", "-S", "--noconfirm", "--needed"],
+            PackageManager::Zypper => &["zypper", "--non-interactive", "install"],
+            PackageManager::Apk    => &["apk", "add"],
+        };
+        args.iter().map(|a| a.to_string()).chain([pkg.to_string()]).collect()
+    }
+}
+
+/// Menedżery dostępne w systemie (lub tylko ten z [deps] manager)
+pub fn detect_package_managers() -> Vec<PackageManager> {
+    let cfg = load_config();
+    if let Some(pm) = cfg.get("deps", "manager").and_then(PackageManager::from_str) {
+        return vec![pm];
+    }
+    PackageManager::ALL.into_iter().filter(|pm| is_installed(pm.binary())).collect()
+}
+
+/// `{pkg}` trafia do komendy jako "$1" — nazwa pakietu nigdy nie jest interpretowana przez powłokę
+fn install_custom(template: &str, pkg: &str) -> Result<bool> {
+    let cmd = template.replace("{pkg}", "\"$1\"");
+    info!("Installing '{}' via custom command: {}", pkg, cmd);
+    let s = Command::new("sh").args(["-c", &cmd, "sh", pkg]).status()
+        .context("Failed to run custom install command")?;
+    Ok(s.success())
+}
+
+/// Zainstaluj pakiet: własna komenda z [deps] install albo wykryty menedżer.
+/// `apt_name` — co zainstalować (może się różnić od nazwy binarki).
 pub fn install_package(apt_name: &str) -> Result<bool> {
-    if which::which("apt-get").is_ok() {
-        info!("Installing '{}' via apt-get...", apt_name);
-        let s = Command::new("sudo")
-            .args(["apt-get", "-y", "install", apt_name])
-            .status()
-            .context("Failed to run sudo apt-get")?;
-        if s.success() { return Ok(true); }
+    if let Some(template) = load_config().get("deps", "install") {
+        if install_custom(template, apt_name)? { return Ok(true); }
     }
-    if which::which("lpm").is_ok() {
+    for pm in detect_package_managers() {
+        info!("Installing '{}' via {}...", apt_name, pm.binary());
         let s = Command::new("sudo")
-            .args(["lpm", "install", apt_name])
+            .args(pm.install_args(apt_name))
             .status()
-            .context("Failed lpm")?;
+            .with_context(|| format!("Failed to run sudo {}", pm.binary()))?;
         if s.success() { return Ok(true); }
     }
     warn!("Could not install '{}'", apt_name);
@@ -47,10 +117,15 @@ pub fn resolve_dependency(bin_name: &str, apt_package: Option<&str>) -> Result<D
 
     eprintln!(
         "\x1b[33m[hl dep]\x1b[0m '{bin}' nie znalezione. \
-        Próbuję: apt install {pkg}..."
+        Próbuję zainstalować pakiet {pkg}..."
     );
 
-    match install_package(pkg) {
+    let installed = install_package(pkg);
+    crate::serve::record_event(match installed {
+        Ok(true) => crate::serve::EVENT_DEP_INSTALLED,
+        _        => crate::serve::EVENT_DEP_FAILED,
+    });
+    match installed {
         Ok(true) => {
             // Sprawdź ponownie czy binarka teraz dostępna
             if is_installed(bin) {
@@ -0,0 +1,200 @@
+use anyhow::{bail, Result};
+use std::io::{BufRead, IsTerminal, Read, Write};
+use std::process::{Command, Stdio};
+use std::sync::Mutex;
+use crate::exit::{classified, ErrorClass};
+use crate::security::{Capability, Manifest};
+
+// ── hl-desktop — schowek i powiadomienia ──────────────────────────────────────
+//
+//   || hl-desktop notify "Backup gotowy" [treść]
+//   || hl-desktop copy "@token"            (bez tekstu — stdin)
+//   || hl-desktop paste                    (na stdout)
+//   > @HL_BIN desktop paste |> @schowek   to samo z powłoki: hl desktop …
+//
+// Uprawnienia: `clipboard` (copy, paste) i `notify` w manifeście `/// Requires:`.
+// Bez deklaracji hl pyta raz na uruchomienie ([t/N]); bez terminala odmawia
+// (exit 126). Zgoda przechodzi na potomne `hl desktop` przez HL_DESKTOP_GRANTED.
+// Backendy wybierane w kolejności, bez wymogu jednego narzędzia:
+//
+//   notify: D-Bus (busctl, gdbus) → notify-send → osascript → OSC 777 w terminalu
+//   copy:   wl-copy → xclip → xsel → pbcopy → OSC 52 (działa też przez SSH)
+//   paste:  wl-paste → xclip → xsel → pbpaste
+
+pub const TOOL: &str = "hl-desktop";
+const GRANTED_ENV: &str = "HL_DESKTOP_GRANTED";
+
+static GRANTED: Mutex<Vec<String>> = Mutex::new(Vec::new());
+static DENIED: Mutex<Vec<String>> = Mutex::new(Vec::new());
+
+fn cap_name(cap: &Capability) -> String { cap.to_string() }
+
+fn grant(name: String) {
+    let mut granted = GRANTED.lock().unwrap_or_else(|e| e.into_inner());
+    if !granted.contains(&name) { granted.push(name); }
+    std::env::set_var(GRANTED_ENV, granted.join(","));
+}
+
+fn granted(name: &str) -> bool {
+    GRANTED.lock().unwrap_or_else(|e| e.into_inner()).iter().any(|g| g == name)
+        || std::env::var(GRANTED_ENV).is_ok_and(|v| v.split(',').any(|g| g == name))
+}
+
+/// Uprawnienia zadeklarowane w manifeście nie wymagają pytania
+pub fn grant_from_manifest(manifest: &Manifest) {
+    for cap in manifest.caps.iter().filter(|c| matches!(c, Capability::Clipboard | Capability::Notify)) {
+        grant(cap_name(cap));
+    }
+}
+
+fn require(cap: Capability, what: &str) -> Result<()> {
+    let name = cap_name(&cap);
+    if granted(&name) { return Ok(()); }
+    let deny = || classified(ErrorClass::Denied, format!(
+        "{}: brak uprawnienia `{}` — dodaj do manifestu: /// Requires: {}", TOOL, name, name));
+    if DENIED.lock().unwrap_or_else(|e| e.into_inner()).contains(&name) { return Err(deny()); }
+    if !std::io::stdin().is_terminal() { return Err(deny()); }
+
+    eprint!("\x1b[33m[hl-desktop]\x1b[0m Skrypt chce: {} (`{}`). Zezwolić? [t/N] ", what, name);
+    std::io::stderr().flush().ok();
+    let mut answer = String::new();
+    std::io::stdin().lock().read_line(&mut answer)?;
+    if matches!(answer.trim().to_lowercase().as_str(), "t" | "tak" | "y" | "yes") {
+        grant(name);
+        Ok(())
+    } else {
+        DENIED.lock().unwrap_or_else(|e| e.into_inner()).push(name);
+        Err(deny())
+    }
+}
+
+fn available(bin: &str) -> bool { which::which(bin).is_ok() }
+
+fn quiet(cmd: &mut Command) -> bool {
+    cmd.stdin(Stdio::null()).stdout(Stdio::null()).stderr(Stdio::null())
+        .status().map(|s| s.success()).unwrap_or(false)
+}
+
+fn piped(bin: &str, args: &[&str], input: &str) -> bool {
+    let Ok(mut child) = Command::new(bin).args(args)
+        .stdin(Stdio::piped()).stdout(Stdio::null()).stderr(Stdio::null()).spawn() else { return false };
+    if let Some(mut stdin) = child.stdin.take() {
+        if stdin.write_all(input.as_bytes()).is_err() { return false; }
+    }
+    child.wait().map(|s| s.success()).unwrap_or(false)
+}
+
+fn captured(bin: &str, args: &[&str]) -> Option<String> {
+    let out = Command::new(bin).args(args).stdin(Stdio::null()).stderr(Stdio::null()).output().ok()?;
+    out.status.success().then(|| String::from_utf8_lossy(&out.stdout).into_owned())
+}
+
+fn wayland() -> bool { std::env::var_os("WAYLAND_DISPLAY").is_some() }
+fn x11() -> bool { std::env::var_os("DISPLAY").is_some() }
+
+/// Znaki sterujące wycięte — tekst trafia do sekwencji terminala
+fn osc_safe(s: &str) -> String { s.chars().filter(|c| !c.is_control()).collect() }
+
+pub fn notify(title: &str, body: &str) -> Result<()> {
+    require(Capability::Notify, "wyświetlić powiadomienie")?;
+    let sent = (available("busctl") && quiet(Command::new("busctl").args([
+            "--user", "call", "org.freedesktop.Notifications", "/org/freedesktop/Notifications",
+            "org.freedesktop.Notifications", "Notify", "susssasa{sv}i",
+            "hl", "0", "", title, body, "0", "0", "5000"])))
+        || (available("gdbus") && quiet(Command::new("gdbus").args([
+            "call", "--session", "--dest", "org.freedesktop.Notifications",
+            "--object-path", "/org/freedesktop/Notifications",
+            "--method", "org.freedesktop.Notifications.Notify",
+            "hl", "0", "", title, body, "[]", "{}", "5000"])))
+        || (available("notify-send") && quiet(Command::new("notify-send").args([title, body])))
+        || (cfg!(target_os = "macos") && available("osascript") && quiet(Command::new("osascript").args([
+            "-e", format!("display notification {:?} with title {:?}", body, title).as_str()])));
+    if !sent {
+        // Terminale z OSC 777 (foot, kitty, konsole, …) pokażą powiadomienie; reszta — dzwonek
+        eprint!("\x1b]777;notify;{};{}\x07\x07", osc_safe(title), osc_safe(body));
+    }
+    Ok(())
+}
+
+pub fn copy(text: &str) -> Result<()> {
+    require(Capability::Clipboard, "zapisać do schowka")?;
+    let done = (wayland() && available("wl-copy") && piped("wl-copy", &[], text))
+        || (x11() && available("xclip") && piped("xclip", &["-selection", "clipboard"], text))
+        || (x11() && available("xsel") && piped("xsel", &["--clipboard", "--input"], text))
+        || (available("pbcopy") && piped("pbcopy", &[], text));
+    if !done {
+        if !std::io::stderr().is_terminal() {
+            return Err(classified(ErrorClass::Toolchain,
+                format!("{} copy: brak schowka (wl-copy, xclip, xsel, pbcopy) i terminala dla OSC 52", TOOL)));
+        }
+        eprint!("\x1b]52;c;{}\x07", base64(text.as_bytes()));
+    }
+    Ok(())
+}
+
+pub fn paste() -> Result<String> {
+    require(Capability::Clipboard, "odczytać schowek")?;
+    let text = (wayland() && available("wl-paste")).then(|| captured("wl-paste", &["--no-newline"])).flatten()
+        .or_else(|| (x11() && available("xclip")).then(|| captured("xclip", &["-selection", "clipboard", "-o"])).flatten())
+        .or_else(|| (x11() && available("xsel")).then(|| captured("xsel", &["--clipboard", "--output"])).flatten())
+        .or_else(|| available("pbpaste").then(|| captured("pbpaste", &[])).flatten());
+    text.ok_or_else(|| classified(ErrorClass::Toolchain,
+        format!("{} paste: brak dostępu do schowka (wl-paste, xclip, xsel, pbpaste)", TOOL)))
+}
+
+fn base64(data: &[u8]) -> String {
+    const ABC: &[u8; 64] = b"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+    let mut out = String::with_capacity(data.len().div_ceil(3) * 4);
+    for chunk in data.chunks(3) {
+        let b = [chunk[0], *chunk.get(1).unwrap_or(&0), *chunk.get(2).unwrap_or(&0)];
+        let n = (b[0] as u32) << 16 | (b[1] as u32) << 8 | b[2] as u32;
+        for i in 0..4 {
+            if i <= chunk.len() { out.push(ABC[(n >> (18 - 6 * i) & 63) as usize] as char); } else { out.push('='); }
+        }
+    }
+    out
+}
+
+/// `hl-desktop <akcja> [argumenty]`; zwraca tekst do wypisania (paste)
+pub fn run(args: &[String]) -> Result<String> {
+    let Some((action, rest)) = args.split_first() else { bail!("{} notify | copy | paste", TOOL) };
+    match action.as_str() {
+        "notify" => {
+            let Some(title) = rest.first() else { bail!("{} notify \"tytuł\" [treść]", TOOL) };
+            notify(title, &rest[1..].join(" "))?;
+            Ok(String::new())
+        }
+        "copy" => {
+            let text = if rest.is_empty() {
+                let mut s = String::new();
+                std::io::stdin().read_to_string(&mut s)?;
+                s
+            } else { rest.join(" ") };
+            copy(&text)?;
+            Ok(String::new())
+        }
+        "paste" => paste(),
+        other => bail!("{}: nieznana akcja '{}' — notify | copy | paste", TOOL, other),
+    }
+}
+
+/// Argumenty linii `|| hl-desktop …` — słowa, cudzysłowy "…" i '…' grupują
+pub fn split_args(line: &str) -> Vec<String> {
+    let mut out = Vec::new();
+    let mut cur = String::new();
+    let mut quote: Option<char> = None;
+    let mut started = false;
+    for c in line.chars() {
+        match quote {
+            Some(q) if c == q => quote = None,
+            Some(_)           => cur.push(c),
+            None if c == '"' || c == '\'' => { quote = Some(c); started = true; }
+            None if c.is_whitespace() => {
+                if started { out.push(std::mem::take(&mut cur)); started = false; }
+            }
+            None => { cur.push(c); started = true; }
+        }
+    }
+    if started { out.push(cur); }
+    out
+}
@@ -1,6 +1,7 @@
 use colored::Colorize;
 use std::fmt;
-use std::collections::HashSet;
+use std::collections::{HashMap, HashSet};
+use hl_parser::ast::Node;
 
 #[derive(Debug, Clone, PartialEq)]
 pub enum DiagLevel { Error, Warning, Hint, Note }
@@ -34,18 +35,31 @@ impl Span {
     pub fn line_only(line: usize) -> Self { Self { line, col: 1, len: 0 } }
 }
 
+/// Poprawka możliwa do zastosowania automatycznie (`hl check --fix`); linie od 1
+#[derive(Debug, Clone, PartialEq)]
+pub enum Fix {
+    ReplaceLine(usize, String),
+    AppendToLine(usize, String),
+    /// Wstaw linię przed linią n (n = liczba linii + 1 → na końcu pliku)
+    InsertLine(usize, String),
+}
+
 #[derive(Debug, Clone)]
 pub struct Diag {
-    pub level: DiagLevel, pub message: String,
+    pub level: DiagLevel, pub message: String, pub code: Option<&'static str>,
     pub span: Option<Span>, pub suggestion: Option<String>, pub notes: Vec<String>,
+    pub fix: Option<Fix>,
 }
 impl Diag {
-    pub fn error(msg: impl Into<String>)   -> Self { Self { level: DiagLevel::Error,   message: msg.into(), span: None, suggestion: None, notes: vec![] } }
-    pub fn warning(msg: impl Into<String>) -> Self { Self { level: DiagLevel::Warning, message: msg.into(), span: None, suggestion: None, notes: vec![] } }
-    pub fn hint(msg: impl Into<String>)    -> Self { Self { level: DiagLevel::Hint,    message: msg.into(), span: None, suggestion: None, notes: vec![] } }
+    pub fn error(msg: impl Into<String>)   -> Self { Self { level: DiagLevel::Error,   message: msg.into(), code: None, span: None, suggestion: None, notes: vec![], fix: None } }
+    pub fn warning(msg: impl Into<String>) -> Self { Self { level: DiagLevel::Warning, message: msg.into(), code: None, span: None, suggestion: None, notes: vec![], fix: None } }
+    pub fn hint(msg: impl Into<String>)    -> Self { Self { level: DiagLevel::Hint,    message: msg.into(), code: None, span: None, suggestion: None, notes: vec![], fix: None } }
+    /// Stały kod diagnostyki (HL0001…) — wyjaśnienie: `hl explain <kod>`
+    pub fn with_code(mut self, code: &'static str) -> Self { self.code = Some(code); self }
     pub fn with_span(mut self, span: Span) -> Self { self.span = Some(span); self }
     pub fn with_suggestion(mut self, s: impl Into<String>) -> Self { self.suggestion = Some(s.into()); self }
     pub fn with_note(mut self, n: impl Into<String>) -> Self { self.notes.push(n.into()); self }
+    pub fn with_fix(mut self, fix: Fix) -> Self { self.fix = Some(fix); self }
 }
 
 pub struct DiagRenderer<'a> { pub filename: &'a str, pub lines: Vec<&'a str> }
@@ -55,7 +69,10 @@ impl<'a> DiagRenderer<'a> {
     }
     pub fn emit(&self, diag: &Diag) {
         let gc = diag.level.gutter_color(); let reset = "\x1b[0m";
-        eprintln!("{}: {}", diag.level.label(), diag.message.white().bold());
+        match diag.code {
+            Some(code) => eprintln!("{}{}: {}", diag.level.label(), format!("[{}]", code).bright_black(), diag.message.white().bold()),
+            None       => eprintln!("{}: {}", diag.level.label(), diag.message.white().bold()),
+        }
         if let Some(ref span) = diag.span {
             eprintln!("  {} {}:{}:{}", "-->".bright_black(), self.filename.bright_white(), span.line, span.col);
             let line_idx = span.line.saturating_sub(1);
@@ -75,6 +92,7 @@ impl<'a> DiagRenderer<'a> {
         }
         if let Some(ref sug) = diag.suggestion { eprintln!("  {} {}", "help:".bright_cyan().bold(), sug.bright_white()); }
         for note in &diag.notes { eprintln!("  {} {}", "note:".bright_black().bold(), note.bright_black()); }
+        if let Some(code) = diag.code { eprintln!("  {} {}", "więcej:".bright_black().bold(), format!("hl explain {}", code).bright_black()); }
         eprintln!();
     }
     pub fn emit_all(&self, diags: &[Diag]) { for d in diags { self.emit(d); } }
@@ -109,9 +127,45 @@ pub fn lint_source(source: &str) -> Vec<Diag> {
     })
     .collect();
 
+    // Miejsce na brakujace `// narzedzie`: za naglowkiem (#!, ///, using) i istniejacymi deklaracjami
+    let dep_insert_line = source.lines()
+    .position(|l| {
+        let t = l.trim();
+        !(t.starts_with("#!") || t.starts_with("///") || t.starts_with("using") || t.is_empty()
+          || (t.starts_with("//") && !t.ends_with("\\\\") && { let r = t[2..].trim(); r.contains('[') || !r.contains(' ') }))
+    })
+    .map(|i| i + 1)
+    .unwrap_or(source.lines().count() + 1);
+
+    // Otwarta lista eksportu `=> NAZWA [`: (linia otwarcia, ostatni element `|`)
+    let mut open_list: Option<(usize, usize)> = None;
+
     for (idx, raw_line) in source.lines().enumerate() {
         let line_no = idx + 1;
         let trimmed = raw_line.trim();
+        let indent = &raw_line[..raw_line.len() - raw_line.trim_start().len()];
+
+        // Lista eksportu bez zamykajacego `]`
+        if let Some((open, last)) = open_list {
+            if trimmed.starts_with('|') { open_list = Some((open, line_no)); continue; }
+            if trimmed == "]" { open_list = None; continue; }
+            if !trimmed.is_empty() && !trimmed.starts_with(";;") {
+                diags.push(unclosed_list_diag(open, last));
+                open_list = None;
+            }
+        }
+        if trimmed.starts_with("=>") && trimmed.ends_with('[') {
+            open_list = Some((line_no, line_no));
+            continue;
+        }
+
+        // Biale znaki na koncu linii metadanych ///
+        if trimmed.starts_with("///") && raw_line.len() != raw_line.trim_end().len() {
+            diags.push(Diag::hint("biale znaki na koncu linii metadanych").with_code("HL0014")
+            .with_span(Span::new(line_no, raw_line.trim_end().len() + 1, raw_line.len() - raw_line.trim_end().len()))
+            .with_suggestion("usun spacje/tabulatory na koncu linii")
+            .with_fix(Fix::ReplaceLine(line_no, raw_line.trim_end().to_string())));
+        }
 
         // echo zakazane w blokach >
         if let Some(rest) = strip_cmd_prefix(trimmed, ">") {
@@ -119,19 +173,24 @@ pub fn lint_source(source: &str) -> Vec<Diag> {
             if rest.starts_with("echo ") || rest == "echo" {
                 let msg = rest.trim_start_matches("echo").trim();
                 let col = raw_line.find('>').map(|c| c+1).unwrap_or(1);
-                diags.push(Diag::error("`echo` jest zabronione w blokach komend HL")
+                let mut diag = Diag::error("`echo` jest zabronione w blokach komend HL").with_code("HL0010")
                 .with_span(Span::new(line_no, col, trimmed.len()))
                 .with_suggestion(if msg.is_empty() { "uzyj: `~>`".into() } else { format!("zamien na: `~> {}`", msg) })
-                .with_note("operator `~>` to jedyny sposob wypisywania tekstu w HL"));
+                .with_note("operator `~>` to jedyny sposob wypisywania tekstu w HL");
+                if !msg.is_empty() {
+                    diag = diag.with_fix(Fix::ReplaceLine(line_no, format!("{}~> {}", indent, unquote(msg))));
+                }
+                diags.push(diag);
             }
             // sudo zamiast ^>
             if rest.starts_with("sudo ") {
                 let actual_cmd = rest.trim_start_matches("sudo").trim();
                 let col = raw_line.find('>').map(|c| c+1).unwrap_or(1);
-                diags.push(Diag::warning("`> sudo` — uzyj operatora `^>`".to_string())
+                diags.push(Diag::warning("`> sudo` — uzyj operatora `^>`".to_string()).with_code("HL0011")
                 .with_span(Span::new(line_no, col, trimmed.len()))
                 .with_suggestion(format!("zamien na: `^> {}`", actual_cmd))
-                .with_note("`^>` to natywny odpowiednik sudo w HL"));
+                .with_note("`^>` to natywny odpowiednik sudo w HL")
+                .with_fix(Fix::ReplaceLine(line_no, format!("{}^> {}", indent, actual_cmd))));
             }
         }
 
@@ -144,7 +203,7 @@ pub fn lint_source(source: &str) -> Vec<Diag> {
                 "JAVA_HOME","GOPATH","CARGO_HOME","PYTHONPATH"];
                 if ENV_VARS.contains(&varname) {
                     let col = raw_line.find('%').map(|c| c+1).unwrap_or(1);
-                    diags.push(Diag::hint(format!("`%{}` to zmienna lokalna HL — uzyj `=>` dla exportu", varname))
+                    diags.push(Diag::hint(format!("`%{}` to zmienna lokalna HL — uzyj `=>` dla exportu", varname)).with_code("HL0012")
                     .with_span(Span::new(line_no, col, trimmed.len()))
                     .with_suggestion(format!("zamien na: `=> {} = <wartosc>`", varname)));
                 }
@@ -152,14 +211,251 @@ pub fn lint_source(source: &str) -> Vec<Diag> {
         }
 
         // Sprawdz narzedzia — uzywa pre-obliczonego HashSet (O(1) lookup)
-        check_missing_dep_fast(trimmed, line_no, &declared_tools, &mut diags);
+        check_missing_dep_fast(trimmed, line_no, &declared_tools, dep_insert_line, &mut diags);
+    }
+    if let Some((open, last)) = open_list { diags.push(unclosed_list_diag(open, last)); }
+    diags.extend(lint_imports(source));
+    diags.extend(lint_functions(source));
+    diags.extend(lint_retry(source));
+    diags.extend(lint_compat(source));
+    diags.extend(crate::migrate::lint_deprecations(source));
+    diags.extend(lint_features(source));
+    diags
+}
+
+/// Nazwy w `?feature(...)` spoza deklaracji `/// Features:` — literowka wycina sekcje po cichu (HL0024)
+fn lint_features(source: &str) -> Vec<Diag> {
+    use hl_parser::features::{declared_features, feature_name, parse_condition};
+    let mut diags = Vec::new();
+    if !source.contains("?feature(") { return diags; }
+    let declared = declared_features(source);
+    for (idx, raw_line) in source.lines().enumerate() {
+        let trimmed = raw_line.trim();
+        let Some(spec) = trimmed.strip_prefix("?feature(") else { continue };
+        let Ok(conditions) = parse_condition(spec.trim_end().trim_end_matches(')')) else { continue };
+        let span = Span::new(idx + 1, raw_line.find('?').map(|c| c + 1).unwrap_or(1), trimmed.len());
+        let Some((_, names)) = &declared else {
+            diags.push(Diag::hint("sekcja `?feature` bez deklaracji `/// Features:` w naglowku").with_code("HL0024")
+            .with_span(span)
+            .with_suggestion(format!("dodaj: `/// Features: {}`",
+                conditions.iter().map(|c| feature_name(c)).collect::<Vec<_>>().join(", "))));
+            return diags;
+        };
+        for c in &conditions {
+            let name = feature_name(c);
+            if names.iter().any(|n| n == name) { continue; }
+            diags.push(Diag::warning(format!("cecha `{}` nie jest zadeklarowana w `/// Features:`", name)).with_code("HL0024")
+            .with_span(span.clone())
+            .with_suggestion(format!("zadeklarowane: {} — popraw nazwe albo dopisz ja do naglowka", names.join(", "))));
+        }
     }
     diags
 }
 
+/// `/// MinHl:` / `/// RequiresOS:` — bledna wartosc (error) lub niespelnione tutaj (warning) (HL0022)
+fn lint_compat(source: &str) -> Vec<Diag> {
+    use crate::compat::{check_requirement, parse_requirements};
+    parse_requirements(source).into_iter().filter_map(|(line, req)| match check_requirement(&req) {
+        Ok(None)         => None,
+        Ok(Some(reason)) => Some(Diag::warning(reason).with_code("HL0022").with_span(Span::line_only(line))
+            .with_suggestion("hl run zakonczy sie kodem 4 na tej maszynie")),
+        Err(e)           => Some(Diag::error(e).with_code("HL0022").with_span(Span::line_only(line))),
+    }).collect()
+}
+
+/// `~retry` przed wezlem, ktory nie zwraca kodu wyjscia — ponowienie nic nie zmieni (HL0020)
+fn lint_retry(source: &str) -> Vec<Diag> {
+    let mut diags = Vec::new();
+    if !source.contains("~retry") { return diags; }
+    let Ok(nodes) = hl_parser::parse_source(source) else { return diags };
+
+    // k-ty wezel Retry to k-ta linia zaczynajaca sie od `~retry`
+    let retry_lines: Vec<usize> = source.lines().enumerate()
+        .filter(|(_, l)| l.trim_start().starts_with("~retry"))
+        .map(|(i, _)| i + 1)
+        .collect();
+    let mut k = 0;
+    visit_nodes(&nodes, false, &mut |node, _| {
+        let Node::Retry { body, .. } = node else { return };
+        let line = retry_lines.get(k).copied();
+        k += 1;
+        let retryable = matches!(body.first(),
+            Some(Node::Command { .. } | Node::PipeToVar { .. } | Node::HshCommand { .. }
+                | Node::FuncCall { .. } | Node::QuickCall { .. } | Node::HackerOsApi { .. } | Node::Block(_)));
+        if retryable { return; }
+        let mut diag = Diag::warning("`~retry` nie dotyczy komendy — nie ma czego ponawiac").with_code("HL0020")
+        .with_suggestion("umiesc komende w tej samej linii: `~retry(3, 5s) > komenda`");
+        if let Some(line) = line { diag = diag.with_span(Span::line_only(line)); }
+        diags.push(diag);
+    });
+    diags
+}
+
+/// Wezly w kolejnosci zrodla; `in_func` — wewnatrz `: nazwa def` / `:: nazwa def`
+pub(crate) fn visit_nodes<'a>(nodes: &'a [Node], in_func: bool, f: &mut dyn FnMut(&'a Node, bool)) {
+    for node in nodes {
+        f(node, in_func);
+        match node {
+            Node::FuncDef { body, .. } | Node::ArenaFuncDef { body, .. } => visit_nodes(body, true, f),
+            Node::RepeatN      { body, .. }
+            | Node::Retry        { body, .. }
+            | Node::Check        { body, .. }
+            | Node::Once         { body, .. }
+            | Node::Undo         { body, .. }
+            | Node::Conditional  { body, .. }
+            | Node::IfExpr       { body, .. }
+            | Node::FeatureBlock { body, .. }
+            | Node::ForIn        { body, .. }
+            | Node::WhileLoop    { body, .. }
+            | Node::Goroutine    { body, .. }
+            | Node::ExternDef    { body, .. }
+            | Node::Block        (body)         => visit_nodes(body, in_func, f),
+            Node::MatchExpr { arms, .. } => for arm in arms { visit_nodes(&arm.body, in_func, f) },
+            _ => {}
+        }
+    }
+}
+
+/// Argumenty i wartosci funkcji: `return` poza funkcja (HL0017) oraz `-- f ... -> @x`
+/// dla funkcji skryptu, ktora nigdy nie wykonuje `return` (HL0018)
+fn lint_functions(source: &str) -> Vec<Diag> {
+    let mut diags = Vec::new();
+    if !source.contains("return") && !source.contains("->") { return diags; }
+    let Ok(nodes) = hl_parser::parse_source(source) else { return diags };
+
+    // k-ty wezel Return to k-ta linia `return` (lexer tworzy jeden token na linie)
+    let return_lines: Vec<usize> = source.lines().enumerate()
+        .filter(|(_, l)| { let t = l.trim_start(); t == "return" || t.starts_with("return ") })
+        .map(|(i, _)| i + 1)
+        .collect();
+    let mut returns = Vec::new();
+    let mut with_return: HashMap<&str, bool> = HashMap::new();
+    let mut captured: Vec<(&str, &str)> = Vec::new();
+    visit_nodes(&nodes, false, &mut |node, in_func| match node {
+        Node::Return { .. } => returns.push(in_func),
+        Node::FuncCall { name, ret: Some(var), .. } => captured.push((name.as_str(), var.as_str())),
+        _ => {}
+    });
+    for node in &nodes {
+        let Node::FuncDef { name, body } = node else { continue };
+        let mut found = false;
+        visit_nodes(body, true, &mut |n, _| if matches!(n, Node::Return { .. }) { found = true });
+        with_return.insert(name.as_str(), found);
+    }
+
+    for (k, in_func) in returns.iter().enumerate() {
+        if *in_func { continue; }
+        let mut diag = Diag::error("`return` poza funkcja").with_code("HL0017")
+        .with_suggestion("przenies `return` do `: nazwa def ... done` albo zakoncz skrypt `> exit <kod>`");
+        if let Some(line) = return_lines.get(k) { diag = diag.with_span(Span::line_only(*line)); }
+        diags.push(diag);
+    }
+
+    let mut seen: HashMap<&str, usize> = HashMap::new();
+    for (name, var) in captured {
+        let nth = seen.entry(name).or_insert(0);
+        let line = source.lines().enumerate()
+            .filter(|(_, l)| {
+                let t = l.trim_start();
+                t.starts_with("--") && t[2..].trim_start().split_whitespace().next() == Some(name) && t.contains("->")
+            })
+            .nth(*nth)
+            .map(|(i, _)| i + 1);
+        *nth += 1;
+        if with_return.get(name) != Some(&false) { continue; }
+        let mut diag = Diag::warning(format!("funkcja `{}` nie zwraca wartosci — `@{}` bedzie pusta", name, var))
+        .with_code("HL0018")
+        .with_suggestion(format!("dodaj `return <wartosc>` w `: {} def` albo usun `-> @{}`", name, var));
+        if let Some(line) = line { diag = diag.with_span(Span::line_only(line)); }
+        diags.push(diag);
+    }
+    diags
+}
+
+/// Importy bibliotek: brakujace funkcje w `::` (HL0016) i kolizje nazw funkcji
+/// miedzy bibliotekami oraz skryptem (HL0015). Biblioteki niezainstalowane,
+/// .so i wbudowane sa pomijane — hl check niczego nie pobiera.
+fn lint_imports(source: &str) -> Vec<Diag> {
+    let mut diags = Vec::new();
+    // funkcja → przestrzen nazw biblioteki, ktora ja dostarcza
+    let mut provided: HashMap<String, String> = HashMap::new();
+    for (idx, raw_line) in source.lines().enumerate() {
+        let trimmed = raw_line.trim();
+        if !trimmed.starts_with('#') || trimmed.starts_with("#!") { continue; }
+        let Some(decl) = hl_parser::parse_import_line(&trimmed[1..]) else { continue };
+        let span = Span::new(idx + 1, raw_line.find('#').map(|c| c + 1).unwrap_or(1), trimmed.len());
+        if let Some(crate::libs::ImportSource::Url { url, sha256 }) = crate::libs::parse_import_spec(&decl.spec) {
+            let valid = |h: &str| h.len() == 64 && h.chars().all(|c| c.is_ascii_hexdigit());
+            match sha256 {
+                None if url.starts_with("http://") => diags.push(Diag::error(format!("import `{}` przez http:// bez przypietego hasha — hl run go odrzuci", url))
+                    .with_code("HL0021").with_span(span.clone())
+                    .with_suggestion("uzyj https:// albo dopisz `#sha256=<hash>` (`hl lib pin <plik.hl>`)")),
+                None => diags.push(Diag::warning(format!("import `{}` bez przypietego hasha — tresc moze sie zmienic", url))
+                    .with_code("HL0021").with_span(span.clone())
+                    .with_suggestion("dopisz `#sha256=<hash>` do adresu albo uruchom `hl lib pin <plik.hl>`")),
+                Some(h) if !valid(&h) => diags.push(Diag::error(format!("nieprawidlowy hash `{}` — oczekiwano 64 znakow hex", h))
+                    .with_code("HL0021").with_span(span.clone())
+                    .with_suggestion("usun `#sha256=...` i uruchom `hl lib pin <plik.hl>`")),
+                Some(_) => {}
+            }
+        }
+        let Some(file) = crate::libs::lib_source_file(&decl.spec) else { continue };
+        let Some(nodes) = std::fs::read_to_string(&file).ok().and_then(|s| hl_parser::parse_source(&s).ok()) else { continue };
+        let ns   = crate::libs::lib_namespace(&decl.spec);
+        let nodes = match crate::libs::select_functions(&nodes, &decl.only, &ns) {
+            Ok(n)  => n,
+            Err(e) => {
+                diags.push(Diag::error(format!("{}", e)).with_code("HL0016").with_span(span)
+                .with_note(format!("zrodlo biblioteki: {}", file.display())));
+                continue;
+            }
+        };
+        for node in &nodes {
+            let Node::FuncDef { name, .. } = node else { continue };
+            if let Some(other) = provided.get(name).filter(|o| **o != ns) {
+                diags.push(Diag::warning(format!("funkcja `{}` jest w bibliotekach `{}` i `{}` — pozniejszy import nadpisuje wczesniejszy", name, other, ns))
+                .with_code("HL0015").with_span(span.clone())
+                .with_suggestion(format!("importuj tylko potrzebne funkcje (`# <...>::funkcja`) albo wywoluj `-- {}::{}`", other, name)));
+            }
+            provided.insert(name.clone(), ns.clone());
+        }
+    }
+    if provided.is_empty() { return diags; }
+
+    // Funkcje skryptu o nazwach funkcji z bibliotek
+    for (idx, raw_line) in source.lines().enumerate() {
+        let trimmed = raw_line.trim();
+        let Some(rest) = trimmed.strip_prefix(':').filter(|r| !r.starts_with(':')) else { continue };
+        let Some(name) = rest.trim().strip_suffix("def").map(str::trim) else { continue };
+        if let Some(ns) = provided.get(name) {
+            let col = raw_line.find(':').map(|c| c + 1).unwrap_or(1);
+            diags.push(Diag::warning(format!("funkcja `{}` przeslania funkcje z biblioteki `{}`", name, ns))
+            .with_code("HL0015").with_span(Span::new(idx + 1, col, trimmed.len()))
+            .with_suggestion("zmien nazwe funkcji albo importuj z biblioteki tylko potrzebne funkcje")
+            .with_note(format!("funkcja biblioteki pozostaje dostepna jako `-- {}::{}`", ns, name)));
+        }
+    }
+    diags
+}
+
+fn unclosed_list_diag(open: usize, last: usize) -> Diag {
+    Diag::error("brakujace `]` — lista eksportu nie jest zamknieta").with_code("HL0007")
+    .with_span(Span::line_only(open))
+    .with_suggestion(format!("dodaj `]` po linii {}", last))
+    .with_fix(Fix::InsertLine(last + 1, "]".into()))
+}
+
+/// `"tekst"` / `'tekst'` → `tekst` (dla poprawki echo → ~>)
+fn unquote(s: &str) -> &str {
+    for q in ['"', '\''] {
+        if s.len() >= 2 && s.starts_with(q) && s.ends_with(q) { return &s[1..s.len() - 1]; }
+    }
+    s
+}
+
 /// Sprawdz czy narzedzie jest uzywane bez deklaracji //
 /// Uzywa przekazanego HashSet zamiast skanowac cale zrodlo (O(1) vs O(n))
-fn check_missing_dep_fast(line: &str, line_no: usize, declared: &HashSet<&str>, diags: &mut Vec<Diag>) {
+fn check_missing_dep_fast(line: &str, line_no: usize, declared: &HashSet<&str>, insert_at: usize, diags: &mut Vec<Diag>) {
     const WATCHED: &[&str] = &["nmap","curl","wget","whois","john","hydra","sqlmap",
     "nikto","masscan","aircrack-ng","hashcat","git","python3"];
     let cmd_content = if let Some(r) = strip_cmd_prefix(line, ">>") { r.to_string() }
@@ -171,12 +467,13 @@ fn check_missing_dep_fast(line: &str, line_no: usize, declared: &HashSet<&str>,
     let first_word = cmd_content.trim().split_whitespace().next().unwrap_or("");
     if let Some(&tool) = WATCHED.iter().find(|&&t| t == first_word) {
         if !declared.contains(tool) {
-            diags.push(Diag::hint(format!("narzedzie `{}` uzyte bez deklaracji `// {}`", tool, tool))
+            diags.push(Diag::hint(format!("narzedzie `{}` uzyte bez deklaracji `// {}`", tool, tool)).with_code("HL0013")
             .with_span(Span::new(line_no, 1, line.len()))
             .with_suggestion(format!(
                 "dodaj: `// {tool}` lub `// {tool} [pakiet-apt]` jesli nazwa pakietu inna niz binarka
                   Przyklady: `// ninja [ninja-build]`  `// rg [ripgrep]`  `// fd [fd-find]`"
-            )));
+            ))
+            .with_fix(Fix::InsertLine(insert_at, format!("// {}", tool))));
         }
     }
 }
@@ -219,28 +516,41 @@ use hl_parser::lexer::LexError;
 pub fn parse_error_to_diag(err: &ParseError) -> Diag {
     match err {
         ParseError::Lex(e) => lex_error_to_diag(e),
-        ParseError::UnexpectedToken(pos, tok) => Diag::error(format!("nieoczekiwany token `{}` (pozycja {})", tok, pos))
+        ParseError::UnexpectedToken(pos, tok) => Diag::error(format!("nieoczekiwany token `{}` (pozycja {})", tok, pos)).with_code("HL0004")
         .with_suggestion("sprawdz skladnie — kazda linia powinna zaczynac sie od operatora"),
-        ParseError::MissingDone => Diag::error("brakujace `done` — blok nie jest zamkniety")
+        ParseError::MissingDone => Diag::error("brakujace `done` — blok nie jest zamkniety").with_code("HL0005")
         .with_suggestion("dodaj `done` na koncu bloku"),
-        ParseError::MissingDef  => Diag::error("brakujace `def` po nazwie funkcji")
+        ParseError::MissingDef  => Diag::error("brakujace `def` po nazwie funkcji").with_code("HL0006")
         .with_suggestion("poprawna skladnia: `: nazwa_funkcji def`"),
-        ParseError::MissingExportListEnd => Diag::error("brakujace `]` — lista eksportu nie jest zamknieta")
+        ParseError::MissingExportListEnd => Diag::error("brakujace `]` — lista eksportu nie jest zamknieta").with_code("HL0007")
         .with_suggestion("dodaj `]` na koncu listy"),
-        ParseError::Gen(gen_err) => Diag::error(format!("blad deklaracji gena: {}", gen_err))
+        ParseError::InvalidRetry(msg) => Diag::error(format!("nieprawidlowe `~retry`: {}", msg)).with_code("HL0019")
+        .with_suggestion("poprawna skladnia: `~retry(3, 5s) > komenda` albo `~retry(5, 1s, backoff) > komenda`"),
+        ParseError::Gen(gen_err) => Diag::error(format!("blad deklaracji gena: {}", gen_err)).with_code("HL0008")
         .with_suggestion("poprawna skladnia: `using <gen 2>`"),
+        ParseError::EmptyCheck => Diag::error("pusty `~check()`")
+        .with_suggestion("poprawna skladnia: `~check(test -f /etc/app.conf) ^> komenda`"),
+        ParseError::InvalidOnce(msg) => Diag::error(format!("nieprawidlowe `~once`: {}", msg))
+        .with_suggestion("poprawna skladnia: `~once(nazwa[, plik...]) > komenda`"),
+        ParseError::EmptyUndo => Diag::error("pusty `~undo()`")
+        .with_suggestion("poprawna skladnia: `~undo(komenda cofajaca) ^> komenda`"),
+        ParseError::InvalidRange(msg) => Diag::error(format!("nieprawidlowa petla for-in: {}", msg))
+        .with_suggestion(format!("zakres `A..B` moze miec najwyzej {} elementow", hl_parser::ast::MAX_RANGE)),
+        ParseError::InvalidFeature(msg) => Diag::error(format!("nieprawidlowe `?feature`: {}", msg)).with_code("HL0024")
+        .with_suggestion("poprawna skladnia: `?feature(gpu)` albo `?feature(!gpu, debug)` ... `done`"),
     }
 }
 
 pub fn lex_error_to_diag(err: &LexError) -> Diag {
     match err {
-        LexError::UnexpectedChar(ch, line, col) => Diag::error(format!("nieoczekiwany znak `{}` w linii {}:{}", ch, line, col))
+        LexError::UnexpectedChar(ch, line, col) => Diag::error(format!("nieoczekiwany znak `{}` w linii {}:{}", ch, line, col)).with_code("HL0001")
         .with_span(Span::new(*line, *col, 1))
         .with_suggestion("usun lub zastap nieznany znak"),
-        LexError::UnterminatedString(line) => Diag::error("niezamkniety string")
+        LexError::UnterminatedString(line) => Diag::error("niezamkniety string").with_code("HL0002")
         .with_span(Span::line_only(*line))
+        .with_fix(Fix::AppendToLine(*line, "\"".into()))
         .with_suggestion("dodaj `\"` na koncu stringa"),
-        LexError::UnterminatedBlockComment => Diag::error("niezamkniety komentarz blokowy")
+        LexError::UnterminatedBlockComment => Diag::error("niezamkniety komentarz blokowy").with_code("HL0003")
         .with_suggestion("zamknij komentarz: `//  tresc  \\\\`"),
     }
 }
@@ -250,7 +560,7 @@ pub fn lint_gen(source: &str) -> Vec<Diag> {
     let mut diags = Vec::new();
     let (_gen, gen_err) = extract_gen(source);
     if let Some(err) = gen_err {
-        diags.push(Diag::error(format!("nieprawidlowa deklaracja gena: {}", err))
+        diags.push(Diag::error(format!("nieprawidlowa deklaracja gena: {}", err)).with_code("HL0008")
         .with_suggestion(format!("poprawna skladnia: `using <gen 2>`  (max gen: {})", HL_MAX_GEN)));
         return diags;
     }
@@ -260,7 +570,7 @@ pub fn lint_gen(source: &str) -> Vec<Diag> {
         if t.starts_with("#!") || t.starts_with(";;") || t.starts_with("///") || t.starts_with("//") || t.is_empty() { continue; }
             if t.starts_with("using") {
                 if seen_code {
-                    diags.push(Diag::warning("deklaracja `using` po kodzie — gen moze nie byc uwzgledniony")
+                    diags.push(Diag::warning("deklaracja `using` po kodzie — gen moze nie byc uwzgledniony").with_code("HL0009")
                     .with_span(Span::new(idx+1, 1, t.len()))
                     .with_suggestion("umies `using <gen N>` na samym poczatku pliku"));
                 }
@@ -0,0 +1,203 @@
+use anyhow::Result;
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::path::Path;
+use std::process::{Command, Stdio};
+
+// ── hl docs search — wyszukiwanie w dokumentacji offline ──────────────────────
+//
+//   hl docs search "memory mode"          ranking z fragmentem tekstu
+//   hl docs search arena --open           otwórz najlepszy wynik (--open 3 — trzeci)
+//
+// Indeks powstaje przy zapytaniu, bez sieci, z czterech źródeł:
+//   docs  — sekcje TUI hl-docs (hl-docs --dump)
+//   lib   — dokumentacja bibliotek z plikiem .hl (hl docs lib: main, std, bit, GitHub)
+//   diag  — wyjaśnienia kodów diagnostyk (hl explain)
+//   learn — lekcje hl learn (wstęp, zadania, podpowiedzi)
+//
+// Dopasowanie jest rozmyte: każde słowo zapytania musi pasować do jakiegoś
+// słowa dokumentu — dokładnie, prefiksem, fragmentem albo z literówką
+// (odległość edycyjna 1, dla długich słów 2). Trafienie w tytule waży więcej.
+// Wielkość liter i polskie znaki nie mają znaczenia.
+
+#[derive(Debug, Clone, Copy, PartialEq, Serialize)]
+#[serde(rename_all = "lowercase")]
+pub enum Kind { Docs, Lib, Diag, Learn }
+
+impl Kind {
+    fn label(self) -> &'static str {
+        match self { Kind::Docs => "docs", Kind::Lib => "lib", Kind::Diag => "diag", Kind::Learn => "learn" }
+    }
+}
+
+#[derive(Debug, Clone)]
+pub struct Entry {
+    pub kind:     Kind,
+    /// Tytuł sekcji / nazwa biblioteki / kod — to, co otwiera wynik
+    pub key:      String,
+    pub title:    String,
+    pub category: String,
+    pub text:     String,
+}
+
+#[derive(Debug, Serialize)]
+pub struct Hit {
+    pub kind:     Kind,
+    pub key:      String,
+    pub title:    String,
+    pub category: String,
+    pub score:    f64,
+    pub snippet:  String,
+}
+
+#[derive(Deserialize)]
+struct DumpedSection { title: String, category: String, content: String }
+
+/// Sekcje TUI z `hl-docs --dump` (brak binarki — brak sekcji)
+pub fn docs_entries(docs_bin: &Path) -> Vec<Entry> {
+    let Ok(out) = Command::new(docs_bin).arg("--dump").stdin(Stdio::null()).stderr(Stdio::null()).output() else {
+        return vec![];
+    };
+    let sections: Vec<DumpedSection> = serde_json::from_slice(&out.stdout).unwrap_or_default();
+    sections.into_iter().map(|s| Entry {
+        kind: Kind::Docs, key: s.title.clone(), title: s.title, category: s.category, text: s.content,
+    }).collect()
+}
+
+pub fn diag_entries() -> Vec<Entry> {
+    crate::explain::EXPLANATIONS.iter().map(|e| Entry {
+        kind: Kind::Diag,
+        key: e.code.to_string(),
+        title: format!("{} {}", e.code, e.title),
+        category: "hl explain".into(),
+        text: format!("{}\n{}\n{}", e.text, e.broken, e.fixed),
+    }).collect()
+}
+
+pub fn learn_entries() -> Vec<Entry> {
+    crate::learn::LESSONS.iter().map(|l| Entry {
+        kind: Kind::Learn,
+        key: l.id.to_string(),
+        title: l.title.to_string(),
+        category: "hl learn".into(),
+        text: std::iter::once(l.intro).chain(l.tasks.iter().flat_map(|t| [t.goal, t.hint])).collect::<Vec<_>>().join("\n"),
+    }).collect()
+}
+
+/// Biblioteki, dla których hl docs lib znajdzie plik .hl
+pub fn lib_entries() -> Vec<Entry> {
+    let mut names: Vec<String> = std::fs::read_dir(crate::libs::MAIN_LIBS_DIR).into_iter().flatten().flatten()
+        .filter_map(|e| {
+            let p = e.path();
+            let stem = p.file_stem()?.to_str()?.to_string();
+            (p.extension().is_some_and(|x| x == "hl") || p.join("lib.hl").is_file()).then_some(stem)
+        })
+        .chain(["http", "logging", "retry"].map(String::from))
+        .chain(crate::complete::lib_names())
+        .collect();
+    names.sort();
+    names.dedup();
+    names.into_iter().filter_map(|name| {
+        let (source, src) = crate::libdoc::find_source(&name).ok()?;
+        let doc = crate::libdoc::extract(&name, &source, &src);
+        Some(Entry {
+            kind: Kind::Lib,
+            key: name.clone(),
+            title: if doc.title.is_empty() { name.clone() } else { format!("{} — {}", name, doc.title) },
+            category: "hl docs lib".into(),
+            text: crate::libdoc::render_markdown(&doc),
+        })
+    }).collect()
+}
+
+/// Małe litery bez polskich znaków
+fn fold(s: &str) -> String {
+    s.chars().flat_map(char::to_lowercase).map(|c| match c {
+        'ą' => 'a', 'ć' => 'c', 'ę' => 'e', 'ł' => 'l', 'ń' => 'n',
+        'ó' => 'o', 'ś' => 's', 'ź' | 'ż' => 'z', other => other,
+    }).collect()
+}
+
+fn words(s: &str) -> Vec<String> {
+    fold(s).split(|c: char| !(c.is_alphanumeric() || c == '_')).filter(|w| !w.is_empty()).map(str::to_string).collect()
+}
+
+fn edit_distance(a: &str, b: &str) -> usize {
+    let (a, b): (Vec<char>, Vec<char>) = (a.chars().collect(), b.chars().collect());
+    let mut prev: Vec<usize> = (0..=b.len()).collect();
+    for (i, ca) in a.iter().enumerate() {
+        let mut cur = vec![i + 1; b.len() + 1];
+        for (j, cb) in b.iter().enumerate() {
+            cur[j + 1] = (prev[j] + usize::from(ca != cb)).min(prev[j + 1] + 1).min(cur[j] + 1);
+        }
+        prev = cur;
+    }
+    prev[b.len()]
+}
+
+fn term_score(term: &str, word: &str) -> f64 {
+    let len = term.chars().count();
+    if word == term { 3.0 }
+    else if word.starts_with(term) { 2.0 }
+    else if len >= 4 && word.chars().count().abs_diff(len) <= 2 && edit_distance(term, word) <= if len >= 8 { 2 } else { 1 } { 1.5 }
+    else if len >= 3 && word.contains(term) { 1.0 }
+    else { 0.0 }
+}
+
+fn best(term: &str, ws: &[String]) -> f64 {
+    ws.iter().map(|w| term_score(term, w)).fold(0.0, f64::max)
+}
+
+/// Linia z największą liczbą trafień, skrócona do ~100 znaków
+fn snippet(text: &str, terms: &[String]) -> String {
+    let line = text.lines()
+        .map(|l| l.trim())
+        .filter(|l| !l.is_empty())
+        .max_by_key(|l| { let ws = words(l); terms.iter().filter(|t| best(t, &ws) > 0.0).count() })
+        .unwrap_or("");
+    let line = line.split_whitespace().collect::<Vec<_>>().join(" ");
+    if line.chars().count() > 100 { format!("{}…", line.chars().take(99).collect::<String>()) } else { line }
+}
+
+pub fn search(entries: &[Entry], query: &str) -> Vec<Hit> {
+    let terms = words(query);
+    if terms.is_empty() { return vec![]; }
+    let mut hits: Vec<Hit> = entries.iter().filter_map(|e| {
+        let title_words = words(&e.title);
+        let text_words = words(&e.text);
+        let mut score = 0.0;
+        for t in &terms {
+            let s = (best(t, &title_words) * 3.0).max(best(t, &text_words));
+            if s == 0.0 { return None; }
+            score += s;
+        }
+        Some(Hit {
+            kind: e.kind, key: e.key.clone(), title: e.title.clone(), category: e.category.clone(),
+            score, snippet: snippet(&e.text, &terms),
+        })
+    }).collect();
+    hits.sort_by(|a, b| b.score.total_cmp(&a.score).then_with(|| a.title.cmp(&b.title)));
+    hits
+}
+
+/// Indeks ze wszystkich źródeł
+pub fn index(docs_bin: &Path) -> Vec<Entry> {
+    let mut entries = docs_entries(docs_bin);
+    entries.extend(lib_entries());
+    entries.extend(diag_entries());
+    entries.extend(learn_entries());
+    entries
+}
+
+pub fn print_hits(query: &str, hits: &[Hit]) {
+    if hits.is_empty() {
+        println!("{} brak wyników dla „{}”", "hl docs search:".bright_magenta().bold(), query);
+        return;
+    }
+    for (i, h) in hits.iter().enumerate() {
+        println!("{:>3}. {} {}  {}", i + 1, format!("[{}]", h.kind.label()).bright_black(), h.title.bright_white().bold(),
+                 h.category.bright_black());
+        if !h.snippet.is_empty() { println!("       {}", h.snippet); }
+    }
+    println!("{}", "  otwórz: hl docs search \"…\" --open <nr>".bright_black());
+}
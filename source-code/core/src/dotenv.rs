@@ -0,0 +1,95 @@
+use anyhow::{bail, Result};
+use std::path::Path;
+use crate::env::{Env, Value};
+
+// ── .env — zmienne projektu ───────────────────────────────────────────────────
+//
+// hl run wczytuje z katalogu skryptu `.env`, a potem `.env.<HL_ENV>` (jeśli HL_ENV
+// jest ustawione). Pierwszeństwo, od najwyższego:
+//
+//   1. hl run --var NAZWA=wartość
+//   2. .env.<HL_ENV>, potem .env
+//   3. [env.<HL_ENV>] w bit.hk projektu (project.rs)
+//   4. `% NAZWA = ...` w skrypcie
+//   5. zmienne środowiskowe procesu
+//
+// Zmienne z 1–3 są nadpisaniami: `%` w skrypcie ich nie zmienia.
+
+pub const DOTENV_FILE: &str = ".env";
+
+pub(crate) fn valid_name(name: &str) -> bool {
+    let mut chars = name.chars();
+    chars.next().is_some_and(|c| c.is_ascii_alphabetic() || c == '_')
+        && chars.all(|c| c.is_ascii_alphanumeric() || c == '_')
+}
+
+fn unquote(value: &str) -> String {
+    let v = value.trim();
+    for q in ['"', '\''] {
+        if v.len() >= 2 && v.starts_with(q) && v.ends_with(q) {
+            let inner = &v[1..v.len() - 1];
+            return if q == '"' { inner.replace("\\n", "\n").replace("\\\"", "\"") } else { inner.to_string() };
+        }
+    }
+    // Komentarz na końcu linii tylko w wartości bez cudzysłowów
+    v.split_once(" #").map(|(a, _)| a).unwrap_or(v).trim_end().to_string()
+}
+
+/// NAZWA=wartość, opcjonalnie z `export `; `#` — komentarz
+pub fn parse_dotenv(source: &str) -> Result<Vec<(String, String)>> {
+    let mut vars = Vec::new();
+    for (i, line) in source.lines().enumerate() {
+        let line = line.trim();
+        if line.is_empty() || line.starts_with('#') { continue; }
+        let line = line.strip_prefix("export ").unwrap_or(line);
+        let Some((name, value)) = line.split_once('=') else {
+            bail!("linia {}: oczekiwano NAZWA=wartość", i + 1);
+        };
+        let name = name.trim();
+        if !valid_name(name) { bail!("linia {}: nieprawidłowa nazwa zmiennej '{}'", i + 1, name); }
+        vars.push((name.to_string(), unquote(value)));
+    }
+    Ok(vars)
+}
+
+/// `--var NAZWA=wartość`
+pub fn parse_var_arg(arg: &str) -> Result<(String, String)> {
+    match arg.split_once('=') {
+        Some((name, value)) if valid_name(name) => Ok((name.to_string(), value.to_string())),
+        _ => bail!("--var '{}': oczekiwano NAZWA=wartość", arg),
+    }
+}
+
+/// Zmienne z [env.<HL_ENV>] w bit.hk, `.env` i `.env.<HL_ENV>` w katalogu `dir`
+/// (późniejsze wygrywają)
+pub fn load_project_env(dir: &Path) -> Result<Vec<(String, String)>> {
+    let mut files = vec![dir.join(DOTENV_FILE)];
+    let active = crate::project::active_env();
+    if let Some(name) = &active { files.push(dir.join(format!("{}.{}", DOTENV_FILE, name))); }
+    let mut vars = Vec::new();
+    if active.is_some() {
+        let abs = dir.canonicalize().unwrap_or_else(|_| dir.to_path_buf());
+        if let Some(path) = crate::project::find_project(&abs) {
+            vars.extend(crate::project::load_project(&path)?.vars);
+        }
+    }
+    for file in files.iter().filter(|f| f.is_file()) {
+        let source = std::fs::read_to_string(file)?;
+        let parsed = parse_dotenv(&source).map_err(|e| anyhow::anyhow!("{}: {}", file.display(), e))?;
+        vars.extend(parsed);
+    }
+    Ok(vars)
+}
+
+/// Ustaw zmienne .env skryptu i --var jako nadpisania (--var na końcu — wygrywa)
+pub fn apply_run_vars(env: &mut Env, script: &Path, cli_vars: &[String]) -> Result<()> {
+    let dir = script.parent().filter(|d| !d.as_os_str().is_empty()).unwrap_or(Path::new("."));
+    for (name, value) in load_project_env(dir)? {
+        env.set_override(&name, Value::String(value));
+    }
+    for arg in cli_vars {
+        let (name, value) = parse_var_arg(arg)?;
+        env.set_override(&name, Value::String(value));
+    }
+    Ok(())
+}
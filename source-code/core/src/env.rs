@@ -1,5 +1,5 @@
 use std::sync::Arc;
-use rustc_hash::FxHashMap;
+use rustc_hash::{FxHashMap, FxHashSet};
 use hl_parser::ast::{Node, StringPart, ArenaSize};
 
 #[derive(Debug, Clone)]
@@ -62,6 +62,12 @@ pub struct Env {
     /// Rejestr arena functions (gen 2): :: nazwa <rozmiar> def
     pub arena_funcs: FxHashMap<String, ArenaFuncEntry>,
     pub last_exit:   i32,
+    /// Wartość z `return` — ustawiona, dopóki wywołanie funkcji jej nie odbierze
+    pub returning:   Option<Value>,
+    /// Zmienne z --var / .env — przypisanie `%` w skrypcie ich nie zmienia
+    overrides:       FxHashSet<String>,
+    /// Zmienne pętli `@ x in` — w komendach wstawiane w cudzysłowie powłoki
+    quoted:          FxHashSet<String>,
     interp_buf:      String,
 }
 
@@ -75,11 +81,17 @@ impl Env {
         vars.insert("HL_VERSION".into(), Value::String("gen 2".into()));
         vars.insert("HL_OS".into(),      Value::String("HackerOS/Debian".into()));
         vars.insert("HL_GEN".into(),     Value::String("2".into()));
+        // Ścieżka do hl — biblioteki wołają przez nią `hl json` itp.
+        vars.insert("HL_BIN".into(),     Value::String(std::env::current_exe()
+            .map(|p| p.display().to_string()).unwrap_or_else(|_| "hl".into())));
         Self {
             vars,
             functions:   FxHashMap::default(),
             arena_funcs: FxHashMap::default(),
             last_exit:   0,
+            returning:   None,
+            overrides:   FxHashSet::default(),
+            quoted:      FxHashSet::default(),
             interp_buf:  String::with_capacity(256),
         }
     }
@@ -93,6 +105,9 @@ impl Env {
             functions:   parent.functions.clone(),
             arena_funcs: parent.arena_funcs.clone(),
             last_exit:   parent.last_exit,
+            returning:   None,
+            overrides:   parent.overrides.clone(),
+            quoted:      parent.quoted.clone(),
             interp_buf:  String::with_capacity(256),
         }
     }
@@ -102,6 +117,27 @@ impl Env {
         self.vars.insert(name.to_string(), val);
     }
 
+    /// Ustaw zmienną z pierwszeństwem przed `%` w skrypcie (dotenv.rs)
+    pub fn set_override(&mut self, name: &str, val: Value) {
+        self.overrides.insert(name.to_string());
+        self.set_var(name, val);
+    }
+
+    #[inline]
+    pub fn is_override(&self, name: &str) -> bool {
+        self.overrides.contains(name)
+    }
+
+    /// Wartość `name` w komendach będzie jednym słowem powłoki (element pętli `@ x in`);
+    /// false — zmienna była już oznaczona
+    pub fn mark_quoted(&mut self, name: &str) -> bool {
+        self.quoted.insert(name.to_string())
+    }
+
+    pub fn unmark_quoted(&mut self, name: &str) {
+        self.quoted.remove(name);
+    }
+
     pub fn get_var_str(&self, name: &str) -> String {
         if let Some(v) = self.vars.get(name) {
             return v.to_string_val();
@@ -120,22 +156,38 @@ impl Env {
     }
 
     pub fn resolve_string_parts(&mut self, parts: &[StringPart]) -> String {
+        self.resolve_parts(parts, false)
+    }
+
+    fn resolve_parts(&mut self, parts: &[StringPart], command: bool) -> String {
         self.interp_buf.clear();
+        // Cudzysłowy z literałów komendy — `"x @f"` jest już jednym słowem, nie cytuj drugi raz
+        let mut quotes = QuoteState::default();
         for part in parts {
             match part {
-                StringPart::Literal(s) => self.interp_buf.push_str(s),
+                StringPart::Literal(s) => {
+                    if command { quotes.scan(s); }
+                    self.interp_buf.push_str(s);
+                }
                 StringPart::Var(v) => {
                     let val = if let Some(val) = self.vars.get(v.as_str()) {
                         val.to_string_val()
                     } else {
                         std::env::var(v).unwrap_or_default()
                     };
-                    self.interp_buf.push_str(&val);
+                    if command && !quotes.inside() && self.quoted.contains(v.as_str()) {
+                        self.interp_buf.push_str(&shell_word(&val));
+                    } else {
+                        self.interp_buf.push_str(&val);
+                    }
                 }
                 // DynVar: @{arg@_i} lub @arg@_i — najpierw rozwiąż nazwę, potem lookup
                 // np. @{arg@_i} z _i=1 → resolve("arg" + get_var("_i")) = resolve("arg1") → get_var("arg1")
                 StringPart::DynVar(inner_parts) => {
+                    // Rekurencja używa interp_buf — zachowaj już złożony prefiks
+                    let prefix   = std::mem::take(&mut self.interp_buf);
                     let var_name = self.resolve_string_parts(inner_parts);
+                    self.interp_buf = prefix;
                     let val = if let Some(val) = self.vars.get(var_name.as_str()) {
                         val.to_string_val()
                     } else {
@@ -154,6 +206,15 @@ impl Env {
         self.resolve_string_parts(&parts)
     }
 
+    /// Jak interpolate, ale dla linii komendy: zmienne pętli po liście/mapie
+    /// (`@ h in @lista`) poza cudzysłowami trafiają do niej jako jedno słowo
+    /// powłoki — `"db 1"` → `'db 1'`
+    pub fn interpolate_command(&mut self, raw: &str) -> String {
+        if !raw.contains('@') { return raw.to_string(); }
+        let parts = hl_parser::ast::parse_string_parts(raw);
+        self.resolve_parts(&parts, true)
+    }
+
     // ── Zwykłe funkcje (gen 1+2): : nazwa def ────────────────────────────────
 
     #[inline]
@@ -189,3 +250,29 @@ impl Env {
         self.arena_funcs.contains_key(name)
     }
 }
+
+/// Czy bieżąca pozycja linii komendy leży w '…' albo "…"
+#[derive(Default)]
+struct QuoteState { single: bool, double: bool, escaped: bool }
+
+impl QuoteState {
+    fn scan(&mut self, s: &str) {
+        for c in s.chars() {
+            if self.escaped { self.escaped = false; continue; }
+            match c {
+                '\\' if !self.single => self.escaped = true,
+                '\'' if !self.double => self.single = !self.single,
+                '"'  if !self.single => self.double = !self.double,
+                _ => {}
+            }
+        }
+    }
+
+    fn inside(&self) -> bool { self.single || self.double }
+}
+
+/// Wartość jako jedno słowo powłoki; zwykłe słowa bez zmian
+fn shell_word(s: &str) -> String {
+    let plain = !s.is_empty() && s.chars().all(|c| c.is_ascii_alphanumeric() || "_-./:=,+%@".contains(c));
+    if plain { s.to_string() } else { format!("'{}'", s.replace('\'', "'\\''")) }
+}
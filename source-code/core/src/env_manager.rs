@@ -58,6 +58,7 @@ impl HlEnv {
 pub fn cmd_env_create(name_or_path: &str) -> Result<()> {
     let (name, env_path) = resolve_env_location(name_or_path);
     let env = HlEnv::from_path(&env_path);
+    let _lock = crate::lock::lock_state("env")?;
 
     if env.exists() {
         bail!(
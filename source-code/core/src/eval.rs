@@ -0,0 +1,41 @@
+use crate::diagnostics::{lint_gen, lint_source, parse_error_to_diag, Diag, DiagRenderer, DiagSummary};
+use crate::env::Env;
+use crate::executor::exec_nodes;
+use crate::exit::{self, exit_code_for};
+use hl_parser::parse_source;
+
+// ── Wspólna ścieżka wykonania źródła ──────────────────────────────────────────
+//
+// lint → parse → exec z diagnostyką na stderr. Używają jej REPL/powłoka
+// (hl-shell), `hl plik.hl`, `hl -c` i `hl shell -c` — jedno miejsce zamiast
+// kilku kopii, które rozjeżdżały się w kodach wyjścia i komunikatach.
+//
+// Kody wyjścia (exit.rs): 3 — błąd lintera lub parsera, 5 — błąd runtime,
+// 4 / 6 — błąd sklasyfikowany (brak zależności / narzędzia),
+// w pozostałych przypadkach exit code skryptu.
+
+pub fn eval_source(filename: &str, source: &str, env: &mut Env) -> i32 {
+    let renderer = DiagRenderer::new(filename, source);
+
+    let mut lint_diags = lint_source(source);
+    lint_diags.extend(lint_gen(source));
+    if !lint_diags.is_empty() {
+        renderer.emit_all(&lint_diags);
+        let sum = DiagSummary::from_diags(&lint_diags);
+        sum.print();
+        if sum.has_errors() { return exit::SOURCE; }
+    }
+
+    let nodes = match parse_source(source) {
+        Ok(n)  => n,
+        Err(e) => { renderer.emit(&parse_error_to_diag(&e)); return exit::SOURCE; }
+    };
+
+    match exec_nodes(&nodes, env) {
+        Ok(r)  => r.exit_code,
+        Err(e) => {
+            renderer.emit(&Diag::error(e.to_string()).with_note(format!("blad runtime w '{}'", filename)));
+            exit_code_for(&e, exit::EXECUTION)
+        }
+    }
+}
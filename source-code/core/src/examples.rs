@@ -0,0 +1,164 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use serde::Deserialize;
+use std::collections::BTreeMap;
+use std::path::{Path, PathBuf};
+use std::process::Command;
+use crate::exit::{classified, ErrorClass};
+
+// ── hl examples — przykłady z repozytorium bit ────────────────────────────────
+//
+//   hl examples list [--refresh]
+//   hl examples show <nazwa>              opis, pliki i treść pliku wejściowego
+//   hl examples run <nazwa> [--isolated] [-- argumenty]
+//
+// Przykłady to sekcja "@examples" w repo-list.json (tej samej liście, której
+// używa bit — cache/repo-list.json, BIT_REPO nadpisuje adres):
+//
+//   "@examples": {
+//     "backup-rsync": { "url": "HackerOS-Linux-System/hl-examples.git", "path": "backup-rsync",
+//                       "entry": "main.hl", "description": "Kopia zapasowa przez rsync",
+//                       "tags": ["backup", "ssh"] }
+//   }
+//
+// `url` jak w pakietach bit: właściciel/repo.git (GitHub), pełny adres git albo
+// archiwum .tar.gz. Przykład jest pobierany do świeżego katalogu tymczasowego
+// hl (hl clean go usuwa). --isolated uruchamia go jak komendy `->`: w osobnych
+// przestrzeniach nazw (mount, pid, sieć) — przez przestrzeń użytkownika, bez sudo.
+
+pub const REPO_RAW: &str = "https://raw.githubusercontent.com/bit-io/repository/main/bit-repo/repo-list.json";
+const SECTION: &str = "@examples";
+
+#[derive(Debug, Clone, Deserialize)]
+pub struct Example {
+    pub url:         String,
+    #[serde(default)]
+    pub path:        Option<String>,
+    #[serde(default)]
+    pub entry:       Option<String>,
+    #[serde(default)]
+    pub description: String,
+    #[serde(default)]
+    pub tags:        Vec<String>,
+}
+
+impl Example {
+    fn entry(&self) -> &str { self.entry.as_deref().unwrap_or("main.hl") }
+}
+
+fn repo_file() -> PathBuf {
+    crate::paths::cache_dir().join("repo-list.json")
+}
+
+fn refresh() -> Result<()> {
+    let url = std::env::var("BIT_REPO").unwrap_or_else(|_| REPO_RAW.to_string());
+    let dest = repo_file();
+    if let Some(dir) = dest.parent() { std::fs::create_dir_all(dir)?; }
+    if let Some(local) = url.strip_prefix("file://") {
+        std::fs::copy(local, &dest).with_context(|| format!("Nie można skopiować {}", local))?;
+        return Ok(());
+    }
+    let part = dest.with_extension(format!("json.{}", std::process::id()));
+    if !crate::net::curl_download(&url, &part)? {
+        let _ = std::fs::remove_file(&part);
+        return Err(classified(ErrorClass::Dependency, format!("Nie można pobrać listy repozytorium: {}", url)));
+    }
+    std::fs::rename(&part, &dest)?;
+    Ok(())
+}
+
+pub fn load_examples(force_refresh: bool) -> Result<BTreeMap<String, Example>> {
+    if force_refresh || !repo_file().exists() { refresh()?; }
+    let src = std::fs::read_to_string(repo_file())?;
+    let repo: serde_json::Value = serde_json::from_str(&src).context("nieprawidłowy repo-list.json")?;
+    let Some(section) = repo.get(SECTION) else { return Ok(BTreeMap::new()) };
+    Ok(serde_json::from_value(section.clone()).context("nieprawidłowa sekcja @examples w repo-list.json")?)
+}
+
+fn find(name: &str) -> Result<Example> {
+    let mut all = load_examples(false)?;
+    if !all.contains_key(name) { all = load_examples(true)?; }
+    all.remove(name).with_context(|| format!("Nie ma przykładu '{}' — lista: hl examples list", name))
+}
+
+fn git_url(url: &str) -> String {
+    if url.contains("://") || url.starts_with('/') || url.starts_with("git@") { url.to_string() }
+    else { format!("https://github.com/{}", url) }
+}
+
+/// Pobierz przykład do nowego katalogu tymczasowego; zwraca katalog przykładu
+fn download(name: &str, ex: &Example) -> Result<PathBuf> {
+    let dir = crate::tmp::run_temp_dir("example")?;
+    let ok = if ex.url.ends_with(".tar.gz") || ex.url.ends_with(".tgz") {
+        let archive = dir.join("example.tar.gz");
+        crate::net::curl_download(&ex.url, &archive)?
+            && Command::new("tar").arg("-xzf").arg(&archive).arg("-C").arg(&dir).status()?.success()
+            && std::fs::remove_file(&archive).is_ok()
+    } else {
+        let url = git_url(&ex.url);
+        crate::net::git_command(&url).args(["clone", "-q", "--depth=1"]).arg(&url).arg(dir.join(name)).status()?.success()
+    };
+    if !ok { return Err(classified(ErrorClass::Dependency, format!("Nie można pobrać przykładu {} ({})", name, ex.url))); }
+    let root = if dir.join(name).is_dir() { dir.join(name) } else { dir };
+    let root = match &ex.path { Some(p) => root.join(p), None => root };
+    if !root.join(ex.entry()).is_file() {
+        bail!("przykład {}: brak pliku wejściowego {} w {}", name, ex.entry(), root.display());
+    }
+    Ok(root)
+}
+
+pub fn cmd_examples_list(refresh: bool) -> Result<()> {
+    let all = load_examples(refresh)?;
+    println!("{}", "hl examples: przykłady z repozytorium bit".bright_magenta().bold());
+    if all.is_empty() { println!("  {}", "brak — lista nie ma sekcji @examples".bright_black()); }
+    let w = all.keys().map(|k| k.len()).max().unwrap_or(0);
+    for (name, ex) in &all {
+        let tags = if ex.tags.is_empty() { String::new() } else { format!("  [{}]", ex.tags.join(", ")) };
+        println!("  {}  {}{}", format!("{:<w$}", name, w = w).bright_white(), ex.description, tags.bright_black());
+    }
+    Ok(())
+}
+
+pub fn cmd_examples_show(name: &str) -> Result<()> {
+    let ex = find(name)?;
+    let dir = download(name, &ex)?;
+    println!("{} {}", name.bright_magenta().bold(), ex.description);
+    println!("  {} {}", "źródło:".bright_black(), ex.url);
+    println!("  {} {}", "katalog:".bright_black(), dir.display());
+    let mut files: Vec<String> = walk(&dir, &dir);
+    files.sort();
+    for f in &files { println!("    {}", f); }
+    println!("\n{} {}", "──".bright_black(), ex.entry().bright_white());
+    print!("{}", std::fs::read_to_string(dir.join(ex.entry()))?);
+    Ok(())
+}
+
+fn walk(root: &Path, dir: &Path) -> Vec<String> {
+    let Ok(rd) = std::fs::read_dir(dir) else { return vec![] };
+    rd.flatten().flat_map(|e| {
+        let p = e.path();
+        if e.file_name() == ".git" { vec![] }
+        else if p.is_dir() { walk(root, &p) }
+        else { vec![p.strip_prefix(root).unwrap_or(&p).display().to_string()] }
+    }).collect()
+}
+
+/// hl examples run — kod wyjścia przykładu
+pub fn cmd_examples_run(name: &str, isolated: bool, args: &[String]) -> Result<i32> {
+    let ex = find(name)?;
+    let dir = download(name, &ex)?;
+    println!("{} {} {}", "hl examples:".bright_magenta().bold(), name.bright_white(), dir.display().to_string().bright_black());
+    let hl = std::env::current_exe().unwrap_or_else(|_| PathBuf::from("hl"));
+    let mut cmd = if isolated {
+        if which::which("unshare").is_err() {
+            return Err(classified(ErrorClass::Toolchain, "--isolated wymaga unshare (util-linux)"));
+        }
+        let mut c = Command::new("unshare");
+        c.args(["--user", "--map-root-user", "--mount", "--pid", "--net", "--fork", "--"]).arg(&hl);
+        c
+    } else {
+        Command::new(&hl)
+    };
+    let status = cmd.arg("run").arg(ex.entry()).args(args).current_dir(&dir).status()?;
+    Ok(status.code().unwrap_or(crate::exit::FAILURE))
+}
@@ -6,6 +6,7 @@ use hl_parser::ast::*;
 use crate::env::{Env, Value};
 use crate::deps::resolve_dependency;
 use crate::libs::resolve_import;
+use crate::exit::{self, classified, ErrorClass};
 use crate::quick::exec_quick;
 use crate::arena::ArenaContext;
 use crate::extern_runner::exec_extern_def;
@@ -161,10 +162,18 @@ fn shell_words(s: &str) -> SmallVec<[String; 8]> {
     let mut cur   = String::with_capacity(32);
     let (mut in_s, mut in_d) = (false, false);
     let mut had_quote = false;
-    for c in s.chars() {
+    let mut chars = s.chars();
+    while let Some(c) = chars.next() {
         match c {
             '\'' if !in_d => { in_s = !in_s; had_quote = true; }
             '"'  if !in_s => { in_d = !in_d; had_quote = true; }
+            // `\x` poza '…' — dosłowny znak (np. `'\''` z Env::interpolate_command);
+            // w "…" tylko przed `"` i `\`, jak w sh
+            '\\' if !in_s => match chars.next() {
+                Some(n) if !in_d || matches!(n, '"' | '\\') => cur.push(n),
+                Some(n) => { cur.push('\\'); cur.push(n); }
+                None    => cur.push('\\'),
+            },
             ' ' | '\t' if !in_s && !in_d => {
                 if !cur.is_empty() || had_quote {
                     words.push(std::mem::take(&mut cur));
@@ -181,7 +190,7 @@ fn shell_words(s: &str) -> SmallVec<[String; 8]> {
 
 fn run_command(raw: &str, sudo: bool, isolated: bool, interpolate: bool, env: &mut Env, capture: bool) -> Result<ExecResult> {
     let expanded = if interpolate || raw.contains('@') {
-        env.interpolate(raw)
+        env.interpolate_command(raw)
     } else {
         raw.to_string()
     };
@@ -199,10 +208,17 @@ fn run_command(raw: &str, sudo: bool, isolated: bool, interpolate: bool, env: &m
         return Ok(ExecResult::err_or_ok(code));
     }
 
-    if needs_shell(trimmed) { return run_via_shell(trimmed, sudo, isolated, capture); }
-    let parts = shell_words(trimmed);
-    if parts.is_empty() { return Ok(ExecResult::ok()); }
-    build_and_run(parts, sudo, isolated, capture)
+    // hl run --record: krok z kodem wyjścia (record.rs)
+    let started = crate::record::step_clock();
+    let r = if needs_shell(trimmed) {
+        run_via_shell(trimmed, sudo, isolated, capture)?
+    } else {
+        let parts = shell_words(trimmed);
+        if parts.is_empty() { return Ok(ExecResult::ok()); }
+        build_and_run(parts, sudo, isolated, capture)?
+    };
+    if started > 0 { crate::record::note_step(trimmed, sudo, r.exit_code, started); }
+    Ok(r)
 }
 
 fn run_via_shell(cmd: &str, sudo: bool, isolated: bool, capture: bool) -> Result<ExecResult> {
@@ -225,6 +241,20 @@ fn build_and_run(parts: SmallVec<[String; 8]>, sudo: bool, isolated: bool, captu
     exec_process(prog, args, capture)
 }
 
+/// Limit przechwyconego stdout (|> @var): HL_CAPTURE_MAX, potem
+/// `[runtime] -> capture_max` w config.hk, domyślnie 16M. Czytany raz na proces —
+/// przechwycenia w pętli nie wczytują config.hk za każdym razem.
+fn capture_limit() -> u64 {
+    const DEFAULT: u64 = 16 << 20;
+    static LIMIT: std::sync::OnceLock<u64> = std::sync::OnceLock::new();
+    *LIMIT.get_or_init(|| {
+        std::env::var("HL_CAPTURE_MAX").ok()
+            .or_else(|| crate::config::load_config().get("runtime", "capture_max").map(str::to_string))
+            .and_then(|s| crate::cache::parse_size(&s).ok())
+            .unwrap_or(DEFAULT)
+    })
+}
+
 fn exec_process(prog: String, args: Vec<String>, capture: bool) -> Result<ExecResult> {
     let mut cmd = Command::new(&prog);
     cmd.args(&args);
@@ -234,10 +264,22 @@ fn exec_process(prog: String, args: Vec<String>, capture: bool) -> Result<ExecRe
         cmd.stdin(Stdio::null())
            .stdout(Stdio::piped())
            .stderr(Stdio::inherit());
-        let out = cmd.output()?;
+        let mut child = cmd.spawn()?;
+        let limit = capture_limit();
+        let mut buf = Vec::new();
+        if let Some(stdout) = child.stdout.take() {
+            std::io::Read::read_to_end(&mut std::io::Read::take(stdout, limit + 1), &mut buf)?;
+        }
+        if buf.len() as u64 > limit {
+            let _ = child.kill();
+            let _ = child.wait();
+            return Err(classified(ErrorClass::Execution, format!(
+                "`{}`: wyjście przekracza limit przechwycenia {} B (HL_CAPTURE_MAX / [runtime] capture_max)",
+                args.last().unwrap_or(&prog), limit)));
+        }
         return Ok(ExecResult {
-            exit_code: out.status.code().unwrap_or(1),
-            stdout:    Some(String::from_utf8_lossy(&out.stdout).into_owned()),
+            exit_code: child.wait()?.code().unwrap_or(1),
+            stdout:    Some(String::from_utf8_lossy(&buf).into_owned()),
         });
     }
     cmd.stdin(Stdio::inherit())
@@ -261,11 +303,15 @@ pub fn exec_nodes(nodes: &[Node], env: &mut Env) -> Result<ExecResult> {
         let r = exec_node(node, env)?;
         env.last_exit = r.exit_code;
         last = r;
+        if env.returning.is_some() { break; }
     }
     Ok(last)
 }
 
 pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
+    if crate::checkmode::check_mode() && crate::checkmode::skip_unchecked(node, env) {
+        return Ok(ExecResult::ok());
+    }
     match node {
         Node::LineComment(_) | Node::DocComment(_) | Node::BlockComment(_) => Ok(ExecResult::ok()),
 
@@ -326,7 +372,7 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
         }
 
         Node::HshCommand { raw } => {
-            let expanded = env.interpolate(raw);
+            let expanded = env.interpolate_command(raw);
             let status = Command::new("hsh")
             .args(["-c", expanded.trim()])
             .stdin(Stdio::inherit()).stdout(Stdio::inherit()).stderr(Stdio::inherit())
@@ -335,16 +381,14 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
             Ok(ExecResult { exit_code: status.code().unwrap_or(1), stdout: None })
         }
 
-        Node::Background { raw } => {
-            let expanded = env.interpolate(raw);
-            let child = Command::new("sh")
-            .args(["-c", expanded.trim()])
-            .stdin(Stdio::null()).stdout(Stdio::inherit()).stderr(Stdio::inherit())
-            .spawn()
-            .map_err(|e| anyhow::anyhow!("Błąd tła: {}", e))?;
-            env.set_var("_bg_pid", Value::Number(child.id() as f64));
-            eprintln!("\x1b[90m[hl &] PID={}\x1b[0m", child.id());
-            std::mem::forget(child);
+        Node::Background { raw, name } => {
+            let expanded = env.interpolate_command(raw);
+            let pid = crate::jobs::spawn_job(name.as_deref(), expanded.trim())?;
+            env.set_var("_bg_pid", Value::Number(pid as f64));
+            match name {
+                Some(n) => eprintln!("\x1b[90m[hl &{}] PID={}\x1b[0m", n, pid),
+                None    => eprintln!("\x1b[90m[hl &] PID={}\x1b[0m", pid),
+            }
             Ok(ExecResult::ok())
         }
 
@@ -353,10 +397,46 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
             for _ in 0..*count {
                 last = exec_nodes(body, env)?;
                 env.last_exit = last.exit_code;
+                if env.returning.is_some() { break; }
             }
             Ok(last)
         }
 
+        Node::Check { check, body } => {
+            let in_state = crate::checkmode::in_desired_state(check, env);
+            if crate::checkmode::check_mode() {
+                crate::checkmode::report_check(in_state, body, env);
+                return Ok(ExecResult::ok());
+            }
+            if in_state {
+                eprintln!("\x1b[90m[hl ~check] bez zmian: {}\x1b[0m", check.trim());
+                return Ok(ExecResult::ok());
+            }
+            exec_nodes(body, env)
+        }
+
+        Node::Once { name, inputs, body } => crate::state::exec_once(name, inputs, body, env),
+
+        Node::Undo { undo, body } => crate::rollback::exec_undoable(undo, body, env),
+
+        Node::Retry { spec, body } => {
+            let mut attempt = 1;
+            loop {
+                let r = exec_nodes(body, env);
+                if env.returning.is_some() || attempt >= spec.attempts { return r; }
+                let why = match &r {
+                    Ok(res) if res.exit_code == 0 => return r,
+                    Ok(res) => format!("kod {}", res.exit_code),
+                    Err(e)  => e.to_string(),
+                };
+                let delay = spec.delay_after(attempt);
+                eprintln!("\x1b[90m[hl ~retry] próba {}/{} nieudana ({}) — ponowienie za {} ms\x1b[0m",
+                    attempt, spec.attempts, why, delay);
+                std::thread::sleep(std::time::Duration::from_millis(delay));
+                attempt += 1;
+            }
+        }
+
         Node::FileImport { path, detail } => {
             let expanded = env.interpolate(path);
             // Dodaj .hl jeśli brak rozszerzenia (gen 2: << nazwa bez końcówki)
@@ -366,9 +446,9 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
                 expanded.clone()
             };
             if !std::path::Path::new(&resolved).exists() {
-                bail!("Import: plik nie istnieje: '{}'", resolved);
+                return Err(classified(ErrorClass::Dependency, format!("Import: plik nie istnieje: '{}'", resolved)));
             }
-            let src = std::fs::read_to_string(&resolved)?;
+            let src = crate::signing::read_trusted_source(std::path::Path::new(&resolved))?;
             if let Some(d) = detail { env.set_var("_import_detail", Value::String(d.clone())); }
             exec_nodes(&hl_parser::parse_source(&src)?, env)
         }
@@ -401,7 +481,7 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
             // Załaduj i wykonaj imports.hl w kontekście katalogu
             // Zmień katalog roboczy tymczasowo żeby << wewnątrz imports.hl
             // działało względem katalogu modułu
-            let src = std::fs::read_to_string(&imports_file)?;
+            let src = crate::signing::read_trusted_source(&imports_file)?;
 
             // Ustaw zmienną _module_dir żeby imports.hl mogło jej użyć
             let abs_dir = std::fs::canonicalize(dir)
@@ -453,6 +533,19 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
         }
 
         Node::VarDecl { name, typ: _typ, value } => {
+            if env.is_override(name) { return Ok(ExecResult::ok()); }
+            // Poprzednia mapa pod tą nazwą — jej wpisy `nazwa.klucz` nie mogą przetrwać przypisania
+            if let Value::List(old) = env.get_var(name) {
+                let stale: Vec<String> = old.iter().map(|k| format!("{}.{}", name, k.to_string_val())).collect();
+                for key in stale { env.vars.remove(&key); }
+            }
+            // Mapa: każdy wpis jako zmienna `nazwa.klucz` (@{nazwa.klucz}), sama nazwa — lista kluczy
+            if let VarValue::Map(entries) = value {
+                for (key, v) in entries {
+                    let val = eval_var_value(v, env)?;
+                    env.set_var(&format!("{}.{}", name, key), val);
+                }
+            }
             let val = eval_var_value(value, env)?;
             env.set_var(name, val);
             Ok(ExecResult::ok())
@@ -477,13 +570,13 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
         Node::Dependency { name, apt_package } => {
             let apt = apt_package.as_deref();
             match resolve_dependency(name, apt) {
-                Ok(r)  => Ok(if r.is_available() { ExecResult::ok() } else { ExecResult::err(1) }),
-                Err(e) => { eprintln!("\x1b[31m[hl dep]\x1b[0m {}", e); Ok(ExecResult::err(1)) }
+                Ok(r)  => Ok(if r.is_available() { ExecResult::ok() } else { ExecResult::err(exit::DEPENDENCY) }),
+                Err(e) => { eprintln!("\x1b[31m[hl dep]\x1b[0m {}", e); Ok(ExecResult::err(exit::DEPENDENCY)) }
             }
         }
 
-        Node::Import { lib, detail } => {
-            resolve_import(lib, detail.as_deref(), env)?;
+        Node::Import { lib, detail, only } => {
+            resolve_import(lib, detail.as_deref(), only, env)?;
             Ok(ExecResult::ok())
         }
 
@@ -492,11 +585,23 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
             Ok(ExecResult::ok())
         }
 
-        Node::FuncCall { name } => {
-            match env.get_function(name) {
-                Some(body) => exec_nodes(&body, env),
-                None       => bail!("Niezdefiniowana funkcja: '{}'", name),
+        Node::FuncCall { name, args, ret } => {
+            let Some(body) = env.get_function(name) else { bail!("Niezdefiniowana funkcja: '{}'", name) };
+            let result = if args.is_empty() { exec_nodes(&body, env)? } else { exec_func_with_args(&body, args, env)? };
+            let value = env.returning.take();
+            if let Some(var) = ret {
+                env.set_var(var, value.unwrap_or(Value::String(String::new())));
             }
+            Ok(result)
+        }
+
+        Node::Return { value } => {
+            let v = match value.as_slice() {
+                [StringPart::Var(v)] => env.get_var_owned(v),
+                _ => Value::String(env.resolve_string_parts(value)),
+            };
+            env.returning = Some(v);
+            Ok(ExecResult::ok())
         }
 
         Node::Conditional { condition, body } => {
@@ -507,15 +612,44 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
             if run { exec_nodes(body, env) } else { Ok(ExecResult::ok()) }
         }
 
+        Node::IfExpr { condition, body } => {
+            let cond_str = env.resolve_string_parts(condition);
+            if eval_condition_fast(&cond_str, env)? { exec_nodes(body, env) } else { Ok(ExecResult::ok()) }
+        }
+
+        Node::FeatureBlock { conditions, body } => {
+            // hl compile wycina nieaktywne sekcje wcześniej; interpreter wybiera według HL_FEATURES
+            if hl_parser::features::is_active(conditions, &hl_parser::features::enabled_from_env()) {
+                exec_nodes(body, env)
+            } else { Ok(ExecResult::ok()) }
+        }
+
         Node::ForIn { var, iterable, body } => {
-            let iter_str = env.resolve_string_parts(iterable);
-            let mut last = ExecResult::ok();
-            for item in iter_str.split_whitespace() {
-                env.set_var(var, Value::String(item.to_string()));
-                last = exec_nodes(body, env)?;
-                env.last_exit = last.exit_code;
+            // @lista — elementy w całości (także ze spacjami); inaczej słowa i zakresy A..B
+            let items = match iterable.as_slice() {
+                [StringPart::Var(v)] => match env.get_var(v) {
+                    Value::List(l) => Some(l.iter().map(|x| x.to_string_val()).collect::<Vec<_>>()),
+                    _ => None,
+                },
+                _ => None,
+            };
+            // Element listy/mapy w komendzie to jedno słowo powłoki, nawet ze spacjami
+            // (Env::interpolate_command); słowa i zakresy wstawiane są jak dotąd
+            let marked = items.is_some() && env.mark_quoted(var);
+            let items = match items {
+                Some(items) => items,
+                None => for_in_items(&env.resolve_string_parts(iterable)).map_err(anyhow::Error::msg)?,
+            };
+            let mut last = Ok(ExecResult::ok());
+            for item in items {
+                env.set_var(var, Value::String(item));
+                last = exec_nodes(body, env);
+                let Ok(r) = &last else { break };
+                env.last_exit = r.exit_code;
+                if env.returning.is_some() { break; }
             }
-            Ok(last)
+            if marked { env.unmark_quoted(var); }
+            last
         }
 
         Node::WhileLoop { condition, body } => {
@@ -530,6 +664,7 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
                 if !eval_condition_fast(&cond_str, env)? { break; }
                 let r = exec_nodes(body, env)?;
                 env.last_exit = r.exit_code;
+                if env.returning.is_some() { break; }
             }
             Ok(ExecResult::ok())
         }
@@ -567,11 +702,15 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
             Ok(ExecResult::ok())
         }
 
-        Node::PipeToVar { command, mode, var_name } => {
+        Node::PipeToVar { command, mode, var_name, strict } => {
             let interpolate = true;
             let sudo     = matches!(mode, CommandMode::Sudo | CommandMode::IsolatedSudo | CommandMode::WithVarsSudo);
             let isolated = matches!(mode, CommandMode::Isolated | CommandMode::IsolatedSudo | CommandMode::WithVarsIsolated);
             let r = run_command(command, sudo, isolated, interpolate, env, true)?;
+            if *strict && r.exit_code != 0 {
+                return Err(classified(ErrorClass::Execution, format!(
+                    "`{}` zakończone kodem {} — @{} nie została ustawiona (|>!)", command.trim(), r.exit_code, var_name)));
+            }
             let output = r.stdout.unwrap_or_default().trim().to_string();
             env.set_var(var_name, Value::String(output));
             Ok(ExecResult { exit_code: r.exit_code, stdout: None })
@@ -581,6 +720,12 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
             let bin = tool.binary_name();
             let args_str = env.resolve_string_parts(args);
             let args_str = args_str.trim();
+            // hl-desktop jest wbudowane — nie wymaga osobnej binarki
+            if bin == crate::desktop::TOOL {
+                let out = crate::desktop::run(&crate::desktop::split_args(args_str))?;
+                if !out.is_empty() { print!("{}", out); }
+                return Ok(ExecResult::ok());
+            }
             if which::which(bin).is_err() {
                 eprintln!("\x1b[33m[hl ||]\x1b[0m Narzędzie '{}' nie jest zainstalowane.", bin);
                 return Ok(ExecResult::err(127));
@@ -593,6 +738,36 @@ pub fn exec_node(node: &Node, env: &mut Env) -> Result<ExecResult> {
     }
 }
 
+/// `-- nazwa a b` — argumenty jako @arg0, @arg1, … i @argc (jak argumenty skryptu);
+/// po powrocie te zmienne mają wartości sprzed wywołania. `@lista` przekazuje listę w całości.
+/// Nie $1..$n: `$` w linii komendy należy do powłoki (`> cp $1 x` idzie do bash -c
+/// bez interpolacji hl), a zmienne hl to `@` — te same nazwy co argumenty skryptu.
+fn exec_func_with_args(body: &[Node], args: &[Vec<StringPart>], env: &mut Env) -> Result<ExecResult> {
+    let values: Vec<Value> = args.iter().map(|a| match a.as_slice() {
+        [StringPart::Var(v)] => env.get_var_owned(v),
+        _ => Value::String(env.resolve_string_parts(a)),
+    }).collect();
+    let outer_argc = env.vars.get("argc").map(|v| v.as_f64() as usize).unwrap_or(0);
+    let names: Vec<String> = std::iter::once("argc".to_string())
+        .chain((0..values.len().max(outer_argc)).map(|i| format!("arg{}", i)))
+        .collect();
+    let saved: Vec<Option<Value>> = names.iter().map(|k| env.vars.remove(k)).collect();
+
+    env.set_var("argc", Value::Number(values.len() as f64));
+    for (i, v) in values.into_iter().enumerate() {
+        env.set_var(&format!("arg{}", i), v);
+    }
+    let result = exec_nodes(body, env);
+
+    for (k, v) in names.into_iter().zip(saved) {
+        match v {
+            Some(v) => { env.vars.insert(k, v); }
+            None    => { env.vars.remove(&k); }
+        }
+    }
+    result
+}
+
 // ── Arena Function execution ──────────────────────────────────────────────────
 
 /// Wykonaj arena function z bump-pointer arena allocatorem
@@ -672,16 +847,9 @@ fn eval_var_value(value: &VarValue, env: &mut Env) -> Result<Value> {
            Value::String(result)
        }
        VarValue::List(items) => {
-           Value::List(items.iter().map(|v| match v {
-               VarValue::String(s) => Value::String(s.clone()),
-                                        VarValue::Int(n)    => Value::Number(*n as f64),
-                                        VarValue::Float(n)  => Value::Number(*n),
-                                        VarValue::Number(n) => Value::Number(*n),
-                                        VarValue::Bool(b)   => Value::Bool(*b),
-                                        _                   => Value::Nil,
-           }).collect())
+           Value::List(items.iter().map(|v| eval_var_value(v, env)).collect::<Result<_>>()?)
        }
-       VarValue::Map(_) => Value::String(String::new()),
+       VarValue::Map(entries) => Value::List(entries.iter().map(|(k, _)| Value::String(k.clone())).collect()),
     })
 }
 
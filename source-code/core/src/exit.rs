@@ -0,0 +1,66 @@
+use std::fmt;
+
+// ── Kody wyjścia hl ───────────────────────────────────────────────────────────
+//
+// Kody dotyczą błędów samego hl. Gdy skrypt dojdzie do końca, hl kończy się
+// jego exit code (hl run / hl plik.hl), więc skrypt może zwrócić dowolny kod.
+//
+//   0    sukces
+//   1    ogólny błąd komendy hl
+//   2    błędne użycie (nieznana opcja, brak argumentu — clap)
+//   3    błędy źródła: parser lub linter (hl check, hl run)
+//   4    brak zależności: narzędzie `//`, biblioteka bit/github, import
+//   5    błąd wykonania w interpreterze
+//   6    brak narzędzia wymaganego przez hl (hl-docs, ssh-keygen, tar, git, …)
+//   126  odmowa uruchomienia: manifest uprawnień, podpis, polityka security.hk
+//   130  przerwane (Ctrl+C / SIGINT)
+
+pub const OK:         i32 = 0;
+pub const FAILURE:    i32 = 1;
+pub const USAGE:      i32 = 2;
+pub const SOURCE:     i32 = 3;
+pub const DEPENDENCY: i32 = 4;
+pub const EXECUTION:  i32 = 5;
+pub const TOOLCHAIN:  i32 = 6;
+pub const DENIED:     i32 = 126;
+pub const CANCELLED:  i32 = 130;
+
+/// Klasa błędu niesiona w łańcuchu anyhow — wyznacza kod wyjścia
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum ErrorClass { Dependency, Execution, Toolchain, Denied }
+
+impl ErrorClass {
+    pub fn code(self) -> i32 {
+        match self {
+            ErrorClass::Dependency => DEPENDENCY,
+            ErrorClass::Execution  => EXECUTION,
+            ErrorClass::Toolchain  => TOOLCHAIN,
+            ErrorClass::Denied     => DENIED,
+        }
+    }
+}
+
+#[derive(Debug)]
+pub struct ClassifiedError {
+    pub class:   ErrorClass,
+    pub message: String,
+}
+
+impl fmt::Display for ClassifiedError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result { f.write_str(&self.message) }
+}
+
+impl std::error::Error for ClassifiedError {}
+
+/// Błąd z klasą: `return Err(classified(ErrorClass::Toolchain, "tar nie jest zainstalowany"))`
+pub fn classified(class: ErrorClass, message: impl Into<String>) -> anyhow::Error {
+    anyhow::Error::new(ClassifiedError { class, message: message.into() })
+}
+
+/// Kod wyjścia dla błędu: klasa z łańcucha przyczyn albo `default`
+pub fn exit_code_for(err: &anyhow::Error, default: i32) -> i32 {
+    err.chain()
+        .find_map(|e| e.downcast_ref::<ClassifiedError>())
+        .map(|c| c.class.code())
+        .unwrap_or(default)
+}
@@ -0,0 +1,221 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+
+// ── hl explain ────────────────────────────────────────────────────────────────
+//
+// Stałe kody diagnostyk lexera, parsera i lintera (HL0001…). Kod widać w nagłówku
+// diagnostyki (`error[HL0005]: ...`); `hl explain HL0005` pokazuje wyjaśnienie,
+// błędny przykład i poprawkę. Kodów się nie przenumerowuje — nowe dopisuje się
+// na końcu tabeli.
+
+pub struct Explanation {
+    pub code:    &'static str,
+    pub title:   &'static str,
+    pub text:    &'static str,
+    pub broken:  &'static str,
+    pub fixed:   &'static str,
+}
+
+pub const EXPLANATIONS: &[Explanation] = &[
+    Explanation {
+        code: "HL0001", title: "nieoczekiwany znak",
+        text: "Lexer trafił na znak, który nie zaczyna żadnego tokenu HL. Zwykle to literówka \
+               w operatorze albo znak skopiowany z edytora (np. typograficzny cudzysłów).",
+        broken: "~> Witaj\n§ x = 1",
+        fixed:  "~> Witaj\n% x = 1",
+    },
+    Explanation {
+        code: "HL0002", title: "niezamknięty string",
+        text: "String otwarty cudzysłowem `\"` nie został zamknięty przed końcem linii.",
+        broken: "> git commit -m \"poprawka",
+        fixed:  "> git commit -m \"poprawka\"",
+    },
+    Explanation {
+        code: "HL0003", title: "niezamknięty komentarz blokowy",
+        text: "Komentarz blokowy `//` obejmujący kilka linii musi kończyć się `\\\\`. \
+               Bez zamknięcia reszta pliku jest traktowana jak komentarz.",
+        broken: "// opis skryptu\n   druga linia\n~> start",
+        fixed:  "// opis skryptu\n   druga linia \\\\\n~> start",
+    },
+    Explanation {
+        code: "HL0004", title: "nieoczekiwany token",
+        text: "Parser oczekiwał początku instrukcji, a znalazł inny token. Każda linia HL \
+               zaczyna się od operatora (`>`, `~>`, `%`, `:`, `--`, `?`, …).",
+        broken: "apt update",
+        fixed:  "> apt update",
+    },
+    Explanation {
+        code: "HL0005", title: "brakujące `done`",
+        text: "Blok (funkcja, warunek, pętla, goroutine, switch) nie został zamknięty słowem `done`.",
+        broken: ": backup def\n    > tar czf /tmp/b.tgz /etc\n\n-- backup",
+        fixed:  ": backup def\n    > tar czf /tmp/b.tgz /etc\ndone\n\n-- backup",
+    },
+    Explanation {
+        code: "HL0006", title: "brakujące `def`",
+        text: "Definicja funkcji ma postać `: nazwa def`. Bez `def` parser nie wie, że to początek funkcji.",
+        broken: ": backup\n    > tar czf /tmp/b.tgz /etc\ndone",
+        fixed:  ": backup def\n    > tar czf /tmp/b.tgz /etc\ndone",
+    },
+    Explanation {
+        code: "HL0007", title: "niezamknięta lista eksportu",
+        text: "Eksport listy `=> NAZWA [` wymaga zamykającego `]` w osobnej linii.",
+        broken: "=> PATH [\n| /usr/local/bin\n| /usr/bin",
+        fixed:  "=> PATH [\n| /usr/local/bin\n| /usr/bin\n]",
+    },
+    Explanation {
+        code: "HL0008", title: "nieprawidłowa deklaracja gena",
+        text: "Deklaracja `using <gen N>` ma złą składnię albo wskazuje gen spoza obsługiwanego zakresu.",
+        broken: "using gen 2",
+        fixed:  "using <gen 2>",
+    },
+    Explanation {
+        code: "HL0009", title: "`using` po kodzie",
+        text: "Gen jest ustalany przed wykonaniem — deklaracja `using` po pierwszej instrukcji \
+               może zostać pominięta.",
+        broken: "~> start\nusing <gen 2>",
+        fixed:  "using <gen 2>\n~> start",
+    },
+    Explanation {
+        code: "HL0010", title: "`echo` w bloku komend",
+        text: "Komenda `echo` jest w HL zabroniona — tekst wypisuje się operatorem `~>`, \
+               który obsługuje interpolację zmiennych.",
+        broken: "> echo Gotowe",
+        fixed:  "~> Gotowe",
+    },
+    Explanation {
+        code: "HL0011", title: "`> sudo` zamiast `^>`",
+        text: "Komendy z uprawnieniami roota uruchamia operator `^>`. hl zna je wtedy przed startem \
+               (sudo pre-flight, manifest uprawnień, analiza bezpieczeństwa).",
+        broken: "> sudo apt update",
+        fixed:  "^> apt update",
+    },
+    Explanation {
+        code: "HL0012", title: "zmienna środowiskowa przez `%`",
+        text: "`%` tworzy zmienną lokalną HL, niewidoczną dla uruchamianych programów. \
+               Zmienne środowiskowe (PATH, HOME, …) ustawia się eksportem `=>`.",
+        broken: "% PATH = /opt/bin:/usr/bin",
+        fixed:  "=> PATH = /opt/bin:/usr/bin",
+    },
+    Explanation {
+        code: "HL0013", title: "narzędzie bez deklaracji `//`",
+        text: "Skrypt używa zewnętrznego narzędzia, którego nie deklaruje. Deklaracja `// narzędzie` \
+               sprawia, że hl zainstaluje brakujący pakiet przed użyciem.",
+        broken: "> curl -fsSL https://example.com",
+        fixed:  "// curl\n> curl -fsSL https://example.com",
+    },
+    Explanation {
+        code: "HL0014", title: "białe znaki w linii metadanych",
+        text: "Linie `///` (Author:, Version:, Requires:, …) są czytane przez hl search, hl inspect \
+               i manifest uprawnień. Spacje na końcu trafiają do wartości — `hl check --fix` je usuwa.",
+        broken: "/// Version: 1.0.0   ",
+        fixed:  "/// Version: 1.0.0",
+    },
+    Explanation {
+        code: "HL0015", title: "kolizja nazw funkcji z biblioteki",
+        text: "Dwie biblioteki albo biblioteka i skrypt definiują funkcję o tej samej nazwie — \
+               wygrywa definicja wykonana później. Zaimportuj tylko potrzebne funkcje \
+               (`# <bit/nazwa>::funkcja`) albo wywołuj funkcję z przestrzenią nazw (`-- biblioteka::funkcja`).",
+        broken: "# <bit/logging>\n# <bit/archive>\n-- rotate",
+        fixed:  "# <bit/logging>::rotate\n# <bit/archive>::{pack, unpack}\n-- rotate",
+    },
+    Explanation {
+        code: "HL0016", title: "brak wybranej funkcji w bibliotece",
+        text: "Import `# <biblioteka>::funkcja` wskazuje funkcję, której biblioteka nie definiuje. \
+               Zwykle to literówka albo funkcja usunięta w nowszej wersji biblioteki.",
+        broken: "# <bit/logging>::rotat",
+        fixed:  "# <bit/logging>::rotate",
+    },
+    Explanation {
+        code: "HL0017", title: "`return` poza funkcją",
+        text: "`return` kończy funkcję i przekazuje wartość do `-- nazwa ... -> @zmienna`. \
+               Na najwyższym poziomie skryptu nie ma do czego wrócić — skrypt kończy się `> exit <kod>`.",
+        broken: "> make build\nreturn ok",
+        fixed:  "> make build\n> exit 0",
+    },
+    Explanation {
+        code: "HL0018", title: "wartość funkcji bez `return`",
+        text: "Wywołanie `-- nazwa ... -> @zmienna` zapisuje wartość z `return`, ale funkcja \
+               nigdy go nie wykonuje, więc zmienna zawsze będzie pusta.",
+        broken: ": host def\n    ~> srv-@arg0\ndone\n-- host 1 -> @h",
+        fixed:  ": host def\n    return srv-@arg0\ndone\n-- host 1 -> @h",
+    },
+    Explanation {
+        code: "HL0019", title: "nieprawidłowe `~retry`",
+        text: "`~retry(próby, opóźnienie[, backoff])` wymaga dodatniej liczby prób i opóźnienia \
+               z jednostką ms, s, m lub h (sama liczba to sekundy). Jedyną opcją jest `backoff`, \
+               który podwaja opóźnienie po każdej nieudanej próbie.",
+        broken: "~retry(3, soon) > apt-get update",
+        fixed:  "~retry(3, 5s) > apt-get update",
+    },
+    Explanation {
+        code: "HL0020", title: "`~retry` bez komendy",
+        text: "`~retry` ponawia następny węzeł, dopóki kończy się niezerowym kodem wyjścia. \
+               Print, zmienne i eksporty nie mają kodu wyjścia, więc ponowienie nic nie daje.",
+        broken: "~retry(3, 5s) ~> pobieram",
+        fixed:  "~> pobieram\n~retry(3, 5s) > curl -fsSLO https://example.com/a.iso",
+    },
+    Explanation {
+        code: "HL0021", title: "import z URL bez przypięcia",
+        text: "Biblioteka importowana z adresu URL może zmienić się po stronie serwera. \
+               `#sha256=<hash>` przypina treść: hl sprawdza hash przy każdym imporcie i przerywa \
+               skrypt (exit 126), gdy się nie zgadza. `hl lib pin plik.hl` dopisuje brakujące hashe. \
+               Import przez `http://` bez hasha jest błędem — hl run go odrzuca.",
+        broken: "# <https://example.com/hl/net-utils.hl>",
+        fixed:  "# <https://example.com/hl/net-utils.hl#sha256=9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08>",
+    },
+    Explanation {
+        code: "HL0022", title: "wymagania środowiska skryptu",
+        text: "`/// MinHl:` podaje minimalną wersję hl, `/// RequiresOS:` listę systemów (ID lub ID_LIKE \
+               z /etc/os-release). hl run sprawdza je przed startem i kończy się kodem 4, gdy nie są \
+               spełnione. hl check ostrzega o niezgodności z bieżącą maszyną i zgłasza błędną wersję.",
+        broken: "/// MinHl: jeden-dwa",
+        fixed:  "/// MinHl: 1.2\n/// RequiresOS: debian, hackeros",
+    },
+    Explanation {
+        code: "HL0023", title: "przestarzała składnia",
+        text: "Konstrukcja nadal działa, ale zniknie w kolejnej głównej wersji hl. Ostrzeżenie podaje \
+               wersję, od której jest przestarzała, i wersję usunięcia. Importy bez `<...>` oraz \
+               przestrzenie `virus/` i `community/` przepisuje `hl check --migrate` (kopia w .bak).",
+        broken: "# std/net <- ports\n# <virus/hashlib>",
+        fixed:  "# <std/net> | <ports>\n# <bit/hashlib>",
+    },
+    Explanation {
+        code: "HL0024", title: "sekcja ?feature z niezadeklarowaną cechą",
+        text: "Sekcja `?feature(...)` ... `done` zostaje tylko dla cech włączonych przez \
+               `hl compile --features` / `hl run --features`. Nazwy muszą być wymienione w nagłówku \
+               `/// Features:` — literówka w nazwie wycięłaby sekcję po cichu.",
+        broken: "/// Features: gpu\n?feature(cuda)\n  > nvidia-smi\ndone",
+        fixed:  "/// Features: gpu\n?feature(gpu)\n  > nvidia-smi\ndone",
+    },
+];
+
+pub fn explanation(code: &str) -> Option<&'static Explanation> {
+    let code = code.trim().to_ascii_uppercase();
+    let code = if code.starts_with("HL") { code } else { format!("HL{:0>4}", code) };
+    EXPLANATIONS.iter().find(|e| e.code == code)
+}
+
+fn print_example(label: colored::ColoredString, src: &str) {
+    println!("  {}", label);
+    for line in src.lines() { println!("    {}", line.bright_white()); }
+    println!();
+}
+
+/// hl explain [KOD] — bez kodu lista wszystkich kodów
+pub fn cmd_explain(code: Option<&str>) -> Result<()> {
+    let Some(code) = code else {
+        println!("{}", "hl explain:".bright_magenta().bold());
+        for e in EXPLANATIONS { println!("  {}  {}", e.code.bright_cyan(), e.title); }
+        return Ok(());
+    };
+    let Some(e) = explanation(code) else {
+        bail!("Nieznany kod diagnostyki '{}' — lista: hl explain", code);
+    };
+    println!("{} {}", e.code.bright_cyan().bold(), e.title.bold());
+    println!();
+    println!("  {}", e.text);
+    println!();
+    print_example("Błędnie:".red().bold(), e.broken);
+    print_example("Poprawnie:".green().bold(), e.fixed);
+    Ok(())
+}
@@ -0,0 +1,202 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+use std::process::Command;
+use crate::deploy::hl_binary;
+use crate::fetch::scripts_cache_dir;
+use crate::graph::resolved_sources;
+use crate::libs::{bare_bit_import, find_bit_lib, github_libs_dir, lib_search_path, parse_import_spec, ImportSource, MAIN_LIBS_DIR};
+use crate::provenance::import_specs;
+use crate::tmp::run_temp_dir;
+
+// ── hl export docker ──────────────────────────────────────────────────────────
+//
+// Buduje kontekst obrazu kontenera dla skryptu HL:
+//   hl export docker skrypt.hl --base <obraz-hackeros> [--out dir] [--build tag]
+//
+// W projekcie z [bins] w bit.hk (hl export docker --base <obraz> bez skryptu)
+// każdy program dostaje własny kontekst <out>/<nazwa>/ i tag <tag>-<nazwa>.
+//
+// Kontekst (domyślnie ./hl-docker/):
+//   Dockerfile
+//   hl               ← binarka bieżącego hl
+//   main-libs/       ← biblioteki main (jeśli zainstalowane)
+//   app/             ← skrypt i jego importy << / <* (ścieżki względem skryptu)
+//   app/libs/<nazwa>/ ← biblioteki bit spoza katalogu skryptu
+//   github-libs/     ← biblioteki github: → /root/.hl/libs/github/ (hl w obrazie działa jako root)
+//
+// Do kontekstu trafia tylko to, co wczyta skrypt (graph::resolved_sources) —
+// nie cały katalog. Biblioteki z URL obraz pobiera przy uruchomieniu (#sha256=
+// nadal obowiązuje). Import spoza katalogu skryptu przerywa eksport.
+// Przy błędzie katalog roboczy jest usuwany.
+//
+// HL działa wyłącznie na HackerOS, dlatego obraz bazowy (--base) musi być
+// obrazem HackerOS — nie zgadujemy go. --build uruchamia docker lub podman.
+
+#[derive(Debug, Clone)]
+pub struct DockerExportOptions {
+    pub script: PathBuf,
+    pub base:   String,
+    pub out:    PathBuf,
+    pub build:  Option<String>,
+}
+
+pub fn render_dockerfile(base: &str, script_name: &str, with_libs: bool, with_github: bool) -> String {
+    let mut out = String::new();
+    out.push_str("# Wygenerowane przez: hl export docker\n");
+    out.push_str(&format!("FROM {}\n\n", base));
+    out.push_str("COPY hl /usr/bin/hl\n");
+    if with_libs {
+        out.push_str(&format!("COPY main-libs/ {}/\n", MAIN_LIBS_DIR));
+    }
+    if with_github {
+        out.push_str("COPY github-libs/ /root/.hl/libs/github/\n");
+    }
+    out.push_str("COPY app/ /app/\n");
+    out.push_str("WORKDIR /app\n\n");
+    out.push_str(&format!("ENTRYPOINT [\"/usr/bin/hl\", \"run\", \"/app/{}\", \"--\"]\n", script_name));
+    out
+}
+
+/// Kopia katalogu bez `skip` (samego kontekstu) i metadanych VCS
+fn copy_dir(src: &Path, dst: &Path, skip: &Path) -> Result<()> {
+    std::fs::create_dir_all(dst)?;
+    for entry in std::fs::read_dir(src)?.flatten() {
+        let path = entry.path();
+        let name = entry.file_name();
+        if name == ".git" || path == skip { continue; }
+        let target = dst.join(&name);
+        let ft = entry.file_type()?;
+        if ft.is_dir() {
+            copy_dir(&path, &target, skip)?;
+        } else if ft.is_file() {
+            std::fs::copy(&path, &target)?;
+        }
+    }
+    Ok(())
+}
+
+/// Tag obrazu programu z [bins]: app:1.0 → app-backup:1.0
+pub fn bin_tag(tag: &str, bin: &str) -> String {
+    let repo_end = tag.rfind('/').map(|i| i + 1).unwrap_or(0);
+    match tag[repo_end..].find(':') {
+        Some(i) => format!("{}-{}{}", &tag[..repo_end + i], bin, &tag[repo_end + i..]),
+        None    => format!("{}-{}", tag, bin),
+    }
+}
+
+/// Kopiuj plik, tworząc katalogi nadrzędne
+fn copy_file(src: &Path, dst: &Path) -> Result<()> {
+    if let Some(parent) = dst.parent() { std::fs::create_dir_all(parent)?; }
+    std::fs::copy(src, dst).with_context(|| format!("Nie można skopiować {}", src.display()))?;
+    Ok(())
+}
+
+/// Biblioteki bit / github: z katalogów skryptu → app/…, reszta → app/libs/<nazwa>, github-libs/<katalog>
+fn lib_dirs(files: &[PathBuf], script_dir: &Path, staging: &Path) -> Result<Vec<(PathBuf, PathBuf)>> {
+    let mut dirs: Vec<(PathBuf, PathBuf)> = Vec::new();
+    for file in files {
+        let Ok(source) = std::fs::read_to_string(file) else { continue };
+        for spec in import_specs(&source) {
+            let spec = spec.trim_start_matches('<').trim_end_matches('>');
+            let dir = match parse_import_spec(spec).or_else(|| bare_bit_import(spec)) {
+                Some(ImportSource::Bit { name, .. }) => {
+                    let Some((dir, _)) = find_bit_lib(&lib_search_path(&name, script_dir), &name) else { continue };
+                    let dir = std::fs::canonicalize(&dir)?;
+                    let target = match dir.strip_prefix(script_dir) {
+                        Ok(rel) => staging.join("app").join(rel),
+                        Err(_)  => staging.join("app").join("libs").join(&name),
+                    };
+                    (dir, target)
+                }
+                Some(ImportSource::GitHub { path, .. }) => {
+                    let name = path.replace('/', "__");
+                    let dir  = github_libs_dir().join(&name);
+                    if !dir.is_dir() { continue; }
+                    (std::fs::canonicalize(&dir)?, staging.join("github-libs").join(name))
+                }
+                _ => continue,
+            };
+            if !dirs.iter().any(|(d, _)| *d == dir.0) { dirs.push(dir); }
+        }
+    }
+    Ok(dirs)
+}
+
+/// Wypełnij kontekst w `staging`; zwraca (main-libs, github-libs)
+fn stage(staging: &Path, opts: &DockerExportOptions, script: &Path, script_dir: &Path, out: &Path) -> Result<(bool, bool)> {
+    let script_name = script.file_name().and_then(|n| n.to_str()).unwrap_or("main.hl");
+    let files = resolved_sources(script)?.iter()
+        .map(|f| std::fs::canonicalize(f).with_context(|| format!("Brak pliku {}", f.display())))
+        .collect::<Result<Vec<_>>>()?;
+    let libs = lib_dirs(&files, script_dir, staging)?;
+    // Pliki są kanoniczne — katalogi też (XDG zostawia symlinki)
+    let canonical = |p: PathBuf| std::fs::canonicalize(&p).unwrap_or(p);
+    let main_libs = Path::new(MAIN_LIBS_DIR);
+    let skipped = [canonical(main_libs.to_path_buf()), canonical(scripts_cache_dir())];
+
+    for file in &files {
+        if libs.iter().any(|(d, _)| file.starts_with(d)) || skipped.iter().any(|d| file.starts_with(d)) { continue; }
+        match file.strip_prefix(script_dir) {
+            Ok(rel) => copy_file(file, &staging.join("app").join(rel))?,
+            Err(_)  => bail!("{} leży poza katalogiem skryptu {} — nie trafi do /app", file.display(), script_dir.display()),
+        }
+    }
+    for (dir, target) in &libs { copy_dir(dir, target, out)?; }
+    let with_github = libs.iter().any(|(_, t)| t.starts_with(staging.join("github-libs")));
+
+    std::fs::copy(hl_binary(), staging.join("hl")).context("Nie można skopiować binarki hl")?;
+
+    let with_libs = main_libs.exists();
+    if with_libs { copy_dir(main_libs, &staging.join("main-libs"), out)?; }
+
+    std::fs::write(staging.join("Dockerfile"), render_dockerfile(&opts.base, script_name, with_libs, with_github))?;
+    Ok((with_libs, with_github))
+}
+
+fn container_engine() -> Option<&'static str> {
+    ["docker", "podman"].into_iter().find(|e| which::which(e).is_ok())
+}
+
+pub fn cmd_export_docker(opts: &DockerExportOptions) -> Result<()> {
+    if !opts.script.exists() { bail!("Plik nie istnieje: {}", opts.script.display()); }
+    let script = std::fs::canonicalize(&opts.script)?;
+    let script_dir  = script.parent().context("skrypt bez katalogu nadrzędnego")?;
+
+    let out = if opts.out.is_absolute() { opts.out.clone() } else { std::env::current_dir()?.join(&opts.out) };
+    if out.exists() { bail!("Katalog {} już istnieje", out.display()); }
+
+    println!("{} {}", "hl export docker:".bright_magenta().bold(), script.display().to_string().bright_white());
+
+    let staging = run_temp_dir("export")?;
+    let staged = stage(&staging, opts, &script, script_dir, &out).and_then(|flags| {
+        if let Some(parent) = out.parent() { std::fs::create_dir_all(parent)?; }
+        if std::fs::rename(&staging, &out).is_err() {
+            // Inny system plików niż katalog tymczasowy — kopiuj (niepełna kopia znika)
+            copy_dir(&staging, &out, &out).inspect_err(|_| { std::fs::remove_dir_all(&out).ok(); })?;
+        }
+        Ok(flags)
+    });
+    std::fs::remove_dir_all(&staging).ok();
+    let (with_libs, with_github) = staged?;
+    println!("  {} kontekst: {}", "✓".green(), out.display().to_string().bright_white());
+    if with_github { println!("  {} biblioteki github: → /root/.hl/libs/github/", "·".bright_black()); }
+    if !with_libs {
+        println!("  {} brak {} — obraz bez bibliotek main (builtin fallback)",
+                 "!".yellow(), MAIN_LIBS_DIR.bright_black());
+    }
+
+    if let Some(ref tag) = opts.build {
+        let engine = container_engine().context("Nie znaleziono docker ani podman")?;
+        println!("  {} {} build -t {}", "→".bright_cyan(), engine, tag.bright_cyan());
+        let status = Command::new(engine)
+            .args(["build", "-t", tag, out.to_str().unwrap_or(".")])
+            .status()?;
+        if !status.success() { bail!("{} build zakończony błędem", engine); }
+        println!("  {} obraz: {}", "✓".green(), tag.bright_cyan().bold());
+        println!("  Uruchom: {}", format!("{} run --rm {}", engine, tag).bright_cyan());
+    } else {
+        println!("  Zbuduj:  {}", format!("docker build -t <tag> {}", out.display()).bright_cyan());
+    }
+    Ok(())
+}
@@ -0,0 +1,207 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use std::io::{BufRead, IsTerminal, Write};
+use std::path::{Path, PathBuf};
+use crate::exit::{classified, ErrorClass};
+use crate::net::git_command;
+use crate::security::{parse_manifest, required_capabilities};
+
+// ── hl run <URL> / github:org/repo@ref#ścieżka ────────────────────────────────
+//
+//   hl run https://example.com/setup.hl
+//   hl run github:org/repo@v1.2#scripts/setup.hl   tag, gałąź albo pełny SHA
+//   hl run github:org/repo            (plik wejściowy jak hl run . — z gałęzi domyślnej)
+//
+// Zamiast `curl | bash`: skrypt trafia do cache (kategoria `scripts` w hl cache),
+// hl pokazuje jego treść, manifest `/// Requires:` i wykryte uprawnienia, po czym
+// pyta o potwierdzenie (`--yes` pomija pytanie). Podpis i manifest są sprawdzane
+// tak samo jak dla plików lokalnych. Zwykły http:// jest odrzucany — skrypt
+// podmieniony po drodze zostałby uruchomiony (z --yes nawet bez podglądu).
+
+#[derive(Debug, Clone, PartialEq)]
+pub enum RemoteSpec {
+    Url(String),
+    Github { repo: String, git_ref: Option<String>, path: Option<String> },
+}
+
+pub fn is_remote_spec(s: &str) -> bool {
+    s.starts_with("https://") || s.starts_with("http://") || s.starts_with("github:")
+}
+
+pub fn parse_remote_spec(s: &str) -> Result<RemoteSpec> {
+    if s.starts_with("https://") {
+        return Ok(RemoteSpec::Url(s.to_string()));
+    }
+    if s.starts_with("http://") {
+        return Err(classified(ErrorClass::Denied,
+            format!("{}: skrypty zdalne tylko przez https:// — http:// można podmienić po drodze", s)));
+    }
+    let Some(rest) = s.strip_prefix("github:") else { bail!("'{}' nie jest adresem skryptu", s) };
+    let (rest, path) = match rest.split_once('#') {
+        Some((r, p)) => (r, Some(p.trim_start_matches('/').to_string())),
+        None         => (rest, None),
+    };
+    let (repo, git_ref) = match rest.split_once('@') {
+        Some((r, g)) => (r, Some(g.to_string())),
+        None         => (rest, None),
+    };
+    let valid = |x: &str| !x.is_empty() && x.chars().all(|c| c.is_ascii_alphanumeric() || "-_.".contains(c));
+    match repo.split_once('/') {
+        Some((org, name)) if valid(org) && valid(name) => {}
+        _ => bail!("Nieprawidłowe repozytorium '{}' — oczekiwano github:org/repo[@ref][#ścieżka]", repo),
+    }
+    if git_ref.as_deref().is_some_and(|g| g.is_empty() || g.starts_with('-')) {
+        bail!("Nieprawidłowa referencja git w '{}'", s);
+    }
+    if path.as_deref().is_some_and(|p| p.split('/').any(|c| c == "..")) {
+        bail!("Ścieżka w '{}' nie może wychodzić poza repozytorium", s);
+    }
+    Ok(RemoteSpec::Github { repo: repo.to_string(), git_ref, path })
+}
+
+pub fn scripts_cache_dir() -> PathBuf {
+    crate::paths::data_dir("scripts")
+}
+
+/// FNV-1a — stabilna nazwa katalogu w cache dla adresu URL
+pub(crate) fn url_key(url: &str) -> String {
+    let hash = url.bytes().fold(0xcbf29ce484222325u64, |h, b| (h ^ b as u64).wrapping_mul(0x100000001b3));
+    format!("{:016x}", hash)
+}
+
+fn require_tool(tool: &str) -> Result<()> {
+    if which::which(tool).is_err() {
+        return Err(classified(ErrorClass::Toolchain, format!("{} nie jest zainstalowany", tool)));
+    }
+    Ok(())
+}
+
+/// Pobierz skrypt do cache; zwraca plik (URL, github z #ścieżką) lub katalog repozytorium
+pub fn fetch_remote(spec: &RemoteSpec) -> Result<PathBuf> {
+    match spec {
+        RemoteSpec::Url(url) => {
+            require_tool("curl")?;
+            let _lock = crate::lock::lock_state("cache")?;
+            let name = url.rsplit('/').next().filter(|n| n.ends_with(".hl")).unwrap_or("script.hl");
+            let dir  = scripts_cache_dir().join(url_key(url));
+            std::fs::create_dir_all(&dir)?;
+            let target = dir.join(name);
+            // Pobranie do pliku tymczasowego — przerwany curl nie nadpisuje poprzedniej wersji
+            let part = dir.join(".download");
+            if !crate::net::curl_download(url, &part)? {
+                let _ = std::fs::remove_file(&part);
+                return Err(classified(ErrorClass::Dependency, format!("Nie można pobrać {}", url)));
+            }
+            std::fs::rename(&part, &target)?;
+            Ok(target)
+        }
+        RemoteSpec::Github { repo, git_ref, path } => {
+            require_tool("git")?;
+            let _lock = crate::lock::lock_state("cache")?;
+            let dir = scripts_cache_dir()
+                .join(format!("github__{}@{}", repo.replace('/', "__"), git_ref.as_deref().unwrap_or("HEAD")));
+            let url = format!("https://github.com/{}.git", repo);
+            let ok = if dir.join(".git").exists() {
+                // Tag i pełny SHA są niezmienne — z cache; gałąź (także domyślna) — odśwież
+                let pinned = git_ref.as_deref().is_some_and(|r| is_full_sha(r) || is_local_tag(&dir, r));
+                pinned || git_command(&url).arg("-C").arg(&dir).args(["pull", "--ff-only", "-q"]).status()?.success()
+            } else {
+                let _ = std::fs::remove_dir_all(&dir);
+                std::fs::create_dir_all(&dir)?;
+                match git_ref.as_deref() {
+                    Some(sha) if is_full_sha(sha) => clone_commit(&url, &dir, sha)?,
+                    _ => {
+                        let mut cmd = git_command(&url);
+                        cmd.args(["clone", "-q", "--depth=1"]);
+                        if let Some(r) = git_ref { cmd.args(["--branch", r]); }
+                        cmd.arg(&url).arg(&dir);
+                        cmd.status()?.success()
+                    }
+                }
+            };
+            if !ok {
+                let _ = std::fs::remove_dir_all(&dir);
+                return Err(classified(ErrorClass::Dependency, format!("Nie można pobrać github:{}", repo)));
+            }
+            Ok(match path { Some(p) => dir.join(p), None => dir })
+        }
+    }
+}
+
+/// 40 znaków hex — commit, nie nazwa gałęzi ani tagu
+fn is_full_sha(r: &str) -> bool {
+    r.len() == 40 && r.chars().all(|c| c.is_ascii_hexdigit())
+}
+
+/// `clone --branch` zostawia tag jako refs/tags/<ref>; gałąź nie ma takiego refa
+fn is_local_tag(dir: &Path, r: &str) -> bool {
+    std::process::Command::new("git").arg("-C").arg(dir)
+        .args(["show-ref", "--verify", "--quiet", &format!("refs/tags/{}", r)])
+        .status().is_ok_and(|s| s.success())
+}
+
+/// `git clone --branch` nie przyjmuje SHA — init, fetch tego commitu, checkout
+fn clone_commit(url: &str, dir: &Path, sha: &str) -> Result<bool> {
+    let git = |args: &[&str]| std::process::Command::new("git").arg("-C").arg(dir).args(args).status();
+    Ok(git(&["init", "-q"])?.success()
+        && git(&["remote", "add", "origin", url])?.success()
+        && git_command(url).arg("-C").arg(dir).args(["fetch", "-q", "--depth=1", "origin", sha]).status()?.success()
+        && git(&["checkout", "-q", "--detach", "FETCH_HEAD"])?.success())
+}
+
+/// Pokaż skrypt i jego uprawnienia; Ok(true) — użytkownik potwierdził (lub `yes`)
+pub fn review_remote(spec: &str, file: &Path, yes: bool) -> Result<bool> {
+    let source = std::fs::read_to_string(file)?;
+    eprintln!("{} {}", "hl run:".bright_magenta().bold(), spec.bright_white().bold());
+    eprintln!("  Plik:  {}", file.display().to_string().bright_black());
+    eprintln!("{}", "── treść ─────────────────────────────────────────────".bright_black());
+    let width = source.lines().count().to_string().len();
+    for (i, line) in source.lines().enumerate() {
+        eprintln!("{} {}", format!("{:>w$} │", i + 1, w = width).bright_black(), line);
+    }
+    eprintln!("{}", "──────────────────────────────────────────────────────".bright_black());
+
+    let declared = match parse_manifest(&source) {
+        Ok(Some(m)) if m.caps.is_empty() => "none".to_string(),
+        Ok(Some(m)) => m.caps.iter().map(|c| c.to_string()).collect::<Vec<_>>().join(", "),
+        Ok(None)    => "(brak manifestu /// Requires:)".to_string(),
+        Err(e)      => format!("błędny manifest: {}", e),
+    };
+    // Importy też zostaną wykonane — wypisz je i policz ich uprawnienia razem ze skryptem
+    let imports: Vec<PathBuf> = match crate::graph::resolved_sources(file) {
+        Ok(files) => files.into_iter().filter(|f| f != file).collect(),
+        Err(e) => {
+            eprintln!("  {} nie można ustalić importów: {}", "!".yellow(), e);
+            Vec::new()
+        }
+    };
+    let mut caps = required_capabilities(&source);
+    for import in &imports {
+        let Ok(src) = std::fs::read_to_string(import) else { continue };
+        for c in required_capabilities(&src) {
+            if !caps.contains(&c) { caps.push(c); }
+        }
+    }
+    let used: Vec<String> = caps.iter().map(|c| c.to_string()).collect();
+    let used = if used.is_empty() { "—".to_string() } else { used.join(", ") };
+    eprintln!("  Manifest:  {}", declared.bright_cyan());
+    eprintln!("  Używa:     {}", used.yellow());
+    if !imports.is_empty() {
+        eprintln!("  Importy ({}) — wykonane razem ze skryptem:", imports.len());
+        let base = file.parent().unwrap_or(Path::new("."));
+        for import in &imports {
+            let shown = import.strip_prefix(base).unwrap_or(import);
+            eprintln!("    {}", shown.display().to_string().bright_black());
+        }
+    }
+
+    if yes { return Ok(true); }
+    if !std::io::stdin().is_terminal() {
+        return Err(classified(ErrorClass::Denied, "Skrypt zdalny w trybie nieinteraktywnym — potwierdź flagą --yes"));
+    }
+    eprint!("  Uruchomić? [t/N] ");
+    std::io::stderr().flush().ok();
+    let mut answer = String::new();
+    std::io::stdin().lock().read_line(&mut answer)?;
+    Ok(matches!(answer.trim().to_lowercase().as_str(), "t" | "tak" | "y" | "yes"))
+}
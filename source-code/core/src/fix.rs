@@ -0,0 +1,85 @@
+use anyhow::Result;
+use std::path::{Path, PathBuf};
+use crate::diagnostics::{lint_gen, lint_source, parse_error_to_diag, Diag, Fix};
+use hl_parser::parse_source;
+
+// ── hl check --fix ────────────────────────────────────────────────────────────
+//
+// Diagnostyki z poprawką (Diag::fix) są stosowane automatycznie:
+//   HL0002  niezamknięty string          → dopisz `"` na końcu linii
+//   HL0007  lista eksportu bez `]`       → wstaw `]` po ostatnim elemencie
+//   HL0010  `> echo tekst`               → `~> tekst`
+//   HL0011  `> sudo cmd`                 → `^> cmd`
+//   HL0013  narzędzie bez deklaracji     → `// narzędzie` za nagłówkiem
+//   HL0014  białe znaki w linii `///`    → obcięte
+//   HL0023  przestarzała składnia        → nowa postać (także samo `--migrate`)
+// Oryginał trafia do <plik>.bak.
+
+/// Zastosuj poprawki do źródła; zwraca nowe źródło i diagnostyki, których poprawki użyto
+pub fn apply_fixes<'a>(source: &str, diags: &'a [Diag]) -> (String, Vec<&'a Diag>) {
+    let lines: Vec<&str> = source.lines().collect();
+    let mut replaced: Vec<Option<String>> = vec![None; lines.len()];
+    let mut appended: Vec<String> = vec![String::new(); lines.len()];
+    let mut inserted: Vec<Vec<String>> = vec![Vec::new(); lines.len() + 1];
+    let mut applied = Vec::new();
+
+    for d in diags {
+        let Some(fix) = &d.fix else { continue };
+        let ok = match fix {
+            Fix::ReplaceLine(n, text) if (1..=lines.len()).contains(n) && replaced[n - 1].is_none() => {
+                replaced[n - 1] = Some(text.clone());
+                true
+            }
+            Fix::AppendToLine(n, text) if (1..=lines.len()).contains(n) && !appended[n - 1].ends_with(text.as_str()) => {
+                appended[n - 1].push_str(text);
+                true
+            }
+            Fix::InsertLine(n, text) if (1..=lines.len() + 1).contains(n) && !inserted[n - 1].contains(text) => {
+                inserted[n - 1].push(text.clone());
+                true
+            }
+            _ => false,
+        };
+        if ok { applied.push(d); }
+    }
+
+    let mut out = String::with_capacity(source.len() + 64);
+    for (i, line) in lines.iter().enumerate() {
+        for ins in &inserted[i] { out.push_str(ins); out.push('\n'); }
+        out.push_str(replaced[i].as_deref().unwrap_or(line));
+        out.push_str(&appended[i]);
+        out.push('\n');
+    }
+    for ins in &inserted[lines.len()] { out.push_str(ins); out.push('\n'); }
+    if !source.ends_with('\n') && !source.is_empty() { out.pop(); }
+    (out, applied)
+}
+
+/// Diagnostyki lintera i parsera — komplet, z którego bierze poprawki `--fix`
+pub fn fixable_diags(source: &str) -> Vec<Diag> {
+    let mut diags = lint_source(source);
+    diags.extend(lint_gen(source));
+    if let Err(e) = parse_source(source) { diags.push(parse_error_to_diag(&e)); }
+    diags
+}
+
+/// Popraw plik na miejscu (kopia w <plik>.bak); zwraca zastosowane diagnostyki i ścieżkę kopii
+pub fn fix_file(file: &Path) -> Result<(Vec<Diag>, Option<PathBuf>)> {
+    rewrite_file(file, fixable_diags)
+}
+
+/// Tylko migracje przestarzałej składni (HL0023) — `hl check --migrate`
+pub fn migrate_file(file: &Path) -> Result<(Vec<Diag>, Option<PathBuf>)> {
+    rewrite_file(file, crate::migrate::lint_deprecations)
+}
+
+fn rewrite_file(file: &Path, diags_of: fn(&str) -> Vec<Diag>) -> Result<(Vec<Diag>, Option<PathBuf>)> {
+    let source = std::fs::read_to_string(file)?;
+    let diags = diags_of(&source);
+    let (fixed, applied) = apply_fixes(&source, &diags);
+    if applied.is_empty() { return Ok((Vec::new(), None)); }
+    let backup = PathBuf::from(format!("{}.bak", file.display()));
+    std::fs::copy(file, &backup)?;
+    std::fs::write(file, fixed)?;
+    Ok((applied.into_iter().cloned().collect(), Some(backup)))
+}
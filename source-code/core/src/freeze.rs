@@ -0,0 +1,408 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use std::collections::HashMap;
+use std::path::{Path, PathBuf};
+use std::time::{SystemTime, UNIX_EPOCH};
+use hl_parser::lexer::{Lexer, PipeCmdMode, Token};
+use crate::exit::{classified, ErrorClass};
+use crate::libs::{lib_source_file_in, parse_import_spec, source_dir, sha256_file, ImportSource};
+
+// ── hl freeze ─────────────────────────────────────────────────────────────────
+//
+//   hl freeze deploy.hl                       → deploy.frozen.hl
+//   hl freeze deploy.hl -o incydent-42.hl --var ENV=prod
+//
+// Jeden samowystarczalny plik .hl do archiwum — dokładnie to, co się wykonało
+// (np. przy incydencie). Importy są rozwiązywane jak w executorze, więc freeze
+// uruchamia się w katalogu, w którym uruchomiono skrypt:
+//
+//   << plik       treść wstawiona w miejsce importu (rekurencyjnie, każde wystąpienie),
+//                 między komentarzami `;; ──` z sha256 pliku
+//   <* katalog    imports.hl katalogu z jego importami; _module_dir i _module_name jako `%`
+//   # biblioteka  import zostaje, wersja i sha256 trafiają do nagłówka jako `/// Lib:`
+//
+// Nagłówek `///` skryptu zostaje; dochodzi `/// Frozen:` (czas, kto, wersja hl,
+// sha256 źródła). Zmienna jest podstawiana wartością tylko wtedy, gdy przez
+// cały przebieg ma jedną: jedno `% nazwa = literał` na poziomie skryptu albo
+// nadpisanie z .env / --var, żadnych innych zapisów (|>, ->, for, export, też
+// w bibliotekach) i wartość bez spacji i znaków powłoki. Podstawienie tylko w
+// liniach interpolowanych (~>, >>, ^>>, ->>, warunki, argumenty wywołań), za
+// deklaracją i poza funkcjami i blokami extern — `>` zostaje bez zmian.
+
+/// Wartość bezpieczna do wstawienia w każdą linię interpolowaną
+fn safe_value(value: &str) -> bool {
+    !value.is_empty() && value.chars().all(|c| c.is_ascii_alphanumeric() || "-_./:+,~".contains(c))
+}
+
+fn unquote(text: &str) -> &str {
+    let text = text.trim();
+    text.strip_prefix('"').and_then(|t| t.strip_suffix('"')).unwrap_or(text)
+}
+
+/// Tokeny pliku pogrupowane po liniach źródła (bez Newline / Eof)
+fn line_tokens(source: &str) -> Result<Vec<(usize, Vec<Token>)>> {
+    let pre = hl_parser::preprocess(source);
+    let mut out: Vec<(usize, Vec<Token>)> = Vec::new();
+    for (no, tok) in Lexer::new(&pre.source).tokenize_with_lines()? {
+        if matches!(tok, Token::Newline | Token::Eof) { continue; }
+        match out.last_mut() {
+            Some((last, toks)) if *last == no => toks.push(tok),
+            _ => out.push((no, vec![tok])),
+        }
+    }
+    Ok(out)
+}
+
+/// Linia otwiera blok zamykany `done`
+fn opens_block(tok: &Token) -> bool {
+    matches!(tok, Token::FuncDef(_) | Token::ArenaFuncDef { .. } | Token::IfOk | Token::IfErr | Token::IfExpr(_)
+        | Token::Feature(_) | Token::WhileStart(_) | Token::ForIn { .. } | Token::SwitchStart(_)
+        | Token::GoroutineStart { .. } | Token::ExternStart { .. })
+}
+
+/// Blok, którego treść nie jest wykonywana w miejscu definicji (albo nie jest hl)
+fn deferred_block(tok: &Token) -> bool {
+    matches!(tok, Token::FuncDef(_) | Token::ArenaFuncDef { .. } | Token::ExternStart { .. })
+}
+
+fn is_prefix(tok: &Token) -> bool {
+    matches!(tok, Token::RepeatN(_) | Token::Retry(_) | Token::Check(_) | Token::Once(_) | Token::Undo(_))
+}
+
+/// Nazwy `@zmiennych` w tekście
+fn var_names(text: &str) -> Vec<String> {
+    let b = text.as_bytes();
+    let mut out = Vec::new();
+    let mut i = 0;
+    while i < b.len() {
+        if b[i] == b'@' && b.get(i + 1).is_some_and(|c| c.is_ascii_alphabetic() || *c == b'_') {
+            let start = i + 1;
+            i = start;
+            while i < b.len() && (b[i].is_ascii_alphanumeric() || b[i] == b'_') { i += 1; }
+            out.push(text[start..i].to_string());
+        } else {
+            i += 1;
+        }
+    }
+    out
+}
+
+/// Zapisy zmiennych w całym pliku (też w funkcjach) i deklaracje literałem poza blokami
+#[derive(Default)]
+struct Writes {
+    decls:    HashMap<String, usize>,
+    other:    HashMap<String, usize>,
+    /// nazwa → (linia deklaracji, wartość)
+    literals: HashMap<String, (usize, String)>,
+}
+
+impl Writes {
+    fn write(&mut self, var: &str) {
+        let var = var.trim().trim_start_matches('!').trim().trim_start_matches('@');
+        *self.other.entry(var.to_string()).or_insert(0) += 1;
+    }
+
+    fn scan(&mut self, lines: &[(usize, Vec<Token>)], record_literals: bool) {
+        let mut depth = 0usize;
+        for (no, toks) in lines {
+            for tok in toks {
+                match tok {
+                    Token::VarDecl { name, value, .. } => {
+                        *self.decls.entry(name.clone()).or_insert(0) += 1;
+                        let v = unquote(value);
+                        if record_literals && depth == 0 && safe_value(v) && !self.literals.contains_key(name) {
+                            self.literals.insert(name.clone(), (*no, v.to_string()));
+                        }
+                    }
+                    Token::CmdPipeToVar { var_name, .. } | Token::QuickPipeToVar { var_name, .. } => self.write(var_name),
+                    Token::Arithmetic { assign_to: Some(v), .. } => self.write(v),
+                    Token::ForIn { var, .. } => self.write(var),
+                    Token::ExportSingle { name, .. } | Token::ExportListStart(name) => self.write(name),
+                    Token::FuncCall { args, .. } => {
+                        if let Some((_, v)) = args.rsplit_once("->") { if v.trim().starts_with('@') { self.write(v); } }
+                    }
+                    Token::ChannelOp(op) => { for v in var_names(op) { self.write(&v); } }
+                    _ => {}
+                }
+                if opens_block(tok) { depth += 1; }
+                if *tok == Token::Done { depth = depth.saturating_sub(1); }
+            }
+        }
+    }
+}
+
+/// Podstaw znane `@zmienne` (reguły jak parse_string_parts: `@{…}` i `@a@b` zostają)
+fn substitute(text: &str, value_of: &dyn Fn(&str) -> Option<String>, used: &mut Vec<String>) -> String {
+    let b = text.as_bytes();
+    let ident = |c: u8| c.is_ascii_alphanumeric() || c == b'_';
+    let mut out = String::with_capacity(text.len());
+    let mut i = 0;
+    let mut copied = 0;
+    while i < b.len() {
+        if b[i] != b'@' || i + 1 >= b.len() { i += 1; continue; }
+        if b[i + 1] == b'{' {
+            i += b[i..].iter().position(|c| *c == b'}').unwrap_or(b.len() - i);
+            continue;
+        }
+        if !(b[i + 1].is_ascii_alphabetic() || b[i + 1] == b'_') { i += 1; continue; }
+        let start = i;
+        let mut end = i + 1;
+        while end < b.len() && ident(b[end]) { end += 1; }
+        let compound = b.get(end) == Some(&b'@') && b.get(end + 1).is_some_and(|c| c.is_ascii_alphabetic() || *c == b'_');
+        if compound {
+            // @arg@_i — dynamiczna referencja, pomiń całą
+            while b.get(end) == Some(&b'@') && b.get(end + 1).is_some_and(|c| c.is_ascii_alphabetic() || *c == b'_') {
+                end += 1;
+                while end < b.len() && ident(b[end]) { end += 1; }
+            }
+        } else if let Some(value) = value_of(&text[start + 1..end]) {
+            out.push_str(&text[copied..start]);
+            out.push_str(&value);
+            copied = end;
+            if !used.iter().any(|u| u == &text[start + 1..end]) { used.push(text[start + 1..end].to_string()); }
+        }
+        i = end;
+    }
+    out.push_str(&text[copied..]);
+    out
+}
+
+/// Fragment linii interpolowany przy wykonaniu: koniec zakresu (bajt) albo None
+fn interpolated_span(tok: &Token, line: &str) -> Option<usize> {
+    match tok {
+        Token::Print(_) | Token::CmdWithVars(_) | Token::CmdWithVarsSudo(_) | Token::CmdWithVarsIsolated(_)
+            | Token::HshCmd(_) | Token::IfExpr(_) | Token::WhileStart(_) => Some(line.len()),
+        Token::CmdPipeToVar { mode: PipeCmdMode::WithVars, .. } => line.rfind("|>"),
+        Token::FuncCall { .. } => Some(match line.rsplit_once("->") {
+            Some((args, v)) if v.trim().starts_with('@') => args.len(),
+            _ => line.len(),
+        }),
+        _ => None,
+    }
+}
+
+struct Included {
+    kind:   &'static str,
+    path:   String,
+    sha256: String,
+}
+
+struct Freezer {
+    stack:    Vec<PathBuf>,
+    included: Vec<Included>,
+    warnings: Vec<String>,
+}
+
+impl Freezer {
+    /// Ścieżka `<<` / `<*` tak, jak rozwiąże ją executor (katalog roboczy `cwd`)
+    fn resolve(cwd: &Path, path: &str, what: &str) -> Result<PathBuf> {
+        let path = path.trim();
+        if path.contains('@') {
+            return Err(classified(ErrorClass::Dependency,
+                format!("{} {}: ścieżka zależy od zmiennej — nie da się jej ustalić bez uruchomienia", what, path)));
+        }
+        Ok(cwd.join(path))
+    }
+
+    fn enter(&mut self, file: &Path) -> Result<()> {
+        let key = file.canonicalize().unwrap_or_else(|_| file.to_path_buf());
+        if self.stack.contains(&key) {
+            bail!("cykl importów: {} importuje sam siebie (przez {})", file.display(),
+                  self.stack.last().map(|p| p.display().to_string()).unwrap_or_default());
+        }
+        self.stack.push(key);
+        Ok(())
+    }
+
+    /// Linie `file` z wstawionymi importami; `cwd` — katalog roboczy executora przy tym pliku
+    fn inline(&mut self, file: &Path, cwd: &Path, top: bool, out: &mut Vec<String>) -> Result<()> {
+        let source = std::fs::read_to_string(file).with_context(|| format!("Nie można odczytać {}", file.display()))?;
+        let imports: HashMap<usize, Token> = line_tokens(&source)
+            .with_context(|| file.display().to_string())?
+            .into_iter()
+            .filter_map(|(no, toks)| toks.into_iter()
+                .find(|t| !matches!(t, Token::Comments(..)))
+                .filter(|t| matches!(t, Token::FileImport { .. } | Token::DirImport { .. }))
+                .map(|t| (no, t)))
+            .collect();
+
+        for (i, line) in source.lines().enumerate() {
+            if i == 0 && !top && line.starts_with("#!") { continue; }
+            match imports.get(&(i + 1)) {
+                Some(Token::FileImport { path, detail }) => {
+                    let shown = if path.contains('.') { path.trim().to_string() } else { format!("{}.hl", path.trim()) };
+                    let target = Self::resolve(cwd, &shown, "<<")?;
+                    if !target.is_file() {
+                        return Err(classified(ErrorClass::Dependency, format!("Import: plik nie istnieje: '{}'", target.display())));
+                    }
+                    let sha256 = sha256_file(&target)?;
+                    out.push(format!(";; ── << {} (sha256 {}) ──", shown, sha256));
+                    if let Some(d) = detail { out.push(format!("% _import_detail: str = {}", d)); }
+                    self.enter(&target)?;
+                    self.inline(&target, cwd, false, out)?;
+                    self.stack.pop();
+                    out.push(format!(";; ── koniec {} ──", shown));
+                    self.included.push(Included { kind: "<<", path: target.display().to_string(), sha256 });
+                }
+                Some(Token::DirImport { path }) => {
+                    let dir = Self::resolve(cwd, path, "<*")?;
+                    if !dir.is_dir() { bail!("<* import: katalog nie istnieje: '{}'", dir.display()); }
+                    let imports_file = dir.join("imports.hl");
+                    if !imports_file.is_file() { bail!("<* import: brak '{}'", imports_file.display()); }
+                    let abs = dir.canonicalize().unwrap_or_else(|_| dir.clone());
+                    let name = abs.file_name().map(|n| n.to_string_lossy().to_string()).unwrap_or_else(|| path.trim().to_string());
+                    let sha256 = sha256_file(&imports_file)?;
+                    out.push(format!(";; ── <* {} (imports.hl sha256 {}) ──", path.trim(), sha256));
+                    out.push(format!("% _module_dir: str = {}", abs.display()));
+                    out.push(format!("% _module_name: str = {}", name));
+                    self.enter(&imports_file)?;
+                    self.inline(&imports_file, &abs, false, out)?;
+                    self.stack.pop();
+                    out.push(format!(";; ── koniec {} ──", path.trim()));
+                    self.warnings.push(format!(
+                        "<* {}: oryginał wykonywał moduł w katalogu roboczym {} — względne ścieżki w komendach modułu działają teraz względem katalogu uruchomienia",
+                        path.trim(), abs.display()));
+                    self.included.push(Included { kind: "<*", path: imports_file.display().to_string(), sha256 });
+                }
+                _ => out.push(line.to_string()),
+            }
+        }
+        Ok(())
+    }
+}
+
+/// Wersja biblioteki do `/// Lib:` i plik, z którego jest ładowana
+fn lib_version(spec: &str, base: &Path) -> (String, Option<PathBuf>) {
+    let spec = spec.trim().trim_start_matches('<').trim_end_matches('>');
+    let file = lib_source_file_in(spec, base);
+    let version = match parse_import_spec(spec) {
+        Some(ImportSource::Main { version, .. }) => version.unwrap_or_else(|| format!("hl {}", crate::compat::HL_VERSION)),
+        Some(ImportSource::Bit { name, version }) => version.or_else(|| bit_commit(&name)).unwrap_or_else(|| "lokalna".into()),
+        Some(ImportSource::GitHub { version, .. }) => version.unwrap_or_else(|| "bez wersji".into()),
+        Some(ImportSource::Url { .. }) => "url".into(),
+        None => bit_commit(spec).unwrap_or_else(|| "lokalna".into()),
+    };
+    (version, file)
+}
+
+/// Commit biblioteki bit: cel dowiązania libs/<nazwa>/current
+fn bit_commit(name: &str) -> Option<String> {
+    std::fs::read_link(crate::libs::bit_current_dir(name)).ok()?
+        .file_name().map(|n| n.to_string_lossy().to_string())
+}
+
+/// Koniec bloku nagłówka (`#!`, using, `///`) — tam trafiają linie freeze
+fn header_end(lines: &[String]) -> usize {
+    let mut end = 0;
+    for (i, line) in lines.iter().enumerate().take(30) {
+        let t = line.trim();
+        if t.is_empty() || t.starts_with(";;") { continue; }
+        if t.starts_with("#!") || t.starts_with("using") || t.starts_with("///") { end = i + 1; } else { break; }
+    }
+    end
+}
+
+fn default_output(script: &Path) -> PathBuf {
+    let stem = script.file_stem().map(|s| s.to_string_lossy().to_string()).unwrap_or_else(|| "script".into());
+    script.with_file_name(format!("{}.frozen.hl", stem))
+}
+
+/// hl freeze — zapisz samowystarczalną kopię skryptu
+pub fn cmd_freeze(script: &Path, output: Option<&Path>, cli_vars: &[String]) -> Result<PathBuf> {
+    if !script.is_file() { bail!("Nie znaleziono skryptu: {}", script.display()); }
+    let mut freezer = Freezer { stack: Vec::new(), included: Vec::new(), warnings: Vec::new() };
+    let mut lines = Vec::new();
+    freezer.enter(script)?;
+    freezer.inline(script, Path::new(""), true, &mut lines)?;
+    let merged = lines.join("\n");
+
+    // Biblioteki: wersje do nagłówka, ich zapisy zmiennych do analizy
+    let mut writes = Writes::default();
+    let tokens = line_tokens(&merged)?;
+    writes.scan(&tokens, true);
+    let mut libs = Vec::new();
+    for spec in crate::provenance::import_specs(&merged) {
+        let (version, file) = lib_version(&spec, source_dir(script));
+        let sha256 = match &file {
+            Some(f) => {
+                if let Ok(lib_tokens) = std::fs::read_to_string(f).map_err(anyhow::Error::from).and_then(|s| line_tokens(&s)) {
+                    writes.scan(&lib_tokens, false);
+                }
+                Some(sha256_file(f)?)
+            }
+            None => {
+                freezer.warnings.push(format!("# {}: biblioteka nie jest zainstalowana — brak sha256", spec));
+                None
+            }
+        };
+        libs.push((spec, version, sha256));
+    }
+
+    // Zmienne o jednej wartości: nazwa → (od linii, wartość)
+    let dir = script.parent().filter(|d| !d.as_os_str().is_empty()).unwrap_or(Path::new("."));
+    let mut overrides: HashMap<String, String> = crate::dotenv::load_project_env(dir)?.into_iter().collect();
+    for arg in cli_vars {
+        let (name, value) = crate::dotenv::parse_var_arg(arg)?;
+        overrides.insert(name, value);
+    }
+    let mut known: HashMap<String, (usize, String)> = HashMap::new();
+    for (name, value) in &overrides {
+        if safe_value(value) && !writes.other.contains_key(name) { known.insert(name.clone(), (0, value.clone())); }
+    }
+    for (name, (line, value)) in &writes.literals {
+        if overrides.contains_key(name) || writes.other.contains_key(name) || writes.decls.get(name) != Some(&1) { continue; }
+        known.insert(name.clone(), (*line, value.clone()));
+    }
+
+    let mut used = Vec::new();
+    let mut blocks: Vec<bool> = Vec::new();
+    for (no, toks) in &tokens {
+        let Some(first) = toks.iter().find(|t| !matches!(t, Token::Comments(..))) else { continue };
+        if *first == Token::Done { blocks.pop(); continue; }
+        let deferred = blocks.iter().any(|d| *d);
+        if opens_block(first) { blocks.push(deferred_block(first)); }
+        if deferred || is_prefix(first) { continue; }
+        let Some(line) = lines.get(no - 1).cloned() else { continue };
+        let Some(end) = interpolated_span(first, &line) else { continue };
+        let value_of = |name: &str| known.get(name).filter(|(at, _)| at < no).map(|(_, v)| v.clone());
+        let replaced = substitute(&line[..end], &value_of, &mut used);
+        lines[no - 1] = format!("{}{}", replaced, &line[end..]);
+    }
+
+    // Nagłówek: /// Frozen:, /// Lib:, lista podstawionych zmiennych
+    let now = SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0);
+    let user = std::env::var("USER").unwrap_or_else(|_| "hl".into());
+    let host = std::fs::read_to_string("/etc/hostname").unwrap_or_else(|_| "hackeros".into());
+    let mut header = vec![format!("/// Frozen: {} {}@{}, hl {}, z {} (sha256 {})", crate::history::format_ts(now),
+        user, host.trim(), crate::compat::HL_VERSION, script.display(), sha256_file(script)?)];
+    for (spec, version, sha256) in &libs {
+        header.push(match sha256 {
+            Some(h) => format!("/// Lib: {} = {} (sha256 {})", spec, version, h),
+            None    => format!("/// Lib: {} = {} (nie zainstalowana)", spec, version),
+        });
+    }
+    used.sort();
+    if !used.is_empty() {
+        header.push(format!(";; hl freeze: podstawione zmienne: {}", used.join(", ")));
+    }
+    let at = header_end(&lines);
+    lines.splice(at..at, header);
+
+    let out = output.map(Path::to_path_buf).unwrap_or_else(|| default_output(script));
+    std::fs::write(&out, lines.join("\n") + "\n").with_context(|| format!("Nie można zapisać {}", out.display()))?;
+
+    println!("{} {} → {}", "hl freeze:".bright_magenta().bold(), script.display(), out.display().to_string().bright_white());
+    for inc in &freezer.included {
+        println!("  {} {:<32} {}", inc.kind, inc.path, format!("sha256 {}", &inc.sha256[..inc.sha256.len().min(16)]).bright_black());
+    }
+    for (spec, version, _) in &libs {
+        println!("  #  {:<32} {}", spec, version.bright_black());
+    }
+    for name in &used {
+        println!("  @  {:<32} {}", name, known.get(name).map(|(_, v)| v.as_str()).unwrap_or("").bright_black());
+    }
+    for w in &freezer.warnings {
+        eprintln!("  {} {}", "⚠".yellow(), w);
+    }
+    Ok(out)
+}
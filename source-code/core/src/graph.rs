@@ -0,0 +1,223 @@
+use anyhow::{bail, Result};
+use hl_parser::ast::Node;
+use std::collections::HashSet;
+use std::path::{Path, PathBuf};
+use crate::diagnostics::visit_nodes;
+use crate::libs::lib_source_file_in;
+
+// ── hl graph ──────────────────────────────────────────────────────────────────
+//
+//   hl graph [plik|katalog] [--format ascii|dot|mermaid]
+//
+// Graf projektu od skryptu wejściowego: importy plików (`<<`) i katalogów (`<*`),
+// biblioteki (`# <…>`, rekurencyjnie, jeśli źródło jest lokalnie), narzędzia
+// systemowe (`//`) i bloki extern (`_>`). Ścieżki z `@zmienną` są pokazywane
+// dosłownie — rozwiązuje je dopiero uruchomienie.
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum GraphFormat { Ascii, Dot, Mermaid }
+
+impl GraphFormat {
+    pub fn from_str(s: &str) -> Option<Self> {
+        match s {
+            "ascii"   => Some(GraphFormat::Ascii),
+            "dot"     => Some(GraphFormat::Dot),
+            "mermaid" => Some(GraphFormat::Mermaid),
+            _ => None,
+        }
+    }
+}
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+enum Kind { Script, Dir, Lib, Tool, Extern }
+
+impl Kind {
+    fn dot_shape(self) -> &'static str {
+        match self {
+            Kind::Script => "box",
+            Kind::Dir    => "folder",
+            Kind::Lib    => "component",
+            Kind::Tool   => "ellipse",
+            Kind::Extern => "hexagon",
+        }
+    }
+}
+
+#[derive(Default)]
+struct Graph {
+    nodes: Vec<(String, Kind)>,
+    edges: Vec<(usize, usize, &'static str)>,
+}
+
+impl Graph {
+    fn node(&mut self, label: String, kind: Kind) -> usize {
+        if let Some(i) = self.nodes.iter().position(|(l, k)| *l == label && *k == kind) { return i; }
+        self.nodes.push((label, kind));
+        self.nodes.len() - 1
+    }
+
+    fn edge(&mut self, from: usize, to: usize, label: &'static str) {
+        if !self.edges.contains(&(from, to, label)) { self.edges.push((from, to, label)); }
+    }
+}
+
+enum Child {
+    File(String),
+    Dir(String),
+    Lib(String),
+    Tool(String),
+    Extern(String),
+}
+
+/// `<< utils` → utils.hl; względem katalogu skryptu, potem bieżącego
+fn resolve(base: &Path, path: &str, add_ext: bool) -> PathBuf {
+    let path = if add_ext && !path.contains('.') { format!("{}.hl", path) } else { path.to_string() };
+    let rel = base.join(&path);
+    if rel.exists() { rel } else { PathBuf::from(path) }
+}
+
+fn display(path: &Path, root: &Path) -> String {
+    path.strip_prefix(root).unwrap_or(path).display().to_string()
+}
+
+struct Walker<'a> {
+    graph: Graph,
+    seen:  HashSet<PathBuf>,
+    files: Vec<PathBuf>,
+    root:  &'a Path,
+}
+
+impl Walker<'_> {
+    fn walk(&mut self, file: &Path, id: usize) -> Result<()> {
+        if !self.seen.insert(file.canonicalize().unwrap_or_else(|_| file.to_path_buf())) { return Ok(()); }
+        let Ok(source) = std::fs::read_to_string(file) else { return Ok(()) };
+        self.files.push(file.to_path_buf());
+        let nodes = hl_parser::parse_source(&source)
+            .map_err(|e| anyhow::anyhow!("{}: {}", file.display(), e))?;
+        let base = file.parent().unwrap_or(Path::new("."));
+
+        let mut children = Vec::new();
+        visit_nodes(&nodes, false, &mut |node, _| match node {
+            Node::FileImport { path, .. }          => children.push(Child::File(path.clone())),
+            Node::DirImport  { path }              => children.push(Child::Dir(path.clone())),
+            Node::Import     { lib, .. }           => children.push(Child::Lib(lib.clone())),
+            Node::Dependency { name, apt_package } => children.push(Child::Tool(match apt_package {
+                Some(pkg) => format!("{} [{}]", name, pkg),
+                None      => name.clone(),
+            })),
+            Node::ExternDef  { file, runtime, .. } => children.push(Child::Extern(
+                format!("{} [{}]", file, format!("{:?}", runtime).to_lowercase()))),
+            _ => {}
+        });
+
+        for child in children {
+            match child {
+                Child::File(path) => {
+                    let target = resolve(base, &path, true);
+                    let to = self.graph.node(display(&target, self.root), Kind::Script);
+                    self.graph.edge(id, to, "<<");
+                    if !path.contains('@') { self.walk(&target, to)?; }
+                }
+                Child::Dir(path) => {
+                    let dir = resolve(base, &path, false);
+                    let to = self.graph.node(display(&dir, self.root), Kind::Dir);
+                    self.graph.edge(id, to, "<*");
+                    if !path.contains('@') { self.walk(&dir.join("imports.hl"), to)?; }
+                }
+                Child::Lib(spec) => {
+                    let to = self.graph.node(format!("<{}>", spec.trim_start_matches('<').trim_end_matches('>')), Kind::Lib);
+                    self.graph.edge(id, to, "#");
+                    if let Some(src) = lib_source_file_in(&spec, self.root) { self.walk(&src, to)?; }
+                }
+                Child::Tool(name) => {
+                    let to = self.graph.node(name, Kind::Tool);
+                    self.graph.edge(id, to, "//");
+                }
+                Child::Extern(name) => {
+                    let to = self.graph.node(name, Kind::Extern);
+                    self.graph.edge(id, to, "_>");
+                }
+            }
+        }
+        Ok(())
+    }
+}
+
+fn quote(s: &str) -> String {
+    s.replace('\\', "\\\\").replace('"', "\\\"")
+}
+
+fn render_dot(g: &Graph) -> String {
+    let mut out = String::from("digraph hl {\n    rankdir=LR;\n");
+    for (i, (label, kind)) in g.nodes.iter().enumerate() {
+        out.push_str(&format!("    n{} [label=\"{}\", shape={}];\n", i, quote(label), kind.dot_shape()));
+    }
+    for (a, b, label) in &g.edges {
+        out.push_str(&format!("    n{} -> n{} [label=\"{}\"];\n", a, b, label));
+    }
+    out.push_str("}\n");
+    out
+}
+
+fn render_mermaid(g: &Graph) -> String {
+    let mut out = String::from("graph LR\n");
+    for (i, (label, kind)) in g.nodes.iter().enumerate() {
+        let label = label.replace('"', "#quot;").replace('<', "&lt;").replace('>', "&gt;");
+        let (open, close) = match kind {
+            Kind::Script => ("[\"", "\"]"),
+            Kind::Dir    => ("[/\"", "\"/]"),
+            Kind::Lib    => ("[[\"", "\"]]"),
+            Kind::Tool   => ("([\"", "\"])"),
+            Kind::Extern => ("{{\"", "\"}}"),
+        };
+        out.push_str(&format!("    n{}{}{}{}\n", i, open, label, close));
+    }
+    for (a, b, label) in &g.edges {
+        out.push_str(&format!("    n{} -->|\"{}\"| n{}\n", a, label.replace('<', "&lt;"), b));
+    }
+    out
+}
+
+fn render_ascii(g: &Graph) -> String {
+    fn walk(g: &Graph, id: usize, prefix: &str, seen: &mut HashSet<usize>, out: &mut String) {
+        let children: Vec<_> = g.edges.iter().filter(|(a, _, _)| *a == id).collect();
+        for (k, (_, to, label)) in children.iter().enumerate() {
+            let last = k + 1 == children.len();
+            let repeated = !seen.insert(*to) && g.edges.iter().any(|(a, _, _)| a == to);
+            out.push_str(&format!("{}{} {} {}{}\n", prefix, if last { "└──" } else { "├──" }, label,
+                                  g.nodes[*to].0, if repeated { "  (↑)" } else { "" }));
+            if !repeated {
+                walk(g, *to, &format!("{}{}", prefix, if last { "    " } else { "│   " }), seen, out);
+            }
+        }
+    }
+    let mut out = format!("{}\n", g.nodes[0].0);
+    let mut seen = HashSet::from([0]);
+    walk(g, 0, "", &mut seen, &mut out);
+    out
+}
+
+/// Graf projektu od `entry` w wybranym formacie
+pub fn project_graph(entry: &Path, format: GraphFormat) -> Result<String> {
+    if !entry.is_file() { bail!("Plik nie istnieje: {}", entry.display()); }
+    let root = entry.parent().unwrap_or(Path::new("."));
+    let mut walker = Walker { graph: Graph::default(), seen: HashSet::new(), files: Vec::new(), root };
+    let label = entry.file_name().map(|n| n.to_string_lossy().to_string()).unwrap_or_default();
+    let id = walker.graph.node(label, Kind::Script);
+    walker.walk(entry, id)?;
+    Ok(match format {
+        GraphFormat::Ascii   => render_ascii(&walker.graph),
+        GraphFormat::Dot     => render_dot(&walker.graph),
+        GraphFormat::Mermaid => render_mermaid(&walker.graph),
+    })
+}
+
+/// Pliki źródłowe, które wczyta `entry`: sam skrypt, importy `<<` / `<*` i biblioteki
+/// z lokalnym źródłem — ta sama kolejność rozwiązywania co graf
+pub fn resolved_sources(entry: &Path) -> Result<Vec<PathBuf>> {
+    let root = entry.parent().unwrap_or(Path::new("."));
+    let mut walker = Walker { graph: Graph::default(), seen: HashSet::new(), files: Vec::new(), root };
+    let id = walker.graph.node(entry.display().to_string(), Kind::Script);
+    walker.walk(entry, id)?;
+    Ok(walker.files)
+}
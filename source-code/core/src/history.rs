@@ -0,0 +1,197 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::process::Command;
+use std::time::{Duration, SystemTime, UNIX_EPOCH};
+use crate::deploy::hl_binary;
+use crate::jobs::{job_records, JobRecord};
+use crate::schedule::schedule_logs_dir;
+
+// ── Dziennik uruchomień (hl history) ──────────────────────────────────────────
+//
+// Każde `hl run` / `hl plik.hl` dopisuje jedną linię JSON do
+// ~/.hackeros/hacker-lang/logs/history.jsonl:
+//   {"id", "ts", "script", "args", "cwd", "env", "exit_code", "secs", "log"}
+//
+//   hl history [--script nazwa] [--failed] [-n 20]   lista ostatnich uruchomień
+//   hl history show <id>                             szczegóły + pełny log
+//   hl history rerun <id>                            ponów z tymi samymi args/cwd/env
+//
+// Zapisywane są tylko zmienne z RECORDED_ENV — jawne ustawienia hl, nigdy
+// tokeny (HL_SERVE_TOKEN) ani inne HL_* z sekretami. Plik jest przepisywany
+// atomowo pod blokadą "history", więc równoległe uruchomienia nie dzielą id.
+// `jobs` — tabela zadań w tle (&) z kodami wyjścia (jobs.rs).
+
+const HISTORY_FILE: &str = "history.jsonl";
+const HISTORY_MAX: usize = 2000;
+
+/// Zmienne HL_* bez sekretów, które zmieniają zachowanie uruchomienia
+const RECORDED_ENV: &[&str] = &[
+    "HL_FEATURES", "HL_GEN", "HL_NO_JIT", "HL_LOG", "HL_LIB_PATH", "HL_LAYOUT", "HL_IGNORE_PIN",
+    "HL_ENV_NAME", "HL_SHELL_MODE", "HL_CAPTURE_MAX", "HL_LIMIT_RATE", "HL_SUDO_PREFLIGHT",
+];
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct HistoryEntry {
+    pub id:        u64,
+    pub ts:        u64,
+    pub script:    String,
+    pub args:      Vec<String>,
+    pub cwd:       String,
+    #[serde(default)]
+    pub env:       Vec<(String, String)>,
+    pub exit_code: i32,
+    pub secs:      f64,
+    #[serde(default)]
+    pub log:       Option<String>,
+    #[serde(default)]
+    pub jobs:      Vec<JobRecord>,
+}
+
+pub fn history_path() -> PathBuf {
+    schedule_logs_dir().join(HISTORY_FILE)
+}
+
+pub fn load_history() -> Vec<HistoryEntry> {
+    std::fs::read_to_string(history_path())
+        .unwrap_or_default()
+        .lines()
+        .filter_map(|l| serde_json::from_str(l).ok())
+        .collect()
+}
+
+/// Dopisz uruchomienie do dziennika. Błędy zapisu są tylko logowane —
+/// dziennik nigdy nie zmienia exit code skryptu.
+pub fn record_run(script: &Path, args: &[String], exit_code: i32, elapsed: Duration) {
+    if let Err(e) = try_record_run(script, args, exit_code, elapsed) {
+        tracing::warn!("history: nie można zapisać dziennika: {}", e);
+    }
+}
+
+fn try_record_run(script: &Path, args: &[String], exit_code: i32, elapsed: Duration) -> Result<()> {
+    let path = history_path();
+    if let Some(dir) = path.parent() { std::fs::create_dir_all(dir)?; }
+
+    let _lock = crate::lock::lock_state("history")?;
+    let mut entries = load_history();
+    let entry = HistoryEntry {
+        id:     entries.last().map(|e| e.id + 1).unwrap_or(1),
+        ts:     SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0),
+        script: std::fs::canonicalize(script).unwrap_or_else(|_| script.to_path_buf()).display().to_string(),
+        args:   args.to_vec(),
+        cwd:    std::env::current_dir().map(|d| d.display().to_string()).unwrap_or_default(),
+        env:    std::env::vars().filter(|(k, _)| RECORDED_ENV.contains(&k.as_str())).collect(),
+        exit_code,
+        secs:   elapsed.as_secs_f64(),
+        log:    std::env::var("HL_RUN_LOG").ok(),
+        jobs:   job_records(),
+    };
+
+    if entries.len() >= HISTORY_MAX {
+        // Przytnij dziennik — zostaw ostatnie HISTORY_MAX/2 wpisów
+        entries.drain(..entries.len() - HISTORY_MAX / 2);
+    }
+    entries.push(entry);
+    let body: String = entries.iter()
+        .filter_map(|e| serde_json::to_string(e).ok())
+        .map(|l| l + "\n")
+        .collect();
+    // Zapis obok i rename — czytelnik nigdy nie widzi uciętego pliku
+    let tmp = path.with_extension(format!("jsonl.{}.tmp", std::process::id()));
+    let mut f = std::fs::File::create(&tmp)?;
+    f.write_all(body.as_bytes())?;
+    f.sync_all()?;
+    std::fs::rename(&tmp, &path)?;
+    Ok(())
+}
+
+fn find_entry(id: u64) -> Result<HistoryEntry> {
+    load_history().into_iter().find(|e| e.id == id)
+        .with_context(|| format!("Brak uruchomienia #{} w dzienniku", id))
+}
+
+/// Czas lokalny „YYYY-MM-DD HH:MM:SS” (localtime_r, bez uruchamiania `date`)
+pub(crate) fn format_ts(ts: u64) -> String {
+    use nix::libc;
+    let t = ts as libc::time_t;
+    // SAFETY: localtime_r zapisuje tylko do przekazanej struktury tm
+    let mut tm: libc::tm = unsafe { std::mem::zeroed() };
+    if unsafe { libc::localtime_r(&t, &mut tm) }.is_null() { return ts.to_string(); }
+    format!("{:04}-{:02}-{:02} {:02}:{:02}:{:02}",
+            tm.tm_year + 1900, tm.tm_mon + 1, tm.tm_mday, tm.tm_hour, tm.tm_min, tm.tm_sec)
+}
+
+pub fn cmd_history_list(script: Option<&str>, failed: bool, limit: usize) -> Result<()> {
+    let entries: Vec<HistoryEntry> = load_history().into_iter()
+        .filter(|e| script.map_or(true, |s| e.script.contains(s)))
+        .filter(|e| !failed || e.exit_code != 0)
+        .collect();
+    if entries.is_empty() {
+        println!("{}", "Brak uruchomień w dzienniku.".bright_black());
+        return Ok(());
+    }
+    println!("{}", "=== Historia uruchomień HL ===".bright_cyan().bold());
+    for e in entries.iter().rev().take(limit) {
+        let status = if e.exit_code == 0 { "✓".green().bold() } else { "✗".red().bold() };
+        let name = Path::new(&e.script).file_name().and_then(|n| n.to_str()).unwrap_or(&e.script);
+        println!("  {} {} {} {} exit={} ({:.1}s){}",
+                 status,
+                 format!("#{:<5}", e.id).bright_black(),
+                 format_ts(e.ts).bright_black(),
+                 format!("{:<24}", name).bright_white(),
+                 e.exit_code, e.secs,
+                 if e.args.is_empty() { String::new() } else { format!(" -- {}", e.args.join(" ")) });
+    }
+    println!("  Szczegóły: {}", "hl history show <id>".bright_cyan());
+    Ok(())
+}
+
+pub fn cmd_history_show(id: u64) -> Result<()> {
+    let e = find_entry(id)?;
+    println!("{} #{}", "hl history:".bright_magenta().bold(), e.id);
+    println!("  Skrypt:    {}", e.script.bright_white());
+    println!("  Argumenty: {}", if e.args.is_empty() { "-".into() } else { e.args.join(" ") });
+    println!("  Katalog:   {}", e.cwd);
+    println!("  Kiedy:     {}", format_ts(e.ts));
+    println!("  Exit code: {}", if e.exit_code == 0 { "0".green() } else { e.exit_code.to_string().red() });
+    println!("  Czas:      {:.2}s", e.secs);
+    for (k, v) in e.env.iter().filter(|(k, _)| RECORDED_ENV.contains(&k.as_str())) { println!("  {}={}", k.bright_black(), v.bright_black()); }
+    if !e.jobs.is_empty() {
+        println!("  Zadania w tle:");
+        for j in &e.jobs {
+            let status = match j.exit_code {
+                None    => "running".yellow(),
+                Some(0) => "exit 0".green(),
+                Some(c) => format!("exit {}", c).red(),
+            };
+            println!("    {:<12} {:>7}  {:<10} {:>6.1}s  {}", j.name, j.pid, status, j.secs, j.cmd.bright_black());
+        }
+    }
+    match e.log {
+        Some(ref log) if Path::new(log).exists() => {
+            println!("{}", format!("--- {} ---", log).bright_black());
+            print!("{}", std::fs::read_to_string(log)?);
+        }
+        Some(ref log) => println!("  Log:       {} {}", log, "(usunięty)".bright_black()),
+        None => println!("  Log:       {}", "brak (uruchomienie z terminala)".bright_black()),
+    }
+    Ok(())
+}
+
+/// Ponów uruchomienie z tymi samymi argumentami, katalogiem i zapisanymi zmiennymi HL_*
+pub fn cmd_history_rerun(id: u64) -> Result<i32> {
+    let e = find_entry(id)?;
+    if !Path::new(&e.script).exists() { bail!("Skrypt już nie istnieje: {}", e.script); }
+    println!("{} #{} {}", "hl history rerun:".bright_magenta().bold(), e.id, e.script.bright_white());
+
+    let mut cmd = Command::new(hl_binary());
+    cmd.arg("run").arg(&e.script);
+    if !e.args.is_empty() { cmd.arg("--").args(&e.args); }
+    if Path::new(&e.cwd).is_dir() { cmd.current_dir(&e.cwd); }
+    // Tylko zmienne z RECORDED_ENV — stare wpisy mogą mieć też HL_RUN_LOG czy tokeny
+    cmd.envs(e.env.iter().filter(|(k, _)| RECORDED_ENV.contains(&k.as_str())).cloned());
+    let status = cmd.status().context("Nie można uruchomić hl run")?;
+    Ok(status.code().unwrap_or(1))
+}
@@ -0,0 +1,227 @@
+use std::fmt::Display;
+use std::sync::OnceLock;
+
+// ── Komunikaty CLI hl (pl / en) ───────────────────────────────────────────────
+//
+// Katalog jak w hl-docs (docs/i18n.go): pomoc główna (`hl --help`), etykiety
+// BŁĄD / UWAGA i błędy samego CLI. Komunikaty bibliotek, diagnostyki i bit
+// pozostają po polsku.
+//
+// Język: --lang <pl|en>, potem HL_LANG, LC_ALL, LC_MESSAGES, LANG; domyślnie pl.
+// `{}` w komunikacie wypełnia tf() kolejnymi argumentami.
+
+static PL: &[(&str, &str)] = &[
+    ("about",            "Hacker Lang — język skryptowy HackerOS (gen 2)"),
+    ("after_help",       "\
+SKRYPTY SYSTEMOWE:
+hl search <nazwa>    Szukaj skryptu w /usr/share/HackerOS/Scripts/Bin/
+hl search all        Pokaż wszystkie dostępne skrypty
+hl exec <nazwa>      Uruchom skrypt z /usr/share/HackerOS/Scripts/Bin/
+
+BYTECODE / JIT:
+hl run plik.hl       Uruchom skrypt (domyślnie: tree-walk interpreter)
+hl run setup         Uruchom program z [bins] w bit.hk projektu
+hl run .             Uruchom projekt (entry z bit.hk, source-code/ src/ lub main.hl)
+hl run --jit plik.hl Uruchom przez JIT pipeline (eksperymentalny)
+hl run --host u@srv plik.hl  Uruchom zdalnie przez SSH (--hosts-file inventory)
+hl run plik.bc       Uruchom bytecode bezpośrednio przez JIT
+hl run --trust plik.hl  Pomiń manifest uprawnień (/// Requires:)
+hl run https://…/x.hl   Skrypt zdalny: podgląd, uprawnienia, potwierdzenie (--yes)
+hl run github:org/repo@v1#x.hl  Skrypt z repozytorium GitHub (ref i ścieżka opcjonalne)
+hl run --var ENV=prod plik.hl  Nadpisz zmienną (pierwszeństwo: --var > .env > % > środowisko)
+hl config validate   Sprawdź config.hk (składnia, nieznane klucze, wartości)
+hl new lib nazwa     Nowa biblioteka bit (lib.hl, tests/, README)
+hl new app nazwa     Nowa aplikacja (main.hl, build.hl, tests/)
+hl migrate --write   bit.hk ze starszego projektu (build.hl, nagłówek ///, importy bit)
+hl sign plik.hl      Podpisz skrypt (plik.hl.sig); --verify sprawdza podpis
+hl trust add k.pub   Dodaj zaufany klucz; hl trust list
+hl compile plik.hl   Kompiluj .hl → .bc (do katalogu źródłowego)
+hl compile --bin x   Kompiluj program z [bins] w bit.hk (bez --bin — wszystkie)
+hl fetch --group dev Zależności z bit.hk: [dependencies] + grupy z [groups]
+hl freeze plik.hl    Jeden plik do archiwum: importy wstawione, wersje bibliotek w nagłówku
+hl check --security plik.hl   Analiza niebezpiecznych komend (security.hk, exit 126)
+hl check .           Sprawdź wszystkie pliki .hl projektu (.hackerignore)
+hl check --fix plik.hl       Zastosuj automatyczne poprawki (kopia w plik.hl.bak)
+hl check --migrate plik.hl   Przepisz przestarzałą składnię na bieżącą (HL0023)
+hl check --format sarif plik.hl > hl.sarif   Diagnostyki jako SARIF (też --format json)
+hl explain HL0005    Wyjaśnienie kodu diagnostyki (przykład i poprawka)
+hl inspect plik.bc   Nagłówek i metadane (/// Author:, Version:, Description:)
+hl clean             Wyczyść cache .bc i pliki tymczasowe hl (--dry-run, --older-than 7d)
+hl cache info|clean|verify|gc   Cache: rozmiary, czyszczenie, weryfikacja .bc, LRU
+hl history           Historia uruchomień (show <id>, rerun <id>, --failed)
+hl paths             Katalogi danych (config.hk [paths] layout => xdg)
+hl bug-report        Pakiet diagnostyczny .tar.gz (-- <komenda> dołącza jej wyjście)
+hl verify-install    Test dymny: compile, .bc, interpreter, JIT, REPL, kontener
+
+CI:
+hl ci init github    Wygeneruj .github/workflows/hacker-lang.yml
+hl ci init gitlab    Wygeneruj .gitlab-ci.yml
+
+WDRAŻANIE:
+hl deploy systemd <nazwa> --script plik.hl [--on-calendar daily] [--install]
+hl schedule add \"0 3 * * *\" plik.hl   Dodaj wpis crontab (list / remove)
+hl export docker plik.hl --base <obraz>   Kontekst obrazu Docker/Podman
+hl serve [--config serve.hk]              Serwer HTTP: POST /run/<trasa>
+
+LOGI (opcje globalne):
+--log-level debug    trace | debug | info | warn | error (też HL_LOG)
+--log-file hl.log    Logi do pliku zamiast na terminal
+--log-format json    Logi i komunikaty BŁĄD/UWAGA jako JSON (wyjście komend bez zmian)
+--lang en            Język komunikatów hl: pl | en (też HL_LANG, LANG)
+
+KODY WYJŚCIA:
+0 OK  1 błąd  2 użycie  3 parser/linter  4 zależność  5 wykonanie  6 brak narzędzia
+126 odmowa (manifest, podpis, security)  130 przerwane
+
+PRZYKŁADY:
+hl run skrypt.hl
+hl exec update-system
+hl search update
+hl repl"),
+    ("error",            "BŁĄD"),
+    ("warning",          "UWAGA"),
+    ("not_hackeros",     "Hacker Lang działa wyłącznie na HackerOS."),
+    ("file.missing",     "Plik nie istnieje: {}"),
+    ("file.ext",         "Nieznane rozszerzenie: .{}"),
+    ("script.missing",   "Skrypt '{}' nie znaleziony w {}"),
+    ("script.hint",      "  Użyj {} aby zobaczyć dostępne skrypty."),
+    ("scripts.missing",  "Katalog skryptów nie istnieje: {}"),
+    ("dir.unreadable",   "Nie można odczytać katalogu: {}"),
+    ("entry.missing",    "{} to katalog bez [project] entry w {} ani {}"),
+    ("manifest.denied",  "skrypt wykracza poza manifest uprawnień ({} naruszeń) — {}"),
+    ("feature.undeclared", "cecha '{}' nie jest zadeklarowana w `/// Features:` ({})"),
+];
+
+static EN: &[(&str, &str)] = &[
+    ("about",            "Hacker Lang — the HackerOS scripting language (gen 2)"),
+    ("after_help",       "\
+SYSTEM SCRIPTS:
+hl search <name>     Search for a script in /usr/share/HackerOS/Scripts/Bin/
+hl search all        List all available scripts
+hl exec <name>       Run a script from /usr/share/HackerOS/Scripts/Bin/
+
+BYTECODE / JIT:
+hl run file.hl       Run a script (default: tree-walk interpreter)
+hl run setup         Run a program from [bins] in the project's bit.hk
+hl run .             Run the project (entry from bit.hk, source-code/ src/ or main.hl)
+hl run --jit file.hl Run through the JIT pipeline (experimental)
+hl run --host u@srv file.hl  Run remotely over SSH (--hosts-file inventory)
+hl run file.bc       Run bytecode directly through the JIT
+hl run --trust file.hl  Skip the permission manifest (/// Requires:)
+hl run https://…/x.hl   Remote script: preview, permissions, confirmation (--yes)
+hl run github:org/repo@v1#x.hl  Script from a GitHub repository (ref and path optional)
+hl run --var ENV=prod file.hl  Override a variable (precedence: --var > .env > % > environment)
+hl config validate   Check config.hk (syntax, unknown keys, values)
+hl new lib name      New bit library (lib.hl, tests/, README)
+hl new app name      New application (main.hl, build.hl, tests/)
+hl migrate --write   bit.hk for an older project (build.hl, /// header, bit imports)
+hl sign file.hl      Sign a script (file.hl.sig); --verify checks the signature
+hl trust add k.pub   Add a trusted key; hl trust list
+hl compile file.hl   Compile .hl → .bc (next to the source)
+hl compile --bin x   Compile a program from [bins] in bit.hk (without --bin — all of them)
+hl fetch --group dev Dependencies from bit.hk: [dependencies] + groups from [groups]
+hl freeze file.hl    Single file for archiving: imports inlined, library versions in the header
+hl check --security file.hl   Dangerous command analysis (security.hk, exit 126)
+hl check .           Check every .hl file in the project (.hackerignore)
+hl check --fix file.hl       Apply automatic fixes (backup in file.hl.bak)
+hl check --migrate file.hl   Rewrite deprecated syntax to the current one (HL0023)
+hl check --format sarif file.hl > hl.sarif   Diagnostics as SARIF (also --format json)
+hl explain HL0005    Explain a diagnostic code (example and fix)
+hl inspect file.bc   Header and metadata (/// Author:, Version:, Description:)
+hl clean             Clear the .bc cache and hl temp files (--dry-run, --older-than 7d)
+hl cache info|clean|verify|gc   Cache: sizes, cleanup, .bc verification, LRU
+hl history           Run history (show <id>, rerun <id>, --failed)
+hl paths             Data directories (config.hk [paths] layout => xdg)
+hl bug-report        Diagnostic .tar.gz bundle (-- <command> attaches its output)
+hl verify-install    Smoke test: compile, .bc, interpreter, JIT, REPL, container
+
+CI:
+hl ci init github    Generate .github/workflows/hacker-lang.yml
+hl ci init gitlab    Generate .gitlab-ci.yml
+
+DEPLOYMENT:
+hl deploy systemd <name> --script file.hl [--on-calendar daily] [--install]
+hl schedule add \"0 3 * * *\" file.hl   Add a crontab entry (list / remove)
+hl export docker file.hl --base <image>   Docker/Podman image context
+hl serve [--config serve.hk]              HTTP server: POST /run/<route>
+
+LOGGING (global options):
+--log-level debug    trace | debug | info | warn | error (also HL_LOG)
+--log-file hl.log    Write logs to a file instead of the terminal
+--log-format json    Logs and ERROR/WARNING messages as JSON (command output unchanged)
+--lang en            Language of hl messages: pl | en (also HL_LANG, LANG)
+
+EXIT CODES:
+0 OK  1 error  2 usage  3 parser/linter  4 dependency  5 execution  6 missing tool
+126 denied (manifest, signature, security)  130 interrupted
+
+EXAMPLES:
+hl run script.hl
+hl exec update-system
+hl search update
+hl repl"),
+    ("error",            "ERROR"),
+    ("warning",          "WARNING"),
+    ("not_hackeros",     "Hacker Lang runs on HackerOS only."),
+    ("file.missing",     "File does not exist: {}"),
+    ("file.ext",         "Unknown extension: .{}"),
+    ("script.missing",   "Script '{}' not found in {}"),
+    ("script.hint",      "  Use {} to list the available scripts."),
+    ("scripts.missing",  "Scripts directory does not exist: {}"),
+    ("dir.unreadable",   "Cannot read directory: {}"),
+    ("entry.missing",    "{} is a directory without a [project] entry in {} or {}"),
+    ("manifest.denied",  "script exceeds its permission manifest ({} violations) — {}"),
+    ("feature.undeclared", "feature '{}' is not declared in `/// Features:` ({})"),
+];
+
+static LANG: OnceLock<&'static str> = OnceLock::new();
+
+fn catalog(lang: &str) -> Option<&'static [(&'static str, &'static str)]> {
+    match lang {
+        "pl" => Some(PL),
+        "en" => Some(EN),
+        _    => None,
+    }
+}
+
+/// Wartość w stylu LANG ("en_US.UTF-8", "pl_PL") → kod katalogu
+fn locale_lang(v: &str) -> Option<&'static str> {
+    let v = v.to_ascii_lowercase();
+    let code = v.split(|c| matches!(c, '_' | '.' | '@' | '-')).next().unwrap_or("");
+    ["pl", "en"].into_iter().find(|l| *l == code)
+}
+
+/// Ustal język z argumentów (--lang) i zmiennych środowiska; wołane przed
+/// parsowaniem CLI — błędną wartość --lang zgłasza potem clap
+pub fn init(args: &[String]) {
+    let from_args = args.iter().enumerate().find_map(|(i, a)| match a.strip_prefix("--lang") {
+        Some("")  => args.get(i + 1).map(String::as_str),
+        Some(v)   => v.strip_prefix('='),
+        None      => None,
+    });
+    let lang = from_args.and_then(locale_lang).or_else(|| ["HL_LANG", "LC_ALL", "LC_MESSAGES", "LANG"].iter()
+        .find_map(|k| std::env::var(k).ok().as_deref().and_then(locale_lang)));
+    let _ = LANG.set(lang.unwrap_or("pl"));
+}
+
+/// Bieżący język ("pl" bez init)
+pub fn lang() -> &'static str {
+    LANG.get().copied().unwrap_or("pl")
+}
+
+/// Komunikat w bieżącym języku (z polskim katalogiem jako zapasowym)
+pub fn t(key: &str) -> &'static str {
+    let find = |cat: &'static [(&'static str, &'static str)]| cat.iter().find(|(k, _)| *k == key).map(|(_, v)| *v);
+    catalog(lang()).and_then(find).or_else(|| find(PL)).unwrap_or("")
+}
+
+/// t() z `{}` wypełnionymi kolejnymi argumentami
+pub fn tf(key: &str, args: &[&dyn Display]) -> String {
+    let mut parts = t(key).split("{}");
+    let mut out = parts.next().unwrap_or("").to_string();
+    for (part, arg) in parts.zip(args.iter().map(Some).chain(std::iter::repeat(None))) {
+        if let Some(a) = arg { out.push_str(&a.to_string()); }
+        out.push_str(part);
+    }
+    out
+}
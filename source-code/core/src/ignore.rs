@@ -0,0 +1,148 @@
+use std::path::{Path, PathBuf};
+
+// ── .hackerignore ─────────────────────────────────────────────────────────────
+//
+// Wykluczenia dla komend chodzących po całym projekcie (hl check <katalog>,
+// hl ci init, hl export docker). Składnia jak .gitignore:
+//
+//   # komentarz
+//   vendor/          tylko katalogi
+//   *.gen.hl         nazwa na dowolnej głębokości
+//   /build.hl        względem katalogu głównego (wzorzec z `/`)
+//   docs/**/*.hl     ** — dowolna liczba katalogów
+//   !keep.gen.hl     negacja — ponowne włączenie
+//
+// Ostatni pasujący wzorzec wygrywa. Zawsze pomijane: .git/ i .cache/.
+
+pub const IGNORE_FILE: &str = ".hackerignore";
+
+const DEFAULT_RULES: &str = ".git/\n.cache/\n";
+
+#[derive(Debug, Clone)]
+struct Rule {
+    pattern:  Vec<char>,
+    negate:   bool,
+    dir_only: bool,
+    anchored: bool,
+}
+
+#[derive(Debug, Clone, Default)]
+pub struct IgnoreRules { rules: Vec<Rule> }
+
+impl IgnoreRules {
+    pub fn parse(source: &str) -> Self {
+        let mut rules = Self::default();
+        rules.extend(source);
+        rules
+    }
+
+    fn extend(&mut self, source: &str) {
+        for line in source.lines() {
+            let line = line.trim_end();
+            if line.is_empty() || line.starts_with('#') { continue; }
+            let (negate, line) = match line.strip_prefix('!') { Some(r) => (true, r), None => (false, line) };
+            let line = line.strip_prefix('\\').unwrap_or(line);
+            let (dir_only, line) = match line.strip_suffix('/') { Some(r) => (true, r), None => (false, line) };
+            let anchored = line.contains('/');
+            let line = line.strip_prefix('/').unwrap_or(line);
+            if line.is_empty() { continue; }
+            self.rules.push(Rule { pattern: line.chars().collect(), negate, dir_only, anchored });
+        }
+    }
+
+    /// Reguły domyślne + `<root>/.hackerignore` (jeśli istnieje)
+    pub fn load(root: &Path) -> Self {
+        let mut rules = Self::parse(DEFAULT_RULES);
+        if let Ok(src) = std::fs::read_to_string(root.join(IGNORE_FILE)) { rules.extend(&src); }
+        rules
+    }
+
+    /// `rel` — ścieżka względem katalogu głównego projektu
+    pub fn is_ignored(&self, rel: &Path, is_dir: bool) -> bool {
+        let path: Vec<char> = rel.to_string_lossy().replace('\\', "/").chars().collect();
+        let name: Vec<char> = rel.file_name().map(|n| n.to_string_lossy().chars().collect()).unwrap_or_default();
+        let mut ignored = false;
+        for rule in &self.rules {
+            if rule.dir_only && !is_dir { continue; }
+            let subject = if rule.anchored { &path } else { &name };
+            if glob(&rule.pattern, subject) { ignored = !rule.negate; }
+        }
+        ignored
+    }
+}
+
+/// Dopasowanie wzorca w stylu gitignore: `*`, `?`, `[a-z]`, `**`
+fn glob(p: &[char], s: &[char]) -> bool {
+    match p.first() {
+        None => s.is_empty(),
+        Some('*') if p.get(1) == Some(&'*') => {
+            let rest = &p[2..];
+            match rest.strip_prefix(&['/']) {
+                // `**/` — zero lub więcej katalogów
+                Some(after) => glob(after, s)
+                    || s.iter().enumerate().any(|(i, c)| *c == '/' && glob(after, &s[i + 1..])),
+                None => (0..=s.len()).any(|i| glob(rest, &s[i..])),
+            }
+        }
+        Some('*') => {
+            for i in 0..=s.len() {
+                if glob(&p[1..], &s[i..]) { return true; }
+                if i < s.len() && s[i] == '/' { break; }
+            }
+            false
+        }
+        Some('?') => s.first().is_some_and(|c| *c != '/') && glob(&p[1..], &s[1..]),
+        Some('[') => match p.iter().skip(1).position(|c| *c == ']') {
+            Some(end) => {
+                let class = &p[1..end + 1];
+                let Some(&c) = s.first() else { return false };
+                class_matches(class, c) && c != '/' && glob(&p[end + 2..], &s[1..])
+            }
+            None => s.first() == Some(&'[') && glob(&p[1..], &s[1..]),
+        },
+        Some('\\') if p.len() > 1 => s.first() == Some(&p[1]) && glob(&p[2..], &s[1..]),
+        Some(c) => s.first() == Some(c) && glob(&p[1..], &s[1..]),
+    }
+}
+
+fn class_matches(class: &[char], c: char) -> bool {
+    let (negate, class) = match class.first() {
+        Some('!') | Some('^') => (true, &class[1..]),
+        _ => (false, class),
+    };
+    let mut hit = false;
+    let mut i = 0;
+    while i < class.len() {
+        if i + 2 < class.len() && class[i + 1] == '-' {
+            if (class[i]..=class[i + 2]).contains(&c) { hit = true; }
+            i += 3;
+        } else {
+            if class[i] == c { hit = true; }
+            i += 1;
+        }
+    }
+    hit != negate
+}
+
+/// Wszystkie pliki .hl w projekcie z pominięciem .hackerignore (posortowane)
+pub fn project_hl_files(root: &Path) -> Vec<PathBuf> {
+    let rules = IgnoreRules::load(root);
+    let mut files = Vec::new();
+    walk(root, Path::new(""), &rules, &mut files);
+    files.sort();
+    files
+}
+
+fn walk(root: &Path, rel: &Path, rules: &IgnoreRules, out: &mut Vec<PathBuf>) {
+    let Ok(rd) = std::fs::read_dir(root.join(rel)) else { return };
+    for entry in rd.flatten() {
+        let rel = rel.join(entry.file_name());
+        let Ok(ft) = entry.file_type() else { continue };
+        if rules.is_ignored(&rel, ft.is_dir()) { continue; }
+        if ft.is_dir() {
+            walk(root, &rel, rules, out);
+        } else if ft.is_file() && rel.extension().and_then(|e| e.to_str()) == Some("hl") {
+            out.push(root.join(&rel));
+        }
+    }
+}
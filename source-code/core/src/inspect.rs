@@ -0,0 +1,151 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use std::path::Path;
+use std::process::Command;
+use crate::libs::sha256_file;
+use crate::tmp::human_size;
+
+// ── hl inspect dla archiwów i binarek ─────────────────────────────────────────
+//
+// hl inspect rozpoznaje plik po magicu, nie po rozszerzeniu:
+//   HLBC (po shebangu)     .bc — nagłówek i metadane (cli)
+//   tar / tar.gz / .pkg    lista plików, rozmiar, manifest z katalogu głównego
+//   ELF                    architektura, typ, static/dynamic, symbole
+// Nic nie jest rozpakowywane — tar czyta archiwum strumieniowo (-t, -xO).
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum FileKind { Bytecode, Archive, Elf, Unknown }
+
+/// Nazwy plików manifestu w katalogu głównym paczki
+const MANIFEST_NAMES: &[&str] = &["manifest.json", "manifest.hk", "info.hk", "package.hk", "hl.hk", "Info.hk"];
+const MANIFEST_MAX: usize = 4096;
+
+pub fn detect_kind(path: &Path) -> Result<FileKind> {
+    use std::io::Read;
+    let mut head = vec![0u8; 512];
+    let n = std::fs::File::open(path)?.read(&mut head)?;
+    head.truncate(n);
+    let body = if head.starts_with(b"#!") {
+        head.iter().position(|&b| b == b'\n').map(|i| &head[i + 1..]).unwrap_or(&[])
+    } else { &head[..] };
+    Ok(if body.starts_with(b"HLBC") {
+        FileKind::Bytecode
+    } else if head.starts_with(b"\x7fELF") {
+        FileKind::Elf
+    } else if head.starts_with(&[0x1f, 0x8b]) || head.starts_with(b"BZh") || head.starts_with(&[0xfd, b'7', b'z', b'X', b'Z'])
+        || head.starts_with(&[0x28, 0xb5, 0x2f, 0xfd]) || head.get(257..262) == Some(b"ustar") {
+        FileKind::Archive
+    } else {
+        FileKind::Unknown
+    })
+}
+
+/// `-rw-r--r-- user/group  1234 2024-01-01 12:00 ścieżka` → (ścieżka, rozmiar)
+fn parse_tar_line(line: &str) -> Option<(String, u64)> {
+    let fields: Vec<&str> = line.split_whitespace().collect();
+    if fields.len() < 6 { return None; }
+    let size = fields[2].parse().ok()?;
+    let path = fields[5..].join(" ");
+    Some((path.split(" -> ").next().unwrap_or(&path).to_string(), size))
+}
+
+fn tar(args: &[&str], archive: &Path) -> Result<Vec<u8>> {
+    if which::which("tar").is_err() {
+        return Err(crate::exit::classified(crate::exit::ErrorClass::Toolchain, "tar nie jest zainstalowany"));
+    }
+    let out = Command::new("tar").args(args).arg(archive).output()?;
+    if !out.status.success() {
+        bail!("tar {}: {}", archive.display(), String::from_utf8_lossy(&out.stderr).trim());
+    }
+    Ok(out.stdout)
+}
+
+pub fn inspect_archive(path: &Path, json: bool) -> Result<()> {
+    let listing = String::from_utf8_lossy(&tar(&["-tvf"], path)?).to_string();
+    let files: Vec<(String, u64)> = listing.lines().filter_map(parse_tar_line).collect();
+    let total: u64 = files.iter().map(|(_, s)| s).sum();
+    let manifest = files.iter()
+        .find(|(f, _)| MANIFEST_NAMES.contains(&f.trim_start_matches("./")))
+        .and_then(|(f, _)| {
+            let out = Command::new("tar").args(["-xOf"]).arg(path).arg(f).output().ok()?;
+            let text = String::from_utf8_lossy(&out.stdout[..out.stdout.len().min(MANIFEST_MAX)]).to_string();
+            Some((f.clone(), text))
+        });
+    let sha256 = sha256_file(path)?;
+    let size = std::fs::metadata(path)?.len();
+
+    if json {
+        println!("{}", serde_json::to_string_pretty(&serde_json::json!({
+            "kind": "archive", "size": size, "sha256": sha256, "unpacked_size": total,
+            "manifest": manifest.as_ref().map(|(f, t)| serde_json::json!({ "file": f, "content": t })),
+            "files": files.iter().map(|(f, s)| serde_json::json!({ "path": f, "size": s })).collect::<Vec<_>>(),
+        }))?);
+        return Ok(());
+    }
+    println!("{} {}", "hl inspect:".bright_magenta().bold(), path.display().to_string().bright_white());
+    println!("  Format:      archiwum tar");
+    println!("  Rozmiar:     {} (po rozpakowaniu {}, {} plików)", human_size(size), human_size(total), files.len());
+    println!("  sha256:      {}", sha256.bright_black());
+    match &manifest {
+        Some((f, text)) => {
+            println!("  Manifest:    {}", f.bright_cyan());
+            for line in text.lines() { println!("    {}", line); }
+        }
+        None => println!("  Manifest:    {}", "-".bright_black()),
+    }
+    println!("  Pliki:");
+    for (f, s) in &files {
+        println!("    {:>9}  {}", human_size(*s).bright_black(), f);
+    }
+    Ok(())
+}
+
+struct ElfInfo {
+    class:       u8,
+    arch:        &'static str,
+    kind:        &'static str,
+    interpreter: Option<bool>,
+    symbols:     Option<bool>,
+}
+
+fn elf_info(raw: &[u8]) -> ElfInfo {
+    let u16_at = |o: usize| raw.get(o..o + 2).map(|b| u16::from_le_bytes([b[0], b[1]])).unwrap_or(0);
+    let u32_at = |o: usize| raw.get(o..o + 4).map(|b| u32::from_le_bytes(b.try_into().unwrap())).unwrap_or(0);
+    let u64_at = |o: usize| raw.get(o..o + 8).map(|b| u64::from_le_bytes(b.try_into().unwrap())).unwrap_or(0) as usize;
+    let class = raw.get(4).copied().unwrap_or(0);
+    let arch = match u16_at(18) {
+        0x3e => "x86_64", 0xb7 => "aarch64", 0x03 => "x86", 0x28 => "arm", 0xf3 => "riscv", _ => "inna",
+    };
+    let kind = match u16_at(16) { 2 => "program", 3 => "program PIE / biblioteka", 1 => "obiekt", 4 => "core", _ => "?" };
+    // Nagłówki programu i sekcji — tylko ELF64 little-endian
+    let (interpreter, symbols) = if class == 2 && raw.get(5) == Some(&1) {
+        let (phoff, phsize, phnum) = (u64_at(0x20), u16_at(0x36) as usize, u16_at(0x38) as usize);
+        let (shoff, shsize, shnum) = (u64_at(0x28), u16_at(0x3a) as usize, u16_at(0x3c) as usize);
+        let interp = (0..phnum).any(|i| u32_at(phoff + i * phsize) == 3);              // PT_INTERP
+        let symtab = (0..shnum).any(|i| u32_at(shoff + i * shsize + 4) == 2);          // SHT_SYMTAB
+        (Some(interp), Some(symtab))
+    } else { (None, None) };
+    ElfInfo { class, arch, kind, interpreter, symbols }
+}
+
+pub fn inspect_elf(path: &Path, json: bool) -> Result<()> {
+    let raw = std::fs::read(path)?;
+    let info = elf_info(&raw);
+    let sha256 = sha256_file(path)?;
+    let linking = info.interpreter.map(|i| if i { "dynamic" } else { "static" });
+    let stripped = info.symbols.map(|s| !s);
+    if json {
+        println!("{}", serde_json::to_string_pretty(&serde_json::json!({
+            "kind": "elf", "size": raw.len(), "sha256": sha256, "bits": if info.class == 2 { 64 } else { 32 },
+            "arch": info.arch, "type": info.kind, "linking": linking, "stripped": stripped,
+        }))?);
+        return Ok(());
+    }
+    println!("{} {}", "hl inspect:".bright_magenta().bold(), path.display().to_string().bright_white());
+    println!("  Format:      ELF{} {} — {}", if info.class == 2 { 64 } else { 32 }, info.arch, info.kind);
+    println!("  Rozmiar:     {}", human_size(raw.len() as u64));
+    println!("  sha256:      {}", sha256.bright_black());
+    if let Some(l) = linking  { println!("  Linkowanie:  {}", l); }
+    if let Some(s) = stripped { println!("  Symbole:     {}", if s { "usunięte (stripped)" } else { "obecne" }); }
+    Ok(())
+}
@@ -0,0 +1,198 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::os::unix::process::ExitStatusExt;
+use std::process::{Child, Command, ExitStatus, Stdio};
+use std::sync::{Condvar, Mutex, MutexGuard};
+use std::time::Instant;
+
+// ── Zadania w tle (&) ─────────────────────────────────────────────────────────
+//
+//   &[dl] curl -fsSLO https://example.com/big.iso    zadanie z nazwą
+//   & make -j8                                       bez nazwy — nazwą jest PID
+//   ::wait dl                                        czekaj (bez nazwy — na wszystkie);
+//                                                    kod wyjścia zadania → ? ok / ? err
+//   ::status dl                                      running / exit <kod>
+//   ::kill dl [SYGNAŁ]                               domyślnie TERM
+//   ::jobs                                           tabela zadań
+//
+// Tabela zadań trafia do dziennika uruchomień (hl history show). Zadania, które
+// nadal działają po zakończeniu skryptu, nie są zatrzymywane.
+
+struct Job {
+    name:    String,
+    cmd:     String,
+    pid:     u32,
+    /// None — ktoś czeka na zadanie (wait_jobs) bez blokady tabeli
+    child:   Option<Child>,
+    started: Instant,
+    exit:    Option<i32>,
+    secs:    f64,
+}
+
+impl Job {
+    fn finish(&mut self, status: ExitStatus) {
+        self.exit = Some(status.code().unwrap_or_else(|| 128 + status.signal().unwrap_or(0)));
+        self.secs = self.started.elapsed().as_secs_f64();
+    }
+
+    fn poll(&mut self) {
+        if self.exit.is_some() { return; }
+        if let Some(Ok(Some(status))) = self.child.as_mut().map(Child::try_wait) { self.finish(status); }
+    }
+
+    fn status(&self) -> String {
+        match self.exit {
+            None       => "running".into(),
+            Some(code) => format!("exit {}", code),
+        }
+    }
+}
+
+/// Wpis tabeli zadań w dzienniku uruchomień; `exit_code: None` — zadanie nadal działało
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct JobRecord {
+    pub name:      String,
+    pub pid:       u32,
+    pub cmd:       String,
+    pub exit_code: Option<i32>,
+    pub secs:      f64,
+}
+
+static JOBS: Mutex<Vec<Job>> = Mutex::new(Vec::new());
+/// Powiadamia czekających na zadanie, którego Child wziął inny wątek
+static FINISHED: Condvar = Condvar::new();
+
+fn jobs() -> MutexGuard<'static, Vec<Job>> {
+    JOBS.lock().unwrap_or_else(|e| e.into_inner())
+}
+
+/// Najnowsze zadanie o tej nazwie (nazwy można używać ponownie po zakończeniu)
+fn find_index(jobs: &[Job], name: &str) -> Result<usize> {
+    match jobs.iter().rposition(|j| j.name == name) {
+        Some(i) => Ok(i),
+        None    => bail!("Nieznane zadanie w tle '{}' — uruchom je przez `&[{}] komenda`", name, name),
+    }
+}
+
+fn find<'a>(jobs: &'a mut [Job], name: &str) -> Result<&'a mut Job> {
+    let i = find_index(jobs, name)?;
+    Ok(&mut jobs[i])
+}
+
+/// Uruchom `cmd` w tle; zwraca PID
+pub fn spawn_job(name: Option<&str>, cmd: &str) -> Result<u32> {
+    let mut jobs = jobs();
+    if let Some(name) = name {
+        if let Ok(job) = find(&mut jobs, name) {
+            job.poll();
+            if job.exit.is_none() { bail!("Zadanie '{}' już działa (PID {})", name, job.pid); }
+        }
+    }
+    let child = Command::new("sh")
+        .args(["-c", cmd])
+        .stdin(Stdio::null()).stdout(Stdio::inherit()).stderr(Stdio::inherit())
+        .spawn()
+        .map_err(|e| anyhow::anyhow!("Błąd tła: {}", e))?;
+    let pid = child.id();
+    jobs.push(Job {
+        name: name.map(str::to_string).unwrap_or_else(|| pid.to_string()),
+        cmd: cmd.to_string(),
+        pid, child: Some(child),
+        started: Instant::now(),
+        exit: None,
+        secs: 0.0,
+    });
+    Ok(pid)
+}
+
+/// Czekaj na zadania (puste `names` — wszystkie); zwraca pierwszy niezerowy kod wyjścia
+pub fn wait_jobs(names: &[&str]) -> Result<i32> {
+    // Zadania nie są usuwane z tabeli, więc indeksy są stałe
+    let targets: Vec<usize> = {
+        let jobs = jobs();
+        if names.is_empty() {
+            (0..jobs.len()).filter(|&i| jobs[i].exit.is_none()).collect()
+        } else {
+            names.iter().map(|n| find_index(&jobs, n)).collect::<Result<_>>()?
+        }
+    };
+    let mut code = 0;
+    for i in targets {
+        let exit = wait_job(i)?;
+        if code == 0 { code = exit; }
+    }
+    Ok(code)
+}
+
+/// Kod wyjścia zadania `i`; child.wait() bez blokady JOBS — spawn, ::status
+/// i ::kill z innych goroutines działają w tym czasie
+fn wait_job(i: usize) -> Result<i32> {
+    let mut jobs = jobs();
+    loop {
+        if let Some(code) = jobs[i].exit { return Ok(code); }
+        let Some(mut child) = jobs[i].child.take() else {
+            jobs = FINISHED.wait(jobs).unwrap_or_else(|e| e.into_inner());
+            continue;
+        };
+        drop(jobs);
+        let status = child.wait();
+        jobs = self::jobs();
+        jobs[i].child = Some(child);
+        if let Ok(s) = &status { jobs[i].finish(*s); }
+        FINISHED.notify_all();
+        status?;
+    }
+}
+
+/// `running` albo `exit <kod>` oraz kod wyjścia (0 dla działającego zadania)
+pub fn job_status(name: &str) -> Result<(String, i32)> {
+    let mut jobs = jobs();
+    let job = find(&mut jobs, name)?;
+    job.poll();
+    Ok((job.status(), job.exit.unwrap_or(0)))
+}
+
+/// Wyślij sygnał (domyślnie TERM); false — zadanie już się zakończyło
+pub fn kill_job(name: &str, signal: Option<&str>) -> Result<bool> {
+    let mut jobs = jobs();
+    let job = find(&mut jobs, name)?;
+    job.poll();
+    if job.exit.is_some() { return Ok(false); }
+    let signal = signal.unwrap_or("TERM").trim_start_matches("SIG");
+    let ok = Command::new("kill").args(["-s", signal, &job.pid.to_string()]).status()?.success();
+    if !ok { bail!("Nie można wysłać SIG{} do zadania '{}' (PID {})", signal, name, job.pid); }
+    Ok(true)
+}
+
+/// ::jobs — tabela zadań tego uruchomienia
+pub fn print_jobs() {
+    let mut jobs = jobs();
+    if jobs.is_empty() {
+        println!("{}", "Brak zadań w tle.".bright_black());
+        return;
+    }
+    for job in jobs.iter_mut() {
+        job.poll();
+        let status = match job.exit {
+            None    => job.status().yellow(),
+            Some(0) => job.status().green(),
+            Some(_) => job.status().red(),
+        };
+        println!("  {:<12} {:>7}  {:<10} {}", job.name.bright_white(), job.pid, status, job.cmd.bright_black());
+    }
+}
+
+/// Stan wszystkich zadań do dziennika uruchomień
+pub fn job_records() -> Vec<JobRecord> {
+    jobs().iter_mut().map(|job| {
+        job.poll();
+        JobRecord {
+            name:      job.name.clone(),
+            pid:       job.pid,
+            cmd:       job.cmd.clone(),
+            exit_code: job.exit,
+            secs:      if job.exit.is_some() { job.secs } else { job.started.elapsed().as_secs_f64() },
+        }
+    }).collect()
+}
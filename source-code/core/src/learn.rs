@@ -0,0 +1,296 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::collections::BTreeMap;
+use std::io::{BufRead, IsTerminal, Write};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::time::{SystemTime, UNIX_EPOCH};
+
+// ── hl learn — interaktywne lekcje ────────────────────────────────────────────
+//
+//   hl learn                      lekcje i postęp
+//   hl learn podstawy             piaskownica + zadania; na terminalu pętla:
+//                                 Enter — sprawdź, h — podpowiedź, e — edytor, q — wyjdź
+//   hl learn podstawy --check     sprawdź bieżące zadanie bez pytań (exit 1 — jeszcze nie)
+//   hl learn podstawy --reset     od nowa: postęp i piaskownica
+//
+// Każda lekcja ma własny katalog-piaskownicę w <dane>/learn/<lekcja>/ z plikami
+// startowymi. Zadanie jest zaliczone, gdy przejdą wszystkie jego sprawdzenia:
+// plik istnieje / zawiera tekst, `hl check` przechodzi, wyjście `hl run`
+// zawiera oczekiwany tekst. Postęp (zaliczone zadania) leży w meta/learn.json.
+
+#[derive(Debug, Clone, Copy)]
+pub enum Check {
+    FileExists(&'static str),
+    FileContains(&'static str, &'static str),
+    CheckPasses(&'static str),
+    OutputContains(&'static str, &'static str),
+}
+
+pub struct Task {
+    pub goal:   &'static str,
+    pub hint:   &'static str,
+    pub checks: &'static [Check],
+}
+
+pub struct Lesson {
+    pub id:     &'static str,
+    pub title:  &'static str,
+    pub intro:  &'static str,
+    /// Pliki startowe piaskownicy: (nazwa, treść)
+    pub files:  &'static [(&'static str, &'static str)],
+    pub tasks:  &'static [Task],
+}
+
+pub const LESSONS: &[Lesson] = &[
+    Lesson {
+        id: "podstawy", title: "Pierwszy skrypt: wypisywanie i zmienne",
+        intro: "Skrypt HL to plik .hl. `~>` wypisuje tekst, `% nazwa = wartość` tworzy zmienną,\n\
+                a `@nazwa` wstawia jej wartość.",
+        files: &[],
+        tasks: &[
+            Task {
+                goal: "Utwórz hello.hl, który wypisuje: Witaj, HackerOS",
+                hint: "Jedna linia: ~> Witaj, HackerOS",
+                checks: &[Check::FileExists("hello.hl"), Check::CheckPasses("hello.hl"),
+                          Check::OutputContains("hello.hl", "Witaj, HackerOS")],
+            },
+            Task {
+                goal: "Dodaj do hello.hl zmienną imie i wypisz: Czesc <imie>",
+                hint: "% imie = Ada\n~> Czesc @imie",
+                checks: &[Check::FileContains("hello.hl", "@imie"), Check::CheckPasses("hello.hl"),
+                          Check::OutputContains("hello.hl", "Czesc ")],
+            },
+        ],
+    },
+    Lesson {
+        id: "petle", title: "Pętle i funkcje",
+        intro: "`@ n in 1..3` powtarza ciało dla każdej wartości aż do `done`.\n\
+                Funkcję definiuje `: nazwa def` … `done`, a wywołuje `-- nazwa`.",
+        files: &[("petle.hl", "using <gen 2>\n\n;; Zadanie 1: pętla wypisująca krok 1, krok 2, krok 3\n\n;; Zadanie 2: funkcja powitaj\n")],
+        tasks: &[
+            Task {
+                goal: "W petle.hl wypisz w pętli: krok 1, krok 2, krok 3",
+                hint: "@ n in 1..3\n    ~> krok @n\ndone",
+                checks: &[Check::FileContains("petle.hl", "@ n in"), Check::CheckPasses("petle.hl"),
+                          Check::OutputContains("petle.hl", "krok 3")],
+            },
+            Task {
+                goal: "Zdefiniuj funkcję powitaj wypisującą `Czesc z funkcji` i wywołaj ją",
+                hint: ": powitaj def\n    ~> Czesc z funkcji\ndone\n\n-- powitaj",
+                checks: &[Check::FileContains("petle.hl", ": powitaj def"), Check::CheckPasses("petle.hl"),
+                          Check::OutputContains("petle.hl", "Czesc z funkcji")],
+            },
+        ],
+    },
+    Lesson {
+        id: "warunki", title: "Komendy, kody wyjścia i warunki",
+        intro: "`> komenda` uruchamia program. Po niej `? ok` / `? err` wykonują blok\n\
+                zależnie od kodu wyjścia, a `? @x == wartość` porównuje zmienne.",
+        files: &[("warunki.hl", "using <gen 2>\n\n% katalog = /tmp\n")],
+        tasks: &[
+            Task {
+                goal: "Sprawdź `test -d @katalog` i przy sukcesie wypisz: katalog jest",
+                hint: ">> test -d @katalog\n? ok\n    ~> katalog jest\ndone",
+                checks: &[Check::FileContains("warunki.hl", "? ok"), Check::CheckPasses("warunki.hl"),
+                          Check::OutputContains("warunki.hl", "katalog jest")],
+            },
+            Task {
+                goal: "Dodaj warunek `? @katalog == /tmp` wypisujący: to tmp",
+                hint: "? @katalog == /tmp\n    ~> to tmp\ndone",
+                checks: &[Check::FileContains("warunki.hl", "=="), Check::CheckPasses("warunki.hl"),
+                          Check::OutputContains("warunki.hl", "to tmp")],
+            },
+        ],
+    },
+];
+
+#[derive(Debug, Default, Serialize, Deserialize)]
+struct LessonProgress {
+    done:     usize,
+    finished: Option<u64>,
+}
+
+#[derive(Debug, Default, Serialize, Deserialize)]
+struct Progress {
+    lessons: BTreeMap<String, LessonProgress>,
+}
+
+fn progress_path() -> PathBuf {
+    crate::paths::data_dir("meta").join("learn.json")
+}
+
+fn load_progress() -> Progress {
+    std::fs::read_to_string(progress_path()).ok()
+        .and_then(|s| serde_json::from_str(&s).ok())
+        .unwrap_or_default()
+}
+
+fn save_progress(p: &Progress) -> Result<()> {
+    let path = progress_path();
+    if let Some(dir) = path.parent() { std::fs::create_dir_all(dir)?; }
+    std::fs::write(&path, serde_json::to_string_pretty(p)? + "\n")?;
+    Ok(())
+}
+
+pub fn sandbox_dir(lesson: &str) -> PathBuf {
+    crate::paths::data_dir("learn").join(lesson)
+}
+
+pub fn lesson(id: &str) -> Option<&'static Lesson> {
+    LESSONS.iter().find(|l| l.id == id)
+}
+
+fn hl_bin() -> PathBuf {
+    std::env::current_exe().unwrap_or_else(|_| PathBuf::from("hl"))
+}
+
+/// Uruchom hl w piaskownicy (bez telemetrii i kolorów)
+fn hl(dir: &Path, args: &[&str]) -> Option<std::process::Output> {
+    Command::new(hl_bin()).args(args).current_dir(dir)
+        .env("HL_TELEMETRY", "0").env("NO_COLOR", "1")
+        .stdin(Stdio::null()).output().ok()
+}
+
+/// None — sprawdzenie przeszło; Some(powód) — nie
+fn run_check(check: &Check, dir: &Path) -> Option<String> {
+    match *check {
+        Check::FileExists(f) => (!dir.join(f).is_file()).then(|| format!("brak pliku {}", f)),
+        Check::FileContains(f, needle) => match std::fs::read_to_string(dir.join(f)) {
+            Ok(s) if s.contains(needle) => None,
+            Ok(_)  => Some(format!("{} nie zawiera `{}`", f, needle)),
+            Err(_) => Some(format!("brak pliku {}", f)),
+        },
+        Check::CheckPasses(f) => match hl(dir, &["check", f]) {
+            Some(o) if o.status.success() => None,
+            Some(o) => Some(format!("hl check {} zgłasza błędy:\n{}", f, String::from_utf8_lossy(&o.stderr).trim_end())),
+            None    => Some("nie można uruchomić hl check".into()),
+        },
+        Check::OutputContains(f, want) => match hl(dir, &["run", f]) {
+            Some(o) => {
+                let out = String::from_utf8_lossy(&o.stdout);
+                (!out.contains(want)).then(|| format!("wyjście `hl run {}` nie zawiera „{}”; było:\n{}", f, want, out.trim_end()))
+            }
+            None => Some("nie można uruchomić hl run".into()),
+        },
+    }
+}
+
+fn prepare_sandbox(l: &Lesson) -> Result<PathBuf> {
+    let dir = sandbox_dir(l.id);
+    std::fs::create_dir_all(&dir)?;
+    for (name, content) in l.files {
+        let path = dir.join(name);
+        if !path.exists() { std::fs::write(&path, content)?; }
+    }
+    Ok(dir)
+}
+
+pub fn cmd_learn_list() -> Result<()> {
+    let progress = load_progress();
+    println!("{}", "hl learn: lekcje".bright_magenta().bold());
+    for l in LESSONS {
+        let done = progress.lessons.get(l.id).map_or(0, |p| p.done.min(l.tasks.len()));
+        let mark = if done == l.tasks.len() { "✓".green() } else if done > 0 { "…".yellow() } else { "·".bright_black() };
+        println!("  {} {:<10} {}/{}  {}", mark, l.id.bright_white(), done, l.tasks.len(), l.title);
+    }
+    println!("{}", "  start: hl learn <lekcja>".bright_black());
+    Ok(())
+}
+
+pub fn cmd_learn_reset(id: &str) -> Result<()> {
+    let Some(l) = lesson(id) else { bail!("nieznana lekcja '{}' — lista: hl learn", id) };
+    let mut progress = load_progress();
+    progress.lessons.remove(l.id);
+    save_progress(&progress)?;
+    let dir = sandbox_dir(l.id);
+    if dir.exists() { std::fs::remove_dir_all(&dir)?; }
+    println!("{} lekcja {} od nowa", "hl learn:".bright_magenta().bold(), l.id.bright_white());
+    Ok(())
+}
+
+fn show_task(l: &Lesson, i: usize) {
+    println!("\n{} {}", format!("Zadanie {}/{}:", i + 1, l.tasks.len()).bright_yellow().bold(), l.tasks[i].goal);
+}
+
+/// Sprawdź kolejne zadania od bieżącego; zwraca liczbę zaliczonych po sprawdzeniu
+fn verify(l: &Lesson, dir: &Path, progress: &mut Progress) -> Result<usize> {
+    let entry = progress.lessons.entry(l.id.to_string()).or_default();
+    while entry.done < l.tasks.len() {
+        let task = &l.tasks[entry.done];
+        if let Some(reason) = task.checks.iter().find_map(|c| run_check(c, dir)) {
+            println!("  {} {}", "✗".red(), reason);
+            break;
+        }
+        println!("  {} {}", "✓".green(), task.goal);
+        entry.done += 1;
+    }
+    if entry.done == l.tasks.len() && entry.finished.is_none() {
+        entry.finished = Some(SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0));
+    }
+    let done = entry.done;
+    save_progress(progress)?;
+    Ok(done)
+}
+
+fn open_editor(dir: &Path, l: &Lesson, task: usize) -> Result<()> {
+    let file = l.tasks[task].checks.iter().find_map(|c| match c {
+        Check::FileExists(f) | Check::FileContains(f, _) | Check::CheckPasses(f) | Check::OutputContains(f, _) => Some(*f),
+    }).unwrap_or(".");
+    let editor = std::env::var("VISUAL").or_else(|_| std::env::var("EDITOR")).unwrap_or_else(|_| "vi".into());
+    Command::new("sh").arg("-c").arg(format!("{} \"$1\"", editor)).arg("sh").arg(file).current_dir(dir).status()?;
+    Ok(())
+}
+
+/// hl learn <lekcja> [--check]; Ok(false) — zadanie jeszcze niezaliczone
+pub fn cmd_learn(id: &str, check_only: bool) -> Result<bool> {
+    let Some(l) = lesson(id) else { bail!("nieznana lekcja '{}' — lista: hl learn", id) };
+    let dir = prepare_sandbox(l)?;
+    let mut progress = load_progress();
+    let total = l.tasks.len();
+
+    if check_only {
+        return Ok(verify(l, &dir, &mut progress)? == total);
+    }
+
+    println!("{} {}", l.title.bright_magenta().bold(), format!("({})", l.id).bright_black());
+    println!("{}", l.intro);
+    println!("{} {}", "Piaskownica:".bright_yellow(), dir.display().to_string().bright_white());
+    let mut done = progress.lessons.get(l.id).map_or(0, |p| p.done.min(total));
+    if done == total {
+        println!("\n  {} lekcja ukończona — od nowa: hl learn {} --reset", "✓".green().bold(), l.id);
+        return Ok(true);
+    }
+    show_task(l, done);
+    if !std::io::stdin().is_terminal() {
+        println!("{}", format!("  sprawdź: hl learn {} --check", l.id).bright_black());
+        return Ok(true);
+    }
+
+    loop {
+        eprint!("{} ", "[Enter] sprawdź · h podpowiedź · e edytor · q wyjdź >".bright_black());
+        std::io::stderr().flush().ok();
+        let mut answer = String::new();
+        if std::io::stdin().lock().read_line(&mut answer)? == 0 { return Ok(false); }
+        match answer.trim() {
+            "q" | "quit" => return Ok(false),
+            "h" => {
+                for line in l.tasks[done].hint.lines() { println!("    {}", line.bright_cyan()); }
+            }
+            "e" => open_editor(&dir, l, done)?,
+            _ => {
+                let now = verify(l, &dir, &mut progress)?;
+                if now == total {
+                    println!("\n  {} lekcja {} ukończona!", "✓".green().bold(), l.id.bright_white());
+                    if let Some(next) = LESSONS.iter().skip_while(|x| x.id != l.id).nth(1) {
+                        println!("  Dalej: hl learn {}", next.id.bright_white());
+                    }
+                    return Ok(true);
+                }
+                if now > done { show_task(l, now); }
+                done = now;
+            }
+        }
+    }
+}
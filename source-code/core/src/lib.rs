@@ -8,6 +8,61 @@ pub mod arena;
 pub mod config;
 pub mod env_manager;
 pub mod extern_runner;
+pub mod ci;
+pub mod deploy;
+pub mod schedule;
+pub mod remote;
+pub mod export;
+pub mod serve;
+pub mod notify;
+pub mod history;
+pub mod bugreport;
+pub mod paths;
+pub mod tmp;
+pub mod cache;
+pub mod security;
+pub mod signing;
+pub mod privilege;
+pub mod eval;
+pub mod scaffold;
+pub mod config_check;
+pub mod exit;
+pub mod explain;
+pub mod report;
+pub mod fix;
+pub mod ignore;
+pub mod fetch;
+pub mod dotenv;
+pub mod jobs;
+pub mod plan;
+pub mod compat;
+pub mod lock;
+pub mod net;
+pub mod provenance;
+pub mod inspect;
+pub mod graph;
+pub mod status;
+pub mod revdiff;
+pub mod record;
+pub mod rollout;
+pub mod checkmode;
+pub mod state;
+pub mod rollback;
+pub mod query;
+pub mod desktop;
+pub mod complete;
+pub mod pin;
+pub mod telemetry;
+pub mod libdoc;
+pub mod docsearch;
+pub mod learn;
+pub mod examples;
+pub mod changelog;
+pub mod migrate;
+pub mod verify_install;
+pub mod freeze;
+pub mod project;
+pub mod i18n;
 
 pub use hl_parser::{
     ast, lexer, parser, gen, shebang,
@@ -51,14 +106,14 @@ pub fn exec_nodes_pub(nodes: &[Node], env: &mut Env) -> Result<executor::ExecRes
 
 pub use env::Value;
 pub use executor::ExecResult;
-pub use diagnostics::{Diag, DiagLevel, DiagRenderer, DiagSummary, Span, lint_source};
+pub use diagnostics::{Diag, DiagLevel, DiagRenderer, DiagSummary, Fix, Span, lint_source};
 pub use libs::{cmd_lib_list, cmd_lib_install, cmd_lib_remove, cmd_clean_cache};
 pub use diagnostics::lint_gen;
 pub use arena::{Arena, ArenaContext, ArenaStats};
 pub use config::{
     HlConfig, load_config, save_config, config_path,
     set_active_env, clear_active_env, get_active_env,
-    envs_base_dir, global_libs_dir,
+    envs_base_dir, global_libs_dir, load_hk_file,
 };
 pub use env_manager::{
     HlEnv,
@@ -66,3 +121,32 @@ pub use env_manager::{
     cmd_env_remove, cmd_env_list, cmd_env_status, cmd_env_help,
 };
 pub use extern_runner::exec_extern_def;
+pub use ci::cmd_ci_init;
+pub use deploy::{SystemdOptions, cmd_deploy_systemd};
+pub use schedule::{cmd_schedule_add, cmd_schedule_list, cmd_schedule_remove};
+pub use remote::{run_remote, load_hosts_file, print_remote_summary, RemoteResult};
+pub use export::{DockerExportOptions, cmd_export_docker};
+pub use serve::cmd_serve;
+pub use notify::{notify_run_finished, RunSummary};
+pub use history::{record_run, cmd_history_list, cmd_history_show, cmd_history_rerun, HistoryEntry};
+pub use bugreport::{cmd_bug_report, install_panic_hook};
+pub use paths::{cmd_paths, ensure_layout};
+pub use tmp::{cmd_clean_temp, parse_age, run_temp_dir};
+pub use cache::{cmd_cache_info, cmd_cache_clean, cmd_cache_verify, cmd_cache_gc};
+pub use security::{security_lint, load_policy, count_denied, SecurityPolicy, parse_manifest, manifest_violations, goroutine_count, Manifest, CmdStep};
+pub use signing::{cmd_sign, cmd_verify, cmd_trust_add, cmd_trust_list, verify_signature, signed_only};
+pub use privilege::{sudo_preflight, sudo_commands, SudoSession};
+pub use eval::eval_source;
+pub use scaffold::{cmd_new, NewKind};
+pub use config_check::{cmd_config_edit, cmd_config_get, cmd_config_list, cmd_config_set, cmd_config_validate, validate_config_source};
+pub use exit::{ErrorClass, classified, exit_code_for};
+pub use explain::{cmd_explain, explanation, Explanation};
+pub use report::{CheckRenderer, DiagFormat, diags_to_json, diags_to_sarif, files_to_sarif, github_annotation};
+pub use fix::{apply_fixes, fix_file, fixable_diags, migrate_file};
+pub use ignore::{IgnoreRules, project_hl_files, IGNORE_FILE};
+pub use fetch::{is_remote_spec, parse_remote_spec, fetch_remote, review_remote, RemoteSpec};
+pub use dotenv::{apply_run_vars, load_project_env, parse_dotenv, parse_var_arg};
+pub use jobs::{job_records, JobRecord};
+pub use compat::check_compat;
+pub use lock::{lock_state, lock_state_shared, set_no_wait, StateLock};
+pub use provenance::{cmd_verify_artifact, write_provenance, provenance_path, Provenance};
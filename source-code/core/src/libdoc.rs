@@ -0,0 +1,198 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use serde::Serialize;
+use std::path::Path;
+
+// ── Dokumentacja bibliotek — hl docs lib <nazwa> ──────────────────────────────
+//
+// Konwencja komentarzy w bibliotece .hl (tak są opisane main-libs/):
+//
+//   ;;; std/logging.hl — Logi z poziomem i czasem      tytuł (pierwsza linia ;;;)
+//   ;;; Dalsze linie ;;; — opis biblioteki            ścieżki (/usr/…) są pomijane
+//   ;;; Przyklad:                                      od tej linii — przykład użycia
+//   ;;;   # <std/logging>
+//   // curl                                            zależność systemowa
+//
+//   ;; Opis zmiennej lub funkcji — linie ;; tuż nad `% ZMIENNA` / `: nazwa def`
+//   : log_info def
+//       ;; % _log_msg = tekst && -- log_info          ;; na początku ciała — użycie
+//   done
+//
+// Nazwy zaczynające się od `_` są prywatne i nie trafiają do dokumentacji.
+// Wynik: tekst na terminal (albo strona w TUI hl-docs) lub markdown.
+
+#[derive(Debug, Default, Serialize)]
+pub struct LibDoc {
+    pub name:     String,
+    pub source:   String,
+    pub title:    String,
+    pub about:    Vec<String>,
+    pub requires: Vec<String>,
+    pub vars:     Vec<VarDoc>,
+    pub funcs:    Vec<FuncDoc>,
+    pub example:  Vec<String>,
+}
+
+#[derive(Debug, Serialize)]
+pub struct VarDoc {
+    pub name:  String,
+    pub value: String,
+    pub doc:   Vec<String>,
+}
+
+#[derive(Debug, Serialize)]
+pub struct FuncDoc {
+    pub name:  String,
+    pub doc:   Vec<String>,
+    pub usage: Vec<String>,
+}
+
+fn is_example_marker(s: &str) -> bool {
+    matches!(s.trim().trim_end_matches(':').to_lowercase().as_str(), "przyklad" | "przykład" | "example" | "uzycie" | "użycie")
+}
+
+/// `: nazwa def` / `:: nazwa <rozmiar> def` → nazwa
+fn func_name(line: &str) -> Option<&str> {
+    let rest = line.strip_prefix(':')?.trim_start_matches(':');
+    let mut words = rest.split_whitespace();
+    let name = words.next()?;
+    (words.last() == Some("def")).then_some(name)
+}
+
+pub fn extract(name: &str, source_label: &str, src: &str) -> LibDoc {
+    let mut doc = LibDoc { name: name.to_string(), source: source_label.to_string(), ..Default::default() };
+    let mut pending: Vec<String> = Vec::new();
+    let mut in_example = false;
+    let mut lines = src.lines().peekable();
+    while let Some(raw) = lines.next() {
+        let line = raw.trim();
+        if let Some(text) = raw.trim_start().strip_prefix(";;;") {
+            let text = text.strip_prefix(' ').unwrap_or(text).trim_end();
+            if in_example {
+                doc.example.push(text.to_string());
+            } else if is_example_marker(text) {
+                in_example = true;
+            } else if doc.title.is_empty() {
+                doc.title = text.split_once(" — ").map_or(text, |(_, t)| t).trim().to_string();
+            } else if !text.starts_with('/') {
+                doc.about.push(text.to_string());
+            }
+            continue;
+        }
+        in_example = false;
+        if let Some(text) = line.strip_prefix(";;") {
+            pending.push(text.trim().to_string());
+            continue;
+        }
+        if let Some(dep) = line.strip_prefix("//") {
+            doc.requires.extend(dep.split_whitespace().map(str::to_string));
+        } else if let Some(var) = line.strip_prefix('%') {
+            if let Some((n, v)) = var.split_once('=') {
+                let n = n.trim();
+                if !n.starts_with('_') {
+                    doc.vars.push(VarDoc { name: n.to_string(), value: v.trim().to_string(), doc: std::mem::take(&mut pending) });
+                }
+            }
+        } else if let Some(f) = func_name(line) {
+            let mut usage = Vec::new();
+            while let Some(next) = lines.peek() {
+                match next.trim().strip_prefix(";;") {
+                    Some(u) if !next.trim().starts_with(";;;") => { usage.push(u.trim().to_string()); lines.next(); }
+                    _ => break,
+                }
+            }
+            // Reszta ciała aż do `done`
+            for body in lines.by_ref() { if body.trim() == "done" { break; } }
+            if !f.starts_with('_') {
+                doc.funcs.push(FuncDoc { name: f.to_string(), doc: std::mem::take(&mut pending), usage });
+            }
+        }
+        pending.clear();
+    }
+    while doc.example.last().is_some_and(|l| l.trim().is_empty()) { doc.example.pop(); }
+    doc
+}
+
+/// Źródło biblioteki: main-libs, std wkompilowane w hl, potem bit / GitHub
+pub fn find_source(name: &str) -> Result<(String, String)> {
+    let bare = name.trim_start_matches("std/").trim_start_matches("main/");
+    let main_dir = Path::new(crate::libs::MAIN_LIBS_DIR);
+    for f in [main_dir.join(format!("{}.hl", bare)), main_dir.join(bare).join("lib.hl")] {
+        if let Ok(src) = std::fs::read_to_string(&f) { return Ok((f.display().to_string(), src)); }
+    }
+    if let Some(src) = crate::libs::std_lib_source(bare) {
+        return Ok((format!("std/{} (hl {})", bare, crate::compat::HL_VERSION), src.to_string()));
+    }
+    if let Some(f) = crate::libs::lib_source_file(name) {
+        return Ok((f.display().to_string(), std::fs::read_to_string(&f)?));
+    }
+    if Path::new(name).extension().and_then(|e| e.to_str()) == Some("hl") && Path::new(name).is_file() {
+        return Ok((name.to_string(), std::fs::read_to_string(name)?));
+    }
+    bail!("biblioteka '{}' nie znaleziona (main-libs, std, bit, GitHub) albo jest wbudowana bez źródła .hl", name)
+}
+
+pub fn render_markdown(doc: &LibDoc) -> String {
+    let mut out = format!("# {}\n\n", doc.name);
+    if !doc.title.is_empty() { out.push_str(&format!("{}\n\n", doc.title)); }
+    for l in &doc.about { out.push_str(&format!("{}\n", l)); }
+    if !doc.about.is_empty() { out.push('\n'); }
+    out.push_str(&format!("Źródło: `{}`\n\n", doc.source));
+    if !doc.requires.is_empty() {
+        out.push_str(&format!("Wymaga: {}\n\n", doc.requires.iter().map(|r| format!("`{}`", r)).collect::<Vec<_>>().join(", ")));
+    }
+    if !doc.example.is_empty() {
+        out.push_str("## Przykład\n\n```\n");
+        for l in &doc.example { out.push_str(&format!("{}\n", l)); }
+        out.push_str("```\n\n");
+    }
+    if !doc.vars.is_empty() {
+        out.push_str("## Zmienne\n\n| Zmienna | Domyślnie | Opis |\n|---|---|---|\n");
+        for v in &doc.vars {
+            out.push_str(&format!("| `{}` | `{}` | {} |\n", v.name, v.value.replace('|', "\\|"), v.doc.join(" ").replace('|', "\\|")));
+        }
+        out.push('\n');
+    }
+    if !doc.funcs.is_empty() {
+        out.push_str("## Funkcje\n\n");
+        for f in &doc.funcs {
+            out.push_str(&format!("### {}\n\n", f.name));
+            for l in &f.doc { out.push_str(&format!("{}\n", l)); }
+            if !f.doc.is_empty() { out.push('\n'); }
+            if !f.usage.is_empty() {
+                out.push_str("```\n");
+                for l in &f.usage { out.push_str(&format!("{}\n", l)); }
+                out.push_str("```\n\n");
+            }
+        }
+    }
+    out
+}
+
+pub fn render_text(doc: &LibDoc) -> String {
+    let mut out = format!("{}  {}\n", doc.name.bright_magenta().bold(), doc.title);
+    out.push_str(&format!("{}\n", doc.source.bright_black()));
+    for l in &doc.about { out.push_str(&format!("{}\n", l)); }
+    if !doc.requires.is_empty() { out.push_str(&format!("{} {}\n", "Wymaga:".bright_yellow(), doc.requires.join(", "))); }
+    if !doc.example.is_empty() {
+        out.push_str(&format!("\n{}\n", "Przykład".bright_yellow().bold()));
+        for l in &doc.example { out.push_str(&format!("  {}\n", l.bright_cyan())); }
+    }
+    if !doc.vars.is_empty() {
+        out.push_str(&format!("\n{}\n", "Zmienne".bright_yellow().bold()));
+        let w = doc.vars.iter().map(|v| v.name.len()).max().unwrap_or(0);
+        for v in &doc.vars {
+            out.push_str(&format!("  {}  = {}\n", format!("{:<w$}", v.name, w = w).bright_white(), v.value));
+            for l in &v.doc { out.push_str(&format!("  {:<w$}    {}\n", "", l.bright_black(), w = w)); }
+        }
+    }
+    if !doc.funcs.is_empty() {
+        out.push_str(&format!("\n{}\n", "Funkcje".bright_yellow().bold()));
+        for f in &doc.funcs {
+            out.push_str(&format!("  {}\n", f.name.bright_green().bold()));
+            for l in &f.doc { out.push_str(&format!("      {}\n", l)); }
+            for l in &f.usage { out.push_str(&format!("      {}\n", l.bright_cyan())); }
+        }
+    }
+    out
+}
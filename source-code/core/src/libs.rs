@@ -1,16 +1,17 @@
 use anyhow::{bail, Result};
+use std::collections::{HashMap, HashSet};
 use std::path::{Path, PathBuf};
 use tracing::info;
+use hl_parser::ast::Node;
 use crate::env::{Env, Value};
+use crate::exit::{classified, ErrorClass};
 
 pub const MAIN_LIBS_DIR: &str = "/usr/lib/HackerOS/Hacker-Lang/main-libs";
 
 // Nowa ścieżka bit libs: ~/.hackeros/hacker-lang/libs/<name>/current/
 // (zamiast starego /usr/lib/HackerOS/Hacker-Lang/bit/<name>.so)
 pub fn bit_base_dir() -> PathBuf {
-    dirs::home_dir()
-    .unwrap_or_else(|| PathBuf::from("/tmp"))
-    .join(".hackeros/hacker-lang/libs")
+    crate::paths::data_dir("libs")
 }
 
 pub fn bit_current_dir(name: &str) -> PathBuf {
@@ -22,10 +23,19 @@ pub enum ImportSource {
     Main    { lib: String, detail: Option<String>, version: Option<String> },
     Bit     { name: String, version: Option<String> },
     GitHub  { path: String, version: Option<String> },
+    /// https://host/lib.hl[#sha256=<hex>]
+    Url     { url: String, sha256: Option<String> },
 }
 
 pub fn parse_import_spec(raw: &str) -> Option<ImportSource> {
     let raw = raw.trim();
+    if raw.starts_with("https://") || raw.starts_with("http://") {
+        let (url, sha256) = match raw.split_once("#sha256=") {
+            Some((u, h)) => (u, Some(h.trim().to_string())),
+            None         => (raw, None),
+        };
+        return Some(ImportSource::Url { url: url.to_string(), sha256 });
+    }
     let (body, version) = if let Some(pos) = raw.rfind(':') {
         let after = &raw[pos + 1..];
         if !after.contains('/') { (&raw[..pos], Some(after.to_string())) } else { (raw, None) }
@@ -48,61 +58,221 @@ pub fn parse_import_spec(raw: &str) -> Option<ImportSource> {
     }
 }
 
-pub fn resolve_import(lib: &str, detail: Option<&str>, env: &mut Env) -> Result<()> {
+pub fn resolve_import(lib: &str, detail: Option<&str>, only: &[String], env: &mut Env) -> Result<()> {
     let lib = lib.trim();
     if lib.starts_with('<') && lib.ends_with('>') {
         let spec = &lib[1..lib.len()-1];
         if let Some(src) = parse_import_spec(spec) {
-            return dispatch_import(src, env);
+            return dispatch_import(src, only, env);
         }
     }
-    if let Some(src) = parse_import_spec(lib) {
-        return dispatch_import(src, env);
+    if let Some(src) = parse_import_spec(lib).or_else(|| bare_bit_import(lib)) {
+        return dispatch_import(src, only, env);
     }
     match lib {
-        "std/net"    | "main/net"    => load_main_lib("net",   detail, env),
-        "std/fs"     | "main/fs"     => load_main_lib("fs",    detail, env),
-        "std/sys"    | "main/sys"    => load_main_lib("sys",   detail, env),
-        "std/str"    | "main/str"    => load_main_lib("str",   detail, env),
-        "std/crypto" | "main/crypto" => load_main_lib("crypto",detail, env),
-        "std/proc"   | "main/proc"   => load_main_lib("proc",  detail, env),
+        "std/net"    | "main/net"    => load_main_lib("net",   detail, only, env),
+        "std/fs"     | "main/fs"     => load_main_lib("fs",    detail, only, env),
+        "std/sys"    | "main/sys"    => load_main_lib("sys",   detail, only, env),
+        "std/str"    | "main/str"    => load_main_lib("str",   detail, only, env),
+        "std/crypto" | "main/crypto" => load_main_lib("crypto",detail, only, env),
+        "std/proc"   | "main/proc"   => load_main_lib("proc",  detail, only, env),
         _ => bail!("Nieznana biblioteka: '{}'", lib),
     }
 }
 
-fn dispatch_import(src: ImportSource, env: &mut Env) -> Result<()> {
+/// `# logging` — nazwa bez przestrzeni to biblioteka bit (`# <bit/logging>`)
+pub(crate) fn bare_bit_import(lib: &str) -> Option<ImportSource> {
+    let valid = !lib.is_empty() && lib.chars().all(|c| c.is_alphanumeric() || c == '_' || c == '-');
+    valid.then(|| ImportSource::Bit { name: lib.to_string(), version: None })
+}
+
+fn dispatch_import(src: ImportSource, only: &[String], env: &mut Env) -> Result<()> {
     match src {
-        ImportSource::Main { lib, detail, .. }  => load_main_lib(&lib, detail.as_deref(), env),
-        ImportSource::Bit  { name, version }     => load_bit_lib(&name, version.as_deref(), env),
-        ImportSource::GitHub { path, version }   => load_github_lib(&path, version.as_deref(), env),
+        ImportSource::Main { lib, detail, version } => {
+            if let Some(v) = version { check_std_version(&lib, &v)?; }
+            load_main_lib(&lib, detail.as_deref(), only, env)
+        }
+        ImportSource::Bit  { name, version }     => load_bit_lib(&name, version.as_deref(), only, env),
+        ImportSource::GitHub { path, version }   => load_github_lib(&path, version.as_deref(), only, env),
+        ImportSource::Url    { url, sha256 }      => {
+            let file = fetch_url_lib(&url, sha256.as_deref())?;
+            let src = {
+                let _lock = crate::lock::lock_state_shared("cache")?;
+                crate::signing::read_trusted_source(&file)?
+            };
+            exec_lib_source(&src, only, &lib_namespace(&url), env)
+        }
+    }
+}
+
+// ── Import wybranych funkcji i przestrzenie nazw ──────────────────────────────
+//
+//   # <bit/logging>::rotate          tylko `rotate` i funkcje, które wywołuje
+//   # <bit/logging>::{rotate, init}
+//   # logging::rotate                nazwa bez przestrzeni = <bit/logging>
+//
+// Każda załadowana funkcja biblioteki jest też dostępna jako
+// `-- <biblioteka>::<funkcja>` (ostatni człon: bit/logging → logging, main/net → net),
+// więc pozostaje osiągalna, gdy skrypt lub inna biblioteka nadpisze krótką nazwę.
+// Kolizje nazw zgłasza hl check (HL0015).
+
+/// Przestrzeń nazw biblioteki — ostatni człon specyfikacji importu
+pub fn lib_namespace(spec: &str) -> String {
+    let spec = spec.trim().trim_start_matches('<').trim_end_matches('>');
+    let name = match parse_import_spec(spec).or_else(|| bare_bit_import(spec)) {
+        Some(ImportSource::Main { lib, .. })   => lib,
+        Some(ImportSource::Bit { name, .. })   => name,
+        Some(ImportSource::GitHub { path, .. }) => path,
+        Some(ImportSource::Url { url, .. })    => url.trim_end_matches(".hl").to_string(),
+        None => spec.to_string(),
+    };
+    name.rsplit('/').next().unwrap_or(&name).to_string()
+}
+
+fn called_functions(nodes: &[Node], out: &mut Vec<String>) {
+    for node in nodes {
+        match node {
+            Node::FuncCall { name, .. } => out.push(name.clone()),
+            Node::RepeatN      { body, .. }
+            | Node::Retry        { body, .. }
+            | Node::Check        { body, .. }
+            | Node::Once         { body, .. }
+            | Node::Undo         { body, .. }
+            | Node::FuncDef      { body, .. }
+            | Node::ArenaFuncDef { body, .. }
+            | Node::Conditional  { body, .. }
+            | Node::IfExpr       { body, .. }
+            | Node::FeatureBlock { body, .. }
+            | Node::ForIn        { body, .. }
+            | Node::WhileLoop    { body, .. }
+            | Node::Goroutine    { body, .. }
+            | Node::ExternDef    { body, .. }
+            | Node::Block        (body)         => called_functions(body, out),
+            Node::MatchExpr { arms, .. } => for arm in arms { called_functions(&arm.body, out) },
+            _ => {}
+        }
     }
 }
 
+/// Węzły biblioteki bez funkcji spoza `only` — zostają wybrane funkcje, funkcje przez
+/// nie wywoływane i kod najwyższego poziomu biblioteki (z funkcjami, których używa)
+pub fn select_functions(nodes: &[Node], only: &[String], ns: &str) -> Result<Vec<Node>> {
+    if only.is_empty() { return Ok(nodes.to_vec()); }
+    let defs: HashMap<&str, &[Node]> = nodes.iter().filter_map(|n| match n {
+        Node::FuncDef { name, body } => Some((name.as_str(), body.as_slice())),
+        _ => None,
+    }).collect();
+    if let Some(missing) = only.iter().find(|n| !defs.contains_key(n.as_str())) {
+        bail!("Biblioteka '{}' nie definiuje funkcji '{}'", ns, missing);
+    }
+    let mut queue: Vec<String> = only.to_vec();
+    let top_level: Vec<Node> = nodes.iter().filter(|n| !matches!(n, Node::FuncDef { .. })).cloned().collect();
+    called_functions(&top_level, &mut queue);
+    let mut keep: HashSet<String> = HashSet::new();
+    while let Some(name) = queue.pop() {
+        let Some(body) = defs.get(name.as_str()) else { continue };
+        if !keep.insert(name) { continue; }
+        called_functions(body, &mut queue);
+    }
+    Ok(nodes.iter().filter(|n| !matches!(n, Node::FuncDef { name, .. } if !keep.contains(name))).cloned().collect())
+}
+
+/// Wykonaj bibliotekę .hl (z ograniczeniem do `only`) i zarejestruj `ns::funkcja`
+fn exec_lib_source(src: &str, only: &[String], ns: &str, env: &mut Env) -> Result<()> {
+    let nodes = select_functions(&hl_parser::parse_source(src)?, only, ns)?;
+    crate::executor::exec_nodes(&nodes, env)?;
+    for node in &nodes {
+        if let Node::FuncDef { name, body } = node {
+            env.define_function(format!("{}::{}", ns, name), body.clone());
+        }
+    }
+    Ok(())
+}
+
+/// Plik .hl biblioteki bez ładowania i pobierania (dla hl check);
+/// None — biblioteka nieznana, niezainstalowana, .so albo wbudowana
+pub fn lib_source_file(spec: &str) -> Option<PathBuf> {
+    lib_source_file_in(spec, Path::new("."))
+}
+
+/// Jak lib_source_file, ale libs/ i vendor/ liczone od katalogu `base` (skryptu)
+pub fn lib_source_file_in(spec: &str, base: &Path) -> Option<PathBuf> {
+    let spec = spec.trim().trim_start_matches('<').trim_end_matches('>');
+    let file = match parse_import_spec(spec).or_else(|| bare_bit_import(spec))? {
+        ImportSource::Main { lib, .. } => {
+            let dir = Path::new(MAIN_LIBS_DIR);
+            [dir.join(format!("{}.hl", lib)), dir.join(&lib).join("lib.hl")].into_iter().find(|p| p.exists())?
+        }
+        ImportSource::Bit { name, .. } => find_bit_lib(&lib_search_path(&name, base), &name)?.1,
+        ImportSource::GitHub { path, .. } => {
+            let dir = github_libs_dir().join(path.replace('/', "__"));
+            ["lib.hl", "mod.hl", "main.hl"].iter().map(|c| dir.join(c)).find(|p| p.exists())?
+        }
+        ImportSource::Url { url, .. } => Some(url_lib_file(&url)).filter(|f| f.exists())?,
+    };
+    (file.extension().and_then(|e| e.to_str()) == Some("hl")).then_some(file)
+}
+
 // ── Main libs — pliki .hl w MAIN_LIBS_DIR ─────────────────────────────────────
+//
+// Zestaw std (http, logging, retry) jest też wkompilowany w hl z main-libs/ —
+// gdy pliku nie ma w MAIN_LIBS_DIR, używana jest wersja z toolchainu.
+// Wersja std to wersja hl: `# <std/http:1>` wymaga hl 1.x, `# <std/http:1.2>` — 1.2.x.
+
+const STD_LIBS: &[(&str, &str)] = &[
+    ("http",    include_str!("../../../main-libs/http.hl")),
+    ("logging", include_str!("../../../main-libs/logging.hl")),
+    ("retry",   include_str!("../../../main-libs/retry.hl")),
+];
+
+/// Źródło biblioteki std wkompilowanej w hl
+pub(crate) fn std_lib_source(lib: &str) -> Option<&'static str> {
+    STD_LIBS.iter().find(|(name, _)| *name == lib).map(|(_, src)| *src)
+}
+
+fn check_std_version(lib: &str, want: &str) -> Result<()> {
+    let have: Vec<&str> = crate::compat::HL_VERSION.split('.').collect();
+    let want_parts: Vec<&str> = want.trim().trim_start_matches('v').split('.').collect();
+    if want_parts.iter().any(|p| p.parse::<u64>().is_err()) {
+        bail!("std/{}: nieprawidłowa wersja '{}' — oczekiwano np. 1 albo 1.2", lib, want);
+    }
+    if have.len() < want_parts.len() || have[..want_parts.len()] != want_parts[..] {
+        return Err(classified(ErrorClass::Dependency, format!(
+            "std/{}:{} — biblioteki std mają wersję toolchainu, zainstalowany hl {}", lib, want, crate::compat::HL_VERSION)));
+    }
+    Ok(())
+}
 
-fn load_main_lib(lib: &str, detail: Option<&str>, env: &mut Env) -> Result<()> {
+fn load_main_lib(lib: &str, detail: Option<&str>, only: &[String], env: &mut Env) -> Result<()> {
     let libs_dir = Path::new(MAIN_LIBS_DIR);
     let hl_file  = libs_dir.join(format!("{}.hl", lib));
     let dir_file = libs_dir.join(lib).join("lib.hl");
 
     if hl_file.exists() {
         info!("Laduje main lib '{}' z {:?}", lib, hl_file);
-        let src   = std::fs::read_to_string(&hl_file)?;
-        let nodes = hl_parser::parse_source(&src)?;
-        crate::executor::exec_nodes(&nodes, env)?;
+        let src = std::fs::read_to_string(&hl_file)?;
+        exec_lib_source(&src, only, lib, env)?;
         eprintln!("\x1b[36m[hl main]\x1b[0m Zaladowano main/{}", lib);
         return Ok(());
     }
     if dir_file.exists() {
         info!("Laduje main lib '{}' z {:?}", lib, dir_file);
-        let src   = std::fs::read_to_string(&dir_file)?;
-        let nodes = hl_parser::parse_source(&src)?;
-        crate::executor::exec_nodes(&nodes, env)?;
+        let src = std::fs::read_to_string(&dir_file)?;
+        exec_lib_source(&src, only, lib, env)?;
         eprintln!("\x1b[36m[hl main]\x1b[0m Zaladowano main/{}", lib);
         return Ok(());
     }
 
+    if let Some(src) = std_lib_source(lib) {
+        exec_lib_source(src, only, lib, env)?;
+        eprintln!("\x1b[36m[hl main]\x1b[0m Zaladowano std/{} (hl {})", lib, crate::compat::HL_VERSION);
+        return Ok(());
+    }
+
     // Builtin fallback
+    if !only.is_empty() {
+        bail!("main/{}: wbudowana biblioteka nie udostępnia funkcji — import `::{}` niemożliwy", lib, only.join(", "));
+    }
     match lib {
         "net"          => load_builtin_net(detail, env),
         "fs"           => load_builtin_fs(detail, env),
@@ -123,98 +293,311 @@ fn load_main_lib(lib: &str, detail: Option<&str>, env: &mut Env) -> Result<()> {
     }
 }
 
-// ── Bit libs — ~/.hackeros/hacker-lang/libs/<name>/current/ ──────────────────
+// ── Bit libs — ścieżka wyszukiwania ─────────────────────────────────────────
 //
-// Nowa struktura (zamiast /usr/lib/HackerOS/Hacker-Lang/bit/):
-//   ~/.hackeros/hacker-lang/libs/<name>/current/  ← symlink do <name>/<commit>/
+// `# <bit/nazwa>` szuka katalogu biblioteki w kolejności:
+//   1. <katalog skryptu>/libs/<nazwa>/    (biblioteki projektu)
+//   2. <katalog skryptu>/vendor/<nazwa>/  (vendorowane zależności)
+//   3. $HL_LIB_PATH, potem [paths] lib_path z config.hk (katalogi rozdzielone `:`)
+//   4. ~/.hackeros/hacker-lang/libs/<nazwa>/current/   ← symlink do <nazwa>/<commit>/ (bit)
+//   5. /usr/share/hacker-lang/libs/<nazwa>/            (systemowe)
 //
-// Szuka w kolejności:
-//   1. current/lib.hl     (biblioteka HL)
-//   2. current/<name>.hl  (plik z nazwą pakietu)
-//   3. current/<name>.so  (biblioteka natywna)
-//
-// Jeśli nie zainstalowany → instrukcja instalacji przez bit
+// Katalog skryptu to katalog HL_SCRIPT — `hl run sub/x.hl` z innego katalogu
+// bierze sub/libs/, nie ./libs/.
+// W katalogu szuka: lib.hl, <nazwa>.hl, main.hl, mod.hl, a na końcu <nazwa>.so.
+// Z --verbose wypisuje, skąd biblioteka została rozwiązana.
 
-fn load_bit_lib(name: &str, _version: Option<&str>, env: &mut Env) -> Result<()> {
-    let current_dir = bit_current_dir(name);
+pub const SYSTEM_LIBS_DIR: &str = "/usr/share/hacker-lang/libs";
 
-    if !current_dir.exists() {
-        // Sprawdź czy pakiet istnieje w repo (online check byłby zbyt wolny — pomijamy)
-        bail!(
-            "Biblioteka bit '{}' nie jest zainstalowana.\n\
+/// Katalog pliku źródłowego; "." dla ścieżek bez katalogu i pustego HL_SCRIPT
+pub fn source_dir(file: &Path) -> &Path {
+    file.parent().filter(|d| !d.as_os_str().is_empty()).unwrap_or(Path::new("."))
+}
+
+pub fn lib_search_path(name: &str, base: &Path) -> Vec<PathBuf> {
+    let mut dirs = vec![
+        base.join("libs").join(name),
+        base.join("vendor").join(name),
+    ];
+    let extra = std::env::var("HL_LIB_PATH").ok().into_iter()
+        .chain(crate::config::load_config().get("paths", "lib_path").map(str::to_string));
+    for list in extra {
+        dirs.extend(list.split(':').filter(|p| !p.is_empty()).map(|p| PathBuf::from(p).join(name)));
+    }
+    dirs.push(bit_current_dir(name));
+    dirs.push(PathBuf::from(SYSTEM_LIBS_DIR).join(name));
+    dirs
+}
+
+fn find_lib_entry(dir: &Path, name: &str) -> Option<PathBuf> {
+    [
+        dir.join("lib.hl"),
+        dir.join(format!("{}.hl", name)),
+        dir.join("main.hl"),
+        dir.join("mod.hl"),
+        dir.join(format!("{}.so", name)),
+    ].into_iter().find(|p| p.exists())
+}
+
+/// Katalog i plik wejściowy biblioteki bit — pierwszy trafiony z `search`
+pub(crate) fn find_bit_lib(search: &[PathBuf], name: &str) -> Option<(PathBuf, PathBuf)> {
+    search.iter()
+        .filter(|d| d.is_dir())
+        .find_map(|d| find_lib_entry(d, name).map(|e| (d.clone(), e)))
+}
+
+fn load_bit_lib(name: &str, _version: Option<&str>, only: &[String], env: &mut Env) -> Result<()> {
+    let script = env.get_var_str("HL_SCRIPT");
+    let search = lib_search_path(name, source_dir(Path::new(&script)));
+    let Some((dir, entry)) = find_bit_lib(&search, name) else {
+        let tried: String = search.iter().map(|d| format!("\n  - {}", d.display())).collect();
+        if !bit_current_dir(name).exists() {
+            return Err(classified(ErrorClass::Dependency, format!(
+                "Biblioteka bit '{}' nie jest zainstalowana.\n\
+Sprawdzone ścieżki:{}\n\
 \n\
 Aby zainstalować:\n\
 \x1b[32m  bit install {}\x1b[0m\n\
 \n\
 Jeśli pakiet nie istnieje w repozytorium:\n\
 \x1b[32m  bit search {}\x1b[0m",
-name, name, name
-        );
-    }
-
-    // Szukaj pliku .hl do załadowania
-    let candidates = [
-        current_dir.join("lib.hl"),
-        current_dir.join(format!("{}.hl", name)),
-        current_dir.join("main.hl"),
-        current_dir.join("mod.hl"),
-    ];
-
-    for candidate in &candidates {
-        if candidate.exists() {
-            info!("Laduje bit lib '{}' z {:?}", name, candidate);
-            let src   = std::fs::read_to_string(candidate)?;
-            let nodes = hl_parser::parse_source(&src)?;
-            crate::executor::exec_nodes(&nodes, env)?;
-            eprintln!("\x1b[35m[hl bit]\x1b[0m Zaladowano bit/{}", name);
-
-            // Ustaw zmienne informacyjne
-            let prefix = name.to_uppercase().replace('-', "_");
-            env.set_var(&format!("BIT_{}_LOADED", prefix), Value::Bool(true));
-            env.set_var(&format!("BIT_{}_PATH", prefix),
-                        Value::String(current_dir.display().to_string()));
-            return Ok(());
+name, tried, name, name
+            )));
         }
+        bail!(
+            "Biblioteka bit '{}': brak pliku lib.hl/{}.hl/main.hl/mod.hl/{}.so\n\
+Sprawdzone ścieżki:{}\n\
+Spróbuj: bit upgrade {}",
+name, name, name, tried, name
+        );
+    };
+    info!("bit/{} rozwiązane z {:?}", name, entry);
+    if let Some(notice) = bit_package_notice(name) {
+        eprintln!("\x1b[33m[hl bit]\x1b[0m {}", notice);
     }
 
-    // Biblioteka natywna .so
-    let so_path = current_dir.join(format!("{}.so", name));
-    if so_path.exists() {
-        let prefix = name.to_uppercase().replace('-', "_");
+    let prefix = name.to_uppercase().replace('-', "_");
+    if entry.extension().and_then(|e| e.to_str()) == Some("so") {
+        // Biblioteka natywna .so
+        if !only.is_empty() { bail!("bit/{}: import wybranych funkcji wymaga biblioteki .hl (jest .so)", name); }
         env.set_var(&format!("BIT_{}_LOADED", prefix), Value::Bool(true));
-        env.set_var(&format!("BIT_{}_PATH", prefix),
-                    Value::String(so_path.display().to_string()));
+        env.set_var(&format!("BIT_{}_PATH", prefix), Value::String(entry.display().to_string()));
         eprintln!("\x1b[35m[hl bit]\x1b[0m Zaladowano bit/{} (.so)", name);
         return Ok(());
     }
 
-    bail!(
-        "Biblioteka bit '{}' zainstalowana w {:?} ale brak pliku lib.hl/{}.hl/main.hl/{}.so\n\
-Spróbuj: bit upgrade {}",
-name, current_dir, name, name, name
-    );
+    let src = crate::signing::read_trusted_source(&entry)?;
+    exec_lib_source(&src, only, name, env)?;
+    eprintln!("\x1b[35m[hl bit]\x1b[0m Zaladowano bit/{}", name);
+
+    // Ustaw zmienne informacyjne
+    env.set_var(&format!("BIT_{}_LOADED", prefix), Value::Bool(true));
+    env.set_var(&format!("BIT_{}_PATH", prefix), Value::String(dir.display().to_string()));
+    Ok(())
+}
+
+/// Status pakietu z listy bit (cache/repo-list.json): `deprecated` / `yanked`
+/// (true lub powód) i opcjonalny `replacement`. None — pakiet aktualny lub brak listy.
+pub fn bit_package_notice(name: &str) -> Option<String> {
+    let src   = std::fs::read_to_string(crate::paths::cache_dir().join("repo-list.json")).ok()?;
+    let repo: serde_json::Value = serde_json::from_str(&src).ok()?;
+    let entry = repo.get(name)?;
+    let flag  = |key: &str, default: &str| match entry.get(key) {
+        Some(serde_json::Value::Bool(true))  => Some(default.to_string()),
+        Some(serde_json::Value::String(s))   => Some(s.clone()),
+        _ => None,
+    };
+    let mut notice = match (flag("yanked", "wycofany"), flag("deprecated", "przestarzały")) {
+        (Some(why), _)    => format!("bit/{} jest wycofany (yanked): {}", name, why),
+        (None, Some(why)) => format!("bit/{} jest przestarzały: {}", name, why),
+        (None, None)      => return None,
+    };
+    if let Some(repl) = entry.get("replacement").and_then(|r| r.as_str()) {
+        notice.push_str(&format!(" — zamiennik: bit install {}", repl));
+    }
+    Some(notice)
 }
 
 // ── GitHub libs ───────────────────────────────────────────────────────────────
 
-fn load_github_lib(path: &str, version: Option<&str>, env: &mut Env) -> Result<()> {
+fn load_github_lib(path: &str, version: Option<&str>, only: &[String], env: &mut Env) -> Result<()> {
     let lib_dir = github_libs_dir().join(path.replace('/', "__"));
 
-    if !lib_dir.exists() {
-        if which::which("git").is_err() { bail!("git nie jest zainstalowany"); }
-        std::fs::create_dir_all(&lib_dir)?;
-        let url = format!("https://github.com/{}.git", path);
-        let mut cmd = std::process::Command::new("git");
-        cmd.args(["clone", "--depth=1"]);
-        if let Some(v) = version { cmd.args(["--branch", v]); }
-        cmd.args([&url, lib_dir.to_str().unwrap_or("/tmp/hl_lib")]);
-        if !cmd.status()?.success() { bail!("Nie mozna pobrac github: {}", path); }
+    if !github_lib_complete(&lib_dir) {
+        let _lock = crate::lock::lock_state("libs")?;
+        if !github_lib_complete(&lib_dir) { install_github_lib(path, version, &lib_dir)?; }
+    }
+
+    load_from_dir(&lib_dir, None, only, env, path)
+}
+
+// Instalacja jest atomowa: git clone trafia do `<katalog>.part-<pid>` obok celu
+// i dopiero kompletny klon jest przenoszony na miejsce przez rename. Przerwane
+// pobranie nie zostawia biblioteki, którą hl uznałby za zainstalowaną.
+
+/// Zainstalowana biblioteka github: repozytorium git z plikiem wejściowym
+fn github_lib_complete(dir: &Path) -> bool {
+    dir.join(".git").is_dir() && ["lib.hl", "mod.hl", "main.hl"].iter().any(|c| dir.join(c).is_file())
+}
+
+/// Uszkodzony katalog → `<katalog>.corrupt` (poprzednia kwarantanna jest nadpisywana)
+fn quarantine(dir: &Path) -> Result<()> {
+    use colored::Colorize;
+    let target = PathBuf::from(format!("{}.corrupt", dir.display()));
+    let _ = std::fs::remove_dir_all(&target);
+    std::fs::rename(dir, &target)?;
+    eprintln!("{} niekompletna biblioteka przeniesiona do {}", "hl:".yellow(), target.display());
+    Ok(())
+}
+
+/// Wymaga blokady `libs`
+fn install_github_lib(path: &str, version: Option<&str>, lib_dir: &Path) -> Result<()> {
+    if which::which("git").is_err() { return Err(classified(ErrorClass::Toolchain, "git nie jest zainstalowany")); }
+    if lib_dir.exists() { quarantine(lib_dir)?; }
+    let part = PathBuf::from(format!("{}.part-{}", lib_dir.display(), std::process::id()));
+    let _ = std::fs::remove_dir_all(&part);
+    if let Some(parent) = lib_dir.parent() { std::fs::create_dir_all(parent)?; }
+
+    let url = format!("https://github.com/{}.git", path);
+    let mut cmd = crate::net::git_command(&url);
+    cmd.args(["clone", "-q", "--depth=1"]);
+    if let Some(v) = version { cmd.args(["--branch", v]); }
+    cmd.arg(&url).arg(&part);
+    if !cmd.status()?.success() {
+        let _ = std::fs::remove_dir_all(&part);
+        return Err(classified(ErrorClass::Dependency, format!("Nie mozna pobrac github: {}", path)));
+    }
+    if !github_lib_complete(&part) {
+        let _ = std::fs::remove_dir_all(&part);
+        return Err(classified(ErrorClass::Dependency,
+            format!("github: {} nie zawiera lib.hl, mod.hl ani main.hl", path)));
+    }
+    std::fs::rename(&part, lib_dir)?;
+    Ok(())
+}
+
+// ── Biblioteki z URL ──────────────────────────────────────────────────────────
+//
+//   # <https://example.com/hl/net-utils.hl#sha256=9f86d081…>
+//
+// Plik trafia do cache (kategoria `scripts`, podkatalog libs/) i jest ładowany jak
+// biblioteka .hl. Przypięty `#sha256=` jest sprawdzany przy każdym imporcie —
+// niezgodność usuwa plik z cache i przerywa skrypt (exit 126). Bez przypięcia
+// hl check ostrzega (HL0021); `hl lib pin plik.hl` dopisuje hashe. Import przez
+// `http://` bez `#sha256=` jest odrzucany — treść nie ma żadnej ochrony w drodze.
+
+fn url_lib_file(url: &str) -> PathBuf {
+    let name = url.rsplit('/').next().filter(|n| n.ends_with(".hl")).unwrap_or("lib.hl");
+    crate::fetch::scripts_cache_dir().join("libs").join(crate::fetch::url_key(url)).join(name)
+}
+
+/// Plik tymczasowy pobierania — unikalny na proces, obok docelowego
+fn download_part(file: &Path) -> PathBuf {
+    let name = file.file_name().and_then(|n| n.to_str()).unwrap_or("lib.hl");
+    file.with_file_name(format!(".{}.{}.download", name, std::process::id()))
+}
+
+pub fn sha256_file(path: &Path) -> Result<String> {
+    if which::which("sha256sum").is_err() { return Err(classified(ErrorClass::Toolchain, "sha256sum nie jest zainstalowany")); }
+    let out = std::process::Command::new("sha256sum").arg(path).output()?;
+    if !out.status.success() { bail!("sha256sum {}: blad", path.display()); }
+    Ok(String::from_utf8_lossy(&out.stdout).split_whitespace().next().unwrap_or("").to_string())
+}
+
+/// Plik biblioteki z URL — z cache albo pobrany; sprawdza `sha256`, jeśli podany
+pub fn fetch_url_lib(url: &str, sha256: Option<&str>) -> Result<PathBuf> {
+    if url.starts_with("http://") && sha256.is_none() {
+        return Err(classified(ErrorClass::Denied, format!(
+            "{}: import przez http:// wymaga przypiętego #sha256= (albo uzyj https://)", url)));
+    }
+    let file = download_url_lib(url)?;
+    if let Some(pin) = sha256 {
+        let actual = sha256_file(&file)?;
+        if !actual.eq_ignore_ascii_case(pin) {
+            let _ = std::fs::remove_file(&file);
+            return Err(classified(ErrorClass::Denied, format!(
+                "{}: sha256 {} nie zgadza sie z przypietym {}", url, actual, pin)));
+        }
+    }
+    Ok(file)
+}
+
+/// Pobierz bibliotekę z URL do cache (bez sprawdzania hasha)
+fn download_url_lib(url: &str) -> Result<PathBuf> {
+    let file = url_lib_file(url);
+    let _lock = crate::lock::lock_state("cache")?;
+    crate::serve::record_event(if file.exists() { crate::serve::EVENT_CACHE_HIT } else { crate::serve::EVENT_CACHE_MISS });
+    if !file.exists() {
+        if which::which("curl").is_err() { return Err(classified(ErrorClass::Toolchain, "curl nie jest zainstalowany")); }
+        std::fs::create_dir_all(file.parent().unwrap_or(Path::new(".")))?;
+        let part = download_part(&file);
+        if !crate::net::curl_download(url, &part)? {
+            let _ = std::fs::remove_file(&part);
+            return Err(classified(ErrorClass::Dependency, format!("Nie mozna pobrac {}", url)));
+        }
+        std::fs::rename(&part, &file)?;
+    }
+    Ok(file)
+}
+
+/// hl lib pin — dopisz `#sha256=` do importów z URL bez przypięcia; zwraca ich liczbę
+pub fn pin_url_imports(script: &Path) -> Result<usize> {
+    let source = std::fs::read_to_string(script)?;
+    prefetch_url_libs(&source)?;
+    let mut pinned = 0;
+    let mut lines = Vec::new();
+    for line in source.lines() {
+        let trimmed = line.trim();
+        let spec = trimmed.strip_prefix('#').filter(|_| !trimmed.starts_with("#!"))
+            .and_then(hl_parser::parse_import_line)
+            .and_then(|d| parse_import_spec(&d.spec));
+        if let Some(ImportSource::Url { url, sha256: None }) = spec {
+            let hash = sha256_file(&download_url_lib(&url)?)?;
+            lines.push(line.replacen(&url, &format!("{}#sha256={}", url, hash), 1));
+            pinned += 1;
+        } else {
+            lines.push(line.to_string());
+        }
+    }
+    if pinned > 0 {
+        let mut out = lines.join("\n");
+        if source.ends_with('\n') { out.push('\n'); }
+        std::fs::write(script, out)?;
+    }
+    Ok(pinned)
+}
+
+/// Pobierz równolegle biblioteki z URL, których nie ma jeszcze w cache
+pub fn prefetch_url_libs(source: &str) -> Result<()> {
+    let mut items: Vec<(String, PathBuf)> = Vec::new();
+    for line in source.lines() {
+        let trimmed = line.trim();
+        let spec = trimmed.strip_prefix('#').filter(|_| !trimmed.starts_with("#!"))
+            .and_then(hl_parser::parse_import_line)
+            .and_then(|d| parse_import_spec(&d.spec));
+        if let Some(ImportSource::Url { url, .. }) = spec {
+            let file = url_lib_file(&url);
+            if !file.exists() && !items.iter().any(|(u, _)| *u == url) { items.push((url, file)); }
+        }
     }
+    if items.len() < 2 { return Ok(()); }
+    if which::which("curl").is_err() { return Err(classified(ErrorClass::Toolchain, "curl nie jest zainstalowany")); }
 
-    load_from_dir(&lib_dir, None, env, path)
+    let _lock = crate::lock::lock_state("cache")?;
+    let staged: Vec<(String, PathBuf)> = items.iter().map(|(url, file)| (url.clone(), download_part(file))).collect();
+    for (_, part) in &staged {
+        if let Some(dir) = part.parent() { std::fs::create_dir_all(dir)?; }
+    }
+    // Nieudane pobrania zostają pominięte — fetch_url_lib zgłosi je z właściwym błędem
+    for (((_, part), (_, file)), ok) in staged.iter().zip(&items).zip(crate::net::download_all(&staged)) {
+        if matches!(ok, Ok(true)) { std::fs::rename(part, file)?; } else { let _ = std::fs::remove_file(part); }
+    }
+    Ok(())
 }
 
-fn load_from_dir(dir: &Path, detail: Option<&str>, env: &mut Env, name: &str) -> Result<()> {
+fn load_from_dir(dir: &Path, detail: Option<&str>, only: &[String], env: &mut Env, name: &str) -> Result<()> {
+    // Katalog github: — hl cache clean/gc i hl lib remove usuwają go pod blokadą libs
+    let lock = crate::lock::lock_state_shared("libs")?;
     let main_file = if let Some(d) = detail {
         let f = dir.join(format!("{}.hl", d));
         if f.exists() { f } else { dir.join(d).join("mod.hl") }
@@ -223,10 +606,9 @@ fn load_from_dir(dir: &Path, detail: Option<&str>, env: &mut Env, name: &str) ->
         .unwrap_or_else(|| dir.join("lib.hl"))
     };
     if !main_file.exists() { bail!("Brak pliku wejsciowego dla '{}' w {:?}", name, dir); }
-    let src   = std::fs::read_to_string(&main_file)?;
-    let nodes = hl_parser::parse_source(&src)?;
-    crate::executor::exec_nodes(&nodes, env)?;
-    Ok(())
+    let src = crate::signing::read_trusted_source(&main_file)?;
+    drop(lock);
+    exec_lib_source(&src, only, name.rsplit('/').next().unwrap_or(name), env)
 }
 
 pub fn github_libs_dir() -> PathBuf {
@@ -235,8 +617,7 @@ pub fn github_libs_dir() -> PathBuf {
 }
 
 pub fn hl_cache_dir() -> PathBuf {
-    dirs::home_dir().unwrap_or_else(|| PathBuf::from("/tmp"))
-    .join(".hackeros/hacker-lang/cache")
+    crate::paths::cache_dir()
 }
 
 // ── Builtin fallbacks ─────────────────────────────────────────────────────────
@@ -342,6 +723,9 @@ pub fn cmd_lib_list() {
         ("main/json","Parser JSON"),
         ("main/hk-parser","Parser plikow .hk (HackerOS Config)"),
         ("main/hacker","Parser plikow .hacker (v1/v2/v3)"),
+        ("std/http","HTTP: get, download, post_json, status (curl)"),
+        ("std/logging","Logi z poziomem: log_info, log_warn, LOG_FILE"),
+        ("std/retry","Ponawianie komendy ze zmiennej z odstepem"),
     ] {
         println!("  {} {}", format!("# <{}>", name).bright_green(), desc.bright_black());
     }
@@ -355,20 +739,24 @@ pub fn cmd_lib_list() {
 pub fn cmd_lib_install(repo: &str) {
     use colored::Colorize;
     let lib_dir = github_libs_dir().join(repo.replace('/', "__"));
-    if lib_dir.exists() { println!("{} '{}' juz zainstalowana.", "✓".green(), repo); return; }
-    let url = format!("https://github.com/{}.git", repo);
-    let status = std::process::Command::new("git")
-    .args(["clone","--depth=1",&url,lib_dir.to_str().unwrap()])
-    .status();
-    match status {
-        Ok(s) if s.success() => println!("{} Zainstalowano: {}", "✓".green(), repo),
-        _ => eprintln!("{} Blad instalacji: {}", "✗".red(), repo)
+    let _lock = match crate::lock::lock_state("libs") {
+        Ok(l)  => l,
+        Err(e) => { eprintln!("{} {}", "✗".red(), e); return; }
+    };
+    if github_lib_complete(&lib_dir) { println!("{} '{}' juz zainstalowana.", "✓".green(), repo); return; }
+    match install_github_lib(repo, None, &lib_dir) {
+        Ok(())  => println!("{} Zainstalowano: {}", "✓".green(), repo),
+        Err(e)  => eprintln!("{} Blad instalacji {}: {}", "✗".red(), repo, e),
     }
 }
 
 pub fn cmd_lib_remove(name: &str) {
     use colored::Colorize;
     let p = github_libs_dir().join(name.replace('/', "__"));
+    let _lock = match crate::lock::lock_state("libs") {
+        Ok(l)  => l,
+        Err(e) => { eprintln!("{} {}", "✗".red(), e); return; }
+    };
     if p.exists() { std::fs::remove_dir_all(&p).unwrap(); println!("{} Usunieto: {}", "✓".green(), name); }
     else { eprintln!("{} Nie znaleziono: {}", "✗".red(), name); }
 }
@@ -376,6 +764,10 @@ pub fn cmd_lib_remove(name: &str) {
 pub fn cmd_clean_cache() {
     use colored::Colorize;
     let cache = hl_cache_dir();
+    let _locks = match crate::lock::lock_state("libs").and_then(|l| Ok((l, crate::lock::lock_state("cache")?))) {
+        Ok(l)  => l,
+        Err(e) => { eprintln!("{} {}", "✗".red(), e); return; }
+    };
     if cache.exists() {
         std::fs::remove_dir_all(&cache).unwrap_or(());
         println!("{} Cache wyczyszczony.", "✓".green());
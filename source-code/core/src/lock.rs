@@ -0,0 +1,94 @@
+use anyhow::Result;
+use colored::Colorize;
+use nix::errno::Errno;
+use nix::fcntl::{Flock, FlockArg};
+use std::fs::{File, OpenOptions};
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::time::{Duration, Instant};
+use crate::exit::{classified, ErrorClass};
+
+// ── Blokady stanu współdzielonego ─────────────────────────────────────────────
+//
+// Dwa równoległe hl mogą jednocześnie pobierać tę samą bibliotekę albo czyścić
+// cache, z którego drugi właśnie czyta. Operacje zapisujące stan współdzielony
+// biorą blokadę flock(2) na pliku meta/locks/<nazwa>.lock:
+//
+//   libs    hl lib install/remove, pobieranie bibliotek github:
+//   cache   skrypty zdalne i biblioteki z URL (oba w cache scripts)
+//   env     tworzenie środowiska i zapis jego bit.lock
+//   history dopisanie uruchomienia do history.jsonl
+//
+// hl cache clean/gc i hl clean usuwają też biblioteki, więc biorą libs, potem
+// cache — zawsze w tej kolejności.
+//
+// Odczyty z tych katalogów biorą blokadę współdzieloną (lock_state_shared) tylko
+// na czas wczytania pliku do pamięci: źródło biblioteki github: (libs), biblioteki
+// z URL i .bc z cache bytecode (cache). Czytelnicy nie blokują siebie nawzajem,
+// a clean/gc czeka, aż skończą. Blokady współdzielonej nie trzyma się w trakcie
+// wykonywania kodu — import w środku mógłby potrzebować tej samej blokady na wyłączność.
+//
+// Zajęta blokada: hl czeka do HL_LOCK_TIMEOUT / [runtime] lock_timeout
+// (domyślnie 5m; 30s, 2m, 1h). `--no-wait` kończy od razu kodem 5.
+// Jądro zwalnia blokadę przy zakończeniu procesu — także po awarii.
+
+static NO_WAIT: AtomicBool = AtomicBool::new(false);
+
+/// `hl --no-wait` — nie czekaj na blokady innych procesów hl
+pub fn set_no_wait(on: bool) {
+    NO_WAIT.store(on, Ordering::Relaxed);
+}
+
+fn lock_timeout() -> Duration {
+    const DEFAULT_MS: u64 = 5 * 60 * 1000;
+    let ms = std::env::var("HL_LOCK_TIMEOUT").ok()
+        .or_else(|| crate::config::load_config().get("runtime", "lock_timeout").map(str::to_string))
+        .and_then(|s| hl_parser::ast::parse_delay_ms(&s))
+        .unwrap_or(DEFAULT_MS);
+    Duration::from_millis(ms)
+}
+
+/// Blokada trzymana do końca zakresu (Drop zwalnia flock)
+pub struct StateLock {
+    _lock: Flock<File>,
+}
+
+/// Weź blokadę `name` na wyłączność (zapis); czeka, gdy trzyma ją inny proces hl
+pub fn lock_state(name: &str) -> Result<StateLock> {
+    acquire(name, FlockArg::LockExclusiveNonblock)
+}
+
+/// Blokada współdzielona `name` (odczyt); czeka tylko na blokadę na wyłączność
+pub fn lock_state_shared(name: &str) -> Result<StateLock> {
+    acquire(name, FlockArg::LockSharedNonblock)
+}
+
+fn acquire(name: &str, arg: FlockArg) -> Result<StateLock> {
+    let dir = crate::paths::data_dir("meta").join("locks");
+    std::fs::create_dir_all(&dir)?;
+    let path = dir.join(format!("{}.lock", name));
+    let mut file = OpenOptions::new().create(true).truncate(false).write(true).open(&path)?;
+
+    let started = Instant::now();
+    let timeout = lock_timeout();
+    let mut announced = false;
+    loop {
+        match Flock::lock(file, arg) {
+            Ok(lock) => return Ok(StateLock { _lock: lock }),
+            Err((f, Errno::EAGAIN)) => file = f,
+            Err((_, e)) => anyhow::bail!("{}: {}", path.display(), e),
+        }
+        if NO_WAIT.load(Ordering::Relaxed) {
+            return Err(classified(ErrorClass::Execution,
+                format!("'{}' jest zablokowane przez inny proces hl (--no-wait)", name)));
+        }
+        if started.elapsed() >= timeout {
+            return Err(classified(ErrorClass::Execution, format!(
+                "'{}' jest zablokowane przez inny proces hl — przekroczono {}s oczekiwania", name, timeout.as_secs())));
+        }
+        if !announced {
+            eprintln!("{} czekam na blokadę '{}' (inny proces hl)…", "hl:".bright_black(), name);
+            announced = true;
+        }
+        std::thread::sleep(Duration::from_millis(100));
+    }
+}
@@ -0,0 +1,111 @@
+use crate::compat::{parse_version, HL_VERSION};
+use crate::diagnostics::{Diag, Fix, Span};
+
+// ── Przestarzała składnia i migracje ──────────────────────────────────────────
+//
+// Tabela DEPRECATIONS opisuje konstrukcje, które hl nadal rozumie, ale które
+// znikną w kolejnej głównej wersji. Każdy wpis ma wersję, od której hl check
+// ostrzega (HL0023), wersję usunięcia i mechaniczne przepisanie linii:
+//
+//   # std/net <- ports     →  # <std/net> | <ports>
+//   # std/sys              →  # <std/sys>
+//   # <virus/hashlib>      →  # <bit/hashlib>
+//   # <community/u/r>      →  # <github/u/r>
+//
+//   hl check --migrate plik.hl   przepisz tylko przestarzałe konstrukcje (kopia w .bak)
+//   hl check --fix plik.hl       wszystkie poprawki, razem z migracjami
+//
+// Wpis ze `since` nowszym niż zainstalowany hl jeszcze nie ostrzega, więc tabelę
+// można uzupełniać z wyprzedzeniem. Przepisania działają na przyciętej linii
+// i są składane po kolei — linia może przejść przez kilka wpisów naraz.
+
+pub struct Deprecation {
+    pub id:      &'static str,
+    pub since:   &'static str,
+    pub removal: &'static str,
+    pub what:    &'static str,
+    /// Linia po migracji albo None, gdy wpis jej nie dotyczy
+    pub rewrite: fn(&str) -> Option<String>,
+}
+
+pub const DEPRECATIONS: &[Deprecation] = &[
+    Deprecation {
+        id: "import-bez-nawiasow", since: "1.0", removal: "2.0",
+        what: "import bez `<...>` (`# lib`, `# lib <- szczegóły`)",
+        rewrite: legacy_import,
+    },
+    Deprecation {
+        id: "przestrzen-virus", since: "1.0", removal: "2.0",
+        what: "przestrzeń nazw `virus/` — teraz `bit/`",
+        rewrite: |line| rename_namespace(line, "virus/", "bit/"),
+    },
+    Deprecation {
+        id: "przestrzen-community", since: "1.0", removal: "2.0",
+        what: "przestrzeń nazw `community/` — teraz `github/`",
+        rewrite: |line| rename_namespace(line, "community/", "github/"),
+    },
+];
+
+/// Treść linii importu (`# ...`, bez `#!`)
+fn import_body(line: &str) -> Option<&str> {
+    let rest = line.strip_prefix('#')?;
+    if rest.starts_with('!') { return None; }
+    let rest = rest.trim();
+    (!rest.is_empty()).then_some(rest)
+}
+
+fn legacy_import(line: &str) -> Option<String> {
+    let body = import_body(line)?;
+    if body.starts_with('<') { return None; }
+    if let Some((spec, detail)) = body.split_once("<-") {
+        let (spec, detail) = (spec.trim(), detail.trim());
+        if spec.is_empty() { return None; }
+        return Some(if detail.is_empty() { format!("# <{}>", spec) } else { format!("# <{}> | <{}>", spec, detail) });
+    }
+    // `lib::funkcja` — wybór funkcji zostaje za nawiasem
+    let (spec, only) = match body.rfind("::") {
+        Some(pos) => (body[..pos].trim_end(), &body[pos..]),
+        None      => (body, ""),
+    };
+    if spec.contains(char::is_whitespace) { return None; }
+    Some(format!("# <{}>{}", spec, only))
+}
+
+fn rename_namespace(line: &str, old: &str, new: &str) -> Option<String> {
+    let body = import_body(line)?;
+    let spec = body.strip_prefix('<')?.strip_prefix(old)?;
+    Some(format!("# <{}{}", new, spec))
+}
+
+/// Wpisy obowiązujące w zainstalowanym hl
+fn active() -> impl Iterator<Item = &'static Deprecation> {
+    let have = parse_version(HL_VERSION).unwrap_or_default();
+    DEPRECATIONS.iter().filter(move |d| parse_version(d.since).is_some_and(|since| have >= since))
+}
+
+/// Ostrzeżenia HL0023 z poprawką — jedna diagnostyka na linię, z całą migracją
+pub fn lint_deprecations(source: &str) -> Vec<Diag> {
+    let mut diags = Vec::new();
+    if !source.contains('#') { return diags; }
+    for (idx, raw_line) in source.lines().enumerate() {
+        let trimmed = raw_line.trim();
+        if !trimmed.starts_with('#') { continue; }
+        let indent = &raw_line[..raw_line.len() - raw_line.trim_start().len()];
+        let mut line = trimmed.to_string();
+        let mut hits: Vec<&Deprecation> = Vec::new();
+        for d in active() {
+            if let Some(new) = (d.rewrite)(&line) { line = new; hits.push(d); }
+        }
+        let Some(first) = hits.first() else { continue };
+        let mut diag = Diag::warning(format!("przestarzała składnia: {}", first.what)).with_code("HL0023")
+            .with_span(Span::new(idx + 1, indent.len() + 1, trimmed.len()))
+            .with_suggestion(format!("zamień na: `{}`", line))
+            .with_note(format!("przestarzałe od hl {}, usunięcie w hl {} ({})", first.since, first.removal, first.id));
+        for d in &hits[1..] {
+            diag = diag.with_note(format!("także: {} ({})", d.what, d.id));
+        }
+        diags.push(diag.with_note("`hl check --migrate` przepisze to automatycznie")
+            .with_fix(Fix::ReplaceLine(idx + 1, format!("{}{}", indent, line))));
+    }
+    diags
+}
@@ -0,0 +1,198 @@
+use anyhow::Result;
+use colored::Colorize;
+use std::io::{IsTerminal, Write};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::sync::atomic::{AtomicU64, Ordering};
+use std::time::Instant;
+use crate::config::load_config;
+
+// ── Sieć: proxy, CA, uwierzytelnianie ─────────────────────────────────────────
+//
+// Każde pobieranie hl (skrypty zdalne, biblioteki z URL i github:) idzie przez
+// curl albo git. Ustawienia z config.hk:
+//
+//   [net]
+//   -> proxy       => http://proxy.firma:3128
+//   -> no_proxy    => localhost,.firma.local
+//   -> ca_bundle   => /etc/ssl/firma-ca.pem
+//   -> client_cert => /etc/ssl/private/hl.pem
+//   -> client_key  => /etc/ssl/private/hl.key
+//   -> tokens      => git.firma.local=FIRMA_TOKEN, pkgs.example.com=PKGS_TOKEN
+//   -> limit_rate  => 1M        limit na jedno pobranie (--limit-rate, HL_LIMIT_RATE)
+//   -> parallel    => 4         ile pobrań naraz przy kilku plikach (domyślnie 4)
+//
+// HTTP(S)_PROXY / NO_PROXY ze środowiska mają pierwszeństwo przed [net].
+// `tokens` to pary host=ZMIENNA — token (Authorization: Bearer) jest czytany
+// ze zmiennej środowiskowej, nigdy nie leży w config.hk. Basic auth: ~/.netrc
+// (curl i git czytają go same). Tokeny nie trafiają do argv — curl dostaje je
+// przez stdin (-K -), git przez GIT_CONFIG_*.
+//
+// Pojedyncze pobranie na terminalu pokazuje licznik curl (prędkość, ETA); przy
+// kilku równoległych każde kończy się linią z rozmiarem i czasem.
+
+const DEFAULT_PARALLEL: usize = 4;
+
+/// `hl --limit-rate` (bajty/s); 0 — nie ustawiono
+static LIMIT_RATE: AtomicU64 = AtomicU64::new(0);
+
+pub fn set_limit_rate(bytes_per_sec: u64) {
+    LIMIT_RATE.store(bytes_per_sec, Ordering::Relaxed);
+}
+
+#[derive(Debug, Default, Clone)]
+pub struct NetConfig {
+    pub proxy:       Option<String>,
+    pub no_proxy:    Option<String>,
+    pub ca_bundle:   Option<String>,
+    pub client_cert: Option<String>,
+    pub client_key:  Option<String>,
+    /// host → nazwa zmiennej środowiskowej z tokenem
+    pub tokens:      Vec<(String, String)>,
+    /// bajty/s na jedno pobranie
+    pub limit_rate:  Option<u64>,
+    pub parallel:    usize,
+}
+
+/// `host=ZMIENNA, host2=ZMIENNA2`
+pub fn parse_tokens(value: &str) -> Result<Vec<(String, String)>, String> {
+    value.split(',').map(str::trim).filter(|p| !p.is_empty()).map(|pair| match pair.split_once('=') {
+        Some((host, var)) if !host.trim().is_empty() && !var.trim().is_empty() =>
+            Ok((host.trim().to_ascii_lowercase(), var.trim().trim_start_matches('$').to_string())),
+        _ => Err(format!("'{}' — oczekiwano host=ZMIENNA", pair)),
+    }).collect()
+}
+
+impl NetConfig {
+    pub fn load() -> Self {
+        let cfg = load_config();
+        let get = |k: &str| cfg.get("net", k).map(str::trim).filter(|v| !v.is_empty()).map(str::to_string);
+        Self {
+            proxy:       get("proxy"),
+            no_proxy:    get("no_proxy"),
+            ca_bundle:   get("ca_bundle"),
+            client_cert: get("client_cert"),
+            client_key:  get("client_key"),
+            tokens:      get("tokens").and_then(|t| parse_tokens(&t).ok()).unwrap_or_default(),
+            limit_rate:  Some(LIMIT_RATE.load(Ordering::Relaxed)).filter(|r| *r > 0)
+                .or_else(|| std::env::var("HL_LIMIT_RATE").ok().or_else(|| get("limit_rate"))
+                    .and_then(|r| crate::cache::parse_size(&r).ok())),
+            parallel:    get("parallel").and_then(|p| p.parse().ok()).filter(|p| *p > 0).unwrap_or(DEFAULT_PARALLEL),
+        }
+    }
+
+    /// Token Bearer dla hosta z URL (zmienna musi być ustawiona)
+    fn token_for(&self, url: &str) -> Option<String> {
+        let host = url_host(url)?;
+        let (_, var) = self.tokens.iter().find(|(h, _)| *h == host)?;
+        std::env::var(var).ok().filter(|t| !t.is_empty())
+    }
+
+    /// Proxy z [net] tylko, gdy środowisko go nie ustawia
+    fn apply_proxy_env(&self, cmd: &mut Command) {
+        let unset = |names: &[&str]| names.iter().all(|n| std::env::var_os(n).is_none());
+        if let Some(p) = &self.proxy {
+            if unset(&["HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"]) {
+                cmd.env("https_proxy", p).env("http_proxy", p);
+            }
+        }
+        if let Some(n) = &self.no_proxy {
+            if unset(&["NO_PROXY", "no_proxy"]) { cmd.env("no_proxy", n); }
+        }
+    }
+}
+
+/// `https://user@host:443/ścieżka` → `host`
+pub fn url_host(url: &str) -> Option<String> {
+    let rest = url.split_once("://")?.1;
+    let authority = rest.split(['/', '?', '#']).next()?;
+    let host = authority.rsplit('@').next()?;
+    let host = host.split(':').next()?;
+    (!host.is_empty()).then(|| host.to_ascii_lowercase())
+}
+
+/// Wartość dla pliku konfiguracyjnego curl (-K)
+fn curl_quote(v: &str) -> String {
+    format!("\"{}\"", v.replace('\\', "\\\\").replace('"', "\\\""))
+}
+
+/// Pobierz `url` do `dest` przez curl z ustawieniami [net]; false — błąd pobierania
+pub fn curl_download(url: &str, dest: &Path) -> Result<bool> {
+    let net = NetConfig::load();
+    curl_run(&net, url, dest, std::io::stderr().is_terminal())
+}
+
+/// Pobierz wiele plików, najwyżej `[net] parallel` naraz; wynik w kolejności `items`
+pub fn download_all(items: &[(String, PathBuf)]) -> Vec<Result<bool>> {
+    let net = NetConfig::load();
+    if items.len() == 1 {
+        return vec![curl_run(&net, &items[0].0, &items[0].1, std::io::stderr().is_terminal())];
+    }
+    let mut results = Vec::with_capacity(items.len());
+    for chunk in items.chunks(net.parallel) {
+        let done: Vec<Result<bool>> = std::thread::scope(|s| {
+            let handles: Vec<_> = chunk.iter().map(|(url, dest)| {
+                let net = &net;
+                s.spawn(move || {
+                    let t0 = Instant::now();
+                    let ok = curl_run(net, url, dest, false);
+                    let size = std::fs::metadata(dest).map(|m| m.len()).unwrap_or(0);
+                    match &ok {
+                        Ok(true) => eprintln!("  {} {} ({}, {:.1}s)", "✓".green(), url,
+                                              crate::tmp::human_size(size), t0.elapsed().as_secs_f64()),
+                        _        => eprintln!("  {} {}", "✗".red(), url),
+                    }
+                    ok
+                })
+            }).collect();
+            handles.into_iter()
+                .map(|h| h.join().unwrap_or_else(|_| Err(anyhow::anyhow!("wątek pobierania przerwany"))))
+                .collect()
+        });
+        results.extend(done);
+    }
+    results
+}
+
+fn curl_run(net: &NetConfig, url: &str, dest: &Path, progress: bool) -> Result<bool> {
+    let mut config = String::new();
+    if let Some(ca) = &net.ca_bundle     { config.push_str(&format!("cacert = {}\n", curl_quote(ca))); }
+    if let Some(c)  = &net.client_cert   { config.push_str(&format!("cert = {}\n", curl_quote(c))); }
+    if let Some(k)  = &net.client_key    { config.push_str(&format!("key = {}\n", curl_quote(k))); }
+    if let Some(t)  = net.token_for(url) {
+        config.push_str(&format!("header = {}\n", curl_quote(&format!("Authorization: Bearer {}", t))));
+    }
+    if let Some(rate) = net.limit_rate { config.push_str(&format!("limit-rate = {}\n", rate)); }
+
+    let mut cmd = Command::new("curl");
+    cmd.arg(if progress { "-fSL" } else { "-fsSL" });
+    cmd.args(["--proto", "=https,http", "--netrc-optional", "-K", "-", "-o"]).arg(dest).arg(url);
+    net.apply_proxy_env(&mut cmd);
+    let mut child = cmd.stdin(Stdio::piped()).spawn()?;
+    if let Some(mut stdin) = child.stdin.take() {
+        stdin.write_all(config.as_bytes())?;
+    }
+    Ok(child.wait()?.success())
+}
+
+/// `git` z proxy, CA, certyfikatem klienta i tokenem dla hosta `url` z [net]
+pub fn git_command(url: &str) -> Command {
+    let net = NetConfig::load();
+    let mut settings: Vec<(&str, String)> = Vec::new();
+    if let Some(ca) = &net.ca_bundle   { settings.push(("http.sslCAInfo", ca.clone())); }
+    if let Some(c)  = &net.client_cert { settings.push(("http.sslCert", c.clone())); }
+    if let Some(k)  = &net.client_key  { settings.push(("http.sslKey", k.clone())); }
+    if let Some(t)  = net.token_for(url) {
+        settings.push(("http.extraHeader", format!("Authorization: Bearer {}", t)));
+    }
+
+    let mut cmd = Command::new("git");
+    net.apply_proxy_env(&mut cmd);
+    if !settings.is_empty() {
+        cmd.env("GIT_CONFIG_COUNT", settings.len().to_string());
+        for (i, (key, value)) in settings.into_iter().enumerate() {
+            cmd.env(format!("GIT_CONFIG_KEY_{}", i), key).env(format!("GIT_CONFIG_VALUE_{}", i), value);
+        }
+    }
+    cmd
+}
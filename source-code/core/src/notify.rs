@@ -0,0 +1,187 @@
+use colored::Colorize;
+use serde_json::json;
+use std::io::Write;
+use std::path::Path;
+use std::process::{Command, Stdio};
+use std::time::Duration;
+use crate::config::{load_config, load_hk_file, HlConfig};
+
+// ── Powiadomienia po zakończeniu skryptu ──────────────────────────────────────
+//
+// Konfiguracja w [notify] bit.hk projektu skryptu, a bez niej — w config.hk:
+//
+//   [notify]
+//   -> on      => failure            ! failure | success | always | never
+//   -> via     => desktop, webhook   ! desktop | webhook | email
+//   -> webhook => https://hooks.slack.com/services/...
+//   -> email   => admin@example.com
+//
+// Treść: skrypt, status, exit code, czas trwania i ostatnie linie logu:
+// z HL_RUN_LOG (hl serve, hl schedule), a bez niego — z dziennika systemd
+// bieżącego uruchomienia (INVOCATION_ID, np. unit z hl deploy systemd).
+// Nieznana wartość `on` wyłącza powiadomienia z ostrzeżeniem (hl config
+// validate zgłasza ją jako błąd). Błędy powiadomień nigdy nie zmieniają exit
+// code skryptu.
+
+const LOG_TAIL_LINES: usize = 20;
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum NotifyOn { Failure, Success, Always, Never }
+
+impl NotifyOn {
+    pub fn parse(s: &str) -> Option<Self> {
+        match s.trim() {
+            "failure" => Some(NotifyOn::Failure),
+            "success" => Some(NotifyOn::Success),
+            "always"  => Some(NotifyOn::Always),
+            "never" | "" => Some(NotifyOn::Never),
+            _         => None,
+        }
+    }
+
+    pub fn matches(&self, exit_code: i32) -> bool {
+        match self {
+            NotifyOn::Failure => exit_code != 0,
+            NotifyOn::Success => exit_code == 0,
+            NotifyOn::Always  => true,
+            NotifyOn::Never   => false,
+        }
+    }
+}
+
+pub struct RunSummary<'a> {
+    pub script:    &'a Path,
+    pub exit_code: i32,
+    pub elapsed:   Duration,
+}
+
+impl RunSummary<'_> {
+    fn title(&self) -> String {
+        let name = self.script.file_name().and_then(|n| n.to_str()).unwrap_or("skrypt");
+        if self.exit_code == 0 {
+            format!("hl: {} zakończony OK", name)
+        } else {
+            format!("hl: {} zakończony błędem (exit {})", name, self.exit_code)
+        }
+    }
+
+    fn body(&self, tail: &str) -> String {
+        let mut b = format!(
+            "Skrypt: {}\nExit code: {}\nCzas: {:.1}s\nHost: {}",
+            self.script.display(), self.exit_code, self.elapsed.as_secs_f64(), hostname(),
+        );
+        if !tail.is_empty() {
+            b.push_str("\n\n--- log ---\n");
+            b.push_str(tail);
+        }
+        b
+    }
+}
+
+fn hostname() -> String {
+    std::fs::read_to_string("/etc/hostname").unwrap_or_else(|_| "hackeros".into()).trim().to_string()
+}
+
+fn log_tail() -> String {
+    let content = match std::env::var("HL_RUN_LOG") {
+        Ok(path) => std::fs::read_to_string(path).unwrap_or_default(),
+        Err(_)   => journal_tail(),
+    };
+    let lines: Vec<&str> = content.lines().collect();
+    lines[lines.len().saturating_sub(LOG_TAIL_LINES)..].join("\n")
+}
+
+/// Wyjście bieżącego uruchomienia z dziennika systemd; pusty poza unitem systemd
+fn journal_tail() -> String {
+    let Ok(id) = std::env::var("INVOCATION_ID") else { return String::new() };
+    if which::which("journalctl").is_err() { return String::new(); }
+    Command::new("journalctl")
+        .args([format!("_SYSTEMD_INVOCATION_ID={}", id), "-n".into(), LOG_TAIL_LINES.to_string(), "-o".into(), "cat".into(), "--no-pager".into()])
+        .stderr(Stdio::null())
+        .output().ok()
+        .filter(|o| o.status.success())
+        .map(|o| String::from_utf8_lossy(&o.stdout).into_owned())
+        .unwrap_or_default()
+}
+
+fn notify_desktop(title: &str, body: &str, failed: bool) -> bool {
+    if which::which("notify-send").is_err() { return false; }
+    Command::new("notify-send")
+        .args(["-a", "Hacker Lang", "-u", if failed { "critical" } else { "normal" }, title, body])
+        .stdout(Stdio::null()).stderr(Stdio::null())
+        .status().map(|s| s.success()).unwrap_or(false)
+}
+
+fn notify_webhook(url: &str, summary: &RunSummary, title: &str, body: &str) -> bool {
+    if which::which("curl").is_err() { return false; }
+    // `text` — format Slack/Mattermost; pozostałe pola dla własnych odbiorców
+    let payload = json!({
+        "text":      format!("*{}*\n```{}```", title, body),
+        "script":    summary.script.display().to_string(),
+        "exit_code": summary.exit_code,
+        "duration":  summary.elapsed.as_secs_f64(),
+        "host":      hostname(),
+    });
+    Command::new("curl")
+        .args(["-fsS", "-m", "10", "-X", "POST", "-H", "Content-Type: application/json",
+               "-d", &payload.to_string(), url])
+        .stdout(Stdio::null())
+        .status().map(|s| s.success()).unwrap_or(false)
+}
+
+fn notify_email(addr: &str, title: &str, body: &str) -> bool {
+    if which::which("mail").is_err() { return false; }
+    let Ok(mut child) = Command::new("mail").args(["-s", title, addr]).stdin(Stdio::piped()).spawn() else {
+        return false;
+    };
+    if let Some(stdin) = child.stdin.as_mut() { let _ = stdin.write_all(body.as_bytes()); }
+    child.wait().map(|s| s.success()).unwrap_or(false)
+}
+
+pub fn notify_run_finished_with(cfg: &HlConfig, summary: &RunSummary) {
+    let raw = cfg.get("notify", "on").unwrap_or("never");
+    let Some(on) = NotifyOn::parse(raw) else {
+        eprintln!("{} nieznana wartość on '{}' (failure | success | always | never) — powiadomienia wyłączone",
+                  "[hl notify]".bright_black(), raw.trim());
+        return;
+    };
+    if !on.matches(summary.exit_code) { return; }
+
+    let title = summary.title();
+    let body  = summary.body(&log_tail());
+    let via   = cfg.get("notify", "via").unwrap_or("desktop");
+
+    for channel in via.split(',').map(str::trim).filter(|c| !c.is_empty()) {
+        let sent = match channel {
+            "desktop" => notify_desktop(&title, &body, summary.exit_code != 0),
+            "webhook" | "slack-webhook" => match cfg.get("notify", "webhook") {
+                Some(url) => notify_webhook(url, summary, &title, &body),
+                None      => false,
+            },
+            "email" => match cfg.get("notify", "email") {
+                Some(addr) => notify_email(addr, &title, &body),
+                None       => false,
+            },
+            _ => false,
+        };
+        if !sent {
+            tracing::warn!("notify: kanał '{}' niedostępny lub nieskonfigurowany", channel);
+            eprintln!("{} powiadomienie '{}' nie zostało wysłane", "[hl notify]".bright_black(), channel);
+        }
+    }
+}
+
+/// [notify] z bit.hk projektu skryptu; bez sekcji lub projektu — config.hk
+fn notify_config(script: &Path) -> HlConfig {
+    let dir = crate::libs::source_dir(script);
+    let dir = std::fs::canonicalize(dir).unwrap_or_else(|_| dir.to_path_buf());
+    crate::project::find_project(&dir)
+        .and_then(|manifest| load_hk_file(&manifest).ok())
+        .filter(|cfg| !cfg.entries("notify").is_empty())
+        .unwrap_or_else(load_config)
+}
+
+/// Wyślij powiadomienia zgodnie z [notify] w bit.hk projektu albo w config.hk
+pub fn notify_run_finished(summary: &RunSummary) {
+    notify_run_finished_with(&notify_config(summary.script), summary);
+}
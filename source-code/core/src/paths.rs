@@ -0,0 +1,165 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use hk_parser::{parse_hk, HkValue};
+use std::path::{Path, PathBuf};
+use std::sync::OnceLock;
+use crate::config::config_path;
+
+// ── Katalogi danych HL: układ legacy / XDG ────────────────────────────────────
+//
+// legacy (domyślny):  ~/.hackeros/hacker-lang/{libs,meta,envs,cache,logs}
+// xdg:                $XDG_DATA_HOME/hacker-lang/{libs,meta,envs}
+//                     $XDG_CACHE_HOME/hacker-lang/cache
+//                     $XDG_STATE_HOME/hacker-lang/logs
+//
+// Włączenie: w config.hk `[paths] -> layout => xdg` (lub HL_LAYOUT=xdg).
+// Przy pierwszym uruchomieniu hl przenosi istniejące katalogi i zostawia
+// w ~/.hackeros/hacker-lang/ symlinki — bit, hl-compiler i stare skrypty
+// korzystające ze starych ścieżek działają dalej.
+//
+// config.hk: $XDG_CONFIG_HOME/hacker-lang/config.hk; dopóki istnieje tylko stary
+// ~/.config/hackeros/hacker-lang/config.hk, hl czyta stary. Migracja XDG przenosi
+// go na nowe miejsce i zostawia symlink (bit czyta active_path ze starej ścieżki).
+// Układ jest czytany raz na proces.
+
+pub const SUBDIRS_DATA:  &[&str] = &["libs", "meta", "envs"];
+pub const SUBDIR_CACHE:  &str    = "cache";
+pub const SUBDIR_LOGS:   &str    = "logs";
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum Layout { Legacy, Xdg }
+
+fn home() -> PathBuf {
+    dirs::home_dir().unwrap_or_else(|| PathBuf::from("/tmp"))
+}
+
+/// Stary katalog bazowy ~/.hackeros/hacker-lang
+pub fn legacy_base() -> PathBuf {
+    home().join(".hackeros").join("hacker-lang")
+}
+
+fn xdg(var: &str, fallback: &str) -> PathBuf {
+    std::env::var_os(var)
+        .map(PathBuf::from)
+        .filter(|p| p.is_absolute())
+        .unwrap_or_else(|| home().join(fallback))
+}
+
+/// config.hk w układzie XDG
+pub fn xdg_config_path() -> PathBuf {
+    xdg("XDG_CONFIG_HOME", ".config").join("hacker-lang").join("config.hk")
+}
+
+/// Stare miejsce config.hk — przed XDG
+pub fn legacy_config_path() -> PathBuf {
+    dirs::config_dir().unwrap_or_else(|| home().join(".config")).join("hackeros").join("hacker-lang").join("config.hk")
+}
+
+static LAYOUT: OnceLock<Layout> = OnceLock::new();
+
+/// Układ katalogów (ustalany raz na proces)
+pub fn layout() -> Layout {
+    *LAYOUT.get_or_init(read_layout)
+}
+
+/// Czyta config.hk bezpośrednio — load_config() sam z niego korzysta
+fn read_layout() -> Layout {
+    if let Ok(v) = std::env::var("HL_LAYOUT") {
+        return if v == "xdg" { Layout::Xdg } else { Layout::Legacy };
+    }
+    let value = std::fs::read_to_string(config_path()).ok()
+        .and_then(|src| parse_hk(&src).ok())
+        .and_then(|cfg| match cfg.get("paths") {
+            Some(HkValue::Map(m)) => match m.get("layout") {
+                Some(HkValue::String(s)) => Some(s.clone()),
+                _ => None,
+            },
+            _ => None,
+        });
+    if value.as_deref() == Some("xdg") { Layout::Xdg } else { Layout::Legacy }
+}
+
+fn dir_for(layout: Layout, sub: &str) -> PathBuf {
+    match layout {
+        Layout::Legacy => legacy_base().join(sub),
+        Layout::Xdg => match sub {
+            SUBDIR_CACHE => xdg("XDG_CACHE_HOME", ".cache").join("hacker-lang").join(sub),
+            SUBDIR_LOGS  => xdg("XDG_STATE_HOME", ".local/state").join("hacker-lang").join(sub),
+            _            => xdg("XDG_DATA_HOME", ".local/share").join("hacker-lang").join(sub),
+        },
+    }
+}
+
+pub fn data_dir(sub: &str) -> PathBuf { dir_for(layout(), sub) }
+pub fn cache_dir() -> PathBuf         { dir_for(layout(), SUBDIR_CACHE) }
+pub fn logs_dir() -> PathBuf          { dir_for(layout(), SUBDIR_LOGS) }
+
+fn is_symlink(path: &Path) -> bool {
+    std::fs::symlink_metadata(path).map(|m| m.file_type().is_symlink()).unwrap_or(false)
+}
+
+fn all_subdirs() -> impl Iterator<Item = &'static str> {
+    SUBDIRS_DATA.iter().copied().chain([SUBDIR_CACHE, SUBDIR_LOGS])
+}
+
+/// Jednorazowa migracja legacy → XDG. Wołane przy starcie hl; no-op,
+/// gdy układ to legacy lub migracja już się odbyła.
+pub fn ensure_layout() -> Result<()> {
+    if layout() != Layout::Xdg { return Ok(()); }
+    migrate_config()?;
+    for sub in all_subdirs() {
+        let old = legacy_base().join(sub);
+        let new = dir_for(Layout::Xdg, sub);
+        if is_symlink(&old) { continue; }
+
+        if let Some(parent) = new.parent() { std::fs::create_dir_all(parent)?; }
+        if old.is_dir() {
+            if new.exists() {
+                bail!("Migracja XDG: {} i {} istnieją jednocześnie — scal je ręcznie",
+                      old.display(), new.display());
+            }
+            std::fs::rename(&old, &new).with_context(|| format!(
+                "Migracja XDG: nie można przenieść {} → {} (inny system plików?)",
+                old.display(), new.display()))?;
+            eprintln!("{} {} → {}", "[hl xdg]".bright_black(), old.display(), new.display());
+        } else {
+            std::fs::create_dir_all(&new)?;
+        }
+        std::fs::create_dir_all(legacy_base())?;
+        std::os::unix::fs::symlink(&new, &old)
+            .with_context(|| format!("Nie można utworzyć symlinku {}", old.display()))?;
+    }
+    Ok(())
+}
+
+/// Stary config.hk → $XDG_CONFIG_HOME/hacker-lang/, na starym miejscu symlink
+fn migrate_config() -> Result<()> {
+    let (old, new) = (legacy_config_path(), xdg_config_path());
+    if old == new || is_symlink(&old) || !old.is_file() || new.exists() { return Ok(()); }
+    if let Some(parent) = new.parent() { std::fs::create_dir_all(parent)?; }
+    std::fs::rename(&old, &new).with_context(|| format!(
+        "Migracja XDG: nie można przenieść {} → {}", old.display(), new.display()))?;
+    std::os::unix::fs::symlink(&new, &old)
+        .with_context(|| format!("Nie można utworzyć symlinku {}", old.display()))?;
+    eprintln!("{} {} → {}", "[hl xdg]".bright_black(), old.display(), new.display());
+    Ok(())
+}
+
+/// Wypisz efektywne katalogi (hl paths)
+pub fn cmd_paths() -> Result<()> {
+    let l = layout();
+    println!("{} {}", "hl paths:".bright_magenta().bold(),
+             if l == Layout::Xdg { "xdg" } else { "legacy" }.bright_white());
+    println!("  {:<8} {}", "config", config_path().display().to_string().bright_white());
+    for sub in all_subdirs() {
+        let p = dir_for(l, sub);
+        let mark = if p.exists() { "✓".green() } else { "·".bright_black() };
+        println!("  {:<8} {} {}", sub, mark, p.display().to_string().bright_white());
+    }
+    if l == Layout::Legacy {
+        println!();
+        println!("  Układ XDG: {} w config.hk, sekcja {}",
+                 "-> layout => xdg".bright_cyan(), "[paths]".bright_cyan());
+    }
+    Ok(())
+}
@@ -0,0 +1,93 @@
+use anyhow::Result;
+use std::path::{Path, PathBuf};
+use crate::compat::{parse_version, HL_VERSION};
+use crate::exit::{classified, ErrorClass};
+
+// ── Przypięta wersja projektu (.hacker-version) ───────────────────────────────
+//
+//   $ cat .hacker-version
+//   1.0                  ! dowolna 1.0.x
+//
+// Przy starcie hl szuka .hacker-version w katalogu bieżącym i wyżej (jak
+// .nvmrc). Wersja z pliku to prefiks: `1` pasuje do 1.x.y, `1.0.2` tylko do
+// siebie. Gdy uruchomiony hl nie pasuje, przełącza się na zainstalowany
+// toolchain z katalogu danych:
+//
+//   <dane>/toolchains/<wersja>/bin/hl        np. ~/.hackeros/hacker-lang/toolchains/1.0.2/bin/hl
+//
+// (najnowszy pasujący). Brak pasującego toolchainu — exit 6 z informacją,
+// gdzie go zainstalować. HL_IGNORE_PIN=1 wyłącza sprawdzanie.
+
+pub const PIN_FILE: &str = ".hacker-version";
+const SWITCHED_ENV: &str = "HL_PINNED";
+
+pub fn toolchains_dir() -> PathBuf {
+    crate::paths::data_dir("toolchains")
+}
+
+/// Najbliższy .hacker-version od `start` w górę: (plik, wersja)
+pub fn find_pin(start: &Path) -> Option<(PathBuf, String)> {
+    start.ancestors().find_map(|dir| {
+        let path = dir.join(PIN_FILE);
+        let text = std::fs::read_to_string(&path).ok()?;
+        let version = text.lines()
+            .map(|l| l.split('!').next().unwrap_or("").trim())
+            .find(|l| !l.is_empty() && !l.starts_with('#'))?
+            .trim_start_matches('v')
+            .to_string();
+        Some((path, version))
+    })
+}
+
+/// `want` jako prefiks wersji `have` (po składowych)
+fn matches(want: &[u64], have: &[u64]) -> bool {
+    want.len() <= have.len() && want.iter().zip(have).all(|(w, h)| w == h)
+}
+
+/// Najnowszy zainstalowany toolchain pasujący do przypiętej wersji
+fn installed(want: &[u64]) -> Option<(String, PathBuf)> {
+    let rd = std::fs::read_dir(toolchains_dir()).ok()?;
+    rd.flatten()
+        .filter_map(|e| {
+            let name = e.file_name().to_str()?.to_string();
+            let version = parse_version(&name)?;
+            let bin = e.path().join("bin").join("hl");
+            (matches(want, &version) && bin.is_file()).then_some((version, name, bin))
+        })
+        .max_by(|a, b| a.0.cmp(&b.0))
+        .map(|(_, name, bin)| (name, bin))
+}
+
+/// Sprawdź przypięcie; przy niezgodności uruchom pasujący hl w miejsce bieżącego
+pub fn enforce_pin() -> Result<()> {
+    if std::env::var("HL_IGNORE_PIN").is_ok_and(|v| v == "1") { return Ok(()); }
+    let Ok(cwd) = std::env::current_dir() else { return Ok(()) };
+    let Some((file, pinned)) = find_pin(&cwd) else { return Ok(()) };
+    let Some(want) = parse_version(&pinned) else {
+        return Err(classified(ErrorClass::Toolchain, format!(
+            "{}: nieprawidłowa wersja '{}' — oczekiwano np. 1.0 albo 1.0.2", file.display(), pinned)));
+    };
+    if matches(&want, &parse_version(HL_VERSION).unwrap_or_default()) { return Ok(()); }
+
+    let missing = || classified(ErrorClass::Toolchain, format!(
+        "projekt przypina hl {} ({}), uruchomiony hl {} — zainstaluj toolchain w {}/{}/bin/hl \
+         albo pomiń sprawdzenie: HL_IGNORE_PIN=1",
+        pinned, file.display(), HL_VERSION, toolchains_dir().display(), pinned));
+    // Przełączony hl też nie pasuje — nie wchodź w pętlę
+    if std::env::var_os(SWITCHED_ENV).is_some() { return Err(missing()); }
+    let Some((version, bin)) = installed(&want) else { return Err(missing()) };
+
+    use std::os::unix::process::CommandExt;
+    let err = std::process::Command::new(&bin)
+        .args(std::env::args_os().skip(1))
+        .env(SWITCHED_ENV, &version)
+        .exec();
+    Err(classified(ErrorClass::Toolchain, format!("{}: {}", bin.display(), err)))
+}
+
+/// Linia do `hl version`: przypięcie w katalogu bieżącym
+pub fn describe_pin() -> Option<String> {
+    let cwd = std::env::current_dir().ok()?;
+    let (file, pinned) = find_pin(&cwd)?;
+    Some(format!("hl {} ({})", pinned, file.display()))
+}
@@ -0,0 +1,512 @@
+use anyhow::Result;
+use std::collections::{HashMap, HashSet};
+use std::path::{Path, PathBuf};
+use hl_parser::ast::{parse_string_parts, HackerOsTool, StringPart};
+use hl_parser::lexer::{Lexer, PipeCmdMode, Token};
+
+// ── hl run --explain ──────────────────────────────────────────────────────────
+//
+// Skrypt nie jest wykonywany. Każda linia źródła trafia na wyjście jako
+// komentarz `# L<linia> [dyrektywa] <źródło>`, a pod nim odpowiednik w bash:
+//
+//   # L4 [sudo] ^> apt-get update
+//   sudo apt-get update
+//   # L5 [pipe-to-var] > curl -s ifconfig.me |> @IP
+//   IP=$(curl -s ifconfig.me)
+//
+// hl nie generuje basha — to przybliżenie do przejrzenia skryptu przed
+// uruchomieniem i do zgłoszeń błędów. Dyrektywy bez odpowiednika (`::`,
+// importy, kanały) mają tylko komentarz.
+//
+//   hl run --explain --minify x.hl   przebieg optymalizujący, bez komentarzy i wcięć
+//   hl run --explain --inline x.hl   małe pliki z `<< plik` wstawione w miejsce importu
+//
+// --minify: jedna kontrola `command -v` na narzędzie (powtórki za kontrolą na
+// poziomie skryptu znikają), sąsiednie `export` w jednym poleceniu, a gałęzie
+// `if` rozstrzygalne przy generowaniu — porównanie zmiennej przypisanej dokładnie
+// raz literałem i sekcje `?feature` według HL_FEATURES — zostają bez `if`
+// albo wypadają w całości.
+
+const ISOLATE: &str = "unshare --mount --pid --net --fork --";
+
+/// `@nazwa` → `${nazwa}` (te same reguły co interpolacja komend)
+fn sh_interp(text: &str) -> String {
+    fn render(parts: &[StringPart], out: &mut String) {
+        for part in parts {
+            match part {
+                StringPart::Literal(s) => out.push_str(s),
+                StringPart::Var(v)     => out.push_str(&format!("${{{}}}", v)),
+                StringPart::DynVar(p)  => { out.push_str("${!"); render(p, out); out.push('}'); }
+            }
+        }
+    }
+    let mut out = String::new();
+    render(&parse_string_parts(text), &mut out);
+    out
+}
+
+fn sh_quote(text: &str) -> String {
+    let text = text.trim();
+    let inner = text.strip_prefix('"').and_then(|t| t.strip_suffix('"')).unwrap_or(text);
+    format!("\"{}\"", sh_interp(inner).replace('"', "\\\""))
+}
+
+/// Komenda z trybem: sudo / izolacja
+fn sh_cmd(raw: &str, sudo: bool, isolated: bool) -> String {
+    let cmd = sh_interp(raw.trim());
+    match (sudo, isolated) {
+        (false, false) => cmd,
+        (true,  false) => format!("sudo {}", cmd),
+        (false, true)  => format!("{} {}", ISOLATE, cmd),
+        (true,  true)  => format!("sudo {} {}", ISOLATE, cmd),
+    }
+}
+
+/// Warunek `? @x == debug` / `?~ @n < 3` jako test basha
+fn sh_cond(cond: &str) -> String {
+    for (op, test) in [("==", "="), ("!=", "!="), ("<=", "-le"), (">=", "-ge"), ("<", "-lt"), (">", "-gt")] {
+        if let Some((a, b)) = cond.split_once(op) {
+            return format!("[ {} {} {} ]", sh_quote(a), test, sh_quote(b));
+        }
+    }
+    let cond = cond.trim();
+    if cond.starts_with('@') && !cond.contains(' ') { format!("[ -n {} ]", sh_quote(cond)) } else { sh_interp(cond) }
+}
+
+/// Wartość bez otaczających cudzysłowów (jak w sh_quote)
+fn unquote(text: &str) -> &str {
+    let text = text.trim();
+    text.strip_prefix('"').and_then(|t| t.strip_suffix('"')).unwrap_or(text)
+}
+
+/// Literał bez interpolacji — jego wartość jest znana przy generowaniu
+fn is_literal(text: &str) -> bool {
+    !text.contains(['@', '$', '`'])
+}
+
+/// `? @x == debug` / `? @x != "a b"` — porównanie zmiennej z literałem
+fn compare(cond: &str) -> Option<Kind> {
+    for (op, eq) in [("==", true), ("!=", false)] {
+        let Some((a, b)) = cond.split_once(op) else { continue };
+        let var = a.trim().strip_prefix('@')?;
+        let lit = unquote(b);
+        if var.is_empty() || !var.chars().all(|c| c.is_alphanumeric() || c == '_') || !is_literal(lit) { return None; }
+        return Some(Kind::Compare(var.to_string(), eq, lit.to_string()));
+    }
+    None
+}
+
+/// `?feature(gpu, !debug)` jako test HL_FEATURES i wynik dla cech bieżącego procesu
+fn sh_feature(spec: &str) -> (String, Kind) {
+    use hl_parser::features::{enabled_from_env, is_active, parse_condition, FEATURES_ENV};
+    let Ok(conditions) = parse_condition(spec) else { return (format!("false  # ?feature({})", spec), Kind::Open) };
+    let test = conditions.iter().map(|c| match c.strip_prefix('!') {
+        Some(name) => format!("[[ \",${{{}}},\" != *\",{},\"* ]]", FEATURES_ENV, name),
+        None       => format!("[[ \",${{{}}},\" == *\",{},\"* ]]", FEATURES_ENV, c),
+    }).collect::<Vec<_>>().join(" && ");
+    (test, Kind::Known(is_active(&conditions, &enabled_from_env())))
+}
+
+/// Pliki z `<< plik` do tylu linii są wstawiane przy --inline
+const INLINE_MAX_LINES: usize = 40;
+
+/// Opcje podglądu `hl run --explain`
+#[derive(Debug, Clone, Copy, Default)]
+pub struct ExplainOptions {
+    /// Przebieg optymalizujący (optimize), bez komentarzy i wcięć
+    pub minify: bool,
+    /// Wstaw małe pliki z `<< plik` w miejsce importu
+    pub inline: bool,
+}
+
+/// Rodzaj linii wyniku — to, czego potrzebuje przebieg optymalizujący
+#[derive(Debug, Clone, PartialEq)]
+enum Kind {
+    Code,
+    /// `# L4 [sudo] …` i inne komentarze
+    Note,
+    /// `command -v <narzędzie>`
+    Dependency(String),
+    /// `export NAZWA=…`
+    Export(String),
+    /// `if` czytający $? poprzedniej komendy (`? ok` / `? err`)
+    Status,
+    /// `if` porównujący zmienną z literałem: (zmienna, równe?, literał)
+    Compare(String, bool, String),
+    /// `if` o wyniku znanym przy generowaniu (`?feature`)
+    Known(bool),
+    /// inna linia otwierająca blok
+    Open,
+    Close,
+}
+
+#[derive(Debug, Clone)]
+struct Out {
+    depth: usize,
+    text:  String,
+    /// komentarz na końcu linii — pomijany przy --minify
+    tail:  Option<String>,
+    kind:  Kind,
+}
+
+struct Block { close: &'static str, arm_open: bool }
+
+/// Odpowiednik dyrektywy w bash
+enum Step {
+    Line(String),
+    /// linia z rodzajem i komentarzem na końcu
+    Tagged(String, Kind, Option<String>),
+    /// linia otwierająca blok, jego zamknięcie (`fi`, `done`, `}`, `esac`) i rodzaj
+    Open(String, &'static str, Kind),
+    Close,
+    Arm(String),
+    /// `<< plik` do wstawienia (--inline)
+    Include(PathBuf),
+    /// bez odpowiednika — tylko komentarz
+    Note,
+}
+
+struct Plan {
+    out:      Vec<Out>,
+    blocks:   Vec<Block>,
+    export:   Option<(String, Vec<String>)>,
+    opts:     ExplainOptions,
+    /// katalog bieżącego pliku i prefiks komentarzy (`lib.hl:` we wstawionych plikach)
+    base:     PathBuf,
+    label:    String,
+    inlined:  HashSet<PathBuf>,
+    /// liczba przypisań każdej zmiennej i przypisanie literałem poza blokami: (indeks w out, wartość)
+    writes:   HashMap<String, usize>,
+    literals: HashMap<String, (usize, String)>,
+}
+
+impl Plan {
+    fn new(base: PathBuf, opts: ExplainOptions) -> Self {
+        Plan {
+            out: Vec::new(), blocks: Vec::new(), export: None, opts, base, label: String::new(),
+            inlined: HashSet::new(), writes: HashMap::new(), literals: HashMap::new(),
+        }
+    }
+
+    fn push(&mut self, text: String, kind: Kind, tail: Option<String>) {
+        self.out.push(Out { depth: self.blocks.len(), text, tail, kind });
+    }
+
+    fn emit(&mut self, line: String) {
+        self.push(line, Kind::Code, None);
+    }
+
+    fn write(&mut self, var: &str) {
+        *self.writes.entry(var.to_string()).or_insert(0) += 1;
+    }
+
+    fn assign(&mut self, var: &str, value: &str) {
+        self.write(var);
+        if self.blocks.is_empty() && is_literal(value) && !self.literals.contains_key(var) {
+            self.literals.insert(var.to_string(), (self.out.len(), unquote(value).to_string()));
+        }
+    }
+
+    /// `<< plik` względem katalogu bieżącego pliku, potem katalogu roboczego (jak executor)
+    fn resolve_include(&self, path: &str) -> Option<PathBuf> {
+        let path = path.trim();
+        if path.contains('@') { return None; }
+        let file = if path.contains('.') { path.to_string() } else { format!("{}.hl", path) };
+        [self.base.join(&file), PathBuf::from(&file)].into_iter().find(|p| p.is_file())
+    }
+
+    fn translate(&mut self, tok: &Token) -> (&'static str, Step) {
+        use Step::*;
+        match tok {
+            Token::Print(s)                 => ("print", Line(format!("echo {}", sh_quote(s)))),
+            Token::Cmd(raw)                 => ("command", Line(sh_cmd(raw, false, false))),
+            Token::CmdWithVars(raw)         => ("command", Line(sh_cmd(raw, false, false))),
+            Token::CmdSudo(raw)             => ("sudo", Line(sh_cmd(raw, true, false))),
+            Token::CmdWithVarsSudo(raw)     => ("sudo", Line(sh_cmd(raw, true, false))),
+            Token::CmdIsolated(raw)         => ("isolated", Line(sh_cmd(raw, false, true))),
+            Token::CmdWithVarsIsolated(raw) => ("isolated", Line(sh_cmd(raw, false, true))),
+            Token::CmdIsolatedSudo(raw)     => ("isolated-sudo", Line(sh_cmd(raw, true, true))),
+            Token::HshCmd(_)                => ("hsh", Note),
+            Token::Background { raw, .. }   => ("background", Line(format!("{} &", sh_interp(raw.trim())))),
+            Token::CmdPipeToVar { cmd, mode, var_name } => {
+                let (strict, var) = match var_name.strip_prefix('!') {
+                    Some(v) => (true, v.trim().trim_start_matches('@')),
+                    None    => (false, var_name.as_str()),
+                };
+                self.write(var);
+                let line = format!("{}=$({})", var, sh_cmd(cmd, *mode == PipeCmdMode::Sudo, false));
+                ("pipe-to-var", Line(if strict { format!("{} || exit $?", line) } else { line }))
+            }
+            Token::HackerOsApi { tool, args } => ("hackeros-api",
+                Line(format!("{} {}", HackerOsTool::from_str(tool).binary_name(), sh_interp(args.trim())))),
+            Token::VarDecl { name, value, .. } => {
+                let v = value.trim();
+                self.assign(name, v);
+                let rhs = match v.strip_prefix("$(").and_then(|e| e.strip_suffix(')')) {
+                    Some(expr) => format!("$(( {} ))", sh_interp(expr.trim())),
+                    None       => sh_quote(v),
+                };
+                ("var", Line(format!("{}={}", name, rhs)))
+            }
+            Token::Arithmetic { expr, assign_to } => ("arithmetic", Line(match assign_to {
+                Some(v) => { self.write(v); format!("{}=$(( {} ))", v, sh_interp(expr)) }
+                None    => format!("echo $(( {} ))", sh_interp(expr)),
+            })),
+            Token::ExportSingle { name, value } => {
+                self.write(name);
+                ("export", Tagged(format!("export {}={}", name, sh_quote(value)), Kind::Export(name.clone()), None))
+            }
+            Token::ExportListStart(name) => { self.export = Some((name.clone(), Vec::new())); ("export", Note) }
+            Token::ExportListItem(item) => {
+                if let Some((_, items)) = &mut self.export { items.push(sh_interp(item.trim())); }
+                ("export", Note)
+            }
+            Token::ExportListEnd => match self.export.take() {
+                Some((name, items)) => {
+                    self.write(&name);
+                    ("export", Tagged(format!("export {}=\"{}\"", name, items.join(":")), Kind::Export(name), None))
+                }
+                None => ("export", Note),
+            },
+            Token::Dependency(bin, pkg) => ("dependency", Tagged(format!("command -v {} >/dev/null", bin),
+                Kind::Dependency(bin.clone()), Some(format!("brak → hl instaluje pakiet {}", pkg.as_deref().unwrap_or(bin))))),
+            Token::FileImport { path, .. } if self.opts.inline => match self.resolve_include(path) {
+                Some(file) => ("import", Include(file)),
+                None       => ("import", Note),
+            },
+            Token::Import { .. } | Token::FileImport { .. } | Token::DirImport { .. } => ("import", Note),
+            Token::FuncDef(name)             => ("function", Open(format!("{}() {{", name), "}", Kind::Open)),
+            Token::ArenaFuncDef { name, .. } => ("arena-function", Open(format!("{}() {{", name), "}", Kind::Open)),
+            Token::FuncCall { name, args } => ("call", Line(match args.rsplit_once("->") {
+                Some((a, v)) if v.trim().starts_with('@') => {
+                    self.write(v.trim().trim_start_matches('@'));
+                    format!("{} {}; {}=\"$_return\"", name, sh_interp(a.trim()), v.trim().trim_start_matches('@')).replace(" ;", ";")
+                }
+                _ => format!("{} {}", name, sh_interp(args.trim())).trim_end().to_string(),
+            })),
+            Token::Return(v) => { self.write("_return"); ("return", Line(format!("_return={}; return", sh_quote(v)))) }
+            Token::IfOk          => ("if-ok",  Open("if [ $? -eq 0 ]; then".into(), "fi", Kind::Status)),
+            Token::IfErr         => ("if-err", Open("if [ $? -ne 0 ]; then".into(), "fi", Kind::Status)),
+            Token::IfExpr(c)     => ("if",     Open(format!("if {}; then", sh_cond(c)), "fi", compare(c).unwrap_or(Kind::Open))),
+            Token::Feature(spec) => {
+                let (test, kind) = sh_feature(spec);
+                ("feature", Open(format!("if {}; then", test), "fi", kind))
+            }
+            Token::WhileStart(c) => ("while",  Open(format!("while {}; do", sh_cond(c)), "done", Kind::Open)),
+            Token::ForIn { var, iterable } => {
+                self.write(var);
+                ("for-in", Open(format!("for {} in {}; do", var, sh_interp(iterable.trim())), "done", Kind::Open))
+            }
+            Token::SwitchStart(subject)    => ("switch", Open(format!("case {} in", sh_quote(subject)), "esac", Kind::Open)),
+            Token::SwitchArm { pattern }   => ("case-arm", Arm(if pattern == "_" { "*".into() } else { pattern.clone() })),
+            Token::Done                    => ("done", Close),
+            Token::GoroutineStart { .. }   => ("goroutine", Open("{".into(), "} &", Kind::Open)),
+            Token::ExternStart { .. }      => ("extern", Open("{".into(), "}", Kind::Open)),
+            Token::ChannelDecl(_) | Token::ChannelOp(_)            => ("channel", Note),
+            Token::QuickCall { .. } | Token::QuickPipeToVar { .. } => ("quick-call", Note),
+            Token::Using(_) => ("gen", Note),
+            _ => ("?", Note),
+        }
+    }
+
+    fn apply(&mut self, step: Step) {
+        match step {
+            Step::Line(l)              => self.emit(l),
+            Step::Tagged(l, kind, tail) => self.push(l, kind, tail),
+            Step::Open(l, close, kind) => { self.push(l, kind, None); self.blocks.push(Block { close, arm_open: false }); }
+            Step::Close => {
+                let Some(block) = self.blocks.pop() else { return };
+                if block.arm_open { self.emit("    ;;".into()); }
+                self.push(block.close.into(), Kind::Close, None);
+            }
+            Step::Arm(pattern) => {
+                let was_open = self.blocks.last_mut().map(|b| std::mem::replace(&mut b.arm_open, true)).unwrap_or(false);
+                if was_open { self.emit(";;".into()); }
+                self.emit(format!("{})", pattern));
+            }
+            Step::Include(file) => self.include(&file),
+            Step::Note => {}
+        }
+    }
+
+    /// Wstaw odpowiednik małego pliku z `<< plik` (każdy plik raz)
+    fn include(&mut self, file: &Path) {
+        let Ok(source) = std::fs::read_to_string(file) else { return };
+        let key = file.canonicalize().unwrap_or_else(|_| file.to_path_buf());
+        if source.lines().count() > INLINE_MAX_LINES || !self.inlined.insert(key) { return; }
+        let name = file.file_name().map(|n| n.to_string_lossy().to_string()).unwrap_or_default();
+        let base = file.parent().map(Path::to_path_buf).unwrap_or_default();
+        let saved = (std::mem::replace(&mut self.label, format!("{}:", name)), std::mem::replace(&mut self.base, base));
+        if let Err(e) = self.file(&source) {
+            self.push(format!("# {}: nie wstawiono ({})", file.display(), e), Kind::Note, None);
+        }
+        (self.label, self.base) = saved;
+    }
+
+    /// Reszta linii po prefiksie jako jedna komenda basha
+    fn inline(&mut self, toks: &[&Token]) -> String {
+        toks.iter()
+            .filter_map(|t| match self.translate(t).1 { Step::Line(l) | Step::Tagged(l, ..) => Some(l), _ => None })
+            .collect::<Vec<_>>()
+            .join("; ")
+    }
+
+    /// Tokeny jednej linii źródła
+    fn line(&mut self, no: usize, src: &str, toks: &[Token]) {
+        let toks: Vec<&Token> = toks.iter()
+            .filter(|t| !matches!(t, Token::Newline | Token::Eof | Token::Comments(..)))
+            .collect();
+        let Some((first, rest)) = toks.split_first() else { return };
+        // Prefiksy _N / ~retry(...) obejmują resztę linii
+        let (kind, step) = match first {
+            Token::RepeatN(n) => ("repeat", Step::Line(format!("for _ in $(seq {}); do {}; done", n, self.inline(rest)))),
+            Token::Retry(spec) => {
+                let (attempts, delay) = match hl_parser::ast::RetrySpec::parse(spec) {
+                    Ok(s)  => (s.attempts, s.delay_ms as f64 / 1000.0),
+                    Err(_) => (1, 0.0),
+                };
+                ("retry", Step::Line(format!("for _ in $(seq {}); do {} && break; sleep {}; done", attempts, self.inline(rest), delay)))
+            }
+            Token::Once(_) => ("once", Step::Line(self.inline(rest))),
+            Token::Undo(undo) => ("undo", Step::Tagged(self.inline(rest), Kind::Code, Some(format!("undo: {}", sh_interp(undo))))),
+            Token::Check(check) => ("check", Step::Line(format!("{} >/dev/null 2>&1 || {{ {}; }}", sh_interp(check), self.inline(rest)))),
+            _ => self.translate(first),
+        };
+        self.push(format!("# {}L{} [{}] {}", self.label, no, kind, src.trim()), Kind::Note, None);
+        self.apply(step);
+    }
+
+    /// Wszystkie linie pliku, grupowane po numerze linii źródła
+    fn file(&mut self, source: &str) -> Result<()> {
+        let pre = hl_parser::preprocess(source);
+        let tokens = Lexer::new(&pre.source).tokenize_with_lines()?;
+        let lines: Vec<&str> = source.lines().collect();
+        let mut i = 0;
+        while i < tokens.len() {
+            let no = tokens[i].0;
+            let end = tokens[i..].iter().position(|(l, _)| *l != no).map(|p| i + p).unwrap_or(tokens.len());
+            let toks: Vec<Token> = tokens[i..end].iter().map(|(_, t)| t.clone()).collect();
+            self.line(no, lines.get(no.saturating_sub(1)).copied().unwrap_or(""), &toks);
+            i = end;
+        }
+        Ok(())
+    }
+
+    /// Zmienne przypisane dokładnie raz, literałem, poza blokami
+    fn known(&self) -> HashMap<String, (usize, String)> {
+        self.literals.iter()
+            .filter(|(var, _)| self.writes.get(*var) == Some(&1))
+            .map(|(var, v)| (var.clone(), v.clone()))
+            .collect()
+    }
+}
+
+/// Przebieg optymalizujący --minify
+fn optimize(out: Vec<Out>, known: &HashMap<String, (usize, String)>) -> Vec<Out> {
+    // 1. Gałęzie rozstrzygalne przy generowaniu: prawdziwa zostaje bez `if`, fałszywa wypada
+    let outcome = |i: usize, kind: &Kind| match kind {
+        Kind::Known(active)         => Some(*active),
+        Kind::Compare(var, eq, lit) => known.get(var).filter(|(at, _)| *at < i).map(|(_, v)| (v == lit) == *eq),
+        _ => None,
+    };
+    let mut kept: Vec<Out> = Vec::with_capacity(out.len());
+    let mut unwrapped: Vec<usize> = Vec::new();
+    let mut i = 0;
+    while i < out.len() {
+        let o = &out[i];
+        match outcome(i, &o.kind) {
+            Some(true) => { unwrapped.push(o.depth); i += 1; continue; }
+            Some(false) => {
+                let close = (i + 1..out.len())
+                    .find(|&j| out[j].kind == Kind::Close && out[j].depth == o.depth)
+                    .unwrap_or(out.len());
+                // `? ok` / `? err` za blokiem czyta $? — bez `if` dostałby kod poprzedniej komendy
+                let status_next = out.iter().skip(close + 1).find(|n| n.kind != Kind::Note)
+                    .is_some_and(|n| n.kind == Kind::Status);
+                if !status_next { i = close + 1; continue; }
+            }
+            None => {}
+        }
+        if o.kind == Kind::Close && unwrapped.last() == Some(&o.depth) {
+            unwrapped.pop();
+            i += 1;
+            continue;
+        }
+        let mut o = o.clone();
+        o.depth = o.depth.saturating_sub(unwrapped.len());
+        kept.push(o);
+        i += 1;
+    }
+
+    // 2. Jedna kontrola na narzędzie, sąsiednie exporty w jednym poleceniu, `:` w pustych blokach
+    let mut checked: HashSet<String> = HashSet::new();
+    let mut group: Vec<String> = Vec::new();
+    let mut result: Vec<Out> = Vec::with_capacity(kept.len());
+    for o in kept {
+        match &o.kind {
+            Kind::Note => continue,
+            Kind::Dependency(bin) => {
+                if checked.contains(bin) { continue; }
+                // kontrola w bloku nie musi się wykonać — nie zastępuje późniejszych
+                if o.depth == 0 { checked.insert(bin.clone()); }
+            }
+            Kind::Export(name) => {
+                let assignment = o.text.strip_prefix("export ").unwrap_or(&o.text);
+                // wartości rozwija bash przed wykonaniem export — odwołanie do nazwy z tej
+                // samej grupy widziałoby starą wartość
+                let refers = group.iter().any(|g| assignment.contains(&format!("${{{}}}", g)) || assignment.contains(&format!("${}", g)));
+                if let Some(prev) = result.last_mut().filter(|p| matches!(p.kind, Kind::Export(_)) && p.depth == o.depth) {
+                    if !refers {
+                        prev.text = format!("{} {}", prev.text, assignment);
+                        group.push(name.clone());
+                        continue;
+                    }
+                }
+                group = vec![name.clone()];
+            }
+            // blok, z którego wszystko wypadło — pusty `then … fi` to błąd składni
+            Kind::Close if result.last().is_some_and(|p| p.depth == o.depth
+                && matches!(p.kind, Kind::Open | Kind::Status | Kind::Compare(..) | Kind::Known(_))) => {
+                result.push(Out { depth: o.depth + 1, text: ":".into(), tail: None, kind: Kind::Code });
+            }
+            _ => {}
+        }
+        result.push(o);
+    }
+    result
+}
+
+fn render(out: &[Out], minify: bool) -> String {
+    let mut s = String::new();
+    for o in out {
+        if minify {
+            s.push_str(o.text.trim_start());
+        } else {
+            s.push_str(&"    ".repeat(o.depth));
+            s.push_str(&o.text);
+            if let Some(tail) = &o.tail { s.push_str("  # "); s.push_str(tail); }
+        }
+        s.push('\n');
+    }
+    s
+}
+
+/// Podgląd `hl run --explain`: odpowiednik w bash z numerami linii źródła
+pub fn explain_source(name: &str, source: &str) -> Result<String> {
+    explain_source_with(name, source, ExplainOptions::default())
+}
+
+/// Podgląd z opcjami `--minify` / `--inline`; `name` to ścieżka skryptu (baza dla `<< plik`)
+pub fn explain_source_with(name: &str, source: &str, opts: ExplainOptions) -> Result<String> {
+    let base = Path::new(name).parent().map(Path::to_path_buf).unwrap_or_default();
+    let mut plan = Plan::new(base, opts);
+    plan.emit("#!/usr/bin/env bash".into());
+    plan.push(format!("# hl run --explain {} — podgląd, nic nie zostało wykonane", name), Kind::Note, None);
+    plan.file(source)?;
+    while let Some(block) = plan.blocks.pop() {
+        plan.push(block.close.into(), Kind::Close, Some("brak `done`".into()));
+    }
+    if !opts.minify { return Ok(render(&plan.out, false)); }
+    let known = plan.known();
+    Ok(render(&optimize(plan.out, &known), true))
+}
@@ -0,0 +1,112 @@
+use colored::Colorize;
+use hl_parser::ast::{CommandMode, Node};
+use std::io::IsTerminal;
+use std::process::{Command, Stdio};
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::sync::Arc;
+use std::time::Duration;
+use crate::config::load_config;
+
+// ── Sudo pre-flight ───────────────────────────────────────────────────────────
+//
+// Zamiast pytać o hasło w losowym momencie skryptu, hl przed startem zbiera
+// z AST wszystkie komendy uruchamiane przez sudo (`^>` i warianty), uwierzytelnia raz
+// (`sudo -v`) i odświeża znacznik czasu sudo w tle, dopóki skrypt działa.
+//
+// Wyłączenie: `[sudo] -> preflight => false` w config.hk lub HL_SUDO_PREFLIGHT=0.
+// Pomijane, gdy hl działa jako root albo sudo nie jest zainstalowane.
+
+const KEEPALIVE_EVERY: Duration = Duration::from_secs(50);
+
+fn is_sudo(mode: &CommandMode) -> bool {
+    matches!(mode, CommandMode::Sudo | CommandMode::IsolatedSudo | CommandMode::WithVarsSudo)
+}
+
+/// Wszystkie komendy uruchamiane przez sudo (rekurencyjnie: funkcje, pętle, bloki, goroutines)
+pub fn sudo_commands(nodes: &[Node]) -> Vec<String> {
+    let mut out = Vec::new();
+    collect(nodes, &mut out);
+    out
+}
+
+fn collect(nodes: &[Node], out: &mut Vec<String>) {
+    for node in nodes {
+        match node {
+            Node::Command   { raw, mode, .. }     if is_sudo(mode) => out.push(raw.clone()),
+            Node::PipeToVar { command, mode, .. } if is_sudo(mode) => out.push(command.clone()),
+            Node::RepeatN      { body, .. }
+            | Node::Retry        { body, .. }
+            | Node::Check        { body, .. }
+            | Node::Once         { body, .. }
+            | Node::Undo         { body, .. }
+            | Node::FuncDef      { body, .. }
+            | Node::ArenaFuncDef { body, .. }
+            | Node::Conditional  { body, .. }
+            | Node::IfExpr       { body, .. }
+            | Node::FeatureBlock { body, .. }
+            | Node::ForIn        { body, .. }
+            | Node::WhileLoop    { body, .. }
+            | Node::Goroutine    { body, .. }
+            | Node::ExternDef    { body, .. }
+            | Node::Block        (body)         => collect(body, out),
+            Node::MatchExpr { arms, .. } => for arm in arms { collect(&arm.body, out) },
+            _ => {}
+        }
+    }
+}
+
+fn preflight_enabled() -> bool {
+    if let Ok(v) = std::env::var("HL_SUDO_PREFLIGHT") { return !(v == "0" || v == "false"); }
+    !matches!(load_config().get("sudo", "preflight"), Some("false" | "no" | "0"))
+}
+
+/// Aktywna sesja sudo — wątek keepalive kończy się przy drop
+pub struct SudoSession {
+    stop: Arc<AtomicBool>,
+}
+
+impl Drop for SudoSession {
+    fn drop(&mut self) { self.stop.store(true, Ordering::Relaxed); }
+}
+
+fn sudo_validate(non_interactive: bool) -> bool {
+    let mut cmd = Command::new("sudo");
+    if non_interactive { cmd.arg("-n").stderr(Stdio::null()); }
+    cmd.arg("-v").status().map(|s| s.success()).unwrap_or(false)
+}
+
+/// Uwierzytelnij raz przed startem, jeśli skrypt zawiera komendy sudo
+pub fn sudo_preflight(nodes: &[Node]) -> Option<SudoSession> {
+    // --check-mode nie uruchamia komend sudo
+    if nix::unistd::getuid().is_root() || !preflight_enabled() || which::which("sudo").is_err()
+        || crate::checkmode::check_mode() {
+        return None;
+    }
+    let cmds = sudo_commands(nodes);
+    if cmds.is_empty() { return None; }
+
+    if !sudo_validate(true) {
+        if !std::io::stdin().is_terminal() {
+            eprintln!("{} skrypt zawiera {} komend sudo, a terminal nie jest interaktywny — sudo może się nie powieść",
+                      "UWAGA".yellow().bold(), cmds.len());
+            return None;
+        }
+        eprintln!("{} skrypt uruchomi {} komend przez sudo, m.in.:", "hl:".bright_magenta().bold(), cmds.len());
+        for c in cmds.iter().take(5) { eprintln!("    {} {}", "^>".bright_black(), c); }
+        if !sudo_validate(false) {
+            eprintln!("{} uwierzytelnianie sudo nie powiodło się — komendy ^> mogą pytać ponownie",
+                      "UWAGA".yellow().bold());
+            return None;
+        }
+    }
+
+    let stop = Arc::new(AtomicBool::new(false));
+    let flag = stop.clone();
+    std::thread::spawn(move || {
+        while !flag.load(Ordering::Relaxed) {
+            std::thread::sleep(KEEPALIVE_EVERY);
+            if flag.load(Ordering::Relaxed) || !sudo_validate(true) { break; }
+        }
+    });
+    Some(SudoSession { stop })
+}
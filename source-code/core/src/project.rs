@@ -0,0 +1,520 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+use crate::config::load_hk_file;
+
+// ── Projekt: bit.hk i nazwane programy ────────────────────────────────────────
+//
+//   [project]
+//   -> name  => narzedzia
+//   -> entry => main.hl
+//
+//   [bins]
+//   -> setup  => setup.hl
+//   -> backup => scripts/backup.hl
+//
+// bit.hk jest szukany w katalogu bieżącym i wyżej (jak .hacker-version), ścieżki
+// programów są względem niego. Z [bins] korzystają:
+//
+//   hl run setup                      nazwa zamiast ścieżki (gdy nie ma takiego pliku)
+//   hl compile --bin backup           jeden program → backup.bc obok skryptu
+//   hl compile                        każdy program z [bins]
+//   hl export docker --base <obraz>   kontekst obrazu na program: <out>/<nazwa>/
+//
+// Projekt bez [bins] ma jeden program — `[project] -> entry` pod nazwą projektu.
+//
+// ── Grupy zależności ──────────────────────────────────────────────────────────
+//
+//   [dependencies]
+//   -> hashlib
+//
+//   [groups]
+//   -> dev      => hl-lint testkit
+//   -> test     => testkit mockfs
+//   -> optional => tui
+//
+// [dependencies] instaluje się zawsze, grupy tylko na żądanie — maszyna
+// produkcyjna nie dostaje bibliotek do lintu i testów:
+//
+//   hl fetch --group dev                  brakujące z [dependencies] + dev
+//   bit install --project --groups dev,test
+//
+// bit.lock zapisuje przy pakiecie grupy, z których został zainstalowany
+// ("default" dla [dependencies]).
+//
+// ── Środowiska — overlay na projekt ───────────────────────────────────────────
+//
+//   [env.prod]
+//   -> entry      => deploy/main.hl     ! zamiast [project] -> entry
+//   -> BACKUP_DIR => /srv/backups       ! zmienna skryptu
+//
+// Aktywne środowisko wybiera `hl run --env prod` albo HL_ENV=prod — ta sama
+// zmienna wybiera .env.<HL_ENV>. Zmienne z overlay są nadpisaniami jak .env,
+// ale .env, .env.<HL_ENV> i --var mają pierwszeństwo (dotenv.rs).
+// `-> scripts.<nazwa> => …` podmienia skrypt z [scripts] w tym środowisku.
+//
+// ── Skrypty projektu ──────────────────────────────────────────────────────────
+//
+//   [scripts]
+//   -> build   => hl compile
+//   -> release => hl compile && tar czf dist/{name}-{version}.tgz *.bc
+//
+//   hl script                  lista skryptów
+//   hl script release [-- a]   sh -c w katalogu bit.hk; argumenty jako "$@"
+//
+// {name}, {version}, {dir}, {env} — metadane z [project] (wstawiane w cudzysłowie
+// powłoki); te same wartości są w HL_PROJECT_NAME, HL_PROJECT_VERSION i HL_PROJECT_DIR.
+//
+// ── hl migrate — projekt sprzed bit.hk ────────────────────────────────────────
+//
+// Starszy projekt (np. z `hl new app`) opisuje się w trzech miejscach, które
+// migrate składa w bit.hk:
+//
+//   build.hl  `% BIT_BUILD_INPUT`  → [project] entry   (bez niego: ENTRY_CANDIDATES)
+//             `% BIT_BUILD_OUTPUT` → [project] name    (albo nagłówek `///`, katalog)
+//             sam plik             → [scripts] build => hl run build.hl
+//   `///`     `Version:`           → [project] version
+//   `# <bit/…>` w entry i jego `<<` / `<*` w katalogu projektu → [dependencies]
+//
+// build.hl zostaje (BIT_BUILD_TARGET czyta dalej bit), więc nic nie ginie.
+// Wynik przechodzi walidację `hl config validate` przed zapisem; bez --write
+// migrate tylko go wypisuje. Istniejącego bit.hk nie nadpisuje.
+
+pub const DEFAULT_GROUP: &str = "default";
+
+/// Zmienna wybierająca środowisko ([env.<nazwa>] w bit.hk, .env.<nazwa>)
+pub const ENV_VAR: &str = "HL_ENV";
+
+pub const PROJECT_FILE: &str = "bit.hk";
+
+/// Plik wejściowy bez `[project] -> entry` — w kolejności `bit run`
+pub const ENTRY_CANDIDATES: &[&str] = &["source-code/main.hl", "src/main.hl", "main.hl"];
+
+const BUILD_FILE: &str = "build.hl";
+
+#[derive(Debug, Clone)]
+pub struct Bin {
+    pub name:   String,
+    pub script: PathBuf,
+}
+
+#[derive(Debug, Clone, PartialEq)]
+pub struct Dependency {
+    pub name:  String,
+    pub group: String,
+}
+
+#[derive(Debug, Clone)]
+pub struct Project {
+    pub path:  PathBuf,
+    pub name:  String,
+    pub bins:  Vec<Bin>,
+    /// `[project] -> entry` po nałożeniu overlay aktywnego środowiska
+    pub entry: Option<PathBuf>,
+    /// Środowiska zdefiniowane w [env.<nazwa>]
+    pub envs:  Vec<String>,
+    /// Zmienne z [env.<aktywne>]
+    pub vars:  Vec<(String, String)>,
+    pub version: Option<String>,
+    /// [scripts] po nałożeniu overlay aktywnego środowiska
+    pub scripts: Vec<(String, String)>,
+}
+
+/// Aktywne środowisko z HL_ENV (ustawia je też `hl run --env`)
+pub fn active_env() -> Option<String> {
+    std::env::var(ENV_VAR).ok().map(|n| n.trim().to_string()).filter(|n| !n.is_empty())
+}
+
+fn valid_bin_name(name: &str) -> bool {
+    name.chars().next().is_some_and(|c| c.is_ascii_alphanumeric())
+        && name.chars().all(|c| c.is_ascii_alphanumeric() || c == '-' || c == '_')
+}
+
+/// Najbliższy bit.hk od `start` w górę
+pub fn find_project(start: &Path) -> Option<PathBuf> {
+    start.ancestors().map(|dir| dir.join(PROJECT_FILE)).find(|p| p.is_file())
+}
+
+pub fn load_project(path: &Path) -> Result<Project> {
+    let cfg = load_hk_file(path).with_context(|| format!("Nie można wczytać {}", path.display()))?;
+    let dir = path.parent().unwrap_or(Path::new("."));
+    let name = cfg.get("project", "name").map(|n| n.trim().to_string()).filter(|n| !n.is_empty())
+        .or_else(|| dir.canonicalize().ok()?.file_name().map(|n| n.to_string_lossy().to_string()))
+        .unwrap_or_else(|| "main".into());
+
+    let envs: Vec<String> = cfg.hk_config().iter()
+        .filter_map(|(section, _)| section.strip_prefix("env.").map(str::to_string))
+        .collect();
+    let mut entry = cfg.get("project", "entry").map(|e| e.trim().to_string());
+    let mut vars = Vec::new();
+    let mut scripts = cfg.entries("scripts");
+    for (script, cmd) in &scripts {
+        if !valid_bin_name(script) {
+            bail!("{}: [scripts] '{}' — nazwa skryptu: litery, cyfry, '-' i '_'", path.display(), script);
+        }
+        if cmd.trim().is_empty() { bail!("{}: [scripts] {} — pusta komenda", path.display(), script); }
+    }
+    if let Some(env) = active_env() {
+        for (key, value) in cfg.entries(&format!("env.{}", env)) {
+            if let Some(script) = key.strip_prefix("scripts.") {
+                match scripts.iter_mut().find(|(s, _)| s == script) {
+                    Some((_, cmd)) => *cmd = value,
+                    None           => scripts.push((script.to_string(), value)),
+                }
+                continue;
+            }
+            match key.as_str() {
+                "entry" => entry = Some(value.trim().to_string()),
+                _ if crate::dotenv::valid_name(&key) => vars.push((key, value)),
+                _ => bail!("{}: [env.{}] '{}' — klucz to `entry`, `scripts.<nazwa>` albo nazwa zmiennej", path.display(), env, key),
+            }
+        }
+    }
+    let version = cfg.get("project", "version").map(|v| v.trim().to_string()).filter(|v| !v.is_empty());
+    let entry = entry.filter(|e| !e.is_empty()).map(|e| dir.join(e));
+
+    let mut bins: Vec<Bin> = Vec::new();
+    for (bin, script) in cfg.entries("bins") {
+        let script = script.trim();
+        if !valid_bin_name(&bin) {
+            bail!("{}: [bins] '{}' — nazwa programu: litery, cyfry, '-' i '_'", path.display(), bin);
+        }
+        if script.is_empty() { bail!("{}: [bins] {} — brak ścieżki skryptu", path.display(), bin); }
+        bins.push(Bin { name: bin, script: dir.join(script) });
+    }
+    if bins.is_empty() {
+        if let Some(entry) = entry.as_ref().filter(|e| e.extension().is_some_and(|x| x == "hl")) {
+            bins.push(Bin { name: name.clone(), script: entry.clone() });
+        }
+    }
+    Ok(Project { path: path.to_path_buf(), name, bins, entry, envs, vars, version, scripts })
+}
+
+/// Projekt katalogu bieżącego (bit.hk tu albo wyżej)
+pub fn current_project() -> Result<Project> {
+    let cwd = std::env::current_dir()?;
+    match find_project(&cwd) {
+        Some(path) => load_project(&path),
+        None       => bail!("Brak {} w {} ani wyżej — podaj plik skryptu albo dodaj sekcję [bins]", PROJECT_FILE, cwd.display()),
+    }
+}
+
+/// Nazwa pakietu z linii `-> nazwa` (tak jak czyta ją bit: pierwsze słowo)
+fn entry_name(line: &str) -> Option<&str> {
+    line.trim_start().strip_prefix("->")?.split_whitespace().next().filter(|n| *n != "=>")
+}
+
+/// Sekcje [dependencies] i [groups] — czytane linia po linii jak w bit.hl,
+/// bo `-> nazwa` bez wartości to zwykły wpis zależności
+fn parse_dependencies(text: &str) -> (Vec<String>, Vec<(String, Vec<String>)>) {
+    let (mut base, mut groups) = (Vec::new(), Vec::<(String, Vec<String>)>::new());
+    let mut section = "";
+    for line in text.lines() {
+        let t = line.trim();
+        if t.starts_with('[') {
+            section = t.trim_start_matches('[').split(']').next().unwrap_or("").trim();
+            continue;
+        }
+        match section {
+            "dependencies" => if let Some(n) = entry_name(t) { base.push(n.to_string()) },
+            "groups" => {
+                let Some(rest) = t.strip_prefix("->") else { continue };
+                let (group, pkgs) = rest.split_once("=>").unwrap_or((rest, ""));
+                let group = group.trim();
+                if group.is_empty() { continue; }
+                let pkgs = pkgs.split(|c: char| c == ',' || c.is_whitespace()).filter(|p| !p.is_empty()).map(str::to_string);
+                match groups.iter_mut().find(|(g, _)| g == group) {
+                    Some((_, list)) => list.extend(pkgs),
+                    None            => groups.push((group.to_string(), pkgs.collect())),
+                }
+            }
+            _ => {}
+        }
+    }
+    (base, groups)
+}
+
+impl Project {
+    /// Grupy zależności zdefiniowane w [groups]
+    pub fn groups(&self) -> Result<Vec<String>> {
+        let text = std::fs::read_to_string(&self.path).with_context(|| format!("Nie można wczytać {}", self.path.display()))?;
+        Ok(parse_dependencies(&text).1.into_iter().map(|(g, _)| g).collect())
+    }
+
+    /// Zależności z [dependencies] i wskazanych grup, bez powtórzeń (pakiet w kilku grupach — pierwsza)
+    pub fn dependencies(&self, groups: &[String]) -> Result<Vec<Dependency>> {
+        let text = std::fs::read_to_string(&self.path).with_context(|| format!("Nie można wczytać {}", self.path.display()))?;
+        let (base, defined) = parse_dependencies(&text);
+        let mut out: Vec<Dependency> = Vec::new();
+        let mut add = |name: String, group: &str| {
+            if !out.iter().any(|d| d.name == name) { out.push(Dependency { name, group: group.to_string() }); }
+        };
+        for name in base { add(name, DEFAULT_GROUP); }
+        for group in groups.iter().filter(|g| g.as_str() != DEFAULT_GROUP) {
+            let Some((_, pkgs)) = defined.iter().find(|(g, _)| g == group) else {
+                let known: Vec<&str> = defined.iter().map(|(g, _)| g.as_str()).collect();
+                bail!("{}: nie ma grupy '{}' w [groups] (są: {})", self.path.display(), group,
+                      if known.is_empty() { "brak".to_string() } else { known.join(", ") });
+            };
+            for name in pkgs { add(name.clone(), group); }
+        }
+        Ok(out)
+    }
+
+    pub fn bin(&self, name: &str) -> Result<&Bin> {
+        self.bins.iter().find(|b| b.name == name).ok_or_else(|| {
+            let known: Vec<&str> = self.bins.iter().map(|b| b.name.as_str()).collect();
+            anyhow::anyhow!("{}: nie ma programu '{}' w [bins] (są: {})", self.path.display(), name,
+                            if known.is_empty() { "brak".to_string() } else { known.join(", ") })
+        })
+    }
+
+    /// Wskazany program albo wszystkie
+    pub fn select(&self, name: Option<&str>) -> Result<Vec<&Bin>> {
+        match name {
+            Some(n) => Ok(vec![self.bin(n)?]),
+            None if self.bins.is_empty() => bail!("{}: brak programów — dodaj sekcję [bins] albo [project] -> entry", self.path.display()),
+            None => Ok(self.bins.iter().collect()),
+        }
+    }
+
+    /// Komenda skryptu z [scripts] z wstawionymi metadanymi projektu
+    pub fn script_command(&self, name: &str) -> Result<String> {
+        let Some((_, cmd)) = self.scripts.iter().find(|(s, _)| s == name) else {
+            let known: Vec<&str> = self.scripts.iter().map(|(s, _)| s.as_str()).collect();
+            bail!("{}: nie ma skryptu '{}' w [scripts] (są: {})", self.path.display(), name,
+                  if known.is_empty() { "brak".to_string() } else { known.join(", ") });
+        };
+        let q = crate::remote::shell_quote;
+        let dir = self.path.parent().unwrap_or(Path::new(".")).display().to_string();
+        Ok(cmd.replace("{name}", &q(&self.name))
+            .replace("{version}", &q(self.version.as_deref().unwrap_or("")))
+            .replace("{dir}", &q(&dir))
+            .replace("{env}", &q(&active_env().unwrap_or_default())))
+    }
+}
+
+impl Bin {
+    /// Artefakt programu obok skryptu, nazwany jak program: scripts/backup.bc
+    pub fn artifact(&self, ext: &str) -> PathBuf {
+        self.script.with_file_name(format!("{}.{}", self.name, ext))
+    }
+}
+
+/// `hl run <nazwa>`: skrypt programu z [bins], jeśli argument to nazwa, a nie istniejący plik
+pub fn bin_script(arg: &Path) -> Result<Option<PathBuf>> {
+    let Some(name) = arg.to_str().filter(|n| valid_bin_name(n)) else { return Ok(None) };
+    if arg.exists() { return Ok(None); }
+    let Some(path) = find_project(&std::env::current_dir()?) else { return Ok(None) };
+    let project = load_project(&path)?;
+    Ok(project.bins.into_iter().find(|b| b.name == name).map(|b| b.script))
+}
+
+/// `hl run <katalog>`: plik wejściowy jak w `bit run` — `[project] -> entry`
+/// z bit.hk (w katalogu albo wyżej), potem ENTRY_CANDIDATES w katalogu
+pub fn detect_entry(dir: &Path) -> Result<Option<PathBuf>> {
+    let abs = dir.canonicalize().unwrap_or_else(|_| dir.to_path_buf());
+    if let Some(path) = find_project(&abs) {
+        if let Some(entry) = load_project(&path)?.entry {
+            if !entry.is_file() { bail!("{}: [project] entry {} nie istnieje", path.display(), entry.display()); }
+            return Ok(Some(entry));
+        }
+    }
+    Ok(ENTRY_CANDIDATES.iter().map(|c| dir.join(c)).find(|p| p.is_file()))
+}
+
+/// `hl run --env <nazwa>`: środowisko musi istnieć w bit.hk albo jako .env.<nazwa> obok skryptu
+pub fn check_env(script: &Path, name: &str) -> Result<()> {
+    let dir = script.parent().filter(|d| !d.as_os_str().is_empty()).unwrap_or(Path::new("."));
+    if dir.join(format!("{}.{}", crate::dotenv::DOTENV_FILE, name)).is_file() { return Ok(()); }
+    let dir = dir.canonicalize().unwrap_or_else(|_| dir.to_path_buf());
+    let envs = match find_project(&dir) {
+        Some(path) => load_project(&path)?.envs,
+        None       => Vec::new(),
+    };
+    if envs.iter().any(|e| e == name) { return Ok(()); }
+    bail!("środowisko '{}' nie istnieje — brak [env.{}] w {} i pliku .env.{} (są: {})", name, name, PROJECT_FILE, name,
+          if envs.is_empty() { "brak".to_string() } else { envs.join(", ") })
+}
+
+/// hl script [nazwa] [-- argumenty] — bez nazwy lista; zwraca kod wyjścia skryptu
+pub fn cmd_script(name: Option<&str>, args: &[String]) -> Result<i32> {
+    let project = current_project()?;
+    let Some(name) = name else {
+        if project.scripts.is_empty() {
+            println!("{}", format!("Brak sekcji [scripts] w {}.", project.path.display()).bright_black());
+        }
+        for (script, cmd) in &project.scripts {
+            println!("  {:<16} {}", script.bright_cyan(), cmd.bright_black());
+        }
+        return Ok(crate::exit::OK);
+    };
+    let cmd = project.script_command(name)?;
+    let dir = project.path.parent().unwrap_or(Path::new("."));
+    eprintln!("{} {} {}", "hl script:".bright_magenta().bold(), name.bright_cyan(), cmd.bright_black());
+    let status = std::process::Command::new("sh").arg("-c").arg(&cmd).arg(name).args(args)
+        .current_dir(dir)
+        .env("HL_PROJECT_NAME", &project.name)
+        .env("HL_PROJECT_VERSION", project.version.as_deref().unwrap_or(""))
+        .env("HL_PROJECT_DIR", dir)
+        .status()
+        .context("Nie można uruchomić sh")?;
+    Ok(status.code().unwrap_or(crate::exit::FAILURE))
+}
+
+/// `--group dev --group test` i `--group dev,test` — jedna lista
+pub fn split_groups(args: &[String]) -> Vec<String> {
+    let mut out: Vec<String> = Vec::new();
+    for g in args.iter().flat_map(|a| a.split(',')).map(str::trim).filter(|g| !g.is_empty()) {
+        if !out.iter().any(|o| o == g) { out.push(g.to_string()); }
+    }
+    out
+}
+
+fn installed(project: &Project, name: &str) -> bool {
+    crate::libs::lib_search_path(name, crate::libs::source_dir(&project.path)).iter().any(|d| d.is_dir())
+}
+
+/// hl fetch — zależności projektu ([dependencies] i wybrane grupy); brakujące
+/// instaluje przez `bit install --project`, żeby bit.lock dostał grupy.
+/// Zwraca kod wyjścia.
+pub fn cmd_fetch(groups: &[String], dry_run: bool) -> Result<i32> {
+    let project = current_project()?;
+    let groups = split_groups(groups);
+    let deps = project.dependencies(&groups)?;
+    let shown = if groups.is_empty() { DEFAULT_GROUP.to_string() } else { format!("{} + {}", DEFAULT_GROUP, groups.join(", ")) };
+    println!("{} {} {}", "hl fetch:".bright_magenta().bold(), project.name.bright_white(), format!("(grupy: {})", shown).bright_black());
+    if deps.is_empty() {
+        println!("  {}", "brak zależności".bright_black());
+        return Ok(crate::exit::OK);
+    }
+
+    let mut missing = 0;
+    for d in &deps {
+        let ok = installed(&project, &d.name);
+        if !ok { missing += 1; }
+        println!("  {} {:<20} {}", if ok { "✓".green() } else { "•".yellow() }, d.name,
+                 format!("[{}]{}", d.group, if ok { "" } else { " — brak" }).bright_black());
+    }
+    if missing == 0 {
+        println!("  {} wszystkie zależności zainstalowane", "✓".green().bold());
+        return Ok(crate::exit::OK);
+    }
+    let mut args = vec!["install".to_string(), "--project".to_string()];
+    if !groups.is_empty() { args.extend(["--groups".to_string(), groups.join(",")]); }
+    if dry_run {
+        println!("  {} do zainstalowania: {} — bit {}", "→".bright_cyan(), missing, args.join(" "));
+        return Ok(crate::exit::OK);
+    }
+    if which::which("bit").is_err() {
+        return Err(crate::exit::classified(crate::exit::ErrorClass::Dependency, format!(
+            "Brak managera pakietów bit w PATH — zainstaluj zależności ręcznie: bit {}", args.join(" "))));
+    }
+    let dir = project.path.parent().unwrap_or(Path::new("."));
+    let status = std::process::Command::new("bit").args(&args).current_dir(dir).status()?;
+    Ok(status.code().unwrap_or(crate::exit::FAILURE))
+}
+
+/// `% BIT_BUILD_<klucz> = wartość` z build.hl (bez komentarza `#` za wartością)
+fn build_var(source: &str, key: &str) -> Option<String> {
+    source.lines().find_map(|line| {
+        let (name, value) = line.trim().strip_prefix('%')?.split_once('=')?;
+        if name.trim().strip_prefix("BIT_BUILD_") != Some(key) { return None; }
+        let value = value.split('#').next().unwrap_or("").trim();
+        (!value.is_empty()).then(|| value.to_string())
+    })
+}
+
+/// Nagłówek `///` skryptu: pierwsze słowo opisu i `Version:`
+fn script_header(source: &str) -> (Option<String>, Option<String>) {
+    let (mut title, mut version) = (None, None);
+    for line in source.lines().skip_while(|l| l.starts_with("#!")) {
+        let Some(doc) = line.trim().strip_prefix("///") else { break };
+        let doc = doc.trim();
+        if let Some(v) = doc.strip_prefix("Version:") {
+            version = Some(v.trim().to_string()).filter(|v| !v.is_empty());
+        } else if title.is_none() && !doc.contains(':') {
+            title = doc.split_whitespace().next().map(str::to_string);
+        }
+    }
+    (title, version)
+}
+
+/// Biblioteki bit importowane przez skrypty projektu (pliki spoza `dir` — np.
+/// źródła zainstalowanych bibliotek — pomija, ich zależności instaluje bit)
+fn bit_imports(entry: &Path, dir: &Path) -> Result<Vec<String>> {
+    let dir = dir.canonicalize().unwrap_or_else(|_| dir.to_path_buf());
+    let mut names: Vec<String> = Vec::new();
+    for file in crate::graph::resolved_sources(entry)? {
+        if !file.canonicalize().is_ok_and(|f| f.starts_with(&dir)) { continue; }
+        let source = std::fs::read_to_string(&file)?;
+        let nodes = hl_parser::parse_source(&source).map_err(|e| anyhow::anyhow!("{}: {}", file.display(), e))?;
+        crate::diagnostics::visit_nodes(&nodes, false, &mut |node, _| {
+            let hl_parser::ast::Node::Import { lib, .. } = node else { return };
+            let lib = lib.trim().trim_start_matches('<').trim_end_matches('>');
+            let src = crate::libs::parse_import_spec(lib).or_else(|| crate::libs::bare_bit_import(lib));
+            if let Some(crate::libs::ImportSource::Bit { name, .. }) = src {
+                if !names.contains(&name) { names.push(name); }
+            }
+        });
+    }
+    Ok(names)
+}
+
+/// Treść bit.hk dla projektu w `dir` zbudowana z build.hl, nagłówka i importów entry
+fn migrated_manifest(dir: &Path) -> Result<String> {
+    let build = std::fs::read_to_string(dir.join(BUILD_FILE)).ok();
+    let entry = build.as_deref().and_then(|b| build_var(b, "INPUT"))
+        .filter(|e| dir.join(e).is_file())
+        .or_else(|| ENTRY_CANDIDATES.iter().find(|c| dir.join(c).is_file()).map(|c| c.to_string()));
+    let Some(entry) = entry else {
+        bail!("{}: nie znaleziono pliku wejściowego (BIT_BUILD_INPUT w {}, {})", dir.display(), BUILD_FILE, ENTRY_CANDIDATES.join(", "));
+    };
+    let source = std::fs::read_to_string(dir.join(&entry)).with_context(|| format!("Nie można wczytać {}", entry))?;
+    let (title, version) = script_header(&source);
+    let name = build.as_deref().and_then(|b| build_var(b, "OUTPUT"))
+        .or(title)
+        .filter(|n| valid_bin_name(n))
+        .or_else(|| dir.canonicalize().ok()?.file_name().map(|n| n.to_string_lossy().to_string()))
+        .unwrap_or_else(|| "main".into());
+
+    let mut out = format!("[project]\n-> name    => {}\n-> entry   => {}\n", name, entry);
+    if let Some(v) = version { out.push_str(&format!("-> version => {}\n", v)); }
+    let deps = bit_imports(&dir.join(&entry), dir)?;
+    if !deps.is_empty() {
+        out.push_str("\n[dependencies]\n");
+        for dep in deps { out.push_str(&format!("-> {}\n", dep)); }
+    }
+    if build.is_some() {
+        out.push_str(&format!("\n[scripts]\n-> build => hl run {}\n", BUILD_FILE));
+    }
+    Ok(out)
+}
+
+/// hl migrate [katalog] [--write] — bit.hk ze starszego układu projektu; Ok(false) przy błędach walidacji
+pub fn cmd_migrate(dir: &Path, write: bool) -> Result<bool> {
+    let target = dir.join(PROJECT_FILE);
+    if target.exists() { bail!("{} już istnieje — sprawdź go przez hl config validate {}", target.display(), target.display()); }
+    let manifest = migrated_manifest(dir)?;
+
+    // Walidacja na pliku obok (load_project czyta z dysku), potem rename albo usunięcie
+    let part = dir.join(format!(".{}.{}.migrate", PROJECT_FILE, std::process::id()));
+    std::fs::write(&part, &manifest).with_context(|| format!("Nie można zapisać {}", part.display()))?;
+    let diags = crate::config_check::validate_project_source(&part, &manifest);
+    let ok = !diags.iter().any(|d| d.level == crate::diagnostics::DiagLevel::Error);
+    let saved = if ok && write { std::fs::rename(&part, &target).map_err(anyhow::Error::from) } else { Ok(()) };
+    let _ = std::fs::remove_file(&part);
+    saved.with_context(|| format!("Nie można zapisać {}", target.display()))?;
+
+    print!("{}", manifest);
+    if !diags.is_empty() {
+        crate::diagnostics::DiagRenderer::new(PROJECT_FILE, &manifest).emit_all(&diags);
+        crate::diagnostics::DiagSummary::from_diags(&diags).print();
+    }
+    match (ok, write) {
+        (false, _)    => {}
+        (true, true)  => println!("{} {}", "✓".green(), target.display()),
+        (true, false) => println!("{}", format!("Bez zapisu — hl migrate --write zapisze {}.", target.display()).bright_black()),
+    }
+    Ok(ok)
+}
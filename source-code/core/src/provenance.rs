@@ -0,0 +1,179 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::path::{Path, PathBuf};
+use crate::exit::{classified, ErrorClass};
+use crate::libs::{lib_source_file_in, parse_import_spec, source_dir, sha256_file, ImportSource};
+use crate::signing::{signature_path, verify_signature};
+
+// ── Pochodzenie artefaktów (provenance) ───────────────────────────────────────
+//
+//   hl compile --provenance x.hl   → x.bc + x.bc.provenance.json
+//   hl compile --sign x.hl         → to samo + x.bc.sig i x.bc.provenance.json.sig
+//   hl verify x.bc                 podpisy, sha256 artefaktu i źródeł
+//   hl verify --allow-unsigned x.bc  artefakt bez podpisu (tylko sha256 z provenance)
+//
+// Plik provenance (w stylu SLSA) zapisuje, z czego i czym zbudowano artefakt:
+// sha256 artefaktu, źródło i każdy import z rozwiązanym plikiem, jego sha256
+// i pochodzeniem (`origin`: URL biblioteki, `git+<remote>@<commit>` dla
+// bibliotek z repozytorium git, inaczej `file:<ścieżka>`), wersję hl, opcje
+// kompilacji i user@host. Podpisy: klucz hl sign.
+//
+// verify domyślnie wymaga podpisu artefaktu; podpisany artefakt wymaga też
+// podpisanego provenance — inaczej builder i materials nie są niczym chronione.
+
+pub const PROVENANCE_TYPE: &str = "https://hackeros.dev/hl/provenance/v1";
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct Digest {
+    pub uri:    String,
+    /// None — importu nie dało się rozwiązać do pliku (np. biblioteka wbudowana)
+    pub sha256: Option<String>,
+    /// Skąd pochodził rozwiązany import (URL, repozytorium@commit, plik)
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub origin: Option<String>,
+}
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct Provenance {
+    #[serde(rename = "_type")]
+    pub kind:      String,
+    pub subject:   Digest,
+    pub builder:   String,
+    pub toolchain: String,
+    pub built_at:  u64,
+    /// Opcje kompilacji (opt_level, stripped, …)
+    pub params:    serde_json::Value,
+    /// Źródło, potem importy w kolejności z pliku
+    pub materials: Vec<Digest>,
+}
+
+pub fn provenance_path(artifact: &Path) -> PathBuf {
+    let mut s = artifact.as_os_str().to_owned();
+    s.push(".provenance.json");
+    PathBuf::from(s)
+}
+
+/// `# <spec>` na początku linii skryptu
+pub(crate) fn import_specs(source: &str) -> Vec<String> {
+    source.lines()
+        .map(str::trim)
+        .filter(|t| t.starts_with('#') && !t.starts_with("#!"))
+        .filter_map(|t| hl_parser::parse_import_line(&t[1..]))
+        .map(|d| d.spec)
+        .collect()
+}
+
+/// `git -C dir <args>` — pierwsza linia wyjścia
+fn git_output(dir: &Path, args: &[&str]) -> Option<String> {
+    let out = std::process::Command::new("git").arg("-C").arg(dir).args(args)
+        .stderr(std::process::Stdio::null()).output().ok()?;
+    let line = String::from_utf8_lossy(&out.stdout).lines().next()?.trim().to_string();
+    (out.status.success() && !line.is_empty()).then_some(line)
+}
+
+/// Pochodzenie pliku biblioteki: URL importu, repozytorium git z commitem albo ścieżka
+fn origin(spec: &str, file: &Path) -> String {
+    if let Some(ImportSource::Url { url, .. }) = parse_import_spec(spec.trim_start_matches('<').trim_end_matches('>')) {
+        return url;
+    }
+    let dir = file.parent().unwrap_or(Path::new("."));
+    match (git_output(dir, &["remote", "get-url", "origin"]), git_output(dir, &["rev-parse", "HEAD"])) {
+        (Some(remote), Some(commit)) => format!("git+{}@{}", remote, commit),
+        _ => format!("file:{}", file.display()),
+    }
+}
+
+fn materials(source_path: &Path, source: &str) -> Result<Vec<Digest>> {
+    let mut out = vec![Digest { uri: format!("file:{}", source_path.display()), sha256: Some(sha256_file(source_path)?), origin: None }];
+    for spec in import_specs(source) {
+        let file = lib_source_file_in(&spec, source_dir(source_path));
+        let sha256 = file.as_deref().map(sha256_file).transpose()?;
+        let origin = file.as_deref().map(|f| origin(&spec, f));
+        out.push(Digest { uri: spec, sha256, origin });
+    }
+    Ok(out)
+}
+
+/// Zapisz `<artefakt>.provenance.json`
+pub fn write_provenance(artifact: &Path, source_path: &Path, params: serde_json::Value) -> Result<PathBuf> {
+    let source = std::fs::read_to_string(source_path)?;
+    let user = std::env::var("USER").unwrap_or_else(|_| "hl".into());
+    let host = std::fs::read_to_string("/etc/hostname").unwrap_or_else(|_| "hackeros".into());
+    let name = artifact.file_name().and_then(|n| n.to_str()).unwrap_or("artifact").to_string();
+    let prov = Provenance {
+        kind:      PROVENANCE_TYPE.into(),
+        subject:   Digest { uri: name, sha256: Some(sha256_file(artifact)?), origin: None },
+        builder:   format!("{}@{}", user, host.trim()),
+        toolchain: format!("hl {}", env!("CARGO_PKG_VERSION")),
+        built_at:  std::time::SystemTime::now().duration_since(std::time::UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0),
+        params,
+        materials: materials(source_path, &source)?,
+    };
+    let path = provenance_path(artifact);
+    std::fs::write(&path, serde_json::to_string_pretty(&prov)? + "\n")?;
+    Ok(path)
+}
+
+/// hl verify — podpisy, sha256 artefaktu i źródeł z provenance; `allow_unsigned` — bez wymogu podpisu
+pub fn cmd_verify_artifact(artifact: &Path, allow_unsigned: bool) -> Result<()> {
+    if !artifact.is_file() { bail!("Plik nie istnieje: {}", artifact.display()); }
+    let prov_path = provenance_path(artifact);
+    let has_sig = signature_path(artifact).exists();
+    if !has_sig && !prov_path.exists() {
+        bail!("{}: brak podpisu i provenance — zbuduj przez `hl compile --sign`", artifact.display());
+    }
+    println!("{} {}", "hl verify:".bright_magenta().bold(), artifact.display().to_string().bright_white());
+    let denied = |msg: String| classified(ErrorClass::Denied, msg);
+
+    if has_sig {
+        let who = verify_signature(artifact).map_err(|e| denied(e.to_string()))?;
+        println!("  {} podpis artefaktu: {}", "✓".green(), who.bright_white());
+    } else if allow_unsigned {
+        println!("  {} artefakt bez podpisu (--allow-unsigned)", "·".bright_black());
+    } else {
+        return Err(denied(format!("{}: artefakt bez podpisu — zbuduj przez `hl compile --sign` albo użyj --allow-unsigned",
+                                  artifact.display())));
+    }
+    if !prov_path.exists() {
+        println!("  {} brak {}", "·".bright_black(), prov_path.display());
+        return Ok(());
+    }
+    if signature_path(&prov_path).exists() {
+        let who = verify_signature(&prov_path).map_err(|e| denied(e.to_string()))?;
+        println!("  {} podpis provenance: {}", "✓".green(), who.bright_white());
+    } else if has_sig {
+        return Err(denied(format!("{}: artefakt jest podpisany, a provenance nie (brak {})",
+                                  prov_path.display(), signature_path(&prov_path).display())));
+    }
+
+    let prov: Provenance = serde_json::from_str(&std::fs::read_to_string(&prov_path)?)
+        .map_err(|e| anyhow::anyhow!("{}: {}", prov_path.display(), e))?;
+    let actual = sha256_file(artifact)?;
+    if prov.subject.sha256.as_deref() != Some(actual.as_str()) {
+        return Err(denied(format!("sha256 artefaktu {} nie zgadza się z provenance", actual)));
+    }
+    println!("  {} sha256 {}", "✓".green(), actual.bright_black());
+    println!("  {} {} · {} · {}", "·".bright_black(), prov.toolchain, prov.builder, prov.params);
+
+    // Źródła mogły się zmienić po zbudowaniu — to informacja, nie błąd artefaktu.
+    // Biblioteki z libs/ i vendor/ — względem skryptu (pierwszy materiał)
+    let script = prov.materials.first().and_then(|m| m.uri.strip_prefix("file:")).map(PathBuf::from).unwrap_or_default();
+    for m in &prov.materials {
+        let local = match m.uri.strip_prefix("file:") {
+            Some(p) => Some(PathBuf::from(p)).filter(|p| p.is_file()),
+            None    => lib_source_file_in(&m.uri, source_dir(&script)),
+        };
+        let status = match (&m.sha256, local) {
+            (None, _)              => "nierozwiązany przy budowaniu".bright_black(),
+            (Some(_), None)        => "brak lokalnie".bright_black(),
+            (Some(want), Some(f))  => match sha256_file(&f) {
+                Ok(have) if have == *want => "zgodny".green(),
+                _                         => "zmieniony od budowania".yellow(),
+            },
+        };
+        println!("    {:<40} {}", m.uri, status);
+        if let Some(origin) = &m.origin { println!("    {:<40} {}", "", origin.bright_black()); }
+    }
+    Ok(())
+}
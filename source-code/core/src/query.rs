@@ -0,0 +1,250 @@
+use anyhow::{bail, Context, Result};
+use serde_json::{Map, Value};
+use std::io::Read;
+use std::path::Path;
+
+// ── hl json / hl yaml — dane strukturalne bez jq i yq ─────────────────────────
+//
+//   hl json get .server.port config.json      wartość (tekst bez cudzysłowów)
+//   hl json get '.hosts[].name' inv.json      każdy element listy
+//   hl yaml keys .services compose.yml
+//   hl json has .token auth.json               exit 0 / 1
+//   hl json len .items data.json
+//   hl json set .server.port 8080 config.json  zapis w miejscu
+//   hl json merge a.json b.json                głębokie scalenie (b wygrywa)
+//
+// Ścieżka: `.`, `.klucz`, `."klucz z kropką"`, `[n]` (ujemne od końca), `[]`.
+// Plik `-` albo brak pliku — stdin. Format z rozszerzenia (.yaml / .yml) albo
+// z komendy (hl yaml). Obiekty i listy są wypisywane w formacie wejścia.
+// Brak wartości pod ścieżką: nic na stdout, exit 1.
+//
+// Biblioteka std/json korzysta z tych komend przez @HL_BIN, więc skrypty
+// działają na systemach bez jq.
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum Format { Json, Yaml }
+
+impl Format {
+    /// Format pliku: jawny (hl yaml) albo z rozszerzenia
+    pub fn detect(forced: Option<Format>, file: Option<&Path>) -> Format {
+        forced.unwrap_or_else(|| match file.and_then(|f| f.extension()).and_then(|e| e.to_str()) {
+            Some("yaml") | Some("yml") => Format::Yaml,
+            _                          => Format::Json,
+        })
+    }
+}
+
+#[derive(Debug, Clone, PartialEq)]
+enum Seg {
+    Key(String),
+    Index(i64),
+    Each,
+}
+
+fn parse_path(path: &str) -> Result<Vec<Seg>> {
+    let p = path.trim();
+    if !p.starts_with('.') && !p.starts_with('[') { bail!("ścieżka '{}' musi zaczynać się od . (np. .klucz)", path); }
+    let chars: Vec<char> = p.chars().collect();
+    let mut segs = Vec::new();
+    let mut i = 0;
+    while i < chars.len() {
+        match chars[i] {
+            '.' => {
+                i += 1;
+                if i < chars.len() && chars[i] == '"' {
+                    let end = chars[i + 1..].iter().position(|c| *c == '"')
+                        .with_context(|| format!("niezamknięty cudzysłów w '{}'", path))?;
+                    segs.push(Seg::Key(chars[i + 1..i + 1 + end].iter().collect()));
+                    i += end + 2;
+                } else {
+                    let start = i;
+                    while i < chars.len() && chars[i] != '.' && chars[i] != '[' { i += 1; }
+                    if i > start { segs.push(Seg::Key(chars[start..i].iter().collect())); }
+                }
+            }
+            '[' => {
+                let end = chars[i..].iter().position(|c| *c == ']')
+                    .with_context(|| format!("niezamknięty [ w '{}'", path))?;
+                let inner: String = chars[i + 1..i + end].iter().collect();
+                let inner = inner.trim();
+                if inner.is_empty() {
+                    segs.push(Seg::Each);
+                } else if let Ok(n) = inner.parse::<i64>() {
+                    segs.push(Seg::Index(n));
+                } else {
+                    segs.push(Seg::Key(inner.trim_matches('"').to_string()));
+                }
+                i += end + 1;
+            }
+            c => bail!("nieoczekiwany znak '{}' w ścieżce '{}'", c, path),
+        }
+    }
+    Ok(segs)
+}
+
+fn index(list: &[Value], n: i64) -> Option<&Value> {
+    let i = if n < 0 { list.len().checked_sub(n.unsigned_abs() as usize)? } else { n as usize };
+    list.get(i)
+}
+
+/// Wartości pod ścieżką (`[]` daje wiele)
+fn select<'a>(root: &'a Value, segs: &[Seg]) -> Vec<&'a Value> {
+    let mut cur = vec![root];
+    for seg in segs {
+        cur = cur.into_iter().flat_map(|v| match (seg, v) {
+            (Seg::Key(k), Value::Object(m))  => m.get(k).into_iter().collect::<Vec<_>>(),
+            (Seg::Index(n), Value::Array(a)) => index(a, *n).into_iter().collect(),
+            (Seg::Each, Value::Array(a))     => a.iter().collect(),
+            (Seg::Each, Value::Object(m))    => m.values().collect(),
+            _ => Vec::new(),
+        }).collect();
+    }
+    cur
+}
+
+fn select_mut<'a>(root: &'a mut Value, segs: &[Seg]) -> Result<&'a mut Value> {
+    let mut cur = root;
+    for seg in segs {
+        cur = match seg {
+            Seg::Key(k) => {
+                if cur.is_null() { *cur = Value::Object(Map::new()); }
+                match cur {
+                    Value::Object(m) => m.entry(k.clone()).or_insert(Value::Null),
+                    _ => bail!("'.{}' — wartość nie jest obiektem", k),
+                }
+            }
+            Seg::Index(n) => match cur {
+                Value::Array(a) => {
+                    let len = a.len();
+                    let i = if *n < 0 { len.checked_sub(n.unsigned_abs() as usize) } else { Some(*n as usize) };
+                    match i.filter(|i| *i < len) {
+                        Some(i) => &mut a[i],
+                        None    => bail!("[{}] — poza listą ({} elementów)", n, len),
+                    }
+                }
+                _ => bail!("[{}] — wartość nie jest listą", n),
+            },
+            Seg::Each => bail!("set nie obsługuje [] — podaj indeks"),
+        };
+    }
+    Ok(cur)
+}
+
+fn read_input(file: Option<&Path>) -> Result<String> {
+    match file {
+        None => {
+            let mut s = String::new();
+            std::io::stdin().read_to_string(&mut s)?;
+            Ok(s)
+        }
+        Some(f) if f.as_os_str() == "-" => read_input(None),
+        Some(f) => std::fs::read_to_string(f).with_context(|| format!("Nie można odczytać {}", f.display())),
+    }
+}
+
+pub fn parse(text: &str, format: Format) -> Result<Value> {
+    Ok(match format {
+        Format::Json => serde_json::from_str(text).context("nieprawidłowy JSON")?,
+        Format::Yaml => serde_yaml::from_str(text).context("nieprawidłowy YAML")?,
+    })
+}
+
+fn load(file: Option<&Path>, format: Format) -> Result<Value> {
+    let text = read_input(file)?;
+    parse(&text, format).with_context(|| file.map(|f| f.display().to_string()).unwrap_or_else(|| "stdin".into()))
+}
+
+fn render(value: &Value, format: Format, compact: bool) -> Result<String> {
+    Ok(match value {
+        Value::String(s) => s.clone(),
+        Value::Object(_) | Value::Array(_) => match format {
+            Format::Yaml           => serde_yaml::to_string(value)?.trim_end().to_string(),
+            Format::Json if compact => serde_json::to_string(value)?,
+            Format::Json           => serde_json::to_string_pretty(value)?,
+        },
+        other => other.to_string(),
+    })
+}
+
+/// hl json get — 0 gdy coś znaleziono, 1 gdy nie
+pub fn cmd_get(path: &str, file: Option<&Path>, format: Format, compact: bool) -> Result<i32> {
+    let root = load(file, format)?;
+    let found = select(&root, &parse_path(path)?);
+    for v in &found { println!("{}", render(v, format, compact)?); }
+    Ok(if found.is_empty() { 1 } else { 0 })
+}
+
+pub fn cmd_keys(path: &str, file: Option<&Path>, format: Format) -> Result<i32> {
+    let root = load(file, format)?;
+    let mut code = 1;
+    for v in select(&root, &parse_path(path)?) {
+        match v {
+            Value::Object(m) => { for k in m.keys() { println!("{}", k); } code = 0; }
+            Value::Array(a)  => { for i in 0..a.len() { println!("{}", i); } code = 0; }
+            _ => bail!("{}: keys — wartość nie jest obiektem ani listą", path),
+        }
+    }
+    Ok(code)
+}
+
+pub fn cmd_has(path: &str, file: Option<&Path>, format: Format) -> Result<i32> {
+    let root = load(file, format)?;
+    Ok(if select(&root, &parse_path(path)?).is_empty() { 1 } else { 0 })
+}
+
+pub fn cmd_len(path: &str, file: Option<&Path>, format: Format) -> Result<i32> {
+    let root = load(file, format)?;
+    let found = select(&root, &parse_path(path)?);
+    for v in &found {
+        let n = match v {
+            Value::Object(m) => m.len(),
+            Value::Array(a)  => a.len(),
+            Value::String(s) => s.chars().count(),
+            Value::Null      => 0,
+            _ => bail!("{}: len — liczba ani bool nie ma długości", path),
+        };
+        println!("{}", n);
+    }
+    Ok(if found.is_empty() { 1 } else { 0 })
+}
+
+/// hl json set — wartość jako JSON (8080, true, [1,2]), inaczej tekst
+pub fn cmd_set(path: &str, value: &str, file: &Path, format: Format) -> Result<i32> {
+    let mut root = load(Some(file), format)?;
+    let new = serde_json::from_str(value).unwrap_or_else(|_| Value::String(value.to_string()));
+    *select_mut(&mut root, &parse_path(path)?)? = new;
+    let out = match format {
+        Format::Json => serde_json::to_string_pretty(&root)? + "\n",
+        Format::Yaml => serde_yaml::to_string(&root)?,
+    };
+    let tmp = file.with_extension(format!("hl-tmp.{}", std::process::id()));
+    std::fs::write(&tmp, out)?;
+    std::fs::rename(&tmp, file)?;
+    Ok(0)
+}
+
+fn merge(into: &mut Value, from: Value) {
+    match (into, from) {
+        (Value::Object(a), Value::Object(b)) => {
+            for (k, v) in b {
+                match a.get_mut(&k) {
+                    Some(existing) => merge(existing, v),
+                    None           => { a.insert(k, v); }
+                }
+            }
+        }
+        (slot, v) => *slot = v,
+    }
+}
+
+pub fn cmd_merge(files: &[std::path::PathBuf], format: Option<Format>, compact: bool) -> Result<i32> {
+    let Some(first) = files.first() else { bail!("merge: podaj co najmniej jeden plik") };
+    let out_format = Format::detect(format, Some(first));
+    let mut acc = Value::Null;
+    for f in files {
+        merge(&mut acc, load(Some(f), Format::detect(format, Some(f)))?);
+    }
+    println!("{}", render(&acc, out_format, compact)?);
+    Ok(0)
+}
+
@@ -87,6 +87,22 @@ pub fn exec_quick(name: &str, args: &[StringPart], env: &mut Env) -> Result<Exec
         "green"  => { println!("\x1b[32m{}\x1b[0m", arg_str); Ok(ExecResult::ok()) }
         "yellow" => { println!("\x1b[33m{}\x1b[0m", arg_str); Ok(ExecResult::ok()) }
         "cyan"   => { println!("\x1b[36m{}\x1b[0m", arg_str); Ok(ExecResult::ok()) }
+        // Zadania w tle (jobs.rs): ::wait [nazwa…], ::status nazwa, ::kill nazwa [SYGNAŁ], ::jobs
+        "wait"   => {
+            let names: Vec<&str> = arg_str.split_whitespace().collect();
+            Ok(ExecResult::err_or_ok(crate::jobs::wait_jobs(&names)?))
+        }
+        "status" => {
+            let (status, code) = crate::jobs::job_status(arg_str)?;
+            println!("{}", status);
+            Ok(ExecResult::err_or_ok(code))
+        }
+        "kill"   => {
+            let (name, signal) = split_first(arg_str);
+            let sent = crate::jobs::kill_job(name, (!signal.is_empty()).then_some(signal))?;
+            Ok(if sent { ExecResult::ok() } else { ExecResult::err(1) })
+        }
+        "jobs"   => { crate::jobs::print_jobs(); Ok(ExecResult::ok()) }
         other    => bail!("Nieznana quick-funkcja '::{}'. Zdefiniuj ją jako arena function: :: {} <4k> def ... done", other, other),
     }
 }
@@ -133,6 +149,7 @@ pub fn exec_quick_capture(name: &str, args: &[StringPart], env: &mut Env) -> Res
                 .unwrap_or_default());
         }
         "which"  => return Ok(which::which(arg_str_t).map(|p| p.display().to_string()).unwrap_or_default()),
+        "status" => return Ok(crate::jobs::job_status(arg_str_t)?.0),
         _ => {}
     }
 
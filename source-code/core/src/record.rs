@@ -0,0 +1,221 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::io::{BufRead, Read, Write};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::sync::{mpsc, Mutex, OnceLock};
+use std::time::{Duration, SystemTime, UNIX_EPOCH};
+use crate::deploy::hl_binary;
+
+// ── Nagrywanie i odtwarzanie uruchomień ───────────────────────────────────────
+//
+//   hl run --record sesja.rec x.hl [-- args]
+//   hl replay sesja.rec [--speed 2] [--max-idle 1s] [--raw]
+//
+// Nagrywanie uruchamia `hl run` jako proces potomny i przepuszcza jego stdout
+// i stderr na terminal, zapisując każdy fragment z czasem od startu. Potomek
+// dopisuje zdarzenie `step` po każdej komendzie (`>`, `^>`, `|>` …) do pliku
+// z HL_RECORD_STEPS. Plik .rec to JSON lines: nagłówek, potem zdarzenia
+//
+//   {"t": ms, "kind": "out" | "err" | "step" | "exit", ...}
+//
+// posortowane po czasie. Odtwarzanie wypisuje je z tymi samymi przerwami
+// (podzielonymi przez --speed); kroki pokazuje jako szare linie z kodem wyjścia.
+
+pub const REC_VERSION: u32 = 1;
+const STEPS_ENV: &str = "HL_RECORD_STEPS";
+const CHUNK: usize = 8192;
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct RecHeader {
+    pub version: u32,
+    pub script:  String,
+    pub args:    Vec<String>,
+    pub cwd:     String,
+    pub started: u64,
+    pub hl:      String,
+}
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+#[serde(tag = "kind", rename_all = "lowercase")]
+pub enum RecEvent {
+    Out  { t: u64, data: String },
+    Err  { t: u64, data: String },
+    Step { t: u64, cmd: String, sudo: bool, exit: i32, ms: u64 },
+    Exit { t: u64, code: i32 },
+}
+
+impl RecEvent {
+    fn t(&self) -> u64 {
+        match self {
+            RecEvent::Out { t, .. } | RecEvent::Err { t, .. } | RecEvent::Step { t, .. } | RecEvent::Exit { t, .. } => *t,
+        }
+    }
+}
+
+fn now_ms() -> u64 {
+    SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_millis() as u64).unwrap_or(0)
+}
+
+// ── Strona potomka: zdarzenia kroków ──────────────────────────────────────────
+
+static STEPS: OnceLock<Option<Mutex<std::fs::File>>> = OnceLock::new();
+
+/// Zapisz wykonaną komendę, jeśli uruchomienie jest nagrywane (czas: ms unix)
+pub(crate) fn note_step(cmd: &str, sudo: bool, exit: i32, started: u64) {
+    let file = STEPS.get_or_init(|| {
+        let path = std::env::var_os(STEPS_ENV)?;
+        std::fs::OpenOptions::new().append(true).open(path).ok().map(Mutex::new)
+    });
+    let Some(file) = file else { return };
+    let ev = RecEvent::Step { t: started, cmd: cmd.to_string(), sudo, exit, ms: now_ms().saturating_sub(started) };
+    if let (Ok(mut f), Ok(line)) = (file.lock(), serde_json::to_string(&ev)) {
+        let _ = writeln!(f, "{}", line);
+    }
+}
+
+/// Czas startu kroku dla `note_step` — 0, gdy nic nie jest nagrywane
+pub(crate) fn step_clock() -> u64 {
+    if std::env::var_os(STEPS_ENV).is_some() { now_ms() } else { 0 }
+}
+
+// ── Strona rodzica: hl run --record ───────────────────────────────────────────
+
+fn pump(mut from: impl Read, err: bool, start: u64, tx: mpsc::Sender<RecEvent>) {
+    let mut buf = vec![0u8; CHUNK];
+    let mut pending: Vec<u8> = Vec::new();
+    loop {
+        let n = match from.read(&mut buf) { Ok(0) | Err(_) => break, Ok(n) => n };
+        let _ = if err { std::io::stderr().write_all(&buf[..n]) } else { std::io::stdout().write_all(&buf[..n]).and_then(|_| std::io::stdout().flush()) };
+        pending.extend_from_slice(&buf[..n]);
+        // Nie tnij znaku UTF-8 między zdarzeniami
+        let cut = match std::str::from_utf8(&pending) {
+            Ok(_)  => pending.len(),
+            Err(e) if e.error_len().is_none() => e.valid_up_to(),
+            Err(_) => pending.len(),
+        };
+        if cut == 0 { continue; }
+        let data = String::from_utf8_lossy(&pending[..cut]).into_owned();
+        pending.drain(..cut);
+        let t = now_ms().saturating_sub(start);
+        let _ = tx.send(if err { RecEvent::Err { t, data } } else { RecEvent::Out { t, data } });
+    }
+}
+
+/// Nagraj `hl run` do `rec`; zwraca kod wyjścia skryptu
+pub fn record_session(rec: &Path, script: &Path, args: &[String], vars: &[String], jit: bool) -> Result<i32> {
+    let steps_path = PathBuf::from(format!("{}.steps", rec.display()));
+    std::fs::write(&steps_path, "").with_context(|| format!("Nie można zapisać {}", steps_path.display()))?;
+    let start = now_ms();
+
+    let mut cmd = Command::new(hl_binary());
+    // Manifest, podpis i zdalne źródło sprawdził już rodzic
+    cmd.arg("run").arg("--trust").arg("--yes");
+    if jit { cmd.arg("--jit"); }
+    for v in vars { cmd.arg("--var").arg(v); }
+    cmd.arg(script);
+    if !args.is_empty() { cmd.arg("--").args(args); }
+    cmd.env(STEPS_ENV, &steps_path).stdin(Stdio::inherit()).stdout(Stdio::piped()).stderr(Stdio::piped());
+    use std::io::IsTerminal;
+    if std::io::stdout().is_terminal() { cmd.env("CLICOLOR_FORCE", "1"); }
+    let mut child = cmd.spawn().context("Nie można uruchomić hl run")?;
+
+    let (tx, rx) = mpsc::channel();
+    let out = child.stdout.take().map(|s| { let tx = tx.clone(); std::thread::spawn(move || pump(s, false, start, tx)) });
+    let err = child.stderr.take().map(|s| { let tx = tx.clone(); std::thread::spawn(move || pump(s, true, start, tx)) });
+    drop(tx);
+    let mut events: Vec<RecEvent> = rx.into_iter().collect();
+    for h in [out, err].into_iter().flatten() { let _ = h.join(); }
+    let code = child.wait()?.code().unwrap_or(1);
+
+    let steps = std::fs::File::open(&steps_path)?;
+    for line in std::io::BufReader::new(steps).lines().map_while(Result::ok) {
+        if let Ok(RecEvent::Step { t, cmd, sudo, exit, ms }) = serde_json::from_str(&line) {
+            events.push(RecEvent::Step { t: t.saturating_sub(start), cmd, sudo, exit, ms });
+        }
+    }
+    let _ = std::fs::remove_file(&steps_path);
+    events.push(RecEvent::Exit { t: now_ms().saturating_sub(start), code });
+    events.sort_by_key(RecEvent::t);
+
+    let header = RecHeader {
+        version: REC_VERSION,
+        script:  std::fs::canonicalize(script).unwrap_or_else(|_| script.to_path_buf()).display().to_string(),
+        args:    args.to_vec(),
+        cwd:     std::env::current_dir().map(|d| d.display().to_string()).unwrap_or_default(),
+        started: start / 1000,
+        hl:      env!("CARGO_PKG_VERSION").into(),
+    };
+    let mut f = std::io::BufWriter::new(std::fs::File::create(rec)?);
+    writeln!(f, "{}", serde_json::to_string(&header)?)?;
+    for ev in &events { writeln!(f, "{}", serde_json::to_string(ev)?)?; }
+    f.flush()?;
+    eprintln!("{} {} ({} zdarzeń)", "hl record:".bright_magenta().bold(), rec.display(), events.len());
+    Ok(code)
+}
+
+// ── hl replay ─────────────────────────────────────────────────────────────────
+
+pub struct ReplayOptions {
+    pub speed:    f64,
+    /// Najdłuższa przerwa między zdarzeniami (po podzieleniu przez speed)
+    pub max_idle: Option<Duration>,
+    /// Tylko stdout/stderr — bez linii kroków i podsumowania
+    pub raw:      bool,
+}
+
+pub fn load_recording(rec: &Path) -> Result<(RecHeader, Vec<RecEvent>)> {
+    let file = std::fs::File::open(rec).with_context(|| format!("Nie można otworzyć {}", rec.display()))?;
+    let mut lines = std::io::BufReader::new(file).lines();
+    let header: RecHeader = match lines.next() {
+        Some(line) => serde_json::from_str(&line?).with_context(|| format!("{}: nieprawidłowy nagłówek", rec.display()))?,
+        None       => bail!("{}: pusty plik nagrania", rec.display()),
+    };
+    if header.version > REC_VERSION {
+        bail!("{}: nagranie w wersji {} — ten hl czyta do {}", rec.display(), header.version, REC_VERSION);
+    }
+    let mut events = Vec::new();
+    for (i, line) in lines.enumerate() {
+        let line = line?;
+        if line.trim().is_empty() { continue; }
+        events.push(serde_json::from_str(&line).with_context(|| format!("{}:{}: nieprawidłowe zdarzenie", rec.display(), i + 2))?);
+    }
+    Ok((header, events))
+}
+
+/// Odtwórz nagranie; zwraca kod wyjścia nagranego uruchomienia
+pub fn cmd_replay(rec: &Path, opts: &ReplayOptions) -> Result<i32> {
+    let (header, events) = load_recording(rec)?;
+    if opts.speed <= 0.0 { bail!("--speed musi być dodatnie"); }
+    if !opts.raw {
+        let args = if header.args.is_empty() { String::new() } else { format!(" -- {}", header.args.join(" ")) };
+        eprintln!("{} {}{}  {}", "hl replay:".bright_magenta().bold(), header.script.bright_white(), args,
+                  format!("(hl {}, ×{})", header.hl, opts.speed).bright_black());
+    }
+    let mut prev = 0u64;
+    let mut code = 0;
+    for ev in &events {
+        let mut wait = Duration::from_secs_f64(ev.t().saturating_sub(prev) as f64 / 1000.0 / opts.speed);
+        if let Some(max) = opts.max_idle { wait = wait.min(max); }
+        std::thread::sleep(wait);
+        prev = ev.t();
+        match ev {
+            RecEvent::Out { data, .. } => { print!("{}", data); std::io::stdout().flush()?; }
+            RecEvent::Err { data, .. } => eprint!("{}", data),
+            RecEvent::Step { cmd, sudo, exit, ms, .. } if !opts.raw => {
+                let mark = if *exit == 0 { "✓".green() } else { format!("✗ {}", exit).red() };
+                eprintln!("{}", format!("  ▸ {}{}  {} {} ms", if *sudo { "sudo " } else { "" }, cmd, mark, ms).bright_black());
+            }
+            RecEvent::Step { .. } => {}
+            RecEvent::Exit { t, code: c } => {
+                code = *c;
+                if !opts.raw {
+                    let status = if *c == 0 { "ok".green() } else { format!("exit {}", c).red() };
+                    eprintln!("{} {} po {:.1}s", "hl replay:".bright_magenta().bold(), status, *t as f64 / 1000.0);
+                }
+            }
+        }
+    }
+    Ok(code)
+}
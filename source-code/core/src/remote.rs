@@ -0,0 +1,187 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use std::io::{BufRead, BufReader, Write};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::sync::{Arc, Mutex};
+use std::time::Instant;
+
+// ── hl run --host ─────────────────────────────────────────────────────────────
+//
+// Zdalne uruchamianie skryptu przez SSH:
+//   hl run --host root@srv1 --host root@srv2 skrypt.hl
+//   hl run --hosts-file inventory skrypt.hl
+//
+// Dla każdego hosta (równolegle):
+//   1. sprawdza czy `hl` istnieje na hoście
+//   2. pakuje sam skrypt i jego rozwiązane importy `<<` / `<*` (ścieżki względne
+//      wobec katalogu skryptu) i rozpakowuje je w katalogu z `mktemp -d` na hoście
+//      — reszta katalogu projektu nie opuszcza maszyny; biblioteki spoza katalogu
+//      skryptu muszą być zainstalowane na hoście
+//   3. uruchamia `hl run <skrypt>` i strumieniuje wyjście z prefiksem [host]
+//   4. sprząta katalog tymczasowy
+// Na końcu podsumowanie: host → exit code + czas. Host zaczynający się od `-`
+// jest odrzucany (nie może zostać opcją ssh).
+
+#[derive(Debug, Clone)]
+pub struct RemoteResult {
+    pub host:      String,
+    pub exit_code: i32,
+    pub secs:      f64,
+    pub error:     Option<String>,
+}
+
+/// Wczytaj plik inventory: jeden host na linię, `#` / `;;` to komentarze
+pub fn load_hosts_file(path: &Path) -> Result<Vec<String>> {
+    let src = std::fs::read_to_string(path)
+        .with_context(|| format!("Nie można odczytać pliku hostów {}", path.display()))?;
+    Ok(src.lines()
+        .map(|l| l.trim())
+        .filter(|l| !l.is_empty() && !l.starts_with('#') && !l.starts_with(";;"))
+        .map(|l| l.split_whitespace().next().unwrap_or(l).to_string())
+        .collect())
+}
+
+pub(crate) fn shell_quote(s: &str) -> String {
+    format!("'{}'", s.replace('\'', r"'\''"))
+}
+
+fn ssh(host: &str) -> Command {
+    let mut cmd = Command::new("ssh");
+    cmd.args(["-o", "BatchMode=yes", "-o", "ConnectTimeout=10", "--", host]);
+    cmd
+}
+
+fn check_host(host: &str) -> Result<()> {
+    if host.is_empty() || host.starts_with('-') || host.chars().any(|c| c.is_whitespace() || c.is_control()) {
+        bail!("nieprawidłowa nazwa hosta '{}'", host);
+    }
+    Ok(())
+}
+
+/// Pliki do wysłania: skrypt i importy z jego katalogu, jako ścieżki względne
+fn shipped_files(script: &Path) -> Result<(PathBuf, Vec<PathBuf>)> {
+    let script = std::fs::canonicalize(script)?;
+    let dir = script.parent().unwrap_or_else(|| Path::new("/")).to_path_buf();
+    let mut files = Vec::new();
+    for f in crate::graph::resolved_sources(&script)? {
+        let Ok(f) = std::fs::canonicalize(&f) else { continue };
+        if let Ok(rel) = f.strip_prefix(&dir) {
+            if !files.iter().any(|x: &PathBuf| x == rel) { files.push(rel.to_path_buf()); }
+        }
+    }
+    Ok((dir, files))
+}
+
+fn stream_prefixed<R: std::io::Read + Send + 'static>(
+    reader: R,
+    prefix: String,
+    lock:   Arc<Mutex<()>>,
+    stderr: bool,
+) -> std::thread::JoinHandle<()> {
+    std::thread::spawn(move || {
+        for line in BufReader::new(reader).lines().map_while(Result::ok) {
+            let _guard = lock.lock();
+            if stderr { eprintln!("{} {}", prefix, line); } else { println!("{} {}", prefix, line); }
+        }
+    })
+}
+
+/// `vars` — `NAZWA=WARTOŚĆ` przekazywane jako `hl run --var` (hl rollout)
+pub(crate) fn run_on_host(host: &str, script: &Path, args: &[String], vars: &[String], lock: Arc<Mutex<()>>) -> Result<i32> {
+    check_host(host)?;
+    let prefix = format!("[{}]", host).bright_cyan().bold().to_string();
+
+    let check = ssh(host).arg("command -v hl >/dev/null").status()
+        .context("ssh nie jest zainstalowany")?;
+    if !check.success() {
+        bail!("brak `hl` na hoście (lub brak połączenia SSH)");
+    }
+
+    let file_name = script.file_name().and_then(|n| n.to_str()).unwrap_or("script.hl");
+    let (dir, files) = shipped_files(script)?;
+
+    // tar lokalnie (tylko skrypt + importy) → ssh → tar w katalogu z mktemp -d
+    let mut tar = Command::new("tar")
+        .args(["czf", "-", "-C"]).arg(&dir).arg("--").args(&files)
+        .stdout(Stdio::piped())
+        .spawn()
+        .context("tar nie jest zainstalowany")?;
+    let tar_out = tar.stdout.take().context("brak stdout tar")?;
+    let unpack = ssh(host)
+        .arg("d=$(mktemp -d) && { tar xzf - -C \"$d\" || { rm -rf \"$d\"; exit 1; }; } && printf '%s\\n' \"$d\"")
+        .stdin(tar_out)
+        .stderr(Stdio::inherit())
+        .output()?;
+    let packed = tar.wait()?;
+    let remote_dir = String::from_utf8_lossy(&unpack.stdout).trim().to_string();
+    if !unpack.status.success() || !packed.success() || !remote_dir.starts_with('/') {
+        bail!("nie udało się skopiować skryptu na hosta");
+    }
+
+    let mut remote_cmd = format!("cd {} && hl run", shell_quote(&remote_dir));
+    for v in vars { remote_cmd.push_str(&format!(" --var {}", shell_quote(v))); }
+    remote_cmd.push_str(&format!(" {}", shell_quote(file_name)));
+    if !args.is_empty() {
+        remote_cmd.push_str(" --");
+        for a in args { remote_cmd.push(' '); remote_cmd.push_str(&shell_quote(a)); }
+    }
+    remote_cmd.push_str(&format!("; rc=$?; rm -rf {}; exit $rc", shell_quote(&remote_dir)));
+
+    let mut child = ssh(host)
+        .arg(remote_cmd)
+        .stdin(Stdio::null())
+        .stdout(Stdio::piped())
+        .stderr(Stdio::piped())
+        .spawn()?;
+    let out = stream_prefixed(child.stdout.take().context("brak stdout")?, prefix.clone(), lock.clone(), false);
+    let err = stream_prefixed(child.stderr.take().context("brak stderr")?, prefix, lock, true);
+    let status = child.wait()?;
+    let _ = out.join();
+    let _ = err.join();
+    Ok(status.code().unwrap_or(255))
+}
+
+/// Uruchom skrypt na wszystkich hostach równolegle; zwraca wyniki w kolejności hostów
+pub fn run_remote(hosts: &[String], script: &Path, args: &[String]) -> Result<Vec<RemoteResult>> {
+    if hosts.is_empty() { bail!("Brak hostów do uruchomienia"); }
+    if !script.exists() { bail!("Plik nie istnieje: {}", script.display()); }
+    let script: PathBuf = std::fs::canonicalize(script)?;
+    let lock = Arc::new(Mutex::new(()));
+
+    let handles: Vec<_> = hosts.iter().cloned().map(|host| {
+        let script = script.clone();
+        let args   = args.to_vec();
+        let lock   = lock.clone();
+        std::thread::spawn(move || {
+            let t0 = Instant::now();
+            let res = run_on_host(&host, &script, &args, &[], lock);
+            let secs = t0.elapsed().as_secs_f64();
+            match res {
+                Ok(code) => RemoteResult { host, exit_code: code, secs, error: None },
+                Err(e)   => RemoteResult { host, exit_code: 255, secs, error: Some(e.to_string()) },
+            }
+        })
+    }).collect();
+
+    Ok(handles.into_iter()
+        .map(|h| h.join().unwrap_or_else(|_| RemoteResult {
+            host: "?".into(), exit_code: 255, secs: 0.0, error: Some("panic wątku".into()),
+        }))
+        .collect())
+}
+
+pub fn print_remote_summary(results: &[RemoteResult]) {
+    let ok = results.iter().filter(|r| r.exit_code == 0).count();
+    println!();
+    println!("{}", "=== hl run --host: podsumowanie ===".bright_cyan().bold());
+    for r in results {
+        let status = if r.exit_code == 0 { "✓".green().bold() } else { "✗".red().bold() };
+        print!("  {} {} exit={} ({:.1}s)", status,
+               format!("{:<30}", r.host).bright_white(), r.exit_code, r.secs);
+        if let Some(ref e) = r.error { print!("  {}", e.bright_black()); }
+        println!();
+    }
+    println!("  {}/{} hostów OK", ok, results.len());
+    let _ = std::io::stdout().flush();
+}
@@ -0,0 +1,222 @@
+use anyhow::Result;
+use colored::Colorize;
+use serde_json::{json, Value};
+use std::path::Path;
+use crate::diagnostics::{Diag, DiagLevel, DiagRenderer, DiagSummary};
+use crate::explain::explanation;
+
+// ── Formaty wyjścia diagnostyk ────────────────────────────────────────────────
+//
+//   text   — renderer z fragmentem źródła i podkreśleniem (stderr, domyślny)
+//   json   — jeden dokument na stdout: plik, diagnostyki z linią/kolumną/długością, podsumowanie
+//   sarif  — SARIF 2.1.0 do wysłania jako code scanning (np. github/codeql-action/upload-sarif)
+//   github — komendy `::error file=…,line=…::…` GitHub Actions (adnotacje w PR, stdout)
+//
+// hl check przekazuje diagnostyki każdego etapu (linter, parser, security) do
+// CheckRenderer::emit, a po ostatnim pliku woła finish z kompletem wyników.
+// Formaty strumieniowe (text, github) piszą od razu, dokumentowe (json, sarif)
+// składają wynik w finish. Nowy format to nowy wariant DiagFormat i implementacja.
+
+pub const SARIF_SCHEMA: &str = "https://json.schemastore.org/sarif-2.1.0.json";
+const REPO_URL: &str = "https://github.com/HackerOS-Linux-System/Hacker-Lang";
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum DiagFormat { Text, Json, Sarif, Github }
+
+impl DiagFormat {
+    pub fn from_str(s: &str) -> Option<Self> {
+        match s {
+            "text"   => Some(DiagFormat::Text),
+            "json"   => Some(DiagFormat::Json),
+            "sarif"  => Some(DiagFormat::Sarif),
+            "github" => Some(DiagFormat::Github),
+            _        => None,
+        }
+    }
+
+    /// Renderer wyników; `project` — sprawdzany katalog (json: lista dokumentów)
+    pub fn renderer(self, project: bool) -> Box<dyn CheckRenderer> {
+        match self {
+            DiagFormat::Text   => Box::new(TextRenderer { project }),
+            DiagFormat::Json   => Box::new(JsonRenderer { project }),
+            DiagFormat::Sarif  => Box::new(SarifRenderer),
+            DiagFormat::Github => Box::new(GithubRenderer),
+        }
+    }
+}
+
+pub trait CheckRenderer {
+    /// Diagnostyki jednego etapu sprawdzania pliku (źródło — dla fragmentów kodu)
+    fn emit(&mut self, _file: &str, _source: &str, _diags: &[Diag]) {}
+    /// Koniec sprawdzania — wszystkie pliki razem z diagnostykami
+    fn finish(&mut self, files: &[(String, Vec<Diag>)]) -> Result<()>;
+}
+
+pub struct TextRenderer { project: bool }
+
+impl CheckRenderer for TextRenderer {
+    fn emit(&mut self, file: &str, source: &str, diags: &[Diag]) {
+        let fname = Path::new(file).file_name().and_then(|n| n.to_str()).unwrap_or("<unknown>");
+        DiagRenderer::new(fname, source).emit_all(diags);
+    }
+
+    fn finish(&mut self, files: &[(String, Vec<Diag>)]) -> Result<()> {
+        if self.project {
+            let failed = files.iter().filter(|(_, d)| d.iter().any(|d| d.level == DiagLevel::Error)).count();
+            println!("{} {} plików, {} z błędami", "hl check:".bright_magenta().bold(), files.len(), failed);
+        }
+        Ok(())
+    }
+}
+
+pub struct JsonRenderer { project: bool }
+
+impl CheckRenderer for JsonRenderer {
+    fn finish(&mut self, files: &[(String, Vec<Diag>)]) -> Result<()> {
+        let out = match files {
+            [(path, diags)] if !self.project => diags_to_json(path, diags),
+            _ => Value::Array(files.iter().map(|(p, d)| diags_to_json(p, d)).collect()),
+        };
+        println!("{}", serde_json::to_string_pretty(&out)?);
+        Ok(())
+    }
+}
+
+pub struct SarifRenderer;
+
+impl CheckRenderer for SarifRenderer {
+    fn finish(&mut self, files: &[(String, Vec<Diag>)]) -> Result<()> {
+        println!("{}", serde_json::to_string_pretty(&files_to_sarif(files))?);
+        Ok(())
+    }
+}
+
+pub struct GithubRenderer;
+
+/// Znaki specjalne komend ::…:: (treść; we właściwościach także `:` i `,`)
+fn gh_escape(s: &str, property: bool) -> String {
+    let s = s.replace('%', "%25").replace('\r', "%0D").replace('\n', "%0A");
+    if property { s.replace(':', "%3A").replace(',', "%2C") } else { s }
+}
+
+/// Linia `::error …` / `::warning …` / `::notice …` dla jednej diagnostyki
+pub fn github_annotation(file: &str, diag: &Diag) -> String {
+    let command = match diag.level { DiagLevel::Error => "error", DiagLevel::Warning => "warning", _ => "notice" };
+    let mut props = vec![format!("file={}", gh_escape(file, true))];
+    if let Some(s) = &diag.span {
+        props.push(format!("line={}", s.line));
+        props.push(format!("col={}", s.col));
+        if s.len > 0 { props.push(format!("endColumn={}", s.col + s.len)); }
+    }
+    props.push(format!("title={}", gh_escape(&rule_id(diag), true)));
+    let mut text = diag.message.clone();
+    if let Some(sug) = &diag.suggestion { text.push_str(&format!("\nhelp: {}", sug)); }
+    for note in &diag.notes { text.push_str(&format!("\nnote: {}", note)); }
+    format!("::{} {}::{}", command, props.join(","), gh_escape(&text, false))
+}
+
+impl CheckRenderer for GithubRenderer {
+    fn emit(&mut self, file: &str, _source: &str, diags: &[Diag]) {
+        for d in diags { println!("{}", github_annotation(file, d)); }
+    }
+
+    fn finish(&mut self, files: &[(String, Vec<Diag>)]) -> Result<()> {
+        let all: Vec<Diag> = files.iter().flat_map(|(_, d)| d.iter().cloned()).collect();
+        println!("hl check: {} plików, {}", files.len(), DiagSummary::from_diags(&all));
+        Ok(())
+    }
+}
+
+fn level_str(level: &DiagLevel) -> &'static str {
+    match level {
+        DiagLevel::Error   => "error",
+        DiagLevel::Warning => "warning",
+        DiagLevel::Hint    => "hint",
+        DiagLevel::Note    => "note",
+    }
+}
+
+/// Identyfikator reguły: kod HLxxxx, `[reguła]` z security.hk albo ogólne "hl"
+fn rule_id(diag: &Diag) -> String {
+    if let Some(code) = diag.code { return code.to_string(); }
+    diag.message.strip_prefix('[')
+        .and_then(|r| r.split_once(']'))
+        .map(|(id, _)| id.to_string())
+        .unwrap_or_else(|| "hl".into())
+}
+
+/// Diagnostyki jako JSON (kontrakt `hl check --format json`)
+pub fn diags_to_json(file: &str, diags: &[Diag]) -> Value {
+    let sum = DiagSummary::from_diags(diags);
+    json!({
+        "file": file,
+        "diagnostics": diags.iter().map(|d| json!({
+            "level":      level_str(&d.level),
+            "code":       d.code,
+            "message":    d.message,
+            "line":       d.span.as_ref().map(|s| s.line),
+            "column":     d.span.as_ref().map(|s| s.col),
+            "length":     d.span.as_ref().map(|s| s.len),
+            "suggestion": d.suggestion,
+            "notes":      d.notes,
+        })).collect::<Vec<_>>(),
+        "summary": { "errors": sum.errors, "warnings": sum.warnings, "hints": sum.hints },
+    })
+}
+
+/// Diagnostyki jako SARIF 2.1.0 — jeden run narzędzia "hl"
+pub fn diags_to_sarif(file: &str, diags: &[Diag]) -> Value {
+    files_to_sarif(&[(file.to_string(), diags.to_vec())])
+}
+
+/// SARIF dla wielu plików (hl check <katalog>) — wspólna lista reguł
+pub fn files_to_sarif(files: &[(String, Vec<Diag>)]) -> Value {
+    let diags = || files.iter().flat_map(|(f, ds)| ds.iter().map(move |d| (f.as_str(), d)));
+    let mut rule_ids: Vec<String> = diags().map(|(_, d)| rule_id(d)).collect();
+    rule_ids.sort();
+    rule_ids.dedup();
+    let rules: Vec<Value> = rule_ids.iter().map(|id| match explanation(id) {
+        Some(e) => json!({
+            "id": id,
+            "shortDescription": { "text": e.title },
+            "fullDescription":  { "text": e.text },
+            "helpUri": format!("{}#linter-i-diagnostyka", REPO_URL),
+        }),
+        None => json!({ "id": id }),
+    }).collect();
+
+    let results: Vec<Value> = diags().map(|(file, d)| {
+        let mut region = json!({ "startLine": 1 });
+        if let Some(s) = &d.span {
+            region = json!({ "startLine": s.line, "startColumn": s.col });
+            if s.len > 0 { region["endColumn"] = json!(s.col + s.len); }
+        }
+        let mut text = d.message.clone();
+        if let Some(sug) = &d.suggestion { text.push_str(&format!(" (help: {})", sug)); }
+        json!({
+            "ruleId": rule_id(d),
+            "level": match d.level { DiagLevel::Error => "error", DiagLevel::Warning => "warning", _ => "note" },
+            "message": { "text": text },
+            "locations": [{
+                "physicalLocation": {
+                    "artifactLocation": { "uri": file },
+                    "region": region,
+                }
+            }],
+        })
+    }).collect();
+
+    json!({
+        "$schema": SARIF_SCHEMA,
+        "version": "2.1.0",
+        "runs": [{
+            "tool": { "driver": {
+                "name": "hl",
+                "version": env!("CARGO_PKG_VERSION"),
+                "informationUri": REPO_URL,
+                "rules": rules,
+            }},
+            "results": results,
+        }],
+    })
+}
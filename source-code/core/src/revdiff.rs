@@ -0,0 +1,215 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use hl_parser::ast::{CommandMode, ExportValue, Node, StringPart, VarValue};
+use std::collections::{BTreeMap, BTreeSet};
+use std::path::{Path, PathBuf};
+use std::process::Command;
+use crate::diagnostics::visit_nodes;
+use crate::exit::{classified, ErrorClass};
+use crate::security::{load_policy, required_capabilities, security_lint};
+
+// ── hl diff ───────────────────────────────────────────────────────────────────
+//
+//   hl diff <rev1> <rev2> [plik.hl]
+//
+// Porównuje zachowanie skryptów między dwiema rewizjami git, nie tekst: obie
+// wersje (git show) są parsowane i zestawiane są zależności `//`, importy,
+// komendy (z trybem sudo), zmienne, funkcje, uprawnienia z security.rs
+// i nowe ostrzeżenia polityki. Bez pliku — każdy .hl zmieniony między
+// rewizjami. Zmiana formatowania czy komentarzy nie daje żadnego wyniku.
+
+#[derive(Default)]
+struct Facts {
+    deps:      BTreeSet<String>,
+    imports:   BTreeSet<String>,
+    /// komenda → ile razy występuje
+    commands:  BTreeMap<String, usize>,
+    sudo:      BTreeSet<String>,
+    vars:      BTreeMap<String, String>,
+    funcs:     BTreeSet<String>,
+    caps:      BTreeSet<String>,
+    /// `[reguła] opis: linia`
+    findings:  BTreeSet<String>,
+}
+
+fn parts_text(parts: &[StringPart]) -> String {
+    parts.iter().map(|p| match p {
+        StringPart::Literal(s) => s.clone(),
+        StringPart::Var(v)     => format!("@{}", v),
+        StringPart::DynVar(p)  => format!("@{{{}}}", parts_text(p)),
+    }).collect()
+}
+
+fn value_text(value: &VarValue) -> String {
+    match value {
+        VarValue::String(s) | VarValue::Arithmetic(s) => s.clone(),
+        VarValue::CmdOutput(c)    => format!("$({})", c),
+        VarValue::Number(n) | VarValue::Float(n) => n.to_string(),
+        VarValue::Int(n)          => n.to_string(),
+        VarValue::Bool(b)         => b.to_string(),
+        VarValue::Interpolated(p) => parts_text(p),
+        VarValue::List(items)     => format!("[{}]", items.iter().map(value_text).collect::<Vec<_>>().join(", ")),
+        VarValue::Map(items)      => format!("{{{}}}", items.iter()
+            .map(|(k, v)| format!("{}: {}", k, value_text(v))).collect::<Vec<_>>().join(", ")),
+    }
+}
+
+fn is_sudo(mode: &CommandMode) -> bool {
+    matches!(mode, CommandMode::Sudo | CommandMode::IsolatedSudo | CommandMode::WithVarsSudo)
+}
+
+fn facts(path: &Path, source: &str) -> Result<Facts> {
+    let nodes = hl_parser::parse_source(source).map_err(|e| anyhow::anyhow!("{}", e))?;
+    let mut f = Facts::default();
+    fn command(raw: &str, sudo: bool, f: &mut Facts) {
+        let raw = raw.trim().to_string();
+        if sudo { f.sudo.insert(raw.clone()); }
+        *f.commands.entry(if sudo { format!("sudo {}", raw) } else { raw }).or_default() += 1;
+    }
+    visit_nodes(&nodes, false, &mut |node, _| match node {
+        Node::Dependency { name, apt_package } => { f.deps.insert(match apt_package {
+            Some(pkg) => format!("{} [{}]", name, pkg),
+            None      => name.clone(),
+        }); }
+        Node::Import     { lib, .. }   => { f.imports.insert(format!("# <{}>", lib.trim_start_matches('<').trim_end_matches('>'))); }
+        Node::FileImport { path, .. }  => { f.imports.insert(format!("<< {}", path)); }
+        Node::DirImport  { path }      => { f.imports.insert(format!("<* {}", path)); }
+        Node::Command    { raw, mode, .. }     => command(raw, is_sudo(mode), &mut f),
+        Node::PipeToVar  { command: c, mode, var_name, .. } => {
+            command(c, is_sudo(mode), &mut f);
+            f.vars.insert(var_name.clone(), format!("$({})", c.trim()));
+        }
+        Node::HshCommand { raw }       => command(&format!("hsh {}", raw.trim()), false, &mut f),
+        Node::Background { raw, .. }   => command(&format!("& {}", raw.trim()), false, &mut f),
+        Node::ExternDef  { file, runtime, .. } =>
+            command(&format!("_> {} [{}]", file, format!("{:?}", runtime).to_lowercase()), false, &mut f),
+        Node::VarDecl    { name, value, .. } => { f.vars.insert(name.clone(), value_text(value)); }
+        Node::Export     { name, value } => { f.vars.insert(format!("export {}", name), match value {
+            ExportValue::Single(p) => parts_text(p),
+            ExportValue::List(items) => items.iter().map(|p| parts_text(p)).collect::<Vec<_>>().join(" "),
+        }); }
+        Node::FuncDef      { name, .. } => { f.funcs.insert(format!(": {}", name)); }
+        Node::ArenaFuncDef { name, .. } => { f.funcs.insert(format!(":: {}", name)); }
+        _ => {}
+    });
+    f.caps = required_capabilities(source).iter().map(|c| c.to_string()).collect();
+    let policy = load_policy(path).unwrap_or_default();
+    let lines: Vec<&str> = source.lines().collect();
+    f.findings = security_lint(source, &policy).into_iter().map(|d| {
+        let line = d.span.and_then(|s| lines.get(s.line - 1)).map(|l| l.trim()).unwrap_or("");
+        format!("{}: {}", d.message, line)
+    }).collect();
+    Ok(f)
+}
+
+fn git(args: &[&str]) -> Result<std::process::Output> {
+    if which::which("git").is_err() {
+        return Err(classified(ErrorClass::Toolchain, "git nie jest zainstalowany"));
+    }
+    Ok(Command::new("git").args(args).output()?)
+}
+
+/// Treść pliku w rewizji; None — pliku w niej nie ma
+fn show(rev: &str, path: &Path) -> Result<Option<String>> {
+    let out = git(&["show", &format!("{}:./{}", rev, path.display())])?;
+    Ok(out.status.success().then(|| String::from_utf8_lossy(&out.stdout).to_string()))
+}
+
+fn check_rev(rev: &str) -> Result<()> {
+    let out = git(&["rev-parse", "--verify", "--quiet", &format!("{}^{{commit}}", rev)])?;
+    if !out.status.success() { bail!("Nieznana rewizja git: {}", rev); }
+    Ok(())
+}
+
+/// Pliki .hl zmienione między rewizjami, względem bieżącego katalogu
+fn changed_files(rev1: &str, rev2: &str) -> Result<Vec<PathBuf>> {
+    let out = git(&["diff", "--name-only", "--relative", rev1, rev2, "--", "*.hl"])?;
+    if !out.status.success() { bail!("git diff: {}", String::from_utf8_lossy(&out.stderr).trim()); }
+    Ok(String::from_utf8_lossy(&out.stdout).lines().filter(|l| !l.is_empty()).map(PathBuf::from).collect())
+}
+
+fn set_changes(title: &str, old: &BTreeSet<String>, new: &BTreeSet<String>, alarm: bool, out: &mut Vec<String>) {
+    let added: Vec<_> = new.difference(old).collect();
+    let removed: Vec<_> = old.difference(new).collect();
+    if added.is_empty() && removed.is_empty() { return; }
+    out.push(format!("  {}", title.bright_white().bold()));
+    for a in added {
+        out.push(if alarm { format!("    {} {}", "+".red().bold(), a.red().bold()) } else { format!("    {} {}", "+".green(), a) });
+    }
+    for r in removed { out.push(format!("    {} {}", "-".red(), r.bright_black())); }
+}
+
+/// Różnice zachowania — puste, gdy zmiana nie wpływa na działanie
+fn describe(old: &Facts, new: &Facts) -> Vec<String> {
+    let mut out = Vec::new();
+    set_changes("Sudo", &old.sudo, &new.sudo, true, &mut out);
+    set_changes("Uprawnienia", &old.caps, &new.caps, true, &mut out);
+    set_changes("Polityka bezpieczeństwa", &old.findings, &new.findings, true, &mut out);
+    set_changes("Zależności //", &old.deps, &new.deps, false, &mut out);
+    set_changes("Importy", &old.imports, &new.imports, false, &mut out);
+
+    let counted = |m: &BTreeMap<String, usize>| -> BTreeSet<String> {
+        m.iter().map(|(c, n)| if *n > 1 { format!("{}  (×{})", c, n) } else { c.clone() }).collect()
+    };
+    set_changes("Komendy", &counted(&old.commands), &counted(&new.commands), false, &mut out);
+    set_changes("Funkcje", &old.funcs, &new.funcs, false, &mut out);
+
+    let mut vars = Vec::new();
+    for (name, value) in &new.vars {
+        match old.vars.get(name) {
+            None                  => vars.push(format!("    {} @{} = {}", "+".green(), name, value)),
+            Some(v) if v != value => vars.push(format!("    {} @{}: {} → {}", "~".yellow(), name, v.bright_black(), value)),
+            _ => {}
+        }
+    }
+    for name in old.vars.keys().filter(|n| !new.vars.contains_key(*n)) {
+        vars.push(format!("    {} @{}", "-".red(), name.bright_black()));
+    }
+    if !vars.is_empty() {
+        out.push(format!("  {}", "Zmienne".bright_white().bold()));
+        out.extend(vars);
+    }
+    out
+}
+
+pub fn cmd_diff(rev1: &str, rev2: &str, file: Option<&Path>) -> Result<()> {
+    check_rev(rev1)?;
+    check_rev(rev2)?;
+    let files = match file {
+        Some(f) => vec![f.to_path_buf()],
+        None    => changed_files(rev1, rev2)?,
+    };
+    println!("{} {}..{}", "hl diff:".bright_magenta().bold(), rev1.bright_white(), rev2.bright_white());
+    if files.is_empty() {
+        println!("  {}", "brak zmienionych plików .hl".bright_black());
+        return Ok(());
+    }
+
+    let mut quiet = 0;
+    for path in &files {
+        let (old_src, new_src) = (show(rev1, path)?, show(rev2, path)?);
+        let label = match (&old_src, &new_src) {
+            (None, None)    => bail!("{}: nie ma w {} ani w {}", path.display(), rev1, rev2),
+            (None, Some(_)) => " (nowy plik)".green().to_string(),
+            (Some(_), None) => " (usunięty)".red().to_string(),
+            _               => String::new(),
+        };
+        let parse = |src: &Option<String>, rev: &str| match src {
+            None      => Ok(Facts::default()),
+            Some(src) => facts(path, src).map_err(|e| anyhow::anyhow!("{} w {}: {}", path.display(), rev, e)),
+        };
+        let lines = match (parse(&old_src, rev1), parse(&new_src, rev2)) {
+            (Ok(old), Ok(new)) => describe(&old, &new),
+            (Err(e), _) | (_, Err(e)) => vec![format!("  {} {}", "✗".red(), e)],
+        };
+        if lines.is_empty() { quiet += 1; continue; }
+        println!();
+        println!("{}{}", path.display().to_string().bright_cyan().bold(), label);
+        for line in lines { println!("{}", line); }
+    }
+    if quiet > 0 {
+        println!();
+        println!("  {}", format!("{} plik(ów) bez zmian w zachowaniu (formatowanie, komentarze)", quiet).bright_black());
+    }
+    Ok(())
+}
@@ -0,0 +1,188 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::io::{BufRead, IsTerminal, Write};
+use std::path::{Path, PathBuf};
+use std::process::Command;
+use std::sync::{Mutex, OnceLock};
+use std::time::{SystemTime, UNIX_EPOCH};
+use hl_parser::ast::{CommandMode, Node};
+use crate::env::Env;
+use crate::exit::{classified, ErrorClass};
+
+// ── Kroki z ~undo i hl rollback ───────────────────────────────────────────────
+//
+//   ~undo(rm -f /etc/app.conf) ^> cp app.conf /etc/app.conf
+//   ~undo(systemctl disable --now app) ^> systemctl enable --now app
+//
+// Po udanym kroku `~undo(...)` komenda cofająca (po podstawieniu zmiennych) jest
+// dopisywana do dziennika uruchomienia w meta/undo/<czas>-<pid>.jsonl — od razu,
+// więc dziennik przetrwa przerwany skrypt. Krok przez sudo cofa się przez sudo.
+//
+//   hl rollback --list           dzienniki z liczbą kroków
+//   hl rollback --last [--yes]   cofnij ostatnie uruchomienie (od końca)
+//   hl rollback <id> [--dry-run]
+//
+// Cofnięty dziennik dostaje końcówkę .done; jeśli komenda cofająca zawiedzie,
+// rollback staje, a w dzienniku zostają kroki jeszcze nie cofnięte.
+
+const DONE_EXT: &str = "done";
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+struct JournalHeader {
+    script:  String,
+    started: u64,
+}
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+struct UndoEntry {
+    step: String,
+    undo: String,
+    sudo: bool,
+}
+
+pub fn undo_dir() -> PathBuf {
+    crate::paths::data_dir("meta").join("undo")
+}
+
+fn now() -> u64 {
+    SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0)
+}
+
+/// Dziennik bieżącego uruchomienia — tworzony przy pierwszym kroku ~undo
+static JOURNAL: OnceLock<Mutex<Option<PathBuf>>> = OnceLock::new();
+
+fn append(entry: &UndoEntry, env: &Env) -> Result<()> {
+    let mut current = JOURNAL.get_or_init(|| Mutex::new(None)).lock().unwrap_or_else(|e| e.into_inner());
+    let path = match current.as_ref() {
+        Some(p) => p.clone(),
+        None => {
+            let dir = undo_dir();
+            std::fs::create_dir_all(&dir)?;
+            let started = now();
+            let path = dir.join(format!("{}-{}.jsonl", started, std::process::id()));
+            let script = env.get_var_str("HL_SCRIPT");
+            let script = std::fs::canonicalize(&script).map(|p| p.display().to_string()).unwrap_or(script);
+            std::fs::write(&path, serde_json::to_string(&JournalHeader { script, started })? + "\n")?;
+            *current = Some(path.clone());
+            path
+        }
+    };
+    let mut f = std::fs::OpenOptions::new().append(true).open(&path)?;
+    writeln!(f, "{}", serde_json::to_string(entry)?)?;
+    Ok(())
+}
+
+fn step_of(node: &Node, env: &mut Env) -> (String, bool) {
+    let sudo = |m: &CommandMode| matches!(m, CommandMode::Sudo | CommandMode::IsolatedSudo | CommandMode::WithVarsSudo);
+    match node {
+        Node::Command   { raw, mode, .. }     => (env.interpolate(raw).trim().to_string(), sudo(mode)),
+        Node::PipeToVar { command, mode, .. } => (env.interpolate(command).trim().to_string(), sudo(mode)),
+        Node::Once { body, .. } | Node::Check { body, .. } | Node::Retry { body, .. } | Node::RepeatN { body, .. } =>
+            body.first().map(|n| step_of(n, env)).unwrap_or_default(),
+        other => (format!("{:?}", other).chars().take(60).collect(), false),
+    }
+}
+
+/// Wykonaj krok i zapisz komendę cofającą, jeśli się udał
+pub(crate) fn exec_undoable(undo: &str, body: &[Node], env: &mut Env) -> Result<crate::executor::ExecResult> {
+    let r = crate::executor::exec_nodes(body, env)?;
+    if r.exit_code != 0 || crate::checkmode::check_mode() { return Ok(r); }
+    let (step, sudo) = body.first().map(|n| step_of(n, env)).unwrap_or_default();
+    let entry = UndoEntry { step, undo: env.interpolate(undo).trim().to_string(), sudo };
+    if let Err(e) = append(&entry, env) {
+        eprintln!("\x1b[33m[hl ~undo] nie zapisano kroku do dziennika: {}\x1b[0m", e);
+    }
+    Ok(r)
+}
+
+fn load_journal(path: &Path) -> Result<(JournalHeader, Vec<UndoEntry>)> {
+    let file = std::fs::File::open(path).with_context(|| format!("Nie można otworzyć {}", path.display()))?;
+    let mut lines = std::io::BufReader::new(file).lines();
+    let header: JournalHeader = match lines.next() {
+        Some(l) => serde_json::from_str(&l?).with_context(|| format!("{}: nieprawidłowy nagłówek", path.display()))?,
+        None    => bail!("{}: pusty dziennik", path.display()),
+    };
+    let entries = lines.map_while(Result::ok).filter_map(|l| serde_json::from_str(&l).ok()).collect();
+    Ok((header, entries))
+}
+
+/// Dzienniki do cofnięcia, od najstarszego
+fn journals() -> Vec<PathBuf> {
+    let Ok(rd) = std::fs::read_dir(undo_dir()) else { return vec![] };
+    let mut out: Vec<PathBuf> = rd.flatten().map(|e| e.path())
+        .filter(|p| p.extension().and_then(|e| e.to_str()) == Some("jsonl"))
+        .collect();
+    out.sort_by_key(|p| p.file_stem().and_then(|s| s.to_str()).and_then(|s| s.split('-').next()?.parse::<u64>().ok()));
+    out
+}
+
+fn journal_id(path: &Path) -> String {
+    path.file_stem().map(|s| s.to_string_lossy().to_string()).unwrap_or_default()
+}
+
+/// Identyfikatory dzienników do cofnięcia (podpowiedzi powłoki)
+pub(crate) fn journal_ids() -> Vec<String> {
+    journals().iter().map(|p| journal_id(p)).collect()
+}
+
+pub fn cmd_rollback_list() -> Result<()> {
+    let all = journals();
+    println!("{}", "hl rollback: dzienniki do cofnięcia".bright_magenta().bold());
+    if all.is_empty() { println!("  {}", "brak".bright_black()); }
+    for path in all {
+        let Ok((header, entries)) = load_journal(&path) else { continue };
+        println!("  {:<22} {}  {:>3} kroków  {}", journal_id(&path).bright_white(),
+                 crate::history::format_ts(header.started).bright_black(), entries.len(), header.script);
+    }
+    Ok(())
+}
+
+/// Cofnij dziennik `id` albo ostatni; zwraca kod wyjścia
+pub fn cmd_rollback(id: Option<&str>, yes: bool, dry_run: bool) -> Result<i32> {
+    let path = match id {
+        Some(id) => undo_dir().join(format!("{}.jsonl", id)),
+        None     => journals().pop().ok_or_else(|| anyhow::anyhow!("Brak uruchomień z krokami ~undo do cofnięcia"))?,
+    };
+    if !path.exists() { bail!("Nie ma dziennika {} — zobacz `hl rollback --list`", journal_id(&path)); }
+    let (header, entries) = load_journal(&path)?;
+    println!("{} {} ({})", "hl rollback:".bright_magenta().bold(), header.script.bright_white(),
+             crate::history::format_ts(header.started));
+    for e in entries.iter().rev() {
+        println!("  {} {}{}   {}", "↶".yellow(), if e.sudo { "sudo " } else { "" }, e.undo, format!("(cofa: {})", e.step).bright_black());
+    }
+    if dry_run || entries.is_empty() { return Ok(0); }
+
+    if !yes {
+        if !std::io::stdin().is_terminal() {
+            return Err(classified(ErrorClass::Denied, "hl rollback w trybie nieinteraktywnym — potwierdź flagą --yes"));
+        }
+        eprint!("  Wykonać {} komend cofających? [t/N] ", entries.len());
+        std::io::stderr().flush().ok();
+        let mut answer = String::new();
+        std::io::stdin().lock().read_line(&mut answer)?;
+        if !matches!(answer.trim().to_lowercase().as_str(), "t" | "tak" | "y" | "yes") {
+            eprintln!("  Anulowano.");
+            return Ok(crate::exit::CANCELLED);
+        }
+    }
+
+    let mut remaining = entries.clone();
+    while let Some(e) = remaining.pop() {
+        let mut cmd = if e.sudo { let mut c = Command::new("sudo"); c.arg("bash"); c } else { Command::new("bash") };
+        let status = cmd.arg("-c").arg(&e.undo).status()?;
+        if !status.success() {
+            remaining.push(e.clone());
+            let mut out = serde_json::to_string(&header)? + "\n";
+            for r in &remaining { out.push_str(&(serde_json::to_string(r)? + "\n")); }
+            std::fs::write(&path, out)?;
+            eprintln!("  {} {} — kod {}; w dzienniku zostało {} kroków", "✗".red(), e.undo,
+                      status.code().unwrap_or(1), remaining.len());
+            return Ok(crate::exit::EXECUTION);
+        }
+        println!("  {} {}", "✓".green(), e.undo);
+    }
+    std::fs::rename(&path, path.with_extension(DONE_EXT))?;
+    println!("  {} cofnięto {} kroków", "✓".green().bold(), entries.len());
+    Ok(0)
+}
@@ -0,0 +1,215 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+use std::sync::{Arc, Mutex};
+use std::time::Instant;
+use crate::config::load_hk_file;
+use crate::remote::{run_on_host, RemoteResult};
+
+// ── hl rollout ────────────────────────────────────────────────────────────────
+//
+//   hl rollout <zadanie> [--inventory inventory.hk] [--group web]
+//              [--max-parallel 5] [--canary 1] [--max-failures 0|10%]
+//
+// Inventory (inventory.hk w katalogu bieżącym):
+//
+//   [hosts]
+//   -> web1 => root@10.0.0.1
+//   -> web2 => root@10.0.0.2
+//   -> db1  => admin@10.0.0.9
+//
+//   [groups]
+//   -> webservers => web1, web2
+//
+//   [vars]
+//   -> all        => REGION=eu
+//   -> webservers => ENV=prod, PORT=80
+//   -> web1       => PORT=8080
+//
+//   [tasks]
+//   -> update => scripts/update.hl
+//
+// Zadanie to nazwa z [tasks] (ścieżka względem inventory) albo ścieżka skryptu.
+// Zmienne trafiają do `hl run --var` na hoście; host wygrywa z grupą, grupa
+// z `all`. Najpierw `--canary` hostów — jeśli którykolwiek zawiedzie, reszta
+// jest pomijana. Potem fale po `--max-parallel`; po każdej fali liczba błędów
+// jest porównywana z `--max-failures` (liczba albo procent hostów).
+
+pub const INVENTORY_FILE: &str = "inventory.hk";
+
+#[derive(Debug, Clone)]
+pub struct Inventory {
+    pub path:   PathBuf,
+    /// nazwa → user@host
+    pub hosts:  Vec<(String, String)>,
+    pub groups: Vec<(String, Vec<String>)>,
+    /// host / grupa / `all` → NAZWA=WARTOŚĆ
+    pub vars:   Vec<(String, Vec<String>)>,
+    pub tasks:  Vec<(String, String)>,
+}
+
+fn list(value: &str) -> Vec<String> {
+    value.split(',').map(str::trim).filter(|s| !s.is_empty()).map(str::to_string).collect()
+}
+
+pub fn load_inventory(path: &Path) -> Result<Inventory> {
+    let cfg = load_hk_file(path).with_context(|| format!("Nie można wczytać inventory {}", path.display()))?;
+    let inv = Inventory {
+        path:   path.to_path_buf(),
+        hosts:  cfg.entries("hosts"),
+        groups: cfg.entries("groups").into_iter().map(|(g, v)| (g, list(&v))).collect(),
+        vars:   cfg.entries("vars").into_iter().map(|(k, v)| (k, list(&v))).collect(),
+        tasks:  cfg.entries("tasks"),
+    };
+    for (owner, vars) in &inv.vars {
+        if let Some(bad) = vars.iter().find(|v| !v.contains('=')) {
+            bail!("{}: [vars] {} — '{}' (oczekiwano NAZWA=WARTOŚĆ)", path.display(), owner, bad);
+        }
+    }
+    Ok(inv)
+}
+
+impl Inventory {
+    /// Skrypt zadania: nazwa z [tasks] albo ścieżka
+    pub fn task_script(&self, task: &str) -> PathBuf {
+        match self.tasks.iter().find(|(name, _)| name == task) {
+            Some((_, script)) => self.path.parent().unwrap_or(Path::new(".")).join(script),
+            None              => PathBuf::from(task),
+        }
+    }
+
+    fn address(&self, name: &str) -> String {
+        self.hosts.iter().find(|(n, _)| n == name).map(|(_, a)| a.clone()).unwrap_or_else(|| name.to_string())
+    }
+
+    /// Hosty grupy (albo pojedynczy host); None / `all` — wszystkie z [hosts]
+    pub fn select(&self, group: Option<&str>) -> Result<Vec<String>> {
+        let names: Vec<String> = match group {
+            None | Some("all") => self.hosts.iter().map(|(n, _)| n.clone()).collect(),
+            Some(g) => match self.groups.iter().find(|(name, _)| name == g) {
+                Some((_, members)) => members.clone(),
+                None if self.hosts.iter().any(|(n, _)| n == g) => vec![g.to_string()],
+                None => bail!("{}: nie ma grupy ani hosta '{}'", self.path.display(), g),
+            },
+        };
+        if names.is_empty() { bail!("{}: brak hostów do uruchomienia", self.path.display()); }
+        Ok(names)
+    }
+
+    /// Zmienne hosta: `all`, potem grupy (w kolejności z pliku), potem sam host
+    fn host_vars(&self, name: &str) -> Vec<String> {
+        let mut owners = vec!["all"];
+        owners.extend(self.groups.iter().filter(|(_, m)| m.iter().any(|h| h == name)).map(|(g, _)| g.as_str()));
+        owners.push(name);
+        let mut out: Vec<String> = Vec::new();
+        for owner in owners {
+            for var in self.vars.iter().filter(|(o, _)| o == owner).flat_map(|(_, v)| v) {
+                let key = var.split('=').next().unwrap_or(var);
+                out.retain(|v| v.split('=').next() != Some(key));
+                out.push(var.clone());
+            }
+        }
+        out
+    }
+}
+
+/// `--max-failures`: liczba hostów albo procent
+#[derive(Debug, Clone, Copy)]
+pub enum Threshold { Count(usize), Percent(f64) }
+
+impl Threshold {
+    pub fn parse(s: &str) -> Result<Self, String> {
+        let s = s.trim();
+        match s.strip_suffix('%') {
+            Some(p) => p.trim().parse::<f64>().ok().filter(|p| (0.0..=100.0).contains(p))
+                .map(Threshold::Percent).ok_or_else(|| format!("'{}' — procent 0..100", s)),
+            None    => s.parse().map(Threshold::Count).map_err(|_| format!("'{}' — oczekiwano liczby lub procentu (np. 2, 10%)", s)),
+        }
+    }
+
+    fn exceeded(self, failed: usize, total: usize) -> bool {
+        match self {
+            Threshold::Count(n)   => failed > n,
+            Threshold::Percent(p) => failed as f64 * 100.0 > p * total as f64,
+        }
+    }
+}
+
+#[derive(Debug, Clone)]
+pub struct RolloutOptions {
+    pub group:        Option<String>,
+    pub max_parallel: usize,
+    pub canary:       usize,
+    pub max_failures: Threshold,
+    pub args:         Vec<String>,
+}
+
+fn run_wave(inv: &Inventory, names: &[String], script: &Path, opts: &RolloutOptions, lock: &Arc<Mutex<()>>) -> Vec<RemoteResult> {
+    std::thread::scope(|s| {
+        let handles: Vec<_> = names.iter().map(|name| {
+            let (addr, vars, lock) = (inv.address(name), inv.host_vars(name), lock.clone());
+            s.spawn(move || {
+                let t0 = Instant::now();
+                let res = run_on_host(&addr, script, &opts.args, &vars, lock);
+                let secs = t0.elapsed().as_secs_f64();
+                match res {
+                    Ok(code) => RemoteResult { host: name.clone(), exit_code: code, secs, error: None },
+                    Err(e)   => RemoteResult { host: name.clone(), exit_code: 255, secs, error: Some(e.to_string()) },
+                }
+            })
+        }).collect();
+        handles.into_iter().zip(names).map(|(h, name)| h.join().unwrap_or_else(|_| RemoteResult {
+            host: name.clone(), exit_code: 255, secs: 0.0, error: Some("panic wątku".into()),
+        })).collect()
+    })
+}
+
+/// Uruchom skrypt na hostach z inventory falami; zwraca kod wyjścia hl rollout
+pub fn cmd_rollout(inv: &Inventory, script: &Path, opts: &RolloutOptions) -> Result<i32> {
+    if !script.is_file() { bail!("Plik nie istnieje: {}", script.display()); }
+    let script = std::fs::canonicalize(script)?;
+    let names = inv.select(opts.group.as_deref())?;
+    let canary = opts.canary.min(names.len());
+    let mut waves: Vec<&[String]> = Vec::new();
+    if canary > 0 { waves.push(&names[..canary]); }
+    waves.extend(names[canary..].chunks(opts.max_parallel.max(1)));
+
+    println!("{} {} → {} hostów, fale: {}{}", "hl rollout:".bright_magenta().bold(),
+             script.display().to_string().bright_white(), names.len(), waves.len(),
+             if canary > 0 { format!(", canary {}", canary) } else { String::new() });
+
+    let lock = Arc::new(Mutex::new(()));
+    let mut results: Vec<RemoteResult> = Vec::new();
+    let mut stopped: Option<String> = None;
+    for (i, wave) in waves.iter().enumerate() {
+        let label = if i == 0 && canary > 0 { "canary".to_string() } else { format!("fala {}", i + 1) };
+        println!("{}", format!("── {}: {} ──", label, wave.join(", ")).bright_cyan());
+        let done = run_wave(inv, wave, &script, opts, &lock);
+        let wave_failed = done.iter().filter(|r| r.exit_code != 0).count();
+        results.extend(done);
+        let failed = results.iter().filter(|r| r.exit_code != 0).count();
+        if i == 0 && canary > 0 && wave_failed > 0 {
+            stopped = Some(format!("canary nie powiodło się ({} z {})", wave_failed, wave.len()));
+        } else if opts.max_failures.exceeded(failed, names.len()) {
+            stopped = Some(format!("{} błędów przekracza --max-failures", failed));
+        }
+        if stopped.is_some() { break; }
+    }
+
+    println!();
+    println!("{}", "=== hl rollout: podsumowanie ===".bright_cyan().bold());
+    for r in &results {
+        let status = if r.exit_code == 0 { "✓".green().bold() } else { "✗".red().bold() };
+        print!("  {} {} exit={} ({:.1}s)", status, format!("{:<20}", r.host).bright_white(), r.exit_code, r.secs);
+        if let Some(ref e) = r.error { print!("  {}", e.bright_black()); }
+        println!();
+    }
+    let skipped: Vec<&String> = names.iter().filter(|n| !results.iter().any(|r| r.host == **n)).collect();
+    for name in &skipped {
+        println!("  {} {} {}", "·".bright_black(), format!("{:<20}", name).bright_black(), "pominięty".bright_black());
+    }
+    let ok = results.iter().filter(|r| r.exit_code == 0).count();
+    println!("  {}/{} hostów OK, {} błędów, {} pominiętych", ok, names.len(), results.len() - ok, skipped.len());
+    if let Some(why) = &stopped { println!("  {} {}", "zatrzymano:".red().bold(), why); }
+    Ok(if ok == names.len() { 0 } else { 1 })
+}
@@ -0,0 +1,186 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+
+// ── hl new ────────────────────────────────────────────────────────────────────
+//
+//   hl new lib <nazwa>    biblioteka bit:  lib.hl, tests/, README.adoc
+//   hl new app <nazwa>    aplikacja:       main.hl, build.hl, tests/
+//
+// Układ biblioteki odpowiada temu, czego szuka `# <bit/nazwa>` (lib.hl w katalogu
+// biblioteki) — katalog można od razu skopiować do libs/ projektu albo opublikować
+// jako repozytorium git i dodać do repo-list.json.
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum NewKind { Lib, App }
+
+fn validate_name(name: &str) -> Result<()> {
+    let ok = !name.is_empty()
+        && name.chars().next().is_some_and(|c| c.is_ascii_lowercase())
+        && name.chars().all(|c| c.is_ascii_lowercase() || c.is_ascii_digit() || c == '-' || c == '_');
+    if !ok { bail!("Nieprawidłowa nazwa '{}' — małe litery, cyfry, '-' i '_', zaczyna się literą", name); }
+    Ok(())
+}
+
+fn author() -> String {
+    std::env::var("USER").unwrap_or_else(|_| "hacker".into())
+}
+
+const CHK_FN: &str = r#": chk def
+    ? ok
+        $(@pass + 1) -> @pass
+        ::green PASS — @_name
+    done
+    ? err
+        $(@fail + 1) -> @fail
+        ::red FAIL — @_name
+    done
+done
+"#;
+
+fn lib_files(name: &str) -> Vec<(String, String)> {
+    let fname = name.replace('-', "_");
+    vec![
+        ("lib.hl".into(), format!(
+r#"/// {name} — biblioteka Hacker Lang
+/// Author: {author}
+/// Version: 0.1.0
+/// Description: TODO
+
+using <gen 2>
+
+;; ── API ──────────────────────────────────────────────────────────────────────
+
+;; {fname}_hello: wypisz powitanie
+;; użycie: % _who = <kto>, potem -- {fname}_hello
+: {fname}_hello def
+    ~> Hello, @_who!
+done
+"#, name = name, fname = fname, author = author())),
+
+        (format!("tests/test_{}.hl", fname), format!(
+r#"#!/usr/bin/hl
+/// Testy biblioteki {name} — uruchom z katalogu biblioteki: hl run tests/test_{fname}.hl
+
+using <gen 2>
+
+<< lib
+
+% pass = 0
+% fail = 0
+
+{chk}
+::cyan Testy {name}
+::nl
+
+% _who  = świat
+% _name = {fname}_hello
+-- {fname}_hello
+-- chk
+
+::nl
+~> @pass passed, @fail failed
+"#, name = name, fname = fname, chk = CHK_FN)),
+
+        ("README.adoc".into(), format!(
+r#"= {name}
+
+Biblioteka Hacker Lang.
+
+== Użycie
+
+[source,hl]
+----
+# <bit/{name}>
+
+% _who = świat
+-- {fname}_hello
+----
+
+== Instalacja lokalna
+
+Skopiuj katalog do `libs/{name}/` w projekcie (hl szuka tam w pierwszej kolejności).
+
+== Testy
+
+[source,bash]
+----
+hl run tests/test_{fname}.hl
+----
+"#, name = name, fname = fname)),
+
+        (".gitignore".into(), ".cache/\n*.bc\n.hl-state.json\n".into()),
+    ]
+}
+
+fn app_files(name: &str) -> Vec<(String, String)> {
+    vec![
+        ("main.hl".into(), format!(
+r#"#!/usr/bin/hl
+/// {name}
+/// Author: {author}
+/// Version: 0.1.0
+
+using <gen 2>
+
+: main def
+    ~> Hello from {name}!
+done
+
+-- main
+"#, name = name, author = author())),
+
+        ("build.hl".into(), format!(
+r#"using <gen 2>
+
+;; Docelowy format wyjściowy
+% BIT_BUILD_TARGET = bc     # bc | elf | so
+
+;; Główny plik wejściowy
+% BIT_BUILD_INPUT = main.hl
+
+;; Nazwa wyjściowa
+% BIT_BUILD_OUTPUT = {name}
+"#, name = name)),
+
+        ("tests/test_main.hl".into(), format!(
+r#"#!/usr/bin/hl
+/// Testy {name} — uruchom z katalogu projektu: hl run tests/test_main.hl
+
+using <gen 2>
+
+% pass = 0
+% fail = 0
+
+{chk}
+> hl check main.hl
+% _name = main.hl przechodzi hl check
+-- chk
+
+::nl
+~> @pass passed, @fail failed
+"#, name = name, chk = CHK_FN)),
+
+        (".gitignore".into(), ".cache/\n*.bc\n.hl-state.json\n".into()),
+    ]
+}
+
+pub fn cmd_new(kind: NewKind, name: &str) -> Result<PathBuf> {
+    validate_name(name)?;
+    let root = PathBuf::from(name);
+    if root.exists() { bail!("Katalog {} już istnieje", root.display()); }
+
+    let files = match kind { NewKind::Lib => lib_files(name), NewKind::App => app_files(name) };
+    for (rel, content) in &files {
+        let path = root.join(rel);
+        if let Some(parent) = path.parent() { std::fs::create_dir_all(parent)?; }
+        std::fs::write(&path, content)?;
+    }
+
+    let what = match kind { NewKind::Lib => "biblioteka", NewKind::App => "aplikacja" };
+    println!("{} {} {}", "hl new:".bright_magenta().bold(), what, name.bright_white().bold());
+    for (rel, _) in &files { println!("  {} {}", "✓".green(), Path::new(name).join(rel).display()); }
+    let test = files.iter().map(|(r, _)| r).find(|r| r.starts_with("tests/")).cloned().unwrap_or_default();
+    println!("  Testy: {}", format!("cd {} && hl run {}", name, test).bright_cyan());
+    Ok(root)
+}
@@ -0,0 +1,189 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use crate::deploy::{hl_binary, validate_unit_name};
+use crate::remote::shell_quote;
+
+// ── hl schedule ───────────────────────────────────────────────────────────────
+//
+// Zarządza wpisami crontab użytkownika uruchamiającymi skrypty HL:
+//   hl schedule add "0 3 * * *" ./maintenance.hl [--name nazwa]
+//   hl schedule list
+//   hl schedule remove nazwa
+//
+// Każdy wpis jest oznaczony komentarzem `# hl-schedule:<nazwa>` — list/remove
+// dotykają tylko tych linii, reszta crontaba zostaje nietknięta.
+// Wyjście skryptu trafia do ~/.hackeros/hacker-lang/logs/<nazwa>.log, a HL_RUN_LOG
+// wskazuje ten plik, więc każde uruchomienie z crona ma w dzienniku (hl history)
+// odnośnik do logu. Ścieżki są cytowane dla powłoki, a `%` (w cronie — nowa linia)
+// zapisywane jako `\%`.
+
+const SCHEDULE_TAG: &str = "# hl-schedule:";
+
+#[derive(Debug, Clone)]
+pub struct ScheduleEntry {
+    pub name:   String,
+    pub spec:   String,
+    pub script: String,
+}
+
+pub fn schedule_logs_dir() -> PathBuf {
+    crate::paths::logs_dir()
+}
+
+fn read_crontab() -> Result<String> {
+    if which::which("crontab").is_err() {
+        bail!("crontab nie jest zainstalowany (// cron)");
+    }
+    let out = Command::new("crontab").arg("-l").output()?;
+    // `crontab -l` bez crontaba kończy się kodem 1 — traktujemy jako pusty
+    if !out.status.success() { return Ok(String::new()); }
+    Ok(String::from_utf8_lossy(&out.stdout).into_owned())
+}
+
+fn write_crontab(content: &str) -> Result<()> {
+    let mut child = Command::new("crontab")
+        .arg("-")
+        .stdin(Stdio::piped())
+        .spawn()
+        .context("Nie można uruchomić crontab")?;
+    if let Some(stdin) = child.stdin.as_mut() {
+        stdin.write_all(content.as_bytes())?;
+    }
+    if !child.wait()?.success() { bail!("crontab odrzucił nową tabelę"); }
+    Ok(())
+}
+
+/// Argument w cytowaniu powłoki z `%` zabezpieczonym dla crona
+fn cron_quote(s: &str) -> String {
+    shell_quote(s).replace('%', "\\%")
+}
+
+fn cron_unquote(s: &str) -> String {
+    let s = s.trim().replace("\\%", "%");
+    if let Some(inner) = s.strip_prefix('\'').and_then(|r| r.strip_suffix('\'')) {
+        return inner.replace("'\\''", "'");
+    }
+    s
+}
+
+fn parse_entry(line: &str) -> Option<ScheduleEntry> {
+    let tag_pos = line.find(SCHEDULE_TAG)?;
+    let name = line[tag_pos + SCHEDULE_TAG.len()..].trim().to_string();
+    let cmd = &line[..tag_pos];
+    let fields: Vec<&str> = cmd.split_whitespace().collect();
+    // <5 pól czasu | @makro> [HL_RUN_LOG=log] <hl> run <skrypt> >> log 2>&1
+    let spec_len = if fields.first()?.starts_with('@') { 1 } else { 5 };
+    if fields.len() < spec_len + 3 { return None; }
+    let after_run = &cmd[cmd.find(" run ")? + 5..];
+    let script = after_run.rfind(" >> ").map_or(after_run, |i| &after_run[..i]);
+    Some(ScheduleEntry {
+        name,
+        spec:   fields[..spec_len].join(" "),
+        script: cron_unquote(script),
+    })
+}
+
+pub fn list_schedules() -> Result<Vec<ScheduleEntry>> {
+    Ok(read_crontab()?.lines().filter_map(parse_entry).collect())
+}
+
+fn validate_spec(spec: &str) -> Result<()> {
+    let fields: Vec<&str> = spec.split_whitespace().collect();
+    if spec.starts_with('@') && fields.len() == 1 { return Ok(()); }
+    if fields.len() != 5 {
+        bail!("Nieprawidłowe wyrażenie cron '{}' — oczekiwano 5 pól (min godz dzień mies dzień-tyg)", spec);
+    }
+    for f in fields {
+        if !f.chars().all(|c| c.is_ascii_alphanumeric() || "*/,-".contains(c)) {
+            bail!("Nieprawidłowe pole cron: '{}'", f);
+        }
+    }
+    Ok(())
+}
+
+fn default_name(script: &Path, taken: &[ScheduleEntry]) -> String {
+    let stem = script.file_stem().and_then(|s| s.to_str()).unwrap_or("script").to_string();
+    if !taken.iter().any(|e| e.name == stem) { return stem; }
+    (2..).map(|i| format!("{}-{}", stem, i))
+        .find(|n| !taken.iter().any(|e| &e.name == n))
+        .unwrap_or(stem)
+}
+
+pub fn cmd_schedule_add(spec: &str, script: &Path, name: Option<&str>) -> Result<()> {
+    validate_spec(spec)?;
+    if let Some(n) = name { validate_unit_name(n)?; }
+    if !script.exists() { bail!("Plik nie istnieje: {}", script.display()); }
+    let script = std::fs::canonicalize(script)?;
+
+    let existing = list_schedules()?;
+    let name = match name {
+        Some(n) => {
+            if existing.iter().any(|e| e.name == n) {
+                bail!("Harmonogram '{}' już istnieje — usuń go: hl schedule remove {}", n, n);
+            }
+            n.to_string()
+        }
+        None => default_name(&script, &existing),
+    };
+    validate_unit_name(&name)?;
+
+    let logs = schedule_logs_dir();
+    std::fs::create_dir_all(&logs)?;
+    let log_file = logs.join(format!("{}.log", name));
+
+    let log = cron_quote(&log_file.display().to_string());
+    let line = format!("{} HL_RUN_LOG={} {} run {} >> {} 2>&1 {}{}",
+        spec, log, cron_quote(&hl_binary()), cron_quote(&script.display().to_string()), log, SCHEDULE_TAG, name);
+
+    let mut tab = read_crontab()?;
+    if !tab.is_empty() && !tab.ends_with('\n') { tab.push('\n'); }
+    tab.push_str(&line);
+    tab.push('\n');
+    write_crontab(&tab)?;
+
+    println!("{} {} {}", "hl schedule:".bright_magenta().bold(),
+             "dodano".green(), name.bright_cyan().bold());
+    println!("  Kiedy:  {}", spec.bright_white());
+    println!("  Skrypt: {}", script.display().to_string().bright_white());
+    println!("  Log:    {}", log_file.display().to_string().bright_black());
+    Ok(())
+}
+
+pub fn cmd_schedule_list() -> Result<()> {
+    let entries = list_schedules()?;
+    if entries.is_empty() {
+        println!("{}", "Brak zaplanowanych skryptów HL.".bright_black());
+        return Ok(());
+    }
+    println!("{}", "=== Zaplanowane skrypty HL ===".bright_cyan().bold());
+    for e in &entries {
+        println!("  {} {} {}",
+                 format!("{:<20}", e.name).bright_white().bold(),
+                 format!("{:<16}", e.spec).bright_yellow(),
+                 e.script.bright_black());
+    }
+    Ok(())
+}
+
+pub fn cmd_schedule_remove(name: &str) -> Result<()> {
+    let tab = read_crontab()?;
+    let tag = format!("{}{}", SCHEDULE_TAG, name);
+    let mut removed = false;
+    let kept: Vec<&str> = tab.lines()
+        .filter(|l| {
+            let hit = l.trim_end().ends_with(&tag);
+            removed |= hit;
+            !hit
+        })
+        .collect();
+    if !removed { bail!("Harmonogram '{}' nie istnieje", name); }
+
+    let mut out = kept.join("\n");
+    if !out.is_empty() { out.push('\n'); }
+    write_crontab(&out)?;
+    println!("{} {} {}", "hl schedule:".bright_magenta().bold(), "usunięto".red(), name.bright_cyan());
+    Ok(())
+}
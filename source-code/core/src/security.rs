@@ -0,0 +1,640 @@
+use anyhow::Result;
+use rustc_hash::FxHashMap;
+use std::fmt;
+use std::path::{Path, PathBuf};
+use crate::config::load_hk_file;
+use hl_parser::ast::Node;
+use crate::diagnostics::{visit_nodes, Diag, DiagLevel, Span};
+
+// ── hl check --security ───────────────────────────────────────────────────────
+//
+// Analiza niebezpiecznych komend w liniach `>`, `>>`, `->`, `^>` itd.
+// Reguły i domyślne poziomy:
+//
+//   rm-root          deny   rm -rf na /, /*, ~ lub $HOME
+//   curl-pipe-shell  deny   curl/wget … | sh/bash
+//   disk-write       deny   dd of=/dev/…, mkfs, zapis do /dev/sd*
+//   etc-write        warn   zapis do /etc (>, >>, tee, cp, mv, sed -i)
+//   sudo-unquoted    warn   niecytowane @zmienne w komendach sudo (^>)
+//   chmod-777        warn   chmod 777
+//
+// Polityka projektu — security.hk obok skryptu (lub w katalogu bieżącym):
+//
+//   [policy]
+//   -> etc-write       => allow
+//   -> sudo-unquoted   => deny
+//
+// deny → error, warn → warning, allow → pominięte. Nieznany identyfikator reguły
+// lub poziom to błąd wczytania polityki — literówka nie wyłącza reguły po cichu.
+// `hl check --security` kończy się kodem 126 (exit::DENIED), jeśli jest choć jedno naruszenie deny.
+
+pub const POLICY_FILE: &str = "security.hk";
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum PolicyLevel { Deny, Warn, Allow }
+
+impl PolicyLevel {
+    pub fn from_str(s: &str) -> Option<Self> {
+        match s.trim() {
+            "deny"  | "error"   => Some(PolicyLevel::Deny),
+            "warn"  | "warning" => Some(PolicyLevel::Warn),
+            "allow" | "off"     => Some(PolicyLevel::Allow),
+            _ => None,
+        }
+    }
+}
+
+pub struct Rule {
+    pub id:         &'static str,
+    pub default:    PolicyLevel,
+    pub message:    &'static str,
+    pub suggestion: &'static str,
+}
+
+pub const RULES: &[Rule] = &[
+    Rule { id: "rm-root",         default: PolicyLevel::Deny,
+           message:    "rekurencyjne usuwanie katalogu głównego lub domowego",
+           suggestion: "podaj konkretną ścieżkę i sprawdź ją przed usunięciem" },
+    Rule { id: "curl-pipe-shell", default: PolicyLevel::Deny,
+           message:    "pobrany skrypt przekazywany prosto do powłoki",
+           suggestion: "pobierz plik, zweryfikuj sumę kontrolną, dopiero potem uruchom" },
+    Rule { id: "disk-write",      default: PolicyLevel::Deny,
+           message:    "bezpośredni zapis na urządzenie blokowe",
+           suggestion: "upewnij się, że urządzenie jest parametrem potwierdzonym przez użytkownika" },
+    Rule { id: "etc-write",       default: PolicyLevel::Warn,
+           message:    "zapis do /etc",
+           suggestion: "zrób kopię pliku przed zmianą lub użyj drop-in (np. /etc/<usługa>.d/)" },
+    Rule { id: "sudo-unquoted",   default: PolicyLevel::Warn,
+           message:    "niecytowana zmienna w komendzie uruchamianej przez sudo",
+           suggestion: "otocz zmienną cudzysłowem: \"@zmienna\"" },
+    Rule { id: "chmod-777",       default: PolicyLevel::Warn,
+           message:    "chmod 777 — plik zapisywalny dla wszystkich",
+           suggestion: "użyj węższych uprawnień, np. 755 lub 644" },
+];
+
+#[derive(Debug, Clone, Default)]
+pub struct SecurityPolicy {
+    overrides: FxHashMap<String, PolicyLevel>,
+    pub source: Option<PathBuf>,
+}
+
+impl SecurityPolicy {
+    pub fn level(&self, rule: &Rule) -> PolicyLevel {
+        self.overrides.get(rule.id).copied().unwrap_or(rule.default)
+    }
+}
+
+/// Wczytaj security.hk z katalogu skryptu, potem z katalogu bieżącego
+pub fn load_policy(script: &Path) -> Result<SecurityPolicy> {
+    let candidates = [
+        script.parent().map(|d| d.join(POLICY_FILE)),
+        Some(PathBuf::from(POLICY_FILE)),
+    ];
+    let Some(path) = candidates.into_iter().flatten().find(|p| p.exists()) else {
+        return Ok(SecurityPolicy::default());
+    };
+    let cfg = load_hk_file(&path)?;
+    let mut overrides = FxHashMap::default();
+    for (rule, level) in cfg.entries("policy") {
+        if !RULES.iter().any(|r| r.id == rule) {
+            let known: Vec<&str> = RULES.iter().map(|r| r.id).collect();
+            anyhow::bail!("{}: nieznana reguła '{}' ({})", path.display(), rule, known.join(" | "));
+        }
+        match PolicyLevel::from_str(&level) {
+            Some(l) => { overrides.insert(rule, l); }
+            None => anyhow::bail!("{}: nieznany poziom '{}' dla '{}' (deny | warn | allow)", path.display(), level, rule),
+        }
+    }
+    Ok(SecurityPolicy { overrides, source: Some(path) })
+}
+
+const CMD_PREFIXES: &[&str] = &["^->>", "^->", "^>>", "^>", "->>", "->", "*>", ">>", ">"];
+
+/// Treść komendy i informacja czy idzie przez sudo (`^` lub jawne `sudo`)
+fn command_of(line: &str) -> Option<(String, bool)> {
+    let t = line.trim();
+    let prefix = CMD_PREFIXES.iter().find(|p| t.starts_with(**p))?;
+    let body = t[prefix.len()..].trim();
+    let sudo = prefix.starts_with('^') || body.starts_with("sudo ");
+    Some((body.trim_start_matches("sudo ").trim().to_string(), sudo))
+}
+
+fn words(cmd: &str) -> Vec<&str> { cmd.split_whitespace().collect() }
+
+fn is_rm_root(cmd: &str) -> bool {
+    cmd.split(['|', ';', '&']).any(|part| {
+        let w = words(part);
+        if w.first() != Some(&"rm") { return false; }
+        let recursive = w.iter().any(|a| a.starts_with('-') && !a.starts_with("--") && (a.contains('r') || a.contains('R')))
+            || w.contains(&"--recursive");
+        recursive && w.iter().skip(1).any(|a| matches!(
+            a.trim_matches('"').trim_matches('\''),
+            "/" | "/*" | "~" | "~/" | "~/*" | "$HOME" | "$HOME/" | "$HOME/*" | "@HOME" | "@HOME/*"
+        ))
+    })
+}
+
+fn is_curl_pipe_shell(cmd: &str) -> bool {
+    let mut parts = cmd.split('|');
+    let Some(first) = parts.next() else { return false };
+    let fetch = words(first).iter().any(|w| *w == "curl" || *w == "wget");
+    fetch && parts.any(|p| {
+        let w = words(p);
+        let w: Vec<&str> = w.into_iter().filter(|x| *x != "sudo" && !x.starts_with('-')).collect();
+        matches!(w.first().copied(), Some("sh" | "bash" | "zsh" | "dash" | "hl"))
+    })
+}
+
+fn is_disk_write(cmd: &str) -> bool {
+    let w = words(cmd);
+    (w.first() == Some(&"dd") && w.iter().any(|a| a.starts_with("of=/dev/") && !a.starts_with("of=/dev/null")))
+        || w.first().is_some_and(|c| c.starts_with("mkfs"))
+        || cmd.contains("> /dev/sd") || cmd.contains("> /dev/nvme")
+}
+
+fn is_etc_write(cmd: &str) -> bool {
+    let targets_etc = |a: &&str| a.trim_matches('"').starts_with("/etc/");
+    cmd.contains("> /etc/") || cmd.contains(">/etc/")
+        || cmd.split(['|', ';', '&']).any(|part| {
+            let w: Vec<&str> = words(part).into_iter().skip_while(|x| *x == "sudo").collect();
+            match w.first().copied() {
+                Some("tee")                   => w.iter().skip(1).any(targets_etc),
+                Some("cp" | "mv" | "install") => w.last().is_some_and(targets_etc),
+                Some("sed")                   => w.contains(&"-i") && w.iter().any(targets_etc),
+                _ => false,
+            }
+        })
+}
+
+fn has_unquoted_var(cmd: &str) -> bool {
+    let mut in_quotes: Option<char> = None;
+    let mut prev = ' ';
+    for c in cmd.chars() {
+        match (in_quotes, c) {
+            (None, '"' | '\'')          => in_quotes = Some(c),
+            (Some(q), _) if c == q      => in_quotes = None,
+            (None, '@' | '$') if prev.is_whitespace() || prev == '=' => return true,
+            _ => {}
+        }
+        prev = c;
+    }
+    false
+}
+
+fn is_chmod_777(cmd: &str) -> bool {
+    let w = words(cmd);
+    w.first() == Some(&"chmod") && w.iter().any(|a| *a == "777" || *a == "0777" || *a == "a+rwx")
+}
+
+fn rule_matches(id: &str, cmd: &str, sudo: bool) -> bool {
+    match id {
+        "rm-root"         => is_rm_root(cmd),
+        "curl-pipe-shell" => is_curl_pipe_shell(cmd),
+        "disk-write"      => is_disk_write(cmd),
+        "etc-write"       => is_etc_write(cmd),
+        "sudo-unquoted"   => sudo && has_unquoted_var(cmd),
+        "chmod-777"       => is_chmod_777(cmd),
+        _ => false,
+    }
+}
+
+/// Analiza bezpieczeństwa źródła wg polityki
+pub fn security_lint(source: &str, policy: &SecurityPolicy) -> Vec<Diag> {
+    let mut diags = Vec::new();
+    for (idx, raw_line) in source.lines().enumerate() {
+        let Some((cmd, sudo)) = command_of(raw_line) else { continue };
+        for rule in RULES {
+            let level = policy.level(rule);
+            if level == PolicyLevel::Allow || !rule_matches(rule.id, &cmd, sudo) { continue; }
+            let col = raw_line.len() - raw_line.trim_start().len() + 1;
+            let msg = format!("[{}] {}", rule.id, rule.message);
+            let diag = if level == PolicyLevel::Deny { Diag::error(msg) } else { Diag::warning(msg) };
+            diags.push(diag
+                .with_span(Span::new(idx + 1, col, raw_line.trim().len()))
+                .with_suggestion(rule.suggestion)
+                .with_note(format!("zmień poziom w {} → [policy] -> {} => allow | warn | deny", POLICY_FILE, rule.id)));
+        }
+    }
+    diags
+}
+
+pub fn count_denied(diags: &[Diag]) -> usize {
+    diags.iter().filter(|d| d.level == DiagLevel::Error).count()
+}
+
+// ── Manifest uprawnień ────────────────────────────────────────────────────────
+//
+// Skrypt deklaruje potrzebne uprawnienia w bloku `///` na początku pliku:
+//
+//   /// Requires: network, sudo, background, write:/var/backups
+//
+// Jeśli manifest istnieje, `hl run` przed startem odrzuca skrypt używający
+// czegoś spoza deklaracji (sieć, ^>/sudo, goroutines :*, zapis poza podanymi
+// ścieżkami). Sprawdzane są komendy po lowerowaniu (ta sama lista co w .bc),
+// łącznie z importami i bibliotekami; .bc niesie manifest w metadanych.
+// Skrypty bez manifestu działają jak dotąd; `hl run --trust` pomija
+// sprawdzenie. Zapis do /tmp i /dev/null jest zawsze dozwolony.
+
+const NET_TOOLS: &[&str] = &["curl", "wget", "ssh", "scp", "sftp", "rsync", "nc", "ncat", "nmap",
+    "ping", "whois", "dig", "nslookup", "ftp", "telnet", "masscan", "sqlmap", "nikto", "hydra"];
+const GIT_NET: &[&str] = &["clone", "fetch", "pull", "push", "ls-remote"];
+const ALWAYS_WRITABLE: &[&str] = &["/tmp", "/dev/null", "/dev/stdout", "/dev/stderr"];
+
+#[derive(Debug, Clone, PartialEq)]
+pub enum Capability { Network, Sudo, Background, Clipboard, Notify, Write(String) }
+
+impl Capability {
+    fn parse(s: &str) -> Option<Self> {
+        match s.trim() {
+            "network" | "net" | "sieć" => Some(Capability::Network),
+            "sudo" | "root"            => Some(Capability::Sudo),
+            "background" | "tło"       => Some(Capability::Background),
+            "clipboard" | "schowek"    => Some(Capability::Clipboard),
+            "notify" | "powiadomienia" => Some(Capability::Notify),
+            w => w.strip_prefix("write:").map(|p| Capability::Write(p.trim().trim_end_matches('/').to_string())),
+        }
+    }
+}
+
+impl fmt::Display for Capability {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Capability::Network    => f.write_str("network"),
+            Capability::Sudo       => f.write_str("sudo"),
+            Capability::Background => f.write_str("background"),
+            Capability::Clipboard  => f.write_str("clipboard"),
+            Capability::Notify     => f.write_str("notify"),
+            Capability::Write(p)   => write!(f, "write:{}", p),
+        }
+    }
+}
+
+#[derive(Debug, Clone, Default)]
+pub struct Manifest {
+    pub caps: Vec<Capability>,
+}
+
+impl Manifest {
+    /// Manifest z listy pozycji (np. `requires` z metadanych .bc)
+    pub fn from_items(items: &[String]) -> Result<Manifest> {
+        let mut m = Manifest::default();
+        for item in items.iter().map(|s| s.trim()).filter(|s| !s.is_empty() && *s != "none") {
+            match Capability::parse(item) {
+                Some(c) => m.caps.push(c),
+                None => anyhow::bail!("Nieznane uprawnienie '{}' w manifeście — network | sudo | background | clipboard | notify | write:<ścieżka>", item),
+            }
+        }
+        Ok(m)
+    }
+
+    fn has(&self, cap: &Capability) -> bool { self.caps.contains(cap) }
+
+    fn may_write(&self, path: &str) -> bool {
+        // Porównanie po normalizacji — `/tmp/../etc/shadow` to `/etc/shadow`
+        let path = normalize_path(path);
+        let under = |dir: &str| {
+            let dir = normalize_path(dir);
+            path == dir || path.starts_with(&format!("{}/", dir.trim_end_matches('/')))
+        };
+        ALWAYS_WRITABLE.iter().any(|d| under(d))
+            || self.caps.iter().any(|c| matches!(c, Capability::Write(d) if under(d)))
+    }
+}
+
+/// Leksykalna normalizacja ścieżki: bez `.`, podwójnych `/` i z rozwiniętym `..`
+/// (bez dostępu do dysku — cel zapisu zwykle jeszcze nie istnieje)
+fn normalize_path(path: &str) -> String {
+    let absolute = path.starts_with('/');
+    let mut parts: Vec<&str> = Vec::new();
+    for part in path.split('/') {
+        match part {
+            "" | "." => {}
+            ".." if parts.last().is_some_and(|p| *p != "..") => { parts.pop(); }
+            ".." if absolute => {}
+            part => parts.push(part),
+        }
+    }
+    let joined = parts.join("/");
+    if absolute { format!("/{}", joined) } else { joined }
+}
+
+/// Manifest z bloku `///` (linie `Requires:` / `Wymaga:`); None — skrypt go nie deklaruje
+pub fn parse_manifest(source: &str) -> Result<Option<Manifest>> {
+    let mut manifest: Option<Manifest> = None;
+    for line in source.lines().take(30) {
+        let t = line.trim();
+        if t.is_empty() || t.starts_with("#!") || t.starts_with("using") || t.starts_with(";;") { continue; }
+        let Some(doc) = t.strip_prefix("///") else { break };
+        let Some((k, v)) = doc.split_once(':') else { continue };
+        if !matches!(k.trim().to_ascii_lowercase().as_str(), "requires" | "wymaga") { continue; }
+        let items: Vec<String> = v.split(',').map(str::to_string).collect();
+        manifest.get_or_insert_with(Manifest::default).caps.extend(Manifest::from_items(&items)?.caps);
+    }
+    Ok(manifest)
+}
+
+// ── Normalizacja komend ───────────────────────────────────────────────────────
+//
+// Manifest sprawdzany jest na prostych komendach, a nie na surowym tekście:
+// podstawienia $(…) i `…` są rozwijane rekurencyjnie, `sh -c '…'` / `eval`
+// analizowane jak osobne komendy, a opakowania (sudo, env VAR=…, nice, nohup,
+// timeout 5, command, exec, stdbuf, xargs, time, doas, setsid) zdejmowane —
+// `env curl` i `$(wget …)` liczą się jako sieć tak samo jak `curl`.
+
+const WRAPPERS: &[&str] = &["sudo", "doas", "env", "nice", "nohup", "timeout", "command", "exec",
+    "stdbuf", "xargs", "time", "setsid", "ionice"];
+const SHELLS: &[&str] = &["sh", "bash", "dash", "zsh", "ksh", "hsh"];
+
+/// Prosta komenda po normalizacji: słowa (pierwsze bez ścieżki) i czy przeszła przez sudo/doas
+struct SimpleCmd {
+    words: Vec<String>,
+    sudo:  bool,
+}
+
+/// Tekst komendy bez podstawień (zastąpionych przez `@?`) i treść samych podstawień
+fn split_substitutions(cmd: &str) -> (String, Vec<String>) {
+    let chars: Vec<char> = cmd.chars().collect();
+    let (mut outer, mut inner) = (String::new(), Vec::new());
+    let mut i = 0;
+    while i < chars.len() {
+        if chars[i] == '$' && chars.get(i + 1) == Some(&'(') && chars.get(i + 2) != Some(&'(') {
+            let (mut depth, mut j) = (1, i + 2);
+            while j < chars.len() && depth > 0 {
+                match chars[j] { '(' => depth += 1, ')' => depth -= 1, _ => {} }
+                j += 1;
+            }
+            let end = if depth == 0 { j - 1 } else { j };
+            inner.push(chars[i + 2..end].iter().collect());
+            outer.push_str("@?");
+            i = j;
+            continue;
+        }
+        if chars[i] == '`' {
+            if let Some(k) = chars[i + 1..].iter().position(|c| *c == '`') {
+                inner.push(chars[i + 1..i + 1 + k].iter().collect());
+                outer.push_str("@?");
+                i += k + 2;
+                continue;
+            }
+        }
+        outer.push(chars[i]);
+        i += 1;
+    }
+    (outer, inner)
+}
+
+/// Podział na części po `|`, `;`, `&` i nowej linii — poza cudzysłowami
+fn split_pipeline(cmd: &str) -> Vec<String> {
+    let (mut parts, mut cur, mut quote) = (Vec::new(), String::new(), None::<char>);
+    for c in cmd.chars() {
+        match (quote, c) {
+            (Some(q), c) if c == q         => { quote = None; cur.push(c); }
+            (Some(_), c)                   => cur.push(c),
+            (None, '\'' | '"')             => { quote = Some(c); cur.push(c); }
+            (None, '|' | ';' | '&' | '\n') => parts.push(std::mem::take(&mut cur)),
+            (None, c)                      => cur.push(c),
+        }
+    }
+    parts.push(cur);
+    parts
+}
+
+/// Słowa z uwzględnieniem cudzysłowów (same cudzysłowy są usuwane)
+fn shell_words(part: &str) -> Vec<String> {
+    let (mut out, mut cur, mut quote, mut any) = (Vec::new(), String::new(), None::<char>, false);
+    for c in part.chars() {
+        match (quote, c) {
+            (Some(q), c) if c == q     => quote = None,
+            (Some(_), c)               => cur.push(c),
+            (None, '\'' | '"')         => { quote = Some(c); any = true; }
+            (None, c) if c.is_whitespace() => {
+                if !cur.is_empty() || any { out.push(std::mem::take(&mut cur)); }
+                any = false;
+            }
+            (None, c)                  => cur.push(c),
+        }
+    }
+    if !cur.is_empty() || any { out.push(cur); }
+    out
+}
+
+fn is_assignment(w: &str) -> bool {
+    w.split_once('=').is_some_and(|(k, _)| !k.is_empty()
+        && k.chars().all(|c| c.is_ascii_alphanumeric() || c == '_')
+        && !k.starts_with(|c: char| c.is_ascii_digit()))
+}
+
+/// Argument opakowania do pominięcia: opcja, VAR=…, liczba lub czas (`-n 10`, `timeout 5s`)
+fn is_wrapper_arg(w: &str) -> bool {
+    w.starts_with('-') || is_assignment(w)
+        || (!w.is_empty() && w.trim_end_matches(['s', 'm', 'h', 'd']).parse::<f64>().is_ok())
+}
+
+fn simple_commands(cmd: &str) -> Vec<SimpleCmd> {
+    let mut out = Vec::new();
+    collect_simple(cmd, false, &mut out, 0);
+    out
+}
+
+fn collect_simple(cmd: &str, sudo: bool, out: &mut Vec<SimpleCmd>, depth: usize) {
+    if depth > 8 { return; }
+    let (outer, inner) = split_substitutions(cmd);
+    for sub in inner { collect_simple(&sub, sudo, out, depth + 1); }
+    for part in split_pipeline(&outer) {
+        let mut w = shell_words(&part);
+        let mut sudo = sudo;
+        let skip = w.iter().take_while(|a| is_assignment(a)).count();
+        w.drain(..skip);
+        while let Some(first) = w.first() {
+            let name = first.rsplit('/').next().unwrap_or(first);
+            if !WRAPPERS.contains(&name) { break; }
+            sudo |= matches!(name, "sudo" | "doas");
+            let skip = 1 + w.iter().skip(1).take_while(|a| is_wrapper_arg(a)).count();
+            w.drain(..skip);
+        }
+        let Some(first) = w.first_mut() else { continue };
+        if let Some(base) = first.rsplit('/').next().map(str::to_string) { *first = base; }
+        if SHELLS.contains(&w[0].as_str()) {
+            if let Some(i) = w.iter().position(|a| a == "-c") {
+                collect_simple(&w[i + 1..].join(" "), sudo, out, depth + 1);
+                continue;
+            }
+        }
+        if w[0] == "eval" {
+            collect_simple(&w[1..].join(" "), sudo, out, depth + 1);
+            continue;
+        }
+        out.push(SimpleCmd { words: w, sudo });
+    }
+}
+
+fn uses_network(cmd: &str) -> bool {
+    simple_commands(cmd).iter().any(|c| match c.words[0].as_str() {
+        "git" => c.words.iter().skip(1).any(|a| GIT_NET.contains(&a.as_str())),
+        name  => NET_TOOLS.contains(&name),
+    })
+}
+
+/// Bezwzględne ścieżki, do których komenda pisze (przekierowania, tee, cp/mv/install, mkdir, touch)
+fn write_targets(cmd: &str) -> Vec<String> {
+    let mut out = Vec::new();
+    let abs = |a: &str| a.starts_with('/').then(|| a.trim_end_matches('/').to_string());
+    for c in simple_commands(cmd) {
+        let w = &c.words;
+        for (i, a) in w.iter().enumerate() {
+            if a == ">" || a == ">>" {
+                if let Some(p) = w.get(i + 1).and_then(|t| abs(t.as_str())) { out.push(p); }
+            } else if let Some(p) = a.strip_prefix(">>").or_else(|| a.strip_prefix('>')).and_then(abs) {
+                out.push(p);
+            }
+        }
+        match w[0].as_str() {
+            "tee" | "mkdir" | "touch" =>
+                out.extend(w.iter().skip(1).filter(|a| !a.starts_with('-')).filter_map(|a| abs(a.as_str()))),
+            "cp" | "mv" | "install" if w.len() > 2 =>
+                out.extend(w.last().and_then(|a| abs(a.as_str()))),
+            _ => {}
+        }
+    }
+    out
+}
+
+/// `|| hl-desktop <akcja>` albo `hl desktop <akcja>` w komendzie
+fn desktop_capability(line: &str) -> Option<Capability> {
+    let t = line.trim();
+    match t.strip_prefix("||") {
+        Some(rest) => desktop_in_cmd(rest),
+        None       => desktop_in_cmd(&command_of(line)?.0),
+    }
+}
+
+fn desktop_in_cmd(cmd: &str) -> Option<Capability> {
+    simple_commands(cmd).iter().find_map(|c| {
+        let w = &c.words;
+        if w[0] == crate::desktop::TOOL { return desktop_action(w.get(1).map(String::as_str)); }
+        (matches!(w[0].as_str(), "hl" | "@HL_BIN") && w.get(1).map(String::as_str) == Some("desktop"))
+            .then(|| desktop_action(w.get(2).map(String::as_str))).flatten()
+    })
+}
+
+fn desktop_action(action: Option<&str>) -> Option<Capability> {
+    match action? {
+        "copy" | "paste" => Some(Capability::Clipboard),
+        "notify"         => Some(Capability::Notify),
+        _                => None,
+    }
+}
+
+/// Uprawnienia, których skrypt faktycznie używa (bez względu na manifest)
+pub fn required_capabilities(source: &str) -> Vec<Capability> {
+    let empty = Manifest::default();
+    let mut caps = Vec::new();
+    let mut add = |c: Capability| if !caps.contains(&c) { caps.push(c) };
+    for raw_line in source.lines() {
+        if raw_line.trim().starts_with(":*") { add(Capability::Background); }
+        if let Some(c) = desktop_capability(raw_line) { add(c); }
+        let Some((cmd, sudo)) = command_of(raw_line) else { continue };
+        if sudo || simple_commands(&cmd).iter().any(|c| c.sudo) { add(Capability::Sudo); }
+        if uses_network(&cmd) { add(Capability::Network); }
+        for target in write_targets(&cmd) {
+            if !empty.may_write(&target) { add(Capability::Write(target)); }
+        }
+    }
+    caps
+}
+
+/// Komenda do sprawdzenia manifestu — z tej samej listy, którą wykonuje bytecode
+/// (hl_compiler::lowered_commands)
+#[derive(Debug, Clone)]
+pub struct CmdStep {
+    pub cmd:  String,
+    pub sudo: bool,
+}
+
+/// Liczba goroutines `:*` w AST — lowering wykonuje je inline, więc trzeba je liczyć osobno
+pub fn goroutine_count(nodes: &[Node]) -> usize {
+    let mut n = 0;
+    visit_nodes(nodes, false, &mut |node, _| if matches!(node, Node::Goroutine { .. }) { n += 1; });
+    n
+}
+
+/// Linia źródła zawierająca stały początek komendy (do `@`) — dla spanu diagnostyki
+fn locate(source: &str, cmd: &str) -> Option<Span> {
+    let cmd = cmd.trim_start_matches("& ").trim_start_matches("hsh -c ");
+    let head: String = cmd.split('@').next().unwrap_or("").trim().chars().take(40).collect();
+    if head.len() < 2 { return None; }
+    source.lines().enumerate().find(|(_, l)| l.contains(&head)).map(|(idx, l)| {
+        let col = l.len() - l.trim_start().len() + 1;
+        Span::new(idx + 1, col, l.trim().len())
+    })
+}
+
+/// Użycia wykraczające poza manifest — error dla każdej komendy. `goroutines` — liczba
+/// `:*` w pliku (bytecode wykonuje je inline, więc nie ma ich na liście komend);
+/// `source` — treść pliku do lokalizacji błędów (brak dla .bc).
+pub fn manifest_violations(steps: &[CmdStep], goroutines: usize, manifest: &Manifest, source: Option<&str>) -> Vec<Diag> {
+    let mut diags = Vec::new();
+    let mut deny = |msg: String, cap: &str, step: Option<&CmdStep>| {
+        let mut d = Diag::error(msg)
+            .with_suggestion(format!("dodaj do manifestu: /// Requires: {}", cap))
+            .with_note("uruchom mimo to: hl run --trust");
+        if let Some(step) = step {
+            match source.and_then(|src| locate(src, &step.cmd)) {
+                Some(span) => d = d.with_span(span),
+                None       => d = d.with_note(format!("komenda: {}", step.cmd)),
+            }
+        }
+        diags.push(d);
+    };
+
+    if goroutines > 0 && !manifest.has(&Capability::Background) {
+        deny(format!("goroutine bez uprawnienia `background` ({}×)", goroutines), "background", None);
+    }
+    for step in steps {
+        let cmd = step.cmd.strip_prefix("& ").unwrap_or(&step.cmd);
+        if let Some(c) = desktop_in_cmd(cmd).filter(|c| !manifest.has(c)) {
+            deny(format!("hl-desktop bez uprawnienia `{}`", c), &c.to_string(), Some(step));
+        }
+        let sudo = step.sudo || simple_commands(cmd).iter().any(|c| c.sudo);
+        if sudo && !manifest.has(&Capability::Sudo) {
+            deny("komenda z uprawnieniami roota bez uprawnienia `sudo`".into(), "sudo", Some(step));
+        }
+        if uses_network(cmd) && !manifest.has(&Capability::Network) {
+            deny("dostęp do sieci bez uprawnienia `network`".into(), "network", Some(step));
+        }
+        for target in write_targets(cmd) {
+            if !manifest.may_write(&target) {
+                deny(format!("zapis do {} poza zadeklarowanymi ścieżkami", target), &format!("write:{}", target), Some(step));
+            }
+        }
+    }
+    diags
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn manifest(src: &str) -> Manifest {
+        parse_manifest(src).unwrap().unwrap()
+    }
+
+    #[test]
+    fn test_manifest_write_paths() {
+        let m = manifest("/// Requires: write:/var/app\n");
+        assert!(m.may_write("/var/app/data.db"));
+        assert!(m.may_write("/var/app/./x/../y"));
+        assert!(m.may_write("/tmp/out.txt"));
+        assert!(!m.may_write("/var/application"));
+    }
+
+    #[test]
+    fn test_manifest_write_rejects_dotdot_escape() {
+        let m = manifest("/// Requires: write:/var/app\n");
+        assert!(!m.may_write("/tmp/../etc/shadow"));
+        assert!(!m.may_write("/var/app/../../etc"));
+        assert!(!m.may_write("/var/app/.."));
+        assert_eq!(normalize_path("/../etc//passwd"), "/etc/passwd");
+        assert_eq!(normalize_path("../a/./b"), "../a/b");
+    }
+}
@@ -0,0 +1,463 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use rustc_hash::FxHashMap;
+use serde_json::json;
+use std::collections::VecDeque;
+use std::io::{BufRead, BufReader, Read, Write};
+use std::net::{TcpListener, TcpStream};
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::sync::atomic::{AtomicU64, Ordering};
+use std::sync::{Arc, Mutex};
+use std::fmt::Write as _;
+use std::time::{Duration, Instant, SystemTime, UNIX_EPOCH};
+use crate::config::load_hk_file;
+use crate::deploy::hl_binary;
+use crate::schedule::schedule_logs_dir;
+
+// ── hl serve ──────────────────────────────────────────────────────────────────
+//
+// Mały serwer HTTP uruchamiający skrypty HL na żądanie (chatops, webhooki).
+// Trasy deklarowane w serve.hk w katalogu projektu:
+//
+//   [serve]
+//   -> bind  => 127.0.0.1:8787
+//   -> token => ${env:HL_SERVE_TOKEN}
+//   -> metrics_token => ${env:HL_METRICS_TOKEN}
+//
+//   [routes]
+//   -> backup => backup.hl
+//   -> update => scripts/update.hl
+//
+//   [concurrency]
+//   -> backup => "1"
+//
+// API:
+//   POST /run/<trasa>   → 202 {"run_id": ..., "route": ..., "log": ...}
+//   GET  /runs/<run_id> → {"run_id", "route", "status", "exit_code"}
+//   GET  /health        → {"status": "ok"}
+//   GET  /metrics       → metryki Prometheus (text exposition format)
+//
+// Autoryzacja: nagłówek `Authorization: Bearer <token>` (jeśli token ustawiony).
+// /metrics wymaga metrics_token, a bez niego — tego samego tokenu co /run.
+// Proces `hl run` dostaje HL_SERVE_STATS=<plik>: dopisuje do niego instalacje
+// zależności i trafienia cache bibliotek (record_event), serwer sumuje je po
+// zakończeniu uruchomienia.
+// Wyjście każdego uruchomienia: ~/.hackeros/hacker-lang/logs/serve/<run_id>.log
+// Serwer pamięta statusy ostatnich MAX_FINISHED_RUNS zakończonych uruchomień;
+// połączenie, które nie wyśle nagłówków w IO_TIMEOUT, jest zamykane.
+
+pub const SERVE_FILE: &str = "serve.hk";
+const DEFAULT_BIND: &str = "127.0.0.1:8787";
+const MAX_HEADER_BYTES: usize = 16 * 1024;
+const MAX_FINISHED_RUNS: usize = 1000;
+const IO_TIMEOUT: Duration = Duration::from_secs(10);
+
+#[derive(Debug, Clone)]
+pub struct Route {
+    pub name:        String,
+    pub script:      PathBuf,
+    pub concurrency: usize,
+}
+
+#[derive(Debug, Clone)]
+pub struct ServeConfig {
+    pub bind:   String,
+    pub token:  Option<String>,
+    pub metrics_token: Option<String>,
+    pub routes: Vec<Route>,
+}
+
+#[derive(Debug, Clone)]
+pub struct RunRecord {
+    pub route:     String,
+    pub exit_code: Option<i32>,
+    pub log:       PathBuf,
+}
+
+// Granice kubełków histogramu czasu trwania (sekundy)
+const DURATION_BUCKETS: [f64; 10] = [0.1, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0, 60.0, 300.0, 900.0];
+
+#[derive(Debug, Clone, Default)]
+pub struct RouteMetrics {
+    pub runs:     u64,
+    pub failures: u64,
+    pub rejected: u64,
+    pub dep_installs: u64,
+    pub dep_failures: u64,
+    pub cache_hits:   u64,
+    pub cache_misses: u64,
+    pub buckets:  [u64; DURATION_BUCKETS.len()],
+    pub sum_secs: f64,
+    pub count:    u64,
+}
+
+impl RouteMetrics {
+    fn observe(&mut self, secs: f64, exit_code: i32) {
+        if exit_code != 0 { self.failures += 1; }
+        for (i, le) in DURATION_BUCKETS.iter().enumerate() {
+            if secs <= *le { self.buckets[i] += 1; }
+        }
+        self.sum_secs += secs;
+        self.count    += 1;
+    }
+
+    /// Zdarzenia z pliku HL_SERVE_STATS uruchomienia
+    fn add_events(&mut self, events: &str) {
+        for ev in events.lines() {
+            match ev {
+                EVENT_DEP_INSTALLED => self.dep_installs += 1,
+                EVENT_DEP_FAILED    => self.dep_failures += 1,
+                EVENT_CACHE_HIT     => self.cache_hits   += 1,
+                EVENT_CACHE_MISS    => self.cache_misses += 1,
+                _ => {}
+            }
+        }
+    }
+}
+
+pub const EVENT_DEP_INSTALLED: &str = "dep_installed";
+pub const EVENT_DEP_FAILED:    &str = "dep_failed";
+pub const EVENT_CACHE_HIT:     &str = "cache_hit";
+pub const EVENT_CACHE_MISS:    &str = "cache_miss";
+
+/// Zdarzenie dla metryk hl serve — no-op, gdy hl run nie działa pod serwerem
+pub fn record_event(event: &str) {
+    let Some(path) = std::env::var_os("HL_SERVE_STATS") else { return };
+    if let Ok(mut f) = std::fs::OpenOptions::new().create(true).append(true).open(path) {
+        let _ = writeln!(f, "{}", event);
+    }
+}
+
+#[derive(Default)]
+pub struct ServeState {
+    pub active:  FxHashMap<String, usize>,
+    pub runs:    FxHashMap<String, RunRecord>,
+    pub metrics: FxHashMap<String, RouteMetrics>,
+    /// Zakończone uruchomienia od najstarszego — najstarsze wypadają z `runs`
+    pub finished: VecDeque<String>,
+}
+
+impl ServeState {
+    /// Zajmij miejsce w limicie trasy; false — limit osiągnięty (liczone jako odrzucenie)
+    fn reserve(&mut self, route: &Route) -> bool {
+        let active = self.active.entry(route.name.clone()).or_insert(0);
+        if *active >= route.concurrency {
+            self.metrics.entry(route.name.clone()).or_default().rejected += 1;
+            return false;
+        }
+        *active += 1;
+        true
+    }
+
+    fn release(&mut self, route: &str) {
+        if let Some(n) = self.active.get_mut(route) { *n = n.saturating_sub(1); }
+    }
+
+    fn finish(&mut self, id: &str, code: i32) {
+        if let Some(r) = self.runs.get_mut(id) { r.exit_code = Some(code); }
+        self.finished.push_back(id.to_string());
+        while self.finished.len() > MAX_FINISHED_RUNS {
+            if let Some(old) = self.finished.pop_front() { self.runs.remove(&old); }
+        }
+    }
+}
+
+pub fn load_serve_config(path: &Path) -> Result<ServeConfig> {
+    let cfg  = load_hk_file(path)?;
+    let base = path.parent().unwrap_or_else(|| Path::new("."));
+    let limits: FxHashMap<String, usize> = cfg.entries("concurrency").into_iter()
+        .filter_map(|(k, v)| v.trim().parse().ok().map(|n| (k, n)))
+        .collect();
+
+    let routes: Vec<Route> = cfg.entries("routes").into_iter()
+        .map(|(name, script)| Route {
+            concurrency: limits.get(&name).copied().unwrap_or(1).max(1),
+            script:      base.join(script),
+            name,
+        })
+        .collect();
+    if routes.is_empty() { bail!("{}: brak tras w sekcji [routes]", path.display()); }
+
+    let token = config_token(cfg.get("serve", "token"))
+        .or_else(|| std::env::var("HL_SERVE_TOKEN").ok().filter(|t| !t.is_empty()));
+
+    Ok(ServeConfig {
+        bind: cfg.get("serve", "bind").unwrap_or(DEFAULT_BIND).to_string(),
+        token,
+        metrics_token: config_token(cfg.get("serve", "metrics_token")),
+        routes,
+    })
+}
+
+/// Token z serve.hk; pusty — brak tokenu
+fn config_token(raw: Option<&str>) -> Option<String> {
+    raw.map(|t| {
+        let t = t.trim();
+        // ${env:NAZWA} — rozwiąż, jeśli parser .hk zostawił interpolację
+        match t.strip_prefix("${env:").and_then(|r| r.strip_suffix('}')) {
+            Some(var) => std::env::var(var).unwrap_or_default(),
+            None      => t.to_string(),
+        }
+    })
+    .filter(|t| !t.is_empty())
+}
+
+fn new_run_id() -> String {
+    static COUNTER: AtomicU64 = AtomicU64::new(0);
+    let secs = SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0);
+    format!("{}-{}", secs, COUNTER.fetch_add(1, Ordering::Relaxed))
+}
+
+struct Request {
+    method: String,
+    path:   String,
+    auth:   Option<String>,
+}
+
+fn read_request(stream: &mut TcpStream) -> Result<Request> {
+    let mut reader = BufReader::new(stream.try_clone()?).take(MAX_HEADER_BYTES as u64);
+    let mut line = String::new();
+    reader.read_line(&mut line)?;
+    let mut parts = line.split_whitespace();
+    let method = parts.next().unwrap_or("").to_string();
+    // Trasa bez query stringu — `/run/deploy?ref=main` to trasa `deploy`
+    let path   = parts.next().unwrap_or("/").split('?').next().unwrap_or("/").to_string();
+
+    let mut auth = None;
+    let mut content_len = 0usize;
+    loop {
+        let mut h = String::new();
+        if reader.read_line(&mut h)? == 0 { break; }
+        let h = h.trim_end();
+        if h.is_empty() { break; }
+        if let Some((k, v)) = h.split_once(':') {
+            match k.trim().to_ascii_lowercase().as_str() {
+                "authorization"  => auth = v.trim().strip_prefix("Bearer ").map(|t| t.trim().to_string()),
+                "content-length" => content_len = v.trim().parse().unwrap_or(0),
+                _ => {}
+            }
+        }
+    }
+    // Ciało żądania (payload webhooka) jest ignorowane — odczytaj je, żeby nie zerwać połączenia
+    if content_len > 0 {
+        let mut sink = vec![0u8; content_len.min(MAX_HEADER_BYTES)];
+        let _ = reader.read_exact(&mut sink);
+    }
+    Ok(Request { method, path, auth })
+}
+
+/// Porównanie tokenu w stałym czasie — czas odpowiedzi nie zdradza, ile znaków się zgadza
+fn token_matches(auth: Option<&str>, token: &str) -> bool {
+    let Some(auth) = auth else { return false };
+    let (a, b) = (auth.as_bytes(), token.as_bytes());
+    if a.len() != b.len() { return false; }
+    a.iter().zip(b).fold(0u8, |acc, (x, y)| acc | (x ^ y)) == 0
+}
+
+/// Metryki w formacie Prometheus. Zależności i cache bibliotek — z plików
+/// HL_SERVE_STATS zakończonych uruchomień.
+pub fn render_metrics(cfg: &ServeConfig, st: &ServeState) -> String {
+    let mut out = String::new();
+    let routes = cfg.routes.iter().map(|r| (r, st.metrics.get(&r.name).cloned().unwrap_or_default()));
+
+    let counters: [(&str, &str, fn(&RouteMetrics) -> u64); 7] = [
+        ("hl_serve_runs_total",         "Uruchomienia skryptów zlecone przez hl serve.",        |m| m.runs),
+        ("hl_serve_run_failures_total", "Uruchomienia zakończone niezerowym exit code.",        |m| m.failures),
+        ("hl_serve_rejected_total",     "Żądania odrzucone przez limit współbieżności.",        |m| m.rejected),
+        ("hl_serve_dep_installs_total", "Zależności `//` zainstalowane podczas uruchomień.",    |m| m.dep_installs),
+        ("hl_serve_dep_failures_total", "Zależności `//`, których nie udało się zainstalować.", |m| m.dep_failures),
+        ("hl_serve_cache_hits_total",   "Biblioteki z URL wczytane z cache.",                   |m| m.cache_hits),
+        ("hl_serve_cache_misses_total", "Biblioteki z URL pobrane do cache.",                   |m| m.cache_misses),
+    ];
+    for (name, help, value) in counters {
+        let _ = writeln!(out, "# HELP {} {}", name, help);
+        let _ = writeln!(out, "# TYPE {} counter", name);
+        for (r, m) in routes.clone() {
+            let _ = writeln!(out, "{}{{route=\"{}\"}} {}", name, r.name, value(&m));
+        }
+    }
+    out.push_str("# HELP hl_serve_runs_active Uruchomienia w toku.\n");
+    out.push_str("# TYPE hl_serve_runs_active gauge\n");
+    for (r, _) in routes.clone() {
+        let _ = writeln!(out, "hl_serve_runs_active{{route=\"{}\"}} {}",
+                         r.name, st.active.get(&r.name).copied().unwrap_or(0));
+    }
+    out.push_str("# HELP hl_serve_run_duration_seconds Czas trwania uruchomień.\n");
+    out.push_str("# TYPE hl_serve_run_duration_seconds histogram\n");
+    for (r, m) in routes {
+        for (le, n) in DURATION_BUCKETS.iter().zip(m.buckets.iter()) {
+            let _ = writeln!(out, "hl_serve_run_duration_seconds_bucket{{route=\"{}\",le=\"{}\"}} {}", r.name, le, n);
+        }
+        let _ = writeln!(out, "hl_serve_run_duration_seconds_bucket{{route=\"{}\",le=\"+Inf\"}} {}", r.name, m.count);
+        let _ = writeln!(out, "hl_serve_run_duration_seconds_sum{{route=\"{}\"}} {}", r.name, m.sum_secs);
+        let _ = writeln!(out, "hl_serve_run_duration_seconds_count{{route=\"{}\"}} {}", r.name, m.count);
+    }
+    out
+}
+
+fn respond_text(stream: &mut TcpStream, body: &str) {
+    let _ = write!(stream,
+        "HTTP/1.1 200 OK\r\nContent-Type: text/plain; version=0.0.4\r\nContent-Length: {}\r\nConnection: close\r\n\r\n{}",
+        body.len(), body);
+}
+
+fn respond(stream: &mut TcpStream, code: u16, body: serde_json::Value) {
+    let reason = match code {
+        200 => "OK", 202 => "Accepted", 401 => "Unauthorized", 404 => "Not Found",
+        405 => "Method Not Allowed", 429 => "Too Many Requests", _ => "Internal Server Error",
+    };
+    let payload = body.to_string();
+    let _ = write!(stream,
+        "HTTP/1.1 {} {}\r\nContent-Type: application/json\r\nContent-Length: {}\r\nConnection: close\r\n\r\n{}",
+        code, reason, payload.len(), payload);
+}
+
+/// Uruchom trasę; miejsce w limicie musi być już zajęte (ServeState::reserve)
+fn start_run(route: &Route, state: &Arc<Mutex<ServeState>>) -> Result<(String, PathBuf)> {
+    let run_id  = new_run_id();
+    let log_dir = schedule_logs_dir().join("serve");
+    std::fs::create_dir_all(&log_dir)?;
+    let log_path = log_dir.join(format!("{}.log", run_id));
+    let log = std::fs::File::create(&log_path)?;
+    let stats_path = log_dir.join(format!("{}.stats", run_id));
+
+    let mut child = Command::new(hl_binary())
+        .arg("run")
+        .arg(&route.script)
+        .env("HL_RUN_ID", &run_id)
+        .env("HL_SERVE_ROUTE", &route.name)
+        .env("HL_RUN_LOG", &log_path)
+        .env("HL_SERVE_STATS", &stats_path)
+        .stdin(Stdio::null())
+        .stdout(log.try_clone()?)
+        .stderr(log)
+        .spawn()
+        .context("Nie można uruchomić hl run")?;
+
+    {
+        let mut st = state.lock().unwrap_or_else(|e| e.into_inner());
+        st.metrics.entry(route.name.clone()).or_default().runs += 1;
+        st.runs.insert(run_id.clone(), RunRecord {
+            route: route.name.clone(), exit_code: None, log: log_path.clone(),
+        });
+    }
+
+    let state = state.clone();
+    let id    = run_id.clone();
+    let name  = route.name.clone();
+    let t0    = Instant::now();
+    std::thread::spawn(move || {
+        let code = child.wait().ok().and_then(|s| s.code()).unwrap_or(1);
+        let events = std::fs::read_to_string(&stats_path).unwrap_or_default();
+        let _ = std::fs::remove_file(&stats_path);
+        let mut st = state.lock().unwrap_or_else(|e| e.into_inner());
+        st.release(&name);
+        let m = st.metrics.entry(name.clone()).or_default();
+        m.observe(t0.elapsed().as_secs_f64(), code);
+        m.add_events(&events);
+        st.finish(&id, code);
+    });
+    Ok((run_id, log_path))
+}
+
+fn handle(mut stream: TcpStream, cfg: &ServeConfig, state: &Arc<Mutex<ServeState>>) {
+    let _ = stream.set_read_timeout(Some(IO_TIMEOUT));
+    let _ = stream.set_write_timeout(Some(IO_TIMEOUT));
+    let req = match read_request(&mut stream) {
+        Ok(r)  => r,
+        Err(_) => return,
+    };
+
+    if req.path == "/health" {
+        return respond(&mut stream, 200, json!({"status": "ok"}));
+    }
+    if req.path == "/metrics" {
+        if let Some(token) = cfg.metrics_token.as_ref().or(cfg.token.as_ref()) {
+            if !token_matches(req.auth.as_deref(), token) {
+                return respond(&mut stream, 401, json!({"error": "unauthorized"}));
+            }
+        }
+        let st = state.lock().unwrap_or_else(|e| e.into_inner());
+        return respond_text(&mut stream, &render_metrics(cfg, &st));
+    }
+    if let Some(ref token) = cfg.token {
+        if !token_matches(req.auth.as_deref(), token) {
+            return respond(&mut stream, 401, json!({"error": "unauthorized"}));
+        }
+    }
+
+    if let Some(name) = req.path.strip_prefix("/run/") {
+        if req.method != "POST" {
+            return respond(&mut stream, 405, json!({"error": "use POST"}));
+        }
+        let Some(route) = cfg.routes.iter().find(|r| r.name == name) else {
+            return respond(&mut stream, 404, json!({"error": "unknown route", "route": name}));
+        };
+        let reserved = state.lock().unwrap_or_else(|e| e.into_inner()).reserve(route);
+        if !reserved {
+            return respond(&mut stream, 429, json!({
+                "error": "concurrency limit reached", "route": route.name, "limit": route.concurrency,
+            }));
+        }
+        return match start_run(route, state) {
+            Ok((run_id, log)) => {
+                eprintln!("{} {} → {}", "[hl serve]".bright_magenta(), route.name.bright_cyan(), run_id);
+                respond(&mut stream, 202, json!({
+                    "run_id": run_id, "route": route.name, "log": log.display().to_string(),
+                }))
+            }
+            Err(e) => {
+                state.lock().unwrap_or_else(|e| e.into_inner()).release(&route.name);
+                respond(&mut stream, 500, json!({"error": e.to_string()}))
+            }
+        };
+    }
+
+    if let Some(id) = req.path.strip_prefix("/runs/") {
+        let st = state.lock().unwrap_or_else(|e| e.into_inner());
+        return match st.runs.get(id) {
+            Some(r) => {
+                let status = match r.exit_code { None => "running", Some(0) => "ok", Some(_) => "failed" };
+                respond(&mut stream, 200, json!({
+                    "run_id": id, "route": r.route, "status": status,
+                    "exit_code": r.exit_code, "log": r.log.display().to_string(),
+                }))
+            }
+            None => respond(&mut stream, 404, json!({"error": "unknown run", "run_id": id})),
+        };
+    }
+
+    respond(&mut stream, 404, json!({"error": "not found"}))
+}
+
+pub fn cmd_serve(config: Option<&Path>, bind: Option<&str>) -> Result<()> {
+    let path = config.map(|p| p.to_path_buf()).unwrap_or_else(|| PathBuf::from(SERVE_FILE));
+    if !path.exists() {
+        bail!("Brak pliku {} — utwórz go z sekcjami [serve] i [routes]", path.display());
+    }
+    let mut cfg = load_serve_config(&path)?;
+    if let Some(b) = bind { cfg.bind = b.to_string(); }
+
+    let listener = TcpListener::bind(&cfg.bind)
+        .with_context(|| format!("Nie można nasłuchiwać na {}", cfg.bind))?;
+
+    println!("{} http://{}", "hl serve:".bright_magenta().bold(), cfg.bind.bright_white());
+    for r in &cfg.routes {
+        println!("  POST /run/{:<20} → {} (max {})",
+                 r.name.bright_cyan(), r.script.display().to_string().bright_black(), r.concurrency);
+    }
+    if cfg.token.is_none() {
+        println!("  {} brak tokenu — każdy z dostępem do {} może uruchamiać skrypty",
+                 "!".yellow().bold(), cfg.bind);
+    }
+
+    let cfg   = Arc::new(cfg);
+    let state = Arc::new(Mutex::new(ServeState::default()));
+    for stream in listener.incoming().flatten() {
+        let cfg   = cfg.clone();
+        let state = state.clone();
+        std::thread::spawn(move || handle(stream, &cfg, &state));
+    }
+    Ok(())
+}
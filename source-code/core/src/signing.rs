@@ -0,0 +1,269 @@
+use anyhow::{bail, Context, Result};
+use colored::Colorize;
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use crate::config::load_config;
+use crate::exit::{classified, ErrorClass};
+
+// ── hl sign / hl trust ────────────────────────────────────────────────────────
+//
+// Podpisy skryptów przez `ssh-keygen -Y` (ed25519, przestrzeń nazw "hacker-lang"):
+//   hl sign plik.hl             → plik.hl.sig (klucz użytkownika tworzony przy pierwszym użyciu)
+//   hl sign --verify plik.hl    sprawdź podpis i pokaż, kto podpisał
+//   hl trust add klucz.pub -n admin    dodaj zaufany klucz
+//   hl trust list
+//
+// Klucze: ~/.hackeros/keys/{hl_ed25519, hl_ed25519.pub, allowed_signers}.
+// Na maszynach zarządzanych centralnie zaufane klucze mogą leżeć też
+// w /etc/hackeros/keys/allowed_signers.
+//
+// Tryb zaufanego uruchamiania: `[security] -> signed_only => true` w config.hk
+// (lub HL_SIGNED_ONLY=1) — hl uruchamia wyłącznie skrypty z poprawnym podpisem
+// od zaufanego klucza. `hl run --trust` tego NIE pomija.
+//
+//   /etc/hackeros/config.hk   polityka systemowa — ufa tylko /etc/hackeros/keys,
+//                             klucze użytkownika (także jego własny) się nie liczą
+//   ~/.hackeros/config.hk     polityka użytkownika — klucze systemowe i użytkownika
+//   HL_SIGNED_ONLY=1          włącza tryb; zmienna tylko zaostrza, `=0` niczego nie wyłącza
+//
+// Sprawdzenie siedzi we wspólnych ścieżkach wczytywania: plik uruchamiany
+// (hl run, hl plik.hl, hl exec, .bc) i każdy importowany plik — <<, <*, biblioteki
+// bit, GitHub i z URL (read_trusted_source). Kod spoza pliku — hl -c, hl shell -c,
+// REPL i powłoka — nie ma podpisu, więc w tym trybie jest odrzucany.
+
+pub const SIG_NAMESPACE: &str = "hacker-lang";
+pub const SYSTEM_KEYS_DIR: &str = "/etc/hackeros/keys";
+pub const SYSTEM_CONFIG: &str = "/etc/hackeros/config.hk";
+const KEY_NAME: &str = "hl_ed25519";
+const ALLOWED_SIGNERS: &str = "allowed_signers";
+
+pub fn keys_dir() -> PathBuf {
+    dirs::home_dir().unwrap_or_else(|| PathBuf::from("/tmp")).join(".hackeros").join("keys")
+}
+
+pub fn signature_path(file: &Path) -> PathBuf {
+    let mut s = file.as_os_str().to_owned();
+    s.push(".sig");
+    PathBuf::from(s)
+}
+
+fn identity() -> String {
+    let user = std::env::var("USER").unwrap_or_else(|_| "hl".into());
+    let host = std::fs::read_to_string("/etc/hostname").unwrap_or_else(|_| "hackeros".into());
+    format!("{}@{}", user, host.trim())
+}
+
+fn ssh_keygen() -> Result<()> {
+    if which::which("ssh-keygen").is_err() {
+        return Err(classified(ErrorClass::Toolchain, "ssh-keygen nie jest zainstalowany (pakiet openssh-client)"));
+    }
+    Ok(())
+}
+
+/// Skąd pochodzi tryb tylko-podpisane
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum SignedOnly {
+    Off,
+    /// config.hk użytkownika albo HL_SIGNED_ONLY
+    User,
+    /// /etc/hackeros/config.hk — użytkownik nie może go wyłączyć ani dodać kluczy
+    System,
+}
+
+fn enabled(value: Option<&str>) -> bool {
+    matches!(value, Some("true" | "yes" | "1"))
+}
+
+pub fn signed_only_policy() -> SignedOnly {
+    let system = crate::config::load_hk_file(Path::new(SYSTEM_CONFIG)).ok();
+    if enabled(system.as_ref().and_then(|c| c.get("security", "signed_only"))) { return SignedOnly::System; }
+    let env = std::env::var("HL_SIGNED_ONLY").ok();
+    if enabled(env.as_deref()) || enabled(load_config().get("security", "signed_only")) {
+        SignedOnly::User
+    } else {
+        SignedOnly::Off
+    }
+}
+
+/// Tryb zaufanego uruchamiania włączony?
+pub fn signed_only() -> bool {
+    signed_only_policy() != SignedOnly::Off
+}
+
+fn denied(e: anyhow::Error, policy: SignedOnly) -> anyhow::Error {
+    let source = if policy == SignedOnly::System { SYSTEM_CONFIG } else { "config.hk / HL_SIGNED_ONLY" };
+    classified(ErrorClass::Denied, format!("{}\n  Tryb tylko-podpisane jest włączony ([security] signed_only w {}).", e, source))
+}
+
+/// W trybie tylko-podpisane: plik musi mieć poprawny podpis zaufanego klucza
+pub fn enforce_signed(file: &Path) -> Result<()> {
+    if signed_only_policy() == SignedOnly::Off { return Ok(()); }
+    read_trusted_bytes(file).map(|_| ())
+}
+
+/// Odczyt pliku do wykonania (skrypt, .bc albo import) — z kontrolą podpisu.
+/// Plik czytany jest raz: ssh-keygen sprawdza dokładnie te bajty, które zwracamy,
+/// więc podmiana pliku po weryfikacji niczego nie zmienia.
+pub fn read_trusted_bytes(file: &Path) -> Result<Vec<u8>> {
+    let bytes = std::fs::read(file).with_context(|| format!("Nie można odczytać {}", file.display()))?;
+    let policy = signed_only_policy();
+    if policy != SignedOnly::Off {
+        verify_with(file, &bytes, policy == SignedOnly::System).map_err(|e| denied(e, policy))?;
+    }
+    Ok(bytes)
+}
+
+/// Jak `read_trusted_bytes`, dla źródeł .hl
+pub fn read_trusted_source(file: &Path) -> Result<String> {
+    String::from_utf8(read_trusted_bytes(file)?)
+        .with_context(|| format!("{}: plik nie jest tekstem UTF-8", file.display()))
+}
+
+/// Kod bez pliku (hl -c, hl shell -c, REPL) — w trybie tylko-podpisane niedozwolony
+pub fn enforce_signed_inline(what: &str) -> Result<()> {
+    let policy = signed_only_policy();
+    if policy == SignedOnly::Off { return Ok(()); }
+    Err(denied(anyhow::anyhow!("{}: kodu spoza podpisanego pliku nie można uruchomić", what), policy))
+}
+
+/// Klucz użytkownika — utwórz przy pierwszym użyciu i dodaj go do zaufanych
+fn ensure_user_key() -> Result<PathBuf> {
+    use std::os::unix::fs::PermissionsExt;
+    let dir = keys_dir();
+    let key = dir.join(KEY_NAME);
+    if key.exists() { return Ok(key); }
+
+    std::fs::create_dir_all(&dir)?;
+    std::fs::set_permissions(&dir, std::fs::Permissions::from_mode(0o700))?;
+    let ident = identity();
+    let status = Command::new("ssh-keygen")
+        .args(["-q", "-t", "ed25519", "-N", "", "-C", &ident, "-f"]).arg(&key)
+        .status()
+        .context("Nie można uruchomić ssh-keygen")?;
+    if !status.success() { bail!("ssh-keygen nie utworzył klucza {}", key.display()); }
+    // Przy polityce systemowej własny klucz niczego nie odblokowuje — nie dopisuj go
+    if signed_only_policy() != SignedOnly::System {
+        trust_key(&key.with_extension("pub"), &ident)?;
+    }
+    println!("  {} nowy klucz: {}", "✓".green(), key.display());
+    Ok(key)
+}
+
+fn trust_key(pubkey: &Path, name: &str) -> Result<()> {
+    let key = std::fs::read_to_string(pubkey)
+        .with_context(|| format!("Nie można odczytać {}", pubkey.display()))?;
+    let key: Vec<&str> = key.split_whitespace().take(2).collect();
+    if key.len() != 2 || !key[0].starts_with("ssh-") { bail!("{}: to nie jest klucz publiczny SSH", pubkey.display()); }
+    if name.contains(char::is_whitespace) { bail!("Nazwa klucza nie może zawierać spacji: '{}'", name); }
+
+    std::fs::create_dir_all(keys_dir())?;
+    let mut f = std::fs::OpenOptions::new().create(true).append(true).open(keys_dir().join(ALLOWED_SIGNERS))?;
+    writeln!(f, "{} namespaces=\"{}\" {} {}", name, SIG_NAMESPACE, key[0], key[1])?;
+    Ok(())
+}
+
+pub fn cmd_sign(file: &Path) -> Result<PathBuf> {
+    ssh_keygen()?;
+    if !file.is_file() { bail!("Plik nie istnieje: {}", file.display()); }
+    let key = ensure_user_key()?;
+    let sig = signature_path(file);
+    std::fs::remove_file(&sig).ok();
+    let out = Command::new("ssh-keygen")
+        .args(["-Y", "sign", "-n", SIG_NAMESPACE, "-f"]).arg(&key).arg(file)
+        .output()
+        .context("Nie można uruchomić ssh-keygen")?;
+    if !out.status.success() {
+        bail!("Podpisywanie nie powiodło się: {}", String::from_utf8_lossy(&out.stderr).trim());
+    }
+    println!("{} {} → {}", "✓".green(), file.display(), sig.display().to_string().bright_white().bold());
+    Ok(sig)
+}
+
+/// Wszystkie pliki zaufanych kluczy połączone w jeden (ssh-keygen przyjmuje jeden -f);
+/// przy polityce systemowej tylko /etc/hackeros/keys
+fn allowed_signers_file(system_only: bool) -> Result<Option<PathBuf>> {
+    let system = Path::new(SYSTEM_KEYS_DIR).join(ALLOWED_SIGNERS);
+    let sources = if system_only { vec![system] } else { vec![keys_dir().join(ALLOWED_SIGNERS), system] };
+    let merged: String = sources.iter().filter_map(|p| std::fs::read_to_string(p).ok()).collect::<Vec<_>>().join("\n");
+    if merged.trim().is_empty() { return Ok(None); }
+    let path = crate::tmp::run_temp_dir("signers")?.join(ALLOWED_SIGNERS);
+    std::fs::write(&path, merged)?;
+    Ok(Some(path))
+}
+
+/// Sprawdź podpis pliku; zwraca nazwę zaufanego klucza, który go złożył
+pub fn verify_signature(file: &Path) -> Result<String> {
+    let bytes = std::fs::read(file).with_context(|| format!("Nie można odczytać {}", file.display()))?;
+    verify_with(file, &bytes, signed_only_policy() == SignedOnly::System)
+}
+
+/// Sprawdź podpis `file` dla podanej treści — ssh-keygen dostaje ją na stdin
+fn verify_with(file: &Path, content: &[u8], system_only: bool) -> Result<String> {
+    ssh_keygen()?;
+    let sig = signature_path(file);
+    if !sig.exists() { bail!("Brak podpisu {} — podpisz: hl sign {}", sig.display(), file.display()); }
+    let Some(allowed) = allowed_signers_file(system_only)? else {
+        if system_only { bail!("Brak zaufanych kluczy w {}", SYSTEM_KEYS_DIR); }
+        bail!("Brak zaufanych kluczy w {} ani {}", keys_dir().display(), SYSTEM_KEYS_DIR);
+    };
+    let result = (|| -> Result<String> {
+        let found = Command::new("ssh-keygen")
+            .args(["-Y", "find-principals", "-f"]).arg(&allowed).arg("-s").arg(&sig)
+            .output()?;
+        let principal = String::from_utf8_lossy(&found.stdout).lines().next().unwrap_or("").trim().to_string();
+        if !found.status.success() || principal.is_empty() {
+            bail!("{}: podpis nie pochodzi od zaufanego klucza", file.display());
+        }
+        let mut child = Command::new("ssh-keygen")
+            .args(["-Y", "verify", "-n", SIG_NAMESPACE, "-I", &principal, "-f"]).arg(&allowed)
+            .arg("-s").arg(&sig)
+            .stdin(Stdio::piped()).stdout(Stdio::null()).stderr(Stdio::piped())
+            .spawn()?;
+        child.stdin.take().unwrap().write_all(content)?;
+        let out = child.wait_with_output()?;
+        if !out.status.success() {
+            bail!("{}: nieprawidłowy podpis (plik zmieniony po podpisaniu?)", file.display());
+        }
+        Ok(principal)
+    })();
+    if let Some(dir) = allowed.parent() { std::fs::remove_dir_all(dir).ok(); }
+    result
+}
+
+pub fn cmd_verify(file: &Path) -> Result<()> {
+    let who = verify_signature(file)?;
+    println!("{} {} — podpisany przez {}", "✓".green(), file.display(), who.bright_white().bold());
+    Ok(())
+}
+
+pub fn cmd_trust_add(pubkey: &Path, name: Option<&str>) -> Result<()> {
+    let default_name = pubkey.file_stem().and_then(|s| s.to_str()).unwrap_or("klucz").to_string();
+    let name = name.unwrap_or(&default_name);
+    trust_key(pubkey, name)?;
+    println!("{} zaufany klucz: {} ({})", "✓".green(), name.bright_white().bold(), pubkey.display());
+    Ok(())
+}
+
+pub fn cmd_trust_list() -> Result<()> {
+    println!("{}", "=== Zaufane klucze ===".bright_cyan().bold());
+    let mut any = false;
+    for path in [keys_dir().join(ALLOWED_SIGNERS), Path::new(SYSTEM_KEYS_DIR).join(ALLOWED_SIGNERS)] {
+        let Ok(src) = std::fs::read_to_string(&path) else { continue };
+        println!("  {}", path.display().to_string().bright_black());
+        for line in src.lines().filter(|l| !l.trim().is_empty() && !l.starts_with('#')) {
+            let mut parts = line.split_whitespace();
+            let name = parts.next().unwrap_or("?");
+            let key_type = parts.find(|p| p.starts_with("ssh-")).unwrap_or("?");
+            println!("    {} {}", name.bright_white().bold(), key_type.bright_black());
+            any = true;
+        }
+    }
+    if !any { println!("  {}", "Brak — hl sign tworzy własny klucz, hl trust add dodaje cudze".bright_black()); }
+    println!("  Tryb tylko-podpisane: {}", match signed_only_policy() {
+        SignedOnly::Off    => "wyłączony".bright_black(),
+        SignedOnly::User   => "włączony".green(),
+        SignedOnly::System => format!("włączony przez {} (tylko klucze systemowe)", SYSTEM_CONFIG).green(),
+    });
+    Ok(())
+}
@@ -0,0 +1,137 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use serde::{Deserialize, Serialize};
+use std::collections::BTreeMap;
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::time::{SystemTime, UNIX_EPOCH};
+use hl_parser::ast::Node;
+use crate::env::Env;
+use crate::exit::{classified, ErrorClass};
+use crate::libs::sha256_file;
+
+// ── Stan kroków ~once (.hl-state.json) ────────────────────────────────────────
+//
+//   ~once(toolchain) ^> apt-get install -y build-essential
+//   ~once(kernel, config/kernel.cfg) > make -j8 install
+//
+// Udane wykonanie kroku `~once(nazwa)` jest zapisywane w .hl-state.json obok
+// skryptu wejściowego (HL_SCRIPT) razem z hashem wejścia: treści kroku po
+// interpolacji zmiennych i zawartości wymienionych plików. Kolejne uruchomienie
+// pomija krok, dopóki hash się nie zmieni — długi instalator po błędzie rusza
+// od miejsca, w którym przerwał. Nieudany krok usuwa swój wpis.
+//
+//   hl state [katalog]                  lista kroków
+//   hl state --reset [--step nazwa]     zapomnij wszystkie / jeden krok
+
+pub const STATE_FILE: &str = ".hl-state.json";
+
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct StepState {
+    pub hash:    String,
+    pub done_at: u64,
+    pub secs:    f64,
+}
+
+#[derive(Debug, Default, Serialize, Deserialize)]
+pub struct ProjectState {
+    pub steps: BTreeMap<String, StepState>,
+}
+
+/// .hl-state.json dla skryptu uruchomionego w `env` (katalog HL_SCRIPT, inaczej bieżący)
+fn state_path_for(env: &Env) -> PathBuf {
+    let script = env.get_var_str("HL_SCRIPT");
+    crate::libs::source_dir(Path::new(&script)).join(STATE_FILE)
+}
+
+pub fn load_state(path: &Path) -> ProjectState {
+    std::fs::read_to_string(path).ok()
+        .and_then(|s| serde_json::from_str(&s).ok())
+        .unwrap_or_default()
+}
+
+fn save_state(path: &Path, state: &ProjectState) -> Result<()> {
+    let tmp = path.with_extension(format!("json.{}", std::process::id()));
+    std::fs::write(&tmp, serde_json::to_string_pretty(state)? + "\n")?;
+    std::fs::rename(&tmp, path)?;
+    Ok(())
+}
+
+fn sha256_text(text: &str) -> Result<String> {
+    if which::which("sha256sum").is_err() {
+        return Err(classified(ErrorClass::Toolchain, "sha256sum nie jest zainstalowany"));
+    }
+    let mut child = Command::new("sha256sum").stdin(Stdio::piped()).stdout(Stdio::piped()).spawn()?;
+    if let Some(mut stdin) = child.stdin.take() { stdin.write_all(text.as_bytes())?; }
+    let out = child.wait_with_output()?;
+    Ok(String::from_utf8_lossy(&out.stdout).split_whitespace().next().unwrap_or("").to_string())
+}
+
+/// Hash wejścia kroku: ciało z podstawionymi zmiennymi + sha256 plików
+fn input_hash(inputs: &[String], body: &[Node], env: &mut Env) -> Result<String> {
+    let mut text = env.interpolate(&serde_json::to_string(body)?);
+    for input in inputs {
+        let path = PathBuf::from(env.interpolate(input));
+        if !path.is_file() {
+            return Err(classified(ErrorClass::Dependency, format!("~once: plik wejścia nie istnieje: {}", path.display())));
+        }
+        text.push_str(&format!("\n{}={}", path.display(), sha256_file(&path)?));
+    }
+    sha256_text(&text)
+}
+
+/// Wykonaj krok `~once` — pomiń, jeśli zapisany hash się zgadza
+pub(crate) fn exec_once(name: &str, inputs: &[String], body: &[Node], env: &mut Env) -> Result<crate::executor::ExecResult> {
+    let path = state_path_for(env);
+    let hash = input_hash(inputs, body, env)?;
+    if load_state(&path).steps.get(name).is_some_and(|s| s.hash == hash) {
+        eprintln!("\x1b[90m[hl ~once] {} — już wykonane ({})\x1b[0m", name, STATE_FILE);
+        return Ok(crate::executor::ExecResult::ok());
+    }
+    let t0 = std::time::Instant::now();
+    let r = crate::executor::exec_nodes(body, env);
+    // --check-mode niczego nie wykonuje — nie ma czego zapisać
+    if crate::checkmode::check_mode() { return r; }
+
+    let mut state = load_state(&path);
+    match &r {
+        Ok(res) if res.exit_code == 0 => {
+            let done_at = SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0);
+            state.steps.insert(name.to_string(), StepState { hash, done_at, secs: t0.elapsed().as_secs_f64() });
+        }
+        _ => { state.steps.remove(name); }
+    }
+    save_state(&path, &state)?;
+    r
+}
+
+/// hl state — lista albo reset kroków projektu
+pub fn cmd_state(dir: &Path, reset: bool, step: Option<&str>) -> Result<()> {
+    let path = dir.join(STATE_FILE);
+    let mut state = load_state(&path);
+    if reset {
+        match step {
+            Some(name) => {
+                if state.steps.remove(name).is_none() { bail!("Brak kroku '{}' w {}", name, path.display()); }
+                save_state(&path, &state)?;
+                println!("{} zapomniano krok {}", "hl state:".bright_magenta().bold(), name.bright_white());
+            }
+            None => {
+                if path.exists() { std::fs::remove_file(&path)?; }
+                println!("{} usunięto {}", "hl state:".bright_magenta().bold(), path.display());
+            }
+        }
+        return Ok(());
+    }
+    println!("{} {}", "hl state:".bright_magenta().bold(), path.display().to_string().bright_white());
+    if state.steps.is_empty() {
+        println!("  {}", "brak zapisanych kroków ~once".bright_black());
+        return Ok(());
+    }
+    for (name, s) in &state.steps {
+        println!("  {} {:<24} {}  {:>6.1}s  {}", "✓".green(), name, crate::history::format_ts(s.done_at).bright_black(),
+                 s.secs, s.hash[..12.min(s.hash.len())].bright_black());
+    }
+    Ok(())
+}
@@ -0,0 +1,151 @@
+use anyhow::Result;
+use colored::Colorize;
+use hl_parser::ast::Node;
+use std::path::Path;
+use crate::cache::CacheKind;
+use crate::diagnostics::{lint_source, visit_nodes, DiagLevel};
+use crate::history::{format_ts, load_history};
+use crate::libs::{bit_package_notice, lib_source_file_in, parse_import_spec, ImportSource};
+use crate::provenance::import_specs;
+use crate::security::parse_manifest;
+use crate::ignore::project_hl_files;
+use crate::tmp::{dir_size, human_size};
+
+// ── hl status ─────────────────────────────────────────────────────────────────
+//
+// Jeden ekran o projekcie: nagłówek `///` i manifest skryptu wejściowego,
+// stan bibliotek i narzędzi `//`, ostatnie uruchomienia z dziennika, rozmiar
+// cache i liczba diagnostyk lintera w plikach projektu. Na końcu komendy,
+// które naprawiają to, co zostało pokazane.
+
+const LAST_RUNS: usize = 5;
+
+fn section(title: &str) {
+    println!();
+    println!("  {}", title.bright_cyan().bold());
+}
+
+/// `/// Version:` / `/// Description:` (lub pierwsza linia `///` bez klucza)
+fn header_info(source: &str) -> (Option<String>, Option<String>) {
+    let (mut version, mut description, mut first) = (None, None, None);
+    for line in source.lines().take(30) {
+        let t = line.trim();
+        if t.is_empty() || t.starts_with("#!") || t.starts_with("using") || t.starts_with(";;") { continue; }
+        let Some(doc) = t.strip_prefix("///") else { break };
+        match doc.split_once(':') {
+            Some((k, v)) => match k.trim().to_ascii_lowercase().as_str() {
+                "version" | "wersja"   => version = Some(v.trim().to_string()),
+                "description" | "opis" => description = Some(v.trim().to_string()),
+                _ => {}
+            },
+            None if !doc.trim().is_empty() => { first.get_or_insert_with(|| doc.trim().to_string()); }
+            None => {}
+        }
+    }
+    (version, description.or(first))
+}
+
+pub fn cmd_status(entry: &Path) -> Result<()> {
+    let root = entry.parent().filter(|d| !d.as_os_str().is_empty()).unwrap_or(Path::new("."));
+    let source = std::fs::read_to_string(entry)?;
+    let files = project_hl_files(root);
+    let mut hints: Vec<String> = Vec::new();
+
+    println!("{} {}", "hl status:".bright_magenta().bold(), entry.display().to_string().bright_white().bold());
+    let (version, description) = header_info(&source);
+    println!("  Wersja:      {}", version.as_deref().unwrap_or("-"));
+    println!("  Opis:        {}", description.as_deref().unwrap_or("-"));
+    println!("  Manifest:    {}", match parse_manifest(&source) {
+        Ok(Some(m)) if m.caps.is_empty() => "none".to_string(),
+        Ok(Some(m)) => m.caps.iter().map(|c| c.to_string()).collect::<Vec<_>>().join(", "),
+        Ok(None)    => "-".to_string(),
+        Err(e)      => format!("błąd: {}", e).red().to_string(),
+    });
+    println!("  Pliki .hl:   {}", files.len());
+
+    // ── Zależności ────────────────────────────────────────────────────────────
+    section("Zależności");
+    let mut specs: Vec<String> = Vec::new();
+    let mut tools: Vec<String> = Vec::new();
+    for file in &files {
+        let Ok(src) = std::fs::read_to_string(file) else { continue };
+        for spec in import_specs(&src) { if !specs.contains(&spec) { specs.push(spec); } }
+        if let Ok(nodes) = hl_parser::parse_source(&src) {
+            visit_nodes(&nodes, false, &mut |node, _| {
+                if let Node::Dependency { name, .. } = node {
+                    if !tools.contains(name) { tools.push(name.clone()); }
+                }
+            });
+        }
+    }
+    if specs.is_empty() && tools.is_empty() { println!("  {}", "brak importów i `//`".bright_black()); }
+    for spec in &specs {
+        let import = parse_import_spec(spec);
+        let notice = match &import {
+            Some(ImportSource::Bit { name, .. }) => bit_package_notice(name),
+            _ => None,
+        };
+        let state = match (&import, lib_source_file_in(spec, root), notice) {
+            (_, _, Some(n))                                      => { hints.push("bit upgrade".into()); n.yellow() }
+            (Some(ImportSource::Url { sha256: None, .. }), _, _) => { hints.push("hl lib pin <plik>".into()); "bez przypięcia #sha256=".yellow() }
+            (_, Some(_), _)                                      => "zainstalowana".green(),
+            (Some(ImportSource::Main { .. }), None, _)           => "wbudowana".bright_black(),
+            (_, None, _)                                         => { hints.push("hl run (pobiera przy imporcie) / bit install".into()); "brak lokalnie".red() }
+        };
+        println!("  {:<36} {}", format!("# <{}>", spec.trim_start_matches('<').trim_end_matches('>')), state);
+    }
+    for tool in &tools {
+        let state = if which::which(tool).is_ok() { "w PATH".green() } else {
+            hints.push("hl run (instaluje brakujące `//`)".into());
+            "brak".red()
+        };
+        println!("  {:<36} {}", format!("// {}", tool), state);
+    }
+
+    // ── Ostatnie uruchomienia ─────────────────────────────────────────────────
+    section("Ostatnie uruchomienia");
+    let root_abs = std::fs::canonicalize(root).unwrap_or_else(|_| root.to_path_buf());
+    let runs: Vec<_> = load_history().into_iter().rev()
+        .filter(|e| Path::new(&e.script).starts_with(&root_abs))
+        .take(LAST_RUNS)
+        .collect();
+    if runs.is_empty() { println!("  {}", "brak w dzienniku".bright_black()); }
+    for run in &runs {
+        let code = if run.exit_code == 0 { "ok".green() } else { format!("exit {}", run.exit_code).red() };
+        let name = Path::new(&run.script).strip_prefix(&root_abs).map(|p| p.display().to_string()).unwrap_or_else(|_| run.script.clone());
+        println!("  #{:<5} {}  {:<24} {:<8} {:.1}s", run.id, format_ts(run.ts).bright_black(), name, code, run.secs);
+    }
+    if runs.first().is_some_and(|r| r.exit_code != 0) { hints.push("hl history show <id>".into()); }
+
+    // ── Cache ─────────────────────────────────────────────────────────────────
+    section("Cache");
+    let sizes: Vec<(&str, u64)> = CacheKind::ALL.iter().map(|k| (k.name(), dir_size(&k.dir()))).collect();
+    let line: Vec<String> = sizes.iter().map(|(n, s)| format!("{} {}", n, human_size(*s))).collect();
+    println!("  {}  (razem {})", line.join(" · "), human_size(sizes.iter().map(|(_, s)| s).sum()));
+
+    // ── Lint ──────────────────────────────────────────────────────────────────
+    section("Diagnostyki");
+    let (mut errors, mut warnings, mut worst) = (0usize, 0usize, Vec::new());
+    for file in &files {
+        let Ok(src) = std::fs::read_to_string(file) else { continue };
+        let diags = lint_source(&src);
+        let e = diags.iter().filter(|d| d.level == DiagLevel::Error).count();
+        let w = diags.iter().filter(|d| d.level == DiagLevel::Warning).count();
+        errors += e;
+        warnings += w;
+        if e + w > 0 { worst.push((e, w, file.clone())); }
+    }
+    println!("  {} błędów, {} ostrzeżeń", errors.to_string().red(), warnings.to_string().yellow());
+    worst.sort_by(|a, b| (b.0, b.1).cmp(&(a.0, a.1)));
+    for (e, w, file) in worst.iter().take(3) {
+        println!("    {:<36} {} / {}", file.strip_prefix(root).unwrap_or(file).display(), e, w);
+    }
+    if errors + warnings > 0 { hints.push("hl check .   (hl check --fix .)".into()); }
+
+    // ── Akcje ─────────────────────────────────────────────────────────────────
+    section("Dalej");
+    hints.dedup();
+    hints.insert(0, format!("hl run {}", entry.display()));
+    for hint in hints { println!("  {}", hint.bright_cyan()); }
+    Ok(())
+}
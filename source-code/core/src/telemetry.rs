@@ -0,0 +1,163 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use serde::Serialize;
+use std::io::Write;
+use std::path::PathBuf;
+use std::process::{Command, Stdio};
+use std::sync::OnceLock;
+use std::time::{Instant, SystemTime, UNIX_EPOCH};
+use crate::config::load_config;
+
+// ── Telemetria (opt-in) ───────────────────────────────────────────────────────
+//
+//   hl telemetry on | off | status | send
+//
+// Domyślnie wyłączona. Po `hl telemetry on` każde wywołanie hl dopisuje jeden
+// rekord do lokalnego bufora meta/telemetry.jsonl:
+//
+//   {"ts":…, "command":"run", "secs":1.42, "ok":true, "exit":0, "os":"linux", "arch":"x86_64", "hl":"1.0.0"}
+//
+// Tylko nazwa podkomendy — bez argumentów, ścieżek, treści plików, nazwy
+// hosta ani identyfikatora instalacji. Bufor jest wysyłany (curl POST,
+// application/x-ndjson) tylko na adres z `[telemetry] -> endpoint` w config.hk:
+// ręcznie przez `hl telemetry send` albo w tle po SEND_EVERY rekordach.
+// `off` usuwa bufor. DO_NOT_TRACK=1 lub HL_TELEMETRY=0 wyłącza zapis zawsze.
+
+const SEND_EVERY: usize = 100;
+
+#[derive(Debug, Serialize)]
+struct Record<'a> {
+    ts:      u64,
+    command: &'a str,
+    secs:    f64,
+    ok:      bool,
+    exit:    i32,
+    os:      &'static str,
+    arch:    &'static str,
+    hl:      &'static str,
+}
+
+static STARTED: OnceLock<(String, Instant)> = OnceLock::new();
+
+pub fn spool_path() -> PathBuf {
+    crate::paths::data_dir("meta").join("telemetry.jsonl")
+}
+
+fn env_opt_out() -> bool {
+    std::env::var("DO_NOT_TRACK").is_ok_and(|v| v == "1") || std::env::var("HL_TELEMETRY").is_ok_and(|v| v == "0")
+}
+
+pub fn enabled() -> bool {
+    !env_opt_out() && matches!(load_config().get("telemetry", "enabled"), Some("true" | "yes" | "1"))
+}
+
+fn endpoint() -> Option<String> {
+    load_config().get("telemetry", "endpoint").map(str::trim).filter(|e| !e.is_empty()).map(str::to_string)
+}
+
+/// Początek wywołania — `command` to sama nazwa podkomendy
+pub fn start(command: &str) {
+    if enabled() { let _ = STARTED.set((command.to_string(), Instant::now())); }
+}
+
+/// Koniec wywołania: dopisz rekord (błędy zapisu są ignorowane)
+pub fn finish(exit: i32) {
+    let Some((command, t0)) = STARTED.get() else { return };
+    let record = Record {
+        ts: SystemTime::now().duration_since(UNIX_EPOCH).map(|d| d.as_secs()).unwrap_or(0),
+        command,
+        secs: (t0.elapsed().as_secs_f64() * 100.0).round() / 100.0,
+        ok: exit == 0,
+        exit,
+        os: std::env::consts::OS,
+        arch: std::env::consts::ARCH,
+        hl: crate::compat::HL_VERSION,
+    };
+    let path = spool_path();
+    let written = (|| -> Result<()> {
+        if let Some(dir) = path.parent() { std::fs::create_dir_all(dir)?; }
+        let mut f = std::fs::OpenOptions::new().create(true).append(true).open(&path)?;
+        writeln!(f, "{}", serde_json::to_string(&record)?)?;
+        Ok(())
+    })();
+    if written.is_ok() && spooled() >= SEND_EVERY {
+        if let Some(url) = endpoint() { send_in_background(&url); }
+    }
+}
+
+fn spooled() -> usize {
+    std::fs::read_to_string(spool_path()).map(|s| s.lines().count()).unwrap_or(0)
+}
+
+/// Bufor przeniesiony pod nazwę tymczasową — kolejne rekordy trafiają do nowego
+fn take_spool() -> Option<PathBuf> {
+    let path = spool_path();
+    let batch = path.with_extension(format!("{}.sending", std::process::id()));
+    std::fs::rename(&path, &batch).ok().map(|_| batch)
+}
+
+fn curl_args(url: &str, batch: &std::path::Path) -> Vec<String> {
+    ["-fsS", "-m", "10", "-X", "POST", "-H", "Content-Type: application/x-ndjson", "--data-binary"]
+        .iter().map(|s| s.to_string())
+        .chain([format!("@{}", batch.display()), url.to_string()])
+        .collect()
+}
+
+fn send_in_background(url: &str) {
+    if which::which("curl").is_err() { return; }
+    let Some(batch) = take_spool() else { return };
+    // Wysłana paczka znika; nieudana wraca na koniec bufora
+    let script = r#"curl "$@" >/dev/null 2>&1 && rm -f "$0" || { cat "$0" >> "$HL_TELEMETRY_SPOOL"; rm -f "$0"; }"#;
+    let _ = Command::new("sh").arg("-c").arg(script).arg(&batch).args(curl_args(url, &batch))
+        .env("HL_TELEMETRY_SPOOL", spool_path())
+        .stdin(Stdio::null()).stdout(Stdio::null()).stderr(Stdio::null())
+        .spawn();
+}
+
+pub fn cmd_telemetry_on() -> Result<()> {
+    crate::config_check::cmd_config_set("telemetry.enabled", "true", false)?;
+    println!("  Zapisywane: podkomenda, czas, wynik, OS/arch, wersja hl — bez argumentów i treści plików.");
+    println!("  Bufor: {}", spool_path().display().to_string().bright_black());
+    if env_opt_out() { println!("  {} DO_NOT_TRACK=1 / HL_TELEMETRY=0 w środowisku — zapis i tak wyłączony", "!".yellow()); }
+    Ok(())
+}
+
+pub fn cmd_telemetry_off() -> Result<()> {
+    crate::config_check::cmd_config_set("telemetry.enabled", "false", false)?;
+    let path = spool_path();
+    if path.exists() {
+        std::fs::remove_file(&path)?;
+        println!("  Usunięto bufor {}", path.display().to_string().bright_black());
+    }
+    Ok(())
+}
+
+pub fn cmd_telemetry_status() -> Result<()> {
+    let state = if enabled() { "włączona".green().bold() } else { "wyłączona".bright_black().bold() };
+    println!("{} {}", "hl telemetry:".bright_magenta().bold(), state);
+    if env_opt_out() { println!("  {}", "DO_NOT_TRACK=1 / HL_TELEMETRY=0 — zapis wyłączony przez środowisko".yellow()); }
+    println!("  bufor:    {} ({} rekordów)", spool_path().display(), spooled());
+    println!("  endpoint: {}", endpoint().unwrap_or_else(|| "brak — rekordy zostają lokalnie".into()));
+    Ok(())
+}
+
+pub fn cmd_telemetry_send() -> Result<()> {
+    let Some(url) = endpoint() else { bail!("brak [telemetry] -> endpoint w config.hk — nie ma dokąd wysłać") };
+    if which::which("curl").is_err() {
+        return Err(crate::exit::classified(crate::exit::ErrorClass::Toolchain, "curl nie jest zainstalowany"));
+    }
+    let count = spooled();
+    if count == 0 { println!("  Bufor pusty."); return Ok(()); }
+    let Some(batch) = take_spool() else { bail!("nie można przenieść bufora {}", spool_path().display()) };
+    let status = Command::new("curl").args(curl_args(&url, &batch)).stdout(Stdio::null()).status()?;
+    if !status.success() {
+        let rest = std::fs::read_to_string(&batch).unwrap_or_default();
+        let mut f = std::fs::OpenOptions::new().create(true).append(true).open(spool_path())?;
+        f.write_all(rest.as_bytes())?;
+        std::fs::remove_file(&batch)?;
+        bail!("wysyłka na {} nie powiodła się (curl {}) — rekordy zostają w buforze", url, status.code().unwrap_or(1));
+    }
+    std::fs::remove_file(&batch)?;
+    println!("  {} wysłano {} rekordów na {}", "✓".green(), count, url);
+    Ok(())
+}
@@ -0,0 +1,140 @@
+use anyhow::{bail, Result};
+use colored::Colorize;
+use std::path::{Path, PathBuf};
+use std::time::{Duration, SystemTime};
+use crate::exit::{classified, ErrorClass};
+
+// ── Pliki tymczasowe hl ───────────────────────────────────────────────────────
+//
+// Wszystko, co hl tworzy tymczasowo (staging hl export, hl bug-report, …),
+// trafia do jednego katalogu należącego do użytkownika:
+//   $TMPDIR/hl-<uid>/<tag>-<pid>-<ts>/
+// `hl clean` usuwa wyłącznie podkatalogi tego korzenia — nigdy nie dotyka
+// cudzych plików w /tmp — i pomija katalogi procesów, które jeszcze działają.
+// Korzeń powstaje od razu z trybem 0700; istniejący musi być zwykłym katalogiem
+// (nie dowiązaniem) użytkownika z trybem 0700, inaczej hl odmawia jego użycia.
+
+pub fn temp_root() -> PathBuf {
+    std::env::temp_dir().join(format!("hl-{}", nix::unistd::getuid()))
+}
+
+/// Sprawdź, że korzeń należy do nas: katalog (lstat), właściciel = uid, tryb 0700
+fn check_root(root: &Path) -> Result<()> {
+    use std::os::unix::fs::{MetadataExt, PermissionsExt};
+    let meta = std::fs::symlink_metadata(root)?;
+    let uid = nix::unistd::getuid().as_raw();
+    if !meta.file_type().is_dir() || meta.uid() != uid || meta.permissions().mode() & 0o777 != 0o700 {
+        return Err(classified(ErrorClass::Denied, format!(
+            "{}: katalog tymczasowy hl nie jest prywatnym katalogiem użytkownika (wymagane: właściciel {}, tryb 0700, nie dowiązanie)",
+            root.display(), uid)));
+    }
+    Ok(())
+}
+
+/// Korzeń katalogów roboczych — tworzony atomowo z trybem 0700 albo sprawdzony
+fn ensure_root() -> Result<PathBuf> {
+    use std::os::unix::fs::DirBuilderExt;
+    let root = temp_root();
+    match std::fs::DirBuilder::new().mode(0o700).create(&root) {
+        Ok(()) => {}
+        Err(e) if e.kind() == std::io::ErrorKind::AlreadyExists => {}
+        Err(e) => return Err(e.into()),
+    }
+    check_root(&root)?;
+    Ok(root)
+}
+
+/// Czy proces, który utworzył katalog `<tag>-<pid>-<ts>`, jeszcze działa
+pub(crate) fn owner_alive(dir_name: &str) -> bool {
+    use nix::libc;
+    let Some(pid) = dir_name.rsplitn(3, '-').nth(1).and_then(|p| p.parse::<libc::pid_t>().ok()) else { return false };
+    if pid <= 0 { return false; }
+    // kill(pid, 0) nie wysyła sygnału — tylko sprawdza istnienie procesu
+    let rc = unsafe { libc::kill(pid, 0) };
+    rc == 0 || std::io::Error::last_os_error().raw_os_error() == Some(libc::EPERM)
+}
+
+/// Utwórz świeży katalog roboczy dla jednego uruchomienia
+pub fn run_temp_dir(tag: &str) -> Result<PathBuf> {
+    let root = ensure_root()?;
+    let ts = SystemTime::now().duration_since(SystemTime::UNIX_EPOCH).map(|d| d.as_millis()).unwrap_or(0);
+    let dir = root.join(format!("{}-{}-{}", tag, std::process::id(), ts));
+    std::fs::create_dir_all(&dir)?;
+    Ok(dir)
+}
+
+/// "30m", "12h", "7d" lub sama liczba (godziny)
+pub fn parse_age(s: &str) -> Result<Duration> {
+    let s = s.trim();
+    let (num, mult) = match s.chars().last() {
+        Some('m') => (&s[..s.len() - 1], 60),
+        Some('h') => (&s[..s.len() - 1], 3600),
+        Some('d') => (&s[..s.len() - 1], 86400),
+        _         => (s, 3600),
+    };
+    match num.parse::<u64>() {
+        Ok(n) => Ok(Duration::from_secs(n * mult)),
+        Err(_) => bail!("Nieprawidłowy wiek '{}' — użyj np. 30m, 12h, 7d", s),
+    }
+}
+
+pub fn dir_size(path: &Path) -> u64 {
+    let Ok(meta) = std::fs::symlink_metadata(path) else { return 0 };
+    if !meta.is_dir() { return meta.len(); }
+    std::fs::read_dir(path)
+        .map(|rd| rd.flatten().map(|e| dir_size(&e.path())).sum())
+        .unwrap_or(0)
+}
+
+pub fn human_size(bytes: u64) -> String {
+    match bytes {
+        b if b >= 1 << 30 => format!("{:.1} GB", b as f64 / (1u64 << 30) as f64),
+        b if b >= 1 << 20 => format!("{:.1} MB", b as f64 / (1u64 << 20) as f64),
+        b if b >= 1 << 10 => format!("{:.1} KB", b as f64 / (1u64 << 10) as f64),
+        b                 => format!("{} B", b),
+    }
+}
+
+/// Usuń katalogi robocze z temp_root(); `older_than` — tylko starsze niż podany wiek
+pub fn cmd_clean_temp(dry_run: bool, older_than: Option<Duration>) -> Result<()> {
+    let root = temp_root();
+    if std::fs::symlink_metadata(&root).is_err() {
+        println!("{}", "Brak plików tymczasowych hl.".bright_black());
+        return Ok(());
+    }
+    check_root(&root)?;
+    let rd = std::fs::read_dir(&root)?;
+    let now = SystemTime::now();
+    let (mut n, mut total, mut busy) = (0usize, 0u64, 0usize);
+    for entry in rd.flatten() {
+        let path = entry.path();
+        if owner_alive(&entry.file_name().to_string_lossy()) { busy += 1; continue; }
+        let age = entry.metadata().ok()
+            .and_then(|m| m.modified().ok())
+            .and_then(|t| now.duration_since(t).ok())
+            .unwrap_or_default();
+        if older_than.is_some_and(|min| age < min) { continue; }
+
+        let size = dir_size(&path);
+        if dry_run {
+            println!("  {} {} ({})", "~".bright_yellow(), path.display(), human_size(size));
+        } else {
+            let res = if path.is_dir() { std::fs::remove_dir_all(&path) } else { std::fs::remove_file(&path) };
+            if let Err(e) = res {
+                eprintln!("  {} {}: {}", "✗".red(), path.display(), e);
+                continue;
+            }
+        }
+        n += 1;
+        total += size;
+    }
+    match (n, dry_run) {
+        (0, _)     => println!("{}", "Brak plików tymczasowych hl do usunięcia.".bright_black()),
+        (_, true)  => println!("{} Do usunięcia: {} katalogów tymczasowych ({})", "~".bright_yellow(), n, human_size(total)),
+        (_, false) => println!("{} Usunięto {} katalogów tymczasowych ({})", "✓".green(), n, human_size(total)),
+    }
+    if busy > 0 {
+        println!("  {}", format!("pominięto {} katalogów działających procesów hl", busy).bright_black());
+    }
+    Ok(())
+}
@@ -0,0 +1,190 @@
+use anyhow::Result;
+use colored::Colorize;
+use std::io::{Read, Write};
+use std::path::Path;
+use std::process::{Command, Stdio};
+use std::thread::JoinHandle;
+use std::time::{Duration, Instant};
+use crate::diagnostics::{lint_source, DiagLevel};
+
+// ── hl verify-install ─────────────────────────────────────────────────────────
+//
+// Test dymny toolchainu po instalacji albo aktualizacji. Wbudowany hello-world
+// przechodzi przez każdy etap, a raport mówi, co działa na tej maszynie:
+//
+//   parser        lexer, parser i linter (w procesie)
+//   compile       hl compile → hello.bc (zapis i ponowne wczytanie)
+//   bytecode      hl run hello.bc
+//   interpreter   hl run hello.hl (tree-walk)
+//   jit           hl run --jit hello.hl
+//   repl          hl repl ze skryptem na stdin
+//   container     hl run w osobnych przestrzeniach nazw (unshare, jak
+//                 hl examples run --isolated)
+//
+// Etapy poza parserem uruchamiają bieżącą binarkę hl jako osobny proces w
+// katalogu tymczasowym, który jest też jego HOME — REPL nie dopisuje niczego
+// do prawdziwej historii, a cache trafia tam, gdzie zostanie sprzątnięty.
+// Etap bez potrzebnego narzędzia (unshare) jest pomijany, nie zawodzi.
+
+const HELLO: &str = "\
+using <gen 2>
+
+% name: str = verify-install
+$( 6 * 7 ) -> @answer
+
+: greet def
+    ~> hl-ok @arg0 @answer
+done
+
+-- greet @name
+";
+
+/// REPL wykonuje linię po linii — bez bloków
+const REPL_INPUT: &str = "% name: str = verify-install\n$( 6 * 7 ) -> @answer\n~> hl-ok @name @answer\n";
+
+const EXPECTED: &str = "hl-ok verify-install 42";
+const TIMEOUT: Duration = Duration::from_secs(60);
+const ISOLATE: &[&str] = &["unshare", "--user", "--map-root-user", "--mount", "--pid", "--net", "--fork", "--"];
+
+enum Outcome {
+    Ok(String),
+    Failed(String),
+    Skipped(String),
+}
+
+fn drain<R: Read + Send + 'static>(pipe: Option<R>) -> JoinHandle<String> {
+    std::thread::spawn(move || {
+        let mut s = String::new();
+        if let Some(mut p) = pipe { p.read_to_string(&mut s).ok(); }
+        s
+    })
+}
+
+/// Bieżąca binarka hl w `dir` (opcjonalnie przez `wrapper`): kod wyjścia, stdout, stderr
+fn run_hl(dir: &Path, wrapper: &[&str], args: &[&str], input: Option<&str>) -> Result<(i32, String, String), String> {
+    let hl = std::env::current_exe().map_err(|e| format!("nie znaleziono binarki hl: {}", e))?;
+    let mut cmd = match wrapper.split_first() {
+        Some((bin, rest)) => { let mut c = Command::new(bin); c.args(rest).arg(&hl); c }
+        None              => Command::new(&hl),
+    };
+    cmd.args(args).current_dir(dir)
+        .env("HOME", dir).env("HL_TELEMETRY", "0").env("HL_NO_UPDATE_NOTICE", "1")
+        .stdin(if input.is_some() { Stdio::piped() } else { Stdio::null() })
+        .stdout(Stdio::piped()).stderr(Stdio::piped());
+    let mut child = cmd.spawn().map_err(|e| format!("nie można uruchomić: {}", e))?;
+    if let (Some(text), Some(mut stdin)) = (input, child.stdin.take()) {
+        stdin.write_all(text.as_bytes()).ok();
+    }
+    let (out, err) = (drain(child.stdout.take()), drain(child.stderr.take()));
+    let deadline = Instant::now() + TIMEOUT;
+    let status = loop {
+        match child.try_wait() {
+            Ok(Some(status)) => break status,
+            Ok(None) if Instant::now() < deadline => std::thread::sleep(Duration::from_millis(20)),
+            Ok(None) => {
+                child.kill().ok();
+                child.wait().ok();
+                return Err(format!("brak odpowiedzi po {}s", TIMEOUT.as_secs()));
+            }
+            Err(e) => return Err(e.to_string()),
+        }
+    };
+    Ok((status.code().unwrap_or(crate::exit::FAILURE), out.join().unwrap_or_default(), err.join().unwrap_or_default()))
+}
+
+/// Wynik etapu uruchamiającego hello-world: kod 0 i oczekiwana linia na stdout
+fn expect_hello(result: Result<(i32, String, String), String>) -> Outcome {
+    match result {
+        Err(e) => Outcome::Failed(e),
+        Ok((0, out, _)) if out.contains(EXPECTED) => Outcome::Ok(EXPECTED.to_string()),
+        Ok((code, _, err)) => {
+            let last = err.lines().rev().find(|l| !l.trim().is_empty()).unwrap_or("").trim().to_string();
+            Outcome::Failed(if code != 0 {
+                format!("kod {}{}", code, if last.is_empty() { String::new() } else { format!(": {}", last) })
+            } else {
+                format!("brak „{}” na wyjściu", EXPECTED)
+            })
+        }
+    }
+}
+
+fn stage_parser() -> Outcome {
+    match hl_parser::parse_source(HELLO) {
+        Err(e) => Outcome::Failed(e.to_string()),
+        Ok(nodes) => {
+            let errors = lint_source(HELLO).iter().filter(|d| d.level == DiagLevel::Error).count();
+            if errors > 0 { Outcome::Failed(format!("linter: {} błędów", errors)) }
+            else { Outcome::Ok(format!("{} węzłów AST", nodes.len())) }
+        }
+    }
+}
+
+fn stage_compile(dir: &Path) -> Outcome {
+    match run_hl(dir, &[], &["compile", "hello.hl", "-o", "hello.bc"], None) {
+        Err(e) => Outcome::Failed(e),
+        Ok((0, ..)) => match std::fs::metadata(dir.join("hello.bc")) {
+            Ok(m)  => Outcome::Ok(format!("hello.bc, {}", crate::tmp::human_size(m.len()))),
+            Err(_) => Outcome::Failed("hl compile nie zapisał hello.bc".into()),
+        },
+        Ok((code, _, err)) => Outcome::Failed(format!("kod {}: {}", code, err.lines().last().unwrap_or("").trim())),
+    }
+}
+
+fn stage_container(dir: &Path) -> Outcome {
+    if which::which("unshare").is_err() { return Outcome::Skipped("brak unshare (util-linux)".into()); }
+    match expect_hello(run_hl(dir, ISOLATE, &["run", "hello.hl"], None)) {
+        Outcome::Failed(e) => Outcome::Failed(format!("{} — przestrzenie nazw użytkownika wyłączone w jądrze?", e)),
+        other => other,
+    }
+}
+
+/// hl verify-install — zwraca kod wyjścia (1, gdy któryś etap zawiódł)
+pub fn cmd_verify_install(keep: bool) -> Result<i32> {
+    let hl = std::env::current_exe().map(|p| p.display().to_string()).unwrap_or_else(|_| "hl".into());
+    println!("{} hl {} {}", "hl verify-install:".bright_magenta().bold(), crate::compat::HL_VERSION,
+             format!("({})", hl).bright_black());
+
+    let dir = crate::tmp::run_temp_dir("verify-install")?;
+    std::fs::write(dir.join("hello.hl"), HELLO)?;
+
+    let stages: [(&str, &str, &dyn Fn() -> Outcome); 7] = [
+        ("parser",      "lexer, parser, linter",   &stage_parser),
+        ("compile",     "hl compile → .bc",        &|| stage_compile(&dir)),
+        ("bytecode",    "hl run hello.bc",         &|| if dir.join("hello.bc").exists() {
+            expect_hello(run_hl(&dir, &[], &["run", "hello.bc"], None))
+        } else { Outcome::Skipped("brak hello.bc — compile nie przeszedł".into()) }),
+        ("interpreter", "hl run hello.hl",         &|| expect_hello(run_hl(&dir, &[], &["run", "hello.hl"], None))),
+        ("jit",         "hl run --jit hello.hl",   &|| expect_hello(run_hl(&dir, &[], &["run", "--jit", "hello.hl"], None))),
+        ("repl",        "hl repl, skrypt na stdin", &|| expect_hello(run_hl(&dir, &[], &["repl"], Some(REPL_INPUT)))),
+        ("container",   "unshare … hl run",        &|| stage_container(&dir)),
+    ];
+
+    let (mut ok, mut failed, mut skipped) = (0, 0, 0);
+    for (name, what, run) in stages {
+        print!("  {:<12} {:<26} ", name, what.bright_black());
+        std::io::stdout().flush().ok();
+        let t0 = Instant::now();
+        let outcome = run();
+        let ms = format!("{}ms", t0.elapsed().as_millis()).bright_black();
+        match outcome {
+            Outcome::Ok(detail)      => { ok += 1;      println!("{} {}  {}", "✓".green(), detail, ms); }
+            Outcome::Failed(detail)  => { failed += 1;  println!("{} {}  {}", "✗".red(), detail.red(), ms); }
+            Outcome::Skipped(detail) => { skipped += 1; println!("{} {}", "-".bright_black(), format!("pominięty: {}", detail).bright_black()); }
+        }
+    }
+
+    if keep {
+        println!("  katalog roboczy: {}", dir.display().to_string().bright_white());
+    } else {
+        std::fs::remove_dir_all(&dir).ok();
+    }
+    println!();
+    let summary = format!("{} działa, {} zawiodło, {} pominięto", ok, failed, skipped);
+    if failed == 0 {
+        println!("  {} {}", "✓".green().bold(), summary);
+        Ok(crate::exit::OK)
+    } else {
+        println!("  {} {} — szczegóły: hl bug-report -- hl verify-install", "✗".red().bold(), summary);
+        Ok(crate::exit::FAILURE)
+    }
+}
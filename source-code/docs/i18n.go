@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Komunikaty interfejsu hl-docs (menu, pasek stanu, ekran powitalny).
+// Treść dokumentacji pozostaje po polsku; tłumaczone są tylko elementy UI.
+//
+// Język: --lang <pl|en>, potem HL_LANG, LC_ALL, LC_MESSAGES, LANG; domyślnie pl.
+
+var catalogs = map[string]map[string]string{
+	"pl": {
+		"loading":       "Ładowanie...",
+		"welcome.title": "Dokumentacja Hacker Lang",
+		"welcome.body":  "Wybierz temat z menu po lewej.\nObsługiwane: gen 1, gen 2, ROLLING",
+		"welcome.keys":  "↑↓ / j k — nawigacja\nEnter — otwórz\n/ — szukaj\nq — wyjdź",
+		"welcome.tip":   "💡 ROLLING = najnowsze funkcje pre-gen 3",
+		"status.search": "SZUKAJ: %s_",
+		"status.count":  "  %d sekcji",
+		"hints.menu":    "↑↓/jk: nawigacja  Enter: otwórz  /: szukaj  q: wyjdź",
+		"hints.content": "↑↓/jk: przewijanie  PgUp/Dn: strona  Esc: menu  q: wyjdź",
+		"error":         "błąd hl-docs: %v\n",
+		"unknown.lang":  "hl-docs: nieznany język %q (pl | en)\n",
+		"page.error":    "hl-docs: nie można wczytać strony %s: %v\n",
+		"page.category": "STRONY",
+	},
+	"en": {
+		"loading":       "Loading...",
+		"welcome.title": "Hacker Lang documentation",
+		"welcome.body":  "Pick a topic from the menu on the left.\nSupported: gen 1, gen 2, ROLLING",
+		"welcome.keys":  "↑↓ / j k — navigate\nEnter — open\n/ — search\nq — quit",
+		"welcome.tip":   "💡 ROLLING = newest pre-gen 3 features",
+		"status.search": "SEARCH: %s_",
+		"status.count":  "  %d sections",
+		"hints.menu":    "↑↓/jk: nav  Enter: open  /: search  q: quit",
+		"hints.content": "↑↓/jk: scroll  PgUp/Dn: page  Esc: menu  q: quit",
+		"error":         "hl-docs error: %v\n",
+		"unknown.lang":  "hl-docs: unknown language %q (pl | en)\n",
+		"page.error":    "hl-docs: cannot load page %s: %v\n",
+		"page.category": "PAGES",
+	},
+}
+
+var lang = "pl"
+
+// localeLang mapuje wartość w stylu LANG ("en_US.UTF-8", "pl_PL") na kod katalogu.
+func localeLang(v string) string {
+	v = strings.ToLower(v)
+	if i := strings.IndexAny(v, "_.@-"); i >= 0 {
+		v = v[:i]
+	}
+	if _, ok := catalogs[v]; ok {
+		return v
+	}
+	return ""
+}
+
+// detectLang ustala język z argumentów i zmiennych środowiska.
+func detectLang(args []string) string {
+	for i, a := range args {
+		val, ok := "", false
+		switch {
+		case a == "--lang" && i+1 < len(args):
+			val, ok = args[i+1], true
+		case strings.HasPrefix(a, "--lang="):
+			val, ok = strings.TrimPrefix(a, "--lang="), true
+		}
+		if !ok {
+			continue
+		}
+		if l := localeLang(val); l != "" {
+			return l
+		}
+		fmt.Fprintf(os.Stderr, T("unknown.lang"), val)
+	}
+	for _, env := range []string{"HL_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if l := localeLang(os.Getenv(env)); l != "" {
+			return l
+		}
+	}
+	return "pl"
+}
+
+// T zwraca komunikat w bieżącym języku (z polskim katalogiem jako zapasowym).
+func T(key string) string {
+	if s, ok := catalogs[lang][key]; ok {
+		return s
+	}
+	return catalogs["pl"][key]
+}
@@ -45,9 +45,9 @@ var (
 )
 
 type DocSection struct {
-	Title    string
-	Category string
-	Content  string
+	Title    string `json:"title"`
+	Category string `json:"category"`
+	Content  string `json:"content"`
 }
 
 var sections = []DocSection{
@@ -324,6 +324,39 @@ Uzyj strzalek do nawigacji. %s = wyjscie.`,
 			styleH2.Render("Kompatybilnosc wstecz — stara skladnia takze dziala"),
 		),
 	},
+	{
+		Title:    "Biblioteka std",
+		Category: "GEN 1",
+		Content: fmt.Sprintf(`%s
+
+Zestaw std jest instalowany razem z hl i wkompilowany w binarke —
+wersja std to wersja toolchainu.
+
+%s
+%s
+
+%s
+%s
+
+%s
+%s
+
+%s
+%s
+
+%s`,
+			styleH1.Render("Biblioteka std"),
+			styleH2.Render("std/http — curl ze wspolnymi flagami"),
+			styleCode.Render("# <std/http>\n% _http_url = https://example.com/api\n-- http_get           ;; → @_http_body, exit != 0 dla 4xx/5xx\n% _http_out = plik.tar.gz\n-- http_download\n-- http_status        ;; → @_http_status\n;; HTTP_TIMEOUT, HTTP_RETRIES, HTTP_USER_AGENT"),
+			styleH2.Render("std/logging"),
+			styleCode.Render("# <std/logging>\n% LOG_LEVEL = warn\n% LOG_FILE  = /var/log/setup.log\n% _log_msg = Brak miejsca\n-- log_warn           ;; 2026-01-01 12:00:00 [warn] Brak miejsca"),
+			styleH2.Render("std/retry"),
+			styleCode.Render("# <std/retry>\n% _retry_cmd = curl -fsS http://localhost:8080/health\n-- retry_run          ;; RETRY_TIMES prob, odstep RETRY_DELAY s (podwajany)\n% _retry_timeout = 60\n-- retry_until"),
+			styleH2.Render("std/fs, std/json — jak main/fs, main/json"),
+			styleCode.Render("# <std/http:1>        ;; wymaga hl 1.x, inaczej blad zaleznosci (exit 4)"),
+			styleTip.Render("Lista: hl lib list"),
+		),
+	},
 
 	// ── GEN 2 ─────────────────────────────────────────────────────────────────
 	{
@@ -641,6 +674,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport = viewport.New(m.width-30, vpH)
 			m.viewport.Style = styleContent
 			m.ready = true
+			if m.currentView == viewContent { m.viewport.SetContent(sections[m.sectionIndex].Content) }
 		} else {
 			m.viewport.Width = m.width - 30
 			m.viewport.Height = vpH
@@ -717,7 +751,7 @@ func (m model) renderMenu() string {
 }
 
 func (m model) View() string {
-	if !m.ready { return "\n  Ladowanie..." }
+	if !m.ready { return "\n  " + T("loading") }
 	sidebarW := 30
 	logoStyle := lipgloss.NewStyle().Foreground(colorMagenta).Bold(true).Width(sidebarW).Align(lipgloss.Center)
 	versionStyle := lipgloss.NewStyle().Foreground(colorMuted).Width(m.width - sidebarW).Align(lipgloss.Right).PaddingRight(2)
@@ -732,10 +766,10 @@ func (m model) View() string {
 		contentSection = m.viewport.View()
 	} else {
 		welcome := fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s",
-			styleH1.Render("Dokumentacja Hacker Lang"),
-			lipgloss.NewStyle().Foreground(colorText).Render("Wybierz temat z menu po lewej.\nObslugiwane: gen 1, gen 2, ROLLING"),
-			lipgloss.NewStyle().Foreground(colorMuted).Render("↑↓ / j k — nawigacja\nEnter — otwórz\n/ — szukaj\nq — wyjdz"),
-			styleTip.Render("💡 ROLLING = najnowsze funkcje pre-gen 3"),
+			styleH1.Render(T("welcome.title")),
+			lipgloss.NewStyle().Foreground(colorText).Render(T("welcome.body")),
+			lipgloss.NewStyle().Foreground(colorMuted).Render(T("welcome.keys")),
+			styleTip.Render(T("welcome.tip")),
 		)
 		contentSection = lipgloss.NewStyle().Width(m.width-sidebarW-2).Height(m.height-5).Padding(2, 3).Render(welcome)
 	}
@@ -747,14 +781,14 @@ func (m model) View() string {
 
 	var statusLeft string
 	if m.searchMode {
-		statusLeft = lipgloss.NewStyle().Foreground(colorYellow).Bold(true).Render(fmt.Sprintf("SZUKAJ: %s_", m.searchQuery))
+		statusLeft = lipgloss.NewStyle().Foreground(colorYellow).Bold(true).Render(fmt.Sprintf(T("status.search"), m.searchQuery))
 	} else if m.currentView == viewContent {
 		statusLeft = lipgloss.NewStyle().Foreground(colorAccent).Render(fmt.Sprintf("  %s", sections[m.sectionIndex].Title))
 	} else {
-		statusLeft = lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf("  %d sekcji", len(m.filtered)))
+		statusLeft = lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf(T("status.count"), len(m.filtered)))
 	}
-	navHints := "↑↓/jk: nav  Enter: open  /: search  q: quit"
-	if m.currentView == viewContent { navHints = "↑↓/jk: scroll  PgUp/Dn: page  Esc: menu  q: quit" }
+	navHints := T("hints.menu")
+	if m.currentView == viewContent { navHints = T("hints.content") }
 	statusRight := lipgloss.NewStyle().Foreground(colorMuted).Render(navHints + "  ")
 	statusBar := lipgloss.JoinHorizontal(lipgloss.Top,
 		statusLeft,
@@ -765,9 +799,28 @@ func (m model) View() string {
 }
 
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	lang = detectLang(os.Args[1:])
+	if hasFlag(os.Args[1:], "--dump") {
+		if err := dumpSections(); err != nil {
+			fmt.Fprintf(os.Stderr, T("error"), err)
+			os.Exit(1)
+		}
+		return
+	}
+	pages := loadPages(os.Args[1:])
+	sections = append(pages, sections...)
+	m := initialModel()
+	if len(pages) > 0 {
+		m.currentView = viewContent
+	}
+	for _, title := range flagValues(os.Args[1:], "--section") {
+		if i := sectionIndex(title); i >= 0 {
+			m.sectionIndex, m.currentView = i, viewContent
+		}
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "hl-docs error: %v\n", err)
+		fmt.Fprintf(os.Stderr, T("error"), err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Strony dołączane z zewnątrz: hl-docs --page <plik> (np. `hl docs lib logging`).
+// Pierwsza linia pliku to tytuł w menu, reszta — treść (tekst, także ANSI).
+// Strony trafiają na początek menu, a pierwsza z nich otwiera się od razu.
+//
+// Dla `hl docs search`:
+//   hl-docs --dump              sekcje jako JSON (tytuł, kategoria, treść) do indeksu
+//   hl-docs --section <tytuł>   otwórz TUI od razu na tej sekcji
+
+// flagValues zbiera wartości flagi w postaci `--f wartość` i `--f=wartość`.
+func flagValues(args []string, flag string) []string {
+	var vals []string
+	for i, a := range args {
+		switch {
+		case a == flag && i+1 < len(args):
+			vals = append(vals, args[i+1])
+		case strings.HasPrefix(a, flag+"="):
+			vals = append(vals, strings.TrimPrefix(a, flag+"="))
+		}
+	}
+	return vals
+}
+
+func hasFlag(args []string, flag string) bool {
+	for _, a := range args {
+		if a == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func pageArgs(args []string) []string { return flagValues(args, "--page") }
+
+func dumpSections() error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sections)
+}
+
+// sectionIndex szuka sekcji po tytule (bez wielkości liter); -1 gdy brak.
+func sectionIndex(title string) int {
+	for i, s := range sections {
+		if strings.EqualFold(s.Title, title) {
+			return i
+		}
+	}
+	return -1
+}
+
+func loadPages(args []string) []DocSection {
+	var pages []DocSection
+	for _, f := range pageArgs(args) {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, T("page.error"), f, err)
+			continue
+		}
+		title, content, _ := strings.Cut(string(data), "\n")
+		pages = append(pages, DocSection{Title: title, Category: T("page.category"), Content: content})
+	}
+	return pages
+}
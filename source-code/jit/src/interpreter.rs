@@ -389,8 +389,8 @@ impl<'a> BytecodeInterpreter<'a> {
             // ── For-in ────────────────────────────────────────────────────────
             Instruction::ForInStart { iter_reg, src } => {
                 let src_str = self.state.get_reg(src).to_str_val(&self.state.interner);
-                // Intern każde słowo — szybsze porównania w pętli
-                let words: Vec<u32> = src_str.split_whitespace()
+                // Intern każde słowo — szybsze porównania w pętli; zakresy A..B rozwinięte
+                let words: Vec<u32> = hl_parser::ast::for_in_items(&src_str).map_err(anyhow::Error::msg)?.iter()
                 .map(|w| self.state.interner.intern(w))
                 .collect();
                 self.state.iters.insert(iter_reg, (words, 0));
@@ -1,6 +1,5 @@
 use anyhow::Result;
-use colored::Colorize;
-use hl_compiler::{compile_to_cache, read_bc_file, HlModule};
+use hl_compiler::{compile_to_cache, read_bc_file, serialize::parse_bc_bytes, HlModule};
 use hl_core::{env::Env, Value};
 use crate::interpreter::BytecodeInterpreter;
 use std::path::Path;
@@ -21,12 +20,10 @@ pub fn run_hl_file(path: &Path, args: &[String]) -> Result<i32> {
     let ext = path.extension().and_then(|e| e.to_str()).unwrap_or("");
 
     match ext {
-        "bc" => {
-            let module = read_bc_file(path)?;
-            run_bc_module(&module, args)
-        }
+        "bc" => run_bc_file(path, args),
         _ => {
-            let source = std::fs::read_to_string(path)?;
+            // Tryb tylko-podpisane: wykonujemy dokładnie sprawdzony bufor
+            let source = hl_core::signing::read_trusted_source(path)?;
             run_hl_source(&source, path, args)
         }
     }
@@ -50,11 +47,13 @@ pub fn run_hl_source(source: &str, source_path: &Path, args: &[String]) -> Resul
     }
 
     // Mały plik — kompiluj do .bc z timeoutem
-    match compile_with_timeout(source, source_path, std::time::Duration::from_secs(30)) {
-        Ok(bc_path) => {
-            let module = read_bc_file(&bc_path)?;
-            run_bc_module(&module, args)
-        }
+    // Blokada współdzielona cache — hl cache clean/gc nie usunie .bc przed odczytem
+    let compiled = hl_core::lock_state_shared("cache").and_then(|_lock| {
+        let bc_path = compile_with_timeout(source, source_path, std::time::Duration::from_secs(30))?;
+        read_bc_file(&bc_path)
+    });
+    match compiled {
+        Ok(module) => run_bc_module(&module, args),
         Err(e) => {
             tracing::warn!("BC compile failed ({}), fallback do AST executor", e);
             run_via_ast(source, source_path, args)
@@ -122,7 +121,7 @@ fn compile_with_timeout(
 
 /// Uruchom plik .bc
 pub fn run_bc_file(path: &Path, args: &[String]) -> Result<i32> {
-    let module = read_bc_file(path)?;
+    let module = parse_bc_bytes(&hl_core::signing::read_trusted_bytes(path)?, path)?;
     run_bc_module(&module, args)
 }
 
@@ -141,39 +140,3 @@ fn inject_args_to_env(args: &[String]) {
         std::env::set_var(format!("arg{}", i), arg);
     }
 }
-
-/// Wypisz statystyki cache (dla `hl cache-info`)
-pub fn print_cache_stats() {
-    use hl_compiler::cache::{cache_dir, cache_list, CACHE_MAX_FILES};
-    let dir = cache_dir();
-    println!("{}", "=== Cache bytecode ===".bright_cyan().bold());
-    println!("  Katalog: {}", dir.display().to_string().bright_white());
-    println!("  Limit:   {} plików .bc", CACHE_MAX_FILES);
-    println!();
-
-    match cache_list() {
-        Ok(entries) => {
-            if entries.is_empty() {
-                println!("  {}", "Cache jest pusty.".bright_black());
-                return;
-            }
-            let total_size: u64 = entries.iter().map(|e| e.size).sum();
-            println!("  Pliki: {}", entries.len().to_string().bright_white());
-            println!("  Rozmiar łączny: {} KB",
-                     (total_size / 1024).to_string().bright_yellow());
-            println!();
-            for entry in entries.iter().take(10) {
-                let name = entry.path.file_name()
-                .and_then(|n| n.to_str())
-                .unwrap_or("?");
-                println!("  {} ({} B)", name.bright_white(), entry.size);
-            }
-            if entries.len() > 10 {
-                println!("  {} ... i {} więcej",
-                         "".bright_black(),
-                         entries.len() - 10);
-            }
-        }
-        Err(e) => eprintln!("  Błąd odczytu cache: {}", e),
-    }
-}
@@ -168,8 +168,20 @@ pub enum Node {
 
     Command     { raw: String, mode: CommandMode, interpolate: bool },
     HshCommand  { raw: String },
-    Background  { raw: String },
+    /// &[nazwa] komenda — nazwa dla ::wait / ::status / ::kill (None — nazwą jest PID)
+    Background  { raw: String, name: Option<String> },
     RepeatN     { count: u64, body: Vec<Node> },
+    /// ~retry(3, 5s) > komenda — ponawia następny węzeł, dopóki kończy się błędem
+    Retry       { spec: RetrySpec, body: Vec<Node> },
+    /// ~check(komenda) ^> komenda — krok stanowy: `check` z kodem 0 znaczy, że
+    /// system jest już w docelowym stanie (krok pomijany, w --check-mode: bez zmian)
+    Check       { check: String, body: Vec<Node> },
+    /// ~once(nazwa, plik...) > komenda — pomijany, jeśli w .hl-state.json jest już
+    /// udane wykonanie z tym samym hashem (treść kroku po interpolacji + pliki)
+    Once        { name: String, inputs: Vec<String>, body: Vec<Node> },
+    /// ~undo(komenda) ^> komenda — po udanym kroku komenda cofająca trafia do
+    /// dziennika uruchomienia; `hl rollback` wykonuje je w odwrotnej kolejności
+    Undo        { undo: String, body: Vec<Node> },
     VarDecl     { name: String, typ: VarType, value: VarValue },
     Export      { name: String, value: ExportValue },
     VarRef      (String),
@@ -177,7 +189,8 @@ pub enum Node {
     ///   // curl              → name="curl", apt_package=None   (apt szuka "curl")
     ///   // ninja [ninja-build] → name="ninja", apt_package=Some("ninja-build")
     Dependency  { name: String, apt_package: Option<String> },
-    Import      { lib: String, detail: Option<String> },
+    /// # <bit/logging>::{rotate, init} — `only`: wybrane funkcje (pusty — cała biblioteka)
+    Import      { lib: String, detail: Option<String>, only: Vec<String> },
     FileImport  { path: String, detail: Option<String> },
 
     // <* katalog — import katalogu (gen 2)
@@ -187,7 +200,11 @@ pub enum Node {
 
     // Zwykła funkcja (gen 1+2): : nazwa def ... done
     FuncDef     { name: String, body: Vec<Node> },
-    FuncCall    { name: String },
+    // -- nazwa a "b c" @x -> @wynik
+    //   → argumenty jako @arg0.. i @argc w ciele funkcji, `return` → @wynik
+    FuncCall    { name: String, args: Vec<Vec<StringPart>>, ret: Option<String> },
+    // return wartość — koniec funkcji z wartością dla `-- nazwa ... -> @zmienna`
+    Return      { value: Vec<StringPart> },
 
     // Arena function (gen 2): :: nazwa <rozmiar> def ... done
     //
@@ -199,11 +216,17 @@ pub enum Node {
     ArenaFuncCall { name: String, args: Vec<StringPart> },
 
     Conditional { condition: ConditionKind, body: Vec<Node> },
+    /// ? <wyrażenie> ... done — te same operatory co ?~ (==, !=, <, <=, >, >=)
+    IfExpr      { condition: Vec<StringPart>, body: Vec<Node> },
+    /// ?feature(gpu, !debug) ... done — sekcja warunkowa; `hl compile --features`
+    /// wycina nieaktywne z AST, interpreter wybiera je według HL_FEATURES
+    FeatureBlock { conditions: Vec<String>, body: Vec<Node> },
     ForIn       { var: String, iterable: Vec<StringPart>, body: Vec<Node> },
     WhileLoop   { condition: Vec<StringPart>, body: Vec<Node> },
     MatchExpr   { subject: Vec<StringPart>, arms: Vec<MatchArm> },
     Arithmetic  { expr: String, assign_to: Option<String> },
-    PipeToVar   { command: String, mode: CommandMode, var_name: String },
+    /// > komenda |> @var — `strict` (`|>! @var`): niezerowy kod wyjścia przerywa skrypt
+    PipeToVar   { command: String, mode: CommandMode, var_name: String, strict: bool },
     HackerOsApi { tool: HackerOsTool, args: Vec<StringPart> },
     Goroutine   { name: Option<String>, body: Vec<Node> },
     ChannelOp   { name: String, value: Option<Vec<StringPart>> },
@@ -241,6 +264,56 @@ pub enum CommandMode {
 #[derive(Debug, Clone, Serialize, Deserialize, PartialEq)]
 pub enum ConditionKind { Ok, Err }
 
+/// `~retry(próby, opóźnienie[, backoff])` — `backoff` podwaja opóźnienie po każdej próbie
+#[derive(Debug, Clone, Serialize, Deserialize, PartialEq)]
+pub struct RetrySpec {
+    pub attempts: u32,
+    pub delay_ms: u64,
+    pub backoff:  bool,
+}
+
+impl RetrySpec {
+    /// Limit prób — lowering do bytecode rozwija body raz na próbę
+    pub const MAX_ATTEMPTS: u32 = 100;
+
+    pub fn parse(spec: &str) -> Result<Self, String> {
+        let args: Vec<&str> = spec.split(',').map(str::trim).collect();
+        let attempts = match args[0].parse::<u32>() {
+            Ok(n) if (1..=Self::MAX_ATTEMPTS).contains(&n) => n,
+            Ok(n) if n > Self::MAX_ATTEMPTS => return Err(format!("najwyżej {} prób, dostano {}", Self::MAX_ATTEMPTS, n)),
+            _ => return Err(format!("liczba prób musi być dodatnia, dostano '{}'", args[0])),
+        };
+        let delay_ms = match args.get(1) {
+            None    => 0,
+            Some(d) => parse_delay_ms(d).ok_or_else(|| format!("nieprawidłowe opóźnienie '{}' (np. 500ms, 5s, 1m)", d))?,
+        };
+        let backoff = match args.get(2) {
+            None            => false,
+            Some(&"backoff") => true,
+            Some(other)     => return Err(format!("nieznana opcja '{}' — dozwolone: backoff", other)),
+        };
+        if args.len() > 3 { return Err("za dużo argumentów — ~retry(próby, opóźnienie, backoff)".into()); }
+        Ok(Self { attempts, delay_ms, backoff })
+    }
+
+    /// Opóźnienie przed próbą `attempt + 1` (numeracja od 1)
+    pub fn delay_after(&self, attempt: u32) -> u64 {
+        if self.backoff { self.delay_ms.saturating_mul(1u64 << (attempt - 1).min(16)) } else { self.delay_ms }
+    }
+}
+
+/// `500ms`, `5s`, `2m`, `1h`; sama liczba — sekundy
+pub fn parse_delay_ms(s: &str) -> Option<u64> {
+    let s = s.trim();
+    let (num, mult) = if let Some(n) = s.strip_suffix("ms") { (n, 1.0) }
+        else if let Some(n) = s.strip_suffix('s') { (n, 1000.0) }
+        else if let Some(n) = s.strip_suffix('m') { (n, 60_000.0) }
+        else if let Some(n) = s.strip_suffix('h') { (n, 3_600_000.0) }
+        else { (s, 1000.0) };
+    let n: f64 = num.trim().parse().ok()?;
+    (n >= 0.0 && n.is_finite()).then(|| (n * mult) as u64)
+}
+
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub enum StringPart {
     Literal(String),
@@ -271,6 +344,59 @@ impl Node {
     }
 }
 
+/// Najwięcej elementów zakresu `A..B` w pętli for-in
+pub const MAX_RANGE: i64 = 1_000_000;
+
+/// Zakres w pętli `@ i in 1..5` — liczby całkowite, oba końce włącznie, także malejąco.
+/// Ok(None), gdy słowo nie jest zakresem (wtedy jest zwykłym elementem listy);
+/// Err dla zakresu spoza i64 albo dłuższego niż MAX_RANGE.
+pub fn range_items(word: &str) -> Result<Option<Vec<String>>, String> {
+    let Some((a, b)) = word.split_once("..") else { return Ok(None) };
+    let int_like = |s: &str| {
+        let digits = s.strip_prefix(['-', '+']).unwrap_or(s);
+        !digits.is_empty() && digits.bytes().all(|c| c.is_ascii_digit())
+    };
+    if !int_like(a) || !int_like(b) { return Ok(None); }
+    let too_long = || format!("zakres {} ma więcej niż {} elementów", word, MAX_RANGE);
+    let (Ok(a), Ok(b)) = (a.parse::<i64>(), b.parse::<i64>()) else { return Err(too_long()) };
+    // checked_sub/checked_abs — skrajne końce (np. i64::MIN..i64::MAX) nie mieszczą się w i64
+    match b.checked_sub(a).and_then(i64::checked_abs) {
+        Some(span) if span < MAX_RANGE => {}
+        _ => return Err(too_long()),
+    }
+    Ok(Some(if a <= b { (a..=b).map(|n| n.to_string()).collect() }
+            else      { (b..=a).rev().map(|n| n.to_string()).collect() }))
+}
+
+/// Argumenty `-- nazwa a "b c" @x`: słowa oddzielone białymi znakami, "..." jako jeden argument
+pub fn split_call_args(src: &str) -> Vec<String> {
+    let (mut args, mut cur) = (Vec::new(), String::new());
+    let (mut quoted, mut started) = (false, false);
+    for c in src.chars() {
+        match c {
+            '"' => { quoted = !quoted; started = true; }
+            c if c.is_whitespace() && !quoted => {
+                if started { args.push(std::mem::take(&mut cur)); started = false; }
+            }
+            c => { cur.push(c); started = true; }
+        }
+    }
+    if started { args.push(cur); }
+    args
+}
+
+/// Elementy pętli for-in: słowa oddzielone białymi znakami, zakresy A..B rozwinięte
+pub fn for_in_items(src: &str) -> Result<Vec<String>, String> {
+    let mut items = Vec::new();
+    for w in src.split_whitespace() {
+        match range_items(w)? {
+            Some(range) => items.extend(range),
+            None        => items.push(w.to_string()),
+        }
+    }
+    Ok(items)
+}
+
 /// Parsuj string interpolowany ze zmiennymi (@var) i dynamicznymi referencjami (@{expr}).
 ///
 /// Obsługuje:
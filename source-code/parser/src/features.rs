@@ -0,0 +1,109 @@
+use crate::ast::Node;
+
+// ── Sekcje warunkowe ?feature(...) ────────────────────────────────────────────
+//
+//   /// Features: gpu, debug, arm
+//
+//   ?feature(gpu)
+//   ^> modprobe nvidia
+//   done
+//   ?feature(!gpu, debug)       wszystkie warunki naraz; `!` — cecha wyłączona
+//   ~> tryb CPU z logami
+//   done
+//
+// `hl compile --features gpu,debug` wycina nieaktywne sekcje z AST przed
+// generowaniem bytecode — .bc zawiera tylko wybrany wariant, bez śladu reszty.
+// `hl run --features …` ustawia HL_FEATURES, a interpreter wybiera sekcje
+// w trakcie wykonania. Deklaracja `/// Features:` w nagłówku jest listą
+// dozwolonych nazw — hl check i hl compile odrzucają nazwy spoza niej.
+
+pub const FEATURES_ENV: &str = "HL_FEATURES";
+
+fn valid_name(name: &str) -> bool {
+    !name.is_empty() && name.chars().all(|c| c.is_ascii_alphanumeric() || c == '_' || c == '-')
+}
+
+/// "gpu, debug" / "gpu,debug" → ["gpu", "debug"] (puste pozycje pomijane)
+pub fn parse_feature_list(s: &str) -> Vec<String> {
+    s.split(',').map(str::trim).filter(|f| !f.is_empty()).map(str::to_string).collect()
+}
+
+/// Warunki z `?feature(gpu, !debug)`; błąd — nieprawidłowa nazwa albo pusta lista
+pub fn parse_condition(spec: &str) -> Result<Vec<String>, String> {
+    let items = parse_feature_list(spec);
+    if items.is_empty() { return Err("pusta lista — oczekiwano ?feature(nazwa[, !nazwa...])".into()); }
+    match items.iter().find(|c| !valid_name(c.strip_prefix('!').unwrap_or(c))) {
+        Some(bad) => Err(format!("'{}' — nazwa cechy to litery, cyfry, `_` i `-`", bad)),
+        None      => Ok(items),
+    }
+}
+
+/// Nazwa cechy bez `!`
+pub fn feature_name(cond: &str) -> &str { cond.strip_prefix('!').unwrap_or(cond) }
+
+/// Czy sekcja z warunkami `conditions` jest aktywna przy włączonych `enabled`
+pub fn is_active(conditions: &[String], enabled: &[String]) -> bool {
+    conditions.iter().all(|c| match c.strip_prefix('!') {
+        Some(name) => !enabled.iter().any(|e| e == name),
+        None       => enabled.iter().any(|e| e == c),
+    })
+}
+
+/// Cechy włączone dla bieżącego procesu (HL_FEATURES)
+pub fn enabled_from_env() -> Vec<String> {
+    std::env::var(FEATURES_ENV).map(|v| parse_feature_list(&v)).unwrap_or_default()
+}
+
+/// `/// Features: a, b` z nagłówka: (linia, nazwy); None — skrypt nie deklaruje cech
+pub fn declared_features(source: &str) -> Option<(usize, Vec<String>)> {
+    for (i, line) in source.lines().enumerate().take(30) {
+        let t = line.trim();
+        if t.is_empty() || t.starts_with("#!") || t.starts_with("using") || t.starts_with(";;") { continue; }
+        let doc = t.strip_prefix("///")?;
+        let Some((k, v)) = doc.split_once(':') else { continue };
+        if matches!(k.trim().to_ascii_lowercase().as_str(), "features" | "cechy") {
+            return Some((i + 1, parse_feature_list(v)));
+        }
+    }
+    None
+}
+
+fn body_mut(node: &mut Node) -> Option<&mut Vec<Node>> {
+    match node {
+        Node::FuncDef      { body, .. }
+        | Node::ArenaFuncDef { body, .. }
+        | Node::RepeatN      { body, .. }
+        | Node::Retry        { body, .. }
+        | Node::Check        { body, .. }
+        | Node::Once         { body, .. }
+        | Node::Undo         { body, .. }
+        | Node::Conditional  { body, .. }
+        | Node::IfExpr       { body, .. }
+        | Node::ForIn        { body, .. }
+        | Node::WhileLoop    { body, .. }
+        | Node::Goroutine    { body, .. }
+        | Node::ExternDef    { body, .. }
+        | Node::FeatureBlock { body, .. }
+        | Node::Block        (body)         => Some(body),
+        _ => None,
+    }
+}
+
+/// Wytnij nieaktywne sekcje `?feature`, a ciała aktywnych wstaw w ich miejsce
+pub fn apply_features(nodes: Vec<Node>, enabled: &[String]) -> Vec<Node> {
+    let mut out = Vec::with_capacity(nodes.len());
+    for mut node in nodes {
+        if let Node::FeatureBlock { conditions, body } = node {
+            if is_active(&conditions, enabled) { out.extend(apply_features(body, enabled)); }
+            continue;
+        }
+        if let Some(body) = body_mut(&mut node) {
+            *body = apply_features(std::mem::take(body), enabled);
+        }
+        if let Node::MatchExpr { arms, .. } = &mut node {
+            for arm in arms { arm.body = apply_features(std::mem::take(&mut arm.body), enabled); }
+        }
+        out.push(node);
+    }
+    out
+}
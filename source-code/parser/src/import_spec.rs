@@ -2,10 +2,28 @@
 pub struct ImportDecl {
     pub spec:   String,
     pub detail: Option<String>,
+    /// `::funkcja` / `::{a, b}` — tylko wybrane funkcje (pusty — cała biblioteka)
+    pub only:   Vec<String>,
 }
 
 pub fn parse_import_line(line: &str) -> Option<ImportDecl> {
-    let line = line.trim();
+    let (line, only) = split_selection(line.trim());
+    let mut decl = parse_spec_line(line)?;
+    decl.only = only;
+    Some(decl)
+}
+
+/// `spec::nazwa` / `spec::{a, b}` → (spec, nazwy); bez `::` — cała linia
+fn split_selection(line: &str) -> (&str, Vec<String>) {
+    let Some(pos) = line.rfind("::") else { return (line, Vec::new()) };
+    let sel = line[pos + 2..].trim();
+    let sel = sel.strip_prefix('{').and_then(|s| s.strip_suffix('}')).unwrap_or(sel);
+    let names: Vec<String> = sel.split(',').map(|n| n.trim().to_string()).collect();
+    let valid = names.iter().all(|n| !n.is_empty() && n.chars().all(|c| c.is_alphanumeric() || c == '_' || c == '-'));
+    if valid { (line[..pos].trim_end(), names) } else { (line, Vec::new()) }
+}
+
+fn parse_spec_line(line: &str) -> Option<ImportDecl> {
     if !line.starts_with('<') { return parse_legacy(line); }
     let close1 = line.find('>')?;
     let spec_raw = line[1..close1].trim().to_string();
@@ -24,7 +42,7 @@ pub fn parse_import_line(line: &str) -> Option<ImportDecl> {
     // Normalizuj stare przestrzenie nazw na nowe
     let spec = normalize_import_spec(&spec_raw);
 
-    Some(ImportDecl { spec, detail })
+    Some(ImportDecl { spec, detail, only: Vec::new() })
 }
 
 /// Mapuj stare nazwy przestrzeni na nowe
@@ -51,12 +69,12 @@ fn parse_legacy(line: &str) -> Option<ImportDecl> {
         let spec_raw = line[..arrow_pos].trim().to_string();
         let detail   = line[arrow_pos + 2..].trim().to_string();
         let spec = normalize_import_spec(&spec_raw);
-        return Some(ImportDecl { spec, detail: if detail.is_empty() { None } else { Some(detail) } });
+        return Some(ImportDecl { spec, detail: if detail.is_empty() { None } else { Some(detail) }, only: Vec::new() });
     }
     let lib = line.trim();
     if !lib.is_empty() {
         let spec = normalize_import_spec(lib);
-        Some(ImportDecl { spec, detail: None })
+        Some(ImportDecl { spec, detail: None, only: Vec::new() })
     } else {
         None
     }
@@ -121,4 +139,24 @@ mod tests {
         let d = parse_import_line("<main/progress-bar>").unwrap();
         assert_eq!(d.spec, "main/progress-bar");
     }
+
+    #[test]
+    fn test_url_import() {
+        let d = parse_import_line("<https://example.com/hl/net-utils.hl#sha256=abc123>::ping").unwrap();
+        assert_eq!(d.spec, "https://example.com/hl/net-utils.hl#sha256=abc123");
+        assert_eq!(d.only, vec!["ping"]);
+    }
+
+    #[test]
+    fn test_selective_import() {
+        let d = parse_import_line("<bit/logging>::rotate").unwrap();
+        assert_eq!(d.spec, "bit/logging");
+        assert_eq!(d.only, vec!["rotate"]);
+        let d = parse_import_line("logging::{rotate, init}").unwrap();
+        assert_eq!(d.spec, "logging");
+        assert_eq!(d.only, vec!["rotate", "init"]);
+        let d = parse_import_line("<bit/logging:1.2>").unwrap();
+        assert_eq!(d.spec, "bit/logging:1.2");
+        assert!(d.only.is_empty());
+    }
 }
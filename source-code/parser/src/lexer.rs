@@ -21,7 +21,8 @@ pub enum Token {
     CmdWithVars(String),
     Cmd(String),
     HshCmd(String),
-    Background(String),
+    /// & komenda / &[nazwa] komenda
+    Background { raw: String, name: Option<String> },
     CmdPipeToVar { cmd: String, mode: PipeCmdMode, var_name: String },
     HackerOsApi { tool: String, args: String },
     VarDecl { name: String, typ: String, value: String },
@@ -33,14 +34,20 @@ pub enum Token {
     /// // narzedzie [pakiet-apt]
     /// Pole 0: nazwa binarka (np. "ninja"), pole 1: apt package (np. Some("ninja-build"))
     Dependency(String, Option<String>),
-    Import { lib: String, detail: Option<String> },
+    Import { lib: String, detail: Option<String>, only: Vec<String> },
     FileImport { path: String, detail: Option<String> },
     // <* katalog — import katalogu (gen 2)
     DirImport  { path: String },
     FuncDef(String),
-    FuncCall(String),
+    FuncCall { name: String, args: String },
+    /// return wartość
+    Return(String),
     IfOk,
     IfErr,
+    /// ? @x == debug — warunek z wyrażeniem (porównanie, @zmienna, komenda shell)
+    IfExpr(String),
+    /// ?feature(gpu, !debug) — sekcja warunkowa (treść nawiasu)
+    Feature(String),
     WhileStart(String),
     SwitchStart(String),
     SwitchArm { pattern: String },
@@ -54,6 +61,14 @@ pub enum Token {
     // _> plik [runtime] — extern system
     ExternStart { file: String, runtime: String },
     RepeatN(u64),
+    /// ~retry(3, 5s) — treść nawiasu; dotyczy następnego węzła
+    Retry(String),
+    /// ~check(dpkg -s nginx) — komenda sprawdzająca dla następnego węzła
+    Check(String),
+    /// ~once(nazwa, plik...) — krok zapisywany w .hl-state.json
+    Once(String),
+    /// ~undo(rm -f /etc/app.conf) — komenda cofająca następny węzeł (hl rollback)
+    Undo(String),
     Comments(CommentKind, String),
     Ident(String),
     StringLit(String),
@@ -85,11 +100,13 @@ pub struct Lexer {
     pub line: usize,
     pub col:  usize,
     in_export_list: bool,
+    /// Linia początku każdego tokenu (tokenize_with_lines)
+    token_lines: Vec<usize>,
 }
 
 impl Lexer {
     pub fn new(source: &str) -> Self {
-        Self { source: source.chars().collect(), pos: 0, line: 1, col: 1, in_export_list: false }
+        Self { source: source.chars().collect(), pos: 0, line: 1, col: 1, in_export_list: false, token_lines: Vec::new() }
     }
 
     #[inline] pub fn peek(&self) -> Option<char> { self.source.get(self.pos).copied() }
@@ -98,6 +115,23 @@ impl Lexer {
     self.source.get(self.pos..self.pos + seq.len()) == Some(seq)
     }
 
+    /// Treść nawiasu po `(` do pasującego `)` (zagnieżdżone nawiasy, do końca linii)
+    fn read_nested_parens(&mut self) -> String {
+        let mut text = String::new();
+        let mut depth = 1usize;
+        while let Some(c) = self.peek() {
+            if c == '\n' { break; }
+            self.advance();
+            match c {
+                '(' => depth += 1,
+                ')' => { depth -= 1; if depth == 0 { break; } }
+                _ => {}
+            }
+            text.push(c);
+        }
+        text.trim().to_string()
+    }
+
     #[inline]
     pub fn advance(&mut self) -> Option<char> {
         let ch = self.source.get(self.pos).copied();
@@ -251,10 +285,20 @@ impl Lexer {
         Some(size_str.trim().to_string())
     }
 
+    /// Tokeny z numerem linii, w której się zaczynają (hl run --explain)
+    pub fn tokenize_with_lines(&mut self) -> Result<Vec<(usize, Token)>, LexError> {
+        let tokens = self.tokenize()?;
+        Ok(std::mem::take(&mut self.token_lines).into_iter().zip(tokens).collect())
+    }
+
     pub fn tokenize(&mut self) -> Result<Vec<Token>, LexError> {
         let mut tokens = Vec::with_capacity(self.source.len() / 8 + 16);
+        let mut token_line = self.line;
 
         while self.pos < self.source.len() {
+            // Tokeny z poprzedniego kroku zaczęły się w linii `token_line`
+            self.token_lines.resize(tokens.len(), token_line);
+            token_line = self.line;
             let ch = match self.peek() { None => break, Some(c) => c };
 
             // ── Export list mode ─────────────────────────────────────────────
@@ -295,6 +339,49 @@ impl Lexer {
                     tokens.push(Token::Print(self.read_line()));
                 }
 
+                // ── ~retry(3, 5s) > komenda ──────────────────────────────────
+                '~' if self.matches_seq(&['~', 'r', 'e', 't', 'r', 'y']) => {
+                    self.skip_n(6); self.skip_ws();
+                    let mut spec = String::new();
+                    if self.peek() == Some('(') {
+                        self.advance();
+                        while let Some(c) = self.peek() {
+                            if c == '\n' { break; }
+                            self.advance();
+                            if c == ')' { break; }
+                            spec.push(c);
+                        }
+                    }
+                    tokens.push(Token::Retry(spec.trim().to_string()));
+                }
+
+                // ── ~check(komenda) ^> komenda ───────────────────────────────
+                '~' if self.matches_seq(&['~', 'c', 'h', 'e', 'c', 'k', '(']) => {
+                    self.skip_n(7);
+                    let cmd = self.read_nested_parens();
+                    tokens.push(Token::Check(cmd));
+                }
+
+                // ── ~undo(komenda cofająca) ^> komenda ───────────────────────
+                '~' if self.matches_seq(&['~', 'u', 'n', 'd', 'o', '(']) => {
+                    self.skip_n(6);
+                    let cmd = self.read_nested_parens();
+                    tokens.push(Token::Undo(cmd));
+                }
+
+                // ── ~once(nazwa, pliki...) > komenda ─────────────────────────
+                '~' if self.matches_seq(&['~', 'o', 'n', 'c', 'e', '(']) => {
+                    self.skip_n(6);
+                    let mut spec = String::new();
+                    while let Some(c) = self.peek() {
+                        if c == '\n' { break; }
+                        self.advance();
+                        if c == ')' { break; }
+                        spec.push(c);
+                    }
+                    tokens.push(Token::Once(spec.trim().to_string()));
+                }
+
                 // ── $( expr ) arytmetyka ──────────────────────────────────────
                 '$' if self.peek_at(1) == Some('(') => {
                     self.skip_n(2);
@@ -492,8 +579,13 @@ impl Lexer {
                 // ── -- func call ──────────────────────────────────────────────
                 '-' if self.matches_seq(&['-', '-']) => {
                     self.skip_n(2); self.skip_ws();
-                    tokens.push(Token::FuncCall(self.read_ident_full()));
-                    self.read_line();
+                    let mut name = self.read_ident_full();
+                    // -- biblioteka::funkcja
+                    if self.matches_seq(&[':', ':']) {
+                        self.skip_n(2);
+                        name = format!("{}::{}", name, self.read_ident_full());
+                    }
+                    tokens.push(Token::FuncCall { name, args: self.read_line() });
                 }
 
                 // ── => export ─────────────────────────────────────────────────
@@ -548,7 +640,17 @@ impl Lexer {
                     } else { tokens.push(Token::Cmd(line)); }
                 }
 
-                '&' => { self.advance(); self.skip_ws(); tokens.push(Token::Background(self.read_line())); }
+                '&' => {
+                    self.advance();
+                    let mut name = None;
+                    if self.peek() == Some('[') {
+                        self.advance();
+                        name = Some(self.read_ident_full());
+                        if self.peek() == Some(']') { self.advance(); }
+                    }
+                    self.skip_ws();
+                    tokens.push(Token::Background { raw: self.read_line(), name });
+                }
 
                 // ── _> extern ─────────────────────────────────────────────────
                 // _> plik.sh [shell] def ... done
@@ -681,7 +783,17 @@ impl Lexer {
                             "ok"     => { tokens.push(Token::IfOk);  self.read_line(); }
                             "err"    => { tokens.push(Token::IfErr); self.read_line(); }
                             "switch" => { self.skip_ws(); tokens.push(Token::SwitchStart(self.read_line())); }
-                            _        => tokens.push(Token::Ident(format!("?{}", kw))),
+                            "feature" if self.peek() == Some('(') => {
+                                self.advance();
+                                let spec = self.read_line();
+                                let spec = spec.trim_end();
+                                tokens.push(Token::Feature(spec.strip_suffix(')').unwrap_or(spec).to_string()));
+                            }
+                            _        => {
+                                let expr = format!("{}{}", kw, self.read_line());
+                                if expr.trim().is_empty() { tokens.push(Token::Ident("?".into())); }
+                                else { tokens.push(Token::IfExpr(expr)); }
+                            }
                         }
                     }
                 }
@@ -695,9 +807,9 @@ impl Lexer {
                     self.skip_ws();
                     let rest = self.read_line();
                     if let Some(decl) = parse_import_line(&rest) {
-                        tokens.push(Token::Import { lib: decl.spec, detail: decl.detail });
+                        tokens.push(Token::Import { lib: decl.spec, detail: decl.detail, only: decl.only });
                     } else {
-                        tokens.push(Token::Import { lib: rest, detail: None });
+                        tokens.push(Token::Import { lib: rest, detail: None, only: Vec::new() });
                     }
                 }
 
@@ -709,6 +821,7 @@ impl Lexer {
                     let id = self.read_ident_full();
                     match id.as_str() {
                         "done"  => { tokens.push(Token::Done); self.read_line(); }
+                        "return" => { self.skip_ws(); tokens.push(Token::Return(self.read_line())); }
                         "using" => { self.skip_ws(); let rest = self.read_line(); tokens.push(Token::Using(format!("using {}", rest))); }
                         "true"  => tokens.push(Token::Bool(true)),
                         "false" => tokens.push(Token::Bool(false)),
@@ -748,7 +861,9 @@ impl Lexer {
             }
         }
 
+        self.token_lines.resize(tokens.len(), token_line);
         tokens.push(Token::Eof);
+        self.token_lines.push(self.line);
         Ok(tokens)
     }
 }
@@ -5,6 +5,7 @@ pub mod parser;
 pub mod shebang;
 pub mod import_spec;
 pub mod extern_spec;
+pub mod features;
 
 pub use ast::*;
 pub use gen::{Gen, GenError, GenFeature, extract_gen, parse_gen_declaration, HL_MAX_GEN, HL_DEFAULT_GEN};
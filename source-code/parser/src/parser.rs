@@ -18,6 +18,18 @@ pub enum ParseError {
     MissingDef,
     #[error("Brakujące ']' — lista eksportu nie jest zamknięta")]
     MissingExportListEnd,
+    #[error("Nieprawidłowe ~retry: {0}")]
+    InvalidRetry(String),
+    #[error("Pusty ~check() — podaj komendę, która kończy się kodem 0, gdy zmiana nie jest potrzebna")]
+    EmptyCheck,
+    #[error("Nieprawidłowe ~once: {0}")]
+    InvalidOnce(String),
+    #[error("Pusty ~undo() — podaj komendę cofającą krok")]
+    EmptyUndo,
+    #[error("Nieprawidłowa pętla for-in: {0}")]
+    InvalidRange(String),
+    #[error("Nieprawidłowe ?feature: {0}")]
+    InvalidFeature(String),
     #[error("Błąd deklaracji gena: {0}")]
     Gen(#[from] GenError),
 }
@@ -29,6 +41,62 @@ pub struct Parser {
     arena_funcs: std::collections::HashSet<String>,
 }
 
+/// Elementy literału listy/mapy rozdzielone przecinkami — z pominięciem
+/// przecinków w cudzysłowach i zagnieżdżonych [ ] / { }
+fn split_items(inner: &str) -> Vec<String> {
+    let (mut items, mut cur) = (Vec::new(), String::new());
+    let (mut depth, mut quoted) = (0i32, false);
+    for c in inner.chars() {
+        match c {
+            '"'                        => quoted = !quoted,
+            '[' | '{' if !quoted       => depth += 1,
+            ']' | '}' if !quoted       => depth -= 1,
+            ',' if !quoted && depth == 0 => { items.push(std::mem::take(&mut cur).trim().to_string()); continue; }
+            _ => {}
+        }
+        cur.push(c);
+    }
+    if !cur.trim().is_empty() { items.push(cur.trim().to_string()); }
+    items
+}
+
+/// Elementy literału `[a, "b c", @x]` — None, gdy zawartość nie jest czystą listą
+/// (`[a-z]`, `[ -f x ]`, `[]`); taka wartość zostaje zwykłym tekstem jak dawniej.
+/// Jeden element bez przecinka musi być w cudzysłowie, zmienną lub literałem —
+/// lista jednoelementowa ze słowa to `[web1,]`.
+fn list_items(inner: &str) -> Option<Vec<String>> {
+    let items = split_items(inner);
+    let lone_word = items.len() == 1 && !inner.contains(',') && !items[0].starts_with(['"', '@', '[', '{']);
+    if items.is_empty() || lone_word { return None; }
+    items.iter().all(|i| clean_item(i)).then_some(items)
+}
+
+/// Element listy/wartość mapy: "tekst", @zmienna, zagnieżdżony literał albo słowo bez spacji
+fn clean_item(item: &str) -> bool {
+    let wrapped = |open: char, close: char| item.len() >= 2 && item.starts_with(open) && item.ends_with(close);
+    (wrapped('"', '"') && item.matches('"').count() == 2)
+        || wrapped('[', ']') || wrapped('{', '}')
+        || (!item.is_empty() && !item.contains(|c: char| c.is_whitespace() || "[]{}\"".contains(c)))
+}
+
+/// `-- f a b -> @wynik`: argumenty i zmienna na wartość z `return`. Tylko `->` poza
+/// cudzysłowem, po którym do końca linii jest samo `@nazwa` — `-- log "a -> b"` to argument.
+fn split_return(args: &str) -> (&str, Option<String>) {
+    let mut quoted = false;
+    let mut split = None;
+    for (i, c) in args.char_indices() {
+        match c {
+            '"' => quoted = !quoted,
+            '-' if !quoted && args[i..].starts_with("->") => split = Some(i),
+            _ => {}
+        }
+    }
+    let Some(i) = split else { return (args, None) };
+    let name = args[i + 2..].trim().strip_prefix('@').unwrap_or("");
+    if name.is_empty() || !name.chars().all(|c| c.is_alphanumeric() || c == '_') { return (args, None); }
+    (&args[..i], Some(name.to_string()))
+}
+
 impl Parser {
     pub fn new(tokens: Vec<Token>) -> Self {
         Self {
@@ -62,6 +130,25 @@ impl Parser {
             "str" | "string" => { return VarValue::String(value.trim_matches('"').to_string()); }
             _ => {}
         }
+        // [a, "b c", @x] — lista; {klucz: wartość, ...} — mapa; inne [..]/{..} to tekst
+        if value.starts_with('[') && value.ends_with(']') && value.len() >= 2 {
+            if let Some(items) = list_items(&value[1..value.len()-1]) {
+                return VarValue::List(items.iter().map(|i| Self::parse_var_value(i, "")).collect());
+            }
+        }
+        if value.starts_with('{') && value.ends_with('}') && value.len() >= 2 {
+            let items = split_items(&value[1..value.len()-1]);
+            let entries: Option<Vec<(String, VarValue)>> = items.iter()
+                .map(|item| {
+                    let (k, v) = item.split_once(':')?;
+                    let k = k.trim().trim_matches('"');
+                    let valid = !k.is_empty() && k.chars().all(|c| c.is_alphanumeric() || c == '_' || c == '-')
+                        && clean_item(v.trim());
+                    valid.then(|| (k.to_string(), Self::parse_var_value(v, "")))
+                })
+                .collect();
+            if let Some(entries) = entries.filter(|e| !e.is_empty()) { return VarValue::Map(entries); }
+        }
         if value.starts_with('"') && value.ends_with('"') && value.len() >= 2 {
             let inner = &value[1..value.len()-1];
             let parts = parse_string_parts(inner);
@@ -78,7 +165,7 @@ impl Parser {
         if value == "true"  { return VarValue::Bool(true);  }
         if value == "false" { return VarValue::Bool(false); }
         let parts = parse_string_parts(value);
-        if parts.iter().any(|p| matches!(p, StringPart::Var(_))) {
+        if parts.iter().any(|p| matches!(p, StringPart::Var(_) | StringPart::DynVar(_))) {
             VarValue::Interpolated(parts)
         } else {
             VarValue::String(value.to_string())
@@ -140,7 +227,7 @@ impl Parser {
             }
 
             Token::Print(msg)             => { self.advance(); Ok(Some(Node::Print { parts: parse_string_parts(&msg) })) }
-            Token::Background(raw)        => { self.advance(); Ok(Some(Node::Background { raw })) }
+            Token::Background { raw, name } => { self.advance(); Ok(Some(Node::Background { raw, name })) }
             Token::HshCmd(raw)            => { self.advance(); Ok(Some(Node::HshCommand { raw })) }
 
             // ── :: operator ──────────────────────────────────────────────────
@@ -190,6 +277,43 @@ impl Parser {
                 Ok(Some(Node::RepeatN { count: n, body }))
             }
 
+            Token::Retry(spec) => {
+                self.advance();
+                let spec = RetrySpec::parse(&spec).map_err(ParseError::InvalidRetry)?;
+                self.skip_newlines();
+                let body = if let Some(node) = self.parse_node()? { vec![node] } else { vec![] };
+                Ok(Some(Node::Retry { spec, body }))
+            }
+
+            Token::Check(check) => {
+                self.advance();
+                if check.is_empty() { return Err(ParseError::EmptyCheck); }
+                self.skip_newlines();
+                let body = if let Some(node) = self.parse_node()? { vec![node] } else { vec![] };
+                Ok(Some(Node::Check { check, body }))
+            }
+
+            Token::Once(spec) => {
+                self.advance();
+                let mut items = spec.split(',').map(str::trim);
+                let name = items.next().unwrap_or("").to_string();
+                if name.is_empty() || name.contains(char::is_whitespace) {
+                    return Err(ParseError::InvalidOnce(format!("'{}' — oczekiwano ~once(nazwa[, plik...])", spec)));
+                }
+                let inputs: Vec<String> = items.filter(|s| !s.is_empty()).map(str::to_string).collect();
+                self.skip_newlines();
+                let body = if let Some(node) = self.parse_node()? { vec![node] } else { vec![] };
+                Ok(Some(Node::Once { name, inputs, body }))
+            }
+
+            Token::Undo(undo) => {
+                self.advance();
+                if undo.is_empty() { return Err(ParseError::EmptyUndo); }
+                self.skip_newlines();
+                let body = if let Some(node) = self.parse_node()? { vec![node] } else { vec![] };
+                Ok(Some(Node::Undo { undo, body }))
+            }
+
             Token::FileImport { path, detail } => { self.advance(); Ok(Some(Node::FileImport { path, detail })) }
 
             Token::DirImport { path } => { self.advance(); Ok(Some(Node::DirImport { path })) }
@@ -203,6 +327,10 @@ impl Parser {
 
             Token::ForIn { var, iterable } => {
                 self.advance();
+                // Zakresy bez zmiennych sprawdzane od razu; `1..@n` dopiero przy wykonaniu
+                for word in iterable.split_whitespace().filter(|w| !w.contains('@')) {
+                    range_items(word).map_err(ParseError::InvalidRange)?;
+                }
                 Ok(Some(Node::ForIn { var, iterable: parse_string_parts(&iterable), body: self.parse_block()? }))
             }
             Token::WhileStart(condition) => {
@@ -223,7 +351,12 @@ impl Parser {
                     PipeCmdMode::Sudo     => CommandMode::Sudo,
                     PipeCmdMode::WithVars => CommandMode::WithVars,
                 };
-                Ok(Some(Node::PipeToVar { command: cmd, mode: cmd_mode, var_name }))
+                // |>! @var — lexer zostawia `!` na początku nazwy zmiennej
+                let (strict, var_name) = match var_name.strip_prefix('!') {
+                    Some(v) => (true, v.trim().trim_start_matches('@').to_string()),
+                    None    => (false, var_name),
+                };
+                Ok(Some(Node::PipeToVar { command: cmd, mode: cmd_mode, var_name, strict }))
             }
 
             Token::HackerOsApi { tool, args } => {
@@ -260,16 +393,31 @@ impl Parser {
             Token::ExportListItem(_) | Token::ExportListEnd => { self.advance(); Ok(None) }
 
             Token::Dependency(name, apt_package) => { self.advance(); Ok(Some(Node::Dependency { name, apt_package })) }
-            Token::Import { lib, detail } => { self.advance(); Ok(Some(Node::Import { lib, detail })) }
+            Token::Import { lib, detail, only } => { self.advance(); Ok(Some(Node::Import { lib, detail, only })) }
 
             Token::FuncDef(name) => {
                 self.advance();
                 Ok(Some(Node::FuncDef { name, body: self.parse_block()? }))
             }
-            Token::FuncCall(name) => { self.advance(); Ok(Some(Node::FuncCall { name })) }
+            Token::FuncCall { name, args } => {
+                self.advance();
+                let (args, ret) = split_return(&args);
+                let args = split_call_args(args).iter().map(|a| parse_string_parts(a)).collect();
+                Ok(Some(Node::FuncCall { name, args, ret }))
+            }
+            Token::Return(value) => { self.advance(); Ok(Some(Node::Return { value: parse_string_parts(value.trim()) })) }
 
             Token::IfOk  => { self.advance(); Ok(Some(Node::Conditional { condition: ConditionKind::Ok,  body: self.parse_block()? })) }
             Token::IfErr => { self.advance(); Ok(Some(Node::Conditional { condition: ConditionKind::Err, body: self.parse_block()? })) }
+            Token::IfExpr(condition) => {
+                self.advance();
+                Ok(Some(Node::IfExpr { condition: parse_string_parts(&condition), body: self.parse_block()? }))
+            }
+            Token::Feature(spec) => {
+                self.advance();
+                let conditions = crate::features::parse_condition(&spec).map_err(ParseError::InvalidFeature)?;
+                Ok(Some(Node::FeatureBlock { conditions, body: self.parse_block()? }))
+            }
 
             Token::ExternStart { file, runtime: rt_str } => {
                 self.advance();
@@ -394,6 +542,131 @@ mod tests {
         assert!(parse_source(src).is_ok());
     }
 
+    #[test]
+    fn test_if_expr() {
+        let src = "% LOG_LEVEL = debug\n? @LOG_LEVEL == debug\n~> verbose\ndone";
+        let nodes = parse_source(src).unwrap();
+        assert!(nodes.iter().any(|n| matches!(n, Node::IfExpr { .. })));
+        let src = "? ok\n~> A\ndone\n? err\n~> B\ndone";
+        let nodes = parse_source(src).unwrap();
+        assert!(nodes.iter().all(|n| !matches!(n, Node::IfExpr { .. })));
+    }
+
+    #[test]
+    fn test_for_in_range() {
+        assert_eq!(for_in_items("1..3").unwrap(), vec!["1", "2", "3"]);
+        assert_eq!(for_in_items("3..1 x").unwrap(), vec!["3", "2", "1", "x"]);
+        assert_eq!(for_in_items("a..b 1.5").unwrap(), vec!["a..b", "1.5"]);
+        assert!(for_in_items("-9223372036854775808..9223372036854775807").is_err());
+        assert!(for_in_items("1..99999999999999999999").is_err());
+        assert!(for_in_items("1..5000000").is_err());
+        assert!(matches!(parse_source("@ n in 1..5000000\n~> @n\ndone"), Err(ParseError::InvalidRange(_))));
+        assert!(parse_source("@ n in 1..@COUNT\n~> @n\ndone").is_ok());
+    }
+
+    #[test]
+    fn test_list_and_map_literals() {
+        let nodes = parse_source("% hosts = [web1, \"db 2\", @extra]\n% cfg = {host: srv, port: 22}").unwrap();
+        assert!(matches!(&nodes[0], Node::VarDecl { value: VarValue::List(items), .. } if items.len() == 3));
+        assert!(matches!(&nodes[1], Node::VarDecl { value: VarValue::Map(e), .. } if e.len() == 2 && e[1].0 == "port"));
+        assert_eq!(split_items("a, [b, c], \"d, e\""), vec!["a", "[b, c]", "\"d, e\""]);
+        // Teksty w nawiasach, które nie są listą/mapą, zostają tekstem
+        let nodes = parse_source("% re = [a-z]\n% t = [ -f x ]\n% o = {}\n% e = []\n% one = [web1,]\n% s = {a b}").unwrap();
+        for (i, raw) in ["[a-z]", "[ -f x ]", "{}", "[]"].iter().enumerate() {
+            assert!(matches!(&nodes[i], Node::VarDecl { value: VarValue::String(v), .. } if v == raw), "{}", raw);
+        }
+        assert!(matches!(&nodes[4], Node::VarDecl { value: VarValue::List(items), .. } if items.len() == 1));
+        assert!(matches!(&nodes[5], Node::VarDecl { value: VarValue::String(_), .. }));
+    }
+
+    #[test]
+    fn test_namespaced_call() {
+        let nodes = parse_source("# <bit/logging>::rotate\n-- logging::rotate").unwrap();
+        assert!(matches!(&nodes[0], Node::Import { only, .. } if only == &vec!["rotate".to_string()]));
+        assert!(matches!(&nodes[1], Node::FuncCall { name, .. } if name == "logging::rotate"));
+    }
+
+    #[test]
+    fn test_func_args_and_return() {
+        let nodes = parse_source(": pair def\n    return @arg0 @arg1\ndone\n-- pair 2 \"3\" -> @p\n-- greet \"Jan Kowalski\"").unwrap();
+        assert!(matches!(&nodes[0], Node::FuncDef { body, .. } if matches!(body[0], Node::Return { .. })));
+        assert!(matches!(&nodes[1], Node::FuncCall { args, ret: Some(r), .. } if args.len() == 2 && r == "p"));
+        assert!(matches!(&nodes[2], Node::FuncCall { args, ret: None, .. }
+            if matches!(args.as_slice(), [a] if matches!(a.as_slice(), [StringPart::Literal(s)] if s == "Jan Kowalski"))));
+        assert_eq!(split_return("\"a -> b\""), ("\"a -> b\"", None));
+        assert_eq!(split_return("x -> @out"), ("x ", Some("out".to_string())));
+        assert_eq!(split_return("a -> @b c"), ("a -> @b c", None));
+    }
+
+    #[test]
+    fn test_named_background() {
+        let nodes = parse_source("&[dl] curl -O https://example.com/a.iso\n& make").unwrap();
+        assert!(matches!(&nodes[0], Node::Background { raw, name: Some(n) } if n == "dl" && raw.starts_with("curl")));
+        assert!(matches!(&nodes[1], Node::Background { name: None, .. }));
+    }
+
+    #[test]
+    fn test_retry() {
+        let nodes = parse_source("~retry(3, 5s) > curl -fsSLO https://example.com/a.iso\n~retry(4, 500ms, backoff) ^> apt-get update").unwrap();
+        assert!(matches!(&nodes[0], Node::Retry { spec, body }
+            if *spec == RetrySpec { attempts: 3, delay_ms: 5000, backoff: false } && matches!(body[..], [Node::Command { .. }])));
+        assert!(matches!(&nodes[1], Node::Retry { spec, .. } if spec.backoff && spec.delay_after(3) == 2000));
+        assert!(parse_source("~retry(0, 1s) > true").is_err());
+        assert!(parse_source("~retry(100, 1s) > true").is_ok());
+        assert!(parse_source("~retry(100000, 1s) > true").is_err());
+        assert!(parse_source("~retry(3, soon) > true").is_err());
+    }
+
+    #[test]
+    fn test_check() {
+        let nodes = parse_source("~check(test -f /etc/app.conf) ^> cp app.conf /etc/\n~check(systemctl is-active $(cat unit)) ^> systemctl start app").unwrap();
+        assert!(matches!(&nodes[0], Node::Check { check, body }
+            if check == "test -f /etc/app.conf" && matches!(body[..], [Node::Command { mode: CommandMode::Sudo, .. }])));
+        assert!(matches!(&nodes[1], Node::Check { check, .. } if check == "systemctl is-active $(cat unit)"));
+        assert!(parse_source("~check() > true").is_err());
+    }
+
+    #[test]
+    fn test_once() {
+        let nodes = parse_source("~once(kernel, config/kernel.cfg, patches.txt) ^> make install\n~once(deps) > npm ci").unwrap();
+        assert!(matches!(&nodes[0], Node::Once { name, inputs, body }
+            if name == "kernel" && inputs == &["config/kernel.cfg", "patches.txt"] && matches!(body[..], [Node::Command { .. }])));
+        assert!(matches!(&nodes[1], Node::Once { inputs, .. } if inputs.is_empty()));
+        assert!(parse_source("~once() > true").is_err());
+        assert!(parse_source("~once(dwa slowa) > true").is_err());
+    }
+
+    #[test]
+    fn test_undo() {
+        let nodes = parse_source("~undo(rm -f /etc/app.conf) ^> cp app.conf /etc/app.conf\n~once(svc) ~undo(systemctl disable --now app) ^> systemctl enable --now app").unwrap();
+        assert!(matches!(&nodes[0], Node::Undo { undo, body }
+            if undo == "rm -f /etc/app.conf" && matches!(body[..], [Node::Command { mode: CommandMode::Sudo, .. }])));
+        assert!(matches!(&nodes[1], Node::Once { body, .. } if matches!(body[..], [Node::Undo { .. }])));
+        assert!(parse_source("~undo() > true").is_err());
+    }
+
+    #[test]
+    fn test_strict_pipe_to_var() {
+        let nodes = parse_source("> curl -s ifconfig.me |>! @IP\n> hostname |> @host").unwrap();
+        assert!(matches!(&nodes[0], Node::PipeToVar { var_name, strict: true, .. } if var_name == "IP"));
+        assert!(matches!(&nodes[1], Node::PipeToVar { var_name, strict: false, .. } if var_name == "host"));
+    }
+
+    #[test]
+    fn test_feature_sections() {
+        use crate::features::apply_features;
+        let src = "?feature(gpu)\n> nvidia-smi\ndone\n?feature(!gpu, debug)\n~> cpu\ndone\n~> zawsze";
+        let nodes = parse_source(src).unwrap();
+        assert!(matches!(&nodes[0], Node::FeatureBlock { conditions, body } if conditions == &["gpu"] && body.len() == 1));
+        assert!(matches!(&nodes[1], Node::FeatureBlock { conditions, .. } if conditions == &["!gpu", "debug"]));
+        let gpu = apply_features(nodes.clone(), &["gpu".to_string()]);
+        assert!(matches!(gpu[..], [Node::Command { .. }, Node::Print { .. }]));
+        let debug = apply_features(nodes, &["debug".to_string()]);
+        assert!(matches!(debug[..], [Node::Print { .. }, Node::Print { .. }]));
+        assert!(parse_source("?feature()\n~> x\ndone").is_err());
+        assert!(parse_source("?feature(gpu rtx)\n~> x\ndone").is_err());
+    }
+
     #[test]
     fn test_switch() {
         let src = "? switch @x\n| a\n~> A\n| *\n~> other\ndone";
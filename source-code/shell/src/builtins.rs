@@ -88,5 +88,7 @@ fn print_help() {
   COMMENTS:  ;; linia  ///  doc  // blok \\
 
   BUILTINS:  cd, vars, funcs, help, clear, exit
+  POMIAR:    :time [kod]  :profile [kod]  -- czas wykonania / czas instrukcji
+  HISTORIA:  Ctrl+R szukaj; touch .hl_history = osobna historia projektu
 "#.bright_white());
 }
@@ -1,15 +1,16 @@
 pub mod builtins;
 pub mod completion;
 pub mod prompt;
+pub mod timing;
 
 use anyhow::Result;
 use colored::Colorize;
-use hl_core::diagnostics::{parse_error_to_diag, DiagRenderer, DiagSummary, lint_source, lint_gen};
 use hl_core::env::Env;
-use hl_core::{check_source, run_source};
+use hl_core::eval_source;
+use hl_core::config::load_config;
 use rustyline::error::ReadlineError;
 use rustyline::{CompletionType, Config, EditMode, Editor};
-use std::path::Path;
+use std::path::{Path, PathBuf};
 use tracing::{debug, warn};
 
 use builtins::{try_builtin, BuiltinResult};
@@ -18,13 +19,16 @@ use prompt::Prompt;
 
 const HISTORY_FILE: &str = ".hl_history";
 const HLRC_FILE:    &str = ".hlrc";
+const HISTORY_SIZE: usize = 5000;
 
 pub fn run_interactive(env: &mut Env) -> Result<()> {
+    hl_core::signing::enforce_signed_inline("hl repl")?;
     print_banner();
     run_editor_loop(env, "<repl>", true)
 }
 
 pub fn run_as_shell(config: Option<&Path>, env: &mut Env) -> Result<()> {
+    hl_core::signing::enforce_signed_inline("hl shell")?;
     let rc_path = config.map(|p| p.to_path_buf()).unwrap_or_else(|| {
         dirs::home_dir().unwrap_or_default().join(HLRC_FILE)
     });
@@ -40,9 +44,27 @@ pub fn run_as_shell(config: Option<&Path>, env: &mut Env) -> Result<()> {
     run_editor_loop(env, "<shell>", false)
 }
 
+/// Historia projektu: ./.hl_history, jeśli istnieje (utwórz pusty plik, by włączyć);
+/// w przeciwnym razie ~/.hl_history
+fn history_path() -> PathBuf {
+    let local = PathBuf::from(HISTORY_FILE);
+    if local.is_file() { return local; }
+    dirs::home_dir().unwrap_or_default().join(HISTORY_FILE)
+}
+
+/// Limit historii: [repl] history_size w config.hk (starsze wpisy są usuwane przy zapisie)
+fn history_size() -> usize {
+    load_config().get("repl", "history_size")
+        .and_then(|v| v.trim().parse().ok())
+        .unwrap_or(HISTORY_SIZE)
+}
+
 fn run_editor_loop(env: &mut Env, ctx: &str, show_hint: bool) -> Result<()> {
+    // Ctrl+R — wyszukiwanie wsteczne w historii (tryb Emacs rustyline)
     let config = Config::builder()
     .history_ignore_space(true)
+    .history_ignore_dups(true)?
+    .max_history_size(history_size())?
     .completion_type(CompletionType::List)
     .edit_mode(EditMode::Emacs)
     .build();
@@ -50,7 +72,7 @@ fn run_editor_loop(env: &mut Env, ctx: &str, show_hint: bool) -> Result<()> {
     let mut rl = Editor::with_config(config)?;
     rl.set_helper(Some(HlCompleter::new()));
 
-    let history_path = dirs::home_dir().unwrap_or_default().join(HISTORY_FILE);
+    let history_path = history_path();
     if history_path.exists() { let _ = rl.load_history(&history_path); }
 
     if show_hint {
@@ -112,66 +134,23 @@ pub fn execute_source(source: &str, filename: &str, env: &mut Env) {
     let trimmed = source.trim();
     if trimmed.is_empty() { return; }
 
+    if let Some(code) = timing::try_meta(trimmed, filename, env) { env.last_exit = code; return; }
+
     match try_builtin(trimmed, env) {
         BuiltinResult::Handled(code) => { env.last_exit = code; return; }
         BuiltinResult::NotBuiltin    => {}
     }
 
-    // Szybki linter - O(n) dzieki HashSet
-    let renderer = DiagRenderer::new(filename, source);
-    let mut lint_diags = lint_source(source);
-    lint_diags.extend(lint_gen(source));
-    if !lint_diags.is_empty() {
-        renderer.emit_all(&lint_diags);
-        let sum = DiagSummary::from_diags(&lint_diags);
-        if sum.has_errors() { sum.print(); env.last_exit = 2; return; }
-        sum.print();
-    }
-
-    if let Err(e) = check_source(source) {
-        renderer.emit(&parse_error_to_diag(&e));
-        env.last_exit = 2; return;
-    }
-
     debug!("exec: {}", trimmed);
-    match run_source(source, env) {
-        Ok(r)  => env.last_exit = r.exit_code,
-        Err(e) => {
-            renderer.emit(&hl_core::Diag::error(e.to_string()).with_note("blad runtime"));
-            env.last_exit = 1;
-        }
-    }
+    env.last_exit = timing::eval_with_mode(filename, source, env);
 }
 
-/// Kluczowa funkcja: run_file bez O(n^2) lintera
-/// Uzywa nowego lint_source z HashSet (O(n))
+/// Wykonaj plik przez wspólną ścieżkę lint → parse → exec (hl_core::eval_source);
+/// w trybie tylko-podpisane plik musi mieć podpis
 pub fn run_file(path: &Path, env: &mut Env) -> Result<i32> {
-    let source   = std::fs::read_to_string(path)?;
+    let source   = hl_core::signing::read_trusted_source(path)?;
     let filename = path.file_name().and_then(|n| n.to_str()).unwrap_or("<unknown>");
-    let renderer = DiagRenderer::new(filename, &source);
-
-    // O(n) linter - bez O(n^2) z oryginalnego kodu
-    let mut lint_diags = lint_source(&source);
-    lint_diags.extend(lint_gen(&source));
-    if !lint_diags.is_empty() {
-        renderer.emit_all(&lint_diags);
-        let sum = DiagSummary::from_diags(&lint_diags);
-        sum.print();
-        if sum.has_errors() { return Ok(2); }
-    }
-
-    if let Err(e) = check_source(&source) {
-        renderer.emit(&parse_error_to_diag(&e)); return Ok(2);
-    }
-
-    match run_source(&source, env) {
-        Ok(r)  => Ok(r.exit_code),
-        Err(e) => {
-            let d = hl_core::Diag::error(e.to_string())
-            .with_note(format!("blad runtime w '{}'", filename));
-            renderer.emit(&d); Ok(1)
-        }
-    }
+    Ok(eval_source(filename, &source, env))
 }
 
 fn is_block_start(line: &str) -> bool {
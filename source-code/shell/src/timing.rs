@@ -0,0 +1,130 @@
+use colored::Colorize;
+use hl_core::diagnostics::{lint_gen, lint_source, parse_error_to_diag, DiagRenderer, DiagSummary};
+use hl_core::env::Env;
+use hl_core::exit::{self, exit_code_for};
+use hl_core::{eval_source, exec_nodes_pub, parse_source, Node};
+use std::sync::atomic::{AtomicU8, Ordering};
+use std::time::{Duration, Instant};
+
+// ── :time / :profile ──────────────────────────────────────────────────────────
+//
+//   :time              przełącz pomiar czasu kolejnych wpisów (także bloków)
+//   :time <kod>        zmierz jednorazowo: lint, parse, całość
+//   :profile           przełącz profilowanie kolejnych wpisów
+//   :profile <kod>     czas każdej instrukcji najwyższego poziomu
+
+const MODE_OFF:     u8 = 0;
+const MODE_TIME:    u8 = 1;
+const MODE_PROFILE: u8 = 2;
+
+static MODE: AtomicU8 = AtomicU8::new(MODE_OFF);
+
+/// Obsłuż :time / :profile; None — to nie jest meta-komenda
+pub fn try_meta(line: &str, filename: &str, env: &mut Env) -> Option<i32> {
+    let (cmd, rest) = line.split_once(' ').map(|(c, r)| (c, r.trim())).unwrap_or((line, ""));
+    let mode = match cmd {
+        ":time"    => MODE_TIME,
+        ":profile" => MODE_PROFILE,
+        _          => return None,
+    };
+    if !rest.is_empty() {
+        return Some(if mode == MODE_TIME { timed(filename, rest, env) } else { profiled(filename, rest, env) });
+    }
+    let on = MODE.load(Ordering::Relaxed) != mode;
+    MODE.store(if on { mode } else { MODE_OFF }, Ordering::Relaxed);
+    println!("  {} {}", cmd.bright_cyan(), if on { "włączony".green() } else { "wyłączony".bright_black() });
+    Some(0)
+}
+
+/// Wykonaj źródło w bieżącym trybie (zwykły / :time / :profile)
+pub fn eval_with_mode(filename: &str, source: &str, env: &mut Env) -> i32 {
+    match MODE.load(Ordering::Relaxed) {
+        MODE_TIME    => timed(filename, source, env),
+        MODE_PROFILE => profiled(filename, source, env),
+        _            => eval_source(filename, source, env),
+    }
+}
+
+fn fmt_dur(d: Duration) -> String {
+    let us = d.as_micros();
+    if us < 1000 { format!("{} µs", us) } else if us < 1_000_000 { format!("{:.2} ms", us as f64 / 1000.0) }
+    else { format!("{:.3} s", d.as_secs_f64()) }
+}
+
+fn timed(filename: &str, source: &str, env: &mut Env) -> i32 {
+    let t = Instant::now();
+    let _ = lint_source(source).len() + lint_gen(source).len();
+    let lint = t.elapsed();
+    let t = Instant::now();
+    let _ = parse_source(source);
+    let parse = t.elapsed();
+
+    let t = Instant::now();
+    let code = eval_source(filename, source, env);
+    let total = t.elapsed();
+    eprintln!("  {} {}  {}", "⏱".bright_cyan(), fmt_dur(total).bright_white().bold(),
+              format!("(lint {}, parse {})", fmt_dur(lint), fmt_dur(parse)).bright_black());
+    code
+}
+
+/// Krótki opis instrukcji do tabeli :profile
+fn describe(node: &Node) -> String {
+    let text = match node {
+        Node::Command   { raw, .. }     => format!("> {}", raw),
+        Node::PipeToVar { command, var_name, .. } => format!("> {} |> @{}", command, var_name),
+        Node::FuncCall  { name, .. }    => format!("-- {}", name),
+        Node::FuncDef   { name, .. }    => format!(": {} def", name),
+        Node::QuickCall { name, .. }    => format!("::{}", name),
+        Node::Background { raw, .. }    => format!("& {}", raw),
+        Node::Arithmetic { expr, .. }   => format!("$( {} )", expr),
+        other => format!("{:?}", other).split(|c: char| c == ' ' || c == '{' || c == '(').next().unwrap_or("?").to_string(),
+    };
+    if text.chars().count() > 48 { format!("{}…", text.chars().take(47).collect::<String>()) } else { text }
+}
+
+fn profiled(filename: &str, source: &str, env: &mut Env) -> i32 {
+    let renderer = DiagRenderer::new(filename, source);
+    let t = Instant::now();
+    let mut diags = lint_source(source);
+    diags.extend(lint_gen(source));
+    let lint = t.elapsed();
+    if !diags.is_empty() {
+        renderer.emit_all(&diags);
+        let sum = DiagSummary::from_diags(&diags);
+        sum.print();
+        if sum.has_errors() { return exit::SOURCE; }
+    }
+
+    let t = Instant::now();
+    let nodes = match parse_source(source) {
+        Ok(n)  => n,
+        Err(e) => { renderer.emit(&parse_error_to_diag(&e)); return exit::SOURCE; }
+    };
+    let parse = t.elapsed();
+
+    let mut rows = Vec::with_capacity(nodes.len());
+    let mut code = 0;
+    for node in &nodes {
+        let t = Instant::now();
+        let res = exec_nodes_pub(std::slice::from_ref(node), env);
+        rows.push((describe(node), t.elapsed()));
+        match res {
+            Ok(r)  => code = r.exit_code,
+            Err(e) => {
+                renderer.emit(&hl_core::Diag::error(e.to_string()).with_note(format!("blad runtime w '{}'", filename)));
+                code = exit_code_for(&e, exit::EXECUTION);
+                break;
+            }
+        }
+    }
+
+    let exec: Duration = rows.iter().map(|(_, d)| *d).sum();
+    eprintln!("{}", "── :profile ──────────────────────────────────────".bright_cyan());
+    eprintln!("  {:>10}  {}", fmt_dur(lint), "lint".bright_black());
+    eprintln!("  {:>10}  {}", fmt_dur(parse), "parse".bright_black());
+    for (desc, d) in &rows {
+        eprintln!("  {:>10}  {}", fmt_dur(*d), desc);
+    }
+    eprintln!("  {}  {}", format!("{:>10}", fmt_dur(lint + parse + exec)).bright_white().bold(), "razem".bold());
+    code
+}